@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"homework/internal/config"
+	"homework/internal/usecase"
+	"time"
+)
+
+// runStaleAlerts - периодически поднимает тревоги по датчикам, которые молчат дольше правил stale
+func runStaleAlerts(ctx context.Context, uc *usecase.Alert, cfg config.Alerts) {
+	ticker := time.NewTicker(cfg.StaleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// ошибки уже записаны в лог внутри usecase
+			_, _ = uc.CheckStale(ctx)
+		}
+	}
+}
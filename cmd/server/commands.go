@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"homework/internal/config"
+	"homework/internal/usecase"
+	"time"
+)
+
+// runCommandExpiry - периодически помечает истёкшими команды, не подтверждённые в срок
+func runCommandExpiry(ctx context.Context, uc *usecase.Command, cfg config.Commands) {
+	ticker := time.NewTicker(cfg.ExpireInterval)
+	defer ticker.Stop()
+
+	for {
+		// ошибки уже записаны в лог внутри usecase
+		_, _ = uc.ExpireCommands(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
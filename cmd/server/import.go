@@ -0,0 +1,185 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"homework/internal/config"
+	"homework/internal/domain"
+	"homework/internal/importer"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+const importUsage = `usage: server import -kind sensors|events [-format csv|ndjson] [-id ID] [-config FILE] FILE
+
+Загружает датчики или события из файла CSV или NDJSON, FILE "-" - стандартный ввод.
+Файлы .gz распаковываются. Прерванная загрузка продолжается повторным запуском с тем же id,
+для файла по умолчанию id - хеш его содержимого.
+`
+
+// runImport - подкоманда import: загрузка файла в хранилище, настроенное как для сервера
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), importUsage)
+		fs.PrintDefaults()
+	}
+
+	var (
+		configFile = fs.String("config", "", "path to YAML config file")
+		kind       = fs.String("kind", "", "what the file contains: sensors or events")
+		formatName = fs.String("format", "", "file format: csv or ndjson, by default from the file extension")
+		id         = fs.String("id", "", "import ID used to resume an interrupted import")
+		batchSize  = fs.Int("batch-size", 1000, "rows saved in one transaction")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("import: exactly one file is required")
+	}
+	path := fs.Arg(0)
+
+	var configArgs []string
+	if *configFile != "" {
+		configArgs = []string{"-config", *configFile}
+	}
+	cfg, err := config.Load(configArgs, os.Getenv)
+	if err != nil {
+		return err
+	}
+	if cfg.Storage.Backend != config.StorageBackendPostgres {
+		return fmt.Errorf("import: storage backend %q is not persistent", cfg.Storage.Backend)
+	}
+
+	logger, err := logging.New(os.Stderr, logging.Config{Level: cfg.Log.Level, Format: cfg.Log.Format})
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(path, ".gz")
+	if *formatName == "" {
+		*formatName = filepath.Ext(name)
+	}
+	format, err := importer.ParseFormat(*formatName)
+	if err != nil {
+		return fmt.Errorf("import: %w %q", err, *formatName)
+	}
+
+	if *id == "" && path != "-" {
+		if *id, err = fileID(path); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ctx = logging.WithContext(ctx, logger)
+
+	repos, closeRepos, err := newRepositories(ctx, cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer closeRepos()
+
+	r, closeFile, err := openImportFile(path)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	src, err := importer.NewReader(r, format, domain.ImportKind(*kind))
+	if err != nil {
+		return err
+	}
+
+	sensorTypes, err := newSensorTypes(ctx, repos.sensorType, cfg.Sensors.Types)
+	if err != nil {
+		return err
+	}
+
+	uc := usecase.NewImport(repos.imports, repos.sensor,
+		usecase.WithImportBatchSize(*batchSize),
+		usecase.WithImportSensorTypes(sensorTypes),
+	)
+	imp, err := uc.Run(ctx, *id, domain.ImportKind(*kind), src)
+	if imp != nil {
+		printImport(os.Stdout, imp)
+	}
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	return nil
+}
+
+// fileID - id загрузки по содержимому файла, чтобы повторный запуск продолжал прерванную загрузку
+func fileID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+func openImportFile(path string) (io.Reader, func(), error) {
+	var (
+		r       io.Reader = os.Stdin
+		closers []io.Closer
+	)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = f
+		closers = append(closers, f)
+	}
+
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			for _, c := range closers {
+				_ = c.Close()
+			}
+			return nil, nil, err
+		}
+		r = gz
+		closers = append(closers, gz)
+	}
+
+	return r, func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			_ = closers[i].Close()
+		}
+	}, nil
+}
+
+func printImport(w io.Writer, imp *domain.Import) {
+	fmt.Fprintf(w, "import %s (%s): %s, rows processed %d, imported %d, failed %d\n",
+		imp.ID, imp.Kind, imp.Status, imp.RowsProcessed, imp.RowsImported, imp.RowsFailed)
+	for _, e := range imp.Errors {
+		fmt.Fprintf(w, "  row %d: %s\n", e.Row, e.Reason)
+	}
+	if hidden := imp.RowsFailed - int64(len(imp.Errors)); hidden > 0 {
+		fmt.Fprintf(w, "  ... and %d more rows\n", hidden)
+	}
+}
@@ -3,49 +3,331 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"homework/internal/config"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
 	"homework/internal/usecase"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	grpcGateway "homework/internal/gateways/grpc"
 	httpGateway "homework/internal/gateways/http"
+	udpGateway "homework/internal/gateways/udp"
+	alertInMemory "homework/internal/repository/alert/inmemory"
+	alertRepository "homework/internal/repository/alert/postgres"
+	commandInMemory "homework/internal/repository/command/inmemory"
+	commandRepository "homework/internal/repository/command/postgres"
+	eventInMemory "homework/internal/repository/event/inmemory"
 	eventRepository "homework/internal/repository/event/postgres"
+	importInMemory "homework/internal/repository/imports/inmemory"
+	importRepository "homework/internal/repository/imports/postgres"
+	outboxInMemory "homework/internal/repository/outbox/inmemory"
+	outboxRepository "homework/internal/repository/outbox/postgres"
+	sceneInMemory "homework/internal/repository/scene/inmemory"
+	sceneRepository "homework/internal/repository/scene/postgres"
+	scheduleInMemory "homework/internal/repository/schedule/inmemory"
+	scheduleRepository "homework/internal/repository/schedule/postgres"
+	sensorCache "homework/internal/repository/sensor/cache"
+	sensorInMemory "homework/internal/repository/sensor/inmemory"
 	sensorRepository "homework/internal/repository/sensor/postgres"
+	sensorTypeInMemory "homework/internal/repository/sensortype/inmemory"
+	sensorTypeRepository "homework/internal/repository/sensortype/postgres"
+	transactionInMemory "homework/internal/repository/transaction/inmemory"
+	transactionRepository "homework/internal/repository/transaction/postgres"
+	userInMemory "homework/internal/repository/user/inmemory"
 	userRepository "homework/internal/repository/user/postgres"
+	virtualSensorInMemory "homework/internal/repository/virtualsensor/inmemory"
+	virtualSensorRepository "homework/internal/repository/virtualsensor/postgres"
+	webhookInMemory "homework/internal/repository/webhook/inmemory"
+	webhookRepository "homework/internal/repository/webhook/postgres"
 )
 
+type repositories struct {
+	event       usecase.EventRepository
+	sensor      usecase.SensorRepository
+	user        usecase.UserRepository
+	sensorOwner usecase.SensorOwnerRepository
+	imports     usecase.ImportRepository
+	sensorType  usecase.SensorTypeRepository
+	command     usecase.CommandRepository
+	scene       usecase.SceneRepository
+	schedule    usecase.ScheduleRepository
+	alert       usecase.AlertRepository
+	webhook     usecase.WebhookRepository
+	outbox      usecase.OutboxRepository
+	uow         usecase.UnitOfWork
+	// virtualSensor - выражения виртуальных датчиков
+	virtualSensor usecase.VirtualSensorRepository
+	// leader - выбор ведущей реплики планировщика, nil - реплика одна
+	leader usecase.Leader
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("can't load config: %v", err)
+	}
+
+	logger, err := logging.New(os.Stdout, logging.Config{
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+	})
+	if err != nil {
+		log.Fatalf("can't create logger: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	if err := run(cfg, logger); err != nil {
+		logger.Error("server stopped with error", logging.Error(err))
+		os.Exit(1)
+	}
+}
+
+func run(cfg *config.Config, logger *slog.Logger) error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	config, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("error during tracing shutdown", logging.Error(err))
+		}
+	}()
+
+	repos, closeRepos, err := newRepositories(ctx, cfg.Storage)
 	if err != nil {
-		log.Fatalf("can't parse pgxpool config")
+		return err
 	}
+	defer closeRepos()
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	sensorTypes, err := newSensorTypes(ctx, repos.sensorType, cfg.Sensors.Types)
 	if err != nil {
-		log.Fatalf("can't create new pool")
+		return err
 	}
-	defer pool.Close()
 
-	er := eventRepository.NewEventRepository(pool)
-	sr := sensorRepository.NewSensorRepository(pool)
-	ur := userRepository.NewUserRepository(pool)
-	sor := userRepository.NewSensorOwnerRepository(pool)
+	sensor := usecase.NewSensor(repos.sensor,
+		usecase.WithOnlineWindow(cfg.Sensors.OnlineWindow),
+		usecase.WithSensorTypes(sensorTypes),
+	)
+	virtualSensor := usecase.NewVirtualSensor(repos.virtualSensor, sensor, repos.event)
 
 	useCases := httpGateway.UseCases{
-		Event:  usecase.NewEvent(er, sr),
-		Sensor: usecase.NewSensor(sr),
-		User:   usecase.NewUser(ur, sor, sr),
+		Event:       newEvent(repos, sensorTypes, virtualSensor, cfg.Outbox),
+		Sensor:      sensor,
+		User:        usecase.NewUser(repos.user, repos.sensorOwner, repos.sensor),
+		Import:      usecase.NewImport(repos.imports, repos.sensor, usecase.WithImportSensorTypes(sensorTypes)),
+		SensorTypes: sensorTypes,
+		Command: usecase.NewCommand(repos.command, repos.sensor,
+			usecase.WithCommandSensorTypes(sensorTypes),
+			usecase.WithCommandTTL(cfg.Commands.TTL),
+		),
+		VirtualSensor: virtualSensor,
+	}
+	useCases.Scene = usecase.NewScene(repos.scene, useCases.Sensor, usecase.WithSceneCommands(useCases.Command))
+	useCases.Scheduler = newScheduler(repos, useCases, cfg.Scheduler)
+	useCases.Alert = usecase.NewAlert(repos.alert, useCases.Sensor, useCases.User)
+	useCases.Event.AddListener(useCases.Command.ConfirmCommands)
+	useCases.Webhook = newWebhook(repos, useCases, sensorTypes, cfg.Webhooks)
+	useCases.Event.AddListener(useCases.Alert.CheckEvent)
+	if !cfg.Outbox.Enabled {
+		// с outbox доставки sensor.event ставит runOutboxRelay
+		useCases.Event.AddListener(useCases.Webhook.OnEvent)
+	}
+	useCases.Sensor.AddListener(useCases.Webhook.OnSensorChange)
+
+	if cfg.Retention.Events > 0 {
+		go runRetention(ctx, useCases.Event, cfg.Retention)
+	}
+	go runCommandExpiry(ctx, useCases.Command, cfg.Commands)
+	go runStaleAlerts(ctx, useCases.Alert, cfg.Alerts)
+	if cfg.Webhooks.Enabled {
+		go runWebhookDeliveries(ctx, useCases.Webhook, cfg.Webhooks)
+	}
+	if cfg.Outbox.Enabled {
+		go runOutboxRelay(ctx, newOutbox(repos, useCases.Webhook, cfg.Outbox), cfg.Outbox)
+	}
+	if cfg.Scheduler.Enabled {
+		go func() {
+			if err := useCases.Scheduler.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("scheduler stopped", logging.Error(err))
+			}
+		}()
 	}
 
-	r := httpGateway.NewServer(useCases)
+	if cfg.MQTT.BrokerURL != "" {
+		subscriber, err := newMQTTSubscriber(useCases.Event, cfg.MQTT)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := subscriber.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("mqtt subscriber stopped", logging.Error(err))
+			}
+		}()
+	}
+
+	if cfg.MQTTBroker.Address != "" {
+		broker, err := newMQTTBroker(useCases.Event, useCases.Sensor, useCases.Command, cfg)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := broker.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("mqtt broker stopped", logging.Error(err))
+			}
+		}()
+	}
+
+	if cfg.GRPC.Port != 0 {
+		grpcServer := grpcGateway.NewServer(grpcGateway.UseCases{
+			Event:  useCases.Event,
+			Sensor: useCases.Sensor,
+			User:   useCases.User,
+		},
+			grpcGateway.WithHost(cfg.Server.Host),
+			grpcGateway.WithPort(cfg.GRPC.Port),
+			grpcGateway.WithPollInterval(cfg.WebSocket.PollInterval),
+		)
+		go func() {
+			logger.Info("grpc server started", slog.Int("port", int(cfg.GRPC.Port)))
+			if err := grpcServer.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("grpc server stopped", logging.Error(err))
+			}
+		}()
+	}
+
+	if cfg.UDP.Address != "" {
+		listener := udpGateway.NewListener(useCases.Event, cfg.UDP.Address,
+			udpGateway.WithRateLimit(cfg.UDP.RateInterval, int(cfg.UDP.RateBurst)),
+		)
+		go func() {
+			if err := listener.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("udp listener stopped", logging.Error(err))
+			}
+		}()
+	}
+
+	r := httpGateway.NewServer(useCases,
+		httpGateway.WithHost(cfg.Server.Host),
+		httpGateway.WithPort(cfg.Server.Port),
+		httpGateway.WithMetricsPort(cfg.Metrics.Port),
+		httpGateway.WithWebSocketPollInterval(cfg.WebSocket.PollInterval),
+	)
+
+	logger.Info("server started", slog.String("host", cfg.Server.Host), slog.Int("port", int(cfg.Server.Port)))
 
 	if err := r.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Printf("error during server shutdown: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// newSensorTypes - реестр типов датчиков со встроенными типами и типами из конфигурации
+func newSensorTypes(ctx context.Context, repo usecase.SensorTypeRepository, types []config.SensorType) (*usecase.SensorTypes, error) {
+	registry := usecase.NewSensorTypes(repo)
+	if err := registry.SaveDefaultSensorTypes(ctx); err != nil {
+		return nil, fmt.Errorf("can't save default sensor types: %w", err)
+	}
+	for _, t := range types {
+		err := registry.SaveSensorType(ctx, &domain.SensorTypeSpec{
+			Name:           domain.SensorType(t.Name),
+			Description:    t.Description,
+			Payload:        domain.PayloadKind(t.Payload),
+			Min:            t.Min,
+			Max:            t.Max,
+			Unit:           t.Unit,
+			ReportInterval: t.ReportInterval,
+			Actuator:       t.Actuator,
+			Virtual:        t.Virtual,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't save sensor type %s: %w", t.Name, err)
+		}
 	}
+	return registry, nil
+}
+
+func newRepositories(ctx context.Context, cfg config.Storage) (*repositories, func(), error) {
+	if cfg.Backend == config.StorageBackendInMemory {
+		event := eventInMemory.NewEventRepository()
+		sensor := sensorInMemory.NewSensorRepository()
+		return &repositories{
+			event:         event,
+			sensor:        sensor,
+			user:          userInMemory.NewUserRepository(),
+			sensorOwner:   userInMemory.NewSensorOwnerRepository(),
+			imports:       importInMemory.NewImportRepository(sensor, event),
+			sensorType:    sensorTypeInMemory.NewSensorTypeRepository(),
+			command:       commandInMemory.NewCommandRepository(),
+			scene:         sceneInMemory.NewSceneRepository(),
+			schedule:      scheduleInMemory.NewScheduleRepository(),
+			alert:         alertInMemory.NewAlertRepository(),
+			webhook:       webhookInMemory.NewWebhookRepository(),
+			outbox:        outboxInMemory.NewOutboxRepository(),
+			uow:           transactionInMemory.NewUnitOfWork(),
+			virtualSensor: virtualSensorInMemory.NewVirtualSensorRepository(),
+		}, func() {}, nil
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sensor usecase.SensorRepository = sensorRepository.NewSensorRepository(pool)
+	if cfg.SensorCacheTTL > 0 {
+		sensor = sensorCache.NewSensorRepository(sensor, sensorCache.WithTTL(cfg.SensorCacheTTL))
+	}
+
+	return &repositories{
+		event:         eventRepository.NewEventRepository(pool),
+		sensor:        sensor,
+		user:          userRepository.NewUserRepository(pool),
+		sensorOwner:   userRepository.NewSensorOwnerRepository(pool),
+		imports:       importRepository.NewImportRepository(pool),
+		sensorType:    sensorTypeRepository.NewSensorTypeRepository(pool),
+		command:       commandRepository.NewCommandRepository(pool),
+		scene:         sceneRepository.NewSceneRepository(pool),
+		schedule:      scheduleRepository.NewScheduleRepository(pool),
+		alert:         alertRepository.NewAlertRepository(pool),
+		webhook:       webhookRepository.NewWebhookRepository(pool),
+		outbox:        outboxRepository.NewOutboxRepository(pool),
+		uow:           transactionRepository.NewUnitOfWork(pool),
+		virtualSensor: virtualSensorRepository.NewVirtualSensorRepository(pool),
+		leader:        scheduleRepository.NewLeader(pool),
+	}, pool.Close, nil
 }
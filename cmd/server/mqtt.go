@@ -0,0 +1,53 @@
+package main
+
+import (
+	"homework/internal/config"
+	"homework/internal/usecase"
+
+	mqttGateway "homework/internal/gateways/mqtt"
+)
+
+func newMQTTSubscriber(uc *usecase.Event, cfg config.MQTT) (*mqttGateway.Subscriber, error) {
+	pattern, err := mqttGateway.ParseTopicPattern(cfg.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	return mqttGateway.NewSubscriber(uc, cfg.BrokerURL,
+		mqttGateway.WithTopicPattern(pattern),
+		mqttGateway.WithQoS(cfg.QoS),
+		mqttGateway.WithClientID(cfg.ClientID),
+		mqttGateway.WithCredentials(cfg.Username, cfg.Password),
+	)
+}
+
+func newMQTTBroker(
+	events *usecase.Event,
+	sensors *usecase.Sensor,
+	commands *usecase.Command,
+	cfg *config.Config,
+) (*mqttGateway.Broker, error) {
+	var patterns [4]mqttGateway.TopicPattern
+	for i, topic := range []string{
+		cfg.MQTT.Topic,
+		cfg.MQTTBroker.StateTopic,
+		cfg.MQTTBroker.EventsTopic,
+		cfg.MQTTBroker.CommandsTopic,
+	} {
+		pattern, err := mqttGateway.ParseTopicPattern(topic)
+		if err != nil {
+			return nil, err
+		}
+		patterns[i] = pattern
+	}
+
+	return mqttGateway.NewBroker(events, sensors, cfg.MQTTBroker.Address,
+		mqttGateway.WithIngestTopic(patterns[0]),
+		mqttGateway.WithStateTopic(patterns[1]),
+		mqttGateway.WithEventsTopic(patterns[2]),
+		mqttGateway.WithCommandsTopic(patterns[3]),
+		mqttGateway.WithCommands(commands),
+		mqttGateway.WithSecret(cfg.MQTTBroker.Secret),
+		mqttGateway.WithReader(cfg.MQTTBroker.ReaderUsername, cfg.MQTTBroker.ReaderPassword),
+	)
+}
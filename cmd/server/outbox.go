@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"homework/internal/config"
+	"homework/internal/usecase"
+	"time"
+)
+
+// newEvent - приём событий в единице работы вместе с пересчётом виртуальных датчиков,
+// с записью в outbox, если он включён
+func newEvent(
+	repos *repositories, sensorTypes *usecase.SensorTypes, virtual *usecase.VirtualSensor, cfg config.Outbox,
+) *usecase.Event {
+	options := []func(*usecase.Event){
+		usecase.WithEventSensorTypes(sensorTypes),
+		usecase.WithEventUnitOfWork(repos.uow),
+		usecase.WithEventVirtualSensors(virtual),
+	}
+	if cfg.Enabled {
+		options = append(options, usecase.WithEventOutbox(repos.outbox))
+	}
+	return usecase.NewEvent(repos.event, repos.sensor, options...)
+}
+
+// newOutbox - публикация outbox в очередь доставок webhooks. Другой брокер подключается
+// своей реализацией usecase.Publisher.
+func newOutbox(repos *repositories, webhook *usecase.Webhook, cfg config.Outbox) *usecase.Outbox {
+	return usecase.NewOutbox(repos.outbox, repos.uow, usecase.PublisherFunc(webhook.PublishEvent),
+		usecase.WithOutboxBatchSize(cfg.BatchSize),
+	)
+}
+
+// runOutboxRelay - периодически публикует накопившиеся сообщения outbox
+func runOutboxRelay(ctx context.Context, uc *usecase.Outbox, cfg config.Outbox) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// ошибки уже записаны в лог внутри usecase
+			_, _ = uc.Relay(ctx)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"homework/internal/config"
+	"homework/internal/usecase"
+	"time"
+)
+
+// runRetention - периодически удаляет события старше cfg.Events
+func runRetention(ctx context.Context, uc *usecase.Event, cfg config.Retention) {
+	ticker := time.NewTicker(cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		// ошибки уже записаны в лог внутри usecase
+		_, _ = uc.DeleteExpiredEvents(ctx, cfg.Events)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
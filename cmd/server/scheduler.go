@@ -0,0 +1,25 @@
+package main
+
+import (
+	"homework/internal/config"
+	"homework/internal/usecase"
+	"net/http"
+
+	httpGateway "homework/internal/gateways/http"
+)
+
+// newScheduler - планировщик расписаний; при нескольких репликах запуски выполняет только ведущая
+func newScheduler(repos *repositories, useCases httpGateway.UseCases, cfg config.Scheduler) *usecase.Scheduler {
+	options := []func(*usecase.Scheduler){
+		usecase.WithSchedulerScenes(useCases.Scene),
+		usecase.WithSchedulerCommands(useCases.Command),
+		usecase.WithSchedulerHTTPClient(&http.Client{Timeout: cfg.HTTPTimeout}),
+		usecase.WithSchedulerInterval(cfg.Interval),
+		usecase.WithMissedRunGrace(cfg.MissedGrace),
+	}
+	if repos.leader != nil {
+		options = append(options, usecase.WithSchedulerLeader(repos.leader))
+	}
+
+	return usecase.NewScheduler(repos.schedule, useCases.Sensor, options...)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"homework/internal/config"
+	"homework/internal/usecase"
+	"time"
+
+	httpGateway "homework/internal/gateways/http"
+)
+
+// newWebhook - подписки на изменения датчиков с настройками доставки из конфигурации
+func newWebhook(
+	repos *repositories,
+	useCases httpGateway.UseCases,
+	sensorTypes *usecase.SensorTypes,
+	cfg config.Webhooks,
+) *usecase.Webhook {
+	return usecase.NewWebhook(repos.webhook, useCases.Sensor, useCases.User,
+		usecase.WithWebhookSensorTypes(sensorTypes),
+		usecase.WithWebhookHTTPClient(usecase.NewWebhookHTTPClient(cfg.Timeout, cfg.AllowPrivateTargets)),
+		usecase.WithWebhookRetries(cfg.MaxAttempts, cfg.MinBackoff, cfg.MaxBackoff),
+	)
+}
+
+// runWebhookDeliveries - периодически отправляет доставки, подошедшие к очередной попытке
+func runWebhookDeliveries(ctx context.Context, uc *usecase.Webhook, cfg config.Webhooks) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// ошибки уже записаны в лог внутри usecase
+			_, _ = uc.DeliverPending(ctx)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	configFileEnv  = "CONFIG_FILE"
+	configFileFlag = "config"
+)
+
+type binding struct {
+	env   string
+	flag  string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) bindings() []binding {
+	return []binding{
+		{"SERVER_HOST", "host", "HTTP server host", (*stringValue)(&c.Server.Host)},
+		{"SERVER_PORT", "port", "HTTP server port", (*uint16Value)(&c.Server.Port)},
+		{"METRICS_PORT", "metrics-port", "port for /metrics, 0 - serve on the HTTP server port", (*uint16Value)(&c.Metrics.Port)},
+		{"STORAGE_BACKEND", "storage", "storage backend: postgres or inmemory", (*stringValue)(&c.Storage.Backend)},
+		{"DATABASE_URL", "database-url", "postgres connection URL", (*stringValue)(&c.Storage.DatabaseURL)},
+		{"DATABASE_MAX_CONNS", "database-max-conns", "maximum size of the postgres pool", (*int32Value)(&c.Storage.MaxConns)},
+		{"DATABASE_MIN_CONNS", "database-min-conns", "minimum size of the postgres pool", (*int32Value)(&c.Storage.MinConns)},
+		{"SENSOR_CACHE_TTL", "sensor-cache-ttl", "how long sensors are cached in the process, 0 - no cache", (*durationValue)(&c.Storage.SensorCacheTTL)},
+		{"SENSOR_ONLINE_WINDOW", "sensor-online-window", "a sensor is online if its last event is not older than this", (*durationValue)(&c.Sensors.OnlineWindow)},
+		{"WEBSOCKET_POLL_INTERVAL", "websocket-poll-interval", "interval between websocket event polls", (*durationValue)(&c.WebSocket.PollInterval)},
+		{"RETENTION_EVENTS", "retention-events", "how long to keep events, 0 - forever", (*durationValue)(&c.Retention.Events)},
+		{"RETENTION_CHECK_INTERVAL", "retention-check-interval", "interval between removals of expired events", (*durationValue)(&c.Retention.CheckInterval)},
+		{"COMMANDS_TTL", "commands-ttl", "default time to live of an unconfirmed device command", (*durationValue)(&c.Commands.TTL)},
+		{"COMMANDS_EXPIRE_INTERVAL", "commands-expire-interval", "interval between expirations of unconfirmed device commands", (*durationValue)(&c.Commands.ExpireInterval)},
+		{"SCHEDULER_ENABLED", "scheduler-enabled", "run schedules in this instance", (*boolValue)(&c.Scheduler.Enabled)},
+		{"SCHEDULER_INTERVAL", "scheduler-interval", "interval between checks for due schedules", (*durationValue)(&c.Scheduler.Interval)},
+		{"SCHEDULER_MISSED_GRACE", "scheduler-missed-grace", "a schedule run delayed longer than this is missed", (*durationValue)(&c.Scheduler.MissedGrace)},
+		{"SCHEDULER_HTTP_TIMEOUT", "scheduler-http-timeout", "timeout of schedule http actions", (*durationValue)(&c.Scheduler.HTTPTimeout)},
+		{"ALERTS_STALE_CHECK_INTERVAL", "alerts-stale-check-interval", "interval between checks for silent sensors", (*durationValue)(&c.Alerts.StaleCheckInterval)},
+		{"WEBHOOKS_ENABLED", "webhooks-enabled", "send webhook deliveries from this instance", (*boolValue)(&c.Webhooks.Enabled)},
+		{"WEBHOOKS_ALLOW_PRIVATE_TARGETS", "webhooks-allow-private-targets", "allow webhook deliveries to loopback and private addresses", (*boolValue)(&c.Webhooks.AllowPrivateTargets)},
+		{"WEBHOOKS_INTERVAL", "webhooks-interval", "interval between checks for pending webhook deliveries", (*durationValue)(&c.Webhooks.Interval)},
+		{"WEBHOOKS_TIMEOUT", "webhooks-timeout", "timeout of a webhook delivery request", (*durationValue)(&c.Webhooks.Timeout)},
+		{"WEBHOOKS_MAX_ATTEMPTS", "webhooks-max-attempts", "attempts before a webhook delivery is dead-lettered", (*intValue)(&c.Webhooks.MaxAttempts)},
+		{"WEBHOOKS_MIN_BACKOFF", "webhooks-min-backoff", "pause after the first failed webhook delivery attempt", (*durationValue)(&c.Webhooks.MinBackoff)},
+		{"WEBHOOKS_MAX_BACKOFF", "webhooks-max-backoff", "maximum pause between webhook delivery attempts", (*durationValue)(&c.Webhooks.MaxBackoff)},
+		{"OUTBOX_ENABLED", "outbox-enabled", "write received events to the outbox and relay them from this instance", (*boolValue)(&c.Outbox.Enabled)},
+		{"OUTBOX_INTERVAL", "outbox-interval", "interval between outbox relay passes", (*durationValue)(&c.Outbox.Interval)},
+		{"OUTBOX_BATCH_SIZE", "outbox-batch-size", "outbox messages published in one transaction", (*intValue)(&c.Outbox.BatchSize)},
+		{"LOG_LEVEL", "log-level", "log level: debug, info, warn or error", (*stringValue)(&c.Log.Level)},
+		{"LOG_FORMAT", "log-format", "log format: json or text", (*stringValue)(&c.Log.Format)},
+		{"OTEL_TRACES_EXPORTER", "tracing-exporter", "trace exporter: none, stdout or otlp", (*stringValue)(&c.Tracing.Exporter)},
+		{"TRACING_ENDPOINT", "tracing-endpoint", "OTLP collector host:port", (*stringValue)(&c.Tracing.Endpoint)},
+		{"TRACING_INSECURE", "tracing-insecure", "send traces to the OTLP collector without TLS", (*boolValue)(&c.Tracing.Insecure)},
+		{"OTEL_SERVICE_NAME", "tracing-service-name", "service name in traces", (*stringValue)(&c.Tracing.ServiceName)},
+		{"MQTT_BROKER_URL", "mqtt-broker-url", "MQTT broker URL, empty - MQTT ingestion is disabled", (*stringValue)(&c.MQTT.BrokerURL)},
+		{"MQTT_TOPIC", "mqtt-topic", "MQTT topic pattern, {serial} - level with the sensor serial number", (*stringValue)(&c.MQTT.Topic)},
+		{"MQTT_QOS", "mqtt-qos", "MQTT subscription QoS: 0, 1 or 2", (*uint8Value)(&c.MQTT.QoS)},
+		{"MQTT_CLIENT_ID", "mqtt-client-id", "MQTT client ID", (*stringValue)(&c.MQTT.ClientID)},
+		{"MQTT_USERNAME", "mqtt-username", "MQTT username", (*stringValue)(&c.MQTT.Username)},
+		{"MQTT_PASSWORD", "mqtt-password", "MQTT password", (*stringValue)(&c.MQTT.Password)},
+		{"MQTT_BROKER_ADDRESS", "mqtt-broker-address", "embedded MQTT broker address, empty - the broker is disabled", (*stringValue)(&c.MQTTBroker.Address)},
+		{"MQTT_BROKER_STATE_TOPIC", "mqtt-broker-state-topic", "embedded MQTT broker topic pattern for retained sensor states", (*stringValue)(&c.MQTTBroker.StateTopic)},
+		{"MQTT_BROKER_EVENTS_TOPIC", "mqtt-broker-events-topic", "embedded MQTT broker topic pattern for received events", (*stringValue)(&c.MQTTBroker.EventsTopic)},
+		{"MQTT_BROKER_COMMANDS_TOPIC", "mqtt-broker-commands-topic", "embedded MQTT broker topic pattern for device commands", (*stringValue)(&c.MQTTBroker.CommandsTopic)},
+		{"MQTT_BROKER_SECRET", "mqtt-broker-secret", "embedded MQTT broker secret sensor passwords are derived from", (*stringValue)(&c.MQTTBroker.Secret)},
+		{"MQTT_BROKER_READER_USERNAME", "mqtt-broker-reader-username", "embedded MQTT broker read-only client username", (*stringValue)(&c.MQTTBroker.ReaderUsername)},
+		{"MQTT_BROKER_READER_PASSWORD", "mqtt-broker-reader-password", "embedded MQTT broker read-only client password", (*stringValue)(&c.MQTTBroker.ReaderPassword)},
+		{"GRPC_PORT", "grpc-port", "gRPC API port, 0 - gRPC server is disabled", (*uint16Value)(&c.GRPC.Port)},
+		{"UDP_ADDRESS", "udp-address", "UDP ingestion address, empty - UDP ingestion is disabled", (*stringValue)(&c.UDP.Address)},
+		{"UDP_RATE_INTERVAL", "udp-rate-interval", "one UDP datagram per source per interval, 0 - unlimited", (*durationValue)(&c.UDP.RateInterval)},
+		{"UDP_RATE_BURST", "udp-rate-burst", "UDP datagrams a source may send in a burst", (*int32Value)(&c.UDP.RateBurst)},
+	}
+}
+
+// parseFlags - разбирает флаги, не применяя их, чтобы они перекрыли файл и окружение
+func parseFlags(args []string, bindings []binding, output io.Writer) (map[string]string, string, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var path string
+	fs.StringVar(&path, configFileFlag, "", "path to the YAML config file")
+
+	values := make(map[string]string)
+	for _, b := range bindings {
+		_, isBool := b.value.(interface{ IsBoolFlag() bool })
+		fs.Var(&flagRecorder{name: b.flag, values: values, isBool: isBool}, b.flag, b.usage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	return values, path, nil
+}
+
+type flagRecorder struct {
+	name   string
+	values map[string]string
+	isBool bool
+}
+
+func (r *flagRecorder) Set(s string) error {
+	r.values[r.name] = s
+	return nil
+}
+
+func (r *flagRecorder) String() string {
+	return ""
+}
+
+func (r *flagRecorder) IsBoolFlag() bool {
+	return r.isBool
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+type uint16Value uint16
+
+func (v *uint16Value) Set(s string) error {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return err
+	}
+	*v = uint16Value(n)
+	return nil
+}
+
+func (v *uint16Value) String() string {
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+type uint8Value uint8
+
+func (v *uint8Value) Set(s string) error {
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return err
+	}
+	*v = uint8Value(n)
+	return nil
+}
+
+func (v *uint8Value) String() string {
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+func (v *boolValue) IsBoolFlag() bool {
+	return true
+}
+
+type int32Value int32
+
+func (v *int32Value) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return err
+	}
+	*v = int32Value(n)
+	return nil
+}
+
+func (v *int32Value) String() string {
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string {
+	return time.Duration(*v).String()
+}
@@ -0,0 +1,496 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StorageBackendPostgres = "postgres"
+	StorageBackendInMemory = "inmemory"
+
+	minMQTTBrokerSecret = 16
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+type Config struct {
+	Server     Server     `yaml:"server"`
+	Metrics    Metrics    `yaml:"metrics"`
+	Storage    Storage    `yaml:"storage"`
+	Sensors    Sensors    `yaml:"sensors"`
+	WebSocket  WebSocket  `yaml:"websocket"`
+	Retention  Retention  `yaml:"retention"`
+	Commands   Commands   `yaml:"commands"`
+	Scheduler  Scheduler  `yaml:"scheduler"`
+	Alerts     Alerts     `yaml:"alerts"`
+	Webhooks   Webhooks   `yaml:"webhooks"`
+	Outbox     Outbox     `yaml:"outbox"`
+	Log        Log        `yaml:"log"`
+	Tracing    Tracing    `yaml:"tracing"`
+	MQTT       MQTT       `yaml:"mqtt"`
+	MQTTBroker MQTTBroker `yaml:"mqtt_broker"`
+	GRPC       GRPC       `yaml:"grpc"`
+	UDP        UDP        `yaml:"udp"`
+}
+
+type Server struct {
+	Host string `yaml:"host"`
+	Port uint16 `yaml:"port"`
+}
+
+type Metrics struct {
+	// Port - порт для /metrics, если 0 - метрики отдаются на основном порту
+	Port uint16 `yaml:"port"`
+}
+
+type Storage struct {
+	// Backend - postgres или inmemory
+	Backend     string `yaml:"backend"`
+	DatabaseURL string `yaml:"database_url"`
+	MaxConns    int32  `yaml:"max_conns"`
+	MinConns    int32  `yaml:"min_conns"`
+	// SensorCacheTTL - сколько датчики хранятся в кэше процесса, если 0 - кэш выключен.
+	// Используется только с postgres.
+	SensorCacheTTL time.Duration `yaml:"sensor_cache_ttl"`
+}
+
+type Sensors struct {
+	// OnlineWindow - датчик считается онлайн, если его последнее событие не старше этого
+	OnlineWindow time.Duration `yaml:"online_window"`
+	// Types - типы датчиков, которые при запуске добавляются в реестр или заменяют описания в нём.
+	// Задаются только в YAML файле.
+	Types []SensorType `yaml:"types"`
+}
+
+// SensorType - описание типа датчика для реестра
+type SensorType struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Payload - binary, level или counter
+	Payload string `yaml:"payload"`
+	// Min, Max - допустимый диапазон payload, если не заданы - без ограничения
+	Min  *float64 `yaml:"min"`
+	Max  *float64 `yaml:"max"`
+	Unit string   `yaml:"unit"`
+	// ReportInterval - с каким периодом датчик присылает события
+	ReportInterval time.Duration `yaml:"report_interval"`
+	// Actuator - датчики этого типа принимают команды
+	Actuator bool `yaml:"actuator"`
+	// Virtual - события датчиков этого типа вычисляются по выражению
+	Virtual bool `yaml:"virtual"`
+}
+
+type WebSocket struct {
+	// PollInterval - период опроса новых событий датчика
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+type Retention struct {
+	// Events - сколько хранить события, если 0 - события не удаляются
+	Events time.Duration `yaml:"events"`
+	// CheckInterval - период удаления устаревших событий
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+type Commands struct {
+	// TTL - через сколько неподтверждённая команда истекает, если срок не задан в запросе
+	TTL time.Duration `yaml:"ttl"`
+	// ExpireInterval - период, с которым просроченные команды помечаются истёкшими
+	ExpireInterval time.Duration `yaml:"expire_interval"`
+}
+
+type Scheduler struct {
+	// Enabled - выполнять расписания в этом экземпляре сервиса; API расписаний доступен всегда
+	Enabled bool `yaml:"enabled"`
+	// Interval - период проверки расписаний и попыток стать ведущей репликой
+	Interval time.Duration `yaml:"interval"`
+	// MissedGrace - запуск, опоздавший больше чем на это время, считается пропущенным
+	MissedGrace time.Duration `yaml:"missed_grace"`
+	// HTTPTimeout - сколько ждать ответа на запрос действия http
+	HTTPTimeout time.Duration `yaml:"http_timeout"`
+}
+
+type Alerts struct {
+	// StaleCheckInterval - период проверки правил stale: не молчат ли датчики дольше допустимого
+	StaleCheckInterval time.Duration `yaml:"stale_check_interval"`
+}
+
+type Webhooks struct {
+	// Enabled - отправлять доставки из этого экземпляра сервиса; подписки и очередь работают всегда
+	Enabled bool `yaml:"enabled"`
+	// AllowPrivateTargets - разрешить доставки на loopback, частные и другие внутренние адреса
+	AllowPrivateTargets bool `yaml:"allow_private_targets"`
+	// Interval - период проверки очереди доставок
+	Interval time.Duration `yaml:"interval"`
+	// Timeout - сколько ждать ответа получателя
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts - после стольких неудачных попыток доставка становится dead
+	MaxAttempts int `yaml:"max_attempts"`
+	// MinBackoff, MaxBackoff - пауза после первой неудачи, которая удваивается с каждой следующей, и её предел
+	MinBackoff time.Duration `yaml:"min_backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+type Outbox struct {
+	// Enabled - записывать принятые события в outbox и ставить из него доставки вебхуков sensor.event
+	Enabled bool `yaml:"enabled"`
+	// Interval - период проверки outbox
+	Interval time.Duration `yaml:"interval"`
+	// BatchSize - сколько сообщений публикуется в одной транзакции
+	BatchSize int `yaml:"batch_size"`
+}
+
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type Tracing struct {
+	Exporter    string `yaml:"exporter"`
+	Endpoint    string `yaml:"endpoint"`
+	Insecure    bool   `yaml:"insecure"`
+	ServiceName string `yaml:"service_name"`
+}
+
+type MQTT struct {
+	// BrokerURL - адрес брокера (tcp://host:1883), если пусто - приём событий по MQTT выключен
+	BrokerURL string `yaml:"broker_url"`
+	// Topic - шаблон топиков датчиков, {serial} - уровень с серийным номером
+	Topic    string `yaml:"topic"`
+	QoS      uint8  `yaml:"qos"`
+	ClientID string `yaml:"client_id"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type MQTTBroker struct {
+	// Address - адрес встроенного MQTT брокера (:1883), если пусто - брокер не запускается.
+	// Датчики публикуют события в топики mqtt.topic.
+	Address string `yaml:"address"`
+	// StateTopic - шаблон топиков с retained текущим состоянием датчиков
+	StateTopic string `yaml:"state_topic"`
+	// EventsTopic - шаблон топиков, в которые публикуются принятые события
+	EventsTopic string `yaml:"events_topic"`
+	// CommandsTopic - шаблон топиков, из которых исполнительные устройства получают команды
+	CommandsTopic string `yaml:"commands_topic"`
+	// Secret - секрет, из которого выводятся пароли датчиков, не короче 16 символов
+	Secret string `yaml:"secret"`
+	// ReaderUsername, ReaderPassword - учётная запись клиента, читающего состояния и события.
+	// Если пусто - читают только датчики.
+	ReaderUsername string `yaml:"reader_username"`
+	ReaderPassword string `yaml:"reader_password"`
+}
+
+type GRPC struct {
+	// Port - порт gRPC API, если 0 - gRPC сервер не запускается. Хост общий с server.host.
+	Port uint16 `yaml:"port"`
+}
+
+type UDP struct {
+	// Address - адрес приёма событий в UDP датаграммах (:5684), если пусто - приём выключен
+	Address string `yaml:"address"`
+	// RateInterval - одна датаграмма от источника в этот период, если 0 - без ограничения
+	RateInterval time.Duration `yaml:"rate_interval"`
+	// RateBurst - сколько датаграмм подряд источник может прислать сверх RateInterval
+	RateBurst int32 `yaml:"rate_burst"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Host: "localhost",
+			Port: 8080,
+		},
+		Storage: Storage{
+			Backend:        StorageBackendPostgres,
+			MaxConns:       4,
+			MinConns:       0,
+			SensorCacheTTL: 5 * time.Second,
+		},
+		Sensors: Sensors{
+			OnlineWindow: 5 * time.Minute,
+		},
+		WebSocket: WebSocket{
+			PollInterval: 5 * time.Second,
+		},
+		Retention: Retention{
+			CheckInterval: time.Hour,
+		},
+		Commands: Commands{
+			TTL:            time.Minute,
+			ExpireInterval: 10 * time.Second,
+		},
+		Scheduler: Scheduler{
+			Enabled:     true,
+			Interval:    10 * time.Second,
+			MissedGrace: time.Minute,
+			HTTPTimeout: 10 * time.Second,
+		},
+		Alerts: Alerts{
+			StaleCheckInterval: time.Minute,
+		},
+		Webhooks: Webhooks{
+			Interval:    5 * time.Second,
+			Timeout:     10 * time.Second,
+			MaxAttempts: 10,
+			MinBackoff:  10 * time.Second,
+			MaxBackoff:  time.Hour,
+		},
+		Outbox: Outbox{
+			Enabled:   true,
+			Interval:  time.Second,
+			BatchSize: 100,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "smarthome",
+		},
+		MQTT: MQTT{
+			Topic:    "sensors/{serial}/events",
+			QoS:      1,
+			ClientID: "smarthome",
+		},
+		MQTTBroker: MQTTBroker{
+			StateTopic:    "out/sensors/{serial}/state",
+			EventsTopic:   "out/sensors/{serial}/events",
+			CommandsTopic: "in/devices/{serial}/commands",
+		},
+		UDP: UDP{
+			RateInterval: time.Second,
+			RateBurst:    10,
+		},
+	}
+}
+
+// Load - собирает конфигурацию. Приоритет источников по возрастанию:
+// значения по умолчанию, YAML файл, переменные окружения, флаги командной строки.
+// Путь к файлу задаётся флагом -config или переменной окружения CONFIG_FILE.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+	bindings := cfg.bindings()
+
+	flags, path, err := parseFlags(args, bindings, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		path = getenv(configFileEnv)
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, b := range bindings {
+		if v := getenv(b.env); v != "" {
+			if err := b.value.Set(v); err != nil {
+				return nil, fmt.Errorf("%w: env %s: %w", ErrInvalidConfig, b.env, err)
+			}
+		}
+	}
+
+	for _, b := range bindings {
+		if v, ok := flags[b.flag]; ok {
+			if err := b.value.Set(v); err != nil {
+				return nil, fmt.Errorf("%w: flag -%s: %w", ErrInvalidConfig, b.flag, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("%w: can't parse config file %s: %w", ErrInvalidConfig, path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port == 0 {
+		errs = append(errs, errors.New("server.port must be set"))
+	}
+	if c.Metrics.Port != 0 && c.Metrics.Port == c.Server.Port {
+		errs = append(errs, errors.New("metrics.port must differ from server.port"))
+	}
+	if c.GRPC.Port != 0 && (c.GRPC.Port == c.Server.Port || c.GRPC.Port == c.Metrics.Port) {
+		errs = append(errs, errors.New("grpc.port must differ from server.port and metrics.port"))
+	}
+
+	switch c.Storage.Backend {
+	case StorageBackendPostgres:
+		if c.Storage.DatabaseURL == "" {
+			errs = append(errs, errors.New("storage.database_url must be set for postgres backend"))
+		}
+	case StorageBackendInMemory:
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage.backend %q", c.Storage.Backend))
+	}
+
+	if c.Storage.MaxConns <= 0 {
+		errs = append(errs, errors.New("storage.max_conns must be positive"))
+	}
+	if c.Storage.MinConns < 0 || c.Storage.MinConns > c.Storage.MaxConns {
+		errs = append(errs, errors.New("storage.min_conns must be between 0 and storage.max_conns"))
+	}
+	if c.Storage.SensorCacheTTL < 0 {
+		errs = append(errs, errors.New("storage.sensor_cache_ttl must not be negative"))
+	}
+
+	if c.Sensors.OnlineWindow <= 0 {
+		errs = append(errs, errors.New("sensors.online_window must be positive"))
+	}
+
+	names := make(map[string]bool, len(c.Sensors.Types))
+	for i, t := range c.Sensors.Types {
+		switch {
+		case t.Name == "":
+			errs = append(errs, fmt.Errorf("sensors.types[%d].name must be set", i))
+		case names[t.Name]:
+			errs = append(errs, fmt.Errorf("sensors.types[%d]: duplicate type %q", i, t.Name))
+		}
+		names[t.Name] = true
+		switch t.Payload {
+		case "binary", "level", "counter":
+		default:
+			errs = append(errs, fmt.Errorf("sensors.types[%d].payload must be binary, level or counter", i))
+		}
+		if t.Min != nil && t.Max != nil && *t.Min > *t.Max {
+			errs = append(errs, fmt.Errorf("sensors.types[%d].min must not exceed max", i))
+		}
+		if t.ReportInterval < 0 {
+			errs = append(errs, fmt.Errorf("sensors.types[%d].report_interval must not be negative", i))
+		}
+	}
+
+	if c.WebSocket.PollInterval <= 0 {
+		errs = append(errs, errors.New("websocket.poll_interval must be positive"))
+	}
+
+	if c.Retention.Events < 0 {
+		errs = append(errs, errors.New("retention.events must not be negative"))
+	}
+	if c.Retention.Events > 0 && c.Retention.CheckInterval <= 0 {
+		errs = append(errs, errors.New("retention.check_interval must be positive"))
+	}
+
+	if c.Commands.TTL <= 0 || c.Commands.TTL > 24*time.Hour {
+		errs = append(errs, errors.New("commands.ttl must be positive and at most 24h"))
+	}
+	if c.Commands.ExpireInterval <= 0 {
+		errs = append(errs, errors.New("commands.expire_interval must be positive"))
+	}
+
+	if c.Scheduler.Interval <= 0 {
+		errs = append(errs, errors.New("scheduler.interval must be positive"))
+	}
+	if c.Scheduler.MissedGrace < c.Scheduler.Interval {
+		errs = append(errs, errors.New("scheduler.missed_grace must not be less than scheduler.interval"))
+	}
+	if c.Scheduler.HTTPTimeout <= 0 {
+		errs = append(errs, errors.New("scheduler.http_timeout must be positive"))
+	}
+
+	if c.Alerts.StaleCheckInterval <= 0 {
+		errs = append(errs, errors.New("alerts.stale_check_interval must be positive"))
+	}
+
+	if c.Webhooks.Interval <= 0 {
+		errs = append(errs, errors.New("webhooks.interval must be positive"))
+	}
+	if c.Webhooks.Timeout <= 0 || c.Webhooks.Timeout > time.Minute {
+		errs = append(errs, errors.New("webhooks.timeout must be positive and at most 1m"))
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhooks.max_attempts must be positive"))
+	}
+	if c.Webhooks.MinBackoff <= 0 {
+		errs = append(errs, errors.New("webhooks.min_backoff must be positive"))
+	}
+	if c.Webhooks.MaxBackoff < c.Webhooks.MinBackoff {
+		errs = append(errs, errors.New("webhooks.max_backoff must not be less than webhooks.min_backoff"))
+	}
+
+	if c.Outbox.Interval <= 0 {
+		errs = append(errs, errors.New("outbox.interval must be positive"))
+	}
+	if c.Outbox.BatchSize < 1 || c.Outbox.BatchSize > 1000 {
+		errs = append(errs, errors.New("outbox.batch_size must be between 1 and 1000"))
+	}
+
+	if c.MQTT.BrokerURL != "" || c.MQTTBroker.Address != "" {
+		if !strings.Contains(c.MQTT.Topic, "{serial}") {
+			errs = append(errs, errors.New("mqtt.topic must contain {serial}"))
+		}
+	}
+
+	if c.MQTT.BrokerURL != "" {
+		if c.MQTTBroker.Address != "" {
+			errs = append(errs, errors.New("mqtt.broker_url and mqtt_broker.address are mutually exclusive"))
+		}
+		if c.MQTT.QoS > 2 {
+			errs = append(errs, errors.New("mqtt.qos must be 0, 1 or 2"))
+		}
+		if c.MQTT.ClientID == "" {
+			errs = append(errs, errors.New("mqtt.client_id must be set"))
+		}
+	}
+
+	if c.MQTTBroker.Address != "" {
+		for name, topic := range map[string]string{
+			"mqtt_broker.state_topic":    c.MQTTBroker.StateTopic,
+			"mqtt_broker.events_topic":   c.MQTTBroker.EventsTopic,
+			"mqtt_broker.commands_topic": c.MQTTBroker.CommandsTopic,
+		} {
+			if !strings.Contains(topic, "{serial}") || strings.ContainsAny(topic, "+#") {
+				errs = append(errs, fmt.Errorf("%s must contain {serial} and no wildcards", name))
+			}
+		}
+		if len(c.MQTTBroker.Secret) < minMQTTBrokerSecret {
+			errs = append(errs, fmt.Errorf("mqtt_broker.secret must be at least %d characters", minMQTTBrokerSecret))
+		}
+		if c.MQTTBroker.ReaderUsername != "" && c.MQTTBroker.ReaderPassword == "" {
+			errs = append(errs, errors.New("mqtt_broker.reader_password must be set with mqtt_broker.reader_username"))
+		}
+	}
+
+	if c.UDP.Address != "" {
+		if c.UDP.RateInterval < 0 {
+			errs = append(errs, errors.New("udp.rate_interval must not be negative"))
+		}
+		if c.UDP.RateInterval > 0 && c.UDP.RateBurst <= 0 {
+			errs = append(errs, errors.New("udp.rate_burst must be positive"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envFrom(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("ok, defaults", func(t *testing.T) {
+		cfg, err := Load(nil, envFrom(map[string]string{
+			"DATABASE_URL": "postgres://localhost/db",
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "localhost", cfg.Server.Host)
+		assert.Equal(t, uint16(8080), cfg.Server.Port)
+		assert.Equal(t, StorageBackendPostgres, cfg.Storage.Backend)
+		assert.Equal(t, 5*time.Second, cfg.WebSocket.PollInterval)
+		assert.Equal(t, 5*time.Second, cfg.Storage.SensorCacheTTL)
+		assert.Equal(t, 5*time.Minute, cfg.Sensors.OnlineWindow)
+		assert.Equal(t, time.Minute, cfg.Commands.TTL)
+		assert.True(t, cfg.Scheduler.Enabled)
+		assert.Equal(t, time.Minute, cfg.Scheduler.MissedGrace)
+		assert.Equal(t, time.Minute, cfg.Alerts.StaleCheckInterval)
+		assert.False(t, cfg.Webhooks.Enabled)
+		assert.False(t, cfg.Webhooks.AllowPrivateTargets)
+		assert.Equal(t, 10, cfg.Webhooks.MaxAttempts)
+		assert.Equal(t, time.Hour, cfg.Webhooks.MaxBackoff)
+		assert.True(t, cfg.Outbox.Enabled)
+		assert.Equal(t, 100, cfg.Outbox.BatchSize)
+	})
+
+	t.Run("ok, file", func(t *testing.T) {
+		path := writeConfig(t, `
+server:
+  host: 0.0.0.0
+  port: 9000
+storage:
+  backend: inmemory
+websocket:
+  poll_interval: 2s
+retention:
+  events: 24h
+commands:
+  ttl: 30s
+`)
+
+		cfg, err := Load([]string{"-config", path}, envFrom(nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+		assert.Equal(t, uint16(9000), cfg.Server.Port)
+		assert.Equal(t, StorageBackendInMemory, cfg.Storage.Backend)
+		assert.Equal(t, 2*time.Second, cfg.WebSocket.PollInterval)
+		assert.Equal(t, 24*time.Hour, cfg.Retention.Events)
+		assert.Equal(t, 30*time.Second, cfg.Commands.TTL)
+	})
+
+	t.Run("ok, sensor types", func(t *testing.T) {
+		path := writeConfig(t, `
+storage:
+  backend: inmemory
+sensors:
+  types:
+    - name: temperature
+      description: Термометр
+      payload: level
+      min: -40
+      max: 125
+      unit: °C
+      report_interval: 1m
+    - name: door
+      payload: binary
+`)
+
+		cfg, err := Load([]string{"-config", path}, envFrom(nil))
+		require.NoError(t, err)
+
+		require.Len(t, cfg.Sensors.Types, 2)
+		temperature := cfg.Sensors.Types[0]
+		assert.Equal(t, "temperature", temperature.Name)
+		require.NotNil(t, temperature.Min)
+		assert.Equal(t, -40.0, *temperature.Min)
+		assert.Equal(t, time.Minute, temperature.ReportInterval)
+		assert.Nil(t, cfg.Sensors.Types[1].Max)
+	})
+
+	t.Run("err, sensor types validation", func(t *testing.T) {
+		path := writeConfig(t, `
+storage:
+  backend: inmemory
+sensors:
+  types:
+    - name: temperature
+      payload: float
+    - name: temperature
+      payload: level
+      min: 10
+      max: 1
+`)
+
+		_, err := Load([]string{"-config", path}, envFrom(nil))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "sensors.types[0].payload")
+		assert.ErrorContains(t, err, "sensors.types[1]: duplicate")
+		assert.ErrorContains(t, err, "sensors.types[1].min")
+	})
+
+	t.Run("ok, env overrides file and flags override env", func(t *testing.T) {
+		path := writeConfig(t, `
+server:
+  port: 9000
+storage:
+  backend: inmemory
+`)
+
+		cfg, err := Load([]string{"-port", "9002", "-tracing-insecure"}, envFrom(map[string]string{
+			"CONFIG_FILE":     path,
+			"SERVER_PORT":     "9001",
+			"SERVER_HOST":     "127.0.0.1",
+			"METRICS_PORT":    "9100",
+			"LOG_LEVEL":       "debug",
+			"STORAGE_BACKEND": "inmemory",
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, uint16(9002), cfg.Server.Port)
+		assert.Equal(t, "127.0.0.1", cfg.Server.Host)
+		assert.Equal(t, uint16(9100), cfg.Metrics.Port)
+		assert.Equal(t, "debug", cfg.Log.Level)
+		assert.True(t, cfg.Tracing.Insecure)
+	})
+
+	t.Run("err, invalid env value", func(t *testing.T) {
+		_, err := Load(nil, envFrom(map[string]string{
+			"STORAGE_BACKEND": "inmemory",
+			"SERVER_PORT":     "port",
+		}))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	t.Run("err, unknown flag", func(t *testing.T) {
+		_, err := Load([]string{"-unknown"}, envFrom(nil))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	t.Run("err, missing file", func(t *testing.T) {
+		_, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, envFrom(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("err, validation", func(t *testing.T) {
+		_, err := Load(nil, envFrom(map[string]string{
+			"STORAGE_BACKEND":         "mongo",
+			"WEBSOCKET_POLL_INTERVAL": "0s",
+			"SENSOR_CACHE_TTL":        "-1s",
+		}))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "storage.backend")
+		assert.ErrorContains(t, err, "storage.sensor_cache_ttl")
+		assert.ErrorContains(t, err, "websocket.poll_interval")
+	})
+	t.Run("err, mqtt validation", func(t *testing.T) {
+		_, err := Load([]string{"-mqtt-qos", "3"}, envFrom(map[string]string{
+			"STORAGE_BACKEND": "inmemory",
+			"MQTT_BROKER_URL": "tcp://localhost:1883",
+			"MQTT_TOPIC":      "sensors/events",
+		}))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "mqtt.topic")
+		assert.ErrorContains(t, err, "mqtt.qos")
+	})
+	t.Run("err, mqtt broker validation", func(t *testing.T) {
+		_, err := Load(nil, envFrom(map[string]string{
+			"STORAGE_BACKEND":             "inmemory",
+			"MQTT_BROKER_URL":             "tcp://localhost:1883",
+			"MQTT_BROKER_ADDRESS":         ":1883",
+			"MQTT_BROKER_STATE_TOPIC":     "out/+/{serial}",
+			"MQTT_BROKER_SECRET":          "short",
+			"MQTT_BROKER_READER_USERNAME": "reader",
+		}))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "mutually exclusive")
+		assert.ErrorContains(t, err, "mqtt_broker.state_topic")
+		assert.ErrorContains(t, err, "mqtt_broker.secret")
+		assert.ErrorContains(t, err, "mqtt_broker.reader_password")
+	})
+
+	t.Run("err, grpc port", func(t *testing.T) {
+		_, err := Load([]string{"-grpc-port", "8080"}, envFrom(map[string]string{
+			"STORAGE_BACKEND": "inmemory",
+		}))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "grpc.port")
+	})
+
+	t.Run("err, udp rate limit", func(t *testing.T) {
+		_, err := Load([]string{"-udp-address", ":5684", "-udp-rate-burst", "0"}, envFrom(map[string]string{
+			"STORAGE_BACKEND": "inmemory",
+		}))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "udp.rate_burst")
+	})
+}
@@ -0,0 +1,235 @@
+// Package cron - разбор cron выражений и вычисление времени следующего запуска.
+//
+// Выражение состоит из пяти полей: минута, час, день месяца, месяц, день недели.
+// Поле - это *, число, диапазон a-b или список через запятую, к * и диапазону можно
+// добавить шаг /n. Месяцы и дни недели можно задавать трёхбуквенными английскими именами
+// (JAN, MON), воскресенье - 0 или 7. Если заданы и день месяца, и день недели, подходит
+// день, совпавший с любым из них. Поддерживаются сокращения @yearly (@annually), @monthly,
+// @weekly, @daily (@midnight) и @hourly.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// maxSearch - дальше этого срока следующий запуск не ищется: выражение вроде 30 февраля не срабатывает никогда
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	weekdayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var fields = [5]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	{name: "day of week", min: 0, max: 7, names: weekdayNames},
+}
+
+// Schedule - разобранное cron выражение, биты масок - подходящие значения полей
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar, dowStar - поле задано как *, тогда оно не ограничивает день
+	domStar, dowStar bool
+}
+
+// Parse - разбирает cron выражение
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return Schedule{}, fmt.Errorf("%w: expected %d fields, got %d", ErrInvalidExpression, len(fields), len(parts))
+	}
+
+	var masks [5]uint64
+	for i, part := range parts {
+		mask, err := parseField(part, fields[i])
+		if err != nil {
+			return Schedule{}, err
+		}
+		masks[i] = mask
+	}
+
+	// воскресенье можно задать и как 7
+	if masks[4]&(1<<7) != 0 {
+		masks[4] = masks[4]&^(1<<7) | 1
+	}
+
+	return Schedule{
+		minute:  masks[0],
+		hour:    masks[1],
+		dom:     masks[2],
+		month:   masks[3],
+		dow:     masks[4],
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var mask uint64
+	for _, item := range strings.Split(s, ",") {
+		bits, err := parseItem(item, f)
+		if err != nil {
+			return 0, err
+		}
+		mask |= bits
+	}
+	return mask, nil
+}
+
+// parseItem - элемент списка: *, n, a-b, */n, a-b/n или a/n (от a до конца диапазона)
+func parseItem(item string, f field) (uint64, error) {
+	invalid := func() error {
+		return fmt.Errorf("%w: %s %q", ErrInvalidExpression, f.name, item)
+	}
+
+	rangePart, stepPart, hasStep := strings.Cut(item, "/")
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepPart)
+		if err != nil || n < 1 {
+			return 0, invalid()
+		}
+		step = n
+	}
+
+	low, high := f.min, f.max
+	switch {
+	case rangePart == "*":
+	case strings.Contains(rangePart, "-"):
+		from, to, _ := strings.Cut(rangePart, "-")
+		var err error
+		if low, err = parseValue(from, f); err != nil {
+			return 0, invalid()
+		}
+		if high, err = parseValue(to, f); err != nil {
+			return 0, invalid()
+		}
+		if low > high {
+			return 0, invalid()
+		}
+	default:
+		v, err := parseValue(rangePart, f)
+		if err != nil {
+			return 0, invalid()
+		}
+		low = v
+		if !hasStep {
+			high = v
+		}
+	}
+
+	var mask uint64
+	for v := low; v <= high; v += step {
+		mask |= 1 << v
+	}
+	return mask, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if v < f.min || v > f.max {
+		return 0, errors.New("out of range")
+	}
+	return v, nil
+}
+
+// Next - первое время запуска строго после t, во временной зоне t. Если в пределах
+// нескольких лет запусков нет, возвращается нулевое время.
+//
+// Запуски во время, пропущенное при переводе часов вперёд, в этот день не происходят.
+// При переводе часов назад повторяющийся час срабатывает один раз.
+func (s Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(maxSearch)
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = advance(t, t.Add(time.Minute))
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// advance - переход к next, но не назад ни по абсолютному времени, ни по часам: при переводе
+// часов назад повторяющийся час пропускается
+func advance(t, next time.Time) time.Time {
+	if !next.After(t) {
+		next = t.Add(time.Minute)
+	}
+	for !wallClock(next).After(wallClock(t)) {
+		next = next.Add(time.Minute)
+	}
+	return next
+}
+
+// wallClock - показания часов в зоне t без учёта смещения
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,115 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * *",
+		"*/15 9-17 * * MON-FRI",
+		"0 8 * * 1-5",
+		"5,35 */2 1,15 jan,jul *",
+		"0 0 * * 7",
+		"@hourly",
+		"@Daily",
+		"30 4/6 * * *",
+	} {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@sometimes",
+	} {
+		_, err := Parse(expr)
+		assert.ErrorIs(t, err, ErrInvalidExpression, expr)
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			from: time.Date(2024, 3, 1, 10, 0, 30, 0, time.UTC),
+			want: time.Date(2024, 3, 1, 10, 1, 0, 0, time.UTC),
+		},
+		{
+			name: "strictly after",
+			expr: "0 8 * * *",
+			from: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays in timezone",
+			expr: "0 8 * * MON-FRI",
+			from: time.Date(2024, 3, 1, 9, 0, 0, 0, moscow), // пятница
+			want: time.Date(2024, 3, 4, 8, 0, 0, 0, moscow),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 13 * 5",
+			from: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), // пятница
+			want: time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never",
+			expr: "0 0 30 2 *",
+			from: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+		{
+			name: "skipped hour",
+			expr: "30 2 * * *",
+			from: time.Date(2024, 3, 30, 12, 0, 0, 0, berlin),
+			want: time.Date(2024, 4, 1, 2, 30, 0, 0, berlin),
+		},
+		{
+			name: "repeated hour fires once",
+			expr: "30 2 * * *",
+			from: time.Date(2024, 10, 27, 2, 30, 0, 0, berlin),
+			want: time.Date(2024, 10, 28, 2, 30, 0, 0, berlin),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+
+			got := s.Next(tt.from)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
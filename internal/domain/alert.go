@@ -0,0 +1,111 @@
+package domain
+
+import "time"
+
+// AlertRuleKind - условие, при котором правило поднимает тревогу
+type AlertRuleKind string
+
+const (
+	// AlertRuleThreshold - значение канала события сравнивается с порогом
+	AlertRuleThreshold AlertRuleKind = "threshold"
+	// AlertRuleStale - от датчика давно не было событий
+	AlertRuleStale AlertRuleKind = "stale"
+)
+
+// AlertOperator - сравнение значения с порогом
+type AlertOperator string
+
+const (
+	AlertOperatorGT  AlertOperator = "gt"
+	AlertOperatorGTE AlertOperator = "gte"
+	AlertOperatorLT  AlertOperator = "lt"
+	AlertOperatorLTE AlertOperator = "lte"
+	AlertOperatorEQ  AlertOperator = "eq"
+	AlertOperatorNE  AlertOperator = "ne"
+)
+
+// Compare - выполняется ли условие value <оператор> threshold
+func (o AlertOperator) Compare(value, threshold float64) bool {
+	switch o {
+	case AlertOperatorGT:
+		return value > threshold
+	case AlertOperatorGTE:
+		return value >= threshold
+	case AlertOperatorLT:
+		return value < threshold
+	case AlertOperatorLTE:
+		return value <= threshold
+	case AlertOperatorEQ:
+		return value == threshold
+	case AlertOperatorNE:
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+// AlertRule - условие тревоги по датчику, например «протечка: значение канала value >= 1»
+type AlertRule struct {
+	ID       int64
+	SensorID int64
+	Name     string
+	Kind     AlertRuleKind
+	// Channel, Operator и Threshold - условие правила threshold. Значение канала DefaultChannel
+	// сравнивается после калибровки датчика.
+	Channel   string
+	Operator  AlertOperator
+	Threshold float64
+	// StaleAfter - сколько датчик может молчать, прежде чем правило stale поднимет тревогу
+	StaleAfter time.Duration
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// AlertStatus - состояние тревоги
+type AlertStatus string
+
+const (
+	// AlertOpen - тревога поднята и ждёт реакции
+	AlertOpen AlertStatus = "open"
+	// AlertAcknowledged - тревогу приняли в работу
+	AlertAcknowledged AlertStatus = "acknowledged"
+	// AlertResolved - тревога закрыта
+	AlertResolved AlertStatus = "resolved"
+)
+
+// Active - тревога ещё не закрыта
+func (s AlertStatus) Active() bool {
+	return s == AlertOpen || s == AlertAcknowledged
+}
+
+// Alert - тревога, поднятая правилом. Пока тревога не закрыта, повторные срабатывания правила
+// не создают новых тревог, а увеличивают Count.
+type Alert struct {
+	ID int64
+	// RuleID - правило, поднявшее тревогу, 0 - правило удалено
+	RuleID   int64
+	SensorID int64
+	Kind     AlertRuleKind
+	Status   AlertStatus
+	Message  string
+	// Value - значение, на котором сработало последнее срабатывание правила threshold
+	Value *float64
+	// Count - число срабатываний правила, пока тревога не закрыта
+	Count          int64
+	OpenedAt       time.Time
+	LastSeenAt     time.Time
+	AcknowledgedBy int64
+	AcknowledgedAt time.Time
+	ResolvedBy     int64
+	ResolvedAt     time.Time
+	// Revision - номер последнего изменения, растёт при каждом изменении любой тревоги
+	Revision int64
+}
+
+// AlertQuery - условия выборки тревог, пустые поля выборку не ограничивают
+type AlertQuery struct {
+	SensorIDs []int64
+	Statuses  []AlertStatus
+	// Limit - сколько последних тревог вернуть, 0 - без ограничения
+	Limit int
+}
@@ -0,0 +1,107 @@
+package domain
+
+import "sort"
+
+// CalibrationKind - вид преобразования сырого значения датчика
+type CalibrationKind string
+
+const (
+	// CalibrationLinear - value = scale * raw + offset
+	CalibrationLinear CalibrationKind = "linear"
+	// CalibrationPolynomial - value = c0 + c1 * raw + c2 * raw^2 + ...
+	CalibrationPolynomial CalibrationKind = "polynomial"
+	// CalibrationLookup - линейная интерполяция по таблице точек, за краями таблицы - крайние значения
+	CalibrationLookup CalibrationKind = "lookup"
+)
+
+// CalibrationPoint - точка таблицы калибровки
+type CalibrationPoint struct {
+	Raw   float64 `json:"raw"`
+	Value float64 `json:"value"`
+}
+
+// Calibration - преобразование сырого значения датчика в значение в единицах датчика
+type Calibration struct {
+	Kind         CalibrationKind    `json:"kind"`
+	Scale        float64            `json:"scale,omitempty"`
+	Offset       float64            `json:"offset,omitempty"`
+	Coefficients []float64          `json:"coefficients,omitempty"`
+	Points       []CalibrationPoint `json:"points,omitempty"`
+}
+
+// Clone - копия калибровки, не разделяющая с ней срезы. Nil калибровка возвращает nil.
+func (c *Calibration) Clone() *Calibration {
+	if c == nil {
+		return nil
+	}
+
+	out := *c
+	out.Coefficients = append([]float64(nil), c.Coefficients...)
+	out.Points = append([]CalibrationPoint(nil), c.Points...)
+	return &out
+}
+
+// Apply - значение, соответствующее сырому raw. Nil калибровка возвращает raw без изменений.
+func (c *Calibration) Apply(raw float64) float64 {
+	if c == nil {
+		return raw
+	}
+
+	switch c.Kind {
+	case CalibrationLinear:
+		return c.Scale*raw + c.Offset
+	case CalibrationPolynomial:
+		// схема Горнера
+		var v float64
+		for i := len(c.Coefficients) - 1; i >= 0; i-- {
+			v = v*raw + c.Coefficients[i]
+		}
+		return v
+	case CalibrationLookup:
+		return c.lookup(raw)
+	default:
+		return raw
+	}
+}
+
+// Affine - коэффициенты преобразования вида scale * raw + offset, false - если оно нелинейное
+func (c *Calibration) Affine() (scale, offset float64, ok bool) {
+	switch {
+	case c == nil:
+		return 1, 0, true
+	case c.Kind == CalibrationLinear:
+		return c.Scale, c.Offset, true
+	case c.Kind == CalibrationPolynomial && len(c.Coefficients) <= 2:
+		var coef [2]float64
+		copy(coef[:], c.Coefficients)
+		return coef[1], coef[0], true
+	default:
+		return 0, 0, false
+	}
+}
+
+func (c *Calibration) lookup(raw float64) float64 {
+	points := c.Points
+	if len(points) == 0 {
+		return raw
+	}
+
+	i := sort.Search(len(points), func(i int) bool { return points[i].Raw >= raw })
+	switch {
+	case i == 0:
+		return points[0].Value
+	case i == len(points):
+		return points[len(points)-1].Value
+	}
+
+	lo, hi := points[i-1], points[i]
+	return lo.Value + (raw-lo.Raw)*(hi.Value-lo.Value)/(hi.Raw-lo.Raw)
+}
+
+// Reading - событие датчика вместе со значением основного канала после калибровки
+// и перевода в единицы Unit
+type Reading struct {
+	Event Event
+	Value float64
+	Unit  string
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// CommandKind - действие, которое команда просит выполнить устройство
+type CommandKind string
+
+const (
+	// CommandSetState - перевести устройство в состояние State
+	CommandSetState CommandKind = "set_state"
+	// CommandToggle - переключить двухпозиционное устройство в противоположное состояние
+	CommandToggle CommandKind = "toggle"
+	// CommandPulse - перевести устройство в состояние State на время Duration и вернуть обратно
+	CommandPulse CommandKind = "pulse"
+)
+
+// CommandStatus - состояние доставки команды
+type CommandStatus string
+
+const (
+	// CommandPending - команда в очереди, устройство её ещё не забрало
+	CommandPending CommandStatus = "pending"
+	// CommandDelivered - устройство забрало команду, но ещё не сообщило новое состояние
+	CommandDelivered CommandStatus = "delivered"
+	// CommandConfirmed - устройство прислало событие с состоянием State
+	CommandConfirmed CommandStatus = "confirmed"
+	// CommandExpired - команда не подтверждена до ExpiresAt
+	CommandExpired CommandStatus = "expired"
+)
+
+// Command - команда исполнительному устройству. Устройство - датчик, тип которого
+// принимает команды, а подтверждение - его событие с ожидаемым состоянием.
+type Command struct {
+	ID       int64
+	SensorID int64
+	Kind     CommandKind
+	// State - состояние, в которое команда переводит устройство; у toggle вычисляется
+	// по текущему состоянию устройства при постановке в очередь
+	State int64
+	// Duration - длительность импульса pulse
+	Duration  time.Duration
+	Status    CommandStatus
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// DeliveredAt, ConfirmedAt - нулевые, пока команда не доставлена (не подтверждена)
+	DeliveredAt time.Time
+	ConfirmedAt time.Time
+}
+
+// Active - команда ещё ждёт доставки или подтверждения
+func (c *Command) Active() bool {
+	return c.Status == CommandPending || c.Status == CommandDelivered
+}
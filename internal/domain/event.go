@@ -2,10 +2,64 @@ package domain
 
 import "time"
 
-// Event - структура события по датчику
+// Event - структура события по датчику. Payload - целое значение основного канала,
+// Channels - типизированные значения по каналам, nil у событий с одним целым значением.
 type Event struct {
 	Timestamp          time.Time
 	SensorSerialNumber string
 	SensorID           int64
 	Payload            int64
+	Channels           map[string]Value `json:",omitempty"`
+}
+
+// Values - значения события по каналам, для целого payload - единственный канал DefaultChannel
+func (e *Event) Values() map[string]Value {
+	if len(e.Channels) > 0 {
+		return e.Channels
+	}
+	return map[string]Value{DefaultChannel: IntValue(e.Payload)}
+}
+
+// Primary - значение основного канала события
+func (e *Event) Primary() Value {
+	if len(e.Channels) == 0 {
+		return IntValue(e.Payload)
+	}
+	return e.Channels[PrimaryChannel(e.Channels)]
+}
+
+// Value - значение канала события
+func (e *Event) Value(channel string) (Value, bool) {
+	v, ok := e.Values()[channel]
+	return v, ok
+}
+
+// SetValue - записывает единственное значение, целые хранятся только в Payload
+func (e *Event) SetValue(v Value) {
+	if v.Kind == ValueKindInt || v.Kind == "" {
+		e.Payload = v.Int
+		e.Channels = nil
+		return
+	}
+	e.SetChannels(map[string]Value{DefaultChannel: v})
+}
+
+// SetChannels - записывает значения по каналам и пересчитывает Payload по основному каналу
+func (e *Event) SetChannels(channels map[string]Value) {
+	if v, ok := channels[DefaultChannel]; ok && len(channels) == 1 && v.Kind == ValueKindInt {
+		e.SetValue(v)
+		return
+	}
+	e.Channels = channels
+	e.Payload = e.Primary().Integer()
+}
+
+// Aggregate - сводка значений канала за интервал, начинающийся в Start
+type Aggregate struct {
+	Start time.Time
+	Count int64
+	Min   float64
+	Max   float64
+	Avg   float64
+	Sum   float64
 }
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// ImportKind - что загружается: датчики или события
+type ImportKind string
+
+const (
+	ImportKindSensors ImportKind = "sensors"
+	ImportKindEvents  ImportKind = "events"
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusRunning   ImportStatus = "running"
+	ImportStatusFailed    ImportStatus = "failed"
+	ImportStatusCompleted ImportStatus = "completed"
+)
+
+// ImportRowError - ошибка в строке загружаемого файла
+type ImportRowError struct {
+	Row    int64
+	Reason string
+}
+
+// Import - состояние загрузки файла. RowsProcessed - число строк данных, результат которых
+// уже сохранён: при повторной загрузке того же файла с тем же ID эти строки пропускаются.
+type Import struct {
+	ID            string
+	Kind          ImportKind
+	Status        ImportStatus
+	RowsProcessed int64
+	RowsImported  int64
+	RowsFailed    int64
+	Errors        []ImportRowError
+	// Error - причина, по которой загрузка прервалась
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// OutboxSensorEvent - тема сообщения outbox о принятом событии датчика
+const OutboxSensorEvent = "sensor.event"
+
+// OutboxMessage - сообщение для внешних систем, записанное в одной транзакции с изменением, которое его вызвало
+type OutboxMessage struct {
+	ID    int64
+	Topic string
+	// Key - ключ сообщения, например ID датчика; сообщения с одним ключом публикуются по порядку
+	Key string
+	// Payload - тело сообщения, JSON
+	Payload   []byte
+	CreatedAt time.Time
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// Scene - именованный набор целевых настроек датчиков, например "Спокойной ночи" или "Никого нет дома"
+type Scene struct {
+	ID          int64
+	Name        string
+	Description string
+	Targets     []SceneTarget
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// SceneTarget - настройки, которые сцена применяет к датчику. Незаданные поля не меняются.
+type SceneTarget struct {
+	SensorID    int64
+	IsActive    *bool
+	Description *string
+	// State - состояние, в которое переводится исполнительное устройство командой set_state
+	State *int64
+}
+
+// SceneActivationStatus - итог применения сцены
+type SceneActivationStatus string
+
+const (
+	// SceneActivationApplied - все настройки применены
+	SceneActivationApplied SceneActivationStatus = "applied"
+	// SceneActivationPartial - часть настроек не применена
+	SceneActivationPartial SceneActivationStatus = "partial"
+	// SceneActivationFailed - не применена ни одна настройка
+	SceneActivationFailed SceneActivationStatus = "failed"
+)
+
+// SceneActivation - запись истории применения сцены
+type SceneActivation struct {
+	ID          int64
+	SceneID     int64
+	Status      SceneActivationStatus
+	ActivatedAt time.Time
+	Results     []SceneTargetResult
+}
+
+// SceneTargetResult - результат применения настроек к одному датчику, пустой Error - успех
+type SceneTargetResult struct {
+	SensorID int64
+	Error    string
+	// CommandID - команда, поставленная в очередь устройства для SceneTarget.State
+	CommandID int64
+}
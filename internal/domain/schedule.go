@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// ScheduleActionKind - что делает расписание при срабатывании
+type ScheduleActionKind string
+
+const (
+	// ScheduleUpdateSensors - меняет IsActive и Description датчиков SensorIDs
+	ScheduleUpdateSensors ScheduleActionKind = "update_sensors"
+	// ScheduleActivateScene - применяет сцену SceneID
+	ScheduleActivateScene ScheduleActionKind = "activate_scene"
+	// ScheduleSendCommand - ставит команду CommandKind с состоянием State в очередь устройств SensorIDs
+	ScheduleSendCommand ScheduleActionKind = "send_command"
+	// ScheduleHTTP - отправляет POST запросом на URL сводку по датчикам SensorIDs
+	ScheduleHTTP ScheduleActionKind = "http"
+)
+
+// ScheduleAction - действие расписания, используются только поля, нужные для Kind
+type ScheduleAction struct {
+	Kind        ScheduleActionKind
+	SensorIDs   []int64
+	IsActive    *bool
+	Description *string
+	SceneID     int64
+	CommandKind CommandKind
+	State       int64
+	URL         string
+}
+
+// MissedRunPolicy - что делать с запусками, пропущенными, пока сервис не работал
+type MissedRunPolicy string
+
+const (
+	// MissedRunOnce - все пропущенные запуски заменяются одним сразу после старта
+	MissedRunOnce MissedRunPolicy = "run_once"
+	// MissedRunSkip - пропущенные запуски не выполняются, расписание ждёт следующего
+	MissedRunSkip MissedRunPolicy = "skip"
+)
+
+// Schedule - действие, которое выполняется по cron выражению во временной зоне Timezone
+type Schedule struct {
+	ID         int64
+	Name       string
+	Cron       string
+	Timezone   string
+	Action     ScheduleAction
+	MissedRuns MissedRunPolicy
+	Enabled    bool
+	// NextRunAt - время следующего запуска, не задано у выключенного расписания
+	NextRunAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ScheduleRunStatus - итог запуска расписания
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunSucceeded ScheduleRunStatus = "succeeded"
+	ScheduleRunFailed    ScheduleRunStatus = "failed"
+	// ScheduleRunSkipped - запуск пропущен по MissedRunSkip
+	ScheduleRunSkipped ScheduleRunStatus = "skipped"
+)
+
+// ScheduleRun - запись истории запусков расписания
+type ScheduleRun struct {
+	ID         int64
+	ScheduleID int64
+	// ScheduledAt - время, на которое был назначен запуск; у запуска вручную совпадает со StartedAt
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	Status      ScheduleRunStatus
+	Error       string
+}
@@ -14,9 +14,55 @@ type Sensor struct {
 	ID           int64
 	SerialNumber string
 	Type         SensorType
+	// CurrentState - основное значение последнего события, округлённое до целого
 	CurrentState int64
+	// CurrentValue - основное значение последнего события без округления, см. State
+	CurrentValue Value
 	Description  string
 	IsActive     bool
 	RegisteredAt time.Time
 	LastActivity time.Time
+	// Version - номер версии датчика, увеличивается при каждом сохранении
+	Version int64
+	// Unit - единица измерения значений после калибровки, пустая - единица типа датчика
+	Unit string
+	// Calibration - преобразование сырых значений, nil - значения не преобразуются
+	Calibration *Calibration
+}
+
+// State - текущее значение датчика. Если CurrentValue не задано (датчик без событий) - CurrentState.
+func (s Sensor) State() Value {
+	if s.CurrentValue.Kind == "" {
+		return IntValue(s.CurrentState)
+	}
+	return s.CurrentValue
+}
+
+// SensorSort - поле, по которому упорядочивается список датчиков
+type SensorSort string
+
+const (
+	SensorSortID           SensorSort = "id"
+	SensorSortSerialNumber SensorSort = "serial_number"
+	SensorSortRegisteredAt SensorSort = "registered_at"
+	SensorSortLastActivity SensorSort = "last_activity"
+)
+
+// SensorQuery - условия выборки датчиков, пустые поля выборку не ограничивают
+type SensorQuery struct {
+	Type     SensorType
+	IsActive *bool
+	// Online - отбирает датчики с событием не раньше OnlineSince (true) или без него (false)
+	Online      *bool
+	OnlineSince time.Time
+	// Search - подстрока описания без учёта регистра
+	Search       string
+	SerialPrefix string
+	// Sort - поле сортировки, при равных значениях датчики упорядочиваются по ID. По умолчанию - ID.
+	Sort SensorSort
+	Desc bool
+	// After - последний датчик предыдущей страницы, используются его ID и поле сортировки
+	After *Sensor
+	// Limit - размер страницы, 0 - без ограничения
+	Limit int
 }
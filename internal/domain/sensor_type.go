@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// PayloadKind - смысл payload событий датчика
+type PayloadKind string
+
+const (
+	// PayloadKindBinary - два состояния: 0 - выключено, иначе включено
+	PayloadKindBinary PayloadKind = "binary"
+	// PayloadKindLevel - измеряемая величина
+	PayloadKindLevel PayloadKind = "level"
+	// PayloadKindCounter - счётчик, который только растёт
+	PayloadKindCounter PayloadKind = "counter"
+)
+
+// SensorTypeSpec - описание типа датчика в реестре
+type SensorTypeSpec struct {
+	Name        SensorType
+	Description string
+	Payload     PayloadKind
+	// Min, Max - допустимый диапазон payload, nil - без ограничения
+	Min *float64
+	Max *float64
+	// Unit - единица измерения payload
+	Unit string
+	// ReportInterval - с каким периодом датчик присылает события, 0 - не задан
+	ReportInterval time.Duration
+	// Actuator - датчики этого типа - исполнительные устройства и принимают команды
+	Actuator bool
+	// Virtual - события датчиков этого типа вычисляются по выражению над другими датчиками
+	// и не принимаются от устройств
+	Virtual bool
+}
+
+// DefaultSensorTypes - типы, которые есть в реестре изначально. Диапазоны у них не заданы,
+// чтобы не отвергать события, которые принимались до появления реестра. Недостающие в хранилище
+// типы добавляются при запуске сервиса (SensorTypes.SaveDefaultSensorTypes).
+func DefaultSensorTypes() []SensorTypeSpec {
+	return []SensorTypeSpec{
+		{
+			Name:        SensorTypeContactClosure,
+			Description: "Контактное замыкание",
+			Payload:     PayloadKindBinary,
+		},
+		{
+			Name:        SensorTypeADC,
+			Description: "АЦП",
+			Payload:     PayloadKindLevel,
+		},
+		{
+			Name:        "relay",
+			Description: "Реле",
+			Payload:     PayloadKindBinary,
+			Actuator:    true,
+		},
+		{
+			Name:        "virtual",
+			Description: "Виртуальный датчик",
+			Payload:     PayloadKindLevel,
+			Virtual:     true,
+		},
+	}
+}
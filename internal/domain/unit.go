@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrIncompatibleUnits = errors.New("incompatible units")
+
+// unit - единица измерения: значение в базовой единице величины = v * scale + offset
+type unit struct {
+	quantity string
+	scale    float64
+	offset   float64
+}
+
+// units - известные единицы по каноническим именам. Между единицами одной величины
+// значения переводятся, остальные единицы считаются просто подписью.
+var units = map[string]unit{
+	"°C": {"temperature", 1, 0},
+	"°F": {"temperature", 5.0 / 9, -32 * 5.0 / 9},
+	"K":  {"temperature", 1, -273.15},
+
+	"Pa":   {"pressure", 1, 0},
+	"hPa":  {"pressure", 100, 0},
+	"kPa":  {"pressure", 1000, 0},
+	"mbar": {"pressure", 100, 0},
+	"bar":  {"pressure", 100000, 0},
+	"mmHg": {"pressure", 133.322387415, 0},
+	"psi":  {"pressure", 6894.757293168, 0},
+
+	"V":  {"voltage", 1, 0},
+	"mV": {"voltage", 0.001, 0},
+
+	"A":  {"current", 1, 0},
+	"mA": {"current", 0.001, 0},
+
+	"W":  {"power", 1, 0},
+	"kW": {"power", 1000, 0},
+
+	"Wh":  {"energy", 1, 0},
+	"kWh": {"energy", 1000, 0},
+
+	"m":  {"length", 1, 0},
+	"cm": {"length", 0.01, 0},
+	"mm": {"length", 0.001, 0},
+
+	"lx": {"illuminance", 1, 0},
+	"%":  {"ratio", 1, 0},
+	"‰":  {"ratio", 0.1, 0},
+}
+
+// unitAliases - другие написания единиц, регистр не учитывается
+var unitAliases = map[string]string{
+	"c":       "°C",
+	"degc":    "°C",
+	"celsius": "°C",
+	"f":       "°F",
+	"degf":    "°F",
+	"k":       "K",
+	"kelvin":  "K",
+}
+
+// CanonicalUnit - каноническое имя единицы, неизвестные единицы возвращаются без изменений
+func CanonicalUnit(name string) string {
+	name = strings.TrimSpace(name)
+	if _, ok := units[name]; ok {
+		return name
+	}
+	if alias, ok := unitAliases[strings.ToLower(name)]; ok {
+		return alias
+	}
+	for canonical := range units {
+		if strings.EqualFold(canonical, name) {
+			return canonical
+		}
+	}
+	return name
+}
+
+// ConvertUnit - коэффициенты перевода значения из единицы from в единицу to:
+// to = v * scale + offset. Пустая to означает from.
+func ConvertUnit(from, to string) (scale, offset float64, err error) {
+	from, to = CanonicalUnit(from), CanonicalUnit(to)
+	if to == "" || from == to {
+		return 1, 0, nil
+	}
+
+	src, ok := units[from]
+	dst, ok2 := units[to]
+	if !ok || !ok2 || src.quantity != dst.quantity {
+		if from == "" {
+			from = "no unit"
+		}
+		return 0, 0, fmt.Errorf("%w: can't convert %s to %s", ErrIncompatibleUnits, from, to)
+	}
+
+	// v -> база: v*src.scale + src.offset, база -> to: (b - dst.offset) / dst.scale
+	return src.scale / dst.scale, (src.offset - dst.offset) / dst.scale, nil
+}
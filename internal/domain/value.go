@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// ValueKind - тип значения канала события
+type ValueKind string
+
+const (
+	ValueKindInt   ValueKind = "int"
+	ValueKindFloat ValueKind = "float"
+	ValueKindBool  ValueKind = "bool"
+)
+
+// DefaultChannel - канал, в котором хранится единственное значение события
+const DefaultChannel = "value"
+
+var ErrInvalidValue = errors.New("invalid value")
+
+// Value - типизированное значение канала события. В JSON записывается числом или true/false,
+// дробные числа всегда с точкой, чтобы при чтении не превратиться в целые.
+type Value struct {
+	Kind  ValueKind
+	Int   int64
+	Float float64
+	Bool  bool
+}
+
+func IntValue(v int64) Value     { return Value{Kind: ValueKindInt, Int: v} }
+func FloatValue(v float64) Value { return Value{Kind: ValueKindFloat, Float: v} }
+func BoolValue(v bool) Value     { return Value{Kind: ValueKindBool, Bool: v} }
+
+// Number - числовое представление значения, true - 1, false - 0
+func (v Value) Number() float64 {
+	switch v.Kind {
+	case ValueKindFloat:
+		return v.Float
+	case ValueKindBool:
+		if v.Bool {
+			return 1
+		}
+		return 0
+	default:
+		return float64(v.Int)
+	}
+}
+
+// Integer - целое представление значения, дробные округляются до ближайшего целого
+func (v Value) Integer() int64 {
+	switch v.Kind {
+	case ValueKindFloat:
+		r := math.Round(v.Float)
+		switch {
+		case r >= math.MaxInt64:
+			return math.MaxInt64
+		case r <= math.MinInt64:
+			return math.MinInt64
+		}
+		return int64(r)
+	case ValueKindBool:
+		if v.Bool {
+			return 1
+		}
+		return 0
+	default:
+		return v.Int
+	}
+}
+
+func (v Value) String() string {
+	switch v.Kind {
+	case ValueKindFloat:
+		s := strconv.FormatFloat(v.Float, 'g', -1, 64)
+		if !bytes.ContainsAny([]byte(s), ".eEn") {
+			s += ".0"
+		}
+		return s
+	case ValueKindBool:
+		return strconv.FormatBool(v.Bool)
+	default:
+		return strconv.FormatInt(v.Int, 10)
+	}
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	if v.Kind == ValueKindFloat && (math.IsNaN(v.Float) || math.IsInf(v.Float, 0)) {
+		return nil, fmt.Errorf("%w: %v is not a finite number", ErrInvalidValue, v.Float)
+	}
+	return []byte(v.String()), nil
+}
+
+func (v *Value) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseValue(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// ParseValue - значение из текста: true/false, целое или дробное число.
+// Число с точкой или экспонентой считается дробным, даже если оно целое.
+func ParseValue(s string) (Value, error) {
+	switch s {
+	case "true":
+		return BoolValue(true), nil
+	case "false":
+		return BoolValue(false), nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return IntValue(i), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return Value{}, fmt.Errorf("%w: %q is not a number or boolean", ErrInvalidValue, s)
+	}
+	return FloatValue(f), nil
+}
+
+// ParseChannels - каналы из JSON объекта {"имя": значение}
+func ParseChannels(data []byte) (map[string]Value, error) {
+	var channels map[string]Value
+	if err := json.Unmarshal(data, &channels); err != nil {
+		if errors.Is(err, ErrInvalidValue) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	}
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("%w: no channels", ErrInvalidValue)
+	}
+	for name := range channels {
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty channel name", ErrInvalidValue)
+		}
+	}
+	return channels, nil
+}
+
+// PrimaryChannel - канал, значение которого попадает в Payload: DefaultChannel,
+// а если его нет - первый по имени
+func PrimaryChannel(channels map[string]Value) string {
+	if _, ok := channels[DefaultChannel]; ok || len(channels) == 0 {
+		return DefaultChannel
+	}
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	return slices.Min(names)
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// VirtualSensor - определение виртуального датчика: его значение вычисляется по выражению над
+// текущими значениями других датчиков, например "avg(s1, s2, s3)" или "s4 && s5"
+type VirtualSensor struct {
+	// SensorID - датчик виртуального типа (SensorTypeSpec.Virtual), в который записываются вычисленные события
+	SensorID   int64
+	Expression string
+	// Inputs - датчики, на которые ссылается выражение; их события пересчитывают значение
+	Inputs    []int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
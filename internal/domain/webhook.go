@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// WebhookTopic - что произошло с датчиком
+type WebhookTopic string
+
+const (
+	// WebhookSensorEvent - принято событие датчика
+	WebhookSensorEvent WebhookTopic = "sensor.event"
+	// WebhookSensorCreated - зарегистрирован новый датчик
+	WebhookSensorCreated WebhookTopic = "sensor.created"
+	// WebhookSensorUpdated - изменены описание, активность, единица или калибровка датчика
+	WebhookSensorUpdated WebhookTopic = "sensor.updated"
+)
+
+// Webhook - подписка внешней системы на изменения датчиков.
+//
+// Фильтры Topics, SensorIDs, UserID и SensorTypes складываются по И, пустой фильтр пропускает всё.
+type Webhook struct {
+	ID  int64
+	URL string
+	// Secret - ключ HMAC подписи доставок
+	Secret    string
+	Topics    []WebhookTopic
+	SensorIDs []int64
+	// UserID - только датчики, привязанные к пользователю, 0 - любые
+	UserID      int64
+	SensorTypes []SensorType
+	// Enabled - false приостанавливает подписку: новые доставки не создаются, поставленные ждут
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDeliveryStatus - состояние доставки
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending - доставка ждёт очередной попытки
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded - получатель ответил 2xx
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryDead - попытки кончились, доставка отложена до ручного повтора
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery - отправка одного изменения датчика одной подписке
+type WebhookDelivery struct {
+	ID        int64
+	WebhookID int64
+	Topic     WebhookTopic
+	SensorID  int64
+	// Payload - тело запроса, JSON
+	Payload  []byte
+	Status   WebhookDeliveryStatus
+	Attempts int
+	// NextAttemptAt - когда можно делать следующую попытку
+	NextAttemptAt  time.Time
+	LastAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
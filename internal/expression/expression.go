@@ -0,0 +1,271 @@
+// Package expression - выражения виртуальных датчиков над значениями других датчиков:
+//
+//	avg(s1, s2, s3)
+//	s4 && s5.motion
+//	if(avg_over(s7, 10m) > 25, 1, 0)
+//
+// s12 - значение датчика 12 (основной канал после калибровки), s12.humidity - значение канала
+// humidity его последнего события. Поддерживаются числа и true/false, арифметика + - * / %,
+// сравнения < <= > >= == !=, логика && || !, функции min, max, avg, abs, round, if(условие, то, иначе)
+// и функции окна avg_over, min_over, max_over, sum_over, count_over(датчик, длительность) -
+// сводка значений датчика за последнюю длительность, например 30s, 10m или 1h30m.
+//
+// В арифметике true - 1, false - 0, в логике любое ненулевое число - истина.
+package expression
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrSyntax = errors.New("syntax error")
+	// ErrNoValue - у входа выражения нет значения: датчик ещё не присылал событий, в событии нет канала
+	// или за окно не было событий
+	ErrNoValue = errors.New("no value")
+	// ErrEvaluation - значение не определено, например при делении на ноль
+	ErrEvaluation = errors.New("evaluation error")
+)
+
+const (
+	// MaxLength - сколько символов может быть в выражении
+	MaxLength = 1024
+	// MaxWindow - самое длинное окно функций *_over
+	MaxWindow = 24 * time.Hour
+	// maxDepth - наибольшая вложенность выражения
+	maxDepth = 32
+)
+
+// Env - значения датчиков, на которые ссылается выражение
+type Env interface {
+	// Value - текущее значение канала датчика, пустой channel - основной канал.
+	// Если значения нет, возвращает ErrNoValue.
+	Value(ctx context.Context, sensorID int64, channel string) (domain.Value, error)
+	// Aggregate - сводка значений канала датчика за последние window, пустой channel - основной канал
+	Aggregate(ctx context.Context, sensorID int64, channel string, window time.Duration) (domain.Aggregate, error)
+}
+
+// Expression - разобранное выражение
+type Expression struct {
+	source string
+	root   node
+	inputs []int64
+}
+
+// Parse - разбирает выражение, ошибка разбора оборачивает ErrSyntax
+func Parse(s string) (*Expression, error) {
+	if len(s) > MaxLength {
+		return nil, fmt.Errorf("%w: expression is longer than %d characters", ErrSyntax, MaxLength)
+	}
+
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Expression{source: s, root: root}
+	walk(root, func(n node) {
+		if r, ok := n.(*refNode); ok {
+			e.inputs = append(e.inputs, r.sensorID)
+		}
+	})
+	slices.Sort(e.inputs)
+	e.inputs = slices.Compact(e.inputs)
+	if len(e.inputs) == 0 {
+		return nil, fmt.Errorf("%w: expression must reference at least one sensor", ErrSyntax)
+	}
+
+	return e, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Inputs - ID датчиков, на которые ссылается выражение, по возрастанию
+func (e *Expression) Inputs() []int64 {
+	return slices.Clone(e.inputs)
+}
+
+// Eval - вычисляет выражение по значениям из env
+func (e *Expression) Eval(ctx context.Context, env Env) (domain.Value, error) {
+	v, err := e.root.eval(ctx, env)
+	if err != nil {
+		return domain.Value{}, err
+	}
+	if v.Kind == domain.ValueKindFloat && !finite(v.Float) {
+		return domain.Value{}, fmt.Errorf("%w: result is not a finite number", ErrEvaluation)
+	}
+	return v, nil
+}
+
+type node interface {
+	eval(ctx context.Context, env Env) (domain.Value, error)
+}
+
+// walk - обходит дерево выражения
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case *unaryNode:
+		walk(n.x, fn)
+	case *binaryNode:
+		walk(n.x, fn)
+		walk(n.y, fn)
+	case *callNode:
+		for _, arg := range n.args {
+			walk(arg, fn)
+		}
+	case *windowNode:
+		walk(n.ref, fn)
+	}
+}
+
+type constNode struct {
+	v domain.Value
+}
+
+func (n *constNode) eval(context.Context, Env) (domain.Value, error) {
+	return n.v, nil
+}
+
+type refNode struct {
+	sensorID int64
+	channel  string
+}
+
+func (n *refNode) eval(ctx context.Context, env Env) (domain.Value, error) {
+	v, err := env.Value(ctx, n.sensorID, n.channel)
+	if err != nil {
+		return domain.Value{}, fmt.Errorf("%s: %w", n, err)
+	}
+	return v, nil
+}
+
+func (n *refNode) String() string {
+	s := "s" + strconv.FormatInt(n.sensorID, 10)
+	if n.channel != "" {
+		s += "." + n.channel
+	}
+	return s
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) eval(ctx context.Context, env Env) (domain.Value, error) {
+	x, err := n.x.eval(ctx, env)
+	if err != nil {
+		return domain.Value{}, err
+	}
+	if n.op == "!" {
+		return domain.BoolValue(!truthy(x)), nil
+	}
+	return negate(x), nil
+}
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+func (n *binaryNode) eval(ctx context.Context, env Env) (domain.Value, error) {
+	x, err := n.x.eval(ctx, env)
+	if err != nil {
+		return domain.Value{}, err
+	}
+
+	// правая часть && и || вычисляется, только если от неё зависит результат
+	switch {
+	case n.op == "&&" && !truthy(x):
+		return domain.BoolValue(false), nil
+	case n.op == "||" && truthy(x):
+		return domain.BoolValue(true), nil
+	}
+
+	y, err := n.y.eval(ctx, env)
+	if err != nil {
+		return domain.Value{}, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return domain.BoolValue(truthy(y)), nil
+	case "<", "<=", ">", ">=", "==", "!=":
+		return compare(n.op, x, y), nil
+	default:
+		return arithmetic(n.op, x, y)
+	}
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n *callNode) eval(ctx context.Context, env Env) (domain.Value, error) {
+	if n.name == "if" {
+		cond, err := n.args[0].eval(ctx, env)
+		if err != nil {
+			return domain.Value{}, err
+		}
+		if truthy(cond) {
+			return n.args[1].eval(ctx, env)
+		}
+		return n.args[2].eval(ctx, env)
+	}
+
+	args := make([]domain.Value, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(ctx, env)
+		if err != nil {
+			return domain.Value{}, err
+		}
+		args = append(args, v)
+	}
+	return functions[n.name].fn(args), nil
+}
+
+type windowNode struct {
+	name   string
+	ref    *refNode
+	window time.Duration
+}
+
+func (n *windowNode) eval(ctx context.Context, env Env) (domain.Value, error) {
+	agg, err := env.Aggregate(ctx, n.ref.sensorID, n.ref.channel, n.window)
+	if err != nil {
+		return domain.Value{}, fmt.Errorf("%s(%s, %s): %w", n.name, n.ref, n.window, err)
+	}
+
+	if n.name == "count_over" {
+		return domain.IntValue(agg.Count), nil
+	}
+	if agg.Count == 0 {
+		return domain.Value{}, fmt.Errorf("%s(%s, %s): %w: no events in window", n.name, n.ref, n.window, ErrNoValue)
+	}
+
+	switch n.name {
+	case "min_over":
+		return domain.FloatValue(agg.Min), nil
+	case "max_over":
+		return domain.FloatValue(agg.Max), nil
+	case "sum_over":
+		return domain.FloatValue(agg.Sum), nil
+	default:
+		return domain.FloatValue(agg.Avg), nil
+	}
+}
@@ -0,0 +1,165 @@
+package expression
+
+import (
+	"context"
+	"homework/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEnv - значения датчиков по ссылке вида s1 или s1.channel, сводки - по ссылке и окну
+type testEnv struct {
+	values     map[string]domain.Value
+	aggregates map[string]domain.Aggregate
+	calls      []string
+}
+
+func (e *testEnv) Value(_ context.Context, sensorID int64, channel string) (domain.Value, error) {
+	ref := (&refNode{sensorID: sensorID, channel: channel}).String()
+	e.calls = append(e.calls, ref)
+	v, ok := e.values[ref]
+	if !ok {
+		return domain.Value{}, ErrNoValue
+	}
+	return v, nil
+}
+
+func (e *testEnv) Aggregate(_ context.Context, sensorID int64, channel string, window time.Duration) (domain.Aggregate, error) {
+	key := (&refNode{sensorID: sensorID, channel: channel}).String() + "/" + window.String()
+	return e.aggregates[key], nil
+}
+
+func TestExpression_Eval(t *testing.T) {
+	env := &testEnv{
+		values: map[string]domain.Value{
+			"s1":        domain.IntValue(20),
+			"s2":        domain.FloatValue(23.5),
+			"s3":        domain.IntValue(0),
+			"s4":        domain.BoolValue(true),
+			"s5.motion": domain.BoolValue(false),
+			"s6":        domain.IntValue(-7),
+		},
+		aggregates: map[string]domain.Aggregate{
+			"s1/10m0s": {Count: 3, Min: 18, Max: 22, Avg: 20, Sum: 60},
+		},
+	}
+
+	tests := []struct {
+		expression string
+		want       domain.Value
+	}{
+		{"s1 + 2 * 3", domain.IntValue(26)},
+		{"(s1 + 2) * 3", domain.IntValue(66)},
+		{"s1 - s6 % 4", domain.IntValue(23)},
+		{"s1 / 8", domain.FloatValue(2.5)},
+		{"s1 + s2", domain.FloatValue(43.5)},
+		{"-s1", domain.IntValue(-20)},
+		{"avg(s1, s2)", domain.FloatValue(21.75)},
+		{"min(s1, s2, s6)", domain.IntValue(-7)},
+		{"max(s1, s2)", domain.FloatValue(23.5)},
+		{"abs(s6)", domain.IntValue(7)},
+		{"round(s2)", domain.IntValue(24)},
+		{"s4 + s4", domain.IntValue(2)},
+		{"s1 > 19 && s2 <= 23.5", domain.BoolValue(true)},
+		{"s4 && s5.motion", domain.BoolValue(false)},
+		{"s3 || !s5.motion", domain.BoolValue(true)},
+		{"s1 == 20.0", domain.BoolValue(true)},
+		{"if(s3, 1, 2.5)", domain.FloatValue(2.5)},
+		{"avg_over(s1, 10m)", domain.FloatValue(20)},
+		{"max_over(s1, 10m) - min_over(s1, 10m)", domain.FloatValue(4)},
+		{"sum_over(s1, 10m)", domain.FloatValue(60)},
+		{"count_over(s1, 10m)", domain.IntValue(3)},
+		{"count_over(s1, 1h)", domain.IntValue(0)},
+		{"1e1 + .5 + s3", domain.FloatValue(10.5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := Parse(tt.expression)
+			require.NoError(t, err)
+
+			got, err := e.Eval(context.Background(), env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpression_EvalErrors(t *testing.T) {
+	env := &testEnv{values: map[string]domain.Value{"s1": domain.IntValue(1), "s2": domain.IntValue(0)}}
+
+	tests := []struct {
+		expression string
+		wantErr    error
+	}{
+		{"s1 / s2", ErrEvaluation},
+		{"s1 % s2", ErrEvaluation},
+		{"s1 + s3", ErrNoValue},
+		{"s1.humidity", ErrNoValue},
+		{"avg_over(s1, 5m)", ErrNoValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := Parse(tt.expression)
+			require.NoError(t, err)
+
+			_, err = e.Eval(context.Background(), env)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("short circuit", func(t *testing.T) {
+		e, err := Parse("s2 && s3 || s1 || s4")
+		require.NoError(t, err)
+
+		env.calls = nil
+		got, err := e.Eval(context.Background(), env)
+		require.NoError(t, err)
+		assert.Equal(t, domain.BoolValue(true), got)
+		assert.Equal(t, []string{"s2", "s1"}, env.calls)
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("inputs", func(t *testing.T) {
+		e, err := Parse("avg(s12, s3.temp) > 20 && count_over(s7, 1h30m) > 0 || s3")
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3, 7, 12}, e.Inputs())
+		assert.Equal(t, "avg(s12, s3.temp) > 20 && count_over(s7, 1h30m) > 0 || s3", e.String())
+	})
+
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{"empty", "", "expected operand at end of expression"},
+		{"no sensors", "1 + 2", "must reference at least one sensor"},
+		{"unexpected character", "s1 # 2", `unexpected '#' at 4`},
+		{"unclosed parenthesis", "(s1 + 2", `expected ")" at end of expression`},
+		{"trailing operand", "s1 s2", `expected operator, got "s2" at 4`},
+		{"chained comparison", "s1 < s2 < s3", `expected operator, got "<" at 9`},
+		{"unknown function", "median(s1)", `unknown function "median" at 1`},
+		{"unknown name", "s1 + x", `unknown name "x" at 6`},
+		{"too few arguments", "if(s1, 1)", "if at 1 needs at least 3 arguments"},
+		{"too many arguments", "abs(s1, s2)", "abs at 1 takes at most 1 arguments"},
+		{"window of expression", "avg_over(s1 + 1, 5m)", `expected ",", got "+" at 13`},
+		{"window without duration", "avg_over(s1, 5)", `expected duration, got "5" at 14`},
+		{"window too long", "avg_over(s1, 25h)", "window 25h at 14 must be between 1s and 24h0m0s"},
+		{"bare duration", "s1 + 5m", "duration 5m at 6 is allowed only as a window"},
+		{"invalid duration", "avg_over(s1, 5x)", `invalid duration "5x" at 14`},
+		{"zero sensor", "s0", `invalid sensor "s0" at 1`},
+		{"too long", "s1" + strings.Repeat(" + s1", MaxLength/5+1), "longer than 1024 characters"},
+		{"too deep", strings.Repeat("-", maxDepth) + "s1", "nested deeper than 32 levels"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+			assert.ErrorIs(t, err, ErrSyntax)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package expression
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenValue
+	tokenDuration
+	tokenRef
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// pos - позиция токена в выражении, с единицы
+	pos    int
+	value  domain.Value
+	window time.Duration
+	ref    *refNode
+}
+
+var (
+	refRe = regexp.MustCompile(`^s([0-9]+)$`)
+	// twoCharOps проверяются раньше односимвольных
+	twoCharOps = []string{"&&", "||", "<=", ">=", "==", "!="}
+	oneCharOps = "+-*/%()<>!,"
+)
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func isIdentByte(b byte) bool {
+	return isLetter(b) || isDigit(b) || b == '_'
+}
+
+// lex - разбивает выражение на токены
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case isDigit(c) || c == '.' && i+1 < len(s) && isDigit(s[i+1]):
+			start := i
+			i = scanNumber(s, i)
+			if i < len(s) && isLetter(s[i]) {
+				// число с единицей времени - длительность окна, например 10m или 1h30m
+				for i < len(s) && (isDigit(s[i]) || s[i] == '.' || isLetter(s[i])) {
+					i++
+				}
+				d, err := time.ParseDuration(s[start:i])
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid duration %q at %d", ErrSyntax, s[start:i], start+1)
+				}
+				tokens = append(tokens, token{kind: tokenDuration, text: s[start:i], pos: start + 1, window: d})
+				continue
+			}
+			v, err := domain.ParseValue(s[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid number %q at %d", ErrSyntax, s[start:i], start+1)
+			}
+			tokens = append(tokens, token{kind: tokenValue, text: s[start:i], pos: start + 1, value: v})
+
+		case isLetter(c) || c == '_':
+			start := i
+			for i < len(s) && isIdentByte(s[i]) {
+				i++
+			}
+			text := s[start:i]
+
+			switch m := refRe.FindStringSubmatch(text); {
+			case text == "true" || text == "false":
+				tokens = append(tokens, token{kind: tokenValue, text: text, pos: start + 1, value: domain.BoolValue(text == "true")})
+			case m != nil:
+				id, err := strconv.ParseInt(m[1], 10, 64)
+				if err != nil || id < 1 {
+					return nil, fmt.Errorf("%w: invalid sensor %q at %d", ErrSyntax, text, start+1)
+				}
+				ref := &refNode{sensorID: id}
+				if i+1 < len(s) && s[i] == '.' && isIdentByte(s[i+1]) {
+					i++
+					channelStart := i
+					for i < len(s) && isIdentByte(s[i]) {
+						i++
+					}
+					ref.channel = s[channelStart:i]
+				}
+				tokens = append(tokens, token{kind: tokenRef, text: s[start:i], pos: start + 1, ref: ref})
+			default:
+				tokens = append(tokens, token{kind: tokenIdent, text: text, pos: start + 1})
+			}
+
+		default:
+			op := ""
+			for _, candidate := range twoCharOps {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" && strings.IndexByte(oneCharOps, c) >= 0 {
+				op = string(c)
+			}
+			if op == "" {
+				r, _ := utf8.DecodeRuneInString(s[i:])
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, r, i+1)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i + 1})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(s) + 1}), nil
+}
+
+// scanNumber - конец числа, начинающегося с i: цифры, дробная часть и порядок
+func scanNumber(s string, i int) int {
+	for i < len(s) && (isDigit(s[i]) || s[i] == '.') {
+		i++
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			return j
+		}
+	}
+	return i
+}
+
+// parser - разбор выражения рекурсивным спуском, от низшего приоритета к высшему:
+// ||, &&, сравнения, + -, * / %, унарные - !
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// back - возвращает токен t, полученный next
+func (p *parser) back(t token) {
+	if t.kind != tokenEOF {
+		p.pos--
+	}
+}
+
+// accept - пропускает оператор op, если он следующий
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		return p.unexpected(fmt.Sprintf("%q", op))
+	}
+	return nil
+}
+
+func (p *parser) unexpected(want string) error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("%w: expected %s at end of expression", ErrSyntax, want)
+	}
+	return fmt.Errorf("%w: expected %s, got %q at %d", ErrSyntax, want, t.text, t.pos)
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected("operator")
+	}
+	return n, nil
+}
+
+// binary - левоассоциативная цепочка операторов ops над операндами operand
+func (p *parser) binary(ops []string, operand func() (node, error)) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokenOp || !slices.Contains(ops, t.text) {
+			return x, nil
+		}
+		p.next()
+
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: t.text, x: x, y: y}
+	}
+}
+
+func (p *parser) or() (node, error) {
+	return p.binary([]string{"||"}, p.and)
+}
+
+func (p *parser) and() (node, error) {
+	return p.binary([]string{"&&"}, p.comparison)
+}
+
+// comparison - сравнения не объединяются в цепочки: a < b < c - ошибка
+func (p *parser) comparison() (node, error) {
+	x, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenOp || !slices.Contains([]string{"<", "<=", ">", ">=", "==", "!="}, t.text) {
+		return x, nil
+	}
+	p.next()
+
+	y, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	return &binaryNode{op: t.text, x: x, y: y}, nil
+}
+
+func (p *parser) sum() (node, error) {
+	return p.binary([]string{"+", "-"}, p.product)
+}
+
+func (p *parser) product() (node, error) {
+	return p.binary([]string{"*", "/", "%"}, p.unary)
+}
+
+func (p *parser) unary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("%w: expression is nested deeper than %d levels", ErrSyntax, maxDepth)
+	}
+
+	for _, op := range []string{"-", "!"} {
+		if p.accept(op) {
+			x, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return &unaryNode{op: op, x: x}, nil
+		}
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenValue:
+		return &constNode{v: t.value}, nil
+	case tokenRef:
+		return t.ref, nil
+	case tokenIdent:
+		return p.call(t)
+	case tokenDuration:
+		return nil, fmt.Errorf("%w: duration %s at %d is allowed only as a window", ErrSyntax, t.text, t.pos)
+	case tokenOp:
+		if t.text == "(" {
+			x, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	p.back(t)
+	return nil, p.unexpected("operand")
+}
+
+func (p *parser) call(name token) (node, error) {
+	if t := p.peek(); t.kind != tokenOp || t.text != "(" {
+		return nil, fmt.Errorf("%w: unknown name %q at %d, sensors are written as s1", ErrSyntax, name.text, name.pos)
+	}
+	if windowFunctions[name.text] {
+		return p.window(name)
+	}
+
+	f, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %q at %d", ErrSyntax, name.text, name.pos)
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	n := &callNode{name: name.text}
+	if !p.accept(")") {
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			n.args = append(n.args, arg)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch {
+	case len(n.args) < f.minArgs:
+		return nil, fmt.Errorf("%w: %s at %d needs at least %d arguments", ErrSyntax, name.text, name.pos, f.minArgs)
+	case f.maxArgs > 0 && len(n.args) > f.maxArgs:
+		return nil, fmt.Errorf("%w: %s at %d takes at most %d arguments", ErrSyntax, name.text, name.pos, f.maxArgs)
+	}
+	return n, nil
+}
+
+// window - функция окна: name(датчик, длительность)
+func (p *parser) window(name token) (node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	ref := p.next()
+	if ref.kind != tokenRef {
+		p.back(ref)
+		return nil, p.unexpected("sensor")
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+
+	window := p.next()
+	if window.kind != tokenDuration {
+		p.back(window)
+		return nil, p.unexpected("duration")
+	}
+	if window.window < time.Second || window.window > MaxWindow {
+		return nil, fmt.Errorf("%w: window %s at %d must be between 1s and %s", ErrSyntax, window.text, window.pos, MaxWindow)
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &windowNode{name: name.text, ref: ref.ref, window: window.window}, nil
+}
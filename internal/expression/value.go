@@ -0,0 +1,135 @@
+package expression
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"math"
+)
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// truthy - значение как условие: число истинно, если оно не ноль
+func truthy(v domain.Value) bool {
+	return v.Number() != 0
+}
+
+// integral - целое значение или true/false, в арифметике они остаются целыми
+func integral(v domain.Value) bool {
+	return v.Kind != domain.ValueKindFloat
+}
+
+func negate(v domain.Value) domain.Value {
+	if integral(v) {
+		return domain.IntValue(-v.Integer())
+	}
+	return domain.FloatValue(-v.Float)
+}
+
+func compare(op string, x, y domain.Value) domain.Value {
+	a, b := x.Number(), y.Number()
+	switch op {
+	case "<":
+		return domain.BoolValue(a < b)
+	case "<=":
+		return domain.BoolValue(a <= b)
+	case ">":
+		return domain.BoolValue(a > b)
+	case ">=":
+		return domain.BoolValue(a >= b)
+	case "==":
+		return domain.BoolValue(a == b)
+	default:
+		return domain.BoolValue(a != b)
+	}
+}
+
+// arithmetic - + - * / %: целые операнды дают целый результат, кроме деления
+func arithmetic(op string, x, y domain.Value) (domain.Value, error) {
+	if (op == "/" || op == "%") && y.Number() == 0 {
+		return domain.Value{}, fmt.Errorf("%w: division by zero", ErrEvaluation)
+	}
+
+	if integral(x) && integral(y) && op != "/" {
+		a, b := x.Integer(), y.Integer()
+		switch op {
+		case "+":
+			return domain.IntValue(a + b), nil
+		case "-":
+			return domain.IntValue(a - b), nil
+		case "*":
+			return domain.IntValue(a * b), nil
+		default:
+			return domain.IntValue(a % b), nil
+		}
+	}
+
+	a, b := x.Number(), y.Number()
+	switch op {
+	case "+":
+		return domain.FloatValue(a + b), nil
+	case "-":
+		return domain.FloatValue(a - b), nil
+	case "*":
+		return domain.FloatValue(a * b), nil
+	case "/":
+		return domain.FloatValue(a / b), nil
+	default:
+		return domain.FloatValue(math.Mod(a, b)), nil
+	}
+}
+
+// function - встроенная функция выражения и допустимое число аргументов, maxArgs 0 - без ограничения
+type function struct {
+	minArgs, maxArgs int
+	fn               func(args []domain.Value) domain.Value
+}
+
+var functions = map[string]function{
+	"min": {minArgs: 1, fn: func(args []domain.Value) domain.Value {
+		out := args[0]
+		for _, v := range args[1:] {
+			if v.Number() < out.Number() {
+				out = v
+			}
+		}
+		return out
+	}},
+	"max": {minArgs: 1, fn: func(args []domain.Value) domain.Value {
+		out := args[0]
+		for _, v := range args[1:] {
+			if v.Number() > out.Number() {
+				out = v
+			}
+		}
+		return out
+	}},
+	"avg": {minArgs: 1, fn: func(args []domain.Value) domain.Value {
+		var sum float64
+		for _, v := range args {
+			sum += v.Number()
+		}
+		return domain.FloatValue(sum / float64(len(args)))
+	}},
+	"abs": {minArgs: 1, maxArgs: 1, fn: func(args []domain.Value) domain.Value {
+		if integral(args[0]) {
+			return domain.IntValue(max(args[0].Integer(), -args[0].Integer()))
+		}
+		return domain.FloatValue(math.Abs(args[0].Float))
+	}},
+	"round": {minArgs: 1, maxArgs: 1, fn: func(args []domain.Value) domain.Value {
+		return domain.IntValue(args[0].Integer())
+	}},
+	// if вычисляется в callNode, чтобы не вычислять ненужную ветку
+	"if": {minArgs: 3, maxArgs: 3},
+}
+
+// windowFunctions - функции сводки значений датчика за окно
+var windowFunctions = map[string]bool{
+	"avg_over":   true,
+	"min_over":   true,
+	"max_over":   true,
+	"sum_over":   true,
+	"count_over": true,
+}
@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/grpc/pb"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toSensorType - имя типа из реестра важнее значения enum, в котором есть только исходные типы
+func toSensorType(t pb.SensorType, name string) domain.SensorType {
+	if name != "" {
+		return domain.SensorType(name)
+	}
+	switch t {
+	case pb.SensorType_SENSOR_TYPE_CONTACT_CLOSURE:
+		return domain.SensorTypeContactClosure
+	case pb.SensorType_SENSOR_TYPE_ADC:
+		return domain.SensorTypeADC
+	default:
+		return ""
+	}
+}
+
+func fromSensorType(t domain.SensorType) pb.SensorType {
+	switch t {
+	case domain.SensorTypeContactClosure:
+		return pb.SensorType_SENSOR_TYPE_CONTACT_CLOSURE
+	case domain.SensorTypeADC:
+		return pb.SensorType_SENSOR_TYPE_ADC
+	default:
+		return pb.SensorType_SENSOR_TYPE_UNSPECIFIED
+	}
+}
+
+// toTimestamp - нулевое время передаётся как отсутствующее значение
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func toSensor(s domain.Sensor) *pb.Sensor {
+	return &pb.Sensor{
+		Id:           s.ID,
+		SerialNumber: s.SerialNumber,
+		Type:         fromSensorType(s.Type),
+		TypeName:     string(s.Type),
+		CurrentState: s.CurrentState,
+		Description:  s.Description,
+		IsActive:     s.IsActive,
+		RegisteredAt: toTimestamp(s.RegisteredAt),
+		LastActivity: toTimestamp(s.LastActivity),
+	}
+}
+
+func toSensors(sensors []domain.Sensor) []*pb.Sensor {
+	out := make([]*pb.Sensor, 0, len(sensors))
+	for _, s := range sensors {
+		out = append(out, toSensor(s))
+	}
+	return out
+}
+
+func toEvent(e domain.Event) *pb.Event {
+	return &pb.Event{
+		SensorId:           e.SensorID,
+		SensorSerialNumber: e.SensorSerialNumber,
+		Payload:            e.Payload,
+		Timestamp:          toTimestamp(e.Timestamp),
+		Channels:           toChannels(e.Channels),
+	}
+}
+
+func toChannels(channels map[string]domain.Value) map[string]*pb.Value {
+	if len(channels) == 0 {
+		return nil
+	}
+	out := make(map[string]*pb.Value, len(channels))
+	for name, v := range channels {
+		switch v.Kind {
+		case domain.ValueKindFloat:
+			out[name] = &pb.Value{Kind: &pb.Value_FloatValue{FloatValue: v.Float}}
+		case domain.ValueKindBool:
+			out[name] = &pb.Value{Kind: &pb.Value_BoolValue{BoolValue: v.Bool}}
+		default:
+			out[name] = &pb.Value{Kind: &pb.Value_IntValue{IntValue: v.Int}}
+		}
+	}
+	return out
+}
+
+func fromChannels(channels map[string]*pb.Value) (map[string]domain.Value, error) {
+	out := make(map[string]domain.Value, len(channels))
+	for name, v := range channels {
+		switch kind := v.GetKind().(type) {
+		case *pb.Value_IntValue:
+			out[name] = domain.IntValue(kind.IntValue)
+		case *pb.Value_FloatValue:
+			if math.IsNaN(kind.FloatValue) || math.IsInf(kind.FloatValue, 0) {
+				return nil, fmt.Errorf("%w: channel %q is not a finite number", domain.ErrInvalidValue, name)
+			}
+			out[name] = domain.FloatValue(kind.FloatValue)
+		case *pb.Value_BoolValue:
+			out[name] = domain.BoolValue(kind.BoolValue)
+		default:
+			return nil, fmt.Errorf("%w: channel %q has no value", domain.ErrInvalidValue, name)
+		}
+	}
+	return out, nil
+}
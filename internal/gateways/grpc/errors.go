@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus - переводит ошибку usecase в статус gRPC. Внутренние ошибки пишутся в лог,
+// а клиенту отдаётся только общее описание, как и в HTTP API.
+func toStatus(ctx context.Context, err error, msg string) error {
+	switch {
+	case errors.Is(err, usecase.ErrWrongSensorSerialNumber),
+		errors.Is(err, usecase.ErrWrongSensorType),
+		errors.Is(err, usecase.ErrInvalidEventTimestamp),
+		errors.Is(err, usecase.ErrPayloadOutOfRange),
+		errors.Is(err, usecase.ErrInvalidEventChannels),
+		errors.Is(err, domain.ErrInvalidValue),
+		errors.Is(err, usecase.ErrInvalidUserName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, usecase.ErrSensorNotFound),
+		errors.Is(err, usecase.ErrUserNotFound),
+		errors.Is(err, usecase.ErrEventNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, usecase.ErrVirtualSensor):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	logging.FromContext(ctx).ErrorContext(ctx, msg, logging.Error(err))
+	return status.Error(codes.Internal, "internal server error: "+msg)
+}
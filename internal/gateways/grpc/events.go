@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/grpc/pb"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxSubscribeSensors - сколько датчиков можно читать одним Subscribe, как в SSE
+	maxSubscribeSensors = 100
+	// maxReportedEventErrors - сколько ошибок событий попадает в ответ StreamEvents
+	maxReportedEventErrors = 10
+)
+
+type eventService struct {
+	pb.UnimplementedEventServiceServer
+
+	events       *usecase.Event
+	sensors      *usecase.Sensor
+	pollInterval time.Duration
+	shutdown     context.Context
+}
+
+// toDomainEvent - событие из запроса; серийный номер заполнен, даже если запрос с ошибкой
+func toDomainEvent(req *pb.SendEventRequest) (*domain.Event, error) {
+	event := &domain.Event{
+		SensorSerialNumber: req.GetSensorSerialNumber(),
+		Payload:            req.GetPayload(),
+		Timestamp:          fromTimestamp(req.GetTimestamp()),
+	}
+	if req.GetTimestamp() == nil {
+		return event, fmt.Errorf("%w: timestamp is required", usecase.ErrInvalidEventTimestamp)
+	}
+
+	if len(req.GetChannels()) > 0 {
+		channels, err := fromChannels(req.GetChannels())
+		if err != nil {
+			return event, err
+		}
+		event.SetChannels(channels)
+	}
+
+	return event, nil
+}
+
+func (s *eventService) SendEvent(ctx context.Context, req *pb.SendEventRequest) (*pb.Event, error) {
+	event, err := toDomainEvent(req)
+	if err == nil {
+		err = s.events.ReceiveEvent(ctx, event)
+	}
+	if err != nil {
+		return nil, toStatus(ctx, err, "unable to process event")
+	}
+
+	return toEvent(*event), nil
+}
+
+func (s *eventService) StreamEvents(stream pb.EventService_StreamEventsServer) error {
+	ctx := stream.Context()
+	resp := &pb.StreamEventsResponse{}
+
+	for index := int64(0); ; index++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		event, err := toDomainEvent(req)
+		if err == nil {
+			err = s.events.ReceiveEvent(ctx, event)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return toStatus(ctx, ctx.Err(), "")
+			}
+
+			logging.FromContext(ctx).WarnContext(ctx, "unable to process event",
+				logging.SerialNumber(event.SensorSerialNumber), logging.Error(err))
+
+			reason := status.Convert(toStatus(ctx, err, "unable to process event")).Message()
+			resp.Rejected++
+			if len(resp.Errors) < maxReportedEventErrors {
+				resp.Errors = append(resp.Errors, &pb.EventError{
+					Index:              index,
+					SensorSerialNumber: event.SensorSerialNumber,
+					Reason:             reason,
+				})
+			}
+			continue
+		}
+
+		resp.Accepted++
+	}
+}
+
+func (s *eventService) GetHistory(ctx context.Context, req *pb.GetHistoryRequest) (*pb.GetHistoryResponse, error) {
+	if req.GetSensorId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "sensor_id must be positive")
+	}
+	if req.GetStart() == nil || req.GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start and end are required")
+	}
+
+	events, err := s.events.GetEventsByTimeFrame(ctx, req.GetSensorId(), req.GetStart().AsTime(), req.GetEnd().AsTime())
+	if err != nil {
+		return nil, toStatus(ctx, err, "unable to retrieve sensor history")
+	}
+
+	resp := &pb.GetHistoryResponse{Events: make([]*pb.Event, 0, len(events))}
+	for _, event := range events {
+		resp.Events = append(resp.Events, toEvent(event))
+	}
+
+	return resp, nil
+}
+
+func (s *eventService) Subscribe(req *pb.SubscribeRequest, stream pb.EventService_SubscribeServer) error {
+	ctx := stream.Context()
+
+	if len(req.GetSensorIds()) == 0 {
+		return status.Error(codes.InvalidArgument, "sensor_ids is required")
+	}
+	if len(req.GetSensorIds()) > maxSubscribeSensors {
+		return status.Errorf(codes.InvalidArgument, "at most %d sensor_ids", maxSubscribeSensors)
+	}
+
+	for _, id := range req.GetSensorIds() {
+		if _, err := s.sensors.GetSensorByID(ctx, id); err != nil {
+			return toStatus(ctx, err, "unable to retrieve sensor")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.shutdown, cancel)
+	defer stop()
+
+	err := s.events.WatchEvents(ctx, req.GetSensorIds(), fromTimestamp(req.GetSince()), s.pollInterval, func(event domain.Event) error {
+		return stream.Send(toEvent(event))
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	if err != nil {
+		return toStatus(ctx, err, "event subscription interrupted")
+	}
+
+	return nil
+}
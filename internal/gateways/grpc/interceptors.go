@@ -0,0 +1,93 @@
+package grpc
+
+import (
+	"context"
+	"homework/internal/logging"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey - ключ метаданных с идентификатором запроса, аналог заголовка X-Request-ID
+const requestIDKey = "x-request-id"
+
+// withRequestLogger - кладёт в контекст логгер с идентификатором запроса из метаданных
+// или сгенерированным и возвращает идентификатор клиенту в заголовке ответа
+func withRequestLogger(ctx context.Context, method string) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" || len(requestID) > 128 {
+		requestID = uuid.NewString()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	logger := logging.FromContext(ctx).With(logging.RequestID(requestID), slog.String("grpc_method", method))
+	return logging.WithContext(ctx, logger)
+}
+
+func logRequest(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, logging.Error(err))
+	}
+
+	logging.FromContext(ctx).LogAttrs(ctx, level, "request handled", attrs...)
+}
+
+func unaryLogger() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = withRequestLogger(ctx, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+		logRequest(ctx, start, err)
+
+		return resp, err
+	}
+}
+
+// loggedStream - поток с контекстом, в который положен логгер запроса
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
+
+func streamLogger() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withRequestLogger(ss.Context(), info.FullMethod)
+
+		err := handler(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+		logRequest(ctx, start, err)
+
+		return err
+	}
+}
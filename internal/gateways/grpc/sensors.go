@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/gateways/grpc/pb"
+	"homework/internal/usecase"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type sensorService struct {
+	pb.UnimplementedSensorServiceServer
+
+	sensors *usecase.Sensor
+}
+
+func (s *sensorService) RegisterSensor(ctx context.Context, req *pb.RegisterSensorRequest) (*pb.Sensor, error) {
+	sensor, err := s.sensors.RegisterSensor(ctx, &domain.Sensor{
+		SerialNumber: req.GetSerialNumber(),
+		Type:         toSensorType(req.GetType(), req.GetTypeName()),
+		Description:  req.GetDescription(),
+		IsActive:     req.GetIsActive(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err, "unable to register sensor")
+	}
+
+	return toSensor(*sensor), nil
+}
+
+func (s *sensorService) ListSensors(ctx context.Context, _ *pb.ListSensorsRequest) (*pb.ListSensorsResponse, error) {
+	sensors, err := s.sensors.GetSensors(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err, "unable to retrieve sensors")
+	}
+
+	return &pb.ListSensorsResponse{Sensors: toSensors(sensors)}, nil
+}
+
+func (s *sensorService) GetSensor(ctx context.Context, req *pb.GetSensorRequest) (*pb.Sensor, error) {
+	if req.GetSensorId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "sensor_id must be positive")
+	}
+
+	sensor, err := s.sensors.GetSensorByID(ctx, req.GetSensorId())
+	if err != nil {
+		return nil, toStatus(ctx, err, "unable to retrieve sensor")
+	}
+
+	return toSensor(*sensor), nil
+}
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/gateways/grpc/pb"
+	"homework/internal/usecase"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+//go:generate protoc -I ../../../api --go_out=module=homework:../../.. --go-grpc_out=module=homework:../../.. smarthome.proto
+
+const defaultPollInterval = 5 * time.Second
+
+type UseCases struct {
+	Event  *usecase.Event
+	Sensor *usecase.Sensor
+	User   *usecase.User
+}
+
+type Server struct {
+	host         string
+	port         uint16
+	pollInterval time.Duration
+	server       *grpc.Server
+	shutdown     context.Context
+	cancel       context.CancelFunc
+}
+
+func NewServer(useCases UseCases, options ...func(*Server)) *Server {
+	s := &Server{host: "localhost", port: 9000, pollInterval: defaultPollInterval}
+	for _, o := range options {
+		o(s)
+	}
+
+	s.shutdown, s.cancel = context.WithCancel(context.Background())
+
+	s.server = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryLogger()),
+		grpc.ChainStreamInterceptor(streamLogger()),
+	)
+
+	pb.RegisterSensorServiceServer(s.server, &sensorService{sensors: useCases.Sensor})
+	pb.RegisterUserServiceServer(s.server, &userService{users: useCases.User})
+	pb.RegisterEventServiceServer(s.server, &eventService{
+		events:       useCases.Event,
+		sensors:      useCases.Sensor,
+		pollInterval: s.pollInterval,
+		shutdown:     s.shutdown,
+	})
+	reflection.Register(s.server)
+
+	return s
+}
+
+func WithHost(host string) func(*Server) {
+	return func(s *Server) {
+		s.host = host
+	}
+}
+
+func WithPort(port uint16) func(*Server) {
+	return func(s *Server) {
+		s.port = port
+	}
+}
+
+// WithPollInterval - период опроса новых событий для Subscribe
+func WithPollInterval(interval time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.pollInterval = interval
+	}
+}
+
+// Run - запускает сервер и останавливает его при отмене ctx
+func (s *Server) Run(ctx context.Context) error {
+	l, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.host, s.port))
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, l)
+}
+
+// Serve - обслуживает соединения l до отмены ctx. Открытые потоки Subscribe при этом закрываются.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	stop := context.AfterFunc(ctx, func() {
+		// GracefulStop ждёт завершения всех потоков, поэтому сначала закрываются подписки
+		s.cancel()
+		s.server.GracefulStop()
+	})
+	defer stop()
+
+	if err := s.server.Serve(l); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
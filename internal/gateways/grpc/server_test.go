@@ -0,0 +1,324 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"homework/internal/gateways/grpc/pb"
+	eventInMemory "homework/internal/repository/event/inmemory"
+	sensorInMemory "homework/internal/repository/sensor/inmemory"
+	userInMemory "homework/internal/repository/user/inmemory"
+	"homework/internal/usecase"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type clients struct {
+	sensors pb.SensorServiceClient
+	users   pb.UserServiceClient
+	events  pb.EventServiceClient
+}
+
+// startServer - запускает сервер поверх in-memory репозиториев и соединения в памяти
+func startServer(t *testing.T) clients {
+	t.Helper()
+
+	er := eventInMemory.NewEventRepository()
+	sr := sensorInMemory.NewSensorRepository()
+	ur := userInMemory.NewUserRepository()
+	sor := userInMemory.NewSensorOwnerRepository()
+
+	s := NewServer(UseCases{
+		Event:  usecase.NewEvent(er, sr),
+		Sensor: usecase.NewSensor(sr),
+		User:   usecase.NewUser(ur, sor, sr),
+	}, WithPollInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := bufconn.Listen(1 << 20)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(ctx, l)
+	}()
+
+	conn, err := grpc.DialContext(context.Background(), "bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+		_ = conn.Close()
+	})
+
+	return clients{
+		sensors: pb.NewSensorServiceClient(conn),
+		users:   pb.NewUserServiceClient(conn),
+		events:  pb.NewEventServiceClient(conn),
+	}
+}
+
+func TestSensorService(t *testing.T) {
+	c := startServer(t)
+	ctx := context.Background()
+
+	t.Run("err, invalid serial number", func(t *testing.T) {
+		_, err := c.sensors.RegisterSensor(ctx, &pb.RegisterSensorRequest{
+			SerialNumber: "123",
+			Type:         pb.SensorType_SENSOR_TYPE_ADC,
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("err, unknown type name", func(t *testing.T) {
+		_, err := c.sensors.RegisterSensor(ctx, &pb.RegisterSensorRequest{
+			SerialNumber: "1234567890",
+			Type:         pb.SensorType_SENSOR_TYPE_ADC,
+			TypeName:     "humidity",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		_, err := c.sensors.GetSensor(ctx, &pb.GetSensorRequest{SensorId: 100})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("ok, register and get", func(t *testing.T) {
+		sensor, err := c.sensors.RegisterSensor(ctx, &pb.RegisterSensorRequest{
+			SerialNumber: "1234567890",
+			Type:         pb.SensorType_SENSOR_TYPE_CONTACT_CLOSURE,
+			Description:  "door",
+			IsActive:     true,
+		})
+		require.NoError(t, err)
+		assert.NotZero(t, sensor.GetId())
+		assert.NotNil(t, sensor.GetRegisteredAt())
+		assert.Nil(t, sensor.GetLastActivity())
+
+		actual, err := c.sensors.GetSensor(ctx, &pb.GetSensorRequest{SensorId: sensor.GetId()})
+		require.NoError(t, err)
+		assert.Equal(t, "door", actual.GetDescription())
+		assert.Equal(t, pb.SensorType_SENSOR_TYPE_CONTACT_CLOSURE, actual.GetType())
+		assert.Equal(t, "cc", actual.GetTypeName())
+
+		list, err := c.sensors.ListSensors(ctx, &pb.ListSensorsRequest{})
+		require.NoError(t, err)
+		assert.Len(t, list.GetSensors(), 1)
+	})
+}
+
+func TestUserService(t *testing.T) {
+	c := startServer(t)
+	ctx := context.Background()
+
+	t.Run("err, empty name", func(t *testing.T) {
+		_, err := c.users.CreateUser(ctx, &pb.CreateUserRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ok, attach sensor", func(t *testing.T) {
+		user, err := c.users.CreateUser(ctx, &pb.CreateUserRequest{Name: "user"})
+		require.NoError(t, err)
+
+		sensor, err := c.sensors.RegisterSensor(ctx, &pb.RegisterSensorRequest{
+			SerialNumber: "1234567890",
+			Type:         pb.SensorType_SENSOR_TYPE_ADC,
+		})
+		require.NoError(t, err)
+
+		_, err = c.users.AttachSensor(ctx, &pb.AttachSensorRequest{UserId: user.GetId(), SensorId: 100})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = c.users.AttachSensor(ctx, &pb.AttachSensorRequest{UserId: user.GetId(), SensorId: sensor.GetId()})
+		require.NoError(t, err)
+
+		sensors, err := c.users.ListUserSensors(ctx, &pb.ListUserSensorsRequest{UserId: user.GetId()})
+		require.NoError(t, err)
+		require.Len(t, sensors.GetSensors(), 1)
+		assert.Equal(t, sensor.GetId(), sensors.GetSensors()[0].GetId())
+	})
+}
+
+func TestEventService(t *testing.T) {
+	c := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sensor, err := c.sensors.RegisterSensor(ctx, &pb.RegisterSensorRequest{
+		SerialNumber: "1234567890",
+		Type:         pb.SensorType_SENSOR_TYPE_ADC,
+	})
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Hour)
+
+	t.Run("err, unknown sensor", func(t *testing.T) {
+		_, err := c.events.SendEvent(ctx, &pb.SendEventRequest{
+			SensorSerialNumber: "0000000000",
+			Payload:            1,
+			Timestamp:          timestamppb.Now(),
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("err, missing timestamp", func(t *testing.T) {
+		_, err := c.events.SendEvent(ctx, &pb.SendEventRequest{SensorSerialNumber: "1234567890", Payload: 1})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ok, send event", func(t *testing.T) {
+		event, err := c.events.SendEvent(ctx, &pb.SendEventRequest{
+			SensorSerialNumber: "1234567890",
+			Payload:            1,
+			Timestamp:          timestamppb.New(start.Add(time.Minute)),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, sensor.GetId(), event.GetSensorId())
+	})
+
+	t.Run("ok, stream events with partial failures", func(t *testing.T) {
+		stream, err := c.events.StreamEvents(ctx)
+		require.NoError(t, err)
+
+		for i, sn := range []string{"1234567890", "0000000000", "1234567890"} {
+			require.NoError(t, stream.Send(&pb.SendEventRequest{
+				SensorSerialNumber: sn,
+				Payload:            int64(i + 2),
+				Timestamp:          timestamppb.New(start.Add(time.Duration(i+2) * time.Minute)),
+			}))
+		}
+
+		resp, err := stream.CloseAndRecv()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), resp.GetAccepted())
+		assert.Equal(t, int64(1), resp.GetRejected())
+		require.Len(t, resp.GetErrors(), 1)
+		assert.Equal(t, int64(1), resp.GetErrors()[0].GetIndex())
+		assert.Equal(t, "0000000000", resp.GetErrors()[0].GetSensorSerialNumber())
+	})
+
+	t.Run("ok, stream events reports first errors", func(t *testing.T) {
+		stream, err := c.events.StreamEvents(ctx)
+		require.NoError(t, err)
+
+		for i := 0; i < maxReportedEventErrors+5; i++ {
+			require.NoError(t, stream.Send(&pb.SendEventRequest{SensorSerialNumber: "0000000000", Payload: 1}))
+		}
+
+		resp, err := stream.CloseAndRecv()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), resp.GetAccepted())
+		assert.Equal(t, int64(maxReportedEventErrors+5), resp.GetRejected())
+		require.Len(t, resp.GetErrors(), maxReportedEventErrors)
+		assert.Equal(t, int64(maxReportedEventErrors-1), resp.GetErrors()[maxReportedEventErrors-1].GetIndex())
+	})
+
+	t.Run("ok, history", func(t *testing.T) {
+		_, err := c.events.GetHistory(ctx, &pb.GetHistoryRequest{SensorId: sensor.GetId()})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		history, err := c.events.GetHistory(ctx, &pb.GetHistoryRequest{
+			SensorId: sensor.GetId(),
+			Start:    timestamppb.New(start),
+			End:      timestamppb.New(start.Add(time.Hour)),
+		})
+		require.NoError(t, err)
+		assert.Len(t, history.GetEvents(), 3)
+	})
+
+	t.Run("err, subscribe to unknown sensor", func(t *testing.T) {
+		stream, err := c.events.Subscribe(ctx, &pb.SubscribeRequest{SensorIds: []int64{100}})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("err, subscribe to too many sensors", func(t *testing.T) {
+		ids := make([]int64, maxSubscribeSensors+1)
+		for i := range ids {
+			ids[i] = sensor.GetId()
+		}
+
+		stream, err := c.events.Subscribe(ctx, &pb.SubscribeRequest{SensorIds: ids})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ok, subscribe with backfill", func(t *testing.T) {
+		subCtx, subCancel := context.WithCancel(ctx)
+		defer subCancel()
+
+		stream, err := c.events.Subscribe(subCtx, &pb.SubscribeRequest{
+			SensorIds: []int64{sensor.GetId()},
+			Since:     timestamppb.New(start.Add(2 * time.Minute)),
+		})
+		require.NoError(t, err)
+
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), event.GetPayload())
+
+		_, err = c.events.SendEvent(ctx, &pb.SendEventRequest{
+			SensorSerialNumber: "1234567890",
+			Payload:            5,
+			Timestamp:          timestamppb.Now(),
+		})
+		require.NoError(t, err)
+
+		event, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), event.GetPayload())
+
+		subCancel()
+		_, err = stream.Recv()
+		assert.False(t, errors.Is(err, io.EOF))
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("ok, channels", func(t *testing.T) {
+		ts := start.Add(-time.Hour)
+		event, err := c.events.SendEvent(ctx, &pb.SendEventRequest{
+			SensorSerialNumber: "1234567890",
+			Timestamp:          timestamppb.New(ts),
+			Channels: map[string]*pb.Value{
+				"temperature": {Kind: &pb.Value_FloatValue{FloatValue: 21.5}},
+				"door":        {Kind: &pb.Value_BoolValue{BoolValue: true}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), event.GetPayload())
+
+		history, err := c.events.GetHistory(ctx, &pb.GetHistoryRequest{
+			SensorId: sensor.GetId(),
+			Start:    timestamppb.New(ts),
+			End:      timestamppb.New(ts),
+		})
+		require.NoError(t, err)
+		require.Len(t, history.GetEvents(), 1)
+		assert.Equal(t, 21.5, history.GetEvents()[0].GetChannels()["temperature"].GetFloatValue())
+		assert.True(t, history.GetEvents()[0].GetChannels()["door"].GetBoolValue())
+
+		_, err = c.events.SendEvent(ctx, &pb.SendEventRequest{
+			SensorSerialNumber: "1234567890",
+			Timestamp:          timestamppb.Now(),
+			Channels:           map[string]*pb.Value{"empty": {}},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/gateways/grpc/pb"
+	"homework/internal/usecase"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userService struct {
+	pb.UnimplementedUserServiceServer
+
+	users *usecase.User
+}
+
+func (s *userService) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	user, err := s.users.RegisterUser(ctx, &domain.User{Name: req.GetName()})
+	if err != nil {
+		return nil, toStatus(ctx, err, "unable to create user")
+	}
+
+	return &pb.User{Id: user.ID, Name: user.Name}, nil
+}
+
+func (s *userService) AttachSensor(ctx context.Context, req *pb.AttachSensorRequest) (*pb.AttachSensorResponse, error) {
+	if req.GetUserId() < 1 || req.GetSensorId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "user_id and sensor_id must be positive")
+	}
+
+	if err := s.users.AttachSensorToUser(ctx, req.GetUserId(), req.GetSensorId()); err != nil {
+		return nil, toStatus(ctx, err, "unable to attach sensor to user")
+	}
+
+	return &pb.AttachSensorResponse{}, nil
+}
+
+func (s *userService) ListUserSensors(ctx context.Context, req *pb.ListUserSensorsRequest) (*pb.ListSensorsResponse, error) {
+	if req.GetUserId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be positive")
+	}
+
+	sensors, err := s.users.GetUserSensors(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(ctx, err, "unable to retrieve user sensors")
+	}
+
+	return &pb.ListSensorsResponse{Sensors: toSensors(sensors)}, nil
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRoutes(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1000000000", Type: domain.SensorTypeContactClosure, Description: "bathroom leak", IsActive: true},
+		domain.Sensor{SerialNumber: "2000000000", Type: domain.SensorTypeADC, IsActive: true},
+	)
+
+	ctx := context.Background()
+	owner, err := uc.User.RegisterUser(ctx, &domain.User{Name: "owner"})
+	require.NoError(t, err)
+	require.NoError(t, uc.User.AttachSensorToUser(ctx, owner.ID, 1))
+	stranger, err := uc.User.RegisterUser(ctx, &domain.User{Name: "stranger"})
+	require.NoError(t, err)
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+	}
+
+	userPath := func(userID int64, suffix string) string {
+		return "/users/" + strconv.FormatInt(userID, 10) + "/alerts" + suffix
+	}
+
+	t.Run("err, invalid rule", func(t *testing.T) {
+		for _, body := range []string{
+			`{"sensor_id":1,"name":"leak","kind":"threshold","operator":"between","threshold":1}`,
+			`{"sensor_id":1,"name":"leak","kind":"threshold"}`,
+			`{"sensor_id":1,"name":"leak","kind":"stale","stale_after":10}`,
+			`{"sensor_id":1,"name":"leak","kind":"stale","stale_after":3600,"operator":"gt"}`,
+			`{"sensor_id":9,"name":"leak","kind":"threshold","operator":"gte","threshold":1}`,
+			`{"sensor_id":1,"name":"","kind":"threshold","operator":"gte","threshold":1}`,
+		} {
+			w := do(t, http.MethodPost, "/alert-rules", body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+		}
+	})
+
+	t.Run("ok, rule crud", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/alert-rules", `{"sensor_id":2,"name":"silent","kind":"stale","stale_after":7200}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, "/alert-rules/1", w.Header().Get("Location"))
+
+		var rule map[string]any
+		decode(t, w, &rule)
+		assert.Equal(t, 7200.0, rule["stale_after"])
+		assert.NotContains(t, rule, "threshold")
+
+		w = do(t, http.MethodPut, "/alert-rules/1", `{"sensor_id":2,"name":"silent","kind":"stale","stale_after":3600}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &rule)
+		assert.Equal(t, 3600.0, rule["stale_after"])
+
+		var rules []map[string]any
+		w = do(t, http.MethodGet, "/alert-rules?sensor_id=2", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		decode(t, w, &rules)
+		assert.Len(t, rules, 1)
+
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, "/alert-rules?sensor_id=9", "").Code)
+		assert.Equal(t, http.StatusNoContent, do(t, http.MethodDelete, "/alert-rules/1", "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, "/alert-rules/1", "").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, do(t, http.MethodGet, "/alert-rules/abc", "").Code)
+	})
+
+	t.Run("ok, leak alert lifecycle", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/alert-rules", `{"sensor_id":1,"name":"leak","kind":"threshold","operator":"gte","threshold":1}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		for range 2 {
+			require.NoError(t, uc.Event.ReceiveEvent(ctx, &domain.Event{
+				SensorSerialNumber: "1000000000",
+				Timestamp:          time.Now(),
+				Payload:            1,
+			}))
+		}
+
+		var alerts []map[string]any
+		w = do(t, http.MethodGet, userPath(owner.ID, "?status=open"), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &alerts)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, 2.0, alerts[0]["count"])
+		assert.Equal(t, "leak: value 1 >= 1", alerts[0]["message"])
+		id := strconv.FormatInt(int64(alerts[0]["id"].(float64)), 10)
+
+		w = do(t, http.MethodGet, userPath(stranger.ID, ""), "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, userPath(stranger.ID, "/"+id), "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodPost, userPath(stranger.ID, "/"+id+"/acknowledge"), "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, userPath(100, ""), "").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, do(t, http.MethodGet, userPath(owner.ID, "?status=lost"), "").Code)
+
+		var alert map[string]any
+		w = do(t, http.MethodPost, userPath(owner.ID, "/"+id+"/acknowledge"), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &alert)
+		assert.Equal(t, "acknowledged", alert["status"])
+		assert.Equal(t, float64(owner.ID), alert["acknowledged_by"])
+		assert.NotEmpty(t, alert["acknowledged_at"])
+
+		assert.Equal(t, http.StatusConflict, do(t, http.MethodPost, userPath(owner.ID, "/"+id+"/acknowledge"), "").Code)
+
+		w = do(t, http.MethodPost, userPath(owner.ID, "/"+id+"/resolve"), "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &alert)
+		assert.Equal(t, "resolved", alert["status"])
+		assert.Equal(t, float64(owner.ID), alert["resolved_by"])
+
+		assert.Equal(t, http.StatusConflict, do(t, http.MethodPost, userPath(owner.ID, "/"+id+"/resolve"), "").Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, do(t, http.MethodGet, userPath(owner.ID, "/"+id+"/resolve"), "").Code)
+	})
+
+	t.Run("ok, alert stream", func(t *testing.T) {
+		NewSSEHandler(uc, WithSSEPollInterval(10*time.Millisecond)).SetupRoutes(router)
+		srv := httptest.NewServer(router)
+		defer srv.Close()
+
+		streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, srv.URL+userPath(owner.ID, "/stream"), nil)
+		require.NoError(t, err)
+		req.Header.Set(lastEventIDHeader, "1")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(resp.Body)
+		assert.Equal(t, "alert", readSSEField(t, reader, "event"))
+		assert.Contains(t, readSSEField(t, reader, "data"), `"status":"resolved"`)
+	})
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalibrationRoutes(t *testing.T) {
+	router, _ := newInMemoryRouter(t, domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC})
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	period := "start_date=" + url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339)) +
+		"&end_date=" + url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+
+	t.Run("err, invalid calibration", func(t *testing.T) {
+		for _, body := range []string{
+			`{"calibration":{"kind":"log"}}`,
+			`{"calibration":{"kind":"linear"}}`,
+			`{"calibration":{"kind":"lookup","points":[{"raw":1,"value":1}]}}`,
+			`{"unit":"much too long unit name"}`,
+		} {
+			w := do(t, http.MethodPatch, "/sensors/1", body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+		}
+	})
+
+	t.Run("ok, sensor calibrated", func(t *testing.T) {
+		w := do(t, http.MethodPatch, "/sensors/1", `{"unit":"C","calibration":{"kind":"linear","scale":0.5,"offset":-40}}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"unit":"°C"`)
+		assert.Contains(t, w.Body.String(), `"calibration":{"kind":"linear","offset":-40,"scale":0.5}`)
+
+		for _, payload := range []string{"100", "200"} {
+			w = do(t, http.MethodPost, "/events", `{"sensor_serial_number":"1234567890","payload":`+payload+`}`)
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("ok, raw and converted history", func(t *testing.T) {
+		var statuses []map[string]any
+
+		w := do(t, http.MethodGet, "/sensors/1/history?"+period, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+		require.Len(t, statuses, 2)
+		assert.NotContains(t, statuses[0], "converted")
+
+		w = do(t, http.MethodGet, "/sensors/1/history?values=converted&"+period, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+		require.Len(t, statuses, 2)
+		assert.ElementsMatch(t, []any{10.0, 60.0}, []any{statuses[0]["converted"], statuses[1]["converted"]})
+		assert.ElementsMatch(t, []any{100.0, 200.0}, []any{statuses[0]["payload"], statuses[1]["payload"]})
+		assert.Equal(t, "°C", statuses[0]["unit"])
+
+		w = do(t, http.MethodGet, "/sensors/1/history?unit=%C2%B0F&"+period, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+		require.Len(t, statuses, 2)
+		assert.ElementsMatch(t, []any{50.0, 140.0}, []any{statuses[0]["converted"], statuses[1]["converted"]})
+		assert.Equal(t, "°F", statuses[0]["unit"])
+	})
+
+	t.Run("ok, converted aggregates", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/sensors/1/aggregates?unit=F&"+period, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var aggs []map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &aggs))
+		require.Len(t, aggs, 1)
+		assert.InDelta(t, 50.0, aggs[0]["min"], 1e-9)
+		assert.InDelta(t, 140.0, aggs[0]["max"], 1e-9)
+		assert.InDelta(t, 190.0, aggs[0]["sum"], 1e-9)
+		assert.Equal(t, "°F", aggs[0]["unit"])
+	})
+
+	t.Run("err, wrong unit", func(t *testing.T) {
+		for _, path := range []string{
+			"/sensors/1/history?unit=hPa&" + period,
+			"/sensors/1/history?values=scaled&" + period,
+			"/sensors/1/aggregates?unit=hPa&" + period,
+		} {
+			w := do(t, http.MethodGet, path, "")
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, path)
+		}
+	})
+}
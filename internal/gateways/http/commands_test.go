@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestCommandRoutes(t *testing.T) {
+	router, _ := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1234567890", Type: "relay"},
+		domain.Sensor{SerialNumber: "1111111111", Type: domain.SensorTypeADC},
+	)
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+	}
+
+	t.Run("err, invalid command", func(t *testing.T) {
+		for path, body := range map[string]string{
+			"/devices/2/commands":  `{"kind":"set_state","state":1}`,
+			"/devices/1/commands/": `{"kind":"blink"}`,
+		} {
+			w := do(t, http.MethodPost, strings.TrimSuffix(path, "/"), body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+		}
+
+		w := do(t, http.MethodPost, "/devices/1/commands", `{"kind":"pulse","state":1}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		w = do(t, http.MethodPost, "/devices/3/commands", `{"kind":"toggle"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	var queued map[string]any
+
+	t.Run("ok, command queued", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/devices/1/commands", `{"kind":"toggle","ttl":60}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		decode(t, w, &queued)
+		assert.Equal(t, "pending", queued["status"])
+		assert.Equal(t, 1.0, queued["state"])
+		assert.Equal(t, "/devices/1/commands/1", w.Header().Get("Location"))
+
+		w = do(t, http.MethodGet, "/devices/1/commands/1", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = do(t, http.MethodGet, "/devices/2/commands/1", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("ok, pickup and confirm", func(t *testing.T) {
+		var commands []map[string]any
+
+		w := do(t, http.MethodPost, "/devices/1/commands/pickup", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &commands)
+		require.Len(t, commands, 1)
+		assert.Equal(t, "delivered", commands[0]["status"])
+
+		w = do(t, http.MethodPost, "/devices/1/commands/pickup?wait=1", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &commands)
+		assert.Empty(t, commands)
+
+		w = do(t, http.MethodPost, "/events", `{"sensor_serial_number":"1234567890","payload":1}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = do(t, http.MethodGet, "/devices/1/commands?status=confirmed", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &commands)
+		require.Len(t, commands, 1)
+		assert.Contains(t, commands[0], "confirmed_at")
+	})
+
+	t.Run("err, wrong parameters", func(t *testing.T) {
+		for _, path := range []string{
+			"/devices/1/commands?status=lost",
+			"/devices/0/commands",
+			"/devices/1/commands/x",
+		} {
+			w := do(t, http.MethodGet, path, "")
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, path)
+		}
+
+		w := do(t, http.MethodPost, "/devices/1/commands/pickup?wait=3600", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		w = do(t, http.MethodDelete, "/devices/1/commands/1", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestCommandStream(t *testing.T) {
+	router, uc := newInMemoryRouter(t, domain.Sensor{SerialNumber: "1234567890", Type: "relay"})
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/devices/1/commands/stream", nil)
+	require.NoError(t, err)
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	_, err = uc.Command.SendCommand(ctx, 1, &domain.Command{Kind: domain.CommandSetState, State: 1}, time.Minute)
+	require.NoError(t, err)
+
+	var command map[string]any
+	require.NoError(t, wsjson.Read(ctx, conn, &command))
+	assert.Equal(t, "set_state", command["kind"])
+	assert.Equal(t, "delivered", command["status"])
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"homework/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	router, uc := newInMemoryRouter(t, domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC, Description: "kitchen"})
+
+	do := func(t *testing.T, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, validators are returned", func(t *testing.T) {
+		for _, path := range []string{"/sensors", "/sensors/1"} {
+			for _, method := range []string{http.MethodGet, http.MethodHead} {
+				w := do(t, method, path, "")
+				require.Equal(t, http.StatusOK, w.Code, method+" "+path)
+				assert.NotEmpty(t, w.Header().Get("ETag"), method+" "+path)
+				assert.NotEmpty(t, w.Header().Get("Last-Modified"), method+" "+path)
+				assert.Equal(t, "Accept", w.Header().Get("Vary"), method+" "+path)
+			}
+		}
+	})
+
+	t.Run("ok, if-none-match", func(t *testing.T) {
+		for _, path := range []string{"/sensors", "/sensors/1"} {
+			etag := do(t, http.MethodGet, path, "").Header().Get("ETag")
+
+			for _, method := range []string{http.MethodGet, http.MethodHead} {
+				w := do(t, method, path, "", "If-None-Match", `"other", `+etag)
+				assert.Equal(t, http.StatusNotModified, w.Code, method+" "+path)
+				assert.Empty(t, w.Body.Bytes())
+				assert.Equal(t, etag, w.Header().Get("ETag"))
+			}
+
+			w := do(t, http.MethodGet, path, "", "If-None-Match", "W/"+etag)
+			assert.Equal(t, http.StatusNotModified, w.Code, "weak comparison")
+
+			w = do(t, http.MethodGet, path, "", "If-None-Match", etag, "Accept", "application/cbor")
+			assert.Equal(t, http.StatusOK, w.Code, "another representation")
+		}
+	})
+
+	t.Run("ok, if-modified-since", func(t *testing.T) {
+		modified := do(t, http.MethodGet, "/sensors/1", "").Header().Get("Last-Modified")
+
+		w := do(t, http.MethodGet, "/sensors/1", "", "If-Modified-Since", modified)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		w = do(t, http.MethodGet, "/sensors/1", "", "If-Modified-Since", "Mon, 02 Jan 2006 15:04:05 GMT")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// If-None-Match важнее If-Modified-Since
+		w = do(t, http.MethodGet, "/sensors/1", "", "If-None-Match", `"other"`, "If-Modified-Since", modified)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ok, event changes etag", func(t *testing.T) {
+		before := do(t, http.MethodGet, "/sensors", "").Header().Get("ETag")
+		sensorBefore := do(t, http.MethodGet, "/sensors/1", "").Header().Get("ETag")
+
+		require.NoError(t, uc.Event.ReceiveEvent(context.Background(), &domain.Event{
+			SensorSerialNumber: "1234567890",
+			Timestamp:          time.Now(),
+			Payload:            1,
+		}))
+
+		w := do(t, http.MethodGet, "/sensors", "", "If-None-Match", before)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = do(t, http.MethodGet, "/sensors/1", "", "If-None-Match", sensorBefore)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ok, update with if-match", func(t *testing.T) {
+		etag := do(t, http.MethodGet, "/sensors/1", "").Header().Get("ETag")
+
+		w := do(t, http.MethodPatch, "/sensors/1", `{"description":"hall"}`, "If-Match", etag)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"description":"hall"`)
+		assert.Contains(t, w.Body.String(), `"is_active":false`)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+		// второй клиент с тем же ETag не затирает изменение
+		w = do(t, http.MethodPatch, "/sensors/1", `{"description":"bedroom"}`, "If-Match", etag)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+		sensor, err := uc.Sensor.GetSensorByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "hall", sensor.Description)
+	})
+
+	t.Run("ok, update without precondition", func(t *testing.T) {
+		w := do(t, http.MethodPatch, "/sensors/1", `{"is_active":true}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"is_active":true`)
+
+		w = do(t, http.MethodPatch, "/sensors/1", `{"is_active":true}`, "If-Match", "*")
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("err, update", func(t *testing.T) {
+		w := do(t, http.MethodPatch, "/sensors/2", `{"is_active":true}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = do(t, http.MethodPatch, "/sensors/1", `{"is_active":`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		for _, etag := range []string{`"2-1-json"`, `W/"1-1-json"`, `"1-1-cbor"`, `"garbage"`} {
+			w = do(t, http.MethodPatch, "/sensors/1", `{"is_active":true}`, "If-Match", etag)
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code, etag)
+		}
+	})
+}
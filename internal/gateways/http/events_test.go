@@ -0,0 +1,119 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRichEventRoutes(t *testing.T) {
+	router, _ := newInMemoryRouter(t, domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC})
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	from := url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339))
+	to := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+
+	t.Run("ok, typed payloads", func(t *testing.T) {
+		for body, echo := range map[string]string{
+			`{"sensor_serial_number":"1234567890","payload":20}`:                                 `"payload":20`,
+			`{"sensor_serial_number":"1234567890","payload":21.0}`:                               `"payload":21.0`,
+			`{"sensor_serial_number":"1234567890","payload":true}`:                               `"payload":true`,
+			`{"sensor_serial_number":"1234567890","payload":{"temperature":22.5,"humidity":40}}`: `"payload":{"humidity":40,"temperature":22.5}`,
+			`{"sensor_serial_number":"1234567890","payload":{"temperature":23.5,"door":false}}`:  `"payload":{"door":false,"temperature":23.5}`,
+		} {
+			w := do(t, http.MethodPost, "/events", body)
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), echo)
+		}
+	})
+
+	t.Run("err, invalid payload", func(t *testing.T) {
+		for body, code := range map[string]int{
+			`{"sensor_serial_number":"1234567890","payload":"on"}`:      http.StatusBadRequest,
+			`{"sensor_serial_number":"1234567890","payload":{}}`:        http.StatusBadRequest,
+			`{"sensor_serial_number":"1234567890","payload":{"a":[1]}}`: http.StatusBadRequest,
+			`{"sensor_serial_number":"1234567890","payload":{"a b":1}}`: http.StatusUnprocessableEntity,
+			`{"sensor_serial_number":"1234567890"}`:                     http.StatusUnprocessableEntity,
+		} {
+			w := do(t, http.MethodPost, "/events", body)
+			assert.Equal(t, code, w.Code, body)
+		}
+	})
+
+	t.Run("ok, history by channel", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/sensors/1/history?start_date="+from+"&end_date="+to, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var statuses []map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+		require.Len(t, statuses, 5)
+
+		w = do(t, http.MethodGet, "/sensors/1/history?channel=temperature&start_date="+from+"&end_date="+to, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+		require.Len(t, statuses, 2)
+		values := []any{statuses[0]["value"], statuses[1]["value"]}
+		assert.ElementsMatch(t, []any{22.5, 23.5}, values)
+
+		w = do(t, http.MethodGet, "/sensors/1/history?channel=a+b&start_date="+from+"&end_date="+to, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("ok, aggregates", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/sensors/1/aggregates?start_date="+from+"&end_date="+to, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var aggs []map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &aggs))
+		require.Len(t, aggs, 1)
+		// value: 20, 21.0 и true
+		assert.Equal(t, 3.0, aggs[0]["count"])
+		assert.Equal(t, 1.0, aggs[0]["min"])
+		assert.Equal(t, 21.0, aggs[0]["max"])
+		assert.Equal(t, 42.0, aggs[0]["sum"])
+
+		w = do(t, http.MethodGet, "/sensors/1/aggregates?channel=temperature&interval=1h&start_date="+from+"&end_date="+to, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &aggs))
+		require.NotEmpty(t, aggs)
+		var count float64
+		for _, agg := range aggs {
+			count += agg["count"].(float64)
+		}
+		assert.Equal(t, 2.0, count)
+	})
+
+	t.Run("err, invalid aggregation", func(t *testing.T) {
+		for path, code := range map[string]int{
+			"/sensors/1/aggregates?interval=1x&start_date=" + from + "&end_date=" + to:  http.StatusUnprocessableEntity,
+			"/sensors/1/aggregates?interval=1ms&start_date=" + from + "&end_date=" + to: http.StatusUnprocessableEntity,
+			"/sensors/1/aggregates?start_date=" + to + "&end_date=" + from:              http.StatusUnprocessableEntity,
+			"/sensors/1/aggregates?end_date=" + to:                                      http.StatusUnprocessableEntity,
+			"/sensors/9/aggregates?start_date=" + from + "&end_date=" + to:              http.StatusNotFound,
+		} {
+			w := do(t, http.MethodGet, path, "")
+			assert.Equal(t, code, w.Code, path)
+		}
+	})
+}
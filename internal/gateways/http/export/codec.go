@@ -0,0 +1,46 @@
+package export
+
+import (
+	"homework/internal/domain"
+	"homework/internal/gateways/http/negotiation"
+	"io"
+)
+
+// codecEncoder - массив событий в двоичном формате ответа API (CBOR, MessagePack).
+// Длина массива пишется перед элементами, поэтому массив собирается целиком и кодируется в Close.
+type codecEncoder struct {
+	w       io.Writer
+	codec   negotiation.Codec
+	records []record
+}
+
+func newCodecEncoder(w io.Writer, c negotiation.Codec) *codecEncoder {
+	return &codecEncoder{w: w, codec: c, records: []record{}}
+}
+
+func (e *codecEncoder) Encode(event domain.Event) error {
+	e.records = append(e.records, toRecord(event))
+	return nil
+}
+
+func (e *codecEncoder) Close() error {
+	data, err := e.codec.Marshal(e.records)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+// CodecExtension - расширение файла выгрузки массива событий в формате кодека c
+func CodecExtension(c negotiation.Codec) string {
+	switch c {
+	case negotiation.CBOR:
+		return ".cbor"
+	case negotiation.MessagePack:
+		return ".msgpack"
+	default:
+		return FormatJSON.Extension()
+	}
+}
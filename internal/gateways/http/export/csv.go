@@ -0,0 +1,56 @@
+package export
+
+import (
+	"encoding/csv"
+	"homework/internal/domain"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{"sensor_id", "sensor_serial_number", "timestamp", "payload", "channels"}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+	row    []string
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), row: make([]string, len(csvHeader))}
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvEncoder) Encode(event domain.Event) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.row[0] = strconv.FormatInt(event.SensorID, 10)
+	e.row[1] = event.SensorSerialNumber
+	e.row[2] = event.Timestamp.UTC().Format(time.RFC3339Nano)
+	e.row[3] = strconv.FormatInt(event.Payload, 10)
+	channels, err := channelsJSON(event)
+	if err != nil {
+		return err
+	}
+	e.row[4] = channels
+
+	return e.w.Write(e.row)
+}
+
+func (e *csvEncoder) Close() error {
+	// заголовок пишется и для пустой выгрузки
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
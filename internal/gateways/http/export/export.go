@@ -0,0 +1,154 @@
+// Package export - потоковая выгрузка событий датчиков в табличных форматах
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/negotiation"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Format - формат выгрузки
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// mediaTypes - типы содержимого форматов, первый из них указывается в ответе
+var mediaTypes = map[Format][]string{
+	FormatJSON:    {"application/json"},
+	FormatCSV:     {"text/csv"},
+	FormatNDJSON:  {"application/x-ndjson", "application/ndjson", "application/jsonl"},
+	FormatParquet: {"application/vnd.apache.parquet", "application/x-parquet", "application/parquet"},
+}
+
+// ContentType - тип содержимого ответа в формате f
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return mediaTypes[f][0]
+}
+
+// Extension - расширение файла выгрузки
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ParseFormat - формат по имени, например из параметра запроса format
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(name))
+	if _, ok := mediaTypes[f]; !ok {
+		return "", ErrUnsupportedFormat
+	}
+	return f, nil
+}
+
+// offers - типы содержимого формата f для выбора по Accept. Массив FormatJSON отдаётся
+// в любом формате ответа API (см. negotiation.MediaTypes).
+func offers(f Format) []string {
+	if f == FormatJSON {
+		return negotiation.MediaTypes()
+	}
+	return mediaTypes[f]
+}
+
+// MediaTypes - все типы содержимого выгрузки, начиная с типов fallback
+func MediaTypes(fallback Format) []string {
+	// fallback первым, чтобы он выигрывал у остальных форматов, подходящих тому же диапазону
+	out := append([]string(nil), offers(fallback)...)
+	for _, f := range []Format{FormatJSON, FormatNDJSON, FormatCSV, FormatParquet} {
+		if f != fallback {
+			out = append(out, offers(f)...)
+		}
+	}
+	return out
+}
+
+// Negotiate - выбирает формат по заголовку Accept с учётом q. Пустой заголовок, */* и application/*
+// означают fallback. Для FormatJSON возвращается и кодек массива, выбранный так же, как у остальных
+// ответов API, для других форматов кодек nil.
+func Negotiate(accept string, fallback Format) (Format, negotiation.Codec, error) {
+	mediaType, err := negotiation.Negotiate(accept, MediaTypes(fallback))
+	if err != nil {
+		return "", nil, ErrUnsupportedFormat
+	}
+
+	if c, ok := negotiation.Lookup(mediaType); ok {
+		return FormatJSON, c, nil
+	}
+
+	for format, types := range mediaTypes {
+		if slices.Contains(types, mediaType) {
+			return format, nil, nil
+		}
+	}
+
+	return "", nil, ErrUnsupportedFormat
+}
+
+// Encoder - записывает события по одному. Close дописывает окончание формата и должен быть вызван,
+// только если все события записаны успешно.
+type Encoder interface {
+	Encode(event domain.Event) error
+	Close() error
+}
+
+// NewEncoder - кодировщик формата f, пишущий в w. Массив FormatJSON записывается кодеком c,
+// nil означает JSON.
+func NewEncoder(w io.Writer, f Format, c negotiation.Codec) (Encoder, error) {
+	switch f {
+	case FormatJSON:
+		if c != nil && c != negotiation.JSON {
+			return newCodecEncoder(w, c), nil
+		}
+		return newJSONEncoder(w, true), nil
+	case FormatNDJSON:
+		return newJSONEncoder(w, false), nil
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatParquet:
+		return newParquetEncoder(w)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// record - представление события в JSON форматах
+type record struct {
+	SensorID           int64     `json:"sensor_id"`
+	SensorSerialNumber string    `json:"sensor_serial_number"`
+	Timestamp          time.Time `json:"timestamp"`
+	Payload            int64     `json:"payload"`
+	// Channels - значения каналов, если их несколько или значение не целое
+	Channels map[string]domain.Value `json:"channels,omitempty"`
+}
+
+func toRecord(event domain.Event) record {
+	return record{
+		SensorID:           event.SensorID,
+		SensorSerialNumber: event.SensorSerialNumber,
+		Timestamp:          event.Timestamp.UTC(),
+		Payload:            event.Payload,
+		Channels:           event.Channels,
+	}
+}
+
+// channelsJSON - каналы события одной строкой JSON, пустая строка у событий без каналов
+func channelsJSON(event domain.Event) (string, error) {
+	if len(event.Channels) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(event.Channels)
+	return string(data), err
+}
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/negotiation"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+var testEvents = []domain.Event{
+	{SensorID: 1, SensorSerialNumber: "1234567890", Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC), Payload: 10},
+	{SensorID: 2, SensorSerialNumber: "0987654321", Timestamp: time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC), Payload: -3},
+	{
+		SensorID: 2, SensorSerialNumber: "0987654321", Timestamp: time.Date(2024, 1, 2, 3, 4, 7, 0, time.UTC), Payload: 22,
+		Channels: map[string]domain.Value{"value": domain.FloatValue(21.5), "door": domain.BoolValue(true)},
+	},
+}
+
+func encode(t *testing.T, f Format, events []domain.Event) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, f, nil)
+	require.NoError(t, err)
+	for _, event := range events {
+		require.NoError(t, enc.Encode(event))
+	}
+	require.NoError(t, enc.Close())
+
+	return buf.Bytes()
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   Format
+		codec  negotiation.Codec
+	}{
+		{"", FormatJSON, negotiation.JSON},
+		{"*/*", FormatJSON, negotiation.JSON},
+		{"application/json", FormatJSON, negotiation.JSON},
+		{"application/cbor", FormatJSON, negotiation.CBOR},
+		{"application/x-msgpack", FormatJSON, negotiation.MessagePack},
+		{"text/csv", FormatCSV, nil},
+		{"text/csv; charset=utf-8", FormatCSV, nil},
+		{"text/*", FormatCSV, nil},
+		{"application/x-ndjson", FormatNDJSON, nil},
+		{"application/vnd.apache.parquet", FormatParquet, nil},
+		{"application/json;q=0.5, text/csv", FormatCSV, nil},
+		{"application/json, text/csv", FormatJSON, negotiation.JSON},
+		{"text/html, application/x-ndjson;q=0.9, */*;q=0.1", FormatNDJSON, nil},
+		{"text/csv;q=0, application/json", FormatJSON, negotiation.JSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			got, codec, err := Negotiate(tt.accept, FormatJSON)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.codec, codec)
+		})
+	}
+
+	for _, accept := range []string{"text/html", "application/xml, image/*", "text/csv;q=0"} {
+		_, _, err := Negotiate(accept, FormatJSON)
+		assert.ErrorIs(t, err, ErrUnsupportedFormat, accept)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("CSV")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+	assert.Equal(t, "text/csv; charset=utf-8", f.ContentType())
+
+	_, err = ParseFormat("xlsx")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestCSVEncoder(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewReader(encode(t, FormatCSV, testEvents))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"sensor_id", "sensor_serial_number", "timestamp", "payload", "channels"},
+		{"1", "1234567890", "2024-01-02T03:04:05.000006Z", "10", ""},
+		{"2", "0987654321", "2024-01-02T03:04:06Z", "-3", ""},
+		{"2", "0987654321", "2024-01-02T03:04:07Z", "22", `{"door":true,"value":21.5}`},
+	}, rows)
+
+	assert.Equal(t, "sensor_id,sensor_serial_number,timestamp,payload,channels\n", string(encode(t, FormatCSV, nil)))
+}
+
+func TestJSONEncoders(t *testing.T) {
+	t.Run("ndjson", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSpace(string(encode(t, FormatNDJSON, testEvents))), "\n")
+		require.Len(t, lines, 3)
+
+		var r record
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &r))
+		assert.Equal(t, toRecord(testEvents[1]), r)
+		assert.NotContains(t, lines[1], "channels")
+
+		require.NoError(t, json.Unmarshal([]byte(lines[2]), &r))
+		assert.Equal(t, toRecord(testEvents[2]), r)
+	})
+
+	t.Run("json array", func(t *testing.T) {
+		var records []record
+		require.NoError(t, json.Unmarshal(encode(t, FormatJSON, testEvents), &records))
+		assert.Equal(t, []record{toRecord(testEvents[0]), toRecord(testEvents[1]), toRecord(testEvents[2])}, records)
+
+		assert.JSONEq(t, "[]", string(encode(t, FormatJSON, nil)))
+	})
+
+	t.Run("cbor array", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc, err := NewEncoder(&buf, FormatJSON, negotiation.CBOR)
+		require.NoError(t, err)
+		for _, event := range testEvents {
+			require.NoError(t, enc.Encode(event))
+		}
+		require.NoError(t, enc.Close())
+
+		var records []record
+		require.NoError(t, negotiation.CBOR.Unmarshal(buf.Bytes(), &records))
+		assert.Equal(t, []record{toRecord(testEvents[0]), toRecord(testEvents[1]), toRecord(testEvents[2])}, records)
+	})
+}
+
+func TestParquetEncoder(t *testing.T) {
+	data := encode(t, FormatParquet, testEvents)
+	assert.True(t, bytes.HasPrefix(data, []byte("PAR1")))
+
+	file, err := buffer.NewBufferFile(data)
+	require.NoError(t, err)
+
+	pr, err := reader.NewParquetReader(file, new(parquetRecord), 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	require.Equal(t, int64(3), pr.GetNumRows())
+
+	rows := make([]parquetRecord, 3)
+	require.NoError(t, pr.Read(&rows))
+	assert.Equal(t, parquetRecord{
+		SensorID:           1,
+		SensorSerialNumber: "1234567890",
+		Timestamp:          testEvents[0].Timestamp.UnixMicro(),
+		Payload:            10,
+	}, rows[0])
+	assert.Equal(t, int64(-3), rows[1].Payload)
+	require.NotNil(t, rows[2].Channels)
+	assert.JSONEq(t, `{"door":true,"value":21.5}`, *rows[2].Channels)
+}
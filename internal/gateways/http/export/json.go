@@ -0,0 +1,50 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"homework/internal/domain"
+	"io"
+)
+
+// jsonEncoder - JSON массив или NDJSON, по объекту на строку
+type jsonEncoder struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	array bool
+	count int
+}
+
+func newJSONEncoder(w io.Writer, array bool) *jsonEncoder {
+	bw := bufio.NewWriter(w)
+	return &jsonEncoder{w: bw, enc: json.NewEncoder(bw), array: array}
+}
+
+func (e *jsonEncoder) Encode(event domain.Event) error {
+	if e.array {
+		sep := byte(',')
+		if e.count == 0 {
+			sep = '['
+		}
+		if err := e.w.WriteByte(sep); err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	return e.enc.Encode(toRecord(event))
+}
+
+func (e *jsonEncoder) Close() error {
+	if e.array {
+		end := "]\n"
+		if e.count == 0 {
+			end = "[]\n"
+		}
+		if _, err := e.w.WriteString(end); err != nil {
+			return err
+		}
+	}
+
+	return e.w.Flush()
+}
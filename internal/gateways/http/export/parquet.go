@@ -0,0 +1,57 @@
+package export
+
+import (
+	"homework/internal/domain"
+	"io"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetRowGroupSize - сколько байт строк накапливается в памяти перед записью группы строк
+const parquetRowGroupSize = 8 << 20
+
+type parquetRecord struct {
+	SensorID           int64  `parquet:"name=sensor_id, type=INT64"`
+	SensorSerialNumber string `parquet:"name=sensor_serial_number, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Timestamp          int64  `parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	Payload            int64  `parquet:"name=payload, type=INT64"`
+	// Channels - каналы события в JSON, пусто у событий без каналов
+	Channels *string `parquet:"name=channels, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+}
+
+type parquetEncoder struct {
+	w *writer.ParquetWriter
+}
+
+func newParquetEncoder(w io.Writer) (*parquetEncoder, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetRecord), 1)
+	if err != nil {
+		return nil, err
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	return &parquetEncoder{w: pw}, nil
+}
+
+func (e *parquetEncoder) Encode(event domain.Event) error {
+	record := parquetRecord{
+		SensorID:           event.SensorID,
+		SensorSerialNumber: event.SensorSerialNumber,
+		Timestamp:          event.Timestamp.UnixMicro(),
+		Payload:            event.Payload,
+	}
+	channels, err := channelsJSON(event)
+	if err != nil {
+		return err
+	}
+	if channels != "" {
+		record.Channels = &channels
+	}
+	return e.w.Write(record)
+}
+
+func (e *parquetEncoder) Close() error {
+	return e.w.WriteStop()
+}
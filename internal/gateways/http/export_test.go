@@ -0,0 +1,184 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/gateways/http/negotiation"
+	"homework/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryExport(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC},
+		domain.Sensor{SerialNumber: "1111111111", Type: domain.SensorTypeADC},
+	)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, sn := range []string{"1234567890", "1111111111", "1234567890"} {
+		require.NoError(t, uc.Event.ReceiveEvent(context.Background(), &domain.Event{
+			SensorSerialNumber: sn,
+			Payload:            int64(i),
+			Timestamp:          start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	const period = "start_date=2024-01-01T00:00:00Z&end_date=2024-01-02T00:00:00Z"
+
+	get := func(t *testing.T, url, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, history csv", func(t *testing.T) {
+		w := get(t, "/sensors/1/history?"+period, "text/csv")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="sensor-1-history.csv"`)
+
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"sensor_id", "sensor_serial_number", "timestamp", "payload", "channels"},
+			{"1", "1234567890", "2024-01-01T00:00:00Z", "0", ""},
+			{"1", "1234567890", "2024-01-01T00:02:00Z", "2", ""},
+		}, rows)
+	})
+
+	t.Run("ok, history format parameter overrides accept", func(t *testing.T) {
+		w := get(t, "/sensors/1/history?format=parquet&"+period, "application/json")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/vnd.apache.parquet", w.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(w.Body.String(), "PAR1"))
+	})
+
+	t.Run("ok, history json is unchanged", func(t *testing.T) {
+		w := get(t, "/sensors/1/history?"+period, "application/json")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[{"timestamp":"2024-01-01T00:00:00Z","payload":0},{"timestamp":"2024-01-01T00:02:00Z","payload":2}]`,
+			w.Body.String())
+	})
+
+	t.Run("ok, history in cbor and msgpack", func(t *testing.T) {
+		for _, c := range []negotiation.Codec{negotiation.CBOR, negotiation.MessagePack} {
+			w := get(t, "/sensors/1/history?"+period, c.ContentType())
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, c.ContentType(), w.Header().Get("Content-Type"))
+
+			var statuses []models.SensorStatus
+			require.NoError(t, c.Unmarshal(w.Body.Bytes(), &statuses))
+			require.Len(t, statuses, 2)
+			assert.Equal(t, int64(2), statuses[1].Payload)
+			assert.True(t, start.Add(2*time.Minute).Equal(statuses[1].Timestamp))
+		}
+	})
+
+	t.Run("err, history not acceptable", func(t *testing.T) {
+		w := get(t, "/sensors/1/history?"+period, "application/xml")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("err, history of unknown sensor", func(t *testing.T) {
+		w := get(t, "/sensors/100/history?"+period, "application/x-ndjson")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("ok, bulk export ndjson by default", func(t *testing.T) {
+		w := get(t, "/events/export?sensor_id=2&sensor_id=1&"+period, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		var lines []string
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		require.Len(t, lines, 3)
+		assert.JSONEq(t, `{"sensor_id":1,"sensor_serial_number":"1234567890","timestamp":"2024-01-01T00:00:00Z","payload":0}`, lines[0])
+		assert.JSONEq(t, `{"sensor_id":2,"sensor_serial_number":"1111111111","timestamp":"2024-01-01T00:01:00Z","payload":1}`, lines[2])
+	})
+
+	t.Run("ok, bulk export in msgpack", func(t *testing.T) {
+		w := get(t, "/events/export?sensor_id=1&"+period, negotiation.MediaTypeMessagePack)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, negotiation.MediaTypeMessagePack, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="events.msgpack"`)
+
+		var records []map[string]any
+		require.NoError(t, negotiation.MessagePack.Unmarshal(w.Body.Bytes(), &records))
+		require.Len(t, records, 2)
+		assert.Equal(t, "1234567890", records[0]["sensor_serial_number"])
+	})
+
+	t.Run("ok, bulk export of all sensors", func(t *testing.T) {
+		w := get(t, "/events/export?format=csv&"+period, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, rows, 4)
+	})
+
+	t.Run("err, bulk export validation", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, get(t, "/events/export?sensor_id=abc&"+period, "").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, get(t, "/events/export?sensor_id=1", "").Code)
+		assert.Equal(t, http.StatusNotFound, get(t, "/events/export?sensor_id=1,100&"+period, "").Code)
+		assert.Equal(t, http.StatusNotAcceptable, get(t, "/events/export?format=xlsx&"+period, "").Code)
+	})
+}
+
+func TestExportInterrupted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Return(&domain.Sensor{ID: 1}, nil)
+	erMock := usecase.NewMockEventRepository(ctrl)
+	erMock.EXPECT().IterateEvents(gomock.Any(), []int64{1}, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ []int64, _, _ time.Time, fn func(domain.Event) error) error {
+			if err := fn(domain.Event{SensorID: 1, Timestamp: time.Now()}); err != nil {
+				return err
+			}
+			return errors.New("connection lost")
+		})
+
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock),
+		Sensor: usecase.NewSensor(srMock),
+	}
+	engine := gin.New()
+	setupRouter(engine, uc, NewWebSocketHandler(uc))
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events/export?sensor_id=1&format=csv&start_date=2024-01-01T00:00:00Z&end_date=2024-01-02T00:00:00Z")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// трейлеры доступны только после чтения тела
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "connection lost", resp.Trailer.Get("X-Export-Error"))
+}
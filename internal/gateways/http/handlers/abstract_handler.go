@@ -1,7 +1,9 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"homework/internal/gateways/http/middleware"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -14,14 +16,57 @@ type Handler interface {
 	SetupRouterGroup(r *gin.Engine)
 }
 
+// WriteHeaders - заголовки ответа на HEAD: длина тела, которое вернул бы GET в выбранном формате.
+// Длину нельзя узнать, не закодировав тело, поэтому HEAD стоит столько же, сколько GET, кроме
+// отправки тела. Дешёвый ответ без тела - 304 на запрос с валидаторами, он отдаётся до кодирования.
 func WriteHeaders(ctx *gin.Context, source any) {
-	data, err := json.Marshal(source)
+	c := middleware.ResponseCodec(ctx)
+	data, err := c.Marshal(source)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to marshal sensor data"})
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to marshal sensor data"})
 		return
 	}
 
+	ctx.Header("Content-Type", c.ContentType())
 	ctx.Header("Content-Length", strconv.Itoa(len(data)))
 
 	ctx.Status(http.StatusOK)
 }
+
+// render - ответ в формате, выбранном по заголовку Accept
+func render(ctx *gin.Context, code int, obj any) {
+	c := middleware.ResponseCodec(ctx)
+	data, err := c.Marshal(obj)
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Data(code, c.ContentType(), data)
+}
+
+// maxBodySize - ограничение тела запроса, которое разбирает bind
+const maxBodySize = 1 << 20
+
+// bind - разбирает тело запроса в формате из заголовка Content-Type.
+// Тело больше maxBodySize не читается дальше предела, ошибка - *http.MaxBytesError.
+func bind(ctx *gin.Context, obj any) error {
+	data, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	return middleware.RequestCodec(ctx).Unmarshal(data, obj)
+}
+
+// renderBindError - ответ на ошибку bind: 413 для слишком большого тела, иначе 400
+func renderBindError(ctx *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		render(ctx, http.StatusRequestEntityTooLarge, gin.H{
+			"reason": "Request body exceeds " + strconv.FormatInt(tooLarge.Limit, 10) + " bytes",
+		})
+		return
+	}
+	render(ctx, http.StatusBadRequest, gin.H{"reason": "Error in the format of the request body"})
+}
@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertRulesHandler - список правил тревог и создание правила
+type AlertRulesHandler struct {
+	uc *usecase.Alert
+}
+
+func NewAlertRulesHandler(uc *usecase.Alert) *AlertRulesHandler {
+	return &AlertRulesHandler{uc: uc}
+}
+
+func (h *AlertRulesHandler) GetPath() string {
+	return "/alert-rules"
+}
+
+func (h *AlertRulesHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPost}
+}
+
+func (h *AlertRulesHandler) SetupRouterGroup(r *gin.Engine) {
+	rulesGroup := r.Group(h.GetPath())
+	{
+		rulesGroup.OPTIONS("", h.rulesOptions)
+		rulesGroup.GET("", middleware.AcceptValidator(), h.getRules)
+		rulesGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.createRule)
+	}
+}
+
+// parsePositiveParam - положительное целое из параметра пути name, при ошибке отвечает 422 и возвращает false
+func parsePositiveParam(ctx *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param(name), 10, 64)
+	if err != nil || id < 1 {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameter " + name + " must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// bindAlertRule - правило тревоги из тела запроса, при ошибке отвечает 400 или 422 и возвращает false
+func bindAlertRule(ctx *gin.Context) (*domain.AlertRule, bool) {
+	v := &models.AlertRuleToSave{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return nil, false
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return nil, false
+	}
+
+	return &domain.AlertRule{
+		SensorID:   *v.SensorID,
+		Name:       *v.Name,
+		Kind:       domain.AlertRuleKind(*v.Kind),
+		Channel:    v.Channel,
+		Operator:   domain.AlertOperator(v.Operator),
+		Threshold:  v.Threshold,
+		StaleAfter: time.Duration(v.StaleAfter) * time.Second,
+	}, true
+}
+
+// renderAlertError - ответ на ошибку usecase.Alert
+func renderAlertError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, usecase.ErrAlertRuleNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Alert rule not found"})
+	case errors.Is(err, usecase.ErrAlertNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Alert not found"})
+	case errors.Is(err, usecase.ErrUserNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "User not found"})
+	case errors.Is(err, usecase.ErrSensorNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Sensor not found"})
+	case errors.Is(err, usecase.ErrAlertStatusConflict):
+		render(ctx, http.StatusConflict, gin.H{"reason": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidAlertRule), errors.Is(err, usecase.ErrInvalidAlertQuery):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+	default:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to "+action, logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to " + action})
+	}
+}
+
+func (h *AlertRulesHandler) getRules(ctx *gin.Context) {
+	var sensorID int64
+	if s := ctx.Query("sensor_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || id < 1 {
+			render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameter sensor_id must be a positive integer"})
+			return
+		}
+		sensorID = id
+	}
+
+	rules, err := h.uc.GetAlertRules(ctx, sensorID)
+	if err != nil {
+		renderAlertError(ctx, err, "retrieve alert rules")
+		return
+	}
+
+	out := make([]models.AlertRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, models.NewAlertRule(r))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *AlertRulesHandler) createRule(ctx *gin.Context) {
+	rule, ok := bindAlertRule(ctx)
+	if !ok {
+		return
+	}
+
+	rule, err := h.uc.CreateAlertRule(ctx, rule)
+	if err != nil {
+		renderAlertError(ctx, err, "create alert rule")
+		return
+	}
+
+	ctx.Header("Location", "/alert-rules/"+strconv.FormatInt(rule.ID, 10))
+	render(ctx, http.StatusCreated, models.NewAlertRule(*rule))
+}
+
+func (h *AlertRulesHandler) rulesOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// AlertRuleHandler - получение, замена и удаление правила тревоги
+type AlertRuleHandler struct {
+	uc *usecase.Alert
+}
+
+func NewAlertRuleHandler(uc *usecase.Alert) *AlertRuleHandler {
+	return &AlertRuleHandler{uc: uc}
+}
+
+func (h *AlertRuleHandler) GetPath() string {
+	return "/alert-rules/:alert_rule_id"
+}
+
+func (h *AlertRuleHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete}
+}
+
+func (h *AlertRuleHandler) SetupRouterGroup(r *gin.Engine) {
+	ruleGroup := r.Group(h.GetPath())
+	{
+		ruleGroup.OPTIONS("", h.ruleOptions)
+		ruleGroup.GET("", middleware.AcceptValidator(), h.getRule)
+		ruleGroup.PUT("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.updateRule)
+		ruleGroup.DELETE("", h.deleteRule)
+	}
+}
+
+func (h *AlertRuleHandler) getRule(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "alert_rule_id")
+	if !ok {
+		return
+	}
+
+	rule, err := h.uc.GetAlertRule(ctx, id)
+	if err != nil {
+		renderAlertError(ctx, err, "retrieve alert rule")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewAlertRule(*rule))
+}
+
+func (h *AlertRuleHandler) updateRule(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "alert_rule_id")
+	if !ok {
+		return
+	}
+
+	rule, ok := bindAlertRule(ctx)
+	if !ok {
+		return
+	}
+
+	rule, err := h.uc.UpdateAlertRule(ctx, id, rule)
+	if err != nil {
+		renderAlertError(ctx, err, "update alert rule")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewAlertRule(*rule))
+}
+
+func (h *AlertRuleHandler) deleteRule(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "alert_rule_id")
+	if !ok {
+		return
+	}
+
+	if err := h.uc.DeleteAlertRule(ctx, id); err != nil {
+		renderAlertError(ctx, err, "delete alert rule")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AlertRuleHandler) ruleOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// UserAlertsHandler - тревоги датчиков пользователя
+type UserAlertsHandler struct {
+	uc *usecase.Alert
+}
+
+func NewUserAlertsHandler(uc *usecase.Alert) *UserAlertsHandler {
+	return &UserAlertsHandler{uc: uc}
+}
+
+func (h *UserAlertsHandler) GetPath() string {
+	return "/users/:user_id/alerts"
+}
+
+func (h *UserAlertsHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet}
+}
+
+func (h *UserAlertsHandler) SetupRouterGroup(r *gin.Engine) {
+	alertsGroup := r.Group(h.GetPath())
+	{
+		alertsGroup.OPTIONS("", h.alertsOptions)
+		alertsGroup.GET("", middleware.AcceptValidator(), h.getAlerts)
+	}
+}
+
+func (h *UserAlertsHandler) getAlerts(ctx *gin.Context) {
+	userID, ok := parsePositiveParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	var limit int
+	if s := ctx.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameter limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	var statuses []domain.AlertStatus
+	for _, s := range ctx.QueryArray("status") {
+		statuses = append(statuses, domain.AlertStatus(s))
+	}
+
+	alerts, err := h.uc.GetUserAlerts(ctx, userID, statuses, limit)
+	if err != nil {
+		renderAlertError(ctx, err, "retrieve alerts")
+		return
+	}
+
+	out := make([]models.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		out = append(out, models.NewAlert(a))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *UserAlertsHandler) alertsOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// UserAlertHandler - тревога пользователя
+type UserAlertHandler struct {
+	uc *usecase.Alert
+}
+
+func NewUserAlertHandler(uc *usecase.Alert) *UserAlertHandler {
+	return &UserAlertHandler{uc: uc}
+}
+
+func (h *UserAlertHandler) GetPath() string {
+	return "/users/:user_id/alerts/:alert_id"
+}
+
+func (h *UserAlertHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet}
+}
+
+func (h *UserAlertHandler) SetupRouterGroup(r *gin.Engine) {
+	alertGroup := r.Group(h.GetPath())
+	{
+		alertGroup.OPTIONS("", h.alertOptions)
+		alertGroup.GET("", middleware.AcceptValidator(), h.getAlert)
+	}
+}
+
+// parseUserAlertIDs - ID пользователя и тревоги из пути, при ошибке отвечает 422 и возвращает false
+func parseUserAlertIDs(ctx *gin.Context) (int64, int64, bool) {
+	userID, ok := parsePositiveParam(ctx, "user_id")
+	if !ok {
+		return 0, 0, false
+	}
+	id, ok := parsePositiveParam(ctx, "alert_id")
+	if !ok {
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func (h *UserAlertHandler) getAlert(ctx *gin.Context) {
+	userID, id, ok := parseUserAlertIDs(ctx)
+	if !ok {
+		return
+	}
+
+	alert, err := h.uc.GetUserAlert(ctx, userID, id)
+	if err != nil {
+		renderAlertError(ctx, err, "retrieve alert")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewAlert(*alert))
+}
+
+func (h *UserAlertHandler) alertOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// UserAlertActionHandler - перевод тревоги пользователя в другое состояние: acknowledge или resolve
+type UserAlertActionHandler struct {
+	uc     *usecase.Alert
+	action string
+	apply  func(*usecase.Alert, *gin.Context, int64, int64) (*domain.Alert, error)
+}
+
+// NewUserAlertAcknowledgeHandler - принятие тревоги в работу
+func NewUserAlertAcknowledgeHandler(uc *usecase.Alert) *UserAlertActionHandler {
+	return &UserAlertActionHandler{
+		uc:     uc,
+		action: "acknowledge",
+		apply: func(uc *usecase.Alert, ctx *gin.Context, userID, id int64) (*domain.Alert, error) {
+			return uc.AcknowledgeAlert(ctx, userID, id)
+		},
+	}
+}
+
+// NewUserAlertResolveHandler - закрытие тревоги
+func NewUserAlertResolveHandler(uc *usecase.Alert) *UserAlertActionHandler {
+	return &UserAlertActionHandler{
+		uc:     uc,
+		action: "resolve",
+		apply: func(uc *usecase.Alert, ctx *gin.Context, userID, id int64) (*domain.Alert, error) {
+			return uc.ResolveAlert(ctx, userID, id)
+		},
+	}
+}
+
+func (h *UserAlertActionHandler) GetPath() string {
+	return "/users/:user_id/alerts/:alert_id/" + h.action
+}
+
+func (h *UserAlertActionHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodPost}
+}
+
+func (h *UserAlertActionHandler) SetupRouterGroup(r *gin.Engine) {
+	actionGroup := r.Group(h.GetPath())
+	{
+		actionGroup.OPTIONS("", h.actionOptions)
+		actionGroup.POST("", middleware.AcceptValidator(), h.applyAction)
+	}
+}
+
+func (h *UserAlertActionHandler) applyAction(ctx *gin.Context) {
+	userID, id, ok := parseUserAlertIDs(ctx)
+	if !ok {
+		return
+	}
+
+	alert, err := h.apply(h.uc, ctx, userID, id)
+	if err != nil {
+		renderAlertError(ctx, err, h.action+" alert")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewAlert(*alert))
+}
+
+func (h *UserAlertActionHandler) actionOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
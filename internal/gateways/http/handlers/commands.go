@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPickupWait - наибольшее время, которое устройство может ждать команды в одном запросе
+const maxPickupWait = time.Minute
+
+// CommandsHandler - постановка команд в очередь устройства и список его команд
+type CommandsHandler struct {
+	uc *usecase.Command
+}
+
+func NewCommandsHandler(uc *usecase.Command) *CommandsHandler {
+	return &CommandsHandler{uc: uc}
+}
+
+func (h *CommandsHandler) GetPath() string {
+	return "/devices/:device_id/commands"
+}
+
+func (h *CommandsHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPost}
+}
+
+func (h *CommandsHandler) SetupRouterGroup(r *gin.Engine) {
+	commandsGroup := r.Group(h.GetPath())
+	{
+		commandsGroup.OPTIONS("", h.commandsOptions)
+		commandsGroup.GET("", middleware.AcceptValidator(), h.getCommands)
+		commandsGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.createCommand)
+	}
+}
+
+// parseDeviceID - ID устройства из пути, при ошибке отвечает 422 и возвращает false
+func parseDeviceID(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("device_id"), 10, 64)
+	if err != nil || id < 1 {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameter device_id must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// renderCommandError - ответ на ошибку usecase.Command
+func renderCommandError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, usecase.ErrSensorNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Device not found"})
+	case errors.Is(err, usecase.ErrCommandNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Command not found"})
+	case errors.Is(err, usecase.ErrNotActuator), errors.Is(err, usecase.ErrInvalidCommand):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+	default:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to "+action, logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to " + action})
+	}
+}
+
+func toCommandModels(commands []domain.Command) []models.Command {
+	out := make([]models.Command, 0, len(commands))
+	for _, c := range commands {
+		out = append(out, models.NewCommand(c))
+	}
+	return out
+}
+
+// getCommands - команды устройства, параметр status (можно несколько раз) оставляет команды в этих состояниях
+func (h *CommandsHandler) getCommands(ctx *gin.Context) {
+	id, ok := parseDeviceID(ctx)
+	if !ok {
+		return
+	}
+
+	var statuses []domain.CommandStatus
+	for _, s := range ctx.QueryArray("status") {
+		status := domain.CommandStatus(s)
+		switch status {
+		case domain.CommandPending, domain.CommandDelivered, domain.CommandConfirmed, domain.CommandExpired:
+			statuses = append(statuses, status)
+		default:
+			render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Unknown command status " + strconv.Quote(s)})
+			return
+		}
+	}
+
+	commands, err := h.uc.GetCommands(ctx, id, statuses)
+	if err != nil {
+		renderCommandError(ctx, err, "retrieve commands")
+		return
+	}
+
+	render(ctx, http.StatusOK, toCommandModels(commands))
+}
+
+func (h *CommandsHandler) createCommand(ctx *gin.Context) {
+	id, ok := parseDeviceID(ctx)
+	if !ok {
+		return
+	}
+
+	v := &models.CommandToCreate{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return
+	}
+
+	command, err := h.uc.SendCommand(ctx, id, &domain.Command{
+		Kind:     domain.CommandKind(*v.Kind),
+		State:    v.State,
+		Duration: time.Duration(v.DurationMs) * time.Millisecond,
+	}, time.Duration(v.TTL)*time.Second)
+	if err != nil {
+		renderCommandError(ctx, err, "send command")
+		return
+	}
+
+	ctx.Header("Location", "/devices/"+strconv.FormatInt(id, 10)+"/commands/"+strconv.FormatInt(command.ID, 10))
+	render(ctx, http.StatusCreated, models.NewCommand(*command))
+}
+
+func (h *CommandsHandler) commandsOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// CommandHandler - состояние команды
+type CommandHandler struct {
+	uc *usecase.Command
+}
+
+func NewCommandHandler(uc *usecase.Command) *CommandHandler {
+	return &CommandHandler{uc: uc}
+}
+
+func (h *CommandHandler) GetPath() string {
+	return "/devices/:device_id/commands/:command_id"
+}
+
+func (h *CommandHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet}
+}
+
+func (h *CommandHandler) SetupRouterGroup(r *gin.Engine) {
+	commandGroup := r.Group(h.GetPath())
+	{
+		commandGroup.OPTIONS("", h.commandOptions)
+		commandGroup.GET("", middleware.AcceptValidator(), h.getCommand)
+	}
+}
+
+func (h *CommandHandler) getCommand(ctx *gin.Context) {
+	deviceID, ok := parseDeviceID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("command_id"), 10, 64)
+	if err != nil || id < 1 {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameter command_id must be a positive integer"})
+		return
+	}
+
+	command, err := h.uc.GetCommand(ctx, deviceID, id)
+	if err != nil {
+		renderCommandError(ctx, err, "retrieve command")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewCommand(*command))
+}
+
+func (h *CommandHandler) commandOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// CommandPickupHandler - устройство забирает команды из очереди долгим опросом
+type CommandPickupHandler struct {
+	uc *usecase.Command
+}
+
+func NewCommandPickupHandler(uc *usecase.Command) *CommandPickupHandler {
+	return &CommandPickupHandler{uc: uc}
+}
+
+func (h *CommandPickupHandler) GetPath() string {
+	return "/devices/:device_id/commands/pickup"
+}
+
+func (h *CommandPickupHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodPost}
+}
+
+func (h *CommandPickupHandler) SetupRouterGroup(r *gin.Engine) {
+	pickupGroup := r.Group(h.GetPath())
+	{
+		pickupGroup.OPTIONS("", h.pickupOptions)
+		pickupGroup.POST("", middleware.AcceptValidator(), h.pickupCommands)
+	}
+}
+
+// pickupCommands - отдаёт ожидающие команды и помечает их доставленными. Если команд нет,
+// ждёт их до wait секунд (не больше maxPickupWait) и отвечает пустым списком.
+func (h *CommandPickupHandler) pickupCommands(ctx *gin.Context) {
+	id, ok := parseDeviceID(ctx)
+	if !ok {
+		return
+	}
+
+	var wait time.Duration
+	if s := ctx.Query("wait"); s != "" {
+		seconds, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxPickupWait {
+			render(ctx, http.StatusUnprocessableEntity, gin.H{
+				"reason": "Query parameter wait must be between 0 and " + strconv.Itoa(int(maxPickupWait/time.Second)) + " seconds",
+			})
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	commands, err := h.uc.ReceiveCommands(ctx, id, wait)
+	if err != nil && ctx.Request.Context().Err() != nil {
+		// устройство отключилось, не дождавшись команд
+		return
+	}
+	if err != nil {
+		renderCommandError(ctx, err, "pick up commands")
+		return
+	}
+
+	render(ctx, http.StatusOK, toCommandModels(commands))
+}
+
+func (h *CommandPickupHandler) pickupOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
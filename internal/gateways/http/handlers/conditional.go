@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// representation - формат тела в ETag: у JSON, CBOR и MessagePack представлений одного датчика
+// разные байты, значит и сильные ETag должны различаться
+func representation(ctx *gin.Context) string {
+	contentType, _, _ := strings.Cut(middleware.ResponseCodec(ctx).ContentType(), ";")
+	_, subtype, _ := strings.Cut(contentType, "/")
+	return subtype
+}
+
+// sensorETag - сильный ETag датчика: ID, версия и формат тела
+func sensorETag(ctx *gin.Context, sensor domain.Sensor) string {
+	return fmt.Sprintf(`"%d-%d-%s"`, sensor.ID, sensor.Version, representation(ctx))
+}
+
+// sensorsETag - сильный ETag списка датчиков: хеш ID и версий в порядке выдачи
+func sensorsETag(ctx *gin.Context, sensors []domain.Sensor) string {
+	h := fnv.New64a()
+	for _, s := range sensors {
+		_, _ = fmt.Fprintf(h, "%d-%d,", s.ID, s.Version)
+	}
+	return fmt.Sprintf(`"%x-%s"`, h.Sum64(), representation(ctx))
+}
+
+// parseSensorETag - версия датчика id из ETag, выданного sensorETag для текущего формата тела
+func parseSensorETag(ctx *gin.Context, id int64, etag string) (int64, bool) {
+	value, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return 0, false
+	}
+	value, ok = strings.CutSuffix(value, `"`)
+	if !ok {
+		return 0, false
+	}
+
+	parts := strings.Split(value, "-")
+	if len(parts) != 3 || parts[0] != strconv.FormatInt(id, 10) || parts[2] != representation(ctx) {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// lastModified - время последнего изменения датчика, которое видно клиенту
+func lastModified(sensor domain.Sensor) time.Time {
+	if sensor.LastActivity.After(sensor.RegisteredAt) {
+		return sensor.LastActivity
+	}
+	return sensor.RegisteredAt
+}
+
+// etags - значения заголовка If-Match или If-None-Match
+func etags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// writeValidators - заголовки, по которым клиент сможет сделать условный запрос
+func writeValidators(ctx *gin.Context, etag string, modified time.Time) {
+	ctx.Header("ETag", etag)
+	ctx.Header("Vary", "Accept")
+	if !modified.IsZero() {
+		ctx.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified - пишет валидаторы и проверяет If-None-Match, а без него If-Modified-Since.
+// Если у клиента актуальное представление, отвечает 304 и возвращает true.
+func notModified(ctx *gin.Context, etag string, modified time.Time) bool {
+	writeValidators(ctx, etag, modified)
+
+	if header := ctx.GetHeader("If-None-Match"); header != "" {
+		for _, tag := range etags(header) {
+			// If-None-Match сравнивает теги слабо
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				ctx.Status(http.StatusNotModified)
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := ctx.GetHeader("If-Modified-Since"); header != "" && !modified.IsZero() {
+		since, err := http.ParseTime(header)
+		if err == nil && !modified.Truncate(time.Second).After(since) {
+			ctx.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
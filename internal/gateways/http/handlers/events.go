@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"homework/internal/domain"
 	"homework/internal/gateways/http/middleware"
 	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
 	"homework/internal/usecase"
 	"net/http"
 	"strings"
@@ -26,7 +28,7 @@ func (h *EventsHandler) SetupRouterGroup(r *gin.Engine) {
 	eventsGroup := r.Group(h.GetPath())
 	{
 		eventsGroup.OPTIONS("", h.eventsOptions)
-		eventsGroup.POST("", middleware.ContentTypeJSONValidator(), h.registerEvent)
+		eventsGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.registerEvent)
 	}
 }
 
@@ -40,7 +42,7 @@ func (h *EventsHandler) GetPath() string {
 
 func toEventModel(event domain.Event) *models.SensorEvent {
 	v := &models.SensorEvent{
-		Payload:            &event.Payload,
+		Payload:            models.NewEventPayload(event),
 		SensorSerialNumber: &event.SensorSerialNumber,
 	}
 
@@ -49,29 +51,39 @@ func toEventModel(event domain.Event) *models.SensorEvent {
 
 func (h *EventsHandler) registerEvent(ctx *gin.Context) {
 	v := &models.SensorEvent{}
-	if err := ctx.ShouldBindJSON(v); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"reason": "Error in the JSON format of the request body"})
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
 		return
 	}
 
 	if err := v.Validate(nil); err != nil {
 		reason := err.Error()
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "JSON body validation error: " + reason})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + reason})
 		return
 	}
 
 	event := domain.Event{
 		SensorSerialNumber: *v.SensorSerialNumber,
-		Payload:            *v.Payload,
 	}
+	v.Payload.Apply(&event)
 	event.Timestamp = time.Now()
 	err := h.uc.ReceiveEvent(ctx, &event)
+	if errors.Is(err, usecase.ErrPayloadOutOfRange) || errors.Is(err, usecase.ErrInvalidEventChannels) {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Payload validation error: " + err.Error()})
+		return
+	}
+	if errors.Is(err, usecase.ErrVirtualSensor) {
+		render(ctx, http.StatusConflict, gin.H{"reason": "Sensor error: " + err.Error()})
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to process event"})
+		logging.FromContext(ctx).WarnContext(ctx, "unable to process event",
+			logging.SerialNumber(event.SensorSerialNumber), logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to process event"})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, toEventModel(event))
+	render(ctx, http.StatusCreated, toEventModel(event))
 }
 
 func (h *EventsHandler) eventsOptions(ctx *gin.Context) {
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/export"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/gateways/http/negotiation"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportErrorTrailer - трейлер, в котором сообщается об ошибке, случившейся после начала выгрузки
+const exportErrorTrailer = "X-Export-Error"
+
+// EventsExportHandler - выгрузка событий нескольких датчиков за период
+type EventsExportHandler struct {
+	uc *usecase.Event
+}
+
+func NewEventsExportHandler(uc *usecase.Event) *EventsExportHandler {
+	return &EventsExportHandler{uc: uc}
+}
+
+func (h *EventsExportHandler) GetPath() string {
+	return "/events/export"
+}
+
+func (h *EventsExportHandler) GetAvailableMethods() []string {
+	return []string{http.MethodGet, http.MethodOptions}
+}
+
+func (h *EventsExportHandler) SetupRouterGroup(r *gin.Engine) {
+	exportGroup := r.Group(h.GetPath())
+	{
+		exportGroup.OPTIONS("", h.exportOptions)
+		exportGroup.GET("", h.exportEvents)
+	}
+}
+
+func (h *EventsExportHandler) exportEvents(ctx *gin.Context) {
+	format, codec, ok := negotiateExportFormat(ctx, export.FormatNDJSON)
+	if !ok {
+		return
+	}
+
+	t := &models.TimeFraneQuery{}
+	if err := ctx.ShouldBindQuery(t); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the query parameters of the request"})
+		return
+	}
+	if err := t.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
+		return
+	}
+
+	ids, err := parseSensorIDs(ctx.QueryArray("sensor_id"))
+	if err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
+		return
+	}
+
+	writeExport(ctx, format, codec, "events", func(fn func(domain.Event) error) error {
+		return h.uc.ExportEvents(ctx, ids, *t.Start, *t.End, fn)
+	})
+}
+
+func (h *EventsExportHandler) exportOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// parseSensorIDs - идентификаторы датчиков из повторяющегося параметра или списка через запятую
+func parseSensorIDs(values []string) ([]int64, error) {
+	var ids []int64
+	for _, value := range values {
+		for _, s := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("invalid sensor_id %q", s)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// negotiateExportFormat - формат из параметра format или заголовка Accept. Массив FormatJSON
+// отдаётся в любом формате ответа API, его кодек становится форматом ответа обработчика.
+// Если подходящего формата нет, отвечает 406 и возвращает false.
+func negotiateExportFormat(ctx *gin.Context, fallback export.Format) (export.Format, negotiation.Codec, bool) {
+	var (
+		format export.Format
+		codec  negotiation.Codec
+		err    error
+	)
+	if name := ctx.Query("format"); name != "" {
+		format, err = export.ParseFormat(name)
+		if format == export.FormatJSON {
+			codec = negotiation.JSON
+		}
+	} else {
+		format, codec, err = export.Negotiate(ctx.GetHeader("Accept"), fallback)
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
+			"reason": "Content type is not supported, expected one of " + strings.Join(export.MediaTypes(fallback), ", "),
+		})
+		return "", nil, false
+	}
+
+	if codec != nil {
+		middleware.SetResponseCodec(ctx, codec)
+	}
+
+	return format, codec, true
+}
+
+// writeExport - отдаёт события из iterate в формате format (массив FormatJSON - кодеком codec),
+// не накапливая их в памяти, кроме массивов в двоичных форматах.
+// Ответ начинается с первым событием, поэтому ошибка до него (например, датчик не найден)
+// возвращается обычным ответом с кодом ошибки. Ошибка после начала выгрузки обрывает её
+// и передаётся в трейлере X-Export-Error.
+func writeExport(
+	ctx *gin.Context,
+	format export.Format,
+	codec negotiation.Codec,
+	filename string,
+	iterate func(fn func(domain.Event) error) error,
+) {
+	var enc export.Encoder
+
+	start := func() error {
+		if enc != nil {
+			return nil
+		}
+
+		contentType, extension := format.ContentType(), format.Extension()
+		if codec != nil && codec != negotiation.JSON {
+			contentType, extension = codec.ContentType(), export.CodecExtension(codec)
+		}
+
+		ctx.Header("Content-Type", contentType)
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s%s"`, filename, extension))
+		ctx.Header("Trailer", exportErrorTrailer)
+		ctx.Status(http.StatusOK)
+
+		var err error
+		enc, err = export.NewEncoder(ctx.Writer, format, codec)
+		return err
+	}
+
+	err := iterate(func(event domain.Event) error {
+		if err := start(); err != nil {
+			return err
+		}
+		return enc.Encode(event)
+	})
+	if err == nil {
+		if err = start(); err == nil {
+			err = enc.Close()
+		}
+	}
+	if err == nil {
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+
+	if enc == nil {
+		if errors.Is(err, usecase.ErrSensorNotFound) {
+			render(ctx, http.StatusNotFound, gin.H{"reason": err.Error()})
+			return
+		}
+		logger.WarnContext(ctx, "unable to export events", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to export events"})
+		return
+	}
+
+	logger.WarnContext(ctx, "events export interrupted", logging.Error(err))
+	ctx.Writer.Header().Set(exportErrorTrailer, err.Error())
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/importer"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportsHandler - загрузка датчиков и событий из файлов CSV и NDJSON
+type ImportsHandler struct {
+	uc *usecase.Import
+}
+
+func NewImportsHandler(uc *usecase.Import) *ImportsHandler {
+	return &ImportsHandler{uc: uc}
+}
+
+func (h *ImportsHandler) GetPath() string {
+	return "/imports"
+}
+
+func (h *ImportsHandler) GetAvailableMethods() []string {
+	return []string{http.MethodPost, http.MethodOptions}
+}
+
+func (h *ImportsHandler) SetupRouterGroup(r *gin.Engine) {
+	importsGroup := r.Group(h.GetPath())
+	{
+		importsGroup.OPTIONS("", h.importsOptions)
+		importsGroup.POST("", middleware.AcceptValidator(), h.createImport)
+	}
+}
+
+// createImport - загружает тело запроса. Ошибки в строках попадают в ответ и не прерывают загрузку.
+// Если загрузка прервалась, её можно продолжить, отправив тот же файл с id из ответа.
+func (h *ImportsHandler) createImport(ctx *gin.Context) {
+	kind := domain.ImportKind(ctx.Query("kind"))
+	if kind != domain.ImportKindSensors && kind != domain.ImportKindEvents {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameter kind must be sensors or events"})
+		return
+	}
+
+	format, err := importFormat(ctx)
+	if err != nil {
+		render(ctx, http.StatusUnsupportedMediaType, gin.H{"reason": "Import file must be CSV or NDJSON"})
+		return
+	}
+
+	body := io.Reader(ctx.Request.Body)
+	if ctx.GetHeader("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			render(ctx, http.StatusBadRequest, gin.H{"reason": "Invalid gzip request body"})
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	src, err := importer.NewReader(body, format, kind)
+	if err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+		return
+	}
+
+	imp, err := h.uc.Run(ctx, ctx.Query("id"), kind, src)
+	switch {
+	case err == nil:
+		render(ctx, http.StatusOK, toImportModel(*imp))
+	case errors.Is(err, usecase.ErrImportKindMismatch):
+		render(ctx, http.StatusConflict, gin.H{"reason": err.Error()})
+	case errors.Is(err, importer.ErrMissingColumn):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error(), "id": imp.ID})
+	case imp != nil:
+		logging.FromContext(ctx).WarnContext(ctx, "import interrupted", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{
+			"reason": "Import interrupted, send the same file with this id to resume: " + err.Error(),
+			"id":     imp.ID,
+		})
+	default:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to start import", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to start import"})
+	}
+}
+
+// importFormat - формат из параметра format или заголовка Content-Type
+func importFormat(ctx *gin.Context) (importer.Format, error) {
+	if name := ctx.Query("format"); name != "" {
+		return importer.ParseFormat(name)
+	}
+	return importer.FormatFromContentType(ctx.GetHeader("Content-Type"))
+}
+
+func (h *ImportsHandler) importsOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// ImportHandler - состояние загрузки
+type ImportHandler struct {
+	uc *usecase.Import
+}
+
+func NewImportHandler(uc *usecase.Import) *ImportHandler {
+	return &ImportHandler{uc: uc}
+}
+
+func (h *ImportHandler) GetPath() string {
+	return "/imports/:import_id"
+}
+
+func (h *ImportHandler) GetAvailableMethods() []string {
+	return []string{http.MethodGet, http.MethodOptions}
+}
+
+func (h *ImportHandler) SetupRouterGroup(r *gin.Engine) {
+	importGroup := r.Group(h.GetPath())
+	{
+		importGroup.OPTIONS("", h.importOptions)
+		importGroup.GET("", middleware.AcceptValidator(), h.getImport)
+	}
+}
+
+func (h *ImportHandler) getImport(ctx *gin.Context) {
+	imp, err := h.uc.GetImport(ctx, ctx.Param("import_id"))
+	if errors.Is(err, usecase.ErrImportNotFound) {
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Import not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "unable to get import", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to get import"})
+		return
+	}
+
+	render(ctx, http.StatusOK, toImportModel(*imp))
+}
+
+func (h *ImportHandler) importOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+func toImportModel(imp domain.Import) models.Import {
+	out := models.Import{
+		ID:            imp.ID,
+		Kind:          string(imp.Kind),
+		Status:        string(imp.Status),
+		RowsProcessed: imp.RowsProcessed,
+		RowsImported:  imp.RowsImported,
+		RowsFailed:    imp.RowsFailed,
+		Errors:        make([]models.ImportRowError, 0, len(imp.Errors)),
+		Error:         imp.Error,
+		CreatedAt:     imp.CreatedAt,
+		UpdatedAt:     imp.UpdatedAt,
+	}
+	for _, e := range imp.Errors {
+		out.Errors = append(out.Errors, models.ImportRowError{Row: e.Row, Reason: e.Reason})
+	}
+
+	return out
+}
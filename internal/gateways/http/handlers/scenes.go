@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScenesHandler - список сцен и создание сцены
+type ScenesHandler struct {
+	uc *usecase.Scene
+}
+
+func NewScenesHandler(uc *usecase.Scene) *ScenesHandler {
+	return &ScenesHandler{uc: uc}
+}
+
+func (h *ScenesHandler) GetPath() string {
+	return "/scenes"
+}
+
+func (h *ScenesHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPost}
+}
+
+func (h *ScenesHandler) SetupRouterGroup(r *gin.Engine) {
+	scenesGroup := r.Group(h.GetPath())
+	{
+		scenesGroup.OPTIONS("", h.scenesOptions)
+		scenesGroup.GET("", middleware.AcceptValidator(), h.getScenes)
+		scenesGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.createScene)
+	}
+}
+
+// parseSceneID - ID сцены из пути, при ошибке отвечает 422 и возвращает false
+func parseSceneID(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("scene_id"), 10, 64)
+	if err != nil || id < 1 {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameter scene_id must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// bindScene - сцена из тела запроса, при ошибке отвечает 400 или 422 и возвращает false
+func bindScene(ctx *gin.Context) (*domain.Scene, bool) {
+	v := &models.SceneToSave{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return nil, false
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return nil, false
+	}
+
+	scene := &domain.Scene{
+		Name:        *v.Name,
+		Description: v.Description,
+		Targets:     make([]domain.SceneTarget, 0, len(v.Targets)),
+	}
+	for i, t := range v.Targets {
+		if t == nil {
+			render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: targets." + strconv.Itoa(i) + " is required"})
+			return nil, false
+		}
+		scene.Targets = append(scene.Targets, domain.SceneTarget{
+			SensorID:    *t.SensorID,
+			IsActive:    t.IsActive,
+			Description: t.Description,
+			State:       t.State,
+		})
+	}
+	return scene, true
+}
+
+// renderSceneError - ответ на ошибку usecase.Scene
+func renderSceneError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, usecase.ErrSceneNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Scene not found"})
+	case errors.Is(err, usecase.ErrSceneExists):
+		render(ctx, http.StatusConflict, gin.H{"reason": "Scene with this name already exists"})
+	case errors.Is(err, usecase.ErrInvalidScene):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+	default:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to "+action, logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to " + action})
+	}
+}
+
+func (h *ScenesHandler) getScenes(ctx *gin.Context) {
+	scenes, err := h.uc.GetScenes(ctx)
+	if err != nil {
+		renderSceneError(ctx, err, "retrieve scenes")
+		return
+	}
+
+	out := make([]models.Scene, 0, len(scenes))
+	for _, s := range scenes {
+		out = append(out, models.NewScene(s))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *ScenesHandler) createScene(ctx *gin.Context) {
+	scene, ok := bindScene(ctx)
+	if !ok {
+		return
+	}
+
+	scene, err := h.uc.CreateScene(ctx, scene)
+	if err != nil {
+		renderSceneError(ctx, err, "create scene")
+		return
+	}
+
+	ctx.Header("Location", "/scenes/"+strconv.FormatInt(scene.ID, 10))
+	render(ctx, http.StatusCreated, models.NewScene(*scene))
+}
+
+func (h *ScenesHandler) scenesOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// SceneHandler - получение, замена и удаление сцены
+type SceneHandler struct {
+	uc *usecase.Scene
+}
+
+func NewSceneHandler(uc *usecase.Scene) *SceneHandler {
+	return &SceneHandler{uc: uc}
+}
+
+func (h *SceneHandler) GetPath() string {
+	return "/scenes/:scene_id"
+}
+
+func (h *SceneHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete}
+}
+
+func (h *SceneHandler) SetupRouterGroup(r *gin.Engine) {
+	sceneGroup := r.Group(h.GetPath())
+	{
+		sceneGroup.OPTIONS("", h.sceneOptions)
+		sceneGroup.GET("", middleware.AcceptValidator(), h.getScene)
+		sceneGroup.PUT("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.updateScene)
+		sceneGroup.DELETE("", h.deleteScene)
+	}
+}
+
+func (h *SceneHandler) getScene(ctx *gin.Context) {
+	id, ok := parseSceneID(ctx)
+	if !ok {
+		return
+	}
+
+	scene, err := h.uc.GetScene(ctx, id)
+	if err != nil {
+		renderSceneError(ctx, err, "retrieve scene")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewScene(*scene))
+}
+
+func (h *SceneHandler) updateScene(ctx *gin.Context) {
+	id, ok := parseSceneID(ctx)
+	if !ok {
+		return
+	}
+
+	scene, ok := bindScene(ctx)
+	if !ok {
+		return
+	}
+
+	scene, err := h.uc.UpdateScene(ctx, id, scene)
+	if err != nil {
+		renderSceneError(ctx, err, "update scene")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewScene(*scene))
+}
+
+func (h *SceneHandler) deleteScene(ctx *gin.Context) {
+	id, ok := parseSceneID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.uc.DeleteScene(ctx, id); err != nil {
+		renderSceneError(ctx, err, "delete scene")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *SceneHandler) sceneOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// SceneActivateHandler - применение сцены
+type SceneActivateHandler struct {
+	uc *usecase.Scene
+}
+
+func NewSceneActivateHandler(uc *usecase.Scene) *SceneActivateHandler {
+	return &SceneActivateHandler{uc: uc}
+}
+
+func (h *SceneActivateHandler) GetPath() string {
+	return "/scenes/:scene_id/activate"
+}
+
+func (h *SceneActivateHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodPost}
+}
+
+func (h *SceneActivateHandler) SetupRouterGroup(r *gin.Engine) {
+	activateGroup := r.Group(h.GetPath())
+	{
+		activateGroup.OPTIONS("", h.activateOptions)
+		activateGroup.POST("", middleware.AcceptValidator(), h.activateScene)
+	}
+}
+
+// activateScene - применяет сцену и отвечает 200 с результатом по каждому датчику,
+// даже если часть настроек не применилась: итог отражает поле status
+func (h *SceneActivateHandler) activateScene(ctx *gin.Context) {
+	id, ok := parseSceneID(ctx)
+	if !ok {
+		return
+	}
+
+	activation, err := h.uc.ActivateScene(ctx, id)
+	if err != nil {
+		renderSceneError(ctx, err, "activate scene")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewSceneActivation(*activation))
+}
+
+func (h *SceneActivateHandler) activateOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// SceneActivationsHandler - история применения сцены
+type SceneActivationsHandler struct {
+	uc *usecase.Scene
+}
+
+func NewSceneActivationsHandler(uc *usecase.Scene) *SceneActivationsHandler {
+	return &SceneActivationsHandler{uc: uc}
+}
+
+func (h *SceneActivationsHandler) GetPath() string {
+	return "/scenes/:scene_id/activations"
+}
+
+func (h *SceneActivationsHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet}
+}
+
+func (h *SceneActivationsHandler) SetupRouterGroup(r *gin.Engine) {
+	activationsGroup := r.Group(h.GetPath())
+	{
+		activationsGroup.OPTIONS("", h.activationsOptions)
+		activationsGroup.GET("", middleware.AcceptValidator(), h.getActivations)
+	}
+}
+
+func (h *SceneActivationsHandler) getActivations(ctx *gin.Context) {
+	id, ok := parseSceneID(ctx)
+	if !ok {
+		return
+	}
+
+	var limit int
+	if s := ctx.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameter limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	activations, err := h.uc.GetActivations(ctx, id, limit)
+	if err != nil {
+		renderSceneError(ctx, err, "retrieve scene activations")
+		return
+	}
+
+	out := make([]models.SceneActivation, 0, len(activations))
+	for _, a := range activations {
+		out = append(out, models.NewSceneActivation(a))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *SceneActivationsHandler) activationsOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchedulesHandler - список расписаний и создание расписания
+type SchedulesHandler struct {
+	uc *usecase.Scheduler
+}
+
+func NewSchedulesHandler(uc *usecase.Scheduler) *SchedulesHandler {
+	return &SchedulesHandler{uc: uc}
+}
+
+func (h *SchedulesHandler) GetPath() string {
+	return "/schedules"
+}
+
+func (h *SchedulesHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPost}
+}
+
+func (h *SchedulesHandler) SetupRouterGroup(r *gin.Engine) {
+	schedulesGroup := r.Group(h.GetPath())
+	{
+		schedulesGroup.OPTIONS("", h.schedulesOptions)
+		schedulesGroup.GET("", middleware.AcceptValidator(), h.getSchedules)
+		schedulesGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.createSchedule)
+	}
+}
+
+// parseScheduleID - ID расписания из пути, при ошибке отвечает 422 и возвращает false
+func parseScheduleID(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("schedule_id"), 10, 64)
+	if err != nil || id < 1 {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameter schedule_id must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// bindSchedule - расписание из тела запроса, при ошибке отвечает 400 или 422 и возвращает false
+func bindSchedule(ctx *gin.Context) (*domain.Schedule, bool) {
+	v := &models.ScheduleToSave{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return nil, false
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return nil, false
+	}
+
+	return &domain.Schedule{
+		Name:     *v.Name,
+		Cron:     *v.Cron,
+		Timezone: v.Timezone,
+		Action: domain.ScheduleAction{
+			Kind:        domain.ScheduleActionKind(*v.Action.Kind),
+			SensorIDs:   v.Action.SensorIds,
+			IsActive:    v.Action.IsActive,
+			Description: v.Action.Description,
+			SceneID:     v.Action.SceneID,
+			CommandKind: domain.CommandKind(v.Action.Command),
+			State:       v.Action.State,
+			URL:         v.Action.URL,
+		},
+		MissedRuns: domain.MissedRunPolicy(v.MissedRuns),
+		Enabled:    v.Enabled == nil || *v.Enabled,
+	}, true
+}
+
+// renderScheduleError - ответ на ошибку usecase.Scheduler
+func renderScheduleError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, usecase.ErrScheduleNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Schedule not found"})
+	case errors.Is(err, usecase.ErrScheduleExists):
+		render(ctx, http.StatusConflict, gin.H{"reason": "Schedule with this name already exists"})
+	case errors.Is(err, usecase.ErrInvalidSchedule):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+	default:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to "+action, logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to " + action})
+	}
+}
+
+func (h *SchedulesHandler) getSchedules(ctx *gin.Context) {
+	schedules, err := h.uc.GetSchedules(ctx)
+	if err != nil {
+		renderScheduleError(ctx, err, "retrieve schedules")
+		return
+	}
+
+	out := make([]models.Schedule, 0, len(schedules))
+	for _, s := range schedules {
+		out = append(out, models.NewSchedule(s))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *SchedulesHandler) createSchedule(ctx *gin.Context) {
+	schedule, ok := bindSchedule(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.uc.CreateSchedule(ctx, schedule)
+	if err != nil {
+		renderScheduleError(ctx, err, "create schedule")
+		return
+	}
+
+	ctx.Header("Location", "/schedules/"+strconv.FormatInt(schedule.ID, 10))
+	render(ctx, http.StatusCreated, models.NewSchedule(*schedule))
+}
+
+func (h *SchedulesHandler) schedulesOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// ScheduleHandler - получение, замена и удаление расписания
+type ScheduleHandler struct {
+	uc *usecase.Scheduler
+}
+
+func NewScheduleHandler(uc *usecase.Scheduler) *ScheduleHandler {
+	return &ScheduleHandler{uc: uc}
+}
+
+func (h *ScheduleHandler) GetPath() string {
+	return "/schedules/:schedule_id"
+}
+
+func (h *ScheduleHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete}
+}
+
+func (h *ScheduleHandler) SetupRouterGroup(r *gin.Engine) {
+	scheduleGroup := r.Group(h.GetPath())
+	{
+		scheduleGroup.OPTIONS("", h.scheduleOptions)
+		scheduleGroup.GET("", middleware.AcceptValidator(), h.getSchedule)
+		scheduleGroup.PUT("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.updateSchedule)
+		scheduleGroup.DELETE("", h.deleteSchedule)
+	}
+}
+
+func (h *ScheduleHandler) getSchedule(ctx *gin.Context) {
+	id, ok := parseScheduleID(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.uc.GetSchedule(ctx, id)
+	if err != nil {
+		renderScheduleError(ctx, err, "retrieve schedule")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewSchedule(*schedule))
+}
+
+func (h *ScheduleHandler) updateSchedule(ctx *gin.Context) {
+	id, ok := parseScheduleID(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, ok := bindSchedule(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.uc.UpdateSchedule(ctx, id, schedule)
+	if err != nil {
+		renderScheduleError(ctx, err, "update schedule")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewSchedule(*schedule))
+}
+
+func (h *ScheduleHandler) deleteSchedule(ctx *gin.Context) {
+	id, ok := parseScheduleID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.uc.DeleteSchedule(ctx, id); err != nil {
+		renderScheduleError(ctx, err, "delete schedule")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *ScheduleHandler) scheduleOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// ScheduleRunsHandler - история запусков расписания
+type ScheduleRunsHandler struct {
+	uc *usecase.Scheduler
+}
+
+func NewScheduleRunsHandler(uc *usecase.Scheduler) *ScheduleRunsHandler {
+	return &ScheduleRunsHandler{uc: uc}
+}
+
+func (h *ScheduleRunsHandler) GetPath() string {
+	return "/schedules/:schedule_id/runs"
+}
+
+func (h *ScheduleRunsHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPost}
+}
+
+func (h *ScheduleRunsHandler) SetupRouterGroup(r *gin.Engine) {
+	runsGroup := r.Group(h.GetPath())
+	{
+		runsGroup.OPTIONS("", h.runsOptions)
+		runsGroup.GET("", middleware.AcceptValidator(), h.getRuns)
+		runsGroup.POST("", middleware.AcceptValidator(), h.runSchedule)
+	}
+}
+
+func (h *ScheduleRunsHandler) getRuns(ctx *gin.Context) {
+	id, ok := parseScheduleID(ctx)
+	if !ok {
+		return
+	}
+
+	var limit int
+	if s := ctx.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameter limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.uc.GetRuns(ctx, id, limit)
+	if err != nil {
+		renderScheduleError(ctx, err, "retrieve schedule runs")
+		return
+	}
+
+	out := make([]models.ScheduleRun, 0, len(runs))
+	for _, r := range runs {
+		out = append(out, models.NewScheduleRun(r))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+// runSchedule - выполняет расписание вне очереди, время следующего запуска не меняется
+func (h *ScheduleRunsHandler) runSchedule(ctx *gin.Context) {
+	id, ok := parseScheduleID(ctx)
+	if !ok {
+		return
+	}
+
+	run, err := h.uc.RunSchedule(ctx, id)
+	if err != nil {
+		renderScheduleError(ctx, err, "run schedule")
+		return
+	}
+
+	render(ctx, http.StatusCreated, models.NewScheduleRun(*run))
+}
+
+func (h *ScheduleRunsHandler) runsOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SensorTypesHandler - список типов датчиков из реестра
+type SensorTypesHandler struct {
+	uc *usecase.SensorTypes
+}
+
+func NewSensorTypesHandler(uc *usecase.SensorTypes) *SensorTypesHandler {
+	return &SensorTypesHandler{uc: uc}
+}
+
+func (h *SensorTypesHandler) GetPath() string {
+	return "/sensor-types"
+}
+
+func (h *SensorTypesHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodHead}
+}
+
+func (h *SensorTypesHandler) SetupRouterGroup(r *gin.Engine) {
+	typesGroup := r.Group(h.GetPath())
+	{
+		typesGroup.OPTIONS("", h.sensorTypesOptions)
+		typesGroup.GET("", middleware.AcceptValidator(), h.getSensorTypes)
+		typesGroup.HEAD("", middleware.AcceptValidator(), h.headSensorTypes)
+	}
+}
+
+func toSensorTypeModel(spec domain.SensorTypeSpec) *models.SensorType {
+	name := string(spec.Name)
+	payload := string(spec.Payload)
+	interval := int64(spec.ReportInterval / time.Second)
+	return &models.SensorType{
+		Name:           &name,
+		Description:    &spec.Description,
+		Payload:        &payload,
+		Min:            spec.Min,
+		Max:            spec.Max,
+		Unit:           &spec.Unit,
+		ReportInterval: &interval,
+		Actuator:       spec.Actuator,
+		Virtual:        spec.Virtual,
+	}
+}
+
+func (h *SensorTypesHandler) getSensorTypesList(ctx *gin.Context) ([]*models.SensorType, bool) {
+	specs, err := h.uc.GetSensorTypes(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "unable to retrieve sensor types", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to retrieve sensor types"})
+		return nil, false
+	}
+
+	types := make([]*models.SensorType, 0, len(specs))
+	for _, spec := range specs {
+		types = append(types, toSensorTypeModel(spec))
+	}
+	return types, true
+}
+
+func (h *SensorTypesHandler) getSensorTypes(ctx *gin.Context) {
+	if types, ok := h.getSensorTypesList(ctx); ok {
+		render(ctx, http.StatusOK, types)
+	}
+}
+
+func (h *SensorTypesHandler) headSensorTypes(ctx *gin.Context) {
+	if types, ok := h.getSensorTypesList(ctx); ok {
+		WriteHeaders(ctx, types)
+	}
+}
+
+func (h *SensorTypesHandler) sensorTypesOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// SensorTypeHandler - добавление и изменение типа датчика
+type SensorTypeHandler struct {
+	uc *usecase.SensorTypes
+}
+
+func NewSensorTypeHandler(uc *usecase.SensorTypes) *SensorTypeHandler {
+	return &SensorTypeHandler{uc: uc}
+}
+
+func (h *SensorTypeHandler) GetPath() string {
+	return "/sensor-types/:type_name"
+}
+
+func (h *SensorTypeHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut}
+}
+
+func (h *SensorTypeHandler) SetupRouterGroup(r *gin.Engine) {
+	typeGroup := r.Group(h.GetPath())
+	{
+		typeGroup.OPTIONS("", h.sensorTypeOptions)
+		typeGroup.GET("", middleware.AcceptValidator(), h.getSensorType)
+		typeGroup.HEAD("", middleware.AcceptValidator(), h.headSensorType)
+		typeGroup.PUT("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.saveSensorType)
+	}
+}
+
+func (h *SensorTypeHandler) getSensorTypeModel(ctx *gin.Context) (*models.SensorType, bool) {
+	spec, err := h.uc.GetSensorType(ctx, domain.SensorType(ctx.Param("type_name")))
+	if errors.Is(err, usecase.ErrWrongSensorType) {
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Sensor type not found"})
+		return nil, false
+	}
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "unable to retrieve sensor type", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to retrieve sensor type"})
+		return nil, false
+	}
+	return toSensorTypeModel(*spec), true
+}
+
+func (h *SensorTypeHandler) getSensorType(ctx *gin.Context) {
+	if t, ok := h.getSensorTypeModel(ctx); ok {
+		render(ctx, http.StatusOK, t)
+	}
+}
+
+func (h *SensorTypeHandler) headSensorType(ctx *gin.Context) {
+	if t, ok := h.getSensorTypeModel(ctx); ok {
+		WriteHeaders(ctx, t)
+	}
+}
+
+// saveSensorType - добавляет тип или заменяет описание существующего. Новые границы
+// применяются только к событиям, принятым после сохранения.
+func (h *SensorTypeHandler) saveSensorType(ctx *gin.Context) {
+	v := &models.SensorTypeToSave{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return
+	}
+
+	spec := domain.SensorTypeSpec{
+		Name:           domain.SensorType(ctx.Param("type_name")),
+		Description:    v.Description,
+		Payload:        domain.PayloadKind(*v.Payload),
+		Min:            v.Min,
+		Max:            v.Max,
+		Unit:           v.Unit,
+		ReportInterval: time.Duration(v.ReportInterval) * time.Second,
+		Actuator:       v.Actuator,
+		Virtual:        v.Virtual,
+	}
+
+	err := h.uc.SaveSensorType(ctx, &spec)
+	if errors.Is(err, usecase.ErrInvalidSensorType) {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "unable to save sensor type", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to save sensor type"})
+		return
+	}
+
+	render(ctx, http.StatusOK, toSensorTypeModel(spec))
+}
+
+func (h *SensorTypeHandler) sensorTypeOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"homework/internal/domain"
 	"homework/internal/gateways/http/middleware"
 	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
 	"homework/internal/usecase"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-openapi/strfmt"
@@ -25,9 +29,9 @@ func (h *SensorsHandler) SetupRouterGroup(r *gin.Engine) {
 	{
 		sensorGroup.Use()
 		sensorGroup.OPTIONS("", h.sensorsOptions)
-		sensorGroup.POST("", middleware.ContentTypeJSONValidator(), h.registerSensor)
-		sensorGroup.GET("", middleware.AcceptJSONValidator(), h.getSensors)
-		sensorGroup.HEAD("", middleware.AcceptJSONValidator(), h.headSensors)
+		sensorGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.registerSensor)
+		sensorGroup.GET("", middleware.AcceptValidator(), h.getSensors)
+		sensorGroup.HEAD("", middleware.AcceptValidator(), h.headSensors)
 	}
 }
 
@@ -41,14 +45,61 @@ func toSensorModel(sensor domain.Sensor) *models.Sensor {
 		SerialNumber: &sensor.SerialNumber,
 		Type:         (*string)(&sensor.Type),
 		CurrentState: &sensor.CurrentState,
+		CurrentValue: sensor.State(),
 		Description:  &sensor.Description,
 		IsActive:     &sensor.IsActive,
 		RegisteredAt: (*strfmt.DateTime)(&sensor.RegisteredAt),
 		LastActivity: (*strfmt.DateTime)(&sensor.LastActivity),
+		Unit:         sensor.Unit,
+		Calibration:  toCalibrationModel(sensor.Calibration),
 	}
 	return v
 }
 
+func toCalibrationModel(c *domain.Calibration) *models.Calibration {
+	if c == nil {
+		return nil
+	}
+	v := &models.Calibration{
+		Kind:         string(c.Kind),
+		Scale:        c.Scale,
+		Offset:       c.Offset,
+		Coefficients: c.Coefficients,
+	}
+	for _, p := range c.Points {
+		v.Points = append(v.Points, &models.CalibrationPoint{Raw: &p.Raw, Value: &p.Value})
+	}
+	return v
+}
+
+// fromCalibrationModel - калибровка из запроса, у калибровки без kind остаётся пустой Kind
+func fromCalibrationModel(v *models.Calibration) *domain.Calibration {
+	if v == nil {
+		return nil
+	}
+	c := &domain.Calibration{
+		Kind:         domain.CalibrationKind(v.Kind),
+		Scale:        v.Scale,
+		Offset:       v.Offset,
+		Coefficients: v.Coefficients,
+	}
+	for _, p := range v.Points {
+		if p != nil {
+			c.Points = append(c.Points, domain.CalibrationPoint{Raw: *p.Raw, Value: *p.Value})
+		}
+	}
+	return c
+}
+
+// renderSensorUnitsError - отвечает 422 на ошибку единицы или калибровки датчика
+func renderSensorUnitsError(ctx *gin.Context, err error) bool {
+	if errors.Is(err, usecase.ErrInvalidUnit) || errors.Is(err, usecase.ErrInvalidCalibration) {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return true
+	}
+	return false
+}
+
 func toSensorsModel(sensors []domain.Sensor) []*models.Sensor {
 	var s []*models.Sensor
 	for _, sensor := range sensors {
@@ -59,14 +110,14 @@ func toSensorsModel(sensors []domain.Sensor) []*models.Sensor {
 
 func (h *SensorsHandler) registerSensor(ctx *gin.Context) {
 	v := &models.SensorToCreate{}
-	if err := ctx.ShouldBindJSON(v); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"reason": "Error in the JSON format of the request body"})
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
 		return
 	}
 
 	if err := v.Validate(nil); err != nil {
 		reason := err.Error()
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "JSON body validation error: " + reason})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + reason})
 		return
 	}
 
@@ -75,20 +126,114 @@ func (h *SensorsHandler) registerSensor(ctx *gin.Context) {
 		IsActive:     *v.IsActive,
 		SerialNumber: *v.SerialNumber,
 		Type:         domain.SensorType(*v.Type),
+		Unit:         v.Unit,
+	}
+	if v.Calibration != nil && v.Calibration.Kind != "" {
+		sensor.Calibration = fromCalibrationModel(v.Calibration)
 	}
 	out, err := h.uc.RegisterSensor(ctx, &sensor)
+	if errors.Is(err, usecase.ErrWrongSensorType) {
+		reason := "unknown sensor type " + *v.Type
+		if errors.Is(err, usecase.ErrVirtualSensor) {
+			reason = "virtual sensors are created with POST /virtual-sensors"
+		}
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + reason})
+		return
+	}
+	if renderSensorUnitsError(ctx, err) {
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to register sensor"})
+		logging.FromContext(ctx).WarnContext(ctx, "unable to register sensor",
+			logging.SerialNumber(sensor.SerialNumber), logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to register sensor"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, toSensorModel(*out))
+	writeValidators(ctx, sensorETag(ctx, *out), lastModified(*out))
+	render(ctx, http.StatusOK, toSensorModel(*out))
+}
+
+// parseSensorsQuery - условия выборки из запроса, при ошибке отвечает 422 и возвращает false
+func parseSensorsQuery(ctx *gin.Context) (domain.SensorQuery, string, bool) {
+	v := &models.SensorsQuery{}
+	if err := ctx.ShouldBindQuery(v); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the query parameters of the request"})
+		return domain.SensorQuery{}, "", false
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
+		return domain.SensorQuery{}, "", false
+	}
+
+	query := domain.SensorQuery{
+		IsActive: v.IsActive,
+		Online:   v.Online,
+	}
+	if v.Type != nil {
+		query.Type = domain.SensorType(*v.Type)
+	}
+	if v.Search != nil {
+		query.Search = *v.Search
+	}
+	if v.SerialPrefix != nil {
+		query.SerialPrefix = *v.SerialPrefix
+	}
+	if v.Sort != nil {
+		sort, desc := strings.CutPrefix(*v.Sort, "-")
+		query.Sort, query.Desc = domain.SensorSort(sort), desc
+	}
+	if v.Limit != nil {
+		query.Limit = int(*v.Limit)
+	}
+
+	var cursor string
+	if v.Cursor != nil {
+		cursor = *v.Cursor
+	}
+
+	return query, cursor, true
+}
+
+// nextLink - ссылка на следующую страницу: тот же запрос с курсором next
+func nextLink(ctx *gin.Context, next string) string {
+	u := *ctx.Request.URL
+	q := u.Query()
+	q.Set("cursor", next)
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI())
 }
 
-func (h *SensorsHandler) getSensorsModel(ctx *gin.Context) ([]*models.Sensor, bool) {
-	out, err := h.uc.GetSensors(ctx)
+// getSensorsList - датчики для GET и HEAD. Если у клиента актуальный список, отвечает 304 и возвращает false.
+func (h *SensorsHandler) getSensorsList(ctx *gin.Context) ([]*models.Sensor, bool) {
+	query, cursor, ok := parseSensorsQuery(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	out, next, err := h.uc.ListSensors(ctx, query, cursor)
+	if errors.Is(err, usecase.ErrInvalidCursor) || errors.Is(err, usecase.ErrWrongSensorSort) {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
+		return nil, false
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to retrieve sensors"})
+		logging.FromContext(ctx).WarnContext(ctx, "unable to retrieve sensors", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to retrieve sensors"})
+		return nil, false
+	}
+
+	if next != "" {
+		ctx.Header("Link", nextLink(ctx, next))
+	}
+
+	var modified time.Time
+	for _, sensor := range out {
+		if t := lastModified(sensor); t.After(modified) {
+			modified = t
+		}
+	}
+	if notModified(ctx, sensorsETag(ctx, out), modified) {
 		return nil, false
 	}
 
@@ -97,13 +242,13 @@ func (h *SensorsHandler) getSensorsModel(ctx *gin.Context) ([]*models.Sensor, bo
 }
 
 func (h *SensorsHandler) getSensors(ctx *gin.Context) {
-	if sensors, ok := h.getSensorsModel(ctx); ok {
-		ctx.JSON(http.StatusOK, sensors)
+	if sensors, ok := h.getSensorsList(ctx); ok {
+		render(ctx, http.StatusOK, sensors)
 	}
 }
 
 func (h *SensorsHandler) headSensors(ctx *gin.Context) {
-	if sensors, ok := h.getSensorsModel(ctx); ok {
+	if sensors, ok := h.getSensorsList(ctx); ok {
 		WriteHeaders(ctx, sensors)
 	}
 }
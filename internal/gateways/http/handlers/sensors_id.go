@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"homework/internal/gateways/http/middleware"
 	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
 	"homework/internal/usecase"
 	"net/http"
 	"strings"
@@ -23,30 +25,53 @@ func (h *SensorHandler) SetupRouterGroup(r *gin.Engine) {
 	{
 		sensorDetailGroup.OPTIONS("", h.sensorOptions)
 		sensorDetailGroup.GET("",
-			middleware.AcceptJSONValidator(),
+			middleware.AcceptValidator(),
 			h.getSensor,
 		)
 		sensorDetailGroup.HEAD("",
-			middleware.AcceptJSONValidator(),
+			middleware.AcceptValidator(),
 			h.headSensor,
 		)
+		sensorDetailGroup.PATCH("",
+			middleware.ContentTypeValidator(),
+			middleware.AcceptValidator(),
+			h.updateSensor,
+		)
 	}
 }
 
-func (h *SensorHandler) getSensorModel(ctx *gin.Context) (*models.Sensor, bool) {
+// parseSensorID - ID датчика из пути, при ошибке отвечает 422 и возвращает false
+func parseSensorID(ctx *gin.Context) (int64, bool) {
 	v := &models.SensorIDParam{}
 	if err := ctx.ShouldBindUri(v); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
-		return nil, false
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+		return 0, false
 	}
 
 	if err := v.Validate(nil); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+		return 0, false
+	}
+
+	return *v.SensorID, true
+}
+
+// getSensorModel - датчик для GET и HEAD. Если у клиента актуальный датчик, отвечает 304 и возвращает false.
+func (h *SensorHandler) getSensorModel(ctx *gin.Context) (*models.Sensor, bool) {
+	id, ok := parseSensorID(ctx)
+	if !ok {
 		return nil, false
 	}
-	sensor, err := h.uc.GetSensorByID(ctx, *v.SensorID)
+
+	sensor, err := h.uc.GetSensorByID(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"reason": "Sensor not found"})
+		logging.FromContext(ctx).DebugContext(ctx, "sensor not found",
+			logging.SensorID(id), logging.Error(err))
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Sensor not found"})
+		return nil, false
+	}
+
+	if notModified(ctx, sensorETag(ctx, *sensor), lastModified(*sensor)) {
 		return nil, false
 	}
 
@@ -55,16 +80,76 @@ func (h *SensorHandler) getSensorModel(ctx *gin.Context) (*models.Sensor, bool)
 
 func (h *SensorHandler) getSensor(ctx *gin.Context) {
 	if sensor, ok := h.getSensorModel(ctx); ok {
-		ctx.JSON(http.StatusOK, sensor)
+		render(ctx, http.StatusOK, sensor)
 	}
 }
 
+// headSensor - заголовки GET /sensors/{sensor_id}; тело кодируется только для Content-Length (см. WriteHeaders)
 func (h *SensorHandler) headSensor(ctx *gin.Context) {
 	if sensor, ok := h.getSensorModel(ctx); ok {
 		WriteHeaders(ctx, sensor)
 	}
 }
 
+// updateSensor - изменяет датчик. С заголовком If-Match изменение применяется, только если
+// датчик не менялся с тех пор, как клиент получил этот ETag, иначе ответ 412.
+func (h *SensorHandler) updateSensor(ctx *gin.Context) {
+	id, ok := parseSensorID(ctx)
+	if !ok {
+		return
+	}
+
+	v := &models.SensorToUpdate{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return
+	}
+
+	var version int64
+	if header := ctx.GetHeader("If-Match"); header != "" && header != "*" {
+		for _, tag := range etags(header) {
+			if version, ok = parseSensorETag(ctx, id, tag); ok {
+				break
+			}
+		}
+		if version == 0 {
+			render(ctx, http.StatusPreconditionFailed, gin.H{"reason": "Sensor has been modified"})
+			return
+		}
+	}
+
+	sensor, err := h.uc.UpdateSensor(ctx, id, version, usecase.SensorUpdate{
+		Description: v.Description,
+		IsActive:    v.IsActive,
+		Unit:        v.Unit,
+		Calibration: fromCalibrationModel(v.Calibration),
+	})
+	if renderSensorUnitsError(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, usecase.ErrSensorNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Sensor not found"})
+		return
+	case errors.Is(err, usecase.ErrSensorVersionConflict):
+		render(ctx, http.StatusPreconditionFailed, gin.H{"reason": "Sensor has been modified"})
+		return
+	case err != nil:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to update sensor",
+			logging.SensorID(id), logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to update sensor"})
+		return
+	}
+
+	writeValidators(ctx, sensorETag(ctx, *sensor), lastModified(*sensor))
+	render(ctx, http.StatusOK, toSensorModel(*sensor))
+}
+
 func (h *SensorHandler) sensorOptions(ctx *gin.Context) {
 	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
 	ctx.Status(http.StatusNoContent)
@@ -75,5 +160,5 @@ func (h *SensorHandler) GetPath() string {
 }
 
 func (h *SensorHandler) GetAvailableMethods() []string {
-	return []string{http.MethodOptions, http.MethodGet, http.MethodHead}
+	return []string{http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPatch}
 }
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SensorAggregatesHandler - сводка значений канала датчика (число, минимум, максимум,
+// среднее и сумма) за период, разбитый на интервалы
+type SensorAggregatesHandler struct {
+	uc *usecase.Event
+}
+
+func NewSensorAggregatesHandler(uc *usecase.Event) *SensorAggregatesHandler {
+	return &SensorAggregatesHandler{uc: uc}
+}
+
+func (h *SensorAggregatesHandler) GetPath() string {
+	return "/sensors/:sensor_id/aggregates"
+}
+
+func (h *SensorAggregatesHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodHead}
+}
+
+func (h *SensorAggregatesHandler) SetupRouterGroup(r *gin.Engine) {
+	aggregatesGroup := r.Group(h.GetPath())
+	{
+		aggregatesGroup.OPTIONS("", h.aggregatesOptions)
+		aggregatesGroup.GET("", middleware.AcceptValidator(), h.getAggregates)
+		aggregatesGroup.HEAD("", middleware.AcceptValidator(), h.headAggregates)
+	}
+}
+
+func (h *SensorAggregatesHandler) getAggregateModels(ctx *gin.Context) ([]*models.SensorAggregate, bool) {
+	q := &models.AggregateQuery{}
+	if err := ctx.ShouldBindQuery(q); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the query parameters of the request"})
+		return nil, false
+	}
+
+	if err := q.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
+		return nil, false
+	}
+
+	s := &models.SensorIDParam{}
+	if err := ctx.ShouldBindUri(s); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+		return nil, false
+	}
+
+	if err := s.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+		return nil, false
+	}
+
+	var (
+		aggs []domain.Aggregate
+		unit string
+		err  error
+	)
+	if q.Converted() {
+		aggs, unit, err = h.uc.AggregateReadings(ctx, *s.SensorID, q.Channel, *q.Start, *q.End, q.GetInterval(), q.Unit)
+	} else {
+		aggs, err = h.uc.AggregateEvents(ctx, *s.SensorID, q.Channel, *q.Start, *q.End, q.GetInterval())
+	}
+	switch {
+	case errors.Is(err, usecase.ErrSensorNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Sensor not found"})
+		return nil, false
+	case errors.Is(err, usecase.ErrInvalidAggregation), errors.Is(err, usecase.ErrInvalidUnit):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
+		return nil, false
+	case err != nil:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to aggregate sensor events",
+			logging.SensorID(*s.SensorID), logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to aggregate events"})
+		return nil, false
+	}
+
+	out := make([]*models.SensorAggregate, 0, len(aggs))
+	for _, agg := range aggs {
+		out = append(out, &models.SensorAggregate{
+			Start: agg.Start,
+			Count: agg.Count,
+			Min:   agg.Min,
+			Max:   agg.Max,
+			Avg:   agg.Avg,
+			Sum:   agg.Sum,
+			Unit:  unit,
+		})
+	}
+	return out, true
+}
+
+func (h *SensorAggregatesHandler) getAggregates(ctx *gin.Context) {
+	if aggs, ok := h.getAggregateModels(ctx); ok {
+		render(ctx, http.StatusOK, aggs)
+	}
+}
+
+func (h *SensorAggregatesHandler) headAggregates(ctx *gin.Context) {
+	if aggs, ok := h.getAggregateModels(ctx); ok {
+		WriteHeaders(ctx, aggs)
+	}
+}
+
+func (h *SensorAggregatesHandler) aggregatesOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"homework/internal/domain"
-	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/export"
 	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
 	"homework/internal/usecase"
 	"net/http"
 	"strings"
@@ -32,76 +35,151 @@ func (h *SensorHistoryHandler) GetAvailableMethods() []string {
 func (h *SensorHistoryHandler) SetupRouterGroup(r *gin.Engine) {
 	sensorDetailGroup := r.Group(h.GetPath())
 	{
-		sensorDetailGroup.GET("",
-			middleware.AcceptJSONValidator(),
-			h.getSensorsHistory,
-		)
-
-		sensorDetailGroup.HEAD("",
-			middleware.AcceptJSONValidator(),
-			h.headSensorsHistory,
-		)
+		sensorDetailGroup.GET("", h.getSensorsHistory)
+		sensorDetailGroup.HEAD("", h.headSensorsHistory)
 
 		sensorDetailGroup.OPTIONS("", h.sensorsHistoryOptions)
 	}
 }
 
-func toSensorStatus(event domain.Event) *models.SensorStatus {
-	return &models.SensorStatus{
+// toSensorStatus - событие истории; если задан channel, в Value попадает его значение,
+// а события без такого канала пропускаются
+func toSensorStatus(event domain.Event, channel string) (*models.SensorStatus, bool) {
+	status := &models.SensorStatus{
 		Timestamp: event.Timestamp,
 		Payload:   event.Payload,
+		Channels:  event.Channels,
+	}
+	if channel == "" {
+		return status, true
+	}
+
+	v, ok := event.Value(channel)
+	if !ok {
+		return nil, false
 	}
+	status.Value = &v
+	return status, true
 }
 
-func (h *SensorHistoryHandler) getSensorStatuses(ctx *gin.Context) ([]*models.SensorStatus, bool) {
+// parseHistoryQuery - датчик и период из запроса, при ошибке отвечает 422 и возвращает false
+func parseHistoryQuery(ctx *gin.Context) (int64, *models.TimeFraneQuery, bool) {
 	t := &models.TimeFraneQuery{}
 	if err := ctx.ShouldBindQuery(t); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the query parameters of the request"})
-		return nil, false
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the query parameters of the request"})
+		return 0, nil, false
 	}
 
 	if err := t.Validate(nil); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
-		return nil, false
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
+		return 0, nil, false
 	}
 
 	s := &models.SensorIDParam{}
 
 	if err := ctx.ShouldBindUri(s); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
-		return nil, false
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+		return 0, nil, false
 	}
 
 	if err := s.Validate(nil); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+		return 0, nil, false
+	}
+
+	return *s.SensorID, t, true
+}
+
+// getConvertedStatuses - история со значениями канала после калибровки датчика
+func (h *SensorHistoryHandler) getConvertedStatuses(ctx *gin.Context, id int64, t *models.TimeFraneQuery) ([]*models.SensorStatus, bool) {
+	readings, err := h.uc.GetReadingsByTimeFrame(ctx, id, t.Channel, *t.Start, *t.End, t.Unit)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidUnit):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: " + err.Error()})
 		return nil, false
+	case err != nil:
+		logging.FromContext(ctx).DebugContext(ctx, "unable to get sensor history",
+			logging.SensorID(id), logging.Error(err))
+		render(ctx, http.StatusNotFound, gin.H{"reason": err.Error()})
+		return nil, false
+	}
+
+	var statuses []*models.SensorStatus
+
+	for _, reading := range readings {
+		status, _ := toSensorStatus(reading.Event, t.Channel)
+		status.Converted = &reading.Value
+		status.Unit = reading.Unit
+		statuses = append(statuses, status)
+	}
+
+	return statuses, true
+}
+
+func (h *SensorHistoryHandler) getSensorStatuses(ctx *gin.Context, id int64, t *models.TimeFraneQuery) ([]*models.SensorStatus, bool) {
+	if t.Converted() {
+		return h.getConvertedStatuses(ctx, id, t)
 	}
 
-	events, err := h.uc.GetEventsByTimeFrame(ctx, *s.SensorID, *t.Start, *t.End)
+	events, err := h.uc.GetEventsByTimeFrame(ctx, id, *t.Start, *t.End)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"reason": err.Error()})
+		logging.FromContext(ctx).DebugContext(ctx, "unable to get sensor history",
+			logging.SensorID(id), logging.Error(err))
+		render(ctx, http.StatusNotFound, gin.H{"reason": err.Error()})
 		return nil, false
 	}
 
 	var statuses []*models.SensorStatus
 
 	for _, event := range events {
-		statuses = append(statuses, toSensorStatus(event))
+		if status, ok := toSensorStatus(event, t.Channel); ok {
+			statuses = append(statuses, status)
+		}
 	}
 
 	return statuses, true
 }
 
-func (h *SensorHistoryHandler) getSensorsHistory(ctx *gin.Context) {
-	if statuses, ok := h.getSensorStatuses(ctx); ok {
-		ctx.JSON(http.StatusOK, statuses)
+// handleHistory - история в формате ответа API (JSON, CBOR, MessagePack) отдаётся массивом SensorStatus,
+// как раньше, а в остальных форматах выгружается потоком строк с полями события и сырыми значениями
+func (h *SensorHistoryHandler) handleHistory(ctx *gin.Context, writeJSON func([]*models.SensorStatus)) {
+	format, _, ok := negotiateExportFormat(ctx, export.FormatJSON)
+	if !ok {
+		return
+	}
+
+	id, t, ok := parseHistoryQuery(ctx)
+	if !ok {
+		return
+	}
+
+	if format == export.FormatJSON {
+		if statuses, ok := h.getSensorStatuses(ctx, id, t); ok {
+			writeJSON(statuses)
+		}
+		return
 	}
+
+	writeExport(ctx, format, nil, fmt.Sprintf("sensor-%d-history", id), func(fn func(domain.Event) error) error {
+		return h.uc.ExportEvents(ctx, []int64{id}, *t.Start, *t.End, func(event domain.Event) error {
+			if _, ok := event.Value(t.Channel); t.Channel != "" && !ok {
+				return nil
+			}
+			return fn(event)
+		})
+	})
+}
+
+func (h *SensorHistoryHandler) getSensorsHistory(ctx *gin.Context) {
+	h.handleHistory(ctx, func(statuses []*models.SensorStatus) {
+		render(ctx, http.StatusOK, statuses)
+	})
 }
 
 func (h *SensorHistoryHandler) headSensorsHistory(ctx *gin.Context) {
-	if statuses, ok := h.getSensorStatuses(ctx); ok {
+	h.handleHistory(ctx, func(statuses []*models.SensorStatus) {
 		WriteHeaders(ctx, statuses)
-	}
+	})
 }
 
 func (h *SensorHistoryHandler) sensorsHistoryOptions(ctx *gin.Context) {
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	sensorLabels = []string{"sensor_id", "serial_number", "type", "description"}
+
+	sensorCurrentStateDesc = prometheus.NewDesc(
+		"sensor_current_state",
+		"Current state of the sensor",
+		sensorLabels, nil,
+	)
+
+	sensorLastActivityDesc = prometheus.NewDesc(
+		"sensor_last_activity_timestamp_seconds",
+		"Unix time of the last sensor event",
+		sensorLabels, nil,
+	)
+)
+
+// SensorsMetricsHandler - состояние датчиков в формате Prometheus для сбора Grafana агентом или Prometheus
+type SensorsMetricsHandler struct {
+	uc *usecase.Sensor
+}
+
+func NewSensorsMetricsHandler(uc *usecase.Sensor) *SensorsMetricsHandler {
+	return &SensorsMetricsHandler{uc: uc}
+}
+
+func (h *SensorsMetricsHandler) GetPath() string {
+	return "/sensors/metrics"
+}
+
+func (h *SensorsMetricsHandler) GetAvailableMethods() []string {
+	return []string{http.MethodGet, http.MethodOptions}
+}
+
+func (h *SensorsMetricsHandler) SetupRouterGroup(r *gin.Engine) {
+	metricsGroup := r.Group(h.GetPath())
+	{
+		metricsGroup.OPTIONS("", h.metricsOptions)
+		metricsGroup.GET("", h.getMetrics)
+	}
+}
+
+func (h *SensorsMetricsHandler) getMetrics(ctx *gin.Context) {
+	sensors, err := h.uc.GetSensors(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "unable to retrieve sensors", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to retrieve sensors"})
+		return
+	}
+
+	// реестр на каждый запрос: состояние датчиков уже получено, и сбор не может завершиться ошибкой
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(sensorsCollector(sensors))
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+func (h *SensorsMetricsHandler) metricsOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// sensorsCollector - метрики по снимку состояния датчиков
+type sensorsCollector []domain.Sensor
+
+func (c sensorsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sensorCurrentStateDesc
+	ch <- sensorLastActivityDesc
+}
+
+func (c sensorsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, sensor := range c {
+		labels := []string{
+			strconv.FormatInt(sensor.ID, 10),
+			sensor.SerialNumber,
+			string(sensor.Type),
+			sensor.Description,
+		}
+
+		ch <- prometheus.MustNewConstMetric(sensorCurrentStateDesc, prometheus.GaugeValue,
+			sensor.State().Number(), labels...)
+
+		// у датчика без событий времени последней активности нет
+		if !sensor.LastActivity.IsZero() {
+			ch <- prometheus.MustNewConstMetric(sensorLastActivityDesc, prometheus.GaugeValue,
+				float64(sensor.LastActivity.UnixNano())/1e9, labels...)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"homework/internal/domain"
 	"homework/internal/gateways/http/middleware"
 	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
 	"homework/internal/usecase"
 	"net/http"
 	"strings"
@@ -23,7 +24,7 @@ func (h *UsersHandler) SetupRouterGroup(r *gin.Engine) {
 	userGroup := r.Group(h.GetPath())
 	{
 		userGroup.OPTIONS("", h.usersOptions)
-		userGroup.POST("", middleware.ContentTypeJSONValidator(), h.createUser)
+		userGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.createUser)
 	}
 }
 
@@ -46,25 +47,26 @@ func userValidator(user domain.User) *models.User {
 
 func (h *UsersHandler) createUser(ctx *gin.Context) {
 	v := &models.UserToCreate{}
-	if err := ctx.ShouldBindJSON(v); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"reason": "Error in the JSON format of the request body"})
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
 		return
 	}
 
 	if err := v.Validate(nil); err != nil {
 		reason := err.Error()
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "JSON body validation error: " + reason})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + reason})
 		return
 	}
 
 	user := domain.User{Name: *v.Name}
 	out, err := h.uc.RegisterUser(ctx, &user)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to register user"})
+		logging.FromContext(ctx).WarnContext(ctx, "unable to register user", logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to register user"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, userValidator(*out))
+	render(ctx, http.StatusOK, userValidator(*out))
 }
 
 func (h *UsersHandler) usersOptions(ctx *gin.Context) {
@@ -3,6 +3,7 @@ package handlers
 import (
 	"homework/internal/gateways/http/middleware"
 	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
 	"homework/internal/usecase"
 	"net/http"
 	"strings"
@@ -23,15 +24,16 @@ func (h *SensorOwnerHandler) SetupRouterGroup(r *gin.Engine) {
 	{
 		sensorOwnerGroup.OPTIONS("", h.usersSensorsOptions)
 		sensorOwnerGroup.POST("",
-			middleware.ContentTypeJSONValidator(),
+			middleware.ContentTypeValidator(),
+			middleware.AcceptValidator(),
 			h.bindSensorToUser,
 		)
 		sensorOwnerGroup.GET("",
-			middleware.AcceptJSONValidator(),
+			middleware.AcceptValidator(),
 			h.getUserSensors,
 		)
 		sensorOwnerGroup.HEAD("",
-			middleware.AcceptJSONValidator(),
+			middleware.AcceptValidator(),
 			h.headUserSensors,
 		)
 	}
@@ -48,30 +50,32 @@ func (h *SensorOwnerHandler) GetPath() string {
 func (h *SensorOwnerHandler) bindSensorToUser(ctx *gin.Context) {
 	u := &models.UserIDParam{}
 	if err := ctx.ShouldBindUri(u); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
 		return
 	}
 
 	if err := u.Validate(nil); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
 		return
 	}
 
 	s := &models.SensorToUserBinding{}
-	if err := ctx.ShouldBindJSON(s); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"reason": "Error in the JSON format of the request body"})
+	if err := bind(ctx, s); err != nil {
+		renderBindError(ctx, err)
 		return
 	}
 
 	if err := s.Validate(nil); err != nil {
 		reason := err.Error()
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "JSON body validation error: " + reason})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + reason})
 		return
 	}
 
 	err := h.uc.AttachSensorToUser(ctx, *u.UserID, *s.SensorID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"reason": "Sensor or user not found"})
+		logging.FromContext(ctx).DebugContext(ctx, "unable to attach sensor to user",
+			logging.UserID(*u.UserID), logging.SensorID(*s.SensorID), logging.Error(err))
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Sensor or user not found"})
 		return
 	}
 
@@ -81,18 +85,20 @@ func (h *SensorOwnerHandler) bindSensorToUser(ctx *gin.Context) {
 func (h *SensorOwnerHandler) getSensorsModel(ctx *gin.Context) ([]*models.Sensor, bool) {
 	v := &models.UserIDParam{}
 	if err := ctx.ShouldBindUri(v); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
 		return nil, false
 	}
 
 	if err := v.Validate(nil); err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
 		return nil, false
 	}
 
 	sensors, err := h.uc.GetUserSensors(ctx, *v.UserID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"reason": "Sensors for the user not found"})
+		logging.FromContext(ctx).DebugContext(ctx, "unable to get user sensors",
+			logging.UserID(*v.UserID), logging.Error(err))
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Sensors for the user not found"})
 		return nil, false
 	}
 
@@ -101,7 +107,7 @@ func (h *SensorOwnerHandler) getSensorsModel(ctx *gin.Context) ([]*models.Sensor
 
 func (h *SensorOwnerHandler) getUserSensors(ctx *gin.Context) {
 	if sensors, ok := h.getSensorsModel(ctx); ok {
-		ctx.JSON(http.StatusOK, sensors)
+		render(ctx, http.StatusOK, sensors)
 	}
 }
 
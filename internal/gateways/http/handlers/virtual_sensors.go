@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VirtualSensorsHandler - список виртуальных датчиков и создание виртуального датчика
+type VirtualSensorsHandler struct {
+	uc *usecase.VirtualSensor
+}
+
+func NewVirtualSensorsHandler(uc *usecase.VirtualSensor) *VirtualSensorsHandler {
+	return &VirtualSensorsHandler{uc: uc}
+}
+
+func (h *VirtualSensorsHandler) GetPath() string {
+	return "/virtual-sensors"
+}
+
+func (h *VirtualSensorsHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPost}
+}
+
+func (h *VirtualSensorsHandler) SetupRouterGroup(r *gin.Engine) {
+	virtualSensorsGroup := r.Group(h.GetPath())
+	{
+		virtualSensorsGroup.OPTIONS("", h.virtualSensorsOptions)
+		virtualSensorsGroup.GET("", middleware.AcceptValidator(), h.getVirtualSensors)
+		virtualSensorsGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.createVirtualSensor)
+	}
+}
+
+// renderVirtualSensorError - ответ на ошибку usecase.VirtualSensor
+func renderVirtualSensorError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, usecase.ErrVirtualSensorNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Virtual sensor not found"})
+	case errors.Is(err, usecase.ErrSensorExists):
+		render(ctx, http.StatusConflict, gin.H{"reason": "Sensor with this serial number already exists"})
+	case errors.Is(err, usecase.ErrInvalidVirtualSensor), errors.Is(err, usecase.ErrWrongSensorSerialNumber),
+		errors.Is(err, usecase.ErrInvalidUnit), errors.Is(err, usecase.ErrInvalidCalibration):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+	default:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to "+action, logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to " + action})
+	}
+}
+
+func (h *VirtualSensorsHandler) getVirtualSensors(ctx *gin.Context) {
+	virtualSensors, err := h.uc.GetVirtualSensors(ctx)
+	if err != nil {
+		renderVirtualSensorError(ctx, err, "retrieve virtual sensors")
+		return
+	}
+
+	out := make([]models.VirtualSensor, 0, len(virtualSensors))
+	for _, vs := range virtualSensors {
+		out = append(out, models.NewVirtualSensor(vs))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *VirtualSensorsHandler) createVirtualSensor(ctx *gin.Context) {
+	v := &models.VirtualSensorToCreate{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return
+	}
+
+	sensor := &domain.Sensor{
+		SerialNumber: *v.SerialNumber,
+		Description:  v.Description,
+		IsActive:     v.IsActive == nil || *v.IsActive,
+		Unit:         v.Unit,
+	}
+	vs, err := h.uc.CreateVirtualSensor(ctx, sensor, *v.Expression)
+	if err != nil {
+		renderVirtualSensorError(ctx, err, "create virtual sensor")
+		return
+	}
+
+	ctx.Header("Location", "/virtual-sensors/"+strconv.FormatInt(vs.SensorID, 10))
+	render(ctx, http.StatusCreated, models.NewVirtualSensor(*vs))
+}
+
+func (h *VirtualSensorsHandler) virtualSensorsOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// VirtualSensorHandler - получение, замена выражения и удаление виртуального датчика
+type VirtualSensorHandler struct {
+	uc *usecase.VirtualSensor
+}
+
+func NewVirtualSensorHandler(uc *usecase.VirtualSensor) *VirtualSensorHandler {
+	return &VirtualSensorHandler{uc: uc}
+}
+
+func (h *VirtualSensorHandler) GetPath() string {
+	return "/virtual-sensors/:sensor_id"
+}
+
+func (h *VirtualSensorHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete}
+}
+
+func (h *VirtualSensorHandler) SetupRouterGroup(r *gin.Engine) {
+	virtualSensorGroup := r.Group(h.GetPath())
+	{
+		virtualSensorGroup.OPTIONS("", h.virtualSensorOptions)
+		virtualSensorGroup.GET("", middleware.AcceptValidator(), h.getVirtualSensor)
+		virtualSensorGroup.PUT("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.updateVirtualSensor)
+		virtualSensorGroup.DELETE("", h.deleteVirtualSensor)
+	}
+}
+
+func (h *VirtualSensorHandler) getVirtualSensor(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "sensor_id")
+	if !ok {
+		return
+	}
+
+	vs, err := h.uc.GetVirtualSensor(ctx, id)
+	if err != nil {
+		renderVirtualSensorError(ctx, err, "retrieve virtual sensor")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewVirtualSensor(*vs))
+}
+
+func (h *VirtualSensorHandler) updateVirtualSensor(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "sensor_id")
+	if !ok {
+		return
+	}
+
+	v := &models.VirtualSensorToUpdate{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return
+	}
+
+	vs, err := h.uc.UpdateVirtualSensor(ctx, id, *v.Expression)
+	if err != nil {
+		renderVirtualSensorError(ctx, err, "update virtual sensor")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewVirtualSensor(*vs))
+}
+
+// deleteVirtualSensor - удаляет только выражение, датчик и его история остаются
+func (h *VirtualSensorHandler) deleteVirtualSensor(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "sensor_id")
+	if !ok {
+		return
+	}
+
+	if err := h.uc.DeleteVirtualSensor(ctx, id); err != nil {
+		renderVirtualSensorError(ctx, err, "delete virtual sensor")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *VirtualSensorHandler) virtualSensorOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
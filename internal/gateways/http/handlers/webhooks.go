@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/middleware"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhooksHandler - список подписок и создание подписки
+type WebhooksHandler struct {
+	uc *usecase.Webhook
+}
+
+func NewWebhooksHandler(uc *usecase.Webhook) *WebhooksHandler {
+	return &WebhooksHandler{uc: uc}
+}
+
+func (h *WebhooksHandler) GetPath() string {
+	return "/webhooks"
+}
+
+func (h *WebhooksHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPost}
+}
+
+func (h *WebhooksHandler) SetupRouterGroup(r *gin.Engine) {
+	webhooksGroup := r.Group(h.GetPath())
+	{
+		webhooksGroup.OPTIONS("", h.webhooksOptions)
+		webhooksGroup.GET("", middleware.AcceptValidator(), h.getWebhooks)
+		webhooksGroup.POST("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.createWebhook)
+	}
+}
+
+// bindWebhook - подписка из тела запроса, при ошибке отвечает 400 или 422 и возвращает false
+func bindWebhook(ctx *gin.Context) (*domain.Webhook, bool) {
+	v := &models.WebhookToSave{}
+	if err := bind(ctx, v); err != nil {
+		renderBindError(ctx, err)
+		return nil, false
+	}
+
+	if err := v.Validate(nil); err != nil {
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Body validation error: " + err.Error()})
+		return nil, false
+	}
+
+	webhook := &domain.Webhook{
+		URL:       *v.URL,
+		Secret:    v.Secret,
+		SensorIDs: v.SensorIds,
+		UserID:    v.UserID,
+		Enabled:   v.Enabled == nil || *v.Enabled,
+	}
+	for _, topic := range v.Topics {
+		webhook.Topics = append(webhook.Topics, domain.WebhookTopic(topic))
+	}
+	for _, t := range v.SensorTypes {
+		webhook.SensorTypes = append(webhook.SensorTypes, domain.SensorType(t))
+	}
+	return webhook, true
+}
+
+// renderWebhookError - ответ на ошибку usecase.Webhook
+func renderWebhookError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, usecase.ErrWebhookNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Webhook not found"})
+	case errors.Is(err, usecase.ErrDeliveryNotFound):
+		render(ctx, http.StatusNotFound, gin.H{"reason": "Webhook delivery not found"})
+	case errors.Is(err, usecase.ErrDeliveryStatusConflict):
+		render(ctx, http.StatusConflict, gin.H{"reason": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidWebhook), errors.Is(err, usecase.ErrInvalidDeliveryQuery):
+		render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+	default:
+		logging.FromContext(ctx).WarnContext(ctx, "unable to "+action, logging.Error(err))
+		render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to " + action})
+	}
+}
+
+func (h *WebhooksHandler) getWebhooks(ctx *gin.Context) {
+	webhooks, err := h.uc.GetWebhooks(ctx)
+	if err != nil {
+		renderWebhookError(ctx, err, "retrieve webhooks")
+		return
+	}
+
+	out := make([]models.Webhook, 0, len(webhooks))
+	for _, w := range webhooks {
+		out = append(out, models.NewWebhook(w))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *WebhooksHandler) createWebhook(ctx *gin.Context) {
+	webhook, ok := bindWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.uc.CreateWebhook(ctx, webhook)
+	if err != nil {
+		renderWebhookError(ctx, err, "create webhook")
+		return
+	}
+
+	// ключ подписи виден только здесь, в том числе сгенерированный
+	out := models.NewWebhook(*webhook)
+	out.Secret = webhook.Secret
+
+	ctx.Header("Location", "/webhooks/"+strconv.FormatInt(webhook.ID, 10))
+	render(ctx, http.StatusCreated, out)
+}
+
+func (h *WebhooksHandler) webhooksOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// WebhookHandler - получение, замена и удаление подписки
+type WebhookHandler struct {
+	uc *usecase.Webhook
+}
+
+func NewWebhookHandler(uc *usecase.Webhook) *WebhookHandler {
+	return &WebhookHandler{uc: uc}
+}
+
+func (h *WebhookHandler) GetPath() string {
+	return "/webhooks/:webhook_id"
+}
+
+func (h *WebhookHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete}
+}
+
+func (h *WebhookHandler) SetupRouterGroup(r *gin.Engine) {
+	webhookGroup := r.Group(h.GetPath())
+	{
+		webhookGroup.OPTIONS("", h.webhookOptions)
+		webhookGroup.GET("", middleware.AcceptValidator(), h.getWebhook)
+		webhookGroup.PUT("", middleware.ContentTypeValidator(), middleware.AcceptValidator(), h.updateWebhook)
+		webhookGroup.DELETE("", h.deleteWebhook)
+	}
+}
+
+func (h *WebhookHandler) getWebhook(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "webhook_id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.uc.GetWebhook(ctx, id)
+	if err != nil {
+		renderWebhookError(ctx, err, "retrieve webhook")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewWebhook(*webhook))
+}
+
+func (h *WebhookHandler) updateWebhook(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "webhook_id")
+	if !ok {
+		return
+	}
+
+	webhook, ok := bindWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.uc.UpdateWebhook(ctx, id, webhook)
+	if err != nil {
+		renderWebhookError(ctx, err, "update webhook")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewWebhook(*webhook))
+}
+
+func (h *WebhookHandler) deleteWebhook(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "webhook_id")
+	if !ok {
+		return
+	}
+
+	if err := h.uc.DeleteWebhook(ctx, id); err != nil {
+		renderWebhookError(ctx, err, "delete webhook")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) webhookOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// WebhookDeliveriesHandler - журнал доставок подписки
+type WebhookDeliveriesHandler struct {
+	uc *usecase.Webhook
+}
+
+func NewWebhookDeliveriesHandler(uc *usecase.Webhook) *WebhookDeliveriesHandler {
+	return &WebhookDeliveriesHandler{uc: uc}
+}
+
+func (h *WebhookDeliveriesHandler) GetPath() string {
+	return "/webhooks/:webhook_id/deliveries"
+}
+
+func (h *WebhookDeliveriesHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodGet}
+}
+
+func (h *WebhookDeliveriesHandler) SetupRouterGroup(r *gin.Engine) {
+	deliveriesGroup := r.Group(h.GetPath())
+	{
+		deliveriesGroup.OPTIONS("", h.deliveriesOptions)
+		deliveriesGroup.GET("", middleware.AcceptValidator(), h.getDeliveries)
+	}
+}
+
+func (h *WebhookDeliveriesHandler) getDeliveries(ctx *gin.Context) {
+	id, ok := parsePositiveParam(ctx, "webhook_id")
+	if !ok {
+		return
+	}
+
+	var limit int
+	if s := ctx.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			render(ctx, http.StatusUnprocessableEntity, gin.H{"reason": "Query parameter limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	var statuses []domain.WebhookDeliveryStatus
+	for _, s := range ctx.QueryArray("status") {
+		statuses = append(statuses, domain.WebhookDeliveryStatus(s))
+	}
+
+	deliveries, err := h.uc.GetDeliveries(ctx, id, statuses, limit)
+	if err != nil {
+		renderWebhookError(ctx, err, "retrieve webhook deliveries")
+		return
+	}
+
+	out := make([]models.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		out = append(out, models.NewWebhookDelivery(d))
+	}
+	render(ctx, http.StatusOK, out)
+}
+
+func (h *WebhookDeliveriesHandler) deliveriesOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
+
+// WebhookDeliveryRetryHandler - повтор доставки, у которой кончились попытки
+type WebhookDeliveryRetryHandler struct {
+	uc *usecase.Webhook
+}
+
+func NewWebhookDeliveryRetryHandler(uc *usecase.Webhook) *WebhookDeliveryRetryHandler {
+	return &WebhookDeliveryRetryHandler{uc: uc}
+}
+
+func (h *WebhookDeliveryRetryHandler) GetPath() string {
+	return "/webhooks/:webhook_id/deliveries/:delivery_id/retry"
+}
+
+func (h *WebhookDeliveryRetryHandler) GetAvailableMethods() []string {
+	return []string{http.MethodOptions, http.MethodPost}
+}
+
+func (h *WebhookDeliveryRetryHandler) SetupRouterGroup(r *gin.Engine) {
+	retryGroup := r.Group(h.GetPath())
+	{
+		retryGroup.OPTIONS("", h.retryOptions)
+		retryGroup.POST("", middleware.AcceptValidator(), h.retryDelivery)
+	}
+}
+
+func (h *WebhookDeliveryRetryHandler) retryDelivery(ctx *gin.Context) {
+	webhookID, ok := parsePositiveParam(ctx, "webhook_id")
+	if !ok {
+		return
+	}
+	id, ok := parsePositiveParam(ctx, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.uc.RetryDelivery(ctx, webhookID, id)
+	if err != nil {
+		renderWebhookError(ctx, err, "retry webhook delivery")
+		return
+	}
+
+	render(ctx, http.StatusOK, models.NewWebhookDelivery(*delivery))
+}
+
+func (h *WebhookDeliveryRetryHandler) retryOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/lineprotocol"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxWriteBodySize - ограничение тела запроса до и после распаковки
+	maxWriteBodySize = 16 << 20
+	maxLineSize      = 64 << 10
+	// maxReportedLineErrors - сколько ошибок строк попадает в ответ
+	maxReportedLineErrors = 10
+)
+
+// Теги, из которых берётся серийный номер датчика, в порядке приоритета.
+// Если ни одного нет, серийным номером считается имя измерения.
+var serialNumberTags = []string{"serial_number", "serial"}
+
+var (
+	errNoValueField = errors.New("point has no numeric or boolean fields")
+	errValueType    = errors.New("value must be numeric or boolean")
+)
+
+// WriteHandler - приём событий в формате InfluxDB line protocol, совместимый с /write InfluxDB 1.x
+type WriteHandler struct {
+	uc *usecase.Event
+}
+
+func NewWriteHandler(uc *usecase.Event) *WriteHandler {
+	return &WriteHandler{uc: uc}
+}
+
+func (h *WriteHandler) GetPath() string {
+	return "/write"
+}
+
+func (h *WriteHandler) GetAvailableMethods() []string {
+	return []string{http.MethodPost, http.MethodOptions}
+}
+
+func (h *WriteHandler) SetupRouterGroup(r *gin.Engine) {
+	writeGroup := r.Group(h.GetPath())
+	{
+		writeGroup.OPTIONS("", h.writeOptions)
+		writeGroup.POST("", h.write)
+	}
+}
+
+// write - сохраняет события из всех корректных строк. Если часть строк отклонена,
+// отвечает 400 с описанием ошибок, как InfluxDB при частичной записи: клиент не должен
+// повторять такой запрос. Ошибка хранилища прерывает запись с ответом 500.
+func (h *WriteHandler) write(ctx *gin.Context) {
+	precision, err := lineprotocol.ParsePrecision(ctx.Query("precision"))
+	if err != nil {
+		render(ctx, http.StatusBadRequest, gin.H{"reason": err.Error()})
+		return
+	}
+
+	body := io.ReadCloser(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxWriteBodySize))
+	if ctx.GetHeader("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			render(ctx, http.StatusBadRequest, gin.H{"reason": "Invalid gzip request body"})
+			return
+		}
+		defer gz.Close()
+		body = http.MaxBytesReader(ctx.Writer, gz, maxWriteBodySize)
+	}
+
+	reader := &lineReader{r: body}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	scanner.Split(reader.scanLines)
+
+	var (
+		lineErrors []string
+		rejected   int
+		now        = time.Now()
+	)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if lineprotocol.Skip(line) {
+			continue
+		}
+
+		err := h.writeLine(ctx, line, precision, now)
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, lineprotocol.ErrInvalidLine), errors.Is(err, errNoValueField),
+			errors.Is(err, errValueType), errors.Is(err, usecase.ErrSensorNotFound),
+			errors.Is(err, usecase.ErrInvalidEventTimestamp), errors.Is(err, usecase.ErrPayloadOutOfRange),
+			errors.Is(err, usecase.ErrInvalidEventChannels), errors.Is(err, usecase.ErrVirtualSensor):
+			rejected++
+			if len(lineErrors) < maxReportedLineErrors {
+				lineErrors = append(lineErrors, fmt.Sprintf("line %d: %s", n, err))
+			}
+		default:
+			logging.FromContext(ctx).WarnContext(ctx, "unable to process line protocol event", logging.Error(err))
+			render(ctx, http.StatusInternalServerError, gin.H{"reason": "Internal server error: unable to process event"})
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			render(ctx, http.StatusRequestEntityTooLarge, gin.H{
+				"reason": fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit),
+			})
+			return
+		}
+		render(ctx, http.StatusBadRequest, gin.H{"reason": "Unable to read request body: " + err.Error()})
+		return
+	}
+
+	if rejected > 0 {
+		render(ctx, http.StatusBadRequest, gin.H{
+			"reason": fmt.Sprintf("partial write: %d points rejected: %s", rejected, strings.Join(lineErrors, "; ")),
+		})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// lineReader - запоминает ошибку чтения тела, чтобы строка, оборванная ею, не считалась последней строкой
+type lineReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+// scanLines - bufio.ScanLines, который после ошибки чтения возвращает её вместо незавершённой строки
+func (r *lineReader) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && r.err != nil && bytes.IndexByte(data, '\n') < 0 {
+		return 0, nil, r.err
+	}
+	return bufio.ScanLines(data, atEOF)
+}
+
+func (h *WriteHandler) writeLine(ctx *gin.Context, line string, precision time.Duration, now time.Time) error {
+	point, err := lineprotocol.ParseLine(line, precision)
+	if err != nil {
+		return err
+	}
+
+	event, err := pointToEvent(point)
+	if err != nil {
+		return err
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = now
+	}
+
+	return h.uc.ReceiveEvent(ctx, event)
+}
+
+// pointToEvent - событие датчика из точки: серийный номер из тега или имени измерения,
+// показание из поля value или единственного поля точки. Если числовых и логических полей
+// несколько, каждое становится каналом события, строковые поля пропускаются.
+func pointToEvent(point lineprotocol.Point) (*domain.Event, error) {
+	event := &domain.Event{
+		SensorSerialNumber: point.Measurement,
+		Timestamp:          point.Timestamp,
+	}
+	for _, tag := range serialNumberTags {
+		if serial, ok := point.Tags[tag]; ok {
+			event.SensorSerialNumber = serial
+			break
+		}
+	}
+
+	if value, ok := point.Fields["value"]; ok || len(point.Fields) == 1 {
+		if !ok {
+			for _, v := range point.Fields {
+				value = v
+			}
+		}
+		v, err := fieldValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(point.Fields) == 1 {
+			event.SetValue(v)
+			return event, nil
+		}
+	}
+
+	channels := make(map[string]domain.Value, len(point.Fields))
+	for name, field := range point.Fields {
+		if _, ok := field.(string); ok {
+			continue
+		}
+		v, err := fieldValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+		channels[name] = v
+	}
+	if len(channels) == 0 {
+		return nil, errNoValueField
+	}
+	event.SetChannels(channels)
+
+	return event, nil
+}
+
+func fieldValue(field any) (domain.Value, error) {
+	switch v := field.(type) {
+	case int64:
+		return domain.IntValue(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return domain.Value{}, fmt.Errorf("%w: %d overflows int64", errValueType, v)
+		}
+		return domain.IntValue(int64(v)), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return domain.Value{}, fmt.Errorf("%w: %v is not a finite number", errValueType, v)
+		}
+		return domain.FloatValue(v), nil
+	case bool:
+		return domain.BoolValue(v), nil
+	default:
+		return domain.Value{}, errValueType
+	}
+}
+
+func (h *WriteHandler) writeOptions(ctx *gin.Context) {
+	ctx.Header("Allow", strings.Join(h.GetAvailableMethods(), ","))
+	ctx.Status(http.StatusNoContent)
+}
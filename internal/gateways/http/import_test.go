@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportRoutes(t *testing.T) {
+	router, uc := newInMemoryRouter(t)
+
+	post := func(t *testing.T, query, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/imports"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) models.Import {
+		t.Helper()
+
+		var imp models.Import
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &imp))
+		return imp
+	}
+
+	t.Run("ok, sensors csv", func(t *testing.T) {
+		w := post(t, "?kind=sensors&id=sensors", "text/csv", strings.Join([]string{
+			"serial_number,type,description,is_active",
+			"1234567890,adc,kitchen,true",
+			"123,adc,broken,true",
+			"0987654321,cc,door,false",
+		}, "\n"))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		imp := decode(t, w)
+		assert.Equal(t, "completed", imp.Status)
+		assert.Equal(t, int64(3), imp.RowsProcessed)
+		assert.Equal(t, int64(2), imp.RowsImported)
+		assert.Equal(t, []models.ImportRowError{{Row: 2, Reason: "wrong sensor serial number"}}, imp.Errors)
+
+		sensors, err := uc.Sensor.GetSensors(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, sensors, 2)
+	})
+
+	t.Run("ok, events ndjson and state", func(t *testing.T) {
+		w := post(t, "?kind=events", "application/x-ndjson", strings.Join([]string{
+			`{"sensor_serial_number":"1234567890","timestamp":"2024-03-01T12:00:00Z","payload":21}`,
+			`{"sensor_serial_number":"1111111111","timestamp":"2024-03-01T12:00:00Z","payload":1}`,
+			`{"sensor_serial_number":"1234567890","timestamp":"2024-03-01T12:01:00Z","payload":22}`,
+		}, "\n"))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		imp := decode(t, w)
+		assert.NotEmpty(t, imp.ID)
+		assert.Equal(t, int64(2), imp.RowsImported)
+		assert.Equal(t, int64(1), imp.RowsFailed)
+
+		sensor, err := uc.Sensor.GetSensors(context.Background())
+		require.NoError(t, err)
+		var id int64
+		for _, s := range sensor {
+			if s.SerialNumber == "1234567890" {
+				id = s.ID
+			}
+		}
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		events, err := uc.Event.GetEventsByTimeFrame(context.Background(), id, start, start.Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+
+		req := httptest.NewRequest(http.MethodGet, "/imports/"+imp.ID, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, imp.ID, decode(t, rec).ID)
+	})
+
+	t.Run("ok, repeated completed import is not loaded again", func(t *testing.T) {
+		w := post(t, "?kind=sensors&id=sensors", "text/csv", "serial_number,type\n5555555555,adc\n")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(3), decode(t, w).RowsProcessed)
+
+		sensors, err := uc.Sensor.GetSensors(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, sensors, 2)
+	})
+
+	t.Run("err, request", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, post(t, "?kind=users", "text/csv", "").Code)
+		assert.Equal(t, http.StatusUnsupportedMediaType, post(t, "?kind=events", "application/json", "").Code)
+		assert.Equal(t, http.StatusConflict, post(t, "?kind=events&id=sensors", "text/csv", "").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity,
+			post(t, "?kind=events&format=csv", "application/octet-stream", "serial_number,payload\n").Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/imports/missing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
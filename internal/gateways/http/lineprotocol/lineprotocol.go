@@ -0,0 +1,202 @@
+// Package lineprotocol - разбор строк InfluxDB line protocol:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+package lineprotocol
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidLine      = errors.New("invalid line")
+	ErrInvalidPrecision = errors.New("invalid precision")
+)
+
+// Point - точка из одной строки. Значения полей имеют тип int64, uint64, float64, bool или string.
+// Timestamp нулевой, если время в строке не указано.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]any
+	Timestamp   time.Time
+}
+
+// ParsePrecision - единица времени из параметра precision запроса (InfluxDB 1.x и 2.x)
+func ParsePrecision(s string) (time.Duration, error) {
+	switch s {
+	case "", "n", "ns":
+		return time.Nanosecond, nil
+	case "u", "us", "µ":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPrecision, s)
+	}
+}
+
+// Skip - строка не содержит точки: пустая или комментарий
+func Skip(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || line[0] == '#'
+}
+
+// ParseLine - разбирает одну строку, время в строке задано в единицах precision
+func ParseLine(line string, precision time.Duration) (Point, error) {
+	p := parser{s: strings.TrimRight(line, "\r")}
+
+	measurement, end := p.token(", ", false)
+	if measurement == "" {
+		return Point{}, p.errorf("measurement is required")
+	}
+	point := Point{Measurement: measurement, Fields: make(map[string]any)}
+
+	for end == ',' {
+		var key, value string
+		key, end = p.token("= ,", false)
+		if end != '=' || key == "" {
+			return Point{}, p.errorf("invalid tag")
+		}
+		value, end = p.token(", ", false)
+		if value == "" {
+			return Point{}, p.errorf("tag %q has no value", key)
+		}
+		if point.Tags == nil {
+			point.Tags = make(map[string]string)
+		}
+		point.Tags[key] = value
+	}
+	if end != ' ' {
+		return Point{}, p.errorf("fields are required")
+	}
+
+	for {
+		var key, raw string
+		key, end = p.token("= ,", false)
+		if end != '=' || key == "" {
+			return Point{}, p.errorf("invalid field")
+		}
+		raw, end = p.token(", ", true)
+		value, err := parseFieldValue(raw)
+		if err != nil {
+			return Point{}, p.errorf("field %q: %s", key, err)
+		}
+		point.Fields[key] = value
+		if end != ',' {
+			break
+		}
+	}
+
+	if end == ' ' {
+		raw := strings.TrimSpace(p.s[p.pos:])
+		if raw != "" {
+			ts, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return Point{}, p.errorf("invalid timestamp %q", raw)
+			}
+			point.Timestamp = time.Unix(0, ts*int64(precision))
+		}
+	}
+
+	return point, nil
+}
+
+// escapable - символы, которые экранируются обратной косой чертой в именах, тегах и ключах полей
+const escapable = ", =\\"
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at column %d", ErrInvalidLine, fmt.Sprintf(format, args...), p.pos+1)
+}
+
+// token - читает до первого неэкранированного символа из stops и возвращает прочитанное
+// без экранирования и символ-разделитель (0 в конце строки). Если quoted, разделители
+// внутри двойных кавычек не учитываются и экранирование сохраняется для parseFieldValue.
+func (p *parser) token(stops string, quoted bool) (string, byte) {
+	var (
+		b        strings.Builder
+		inQuotes bool
+	)
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+
+		switch {
+		case c == '\\' && p.pos < len(p.s):
+			next := p.s[p.pos]
+			if quoted || strings.IndexByte(escapable, next) >= 0 {
+				if quoted {
+					b.WriteByte(c)
+				}
+				b.WriteByte(next)
+				p.pos++
+				continue
+			}
+		case quoted && c == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && strings.IndexByte(stops, c) >= 0:
+			return b.String(), c
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String(), 0
+}
+
+func parseFieldValue(raw string) (any, error) {
+	if raw == "" {
+		return nil, errors.New("value is required")
+	}
+
+	if raw[0] == '"' {
+		if len(raw) < 2 || raw[len(raw)-1] != '"' {
+			return nil, errors.New("unterminated string")
+		}
+		r := strings.NewReplacer(`\"`, `"`, `\\`, `\`)
+		return r.Replace(raw[1 : len(raw)-1]), nil
+	}
+
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+
+	switch raw[len(raw)-1] {
+	case 'i':
+		v, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", raw)
+		}
+		return v, nil
+	case 'u':
+		v, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		return v, nil
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", raw)
+	}
+	return v, nil
+}
@@ -0,0 +1,113 @@
+package lineprotocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		precision time.Duration
+		want      Point
+	}{
+		{
+			name:      "all field types",
+			line:      `temp,serial=1234567890,room=kitchen i=42i,u=7u,f=21.5,b=true,s="on" 1700000000000000000`,
+			precision: time.Nanosecond,
+			want: Point{
+				Measurement: "temp",
+				Tags:        map[string]string{"serial": "1234567890", "room": "kitchen"},
+				Fields:      map[string]any{"i": int64(42), "u": uint64(7), "f": 21.5, "b": true, "s": "on"},
+				Timestamp:   time.Unix(1700000000, 0),
+			},
+		},
+		{
+			name:      "no tags and timestamp",
+			line:      "1234567890 value=1i",
+			precision: time.Nanosecond,
+			want: Point{
+				Measurement: "1234567890",
+				Fields:      map[string]any{"value": int64(1)},
+			},
+		},
+		{
+			name:      "escapes",
+			line:      `my\ room,tag\,key=a\=b\ c value="say \"hi\", world" 1700000000`,
+			precision: time.Second,
+			want: Point{
+				Measurement: "my room",
+				Tags:        map[string]string{"tag,key": "a=b c"},
+				Fields:      map[string]any{"value": `say "hi", world`},
+				Timestamp:   time.Unix(1700000000, 0),
+			},
+		},
+		{
+			name:      "trailing carriage return",
+			line:      "m value=-3i 5\r",
+			precision: time.Millisecond,
+			want: Point{
+				Measurement: "m",
+				Fields:      map[string]any{"value": int64(-3)},
+				Timestamp:   time.UnixMilli(5),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("ok, "+tt.name, func(t *testing.T) {
+			point, err := ParseLine(tt.line, tt.precision)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.Measurement, point.Measurement)
+			assert.Equal(t, tt.want.Tags, point.Tags)
+			assert.Equal(t, tt.want.Fields, point.Fields)
+			assert.True(t, tt.want.Timestamp.Equal(point.Timestamp), "timestamp %v", point.Timestamp)
+		})
+	}
+
+	for _, line := range []string{
+		"",
+		"m",
+		"m,tag value=1",
+		"m,=v value=1",
+		"m value",
+		"m value=",
+		`m value="open`,
+		"m value=1x",
+		"m value=12.5i",
+		"m value=1 tomorrow",
+	} {
+		t.Run("err, "+line, func(t *testing.T) {
+			_, err := ParseLine(line, time.Nanosecond)
+			assert.ErrorIs(t, err, ErrInvalidLine)
+		})
+	}
+}
+
+func TestParsePrecision(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"":   time.Nanosecond,
+		"ns": time.Nanosecond,
+		"u":  time.Microsecond,
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"h":  time.Hour,
+	} {
+		got, err := ParsePrecision(s)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, s)
+	}
+
+	_, err := ParsePrecision("d")
+	assert.ErrorIs(t, err, ErrInvalidPrecision)
+}
+
+func TestSkip(t *testing.T) {
+	assert.True(t, Skip(""))
+	assert.True(t, Skip("   "))
+	assert.True(t, Skip("# comment"))
+	assert.False(t, Skip("m value=1"))
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"homework/internal/gateways/http/negotiation"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	responseCodecKey = "negotiation.response_codec"
+	requestCodecKey  = "negotiation.request_codec"
+)
+
+// AcceptValidator - выбирает формат ответа по заголовку Accept с учётом q и шаблонов
+// (*/*, application/*). Если ни один формат не подходит, отвечает 406.
+func AcceptValidator() func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		c, err := negotiation.NegotiateCodec(ctx.GetHeader("Accept"))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
+				"reason": "None of the accepted content types is supported, expected one of " +
+					strings.Join(negotiation.MediaTypes(), ", "),
+			})
+			return
+		}
+		ctx.Set(responseCodecKey, c)
+		ctx.Next()
+	}
+}
+
+// ContentTypeValidator - проверяет, что тело запроса в поддерживаемом формате, иначе отвечает 415
+func ContentTypeValidator() func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		c, err := negotiation.RequestCodec(ctx.GetHeader("Content-Type"))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"reason": "Content type not supported, expected one of " + strings.Join(negotiation.MediaTypes(), ", "),
+			})
+			return
+		}
+		ctx.Set(requestCodecKey, c)
+		ctx.Next()
+	}
+}
+
+// SetResponseCodec - формат ответа для обработчиков, которые выбирают его сами, а не через AcceptValidator
+func SetResponseCodec(ctx *gin.Context, c negotiation.Codec) {
+	ctx.Set(responseCodecKey, c)
+}
+
+// ResponseCodec - формат ответа, выбранный AcceptValidator или SetResponseCodec, по умолчанию JSON
+func ResponseCodec(ctx *gin.Context) negotiation.Codec {
+	if c, ok := ctx.Get(responseCodecKey); ok {
+		return c.(negotiation.Codec)
+	}
+	return negotiation.JSON
+}
+
+// RequestCodec - формат тела запроса, выбранный ContentTypeValidator, по умолчанию JSON
+func RequestCodec(ctx *gin.Context) negotiation.Codec {
+	if c, ok := ctx.Get(requestCodecKey); ok {
+		return c.(negotiation.Codec)
+	}
+	return negotiation.JSON
+}
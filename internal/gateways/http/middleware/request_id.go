@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"homework/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID - берёт X-Request-ID из запроса или генерирует новый, возвращает его в ответе
+// и кладёт в контекст запроса логгер с этим идентификатором
+func RequestID() func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Header(RequestIDHeader, requestID)
+
+		reqCtx := ctx.Request.Context()
+		logger := logging.FromContext(reqCtx).With(logging.RequestID(requestID))
+		ctx.Request = ctx.Request.WithContext(logging.WithContext(reqCtx, logger))
+
+		ctx.Next()
+	}
+}
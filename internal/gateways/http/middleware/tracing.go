@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"homework/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+const TraceIDHeader = "X-Trace-ID"
+
+func Tracing(service string) func(ctx *gin.Context) {
+	return otelgin.Middleware(service)
+}
+
+func TraceID() func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		if traceID := tracing.TraceID(ctx.Request.Context()); traceID != "" {
+			ctx.Header(TraceIDHeader, traceID)
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+)
+
+// AggregateQuery - параметры сводки значений канала: период, канал и длина интервала
+type AggregateQuery struct {
+	TimeFraneQuery
+	Interval string `form:"interval"`
+}
+
+func (m *AggregateQuery) Validate(formats strfmt.Registry) error {
+	if err := m.TimeFraneQuery.Validate(formats); err != nil {
+		return err
+	}
+	if m.Interval != "" {
+		if _, err := time.ParseDuration(m.Interval); err != nil {
+			return errors.InvalidType("interval", "query", "duration", m.Interval)
+		}
+	}
+	return nil
+}
+
+func (m *AggregateQuery) ContextValidate(_ context.Context, _ strfmt.Registry) error {
+	return nil
+}
+
+// GetInterval - длина интервала, 0 - без разбиения
+func (m *AggregateQuery) GetInterval() time.Duration {
+	d, _ := time.ParseDuration(m.Interval)
+	return d
+}
+
+// SensorAggregate - сводка значений канала за интервал, Unit - единица пересчитанных значений
+type SensorAggregate struct {
+	Start time.Time `json:"start"`
+	Count int64     `json:"count"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Avg   float64   `json:"avg"`
+	Sum   float64   `json:"sum"`
+	Unit  string    `json:"unit,omitempty"`
+}
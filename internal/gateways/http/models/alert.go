@@ -0,0 +1,82 @@
+package models
+
+import (
+	"homework/internal/domain"
+	"time"
+)
+
+// AlertRule - правило тревоги в ответах
+type AlertRule struct {
+	ID         int64     `json:"id"`
+	SensorID   int64     `json:"sensor_id"`
+	Name       string    `json:"name"`
+	Kind       string    `json:"kind"`
+	Channel    string    `json:"channel,omitempty"`
+	Operator   string    `json:"operator,omitempty"`
+	Threshold  *float64  `json:"threshold,omitempty"`
+	StaleAfter int64     `json:"stale_after,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewAlertRule(r domain.AlertRule) AlertRule {
+	out := AlertRule{
+		ID:         r.ID,
+		SensorID:   r.SensorID,
+		Name:       r.Name,
+		Kind:       string(r.Kind),
+		Channel:    r.Channel,
+		Operator:   string(r.Operator),
+		StaleAfter: int64(r.StaleAfter / time.Second),
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+	if r.Kind == domain.AlertRuleThreshold {
+		out.Threshold = &r.Threshold
+	}
+	return out
+}
+
+// Alert - тревога в ответах и в потоке изменений тревог
+type Alert struct {
+	ID             int64      `json:"id"`
+	RuleID         int64      `json:"rule_id,omitempty"`
+	SensorID       int64      `json:"sensor_id"`
+	Kind           string     `json:"kind"`
+	Status         string     `json:"status"`
+	Message        string     `json:"message"`
+	Value          *float64   `json:"value,omitempty"`
+	Count          int64      `json:"count"`
+	OpenedAt       time.Time  `json:"opened_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	AcknowledgedBy int64      `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedBy     int64      `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	Revision       int64      `json:"revision"`
+}
+
+func NewAlert(a domain.Alert) Alert {
+	out := Alert{
+		ID:             a.ID,
+		RuleID:         a.RuleID,
+		SensorID:       a.SensorID,
+		Kind:           string(a.Kind),
+		Status:         string(a.Status),
+		Message:        a.Message,
+		Value:          a.Value,
+		Count:          a.Count,
+		OpenedAt:       a.OpenedAt,
+		LastSeenAt:     a.LastSeenAt,
+		AcknowledgedBy: a.AcknowledgedBy,
+		ResolvedBy:     a.ResolvedBy,
+		Revision:       a.Revision,
+	}
+	if !a.AcknowledgedAt.IsZero() {
+		out.AcknowledgedAt = &a.AcknowledgedAt
+	}
+	if !a.ResolvedAt.IsZero() {
+		out.ResolvedAt = &a.ResolvedAt
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"homework/internal/domain"
+	"time"
+)
+
+// Command - команда исполнительному устройству и состояние её доставки.
+// DeliveredAt и ConfirmedAt не заданы, пока команда не доставлена (не подтверждена).
+type Command struct {
+	ID          int64      `json:"id"`
+	DeviceID    int64      `json:"device_id"`
+	Kind        string     `json:"kind"`
+	State       int64      `json:"state"`
+	DurationMs  int64      `json:"duration_ms,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// NewCommand - представление команды в ответах и в потоке WebSocket
+func NewCommand(c domain.Command) Command {
+	out := Command{
+		ID:         c.ID,
+		DeviceID:   c.SensorID,
+		Kind:       string(c.Kind),
+		State:      c.State,
+		DurationMs: c.Duration.Milliseconds(),
+		Status:     string(c.Status),
+		CreatedAt:  c.CreatedAt,
+		ExpiresAt:  c.ExpiresAt,
+	}
+	if !c.DeliveredAt.IsZero() {
+		out.DeliveredAt = &c.DeliveredAt
+	}
+	if !c.ConfirmedAt.IsZero() {
+		out.ConfirmedAt = &c.ConfirmedAt
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"homework/internal/domain"
+
+	"github.com/go-openapi/strfmt"
+)
+
+// EventPayload - значение события: целое или дробное число, true/false
+// или объект {"канал": значение} для датчиков с несколькими каналами
+type EventPayload struct {
+	Value    domain.Value
+	Channels map[string]domain.Value
+}
+
+// NewEventPayload - значение события в том виде, в котором оно было принято
+func NewEventPayload(event domain.Event) *EventPayload {
+	if len(event.Channels) == 0 {
+		return &EventPayload{Value: domain.IntValue(event.Payload)}
+	}
+	if v, ok := event.Channels[domain.DefaultChannel]; ok && len(event.Channels) == 1 {
+		return &EventPayload{Value: v}
+	}
+	return &EventPayload{Channels: event.Channels}
+}
+
+// Apply - записывает значение в событие
+func (m *EventPayload) Apply(event *domain.Event) {
+	if m.Channels != nil {
+		event.SetChannels(m.Channels)
+		return
+	}
+	event.SetValue(m.Value)
+}
+
+// Validate validates this event payload
+func (m *EventPayload) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this event payload based on context it is used
+func (m *EventPayload) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+func (m EventPayload) MarshalJSON() ([]byte, error) {
+	if m.Channels != nil {
+		return json.Marshal(m.Channels)
+	}
+	return m.Value.MarshalJSON()
+}
+
+func (m *EventPayload) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		channels, err := domain.ParseChannels(data)
+		if err != nil {
+			return err
+		}
+		*m = EventPayload{Channels: channels}
+		return nil
+	}
+
+	var v domain.Value
+	if err := v.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*m = EventPayload{Value: v}
+	return nil
+}
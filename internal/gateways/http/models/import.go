@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Import - состояние загрузки файла датчиков или событий
+type Import struct {
+	ID            string           `json:"id"`
+	Kind          string           `json:"kind"`
+	Status        string           `json:"status"`
+	RowsProcessed int64            `json:"rows_processed"`
+	RowsImported  int64            `json:"rows_imported"`
+	RowsFailed    int64            `json:"rows_failed"`
+	Errors        []ImportRowError `json:"errors"`
+	Error         string           `json:"error,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+type ImportRowError struct {
+	Row    int64  `json:"row"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"homework/internal/domain"
+	"time"
+)
+
+// SceneTarget - настройки, которые сцена применяет к датчику
+type SceneTarget struct {
+	SensorID    int64   `json:"sensor_id"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+	Description *string `json:"description,omitempty"`
+	State       *int64  `json:"state,omitempty"`
+}
+
+// Scene - сцена в ответах
+type Scene struct {
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Targets     []SceneTarget `json:"targets"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+func NewScene(s domain.Scene) Scene {
+	out := Scene{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		Targets:     make([]SceneTarget, 0, len(s.Targets)),
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	for _, t := range s.Targets {
+		out.Targets = append(out.Targets, SceneTarget(t))
+	}
+	return out
+}
+
+// SceneTargetResult - результат применения настроек к датчику: ошибка или ID поставленной команды
+type SceneTargetResult struct {
+	SensorID  int64  `json:"sensor_id"`
+	Error     string `json:"error,omitempty"`
+	CommandID int64  `json:"command_id,omitempty"`
+}
+
+// SceneActivation - запись истории применения сцены
+type SceneActivation struct {
+	ID          int64               `json:"id"`
+	SceneID     int64               `json:"scene_id"`
+	Status      string              `json:"status"`
+	ActivatedAt time.Time           `json:"activated_at"`
+	Results     []SceneTargetResult `json:"results"`
+}
+
+func NewSceneActivation(a domain.SceneActivation) SceneActivation {
+	out := SceneActivation{
+		ID:          a.ID,
+		SceneID:     a.SceneID,
+		Status:      string(a.Status),
+		ActivatedAt: a.ActivatedAt,
+		Results:     make([]SceneTargetResult, 0, len(a.Results)),
+	}
+	for _, r := range a.Results {
+		out.Results = append(out.Results, SceneTargetResult(r))
+	}
+	return out
+}
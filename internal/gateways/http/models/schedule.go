@@ -0,0 +1,82 @@
+package models
+
+import (
+	"homework/internal/domain"
+	"time"
+)
+
+// ScheduleAction - действие расписания в ответах
+type ScheduleAction struct {
+	Kind        string  `json:"kind"`
+	SensorIDs   []int64 `json:"sensor_ids,omitempty"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+	Description *string `json:"description,omitempty"`
+	SceneID     int64   `json:"scene_id,omitempty"`
+	Command     string  `json:"command,omitempty"`
+	State       *int64  `json:"state,omitempty"`
+	URL         string  `json:"url,omitempty"`
+}
+
+// Schedule - расписание в ответах. NextRunAt не задано у выключенного расписания.
+type Schedule struct {
+	ID         int64          `json:"id"`
+	Name       string         `json:"name"`
+	Cron       string         `json:"cron"`
+	Timezone   string         `json:"timezone"`
+	Action     ScheduleAction `json:"action"`
+	MissedRuns string         `json:"missed_runs"`
+	Enabled    bool           `json:"enabled"`
+	NextRunAt  *time.Time     `json:"next_run_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func NewSchedule(s domain.Schedule) Schedule {
+	out := Schedule{
+		ID:       s.ID,
+		Name:     s.Name,
+		Cron:     s.Cron,
+		Timezone: s.Timezone,
+		Action: ScheduleAction{
+			Kind:        string(s.Action.Kind),
+			SensorIDs:   s.Action.SensorIDs,
+			IsActive:    s.Action.IsActive,
+			Description: s.Action.Description,
+			SceneID:     s.Action.SceneID,
+			Command:     string(s.Action.CommandKind),
+			URL:         s.Action.URL,
+		},
+		MissedRuns: string(s.MissedRuns),
+		Enabled:    s.Enabled,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+	if s.Action.CommandKind == domain.CommandSetState {
+		out.Action.State = &s.Action.State
+	}
+	if !s.NextRunAt.IsZero() {
+		out.NextRunAt = &s.NextRunAt
+	}
+	return out
+}
+
+// ScheduleRun - запись истории запусков расписания
+type ScheduleRun struct {
+	ID          int64     `json:"id"`
+	ScheduleID  int64     `json:"schedule_id"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	StartedAt   time.Time `json:"started_at"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+func NewScheduleRun(r domain.ScheduleRun) ScheduleRun {
+	return ScheduleRun{
+		ID:          r.ID,
+		ScheduleID:  r.ScheduleID,
+		ScheduledAt: r.ScheduledAt,
+		StartedAt:   r.StartedAt,
+		Status:      string(r.Status),
+		Error:       r.Error,
+	}
+}
@@ -7,7 +7,8 @@ package models
 
 import (
 	"context"
-	"encoding/json"
+
+	"homework/internal/domain"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -18,15 +19,21 @@ import (
 // Sensor Sensor
 //
 // Датчик умного дома
-// Example: {"current_state":1,"description":"Датчик температуры","id":1,"is_active":true,"last_activity":"2018-01-01T00:00:00Z","registered_at":"2018-01-01T00:00:00Z","serial_number":"1234567890","type":"cc"}
+// Example: {"current_state":1,"current_value":1,"description":"Датчик температуры","id":1,"is_active":true,"last_activity":"2018-01-01T00:00:00Z","registered_at":"2018-01-01T00:00:00Z","serial_number":"1234567890","type":"cc"}
 //
 // swagger:model Sensor
 type Sensor struct {
 
+	// Калибровка сырых значений, если не задана - значения не преобразуются
+	Calibration *Calibration `json:"calibration,omitempty"`
+
 	// Состояние датчика, соответствует значению в payload последнего обработанного события.
 	// Required: true
 	CurrentState *int64 `json:"current_state"`
 
+	// Значение основного канала последнего обработанного события без округления
+	CurrentValue domain.Value `json:"current_value"`
+
 	// Описание
 	// Required: true
 	Description *string `json:"description"`
@@ -57,14 +64,21 @@ type Sensor struct {
 
 	// Тип
 	// Required: true
-	// Enum: [cc adc]
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
 	Type *string `json:"type"`
+
+	// Единица измерения значений после калибровки, если не задана - единица типа датчика
+	Unit string `json:"unit,omitempty"`
 }
 
 // Validate validates this sensor
 func (m *Sensor) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateCalibration(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateCurrentState(formats); err != nil {
 		res = append(res, err)
 	}
@@ -103,6 +117,25 @@ func (m *Sensor) Validate(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *Sensor) validateCalibration(formats strfmt.Registry) error {
+	if swag.IsZero(m.Calibration) { // not required
+		return nil
+	}
+
+	if m.Calibration != nil {
+		if err := m.Calibration.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("calibration")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("calibration")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *Sensor) validateCurrentState(formats strfmt.Registry) error {
 
 	if err := validate.Required("current_state", "body", m.CurrentState); err != nil {
@@ -182,43 +215,13 @@ func (m *Sensor) validateSerialNumber(formats strfmt.Registry) error {
 	return nil
 }
 
-var sensorTypeTypePropEnum []interface{}
-
-func init() {
-	var res []string
-	if err := json.Unmarshal([]byte(`["cc","adc"]`), &res); err != nil {
-		panic(err)
-	}
-	for _, v := range res {
-		sensorTypeTypePropEnum = append(sensorTypeTypePropEnum, v)
-	}
-}
-
-const (
-
-	// SensorTypeCc captures enum value "cc"
-	SensorTypeCc string = "cc"
-
-	// SensorTypeAdc captures enum value "adc"
-	SensorTypeAdc string = "adc"
-)
-
-// prop value enum
-func (m *Sensor) validateTypeEnum(path, location string, value string) error {
-	if err := validate.EnumCase(path, location, value, sensorTypeTypePropEnum, true); err != nil {
-		return err
-	}
-	return nil
-}
-
 func (m *Sensor) validateType(formats strfmt.Registry) error {
 
 	if err := validate.Required("type", "body", m.Type); err != nil {
 		return err
 	}
 
-	// value enum
-	if err := m.validateTypeEnum("type", "body", *m.Type); err != nil {
+	if err := validate.Pattern("type", "body", *m.Type, `^[a-z][a-z0-9_]{0,31}$`); err != nil {
 		return err
 	}
 
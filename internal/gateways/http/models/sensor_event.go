@@ -17,14 +17,14 @@ import (
 // SensorEvent SensorEvent
 //
 // Событие датчика
-// Example: {"payload":10,"sensor_serial_number":"1234567890"}
+// Example: {"payload":{"humidity":40,"temperature":21.5},"sensor_serial_number":"1234567890"}
 //
 // swagger:model SensorEvent
 type SensorEvent struct {
 
-	// Информация от датчика
+	// Информация от датчика: целое или дробное число, true/false или объект с каналами
 	// Required: true
-	Payload *int64 `json:"payload"`
+	Payload *EventPayload `json:"payload"`
 
 	// Серийный номер датчика
 	// Required: true
@@ -56,6 +56,15 @@ func (m *SensorEvent) validatePayload(formats strfmt.Registry) error {
 		return err
 	}
 
+	if m.Payload != nil {
+		if err := m.Payload.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("payload")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -1,8 +1,19 @@
 package models
 
-import "time"
+import (
+	"homework/internal/domain"
+	"time"
+)
 
+// SensorStatus - событие в истории датчика. Payload - целое значение основного канала,
+// Channels - значения всех каналов, если их несколько или значение не целое,
+// Value - значение канала, запрошенного параметром channel,
+// Converted - значение канала после калибровки в единицах Unit.
 type SensorStatus struct {
-	Timestamp time.Time `json:"timestamp"`
-	Payload   int64     `json:"payload"`
+	Timestamp time.Time               `json:"timestamp"`
+	Payload   int64                   `json:"payload"`
+	Channels  map[string]domain.Value `json:"channels,omitempty"`
+	Value     *domain.Value           `json:"value,omitempty"`
+	Converted *float64                `json:"converted,omitempty"`
+	Unit      string                  `json:"unit,omitempty"`
 }
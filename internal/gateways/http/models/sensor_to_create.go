@@ -7,7 +7,6 @@ package models
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -23,6 +22,9 @@ import (
 // swagger:model SensorToCreate
 type SensorToCreate struct {
 
+	// Калибровка сырых значений
+	Calibration *Calibration `json:"calibration,omitempty"`
+
 	// Описание
 	// Required: true
 	Description *string `json:"description"`
@@ -38,14 +40,22 @@ type SensorToCreate struct {
 
 	// Тип
 	// Required: true
-	// Enum: [cc adc]
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
 	Type *string `json:"type"`
+
+	// Единица измерения значений после калибровки, если не задана - единица типа датчика
+	// Max Length: 16
+	Unit string `json:"unit,omitempty"`
 }
 
 // Validate validates this sensor to create
 func (m *SensorToCreate) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateCalibration(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateDescription(formats); err != nil {
 		res = append(res, err)
 	}
@@ -62,12 +72,35 @@ func (m *SensorToCreate) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateUnit(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
 	return nil
 }
 
+func (m *SensorToCreate) validateCalibration(formats strfmt.Registry) error {
+	if swag.IsZero(m.Calibration) { // not required
+		return nil
+	}
+
+	if m.Calibration != nil {
+		if err := m.Calibration.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("calibration")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("calibration")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *SensorToCreate) validateDescription(formats strfmt.Registry) error {
 
 	if err := validate.Required("description", "body", m.Description); err != nil {
@@ -99,43 +132,25 @@ func (m *SensorToCreate) validateSerialNumber(formats strfmt.Registry) error {
 	return nil
 }
 
-var sensorToCreateTypeTypePropEnum []interface{}
+func (m *SensorToCreate) validateType(formats strfmt.Registry) error {
 
-func init() {
-	var res []string
-	if err := json.Unmarshal([]byte(`["cc","adc"]`), &res); err != nil {
-		panic(err)
-	}
-	for _, v := range res {
-		sensorToCreateTypeTypePropEnum = append(sensorToCreateTypeTypePropEnum, v)
+	if err := validate.Required("type", "body", m.Type); err != nil {
+		return err
 	}
-}
-
-const (
-
-	// SensorToCreateTypeCc captures enum value "cc"
-	SensorToCreateTypeCc string = "cc"
 
-	// SensorToCreateTypeAdc captures enum value "adc"
-	SensorToCreateTypeAdc string = "adc"
-)
-
-// prop value enum
-func (m *SensorToCreate) validateTypeEnum(path, location string, value string) error {
-	if err := validate.EnumCase(path, location, value, sensorToCreateTypeTypePropEnum, true); err != nil {
+	if err := validate.Pattern("type", "body", *m.Type, `^[a-z][a-z0-9_]{0,31}$`); err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func (m *SensorToCreate) validateType(formats strfmt.Registry) error {
-
-	if err := validate.Required("type", "body", m.Type); err != nil {
-		return err
+func (m *SensorToCreate) validateUnit(formats strfmt.Registry) error {
+	if swag.IsZero(m.Unit) { // not required
+		return nil
 	}
 
-	// value enum
-	if err := m.validateTypeEnum("type", "body", *m.Type); err != nil {
+	if err := validate.MaxLength("unit", "body", m.Unit, 16); err != nil {
 		return err
 	}
 
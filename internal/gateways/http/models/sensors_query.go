@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+)
+
+// SensorsQuery - фильтры, сортировка и страница списка датчиков
+type SensorsQuery struct {
+	Type         *string `form:"type"`
+	IsActive     *bool   `form:"is_active"`
+	Online       *bool   `form:"online"`
+	Search       *string `form:"q"`
+	SerialPrefix *string `form:"serial_prefix"`
+	// Sort - поле сортировки, с минусом - по убыванию
+	Sort   *string `form:"sort"`
+	Limit  *int64  `form:"limit"`
+	Cursor *string `form:"cursor"`
+}
+
+const MaxSensorsLimit = 1000
+
+var sensorsQuerySortEnum = []interface{}{"id", "serial_number", "registered_at", "last_activity"}
+
+func (m *SensorsQuery) Validate(_ strfmt.Registry) error {
+	if m.Type != nil {
+		if err := validate.Pattern("type", "query", *m.Type, `^[a-z][a-z0-9_]{0,31}$`); err != nil {
+			return err
+		}
+	}
+	if m.Sort != nil {
+		if err := validate.EnumCase("sort", "query", strings.TrimPrefix(*m.Sort, "-"), sensorsQuerySortEnum, true); err != nil {
+			return err
+		}
+	}
+	if m.Limit != nil {
+		if err := validate.MinimumInt("limit", "query", *m.Limit, 1, false); err != nil {
+			return err
+		}
+		if err := validate.MaximumInt("limit", "query", *m.Limit, MaxSensorsLimit, false); err != nil {
+			return err
+		}
+	}
+	if m.SerialPrefix != nil {
+		if err := validate.MaxLength("serial_prefix", "query", *m.SerialPrefix, 64); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *SensorsQuery) ContextValidate(_ context.Context, _ strfmt.Registry) error {
+	return nil
+}
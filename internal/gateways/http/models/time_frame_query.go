@@ -8,9 +8,14 @@ import (
 	"github.com/go-openapi/validate"
 )
 
+// TimeFraneQuery - период и канал истории. Values = converted или заданная Unit
+// включают значения после калибровки датчика.
 type TimeFraneQuery struct {
-	Start *time.Time `form:"start_date"`
-	End   *time.Time `form:"end_date"`
+	Start   *time.Time `form:"start_date"`
+	End     *time.Time `form:"end_date"`
+	Channel string     `form:"channel"`
+	Values  string     `form:"values"`
+	Unit    string     `form:"unit"`
 }
 
 func (m *TimeFraneQuery) Validate(_ strfmt.Registry) error {
@@ -20,9 +25,36 @@ func (m *TimeFraneQuery) Validate(_ strfmt.Registry) error {
 	if err := validate.Required("end_date", "query", m.End); err != nil {
 		return err
 	}
+	if m.Channel != "" {
+		if err := validate.Pattern("channel", "query", m.Channel, `^[A-Za-z0-9_.-]{1,64}$`); err != nil {
+			return err
+		}
+	}
+	if m.Values != "" {
+		if err := validate.EnumCase("values", "query", m.Values, []interface{}{ValuesRaw, ValuesConverted}, true); err != nil {
+			return err
+		}
+	}
+	if m.Unit != "" {
+		if err := validate.MaxLength("unit", "query", m.Unit, 16); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+const (
+	// ValuesRaw - значения в том виде, в каком их прислал датчик
+	ValuesRaw = "raw"
+	// ValuesConverted - значения после калибровки в единицах датчика или запрошенной единице
+	ValuesConverted = "converted"
+)
+
+// Converted - нужны ли значения после калибровки
+func (m *TimeFraneQuery) Converted() bool {
+	return m.Values == ValuesConverted || m.Unit != ""
+}
+
 func (m *TimeFraneQuery) ContextValidate(_ context.Context, _ strfmt.Registry) error {
 	return nil
 }
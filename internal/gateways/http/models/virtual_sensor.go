@@ -0,0 +1,25 @@
+package models
+
+import (
+	"homework/internal/domain"
+	"time"
+)
+
+// VirtualSensor - выражение виртуального датчика в ответах
+type VirtualSensor struct {
+	SensorID   int64     `json:"sensor_id"`
+	Expression string    `json:"expression"`
+	Inputs     []int64   `json:"inputs"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewVirtualSensor(vs domain.VirtualSensor) VirtualSensor {
+	return VirtualSensor{
+		SensorID:   vs.SensorID,
+		Expression: vs.Expression,
+		Inputs:     append(make([]int64, 0, len(vs.Inputs)), vs.Inputs...),
+		CreatedAt:  vs.CreatedAt,
+		UpdatedAt:  vs.UpdatedAt,
+	}
+}
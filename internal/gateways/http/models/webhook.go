@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"homework/internal/domain"
+	"time"
+)
+
+// Webhook - подписка в ответах. Ключ подписи отдаётся только при создании.
+type Webhook struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Topics      []string  `json:"topics"`
+	SensorIDs   []int64   `json:"sensor_ids"`
+	UserID      int64     `json:"user_id,omitempty"`
+	SensorTypes []string  `json:"sensor_types"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func NewWebhook(w domain.Webhook) Webhook {
+	out := Webhook{
+		ID:          w.ID,
+		URL:         w.URL,
+		Topics:      make([]string, 0, len(w.Topics)),
+		SensorIDs:   make([]int64, 0, len(w.SensorIDs)),
+		UserID:      w.UserID,
+		SensorTypes: make([]string, 0, len(w.SensorTypes)),
+		Enabled:     w.Enabled,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+	for _, topic := range w.Topics {
+		out.Topics = append(out.Topics, string(topic))
+	}
+	out.SensorIDs = append(out.SensorIDs, w.SensorIDs...)
+	for _, t := range w.SensorTypes {
+		out.SensorTypes = append(out.SensorTypes, string(t))
+	}
+	return out
+}
+
+// WebhookDelivery - доставка в журнале доставок подписки
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Topic          string          `json:"topic"`
+	SensorID       int64           `json:"sensor_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+func NewWebhookDelivery(d domain.WebhookDelivery) WebhookDelivery {
+	out := WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Topic:          string(d.Topic),
+		SensorID:       d.SensorID,
+		Payload:        json.RawMessage(d.Payload),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+	// время следующей попытки имеет смысл только для ожидающей доставки
+	if d.Status == domain.WebhookDeliveryPending {
+		out.NextAttemptAt = &d.NextAttemptAt
+	}
+	if !d.LastAttemptAt.IsZero() {
+		out.LastAttemptAt = &d.LastAttemptAt
+	}
+	if !d.DeliveredAt.IsZero() {
+		out.DeliveredAt = &d.DeliveredAt
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package negotiation
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec - кодирование тел запросов и ответов в одном формате
+type Codec interface {
+	// ContentType - значение заголовка Content-Type ответа
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeCBOR        = "application/cbor"
+	MediaTypeMessagePack = "application/msgpack"
+)
+
+var (
+	JSON        Codec = jsonCodec{}
+	CBOR        Codec = newCBOR()
+	MessagePack Codec = newMessagePack()
+)
+
+// mapType - объекты декодируются со строковыми ключами, чтобы их можно было перевести в JSON
+var mapType = reflect.TypeOf(map[string]any(nil))
+
+// codecs - кодеки по типам содержимого в порядке предпочтения: при Accept: */* отвечаем JSON
+var codecs = []struct {
+	mediaType string
+	codec     Codec
+}{
+	{MediaTypeJSON, JSON},
+	{MediaTypeCBOR, CBOR},
+	{MediaTypeMessagePack, MessagePack},
+	{"application/x-msgpack", MessagePack},
+	{"application/vnd.msgpack", MessagePack},
+}
+
+// MediaTypes - поддерживаемые типы содержимого
+func MediaTypes() []string {
+	out := make([]string, 0, len(codecs))
+	for _, c := range codecs {
+		out = append(out, c.mediaType)
+	}
+	return out
+}
+
+// Lookup - кодек типа содержимого mediaType без параметров
+func Lookup(mediaType string) (Codec, bool) {
+	for _, c := range codecs {
+		if c.mediaType == mediaType {
+			return c.codec, true
+		}
+	}
+	return nil, false
+}
+
+// NegotiateCodec - кодек ответа по заголовку Accept
+func NegotiateCodec(accept string) (Codec, error) {
+	mediaType, err := Negotiate(accept, MediaTypes())
+	if err != nil {
+		return nil, err
+	}
+	c, _ := Lookup(mediaType)
+	return c, nil
+}
+
+// RequestCodec - кодек тела запроса по заголовку Content-Type
+func RequestCodec(contentType string) (Codec, error) {
+	mediaType, err := ContentType(contentType, MediaTypes())
+	if err != nil {
+		return nil, err
+	}
+	c, _ := Lookup(mediaType)
+	return c, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json; charset=utf-8" }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// treeCodec - двоичный формат, в который значение переводится через JSON представление.
+// Так имена полей, форматы дат и проверки моделей одинаковы во всех форматах.
+type treeCodec struct {
+	contentType string
+	handle      codec.Handle
+}
+
+func newCBOR() *treeCodec {
+	h := &codec.CborHandle{}
+	h.MapType = mapType
+
+	return &treeCodec{contentType: MediaTypeCBOR, handle: h}
+}
+
+func newMessagePack() *treeCodec {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.MapType = mapType
+	h.RawToString = true
+
+	return &treeCodec{contentType: MediaTypeMessagePack, handle: h}
+}
+
+func (c *treeCodec) ContentType() string { return c.contentType }
+
+func (c *treeCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, c.handle).Encode(normalize(tree)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *treeCodec) Unmarshal(data []byte, v any) error {
+	var tree any
+	if err := codec.NewDecoderBytes(data, c.handle).Decode(&tree); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// normalize - заменяет json.Number целыми или дробными числами
+func normalize(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalize(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,142 @@
+// Package negotiation - выбор формата тела запроса и ответа по заголовкам Content-Type и Accept
+package negotiation
+
+import (
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotAcceptable        = errors.New("no acceptable media type")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// MediaRange - элемент заголовка Accept
+type MediaRange struct {
+	Type    string
+	Subtype string
+	Q       float64
+	// order - позиция в заголовке, при равном q выигрывает более ранний диапазон
+	order int
+}
+
+// ParseAccept - диапазоны из заголовка Accept. Некорректные элементы пропускаются,
+// параметры кроме q не учитываются.
+func ParseAccept(accept string) []MediaRange {
+	var ranges []MediaRange
+
+	for i, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, MediaRange{Type: typ, Subtype: subtype, Q: q, order: i})
+	}
+
+	return ranges
+}
+
+// specificity - 0, если диапазон не подходит типу, иначе тем больше, чем точнее совпадение
+func (r MediaRange) specificity(typ, subtype string) int {
+	switch {
+	case r.Type == typ && r.Subtype == subtype:
+		return 3
+	case r.Type == typ && r.Subtype == "*":
+		return 2
+	case r.Type == "*":
+		return 1
+	}
+	return 0
+}
+
+// Negotiate - лучший из offers для заголовка Accept. Каждому варианту соответствует самый точный
+// подходящий диапазон (RFC 9110, 12.5.1), выбирается вариант с наибольшим q. При равном q выигрывает
+// вариант, чей диапазон раньше в заголовке, затем - раньше указанный в offers, поэтому */* и пустой
+// заголовок означают первый вариант.
+func Negotiate(accept string, offers []string) (string, error) {
+	if len(offers) == 0 {
+		return "", ErrNotAcceptable
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], nil
+	}
+
+	ranges := ParseAccept(accept)
+
+	type candidate struct {
+		offer string
+		q     float64
+		// order - позиция подошедшего диапазона в заголовке
+		order int
+	}
+	var candidates []candidate
+
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+
+		best, bestSpecificity := MediaRange{}, 0
+		for _, r := range ranges {
+			if s := r.specificity(typ, subtype); s > bestSpecificity {
+				best, bestSpecificity = r, s
+			}
+		}
+		if bestSpecificity == 0 || best.Q == 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{offer: offer, q: best.Q, order: best.order})
+	}
+
+	if len(candidates) == 0 {
+		return "", ErrNotAcceptable
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].q != candidates[j].q {
+			return candidates[i].q > candidates[j].q
+		}
+		return candidates[i].order < candidates[j].order
+	})
+
+	return candidates[0].offer, nil
+}
+
+// ContentType - тип из заголовка Content-Type, если он есть среди supported.
+// Параметр charset допускается только utf-8.
+func ContentType(contentType string, supported []string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnsupportedMediaType
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return "", ErrUnsupportedMediaType
+	}
+
+	for _, s := range supported {
+		if s == mediaType {
+			return mediaType, nil
+		}
+	}
+
+	return "", ErrUnsupportedMediaType
+}
@@ -0,0 +1,108 @@
+package negotiation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "application/cbor", "application/msgpack"}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/*", "application/json"},
+		{"application/json; charset=utf-8", "application/json"},
+		{"application/cbor", "application/cbor"},
+		{"text/html, application/msgpack;q=0.8, */*;q=0.1", "application/msgpack"},
+		{"application/json;q=0.5, application/cbor", "application/cbor"},
+		{"application/cbor, application/json", "application/cbor"},
+		{"application/json;q=0, */*", "application/cbor"},
+		{"*/*;q=0.5, application/cbor;q=0", "application/json"},
+		{"application/json;q=abc, application/cbor", "application/cbor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			got, err := Negotiate(tt.accept, offers)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, accept := range []string{"application/xml", "text/*", "application/*;q=0", "*/json"} {
+		_, err := Negotiate(accept, offers)
+		assert.ErrorIs(t, err, ErrNotAcceptable, accept)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	for _, contentType := range []string{"application/json", "application/json; charset=utf-8", "Application/JSON; charset=UTF-8"} {
+		got, err := ContentType(contentType, MediaTypes())
+		require.NoError(t, err, contentType)
+		assert.Equal(t, MediaTypeJSON, got)
+	}
+
+	for _, contentType := range []string{"", "application/xml", "application/json; charset=latin1", "application/json;;"} {
+		_, err := ContentType(contentType, MediaTypes())
+		assert.ErrorIs(t, err, ErrUnsupportedMediaType, contentType)
+	}
+
+	c, err := RequestCodec("application/x-msgpack")
+	require.NoError(t, err)
+	assert.Equal(t, MessagePack, c)
+}
+
+type testModel struct {
+	ID        *int64    `json:"id"`
+	Name      string    `json:"name"`
+	Ratio     float64   `json:"ratio"`
+	Tags      []string  `json:"tags"`
+	Timestamp time.Time `json:"timestamp"`
+	Skipped   string    `json:"-"`
+}
+
+func TestCodecs(t *testing.T) {
+	id := int64(1) << 40
+	in := testModel{
+		ID:        &id,
+		Name:      "Датчик",
+		Ratio:     0.25,
+		Tags:      []string{"kitchen"},
+		Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Skipped:   "skipped",
+	}
+
+	for _, c := range []Codec{JSON, CBOR, MessagePack} {
+		t.Run(c.ContentType(), func(t *testing.T) {
+			data, err := c.Marshal(in)
+			require.NoError(t, err)
+
+			var out testModel
+			require.NoError(t, c.Unmarshal(data, &out))
+
+			want := in
+			want.Skipped = ""
+			assert.Equal(t, want, out)
+		})
+	}
+
+	t.Run("field names follow json tags", func(t *testing.T) {
+		data, err := CBOR.Marshal(map[string]int{"a": 1})
+		require.NoError(t, err)
+		// map(1) "a" 1
+		assert.Equal(t, []byte{0xa1, 0x61, 'a', 0x01}, data)
+	})
+
+	t.Run("err, invalid body", func(t *testing.T) {
+		var out testModel
+		assert.Error(t, CBOR.Unmarshal([]byte{0xff, 0x00}, &out))
+		assert.Error(t, MessagePack.Unmarshal([]byte{0xc1}, &out))
+		assert.Error(t, JSON.Unmarshal([]byte("{"), &out))
+	})
+}
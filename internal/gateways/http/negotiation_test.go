@@ -0,0 +1,106 @@
+package http
+
+import (
+	"bytes"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/gateways/http/negotiation"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	router, _ := newInMemoryRouter(t, domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC, Description: "kitchen"})
+
+	do := func(t *testing.T, method, path string, body []byte, headers ...string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, json accept variants", func(t *testing.T) {
+		for _, accept := range []string{"", "*/*", "application/*", "application/json; charset=utf-8", "text/html, application/json;q=0.5"} {
+			w := do(t, http.MethodGet, "/sensors", nil, "Accept", accept)
+			require.Equal(t, http.StatusOK, w.Code, accept)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"), accept)
+		}
+	})
+
+	t.Run("ok, binary formats", func(t *testing.T) {
+		for _, c := range []negotiation.Codec{negotiation.CBOR, negotiation.MessagePack} {
+			w := do(t, http.MethodGet, "/sensors", nil, "Accept", "application/json;q=0.1, "+c.ContentType())
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, c.ContentType(), w.Header().Get("Content-Type"))
+
+			var sensors []models.Sensor
+			require.NoError(t, c.Unmarshal(w.Body.Bytes(), &sensors))
+			require.Len(t, sensors, 1)
+			assert.Equal(t, "kitchen", *sensors[0].Description)
+
+			head := do(t, http.MethodHead, "/sensors", nil, "Accept", c.ContentType())
+			assert.Equal(t, c.ContentType(), head.Header().Get("Content-Type"))
+			assert.Equal(t, strconv.Itoa(w.Body.Len()), head.Header().Get("Content-Length"))
+		}
+	})
+
+	t.Run("ok, cbor request and msgpack response", func(t *testing.T) {
+		body, err := negotiation.CBOR.Marshal(map[string]any{
+			"serial_number": "0987654321",
+			"type":          "cc",
+			"description":   "door",
+			"is_active":     true,
+		})
+		require.NoError(t, err)
+
+		w := do(t, http.MethodPost, "/sensors", body, "Content-Type", "application/cbor", "Accept", "application/x-msgpack")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, negotiation.MediaTypeMessagePack, w.Header().Get("Content-Type"))
+
+		var sensor models.Sensor
+		require.NoError(t, negotiation.MessagePack.Unmarshal(w.Body.Bytes(), &sensor))
+		assert.Equal(t, "0987654321", *sensor.SerialNumber)
+	})
+
+	t.Run("ok, errors follow accept", func(t *testing.T) {
+		body, err := negotiation.MessagePack.Marshal(map[string]any{"serial_number": "1"})
+		require.NoError(t, err)
+
+		w := do(t, http.MethodPost, "/sensors", body, "Content-Type", "application/msgpack", "Accept", "application/cbor")
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var resp models.Error
+		require.NoError(t, negotiation.CBOR.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, *resp.Reason, "validation error")
+	})
+
+	t.Run("err, unsupported types", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/sensors", nil, "Accept", "application/xml")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+		w = do(t, http.MethodPost, "/sensors", []byte(`{}`), "Content-Type", "application/json; charset=latin1")
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+		w = do(t, http.MethodPost, "/users", []byte(`{"name":`), "Content-Type", "application/json; charset=utf-8")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "application/json"))
+	})
+
+	t.Run("err, body too large", func(t *testing.T) {
+		body := []byte(`{"name": "` + strings.Repeat("a", 2<<20) + `"}`)
+		w := do(t, http.MethodPost, "/users", body, "Content-Type", "application/json")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "exceeds")
+	})
+}
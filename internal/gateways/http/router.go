@@ -1,11 +1,13 @@
 package http
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"homework/internal/gateways/http/handlers"
+	"homework/internal/gateways/http/middleware"
 	"homework/internal/gateways/http/models"
+	"homework/internal/usecase"
 	"net/http"
 	"strconv"
 	"time"
@@ -81,7 +83,19 @@ func contains(slice []string, val string) bool {
 }
 
 func setupRouter(r *gin.Engine, cases UseCases, wsHandler *WebSocketHandler) {
-	r.Use(metricsMiddleware())
+	r.ContextWithFallback = true
+	r.Use(
+		middleware.Tracing(serviceName),
+		middleware.TraceID(),
+		middleware.RequestID(),
+		middleware.Logger(),
+		metricsMiddleware(),
+	)
+
+	sensorTypes := cases.SensorTypes
+	if sensorTypes == nil {
+		sensorTypes = usecase.NewSensorTypes(nil)
+	}
 
 	endpoints := []handlers.Handler{
 		handlers.NewUsersHandler(cases.User),
@@ -90,6 +104,36 @@ func setupRouter(r *gin.Engine, cases UseCases, wsHandler *WebSocketHandler) {
 		handlers.NewEventsHandler(cases.Event),
 		handlers.NewSensorOwnerHandler(cases.User),
 		handlers.NewSensorHistoryHandler(cases.Event),
+		handlers.NewSensorAggregatesHandler(cases.Event),
+		handlers.NewSensorsMetricsHandler(cases.Sensor),
+		handlers.NewWriteHandler(cases.Event),
+		handlers.NewEventsExportHandler(cases.Event),
+		handlers.NewImportsHandler(cases.Import),
+		handlers.NewImportHandler(cases.Import),
+		handlers.NewSensorTypesHandler(sensorTypes),
+		handlers.NewSensorTypeHandler(sensorTypes),
+		handlers.NewCommandsHandler(cases.Command),
+		handlers.NewCommandHandler(cases.Command),
+		handlers.NewCommandPickupHandler(cases.Command),
+		handlers.NewScenesHandler(cases.Scene),
+		handlers.NewSceneHandler(cases.Scene),
+		handlers.NewSceneActivateHandler(cases.Scene),
+		handlers.NewSceneActivationsHandler(cases.Scene),
+		handlers.NewSchedulesHandler(cases.Scheduler),
+		handlers.NewScheduleHandler(cases.Scheduler),
+		handlers.NewScheduleRunsHandler(cases.Scheduler),
+		handlers.NewAlertRulesHandler(cases.Alert),
+		handlers.NewAlertRuleHandler(cases.Alert),
+		handlers.NewUserAlertsHandler(cases.Alert),
+		handlers.NewUserAlertHandler(cases.Alert),
+		handlers.NewUserAlertAcknowledgeHandler(cases.Alert),
+		handlers.NewUserAlertResolveHandler(cases.Alert),
+		handlers.NewWebhooksHandler(cases.Webhook),
+		handlers.NewWebhookHandler(cases.Webhook),
+		handlers.NewWebhookDeliveriesHandler(cases.Webhook),
+		handlers.NewWebhookDeliveryRetryHandler(cases.Webhook),
+		handlers.NewVirtualSensorsHandler(cases.VirtualSensor),
+		handlers.NewVirtualSensorHandler(cases.VirtualSensor),
 	}
 
 	methods := []string{
@@ -138,5 +182,41 @@ func setupRouter(r *gin.Engine, cases UseCases, wsHandler *WebSocketHandler) {
 		},
 	)
 
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/users/:user_id/events",
+		func(ctx *gin.Context) {
+			v := &models.UserIDParam{}
+			if err := ctx.ShouldBindUri(v); err != nil {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+				return
+			}
+
+			if err := v.Validate(nil); err != nil {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+				return
+			}
+			err := wsHandler.HandleSubscriptions(ctx, *v.UserID)
+			if err != nil {
+				ctx.JSON(http.StatusNotFound, gin.H{"reason": err.Error()})
+			}
+		},
+	)
+
+	r.GET("/devices/:device_id/commands/stream",
+		func(ctx *gin.Context) {
+			id, err := strconv.ParseInt(ctx.Param("device_id"), 10, 64)
+			if err != nil || id < 1 {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameter device_id must be a positive integer"})
+				return
+			}
+
+			err = wsHandler.HandleCommands(ctx, id)
+			if errors.Is(err, usecase.ErrNotActuator) {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": err.Error()})
+				return
+			}
+			if err != nil {
+				ctx.JSON(http.StatusNotFound, gin.H{"reason": err.Error()})
+			}
+		},
+	)
 }
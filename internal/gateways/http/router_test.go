@@ -418,6 +418,7 @@ func TestSensorsRoutes(t *testing.T) {
 		assert.Contains(t, allowed, http.MethodOptions, "В разрешённых методах нет OPTIONS")
 		assert.Contains(t, allowed, http.MethodGet, "В разрешённых методах нет GET")
 		assert.Contains(t, allowed, http.MethodHead, "В разрешённых методах нет HEAD")
+		assert.Contains(t, allowed, http.MethodPatch, "В разрешённых методах нет PATCH")
 	})
 
 	// Другие методы не поддерживаем.
@@ -430,7 +431,6 @@ func TestSensorsRoutes(t *testing.T) {
 			{http.MethodPost, http.MethodPost, http.StatusMethodNotAllowed},
 			{http.MethodPut, http.MethodPut, http.StatusMethodNotAllowed},
 			{http.MethodDelete, http.MethodDelete, http.StatusMethodNotAllowed},
-			{http.MethodPatch, http.MethodPatch, http.StatusMethodNotAllowed},
 			{http.MethodConnect, http.MethodConnect, http.StatusMethodNotAllowed},
 			{http.MethodTrace, http.MethodTrace, http.StatusMethodNotAllowed},
 		}
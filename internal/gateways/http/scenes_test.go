@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSceneRoutes(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1234567890", Type: "relay"},
+		domain.Sensor{SerialNumber: "1111111111", Type: domain.SensorTypeADC, IsActive: true},
+	)
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+	}
+
+	t.Run("err, invalid scene", func(t *testing.T) {
+		for _, body := range []string{
+			`{"name":"night","targets":[]}`,
+			`{"name":"night","targets":[{"sensor_id":2}]}`,
+			`{"name":"night","targets":[{"sensor_id":3,"is_active":false}]}`,
+			`{"name":"night","targets":[{"sensor_id":2,"state":1}]}`,
+			`{"targets":[{"sensor_id":2,"is_active":false}]}`,
+		} {
+			w := do(t, http.MethodPost, "/scenes", body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+		}
+
+		w := do(t, http.MethodPost, "/scenes", `{"name":`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ok, create, update and list", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/scenes",
+			`{"name":"night","targets":[{"sensor_id":1,"state":0},{"sensor_id":2,"is_active":false}]}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, "/scenes/1", w.Header().Get("Location"))
+
+		w = do(t, http.MethodPost, "/scenes", `{"name":"night","targets":[{"sensor_id":2,"is_active":true}]}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = do(t, http.MethodPut, "/scenes/1",
+			`{"name":"night","description":"lights off","targets":[{"sensor_id":1,"state":1},{"sensor_id":2,"is_active":false}]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var scenes []map[string]any
+		w = do(t, http.MethodGet, "/scenes", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		decode(t, w, &scenes)
+		require.Len(t, scenes, 1)
+		assert.Equal(t, "lights off", scenes[0]["description"])
+
+		w = do(t, http.MethodGet, "/scenes/2", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("ok, activate and history", func(t *testing.T) {
+		var activation map[string]any
+		w := do(t, http.MethodPost, "/scenes/1/activate", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &activation)
+		assert.Equal(t, "applied", activation["status"])
+		assert.Equal(t, []any{
+			map[string]any{"sensor_id": 1.0, "command_id": 1.0},
+			map[string]any{"sensor_id": 2.0},
+		}, activation["results"])
+
+		sensor, err := uc.Sensor.GetSensorByID(context.Background(), 2)
+		require.NoError(t, err)
+		assert.False(t, sensor.IsActive)
+
+		var history []map[string]any
+		w = do(t, http.MethodGet, "/scenes/1/activations?limit=10", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &history)
+		require.Len(t, history, 1)
+		assert.Equal(t, activation["id"], history[0]["id"])
+
+		w = do(t, http.MethodGet, "/scenes/1/activations?limit=0", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("ok, delete", func(t *testing.T) {
+		w := do(t, http.MethodDelete, "/scenes/1", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = do(t, http.MethodPost, "/scenes/1/activate", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
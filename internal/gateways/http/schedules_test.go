@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleRoutes(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1234567890", Type: "relay"},
+		domain.Sensor{SerialNumber: "1111111111", Type: domain.SensorTypeADC, IsActive: true},
+	)
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+	}
+
+	t.Run("err, invalid schedule", func(t *testing.T) {
+		for _, body := range []string{
+			`{"name":"garage","cron":"0 8 * *","action":{"kind":"update_sensors","sensor_ids":[2],"is_active":false}}`,
+			`{"name":"garage","cron":"@daily","timezone":"Nowhere","action":{"kind":"update_sensors","sensor_ids":[2],"is_active":false}}`,
+			`{"name":"garage","cron":"@daily","action":{"kind":"send_command","sensor_ids":[2],"command":"toggle"}}`,
+			`{"name":"garage","cron":"@daily","action":{"kind":"reboot"}}`,
+			`{"name":"garage","cron":"@daily","missed_runs":"twice","action":{"kind":"http","url":"http://localhost"}}`,
+			`{"name":"garage","cron":"@daily"}`,
+		} {
+			w := do(t, http.MethodPost, "/schedules", body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+		}
+	})
+
+	var created map[string]any
+
+	t.Run("ok, create and list", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/schedules",
+			`{"name":"garage","cron":"0 8 * * MON-FRI","timezone":"Europe/Moscow","action":{"kind":"update_sensors","sensor_ids":[2],"is_active":false}}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, "/schedules/1", w.Header().Get("Location"))
+		decode(t, w, &created)
+		assert.Equal(t, true, created["enabled"])
+		assert.Equal(t, "run_once", created["missed_runs"])
+		assert.NotEmpty(t, created["next_run_at"])
+
+		w = do(t, http.MethodPost, "/schedules",
+			`{"name":"garage","cron":"@daily","action":{"kind":"send_command","sensor_ids":[1],"command":"set_state","state":1}}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = do(t, http.MethodPost, "/schedules",
+			`{"name":"relay","cron":"@daily","enabled":false,"action":{"kind":"send_command","sensor_ids":[1],"command":"set_state","state":1}}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var schedules []map[string]any
+		w = do(t, http.MethodGet, "/schedules", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		decode(t, w, &schedules)
+		require.Len(t, schedules, 2)
+		assert.Nil(t, schedules[1]["next_run_at"])
+		assert.Equal(t, 1.0, schedules[1]["action"].(map[string]any)["state"])
+	})
+
+	t.Run("ok, run now", func(t *testing.T) {
+		var run map[string]any
+		w := do(t, http.MethodPost, "/schedules/1/runs", "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		decode(t, w, &run)
+		assert.Equal(t, "succeeded", run["status"])
+
+		sensor, err := uc.Sensor.GetSensorByID(context.Background(), 2)
+		require.NoError(t, err)
+		assert.False(t, sensor.IsActive)
+
+		var runs []map[string]any
+		w = do(t, http.MethodGet, "/schedules/1/runs", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		decode(t, w, &runs)
+		require.Len(t, runs, 1)
+
+		var schedule map[string]any
+		w = do(t, http.MethodGet, "/schedules/1", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		decode(t, w, &schedule)
+		assert.Equal(t, created["next_run_at"], schedule["next_run_at"])
+	})
+
+	t.Run("ok, update and delete", func(t *testing.T) {
+		w := do(t, http.MethodPut, "/schedules/1",
+			`{"name":"garage","cron":"0 9 * * *","enabled":false,"action":{"kind":"update_sensors","sensor_ids":[2],"is_active":true}}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = do(t, http.MethodDelete, "/schedules/1", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = do(t, http.MethodGet, "/schedules/1/runs", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorTypesRoutes(t *testing.T) {
+	router, uc := newInMemoryRouter(t)
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, default types", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/sensor-types", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var types []map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &types))
+		require.Len(t, types, 4)
+		assert.Equal(t, "adc", types[0]["name"])
+		assert.Equal(t, "cc", types[1]["name"])
+		assert.Equal(t, "binary", types[1]["payload"])
+		assert.Equal(t, "relay", types[2]["name"])
+		assert.Equal(t, true, types[2]["actuator"])
+		assert.Equal(t, "virtual", types[3]["name"])
+		assert.Equal(t, true, types[3]["virtual"])
+	})
+
+	t.Run("ok, new type with range", func(t *testing.T) {
+		w := do(t, http.MethodPut, "/sensor-types/temperature",
+			`{"description":"Термометр","payload":"level","min":-40,"max":125,"unit":"°C","report_interval":60}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"name":"temperature"`)
+
+		w = do(t, http.MethodGet, "/sensor-types/temperature", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"report_interval":60`)
+
+		w = do(t, http.MethodPost, "/sensors",
+			`{"serial_number":"1234567890","type":"temperature","description":"hall","is_active":true}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = do(t, http.MethodPost, "/events", `{"sensor_serial_number":"1234567890","payload":200}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		w = do(t, http.MethodPost, "/events", `{"sensor_serial_number":"1234567890","payload":21}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		events, err := uc.Event.GetEventsByTimeFrame(context.Background(), 1, time.Time{}, time.Now())
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("err, unknown type", func(t *testing.T) {
+		w := do(t, http.MethodGet, "/sensor-types/humidity", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = do(t, http.MethodPost, "/sensors",
+			`{"serial_number":"1111111111","type":"humidity","description":"bath","is_active":true}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("err, sensor of custom virtual type", func(t *testing.T) {
+		w := do(t, http.MethodPut, "/sensor-types/formula", `{"payload":"level","virtual":true}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"virtual":true`)
+
+		w = do(t, http.MethodPost, "/sensors",
+			`{"serial_number":"2222222222","type":"formula","description":"avg","is_active":true}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "POST /virtual-sensors")
+	})
+
+	t.Run("err, invalid type", func(t *testing.T) {
+		for path, body := range map[string]string{
+			"/sensor-types/Humidity": `{"payload":"level"}`,
+			"/sensor-types/humidity": `{"payload":"float"}`,
+			"/sensor-types/range":    `{"payload":"level","min":10,"max":1}`,
+			"/sensor-types/switch":   `{"payload":"binary","actuator":true,"virtual":true}`,
+		} {
+			w := do(t, http.MethodPut, path, body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, path)
+		}
+
+		_, err := uc.SensorTypes.GetSensorType(context.Background(), "range")
+		assert.Error(t, err)
+	})
+
+	t.Run("ok, options", func(t *testing.T) {
+		w := do(t, http.MethodOptions, "/sensor-types/cc", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Contains(t, w.Header().Get("Allow"), http.MethodPut)
+
+		w = do(t, http.MethodDelete, "/sensor-types/cc", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorsList(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "3000000000", Type: domain.SensorTypeADC, Description: "Kitchen", IsActive: true},
+		domain.Sensor{SerialNumber: "1000000000", Type: domain.SensorTypeContactClosure, Description: "kitchen door", IsActive: true},
+		domain.Sensor{SerialNumber: "1200000000", Type: domain.SensorTypeADC, Description: "hall"},
+		domain.Sensor{SerialNumber: "2000000000", Type: domain.SensorTypeADC, Description: "bedroom", IsActive: true},
+	)
+	require.NoError(t, uc.Event.ReceiveEvent(context.Background(), &domain.Event{
+		SensorSerialNumber: "2000000000",
+		Timestamp:          time.Now(),
+		Payload:            1,
+	}))
+
+	list := func(t *testing.T, target string) ([]int64, *httptest.ResponseRecorder) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			return nil, w
+		}
+
+		var sensors []models.Sensor
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sensors))
+		ids := []int64{}
+		for _, s := range sensors {
+			ids = append(ids, *s.ID)
+		}
+		return ids, w
+	}
+
+	t.Run("ok, filters", func(t *testing.T) {
+		tests := map[string][]int64{
+			"/sensors":                           {1, 2, 3, 4},
+			"/sensors?type=adc":                  {1, 3, 4},
+			"/sensors?is_active=false":           {3},
+			"/sensors?online=true":               {4},
+			"/sensors?online=false&type=adc":     {1, 3},
+			"/sensors?q=KITCHEN":                 {1, 2},
+			"/sensors?serial_prefix=1":           {2, 3},
+			"/sensors?sort=serial_number":        {2, 3, 4, 1},
+			"/sensors?sort=-serial_number":       {1, 4, 3, 2},
+			"/sensors?sort=-last_activity":       {4, 3, 2, 1},
+			"/sensors?q=nothing":                 {},
+			"/sensors?type=adc&sort=-id&limit=5": {4, 3, 1},
+		}
+		for target, want := range tests {
+			ids, w := list(t, target)
+			require.Equal(t, http.StatusOK, w.Code, target)
+			assert.Equal(t, want, ids, target)
+		}
+	})
+
+	t.Run("ok, pages", func(t *testing.T) {
+		target := "/sensors?sort=serial_number&limit=3"
+		var all []int64
+		for i := 0; target != ""; i++ {
+			require.Less(t, i, 3)
+
+			ids, w := list(t, target)
+			require.Equal(t, http.StatusOK, w.Code, target)
+			all = append(all, ids...)
+
+			target = ""
+			if link := w.Header().Get("Link"); link != "" {
+				m := regexp.MustCompile(`^<(.+)>; rel="next"$`).FindStringSubmatch(link)
+				require.Len(t, m, 2, link)
+				u, err := url.Parse(m[1])
+				require.NoError(t, err)
+				assert.Equal(t, "serial_number", u.Query().Get("sort"))
+				target = m[1]
+			}
+		}
+		assert.Equal(t, []int64{2, 3, 4, 1}, all)
+	})
+
+	t.Run("err, invalid query", func(t *testing.T) {
+		for _, target := range []string{
+			"/sensors?type=Thermo",
+			"/sensors?sort=type",
+			"/sensors?limit=0",
+			"/sensors?limit=1001",
+			"/sensors?is_active=maybe",
+			"/sensors?cursor=broken",
+		} {
+			_, w := list(t, target)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, target)
+		}
+
+		_, w := list(t, "/sensors?limit=1")
+		next := regexp.MustCompile(`cursor=([^&>]+)`).FindStringSubmatch(w.Header().Get("Link"))
+		require.Len(t, next, 2)
+		_, w = list(t, "/sensors?limit=1&sort=-id&cursor="+next[1])
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "cursor of another sort")
+	})
+}
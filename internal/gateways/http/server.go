@@ -2,34 +2,66 @@ package http
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"homework/internal/usecase"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+const serviceName = "smarthome"
+
+const shutdownTimeout = 10 * time.Second
+
 type Server struct {
-	host        string
-	port        uint16
-	metricsPort uint16
-	router      *gin.Engine
+	host             string
+	port             uint16
+	metricsPort      uint16
+	wsPollInterval   time.Duration
+	router           *gin.Engine
+	websocketHandler *WebSocketHandler
+	sseHandler       *SSEHandler
 }
 
 type UseCases struct {
 	Event  *usecase.Event
 	Sensor *usecase.Sensor
 	User   *usecase.User
+	Import *usecase.Import
+	// SensorTypes - реестр типов датчиков, без него доступны только исходные типы
+	SensorTypes   *usecase.SensorTypes
+	Command       *usecase.Command
+	Scene         *usecase.Scene
+	Scheduler     *usecase.Scheduler
+	Alert         *usecase.Alert
+	Webhook       *usecase.Webhook
+	VirtualSensor *usecase.VirtualSensor
 }
 
 func NewServer(useCases UseCases, options ...func(*Server)) *Server {
-	r := gin.Default()
-	setupRouter(r, useCases, NewWebSocketHandler(useCases))
-
-	s := &Server{router: r, host: "localhost", port: 8080}
+	s := &Server{host: "localhost", port: 8080, wsPollInterval: defaultPollInterval}
 	for _, o := range options {
 		o(s)
 	}
 
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	s.websocketHandler = NewWebSocketHandler(useCases, WithPollInterval(s.wsPollInterval))
+	setupRouter(r, useCases, s.websocketHandler)
+
+	s.sseHandler = NewSSEHandler(useCases, WithSSEPollInterval(s.wsPollInterval))
+	s.sseHandler.SetupRoutes(r)
+
+	if s.metricsPort == 0 {
+		r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
+	s.router = r
+
 	return s
 }
 
@@ -45,6 +77,61 @@ func WithPort(port uint16) func(*Server) {
 	}
 }
 
-func (s *Server) Run(_ context.Context) error {
-	return s.router.Run(fmt.Sprintf("%s:%d", s.host, s.port))
+// WithMetricsPort - отдавать /metrics на отдельном порту
+func WithMetricsPort(port uint16) func(*Server) {
+	return func(s *Server) {
+		s.metricsPort = port
+	}
+}
+
+func WithWebSocketPollInterval(interval time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.wsPollInterval = interval
+	}
+}
+
+// Run - запускает сервер и останавливает его при отмене ctx
+func (s *Server) Run(ctx context.Context) error {
+	servers := []*http.Server{{
+		Addr:              fmt.Sprintf("%s:%d", s.host, s.port),
+		Handler:           s.router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}}
+
+	if s.metricsPort != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		servers = append(servers, &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", s.host, s.metricsPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		})
+	}
+
+	errCh := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			errCh <- srv.ListenAndServe()
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errCh:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	errs := []error{err, s.websocketHandler.Shutdown(), s.sseHandler.Shutdown()}
+	for _, srv := range servers {
+		errs = append(errs, srv.Shutdown(shutdownCtx))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	return http.ErrServerClosed
 }
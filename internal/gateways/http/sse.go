@@ -0,0 +1,324 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	// maxStreamSensors - сколько датчиков можно читать одним потоком /events/stream
+	maxStreamSensors = 100
+)
+
+var errInvalidLastEventID = errors.New("invalid Last-Event-ID")
+
+type SSEHandler struct {
+	useCases     UseCases
+	pollInterval time.Duration
+	shutdown     context.Context
+	cancel       context.CancelFunc
+}
+
+func NewSSEHandler(useCases UseCases, options ...func(*SSEHandler)) *SSEHandler {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &SSEHandler{
+		useCases:     useCases,
+		pollInterval: defaultPollInterval,
+		shutdown:     ctx,
+		cancel:       cancel,
+	}
+	for _, o := range options {
+		o(h)
+	}
+	return h
+}
+
+// WithSSEPollInterval - период опроса новых событий датчика
+func WithSSEPollInterval(interval time.Duration) func(*SSEHandler) {
+	return func(h *SSEHandler) {
+		h.pollInterval = interval
+	}
+}
+
+// streamCursor - момент последнего отправленного события каждого датчика потока.
+// Идентификатор события в потоке - курсор после его отправки: время события в наносекундах Unix,
+// если датчик в потоке один, иначе пары sensor_id:время через запятую.
+// Курсор у каждого датчика свой, поэтому при возобновлении не теряются ещё не отправленные события
+// других датчиков с тем же или более ранним временем.
+type streamCursor map[int64]time.Time
+
+// lastEventCursor - курсор, с которого клиент продолжает чтение потока датчиков ids.
+// Датчики без отправленных событий читаются так же, как в новом потоке.
+func lastEventCursor(ctx *gin.Context, ids []int64) (streamCursor, error) {
+	id := ctx.GetHeader(lastEventIDHeader)
+	if id == "" {
+		id = ctx.Query("last_event_id")
+	}
+
+	cursor := make(streamCursor, len(ids))
+	for _, sensorID := range ids {
+		cursor[sensorID] = time.Time{}
+	}
+	if id == "" {
+		return cursor, nil
+	}
+
+	if !strings.Contains(id, ":") {
+		since, err := parseEventNanos(id)
+		if err != nil {
+			return nil, err
+		}
+		for sensorID := range cursor {
+			cursor[sensorID] = since
+		}
+		return cursor, nil
+	}
+
+	for _, pair := range strings.Split(id, ",") {
+		rawID, rawNanos, _ := strings.Cut(pair, ":")
+		sensorID, err := strconv.ParseInt(rawID, 10, 64)
+		if err != nil {
+			return nil, errInvalidLastEventID
+		}
+		if _, ok := cursor[sensorID]; !ok {
+			return nil, errInvalidLastEventID
+		}
+		since, err := parseEventNanos(rawNanos)
+		if err != nil {
+			return nil, err
+		}
+		cursor[sensorID] = since
+	}
+
+	return cursor, nil
+}
+
+func parseEventNanos(value string) (time.Time, error) {
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || nanos <= 0 {
+		return time.Time{}, errInvalidLastEventID
+	}
+
+	return time.Unix(0, nanos), nil
+}
+
+// eventID - курсор потока датчиков ids, записанный как идентификатор события
+func (c streamCursor) eventID(ids []int64) string {
+	if len(ids) == 1 {
+		return strconv.FormatInt(c[ids[0]].UnixNano(), 10)
+	}
+
+	pairs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if since := c[id]; !since.IsZero() {
+			pairs = append(pairs, strconv.FormatInt(id, 10)+":"+strconv.FormatInt(since.UnixNano(), 10))
+		}
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Handle - отдаёт события датчиков ids в формате text/event-stream.
+// Ошибка возвращается, только если поток ещё не начат.
+func (h *SSEHandler) Handle(ctx *gin.Context, ids []int64) error {
+	for _, id := range ids {
+		if _, err := h.useCases.Sensor.GetSensorByID(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	cursor, err := lastEventCursor(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	ctx.Header("Content-Type", sse.ContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	logger := logging.FromContext(ctx)
+	logger.DebugContext(ctx, "event stream opened", "sensor_ids", ids)
+	defer logger.DebugContext(ctx, "event stream closed", "sensor_ids", ids)
+
+	streamCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	stop := context.AfterFunc(h.shutdown, cancel)
+	defer stop()
+
+	since := maps.Clone(cursor)
+	err = h.useCases.Event.WatchEventsFrom(streamCtx, since, h.pollInterval, func(event domain.Event) error {
+		cursor[event.SensorID] = event.Timestamp
+		ctx.Render(-1, sse.Event{
+			Id:    cursor.eventID(ids),
+			Event: "event",
+			Data:  event,
+		})
+		ctx.Writer.Flush()
+		return ctx.Request.Context().Err()
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logger.WarnContext(ctx, "event stream interrupted", logging.Error(err))
+	}
+
+	return nil
+}
+
+func (h *SSEHandler) SetupRoutes(r *gin.Engine) {
+	r.GET("/sensors/:sensor_id/events/stream", func(ctx *gin.Context) {
+		v := &models.SensorIDParam{}
+		if err := ctx.ShouldBindUri(v); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+			return
+		}
+
+		if err := v.Validate(nil); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+			return
+		}
+
+		h.handle(ctx, []int64{*v.SensorID})
+	})
+
+	r.GET("/events/stream", func(ctx *gin.Context) {
+		values := ctx.QueryArray("sensor_id")
+		if len(values) == 0 {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: sensor_id is required"})
+			return
+		}
+		if len(values) > maxStreamSensors {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+				"reason": "Query parameters validation error: at most " + strconv.Itoa(maxStreamSensors) + " sensor_id",
+			})
+			return
+		}
+
+		ids := make([]int64, 0, len(values))
+		for _, value := range values {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id < 1 {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Query parameters validation error: invalid sensor_id " + value})
+				return
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+
+		h.handle(ctx, ids)
+	})
+
+	r.GET("/users/:user_id/alerts/stream", func(ctx *gin.Context) {
+		v := &models.UserIDParam{}
+		if err := ctx.ShouldBindUri(v); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Error in the URI parameters of the request"})
+			return
+		}
+
+		if err := v.Validate(nil); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "URI parameters validation error: " + err.Error()})
+			return
+		}
+
+		err := h.HandleAlerts(ctx, *v.UserID)
+		switch {
+		case err == nil:
+		case errors.Is(err, errInvalidLastEventID):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Header validation error: " + err.Error()})
+		default:
+			ctx.JSON(http.StatusNotFound, gin.H{"reason": err.Error()})
+		}
+	})
+}
+
+// lastAlertRevision - ревизия, после которой клиент продолжает чтение потока тревог.
+// Идентификатор события в потоке тревог - Revision тревоги.
+func lastAlertRevision(ctx *gin.Context) (int64, error) {
+	id := ctx.GetHeader(lastEventIDHeader)
+	if id == "" {
+		id = ctx.Query("last_event_id")
+	}
+	if id == "" {
+		return 0, nil
+	}
+
+	revision, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || revision <= 0 {
+		return 0, errInvalidLastEventID
+	}
+
+	return revision, nil
+}
+
+// HandleAlerts - отдаёт изменения тревог датчиков пользователя в формате text/event-stream.
+// Ошибка возвращается, только если поток ещё не начат.
+func (h *SSEHandler) HandleAlerts(ctx *gin.Context, userID int64) error {
+	if _, err := h.useCases.User.GetUserSensors(ctx, userID); err != nil {
+		return err
+	}
+
+	since, err := lastAlertRevision(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx.Header("Content-Type", sse.ContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	logger := logging.FromContext(ctx).With(logging.UserID(userID))
+	logger.DebugContext(ctx, "alert stream opened")
+	defer logger.DebugContext(ctx, "alert stream closed")
+
+	streamCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	stop := context.AfterFunc(h.shutdown, cancel)
+	defer stop()
+
+	err = h.useCases.Alert.WatchUserAlerts(streamCtx, userID, since, h.pollInterval, func(alert domain.Alert) error {
+		ctx.Render(-1, sse.Event{
+			Id:    strconv.FormatInt(alert.Revision, 10),
+			Event: "alert",
+			Data:  models.NewAlert(alert),
+		})
+		ctx.Writer.Flush()
+		return ctx.Request.Context().Err()
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logger.WarnContext(ctx, "alert stream interrupted", logging.Error(err))
+	}
+
+	return nil
+}
+
+func (h *SSEHandler) handle(ctx *gin.Context, ids []int64) {
+	err := h.Handle(ctx, ids)
+	switch {
+	case err == nil:
+	case errors.Is(err, errInvalidLastEventID):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"reason": "Header validation error: " + err.Error()})
+	default:
+		ctx.JSON(http.StatusNotFound, gin.H{"reason": err.Error()})
+	}
+}
+
+func (h *SSEHandler) Shutdown() error {
+	h.cancel()
+	return nil
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSSETestServer(t *testing.T, erMock *usecase.MockEventRepository, srMock *usecase.MockSensorRepository) *httptest.Server {
+	engine := gin.New()
+
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock),
+		Sensor: usecase.NewSensor(srMock),
+	}
+
+	h := NewSSEHandler(uc, WithSSEPollInterval(10*time.Millisecond))
+	h.SetupRoutes(engine)
+	t.Cleanup(func() {
+		assert.NoError(t, h.Shutdown())
+	})
+
+	return httptest.NewServer(engine)
+}
+
+func readSSEField(t *testing.T, reader *bufio.Reader, field string) string {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if value, ok := strings.CutPrefix(line, field+":"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+}
+
+func TestSSEHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, resume from Last-Event-ID", func(t *testing.T) {
+		since := time.Now().Add(-time.Hour)
+		missed := domain.Event{SensorID: 1, Timestamp: since.Add(time.Minute), Payload: 10}
+		last := domain.Event{SensorID: 1, Timestamp: since.Add(2 * time.Minute), Payload: 20}
+
+		erMock := usecase.NewMockEventRepository(ctrl)
+		erMock.EXPECT().GetEventsByTimeFrame(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).
+			Return([]domain.Event{missed}, nil).Times(1)
+		erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(1)).Return(&last, nil).AnyTimes()
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Return(&domain.Sensor{ID: 1}, nil).Times(1)
+
+		srv := newSSETestServer(t, erMock, srMock)
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/sensors/1/events/stream", nil)
+		require.NoError(t, err)
+		req.Header.Set(lastEventIDHeader, strconv.FormatInt(since.UnixNano(), 10))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(resp.Body)
+		assert.Equal(t, strconv.FormatInt(missed.Timestamp.UnixNano(), 10), readSSEField(t, reader, "id"))
+		assert.Contains(t, readSSEField(t, reader, "data"), `"Payload":10`)
+		assert.Equal(t, strconv.FormatInt(last.Timestamp.UnixNano(), 10), readSSEField(t, reader, "id"))
+		assert.Contains(t, readSSEField(t, reader, "data"), `"Payload":20`)
+	})
+
+	t.Run("ok, several sensors", func(t *testing.T) {
+		erMock := usecase.NewMockEventRepository(ctrl)
+		erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(1)).
+			Return(&domain.Event{SensorID: 1, Timestamp: time.Now()}, nil).AnyTimes()
+		erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(2)).
+			Return(&domain.Event{SensorID: 2, Timestamp: time.Now()}, nil).AnyTimes()
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Return(&domain.Sensor{}, nil).Times(2)
+
+		srv := newSSETestServer(t, erMock, srMock)
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events/stream?sensor_id=1&sensor_id=2", nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+		data := readSSEField(t, reader, "data") + readSSEField(t, reader, "data")
+		assert.Contains(t, data, `"SensorID":1`)
+		assert.Contains(t, data, `"SensorID":2`)
+	})
+
+	t.Run("ok, resume several sensors with the same timestamp", func(t *testing.T) {
+		sent := time.Unix(0, time.Now().Add(-time.Hour).UnixNano())
+		pending := domain.Event{SensorID: 2, Timestamp: sent, Payload: 20}
+
+		erMock := usecase.NewMockEventRepository(ctrl)
+		erMock.EXPECT().GetEventsByTimeFrame(gomock.Any(), int64(1), sent.Add(time.Nanosecond), gomock.Any()).
+			Return(nil, nil).Times(1)
+		erMock.EXPECT().GetEventsByTimeFrame(gomock.Any(), int64(2), sent.Add(-time.Minute+time.Nanosecond), gomock.Any()).
+			Return([]domain.Event{pending}, nil).Times(1)
+		erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(1)).
+			Return(&domain.Event{SensorID: 1, Timestamp: sent}, nil).AnyTimes()
+		erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(2)).Return(&pending, nil).AnyTimes()
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Return(&domain.Sensor{}, nil).Times(2)
+
+		srv := newSSETestServer(t, erMock, srMock)
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events/stream?sensor_id=1&sensor_id=2", nil)
+		require.NoError(t, err)
+		req.Header.Set(lastEventIDHeader, "1:"+strconv.FormatInt(sent.UnixNano(), 10)+
+			",2:"+strconv.FormatInt(sent.Add(-time.Minute).UnixNano(), 10))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+		nanos := strconv.FormatInt(sent.UnixNano(), 10)
+		assert.Equal(t, "1:"+nanos+",2:"+nanos, readSSEField(t, reader, "id"))
+		assert.Contains(t, readSSEField(t, reader, "data"), `"SensorID":2`)
+	})
+
+	t.Run("fail, Last-Event-ID of another sensor", func(t *testing.T) {
+		erMock := usecase.NewMockEventRepository(ctrl)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Return(&domain.Sensor{}, nil).Times(2)
+
+		srv := newSSETestServer(t, erMock, srMock)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/events/stream?sensor_id=1&sensor_id=2&last_event_id=3:1")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("fail, sensor not found", func(t *testing.T) {
+		erMock := usecase.NewMockEventRepository(ctrl)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), int64(3)).Return(nil, usecase.ErrSensorNotFound).Times(1)
+
+		srv := newSSETestServer(t, erMock, srMock)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/sensors/3/events/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("fail, invalid Last-Event-ID", func(t *testing.T) {
+		erMock := usecase.NewMockEventRepository(ctrl)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Return(&domain.Sensor{ID: 1}, nil).Times(1)
+
+		srv := newSSETestServer(t, erMock, srMock)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/sensors/1/events/stream?last_event_id=abc")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("fail, no sensors", func(t *testing.T) {
+		srv := newSSETestServer(t, usecase.NewMockEventRepository(ctrl), usecase.NewMockSensorRepository(ctrl))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/events/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("fail, too many sensors", func(t *testing.T) {
+		srv := newSSETestServer(t, usecase.NewMockEventRepository(ctrl), usecase.NewMockSensorRepository(ctrl))
+		defer srv.Close()
+
+		query := url.Values{}
+		for id := 1; id <= maxStreamSensors+1; id++ {
+			query.Add("sensor_id", strconv.Itoa(id))
+		}
+
+		resp, err := http.Get(srv.URL + "/events/stream?" + query.Encode())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
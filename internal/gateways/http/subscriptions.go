@@ -0,0 +1,252 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// Типы сообщений протокола подписок
+const (
+	messageSubscribe   = "subscribe"
+	messageUnsubscribe = "unsubscribe"
+	messagePing        = "ping"
+	messagePong        = "pong"
+	messageAck         = "ack"
+	messageError       = "error"
+	messageEvent       = "event"
+)
+
+var (
+	errUnknownMessageType = errors.New("unknown message type")
+	errNoSensorsRequested = errors.New("sensor_ids or all is required")
+	errTooManySensors     = fmt.Errorf("at most %d sensor subscriptions per connection", maxStreamSensors)
+)
+
+// subscriptionRequest - управляющее сообщение клиента.
+// All - подписка (отписка) на все датчики, привязанные к пользователю.
+type subscriptionRequest struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id,omitempty"`
+	SensorIDs []int64 `json:"sensor_ids,omitempty"`
+	All       bool    `json:"all,omitempty"`
+}
+
+// subscriptionMessage - сообщение сервера: ответ на управляющее сообщение или событие датчика
+type subscriptionMessage struct {
+	Type      string        `json:"type"`
+	ID        string        `json:"id,omitempty"`
+	SensorIDs []int64       `json:"sensor_ids,omitempty"`
+	SensorID  int64         `json:"sensor_id,omitempty"`
+	Event     *domain.Event `json:"event,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+}
+
+// subscriptionSession - подписки одного websocket соединения.
+// Управляющие сообщения обрабатываются последовательно, поэтому subs не требует блокировки.
+type subscriptionSession struct {
+	h      *WebSocketHandler
+	conn   *websocket.Conn
+	userID int64
+
+	subs map[int64]context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// HandleSubscriptions - websocket с подписками на события нескольких датчиков пользователя.
+// Подписаться можно только на датчики, привязанные к пользователю userID.
+func (h *WebSocketHandler) HandleSubscriptions(ctx *gin.Context, userID int64) error {
+	if _, err := h.useCases.User.GetUserSensors(ctx, userID); err != nil {
+		return err
+	}
+
+	conn, err := websocket.Accept(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close(websocket.StatusNormalClosure, "bye-bye")
+
+	logger := logging.FromContext(ctx).With(logging.UserID(userID))
+	logger.DebugContext(ctx, "subscription websocket opened")
+	defer logger.DebugContext(ctx, "subscription websocket closed")
+
+	stop := context.AfterFunc(h.shutdown, func() {
+		conn.Close(websocket.StatusGoingAway, "server shutting down")
+	})
+	defer stop()
+
+	sessionCtx, cancel := context.WithCancel(ctx.Request.Context())
+
+	s := &subscriptionSession{
+		h:      h,
+		conn:   conn,
+		userID: userID,
+		subs:   make(map[int64]context.CancelFunc),
+	}
+	defer s.wg.Wait()
+	defer cancel()
+
+	for {
+		var req subscriptionRequest
+		if err := wsjson.Read(sessionCtx, conn, &req); err != nil {
+			if websocket.CloseStatus(err) == -1 && sessionCtx.Err() == nil && h.shutdown.Err() == nil {
+				logger.DebugContext(ctx, "can't read subscription request", logging.Error(err))
+			}
+			return nil
+		}
+
+		if err := s.handle(sessionCtx, req); err != nil {
+			logger.WarnContext(ctx, "can't write to websocket", logging.Error(err))
+			return nil
+		}
+	}
+}
+
+func (s *subscriptionSession) handle(ctx context.Context, req subscriptionRequest) error {
+	var (
+		ids     []int64
+		watches []sensorWatch
+		err     error
+	)
+
+	switch req.Type {
+	case messagePing:
+		return s.write(ctx, subscriptionMessage{Type: messagePong, ID: req.ID})
+	case messageSubscribe:
+		watches, err = s.subscribe(ctx, req)
+		for _, w := range watches {
+			ids = append(ids, w.id)
+		}
+	case messageUnsubscribe:
+		ids, err = s.unsubscribe(req)
+	default:
+		err = fmt.Errorf("%w: %q", errUnknownMessageType, req.Type)
+	}
+
+	if err != nil {
+		return s.write(ctx, subscriptionMessage{Type: messageError, ID: req.ID, Reason: err.Error()})
+	}
+
+	// подтверждение должно уйти раньше первого события подписки
+	if err := s.write(ctx, subscriptionMessage{Type: messageAck, ID: req.ID, SensorIDs: ids}); err != nil {
+		return err
+	}
+
+	for _, w := range watches {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.watch(w.ctx, w.id)
+		}()
+	}
+
+	return nil
+}
+
+type sensorWatch struct {
+	id  int64
+	ctx context.Context
+}
+
+// subscribe - проверяет, что все запрошенные датчики привязаны к пользователю, и регистрирует подписки.
+// У соединения не больше maxStreamSensors подписок.
+// Запрос выполняется целиком или не выполняется совсем.
+func (s *subscriptionSession) subscribe(ctx context.Context, req subscriptionRequest) ([]sensorWatch, error) {
+	if !req.All && len(req.SensorIDs) == 0 {
+		return nil, errNoSensorsRequested
+	}
+
+	sensors, err := s.h.useCases.User.GetUserSensors(ctx, s.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bound := make([]int64, 0, len(sensors))
+	for _, sensor := range sensors {
+		bound = append(bound, sensor.ID)
+	}
+
+	ids := req.SensorIDs
+	if req.All {
+		ids = bound
+	}
+
+	for _, id := range ids {
+		if !slices.Contains(bound, id) {
+			return nil, fmt.Errorf("sensor %d is not bound to user %d", id, s.userID)
+		}
+	}
+
+	var fresh []int64
+	for _, id := range ids {
+		if _, ok := s.subs[id]; !ok && !slices.Contains(fresh, id) {
+			fresh = append(fresh, id)
+		}
+	}
+
+	// каждая подписка опрашивает датчик отдельно, поэтому их число на соединение ограничено, как в SSE
+	if len(s.subs)+len(fresh) > maxStreamSensors {
+		return nil, errTooManySensors
+	}
+
+	var watches []sensorWatch
+	for _, id := range fresh {
+		watchCtx, cancel := context.WithCancel(ctx)
+		s.subs[id] = cancel
+		watches = append(watches, sensorWatch{id: id, ctx: watchCtx})
+	}
+
+	return watches, nil
+}
+
+func (s *subscriptionSession) unsubscribe(req subscriptionRequest) ([]int64, error) {
+	if !req.All && len(req.SensorIDs) == 0 {
+		return nil, errNoSensorsRequested
+	}
+
+	ids := req.SensorIDs
+	if req.All {
+		ids = make([]int64, 0, len(s.subs))
+		for id := range s.subs {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+	}
+
+	var unsubscribed []int64
+	for _, id := range ids {
+		cancel, ok := s.subs[id]
+		if !ok {
+			continue
+		}
+		cancel()
+		delete(s.subs, id)
+		unsubscribed = append(unsubscribed, id)
+	}
+
+	return unsubscribed, nil
+}
+
+func (s *subscriptionSession) watch(ctx context.Context, id int64) {
+	err := s.h.useCases.Event.WatchSensorEvents(ctx, id, time.Time{}, s.h.pollInterval, func(event domain.Event) error {
+		return s.write(ctx, subscriptionMessage{Type: messageEvent, SensorID: id, Event: &event})
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logging.FromContext(ctx).WarnContext(ctx, "sensor subscription interrupted",
+			logging.UserID(s.userID), logging.SensorID(id), logging.Error(err))
+	}
+}
+
+func (s *subscriptionSession) write(ctx context.Context, msg subscriptionMessage) error {
+	return wsjson.Write(ctx, s.conn, msg)
+}
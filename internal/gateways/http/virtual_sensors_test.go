@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualSensorRoutes(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1000000000", Type: domain.SensorTypeADC, IsActive: true},
+		domain.Sensor{SerialNumber: "2000000000", Type: domain.SensorTypeADC, IsActive: true},
+	)
+
+	ctx := context.Background()
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var created struct {
+		SensorID   int64   `json:"sensor_id"`
+		Expression string  `json:"expression"`
+		Inputs     []int64 `json:"inputs"`
+	}
+
+	t.Run("err, invalid virtual sensor", func(t *testing.T) {
+		for body, status := range map[string]int{
+			`{}`: http.StatusUnprocessableEntity,
+			`{"serial_number":"3000000000","expression":"s1 +"}`:     http.StatusUnprocessableEntity,
+			`{"serial_number":"3000000000","expression":"s1 + s99"}`: http.StatusUnprocessableEntity,
+			`{"serial_number":"300","expression":"s1"}`:              http.StatusUnprocessableEntity,
+			`{"serial_number":"1000000000","expression":"s2"}`:       http.StatusConflict,
+		} {
+			w := do(t, http.MethodPost, "/virtual-sensors", body)
+			assert.Equal(t, status, w.Code, body+": "+w.Body.String())
+		}
+	})
+
+	t.Run("ok, create", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/virtual-sensors",
+			`{"serial_number":"3000000000","description":"Среднее","expression":"avg(s1,s2)"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "avg(s1,s2)", created.Expression)
+		assert.Equal(t, []int64{1, 2}, created.Inputs)
+		assert.Equal(t, "/virtual-sensors/3", w.Header().Get("Location"))
+
+		sensor, err := uc.Sensor.GetSensorByID(ctx, created.SensorID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SensorType("virtual"), sensor.Type)
+		assert.True(t, sensor.IsActive)
+
+		w = do(t, http.MethodGet, "/virtual-sensors", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[`+strings.TrimSpace(do(t, http.MethodGet, "/virtual-sensors/3", "").Body.String())+`]`, w.Body.String())
+	})
+
+	t.Run("err, events of virtual sensor", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/events", `{"sensor_serial_number":"3000000000","payload":1}`)
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		w = do(t, http.MethodPost, "/sensors",
+			`{"serial_number":"4000000000","type":"virtual","description":"","is_active":true}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	})
+
+	t.Run("ok, recomputed on input events", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/events", `{"sensor_serial_number":"1000000000","payload":20}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		// второго входа ещё нет, значение не определено
+		_, err := uc.Event.GetLastEventBySensorID(ctx, created.SensorID)
+		require.Error(t, err)
+
+		w = do(t, http.MethodPost, "/events", `{"sensor_serial_number":"2000000000","payload":23}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		event, err := uc.Event.GetLastEventBySensorID(ctx, created.SensorID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.FloatValue(21.5), event.Primary())
+		assert.Equal(t, "3000000000", event.SensorSerialNumber)
+	})
+
+	t.Run("ok, update and delete", func(t *testing.T) {
+		w := do(t, http.MethodPut, "/virtual-sensors/3", `{"expression":"s3 + 1"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+
+		w = do(t, http.MethodPut, "/virtual-sensors/3", `{"expression":"max(s1, s2)"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"expression":"max(s1, s2)"`)
+
+		w = do(t, http.MethodDelete, "/virtual-sensors/3", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		w = do(t, http.MethodGet, "/virtual-sensors/3", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// датчик и его история остаются
+		_, err := uc.Event.GetLastEventBySensorID(ctx, 3)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"homework/internal/domain"
+	"homework/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRoutes(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1000000000", Type: domain.SensorTypeContactClosure, IsActive: true},
+		domain.Sensor{SerialNumber: "2000000000", Type: domain.SensorTypeADC, IsActive: true},
+	)
+
+	ctx := context.Background()
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	do := func(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+	}
+
+	t.Run("err, invalid webhook", func(t *testing.T) {
+		for _, body := range []string{
+			`{}`,
+			`{"url":"ftp://localhost/hook"}`,
+			`{"url":"` + receiver.URL + `","topics":["sensor.deleted"]}`,
+			`{"url":"` + receiver.URL + `","sensor_ids":[9]}`,
+			`{"url":"` + receiver.URL + `","sensor_types":["lidar"]}`,
+			`{"url":"` + receiver.URL + `","user_id":9}`,
+			`{"url":"` + receiver.URL + `","secret":"short"}`,
+		} {
+			w := do(t, http.MethodPost, "/webhooks", body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+		}
+	})
+
+	t.Run("ok, webhook crud", func(t *testing.T) {
+		w := do(t, http.MethodPost, "/webhooks", `{"url":"`+receiver.URL+`","topics":["sensor.updated"],"enabled":false}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, "/webhooks/1", w.Header().Get("Location"))
+
+		var webhook map[string]any
+		decode(t, w, &webhook)
+		assert.Len(t, webhook["secret"], 64)
+		assert.Equal(t, false, webhook["enabled"])
+
+		var stored map[string]any
+		w = do(t, http.MethodGet, "/webhooks/1", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		decode(t, w, &stored)
+		assert.NotContains(t, stored, "secret")
+
+		w = do(t, http.MethodPut, "/webhooks/1", `{"url":"`+receiver.URL+`/v2","sensor_types":["adc"]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &webhook)
+		assert.Equal(t, receiver.URL+"/v2", webhook["url"])
+		assert.Equal(t, true, webhook["enabled"])
+		assert.Equal(t, []any{"adc"}, webhook["sensor_types"])
+
+		var webhooks []map[string]any
+		w = do(t, http.MethodGet, "/webhooks", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		decode(t, w, &webhooks)
+		assert.Len(t, webhooks, 1)
+
+		assert.Equal(t, http.StatusNoContent, do(t, http.MethodDelete, "/webhooks/1", "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, "/webhooks/1", "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, "/webhooks/1/deliveries", "").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, do(t, http.MethodGet, "/webhooks/abc", "").Code)
+	})
+
+	t.Run("ok, signed delivery", func(t *testing.T) {
+		secret := "0123456789abcdef"
+		w := do(t, http.MethodPost, "/webhooks", `{"url":"`+receiver.URL+`","secret":"`+secret+`","sensor_ids":[1],"topics":["sensor.event"]}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var webhook map[string]any
+		decode(t, w, &webhook)
+		path := "/webhooks/" + strconv.FormatInt(int64(webhook["id"].(float64)), 10)
+
+		for _, serial := range []string{"2000000000", "1000000000"} {
+			require.NoError(t, uc.Event.ReceiveEvent(ctx, &domain.Event{
+				SensorSerialNumber: serial,
+				Timestamp:          time.Now(),
+				Payload:            1,
+			}))
+		}
+
+		delivered, err := uc.Webhook.DeliverPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+
+		got := <-requests
+		assert.Equal(t, "sensor.event", got.header.Get(usecase.WebhookTopicHeader))
+		assert.Equal(t,
+			usecase.SignWebhook(secret, got.header.Get(usecase.WebhookTimestampHeader), got.body),
+			got.header.Get(usecase.WebhookSignatureHeader),
+		)
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(got.body, &payload))
+		assert.Equal(t, "1000000000", payload["sensor"].(map[string]any)["serial_number"])
+
+		var deliveries []map[string]any
+		w = do(t, http.MethodGet, path+"/deliveries?status=succeeded", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &deliveries)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 1.0, deliveries[0]["attempts"])
+		assert.Equal(t, 204.0, deliveries[0]["last_status_code"])
+		assert.Equal(t, "sensor.event", deliveries[0]["payload"].(map[string]any)["topic"])
+		id := strconv.FormatInt(int64(deliveries[0]["id"].(float64)), 10)
+
+		assert.Equal(t, http.StatusConflict, do(t, http.MethodPost, path+"/deliveries/"+id+"/retry", "").Code)
+		assert.Equal(t, http.StatusNotFound, do(t, http.MethodPost, path+"/deliveries/100/retry", "").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, do(t, http.MethodGet, path+"/deliveries?status=lost", "").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, do(t, http.MethodGet, path+"/deliveries?limit=0", "").Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, do(t, http.MethodGet, path+"/deliveries/"+id+"/retry", "").Code)
+	})
+}
@@ -3,6 +3,8 @@ package http
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/logging"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,18 +12,33 @@ import (
 	"nhooyr.io/websocket/wsjson"
 )
 
+const defaultPollInterval = 5 * time.Second
+
 type WebSocketHandler struct {
-	useCases UseCases
-	shutdown context.Context
-	cancel   context.CancelFunc
+	useCases     UseCases
+	pollInterval time.Duration
+	shutdown     context.Context
+	cancel       context.CancelFunc
 }
 
-func NewWebSocketHandler(useCases UseCases) *WebSocketHandler {
+func NewWebSocketHandler(useCases UseCases, options ...func(*WebSocketHandler)) *WebSocketHandler {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &WebSocketHandler{
-		useCases: useCases,
-		shutdown: ctx,
-		cancel:   cancel,
+	h := &WebSocketHandler{
+		useCases:     useCases,
+		pollInterval: defaultPollInterval,
+		shutdown:     ctx,
+		cancel:       cancel,
+	}
+	for _, o := range options {
+		o(h)
+	}
+	return h
+}
+
+// WithPollInterval - период опроса новых событий датчика
+func WithPollInterval(interval time.Duration) func(*WebSocketHandler) {
+	return func(h *WebSocketHandler) {
+		h.pollInterval = interval
 	}
 }
 
@@ -37,34 +54,49 @@ func (h *WebSocketHandler) Handle(ctx *gin.Context, id int64) error {
 
 	defer conn.Close(websocket.StatusNormalClosure, "bye-bye")
 
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	logger := logging.FromContext(ctx).With(logging.SensorID(id))
+	logger.DebugContext(ctx, "websocket connection opened")
+	defer logger.DebugContext(ctx, "websocket connection closed")
 
 	closeCtx := conn.CloseRead(h.shutdown)
 
-	var event *domain.Event
+	return h.useCases.Event.WatchSensorEvents(closeCtx, id, time.Time{}, h.pollInterval, func(event domain.Event) error {
+		if err := wsjson.Write(ctx, conn, event); err != nil {
+			logger.WarnContext(ctx, "can't write event to websocket", logging.Error(err))
+			return err
+		}
+		return nil
+	})
+}
 
-	for {
-		select {
-		case <-closeCtx.Done():
-			return closeCtx.Err()
-		case <-ticker.C:
-			newEvent, err := h.useCases.Event.GetLastEventBySensorID(ctx, id)
-			if err != nil {
-				continue
-			}
+// HandleCommands - поток команд исполнительного устройства: команды отправляются по мере
+// постановки в очередь и помечаются доставленными
+func (h *WebSocketHandler) HandleCommands(ctx *gin.Context, id int64) error {
+	if _, err := h.useCases.Command.GetActuator(ctx, id); err != nil {
+		return err
+	}
 
-			if event != nil && newEvent.Timestamp.Equal(event.Timestamp) {
-				continue
-			}
+	conn, err := websocket.Accept(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return err
+	}
 
-			event = newEvent
+	defer conn.Close(websocket.StatusNormalClosure, "bye-bye")
 
-			if err := wsjson.Write(ctx, conn, event); err != nil {
-				return err
-			}
-		}
+	logger := logging.FromContext(ctx).With(logging.SensorID(id))
+	logger.DebugContext(ctx, "commands websocket connection opened")
+	defer logger.DebugContext(ctx, "commands websocket connection closed")
+
+	closeCtx := conn.CloseRead(h.shutdown)
+
+	// после установки соединения ответить ошибкой уже нельзя, поэтому она только пишется в лог
+	err = h.useCases.Command.WatchCommands(closeCtx, id, func(command domain.Command) error {
+		return wsjson.Write(ctx, conn, models.NewCommand(command))
+	})
+	if err != nil && closeCtx.Err() == nil {
+		logger.WarnContext(ctx, "can't stream commands to websocket", logging.Error(err))
 	}
+	return nil
 }
 
 func (h *WebSocketHandler) Shutdown() error {
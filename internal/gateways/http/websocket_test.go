@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
 )
 
 type testSuite struct {
@@ -173,6 +174,168 @@ func (t *testSuite) TestWebSocketShutdown_Client() {
 	assert.NoError(t.T(), ws.Shutdown())
 }
 
+func (t *testSuite) TestWebSocketSubscriptions() {
+	engine := gin.Default()
+
+	erMock := usecase.NewMockEventRepository(t.ctrl)
+	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Event{SensorID: 1, Payload: 100}, nil).AnyTimes()
+	srMock := usecase.NewMockSensorRepository(t.ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Sensor{ID: 1}, nil).AnyTimes()
+	urMock := usecase.NewMockUserRepository(t.ctrl)
+	urMock.EXPECT().GetUserByID(gomock.Any(), gomock.Eq(int64(3))).Return(&domain.User{ID: 3}, nil).AnyTimes()
+	sorMock := usecase.NewMockSensorOwnerRepository(t.ctrl)
+	sorMock.EXPECT().GetSensorsByUserID(gomock.Any(), gomock.Eq(int64(3))).Return([]domain.SensorOwner{{UserID: 3, SensorID: 1}}, nil).AnyTimes()
+
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock),
+		Sensor: usecase.NewSensor(srMock),
+		User:   usecase.NewUser(urMock, sorMock, srMock),
+	}
+
+	ws := NewWebSocketHandler(uc, WithPollInterval(10*time.Millisecond))
+	setupRouter(engine, uc, ws)
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, srvURL.String()+"/users/3/events", nil)
+	require.NoError(t.T(), err)
+	defer conn.Close(websocket.StatusNormalClosure, "bye-bye")
+
+	exchange := func(req subscriptionRequest) subscriptionMessage {
+		require.NoError(t.T(), wsjson.Write(ctx, conn, req))
+		var msg subscriptionMessage
+		require.NoError(t.T(), wsjson.Read(ctx, conn, &msg))
+		return msg
+	}
+
+	msg := exchange(subscriptionRequest{Type: messagePing, ID: "1"})
+	assert.Equal(t.T(), subscriptionMessage{Type: messagePong, ID: "1"}, msg)
+
+	msg = exchange(subscriptionRequest{Type: messageSubscribe, ID: "2", SensorIDs: []int64{2}})
+	assert.Equal(t.T(), messageError, msg.Type)
+	assert.Equal(t.T(), "2", msg.ID)
+	assert.NotEmpty(t.T(), msg.Reason)
+
+	msg = exchange(subscriptionRequest{Type: "unknown", ID: "3"})
+	assert.Equal(t.T(), messageError, msg.Type)
+
+	msg = exchange(subscriptionRequest{Type: messageSubscribe, ID: "4", All: true})
+	assert.Equal(t.T(), subscriptionMessage{Type: messageAck, ID: "4", SensorIDs: []int64{1}}, msg)
+
+	require.NoError(t.T(), wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t.T(), messageEvent, msg.Type)
+	assert.Equal(t.T(), int64(1), msg.SensorID)
+	require.NotNil(t.T(), msg.Event)
+	assert.Equal(t.T(), int64(100), msg.Event.Payload)
+
+	msg = exchange(subscriptionRequest{Type: messageUnsubscribe, ID: "5", SensorIDs: []int64{1}})
+	assert.Equal(t.T(), subscriptionMessage{Type: messageAck, ID: "5", SensorIDs: []int64{1}}, msg)
+}
+
+func (t *testSuite) TestWebSocketSubscriptionsLimit() {
+	engine := gin.Default()
+
+	owners := make([]domain.SensorOwner, 0, maxStreamSensors+1)
+	for id := int64(1); id <= maxStreamSensors+1; id++ {
+		owners = append(owners, domain.SensorOwner{UserID: 3, SensorID: id})
+	}
+
+	erMock := usecase.NewMockEventRepository(t.ctrl)
+	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Any()).Return(nil, usecase.ErrEventNotFound).AnyTimes()
+	srMock := usecase.NewMockSensorRepository(t.ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id int64) (*domain.Sensor, error) {
+		return &domain.Sensor{ID: id}, nil
+	}).AnyTimes()
+	urMock := usecase.NewMockUserRepository(t.ctrl)
+	urMock.EXPECT().GetUserByID(gomock.Any(), gomock.Eq(int64(3))).Return(&domain.User{ID: 3}, nil).AnyTimes()
+	sorMock := usecase.NewMockSensorOwnerRepository(t.ctrl)
+	sorMock.EXPECT().GetSensorsByUserID(gomock.Any(), gomock.Eq(int64(3))).Return(owners, nil).AnyTimes()
+
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock),
+		Sensor: usecase.NewSensor(srMock),
+		User:   usecase.NewUser(urMock, sorMock, srMock),
+	}
+
+	ws := NewWebSocketHandler(uc, WithPollInterval(10*time.Millisecond))
+	setupRouter(engine, uc, ws)
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, srvURL.String()+"/users/3/events", nil)
+	require.NoError(t.T(), err)
+	defer conn.Close(websocket.StatusNormalClosure, "bye-bye")
+
+	exchange := func(req subscriptionRequest) subscriptionMessage {
+		require.NoError(t.T(), wsjson.Write(ctx, conn, req))
+		var msg subscriptionMessage
+		require.NoError(t.T(), wsjson.Read(ctx, conn, &msg))
+		return msg
+	}
+
+	msg := exchange(subscriptionRequest{Type: messageSubscribe, ID: "1", All: true})
+	assert.Equal(t.T(), messageError, msg.Type)
+	assert.Equal(t.T(), errTooManySensors.Error(), msg.Reason)
+
+	ids := make([]int64, 0, maxStreamSensors)
+	for id := int64(1); id <= maxStreamSensors; id++ {
+		ids = append(ids, id)
+	}
+	msg = exchange(subscriptionRequest{Type: messageSubscribe, ID: "2", SensorIDs: ids})
+	assert.Equal(t.T(), messageAck, msg.Type)
+	assert.Len(t.T(), msg.SensorIDs, maxStreamSensors)
+
+	msg = exchange(subscriptionRequest{Type: messageSubscribe, ID: "3", SensorIDs: []int64{maxStreamSensors + 1}})
+	assert.Equal(t.T(), messageError, msg.Type)
+	assert.Equal(t.T(), errTooManySensors.Error(), msg.Reason)
+
+	msg = exchange(subscriptionRequest{Type: messageSubscribe, ID: "4", SensorIDs: []int64{1}})
+	assert.Equal(t.T(), subscriptionMessage{Type: messageAck, ID: "4"}, msg)
+}
+
+func (t *testSuite) TestWebSocketSubscriptionsUserNotFound() {
+	engine := gin.Default()
+
+	erMock := usecase.NewMockEventRepository(t.ctrl)
+	srMock := usecase.NewMockSensorRepository(t.ctrl)
+	urMock := usecase.NewMockUserRepository(t.ctrl)
+	urMock.EXPECT().GetUserByID(gomock.Any(), gomock.Eq(int64(3))).Return(nil, usecase.ErrUserNotFound).Times(1)
+	sorMock := usecase.NewMockSensorOwnerRepository(t.ctrl)
+
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock),
+		Sensor: usecase.NewSensor(srMock),
+		User:   usecase.NewUser(urMock, sorMock, srMock),
+	}
+
+	ws := NewWebSocketHandler(uc)
+	setupRouter(engine, uc, ws)
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	_, resp, err := websocket.Dial(ctx, srvURL.String()+"/users/3/events", nil)
+	require.Error(t.T(), err)
+	assert.Equal(t.T(), http.StatusNotFound, resp.StatusCode)
+}
+
 func TestWebSocketHandler(t *testing.T) {
 	ts := new(testSuite)
 	defer func() {
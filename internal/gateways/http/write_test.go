@@ -0,0 +1,241 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertInMemory "homework/internal/repository/alert/inmemory"
+	commandInMemory "homework/internal/repository/command/inmemory"
+	eventInMemory "homework/internal/repository/event/inmemory"
+	importInMemory "homework/internal/repository/imports/inmemory"
+	sceneInMemory "homework/internal/repository/scene/inmemory"
+	scheduleInMemory "homework/internal/repository/schedule/inmemory"
+	sensorInMemory "homework/internal/repository/sensor/inmemory"
+	sensorTypeInMemory "homework/internal/repository/sensortype/inmemory"
+	userInMemory "homework/internal/repository/user/inmemory"
+	virtualSensorInMemory "homework/internal/repository/virtualsensor/inmemory"
+	webhookInMemory "homework/internal/repository/webhook/inmemory"
+)
+
+// newInMemoryRouter - роутер поверх in-memory репозиториев с зарегистрированными датчиками
+func newInMemoryRouter(t *testing.T, sensors ...domain.Sensor) (*gin.Engine, UseCases) {
+	t.Helper()
+
+	sr := sensorInMemory.NewSensorRepository()
+	er := eventInMemory.NewEventRepository()
+	types := usecase.NewSensorTypes(sensorTypeInMemory.NewSensorTypeRepository())
+	sensorUC := usecase.NewSensor(sr, usecase.WithSensorTypes(types))
+	virtual := usecase.NewVirtualSensor(virtualSensorInMemory.NewVirtualSensorRepository(), sensorUC, er)
+	uc := UseCases{
+		Event: usecase.NewEvent(er, sr,
+			usecase.WithEventSensorTypes(types),
+			usecase.WithEventVirtualSensors(virtual),
+		),
+		Sensor:        sensorUC,
+		User:          usecase.NewUser(userInMemory.NewUserRepository(), userInMemory.NewSensorOwnerRepository(), sr),
+		Import:        usecase.NewImport(importInMemory.NewImportRepository(sr, er), sr, usecase.WithImportSensorTypes(types)),
+		SensorTypes:   types,
+		Command:       usecase.NewCommand(commandInMemory.NewCommandRepository(), sr, usecase.WithCommandSensorTypes(types)),
+		VirtualSensor: virtual,
+	}
+	uc.Scene = usecase.NewScene(sceneInMemory.NewSceneRepository(), uc.Sensor, usecase.WithSceneCommands(uc.Command))
+	uc.Scheduler = usecase.NewScheduler(scheduleInMemory.NewScheduleRepository(), uc.Sensor,
+		usecase.WithSchedulerScenes(uc.Scene),
+		usecase.WithSchedulerCommands(uc.Command),
+	)
+	uc.Alert = usecase.NewAlert(alertInMemory.NewAlertRepository(), uc.Sensor, uc.User)
+	uc.Event.AddListener(uc.Command.ConfirmCommands)
+	uc.Webhook = usecase.NewWebhook(webhookInMemory.NewWebhookRepository(), uc.Sensor, uc.User,
+		usecase.WithWebhookSensorTypes(types),
+		// получатель в тестах слушает loopback
+		usecase.WithWebhookHTTPClient(usecase.NewWebhookHTTPClient(time.Second, true)),
+	)
+	uc.Event.AddListener(uc.Alert.CheckEvent)
+	uc.Event.AddListener(uc.Webhook.OnEvent)
+	uc.Sensor.AddListener(uc.Webhook.OnSensorChange)
+
+	for _, sensor := range sensors {
+		_, err := uc.Sensor.RegisterSensor(context.Background(), &sensor)
+		require.NoError(t, err)
+	}
+
+	engine := gin.New()
+	setupRouter(engine, uc, NewWebSocketHandler(uc))
+
+	return engine, uc
+}
+
+func TestWriteRoute(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC},
+		domain.Sensor{SerialNumber: "1111111111", Type: domain.SensorTypeContactClosure},
+	)
+
+	write := func(t *testing.T, query, body string, headers ...string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/write"+query, strings.NewReader(body))
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	history := func(t *testing.T, id int64) []domain.Event {
+		t.Helper()
+
+		events, err := uc.Event.GetEventsByTimeFrame(context.Background(), id, start.Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		return events
+	}
+
+	t.Run("ok, tags and measurement", func(t *testing.T) {
+		w := write(t, "?db=telegraf&precision=s", strings.Join([]string{
+			"# telegraf batch",
+			"temperature,serial_number=1234567890,room=kitchen value=21.6,humidity=40i 1700000000",
+			"",
+			"door,serial=1111111111 open=true 1700000001",
+			"1234567890 value=23i 1700000002",
+		}, "\n"))
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+		events := history(t, 1)
+		require.Len(t, events, 2)
+		assert.Equal(t, int64(22), events[0].Payload)
+		assert.Equal(t, map[string]domain.Value{
+			"value":    domain.FloatValue(21.6),
+			"humidity": domain.IntValue(40),
+		}, events[0].Channels)
+		assert.True(t, start.Equal(events[0].Timestamp))
+		assert.Equal(t, int64(23), events[1].Payload)
+		assert.Nil(t, events[1].Channels)
+
+		events = history(t, 2)
+		require.Len(t, events, 1)
+		assert.Equal(t, int64(1), events[0].Payload)
+	})
+
+	t.Run("ok, gzip", func(t *testing.T) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, _ = io.WriteString(gz, "m,serial=1111111111 value=0i 1700000003000\n")
+		require.NoError(t, gz.Close())
+
+		w := write(t, "?precision=ms", buf.String(), "Content-Encoding", "gzip")
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+		assert.Len(t, history(t, 2), 2)
+	})
+
+	t.Run("err, gzip body too large", func(t *testing.T) {
+		// последняя строка обрывается пределом распакованного тела на "value=12"
+		last := "m,serial=1111111111 value=12345i 1700000020\n"
+		cut := len("m,serial=1111111111 value=12")
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		comment := "# " + strings.Repeat("x", 1021) + "\n"
+		for i := 0; i < 16<<10-1; i++ {
+			_, _ = io.WriteString(gz, comment)
+		}
+		_, _ = io.WriteString(gz, "# "+strings.Repeat("x", len(comment)-cut-3)+"\n")
+		_, _ = io.WriteString(gz, last)
+		require.NoError(t, gz.Close())
+
+		before := len(history(t, 2))
+		w := write(t, "", buf.String(), "Content-Encoding", "gzip")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+		assert.Len(t, history(t, 2), before)
+	})
+
+	t.Run("err, partial write", func(t *testing.T) {
+		w := write(t, "?precision=s", strings.Join([]string{
+			"m,serial=1234567890 value=30i 1700000010",
+			"m,serial=0000000000 value=1i 1700000011",
+			`m,serial=1234567890 a="x",b="y" 1700000012`,
+			`m,serial=1234567890 value="on" 1700000013`,
+			"m,serial=1234567890 broken",
+			"m,serial=1234567890 value=31i 1700000014",
+		}, "\n"))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, resp["reason"], "4 points rejected")
+		for _, line := range []string{"line 2:", "line 3:", "line 4:", "line 5:"} {
+			assert.Contains(t, resp["reason"], line)
+		}
+
+		// корректные строки записаны
+		events := history(t, 1)
+		assert.Equal(t, int64(31), events[len(events)-1].Payload)
+	})
+
+	t.Run("err, invalid precision", func(t *testing.T) {
+		w := write(t, "?precision=d", "m,serial=1234567890 value=1i")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("err, method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/write", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestSensorsMetricsRoute(t *testing.T) {
+	router, uc := newInMemoryRouter(t,
+		domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC, Description: "kitchen"},
+		domain.Sensor{SerialNumber: "1111111111", Type: domain.SensorTypeContactClosure, Description: "door"},
+	)
+
+	before := time.Now()
+	event := &domain.Event{SensorSerialNumber: "1234567890", Timestamp: time.Now()}
+	event.SetValue(domain.FloatValue(42.5))
+	require.NoError(t, uc.Event.ReceiveEvent(context.Background(), event))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sensors/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+	body := w.Body.String()
+	assert.Contains(t, body,
+		`sensor_current_state{description="kitchen",sensor_id="1",serial_number="1234567890",type="adc"} 42.5`)
+	assert.Contains(t, body,
+		`sensor_current_state{description="door",sensor_id="2",serial_number="1111111111",type="cc"} 0`)
+
+	prefix := `sensor_last_activity_timestamp_seconds{description="kitchen",sensor_id="1",serial_number="1234567890",type="adc"} `
+	_, value, ok := strings.Cut(body, prefix)
+	require.True(t, ok, body)
+	value, _, _ = strings.Cut(value, "\n")
+	lastActivity, err := strconv.ParseFloat(value, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(before.Unix()), lastActivity, 5)
+	assert.NotContains(t, body, `sensor_last_activity_timestamp_seconds{description="door"`)
+
+	// /sensors/:sensor_id по-прежнему работает рядом со статическим путём
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sensors/1", nil)
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
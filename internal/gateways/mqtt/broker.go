@@ -0,0 +1,532 @@
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"log/slog"
+	"sync"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+const (
+	defaultStateTopic  = "out/sensors/" + SerialPlaceholder + "/state"
+	defaultEventsTopic = "out/sensors/" + SerialPlaceholder + "/events"
+	// defaultCommandsTopic - топик, из которого исполнительное устройство получает команды
+	defaultCommandsTopic = "in/devices/" + SerialPlaceholder + "/commands"
+)
+
+var (
+	ErrAmbiguousTopicPattern = errors.New("topic pattern must not contain wildcards")
+	ErrNoBrokerSecret        = errors.New("mqtt broker secret must be set")
+)
+
+// Broker - встроенный MQTT брокер.
+//
+// Датчики публикуют события в топики шаблона ingest, подключаясь с именем пользователя,
+// равным своему серийному номеру, и паролем SensorPassword(secret, serial). Подключиться может
+// только зарегистрированный датчик, публиковать - только в топик своего датчика. Принятые события
+// (из любого источника, не только MQTT) публикуются в топики шаблона events, а текущее состояние
+// датчика - в retained сообщение топика шаблона state. Эти топики доступны клиентам только для
+// чтения: датчикам и читателю, заданному WithReader. Остальные подключения отклоняются.
+//
+// Если заданы команды (WithCommands), исполнительное устройство получает их в топике шаблона
+// commands. Подписки, которые покрывают топик команд другого устройства, отклоняются. Команды
+// публикуются с QoS 1, только когда устройство подписано на свой топик команд именно этим
+// фильтром с QoS не ниже 1: ожидающие команды - сразу после подписки, новые команды этого
+// экземпляра сервиса - при постановке в очередь. Доставленной команда помечается, когда
+// устройство подтвердит получение (PUBACK или PUBREC), до этого она остаётся ожидающей.
+type Broker struct {
+	events   *usecase.Event
+	sensors  *usecase.Sensor
+	commands *usecase.Command
+	address  string
+	ingest   TopicPattern
+	state    TopicPattern
+	out      TopicPattern
+	in       TopicPattern
+	secret   []byte
+	reader   string
+	password []byte
+
+	mu     sync.RWMutex
+	server *mochi.Server
+
+	// deliverMu - команды одному устройству отправляются по очереди, чтобы не отправить дважды
+	deliverMu sync.Mutex
+}
+
+func NewBroker(events *usecase.Event, sensors *usecase.Sensor, address string, options ...func(*Broker)) (*Broker, error) {
+	b := &Broker{
+		events:  events,
+		sensors: sensors,
+		address: address,
+	}
+
+	for _, p := range []struct {
+		pattern string
+		dst     *TopicPattern
+	}{
+		{defaultTopic, &b.ingest},
+		{defaultStateTopic, &b.state},
+		{defaultEventsTopic, &b.out},
+		{defaultCommandsTopic, &b.in},
+	} {
+		pattern, err := ParseTopicPattern(p.pattern)
+		if err != nil {
+			return nil, err
+		}
+		*p.dst = pattern
+	}
+
+	for _, o := range options {
+		o(b)
+	}
+
+	for _, p := range []TopicPattern{b.state, b.out, b.in} {
+		if _, ok := p.Topic(""); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrAmbiguousTopicPattern, p)
+		}
+	}
+	if len(b.secret) == 0 {
+		return nil, ErrNoBrokerSecret
+	}
+
+	return b, nil
+}
+
+// WithIngestTopic - шаблон топиков, в которые датчики публикуют события
+func WithIngestTopic(pattern TopicPattern) func(*Broker) {
+	return func(b *Broker) {
+		b.ingest = pattern
+	}
+}
+
+// WithStateTopic - шаблон топиков с retained состоянием датчиков
+func WithStateTopic(pattern TopicPattern) func(*Broker) {
+	return func(b *Broker) {
+		b.state = pattern
+	}
+}
+
+// WithEventsTopic - шаблон топиков, в которые публикуются принятые события
+func WithEventsTopic(pattern TopicPattern) func(*Broker) {
+	return func(b *Broker) {
+		b.out = pattern
+	}
+}
+
+// WithSecret - секрет, из которого выводятся пароли датчиков (см. SensorPassword)
+func WithSecret(secret string) func(*Broker) {
+	return func(b *Broker) {
+		b.secret = []byte(secret)
+	}
+}
+
+// WithReader - учётная запись клиента, который может только читать топики state и events
+func WithReader(username, password string) func(*Broker) {
+	return func(b *Broker) {
+		b.reader = username
+		b.password = []byte(password)
+	}
+}
+
+// SensorPassword - пароль датчика во встроенном брокере: HMAC-SHA256 серийного номера
+// на секрете брокера в hex
+func SensorPassword(secret, serial string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(serial))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WithCommands - отправлять устройствам команды из очереди
+func WithCommands(commands *usecase.Command) func(*Broker) {
+	return func(b *Broker) {
+		b.commands = commands
+	}
+}
+
+// WithCommandsTopic - шаблон топиков, из которых устройства получают команды
+func WithCommandsTopic(pattern TopicPattern) func(*Broker) {
+	return func(b *Broker) {
+		b.in = pattern
+	}
+}
+
+// Run - запускает брокер и останавливает его при отмене ctx
+func (b *Broker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("mqtt_broker", b.address))
+	ctx = logging.WithContext(ctx, logger)
+
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       logger,
+	})
+
+	if err := server.AddHook(&aclHook{ctx: ctx, broker: b}, nil); err != nil {
+		return err
+	}
+	if err := server.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: b.address})); err != nil {
+		return fmt.Errorf("can't listen mqtt address: %w", err)
+	}
+
+	err := server.Subscribe(b.ingest.Filter(), 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		b.handle(ctx, pk)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := server.Serve(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.server = server
+	b.mu.Unlock()
+
+	b.events.AddListener(b.publish)
+	if b.commands != nil {
+		b.commands.AddListener(func(ctx context.Context, _ domain.Command, sensor domain.Sensor) {
+			b.deliverCommands(ctx, sensor)
+		})
+	}
+
+	if err := b.publishStates(ctx); err != nil {
+		logger.ErrorContext(ctx, "can't publish sensor states", logging.Error(err))
+	}
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	b.server = nil
+	b.mu.Unlock()
+
+	if err := server.Close(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+func (b *Broker) handle(ctx context.Context, pk packets.Packet) {
+	// сообщения самого брокера не принимаются как события, даже если топики пересекаются
+	if pk.Origin == mochi.InlineClientId {
+		return
+	}
+
+	logger := logging.FromContext(ctx).With(slog.String("mqtt_topic", pk.TopicName))
+	ctx = logging.WithContext(ctx, logger)
+
+	event, err := parseEvent(b.ingest, pk.TopicName, pk.Payload)
+	if err != nil {
+		logger.WarnContext(ctx, "dropping invalid mqtt message", logging.Error(err))
+		return
+	}
+
+	if err := b.events.ReceiveEvent(ctx, event); err != nil {
+		logger.ErrorContext(ctx, "unable to process mqtt event",
+			logging.SerialNumber(event.SensorSerialNumber), logging.Error(err))
+	}
+}
+
+// publishStates - публикует состояние всех датчиков, чтобы новые подписчики сразу получили его
+func (b *Broker) publishStates(ctx context.Context) error {
+	sensors, err := b.sensors.GetSensors(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, sensor := range sensors {
+		b.publishState(ctx, sensor)
+	}
+
+	return nil
+}
+
+func (b *Broker) publish(ctx context.Context, event domain.Event, sensor domain.Sensor) {
+	b.publishState(ctx, sensor)
+
+	topic, _ := b.out.Topic(sensor.SerialNumber)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	b.send(ctx, topic, payload, false, 0)
+}
+
+func (b *Broker) publishState(ctx context.Context, sensor domain.Sensor) {
+	topic, _ := b.state.Topic(sensor.SerialNumber)
+	b.send(ctx, topic, []byte(sensor.State().String()), true, 0)
+}
+
+// commandMessage - команда в топике устройства
+type commandMessage struct {
+	ID         int64     `json:"id"`
+	Kind       string    `json:"kind"`
+	State      int64     `json:"state"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// deliverCommands - отправляет устройству ожидающие команды, которые ещё не ждут его подтверждения,
+// если оно подписано на свой топик
+func (b *Broker) deliverCommands(ctx context.Context, sensor domain.Sensor) {
+	b.deliverMu.Lock()
+	defer b.deliverMu.Unlock()
+
+	topic, _ := b.in.Topic(sensor.SerialNumber)
+	cl := b.subscriber(topic, sensor.SerialNumber)
+	if cl == nil {
+		return
+	}
+
+	commands, err := b.commands.PendingCommands(ctx, sensor.ID)
+	if err != nil {
+		if !errors.Is(err, usecase.ErrNotActuator) {
+			logging.FromContext(ctx).ErrorContext(ctx, "can't receive commands",
+				logging.SerialNumber(sensor.SerialNumber), logging.Error(err))
+		}
+		return
+	}
+
+	inflight := inflightCommands(cl)
+	for _, c := range commands {
+		if inflight[c.ID] {
+			continue
+		}
+		payload, err := json.Marshal(commandMessage{
+			ID:         c.ID,
+			Kind:       string(c.Kind),
+			State:      c.State,
+			DurationMs: c.Duration.Milliseconds(),
+			ExpiresAt:  c.ExpiresAt,
+		})
+		if err != nil {
+			continue
+		}
+		b.send(ctx, topic, payload, false, 1)
+	}
+}
+
+// subscriber - подключённый клиент датчика serial, подписанный на топик topic именно этим фильтром
+// с QoS не ниже 1, или nil. Подписки других клиентов и подписки фильтрами с + и # не учитываются.
+func (b *Broker) subscriber(topic, serial string) *mochi.Client {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.server == nil {
+		return nil
+	}
+
+	for id := range b.server.Topics.Subscribers(topic).Subscriptions {
+		cl, ok := b.server.Clients.Get(id)
+		if !ok || cl.Closed() || string(cl.Properties.Username) != serial {
+			continue
+		}
+		if sub, ok := cl.State.Subscriptions.Get(topic); ok && sub.Qos >= 1 {
+			return cl
+		}
+	}
+	return nil
+}
+
+// inflightCommands - ID команд, отправленных клиенту и ещё не подтверждённых им
+func inflightCommands(cl *mochi.Client) map[int64]bool {
+	ids := make(map[int64]bool)
+	for _, pk := range cl.State.Inflight.GetAll(false) {
+		if id, ok := commandID(pk); ok {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// commandID - ID команды в сообщении, которое брокер отправил устройству
+func commandID(pk packets.Packet) (int64, bool) {
+	if pk.FixedHeader.Type != packets.Publish || pk.Origin != mochi.InlineClientId {
+		return 0, false
+	}
+	var msg commandMessage
+	if err := json.Unmarshal(pk.Payload, &msg); err != nil || msg.ID == 0 {
+		return 0, false
+	}
+	return msg.ID, true
+}
+
+func (b *Broker) send(ctx context.Context, topic string, payload []byte, retain bool, qos byte) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.server == nil {
+		return
+	}
+
+	if err := b.server.Publish(topic, payload, retain, qos); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "can't publish mqtt message",
+			slog.String("mqtt_topic", topic), logging.Error(err))
+	}
+}
+
+// aclHook - пускает только зарегистрированные датчики и читателя, разрешает датчику публикацию
+// и подписку на команды только в свой топик
+type aclHook struct {
+	mochi.HookBase
+
+	ctx    context.Context
+	broker *Broker
+}
+
+func (h *aclHook) ID() string {
+	return "sensor-acl"
+}
+
+func (h *aclHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{mochi.OnConnectAuthenticate, mochi.OnACLCheck, mochi.OnSubscribed, mochi.OnPacketRead}, []byte{b})
+}
+
+// isReader - подключён ли клиент как читатель. Имя читателя проверяется раньше серийных номеров,
+// поэтому датчик с таким же серийным номером подключиться не может.
+func (h *aclHook) isReader(cl *mochi.Client) bool {
+	return h.broker.reader != "" && string(cl.Properties.Username) == h.broker.reader
+}
+
+func (h *aclHook) OnConnectAuthenticate(cl *mochi.Client, pk packets.Packet) bool {
+	if h.isReader(cl) {
+		return subtle.ConstantTimeCompare(pk.Connect.Password, h.broker.password) == 1
+	}
+
+	serial := string(cl.Properties.Username)
+	password := SensorPassword(string(h.broker.secret), serial)
+	if !hmac.Equal(pk.Connect.Password, []byte(password)) {
+		return false
+	}
+
+	sensor, err := h.broker.sensors.GetSensorBySerialNumber(h.ctx, serial)
+	if err != nil {
+		if !errors.Is(err, usecase.ErrSensorNotFound) {
+			logging.FromContext(h.ctx).ErrorContext(h.ctx, "can't authenticate mqtt client",
+				logging.SerialNumber(serial), logging.Error(err))
+		}
+		return false
+	}
+
+	// события виртуального датчика вычисляются сервером
+	return h.acceptsEvents(sensor)
+}
+
+func (h *aclHook) OnACLCheck(cl *mochi.Client, topic string, write bool) bool {
+	if h.isReader(cl) {
+		if write {
+			return false
+		}
+		return h.broker.commands == nil || !h.broker.in.CoversOtherSerial(topic, "")
+	}
+
+	// фильтры с + и # тоже не должны получать команды других устройств
+	if !write {
+		return h.broker.commands == nil || !h.broker.in.CoversOtherSerial(topic, string(cl.Properties.Username))
+	}
+
+	serial, ok := h.broker.ingest.SerialNumber(topic)
+	if !ok || string(cl.Properties.Username) != serial {
+		return false
+	}
+
+	// датчик могли удалить или сделать виртуальным после подключения
+	sensor, err := h.broker.sensors.GetSensorBySerialNumber(h.ctx, serial)
+	if err != nil {
+		if !errors.Is(err, usecase.ErrSensorNotFound) {
+			logging.FromContext(h.ctx).ErrorContext(h.ctx, "can't check mqtt publish permission",
+				logging.SerialNumber(serial), logging.Error(err))
+		}
+		return false
+	}
+
+	return h.acceptsEvents(sensor)
+}
+
+// acceptsEvents - датчик принимает события от устройства, то есть его тип не виртуальный
+func (h *aclHook) acceptsEvents(sensor *domain.Sensor) bool {
+	virtual, err := h.broker.sensors.IsVirtual(h.ctx, sensor)
+	if err != nil {
+		logging.FromContext(h.ctx).ErrorContext(h.ctx, "can't get mqtt sensor type",
+			logging.SerialNumber(sensor.SerialNumber), logging.Error(err))
+		return false
+	}
+	return !virtual
+}
+
+// OnSubscribed - устройство, подписавшееся на свой топик команд, сразу получает ожидающие команды
+func (h *aclHook) OnSubscribed(cl *mochi.Client, pk packets.Packet, reasonCodes []byte) {
+	if h.broker.commands == nil || h.isReader(cl) {
+		return
+	}
+
+	for i, sub := range pk.Filters {
+		// подписка, отклонённая OnACLCheck
+		if i < len(reasonCodes) && reasonCodes[i] >= packets.ErrUnspecifiedError.Code {
+			continue
+		}
+		serial, ok := h.broker.in.SerialNumber(sub.Filter)
+		if !ok || string(cl.Properties.Username) != serial {
+			continue
+		}
+
+		sensor, err := h.broker.sensors.GetSensorBySerialNumber(h.ctx, serial)
+		if err != nil {
+			continue
+		}
+		go h.broker.deliverCommands(h.ctx, *sensor)
+	}
+}
+
+// OnPacketRead - подтверждение устройством команды, отправленной с QoS 1 или 2, помечает её доставленной
+func (h *aclHook) OnPacketRead(cl *mochi.Client, pk packets.Packet) (packets.Packet, error) {
+	if h.broker.commands == nil || h.isReader(cl) {
+		return pk, nil
+	}
+	if pk.FixedHeader.Type != packets.Puback && pk.FixedHeader.Type != packets.Pubrec {
+		return pk, nil
+	}
+	// MQTT 5: устройство отказалось принять сообщение
+	if pk.ReasonCode >= packets.ErrUnspecifiedError.Code {
+		return pk, nil
+	}
+
+	sent, ok := cl.State.Inflight.Get(pk.PacketID)
+	if !ok {
+		return pk, nil
+	}
+	id, ok := commandID(sent)
+	if !ok {
+		return pk, nil
+	}
+
+	serial := string(cl.Properties.Username)
+	go func() {
+		sensor, err := h.broker.sensors.GetSensorBySerialNumber(h.ctx, serial)
+		if err == nil {
+			_, err = h.broker.commands.DeliverCommand(h.ctx, sensor.ID, id)
+		}
+		if err != nil {
+			logging.FromContext(h.ctx).ErrorContext(h.ctx, "can't mark command delivered",
+				logging.SerialNumber(serial), slog.Int64("command_id", id), logging.Error(err))
+		}
+	}()
+
+	return pk, nil
+}
@@ -0,0 +1,380 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"homework/internal/domain"
+	commandInMemory "homework/internal/repository/command/inmemory"
+	eventInMemory "homework/internal/repository/event/inmemory"
+	sensorInMemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+const (
+	testSecret         = "0123456789abcdef"
+	testReader         = "reader"
+	testReaderPassword = "reader-password"
+)
+
+func newClient(addr, clientID, username, password string) paho.Client {
+	return paho.NewClient(paho.NewClientOptions().
+		AddBroker("tcp://" + addr).
+		SetClientID(clientID).
+		SetUsername(username).
+		SetPassword(password).
+		SetAutoReconnect(false))
+}
+
+// connectSensor - подключает клиента датчика с паролем, выведенным из testSecret
+func connectSensor(t *testing.T, addr, clientID, serial string) paho.Client {
+	t.Helper()
+	return connect(t, addr, clientID, serial, SensorPassword(testSecret, serial))
+}
+
+func connect(t *testing.T, addr, clientID, username, password string) paho.Client {
+	t.Helper()
+	return connectClient(t, newClient(addr, clientID, username, password))
+}
+
+// connectClient - подключает клиента, когда брокер запустится, и отключает в конце теста
+func connectClient(t *testing.T, client paho.Client) paho.Client {
+	t.Helper()
+
+	var token paho.Token
+	require.Eventually(t, func() bool {
+		token = client.Connect()
+		return token.WaitTimeout(time.Second) && token.Error() == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	t.Cleanup(func() {
+		client.Disconnect(0)
+	})
+
+	return client
+}
+
+type received struct {
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func (r *received) handler(_ paho.Client, msg paho.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages[msg.Topic()] = append(r.messages[msg.Topic()], string(msg.Payload()))
+}
+
+func (r *received) get(topic string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.messages[topic]...)
+}
+
+func TestBroker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	er := eventInMemory.NewEventRepository()
+	sr := sensorInMemory.NewSensorRepository()
+	sensor := &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC, CurrentState: 3}
+	require.NoError(t, sr.SaveSensor(ctx, sensor))
+	require.NoError(t, sr.SaveSensor(ctx, &domain.Sensor{SerialNumber: "0000000000", Type: domain.SensorTypeADC}))
+	require.NoError(t, sr.SaveSensor(ctx, &domain.Sensor{SerialNumber: "9000000000", Type: "virtual"}))
+
+	events := usecase.NewEvent(er, sr)
+	addr := freeAddress(t)
+
+	b, err := NewBroker(events, usecase.NewSensor(sr), addr,
+		WithSecret(testSecret), WithReader(testReader, testReaderPassword))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Run(ctx)
+	}()
+
+	device := connectSensor(t, addr, "device", sensor.SerialNumber)
+	spoofer := connectSensor(t, addr, "spoofer", "0000000000")
+
+	t.Run("err, connect rejected", func(t *testing.T) {
+		for name, credentials := range map[string][2]string{
+			"no credentials":    {"", ""},
+			"no password":       {sensor.SerialNumber, ""},
+			"wrong password":    {sensor.SerialNumber, SensorPassword("another secret", sensor.SerialNumber)},
+			"other password":    {sensor.SerialNumber, SensorPassword(testSecret, "0000000000")},
+			"unknown sensor":    {"1111111111", SensorPassword(testSecret, "1111111111")},
+			"virtual sensor":    {"9000000000", SensorPassword(testSecret, "9000000000")},
+			"wrong reader pass": {testReader, "wrong"},
+		} {
+			client := newClient(addr, "rejected", credentials[0], credentials[1])
+			token := client.Connect()
+			require.True(t, token.WaitTimeout(time.Second), name)
+			assert.Error(t, token.Error(), name)
+		}
+	})
+
+	t.Run("ok, retained state for late subscriber", func(t *testing.T) {
+		consumer := connect(t, addr, "consumer-1", testReader, testReaderPassword)
+		r := &received{messages: make(map[string][]string)}
+		require.True(t, consumer.Subscribe("out/sensors/+/state", 0, r.handler).WaitTimeout(time.Second))
+
+		assert.Eventually(t, func() bool {
+			return assert.ObjectsAreEqual([]string{"3"}, r.get("out/sensors/1234567890/state"))
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("ok, device publishes event", func(t *testing.T) {
+		consumer := connect(t, addr, "consumer-2", testReader, testReaderPassword)
+		r := &received{messages: make(map[string][]string)}
+		require.True(t, consumer.Subscribe("out/sensors/#", 1, r.handler).WaitTimeout(time.Second))
+
+		// MQTT 3.1.1 не умеет отклонять публикацию, поэтому брокер отключает нарушителя
+		intruder := connect(t, addr, "intruder", testReader, testReaderPassword)
+		spoofer.Publish("sensors/1234567890/events", 1, false, "99").WaitTimeout(time.Second)
+		intruder.Publish("out/sensors/1234567890/state", 1, false, "98").WaitTimeout(time.Second)
+		require.True(t, device.Publish("sensors/1234567890/events", 1, false, "5").WaitTimeout(time.Second))
+
+		assert.Eventually(t, func() bool {
+			event, err := er.GetLastEventBySensorID(ctx, sensor.ID)
+			return err == nil && event.Payload == 5
+		}, 5*time.Second, 20*time.Millisecond)
+
+		stored, err := er.GetEventsByTimeFrame(ctx, sensor.ID, time.Now().Add(-time.Minute), time.Now())
+		require.NoError(t, err)
+		assert.Len(t, stored, 1)
+
+		assert.Eventually(t, func() bool {
+			return len(r.get("out/sensors/1234567890/events")) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+
+		var event domain.Event
+		require.NoError(t, json.Unmarshal([]byte(r.get("out/sensors/1234567890/events")[0]), &event))
+		assert.Equal(t, int64(5), event.Payload)
+		assert.Equal(t, sensor.ID, event.SensorID)
+		assert.Contains(t, r.get("out/sensors/1234567890/state"), "5")
+		assert.NotContains(t, r.get("out/sensors/1234567890/state"), "98")
+	})
+
+	t.Run("ok, events from other sources are republished", func(t *testing.T) {
+		consumer := connect(t, addr, "consumer-3", testReader, testReaderPassword)
+		r := &received{messages: make(map[string][]string)}
+		require.True(t, consumer.Subscribe("out/sensors/+/events", 1, r.handler).WaitTimeout(time.Second))
+
+		require.True(t, consumer.Subscribe("out/sensors/+/state", 1, r.handler).WaitTimeout(time.Second))
+
+		event := &domain.Event{SensorSerialNumber: sensor.SerialNumber, Timestamp: time.Now()}
+		event.SetValue(domain.FloatValue(7.5))
+		require.NoError(t, events.ReceiveEvent(ctx, event))
+
+		assert.Eventually(t, func() bool {
+			return len(r.get("out/sensors/1234567890/events")) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+		// в retained состоянии значение без округления
+		assert.Eventually(t, func() bool {
+			return slices.Contains(r.get("out/sensors/1234567890/state"), "7.5")
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func TestBrokerCommands(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	sr := sensorInMemory.NewSensorRepository()
+	relay := &domain.Sensor{SerialNumber: "1234567890", Type: "relay"}
+	require.NoError(t, sr.SaveSensor(ctx, relay))
+	require.NoError(t, sr.SaveSensor(ctx, &domain.Sensor{SerialNumber: "0000000000", Type: "relay"}))
+
+	events := usecase.NewEvent(eventInMemory.NewEventRepository(), sr)
+	commands := usecase.NewCommand(commandInMemory.NewCommandRepository(), sr)
+	events.AddListener(commands.ConfirmCommands)
+	addr := freeAddress(t)
+
+	b, err := NewBroker(events, usecase.NewSensor(sr), addr,
+		WithCommands(commands), WithSecret(testSecret), WithReader(testReader, testReaderPassword))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Run(ctx)
+	}()
+
+	device := connectSensor(t, addr, "device", relay.SerialNumber)
+	snooper := connectSensor(t, addr, "snooper", "0000000000")
+	reader := connect(t, addr, "reader", testReader, testReaderPassword)
+
+	// команда, поставленная до подписки, ждёт устройство в очереди
+	queued, err := commands.SendCommand(ctx, relay.ID, &domain.Command{Kind: domain.CommandSetState, State: 1}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CommandPending, queued.Status)
+
+	// подписки, которые покрывают топики команд других устройств, отклоняются
+	stolen := &received{messages: make(map[string][]string)}
+	for _, filter := range []string{"in/devices/+/commands", "in/devices/1234567890/commands", "in/#", "#", "$share/g/in/devices/+/commands"} {
+		for _, client := range []paho.Client{snooper, reader} {
+			token := client.Subscribe(filter, 1, stolen.handler)
+			require.True(t, token.WaitTimeout(time.Second))
+			// результат общей подписки paho хранит под фильтром без $share/{группа}/
+			result := token.(*paho.SubscribeToken).Result()[strings.TrimPrefix(filter, "$share/g/")]
+			assert.Equal(t, byte(0x80), result, filter)
+		}
+	}
+
+	// подписка фильтром с + или с QoS 0 не считается подпиской устройства на команды
+	r := &received{messages: make(map[string][]string)}
+	require.True(t, device.Subscribe("in/devices/1234567890/+", 1, r.handler).WaitTimeout(time.Second))
+	require.True(t, device.Subscribe("in/devices/1234567890/commands", 0, r.handler).WaitTimeout(time.Second))
+	assert.Never(t, func() bool {
+		return len(r.get("in/devices/1234567890/commands")) > 0
+	}, 300*time.Millisecond, 20*time.Millisecond)
+	command, err := commands.GetCommand(ctx, relay.ID, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CommandPending, command.Status)
+
+	require.True(t, device.Unsubscribe("in/devices/1234567890/+").WaitTimeout(time.Second))
+	require.True(t, device.Subscribe("in/devices/1234567890/commands", 1, r.handler).WaitTimeout(time.Second))
+
+	assert.Eventually(t, func() bool {
+		return len(r.get("in/devices/1234567890/commands")) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	// доставленной команду делает подтверждение устройства
+	assert.Eventually(t, func() bool {
+		command, err := commands.GetCommand(ctx, relay.ID, queued.ID)
+		return err == nil && command.Status == domain.CommandDelivered
+	}, 5*time.Second, 20*time.Millisecond)
+
+	_, err = commands.SendCommand(ctx, relay.ID, &domain.Command{Kind: domain.CommandPulse, State: 1, Duration: time.Second}, 0)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(r.get("in/devices/1234567890/commands")) == 2
+	}, 5*time.Second, 20*time.Millisecond)
+
+	var message commandMessage
+	require.NoError(t, json.Unmarshal([]byte(r.get("in/devices/1234567890/commands")[1]), &message))
+	assert.Equal(t, "pulse", message.Kind)
+	assert.Equal(t, int64(1000), message.DurationMs)
+	// подтверждённая первая команда повторно не отправляется
+	assert.Never(t, func() bool {
+		return len(r.get("in/devices/1234567890/commands")) > 2
+	}, 300*time.Millisecond, 20*time.Millisecond)
+
+	require.True(t, device.Publish("sensors/1234567890/events", 1, false, "1").WaitTimeout(time.Second))
+
+	assert.Eventually(t, func() bool {
+		command, err := commands.GetCommand(ctx, relay.ID, queued.ID)
+		return err == nil && command.Status == domain.CommandConfirmed
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Empty(t, stolen.get("in/devices/1234567890/commands"))
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func TestBrokerCommandsUnacknowledged(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	sr := sensorInMemory.NewSensorRepository()
+	relay := &domain.Sensor{SerialNumber: "1234567890", Type: "relay"}
+	require.NoError(t, sr.SaveSensor(ctx, relay))
+
+	events := usecase.NewEvent(eventInMemory.NewEventRepository(), sr)
+	commands := usecase.NewCommand(commandInMemory.NewCommandRepository(), sr)
+	addr := freeAddress(t)
+
+	b, err := NewBroker(events, usecase.NewSensor(sr), addr, WithCommands(commands), WithSecret(testSecret))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Run(ctx)
+	}()
+
+	device := connectClient(t, paho.NewClient(paho.NewClientOptions().
+		AddBroker("tcp://"+addr).
+		SetClientID("device").
+		SetUsername(relay.SerialNumber).
+		SetPassword(SensorPassword(testSecret, relay.SerialNumber)).
+		SetAutoReconnect(false).
+		SetAutoAckDisabled(true)))
+
+	first, err := commands.SendCommand(ctx, relay.ID, &domain.Command{Kind: domain.CommandSetState, State: 1}, 0)
+	require.NoError(t, err)
+
+	messages := make(chan paho.Message, 10)
+	require.True(t, device.Subscribe("in/devices/1234567890/commands", 1, func(_ paho.Client, msg paho.Message) {
+		messages <- msg
+	}).WaitTimeout(time.Second))
+
+	var unacked paho.Message
+	select {
+	case unacked = <-messages:
+	case <-ctx.Done():
+		require.FailNow(t, "command is not published")
+	}
+
+	// пока устройство не подтвердило получение, команда ждёт доставки и повторно не отправляется
+	second, err := commands.SendCommand(ctx, relay.ID, &domain.Command{Kind: domain.CommandSetState, State: 0}, 0)
+	require.NoError(t, err)
+
+	var message commandMessage
+	select {
+	case msg := <-messages:
+		require.NoError(t, json.Unmarshal(msg.Payload(), &message))
+		assert.Equal(t, second.ID, message.ID)
+		msg.Ack()
+	case <-ctx.Done():
+		require.FailNow(t, "second command is not published")
+	}
+	assert.Never(t, func() bool {
+		return len(messages) > 0
+	}, 300*time.Millisecond, 20*time.Millisecond)
+
+	command, err := commands.GetCommand(ctx, relay.ID, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CommandPending, command.Status)
+
+	unacked.Ack()
+	assert.Eventually(t, func() bool {
+		command, err := commands.GetCommand(ctx, relay.ID, first.ID)
+		return err == nil && command.Status == domain.CommandDelivered
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func TestNewBroker(t *testing.T) {
+	pattern, err := ParseTopicPattern("out/+/{serial}")
+	require.NoError(t, err)
+
+	_, err = NewBroker(nil, nil, "127.0.0.1:0", WithStateTopic(pattern), WithSecret(testSecret))
+	assert.ErrorIs(t, err, ErrAmbiguousTopicPattern)
+
+	_, err = NewBroker(nil, nil, "127.0.0.1:0")
+	assert.ErrorIs(t, err, ErrNoBrokerSecret)
+}
@@ -0,0 +1,238 @@
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"log/slog"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultTopic    = "sensors/" + SerialPlaceholder + "/events"
+	defaultClientID = "smarthome"
+	defaultQoS      = 1
+
+	connectTimeout    = 10 * time.Second
+	disconnectQuiesce = 250 // мс
+)
+
+var (
+	ErrInvalidQoS     = errors.New("qos must be 0, 1 or 2")
+	ErrInvalidPayload = errors.New("invalid event payload")
+)
+
+// Subscriber - принимает события датчиков из MQTT брокера и передаёт их в usecase.Event.
+//
+// При QoS 1 и 2 сессия на брокере сохраняется между подключениями, а сообщение подтверждается
+// только после обработки: если событие не удалось сохранить, брокер доставит его повторно
+// после переподключения. Невалидные сообщения и события неизвестных датчиков подтверждаются
+// и отбрасываются, чтобы не получать их снова.
+type Subscriber struct {
+	events   *usecase.Event
+	broker   string
+	clientID string
+	username string
+	password string
+	pattern  TopicPattern
+	qos      byte
+}
+
+func NewSubscriber(events *usecase.Event, broker string, options ...func(*Subscriber)) (*Subscriber, error) {
+	pattern, err := ParseTopicPattern(defaultTopic)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscriber{
+		events:   events,
+		broker:   broker,
+		clientID: defaultClientID,
+		pattern:  pattern,
+		qos:      defaultQoS,
+	}
+	for _, o := range options {
+		o(s)
+	}
+
+	if s.qos > 2 {
+		return nil, ErrInvalidQoS
+	}
+
+	return s, nil
+}
+
+// WithTopicPattern - шаблон топиков датчиков, см. TopicPattern
+func WithTopicPattern(pattern TopicPattern) func(*Subscriber) {
+	return func(s *Subscriber) {
+		s.pattern = pattern
+	}
+}
+
+func WithQoS(qos byte) func(*Subscriber) {
+	return func(s *Subscriber) {
+		s.qos = qos
+	}
+}
+
+func WithClientID(clientID string) func(*Subscriber) {
+	return func(s *Subscriber) {
+		s.clientID = clientID
+	}
+}
+
+func WithCredentials(username, password string) func(*Subscriber) {
+	return func(s *Subscriber) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// Run - подключается к брокеру и принимает события до отмены ctx.
+// Обрыв соединения не прерывает работу: клиент переподключается и подписывается заново.
+func (s *Subscriber) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With(slog.String("broker", s.broker), slog.String("topic", s.pattern.Filter()))
+	ctx = logging.WithContext(ctx, logger)
+
+	opts := paho.NewClientOptions().
+		AddBroker(s.broker).
+		SetClientID(s.clientID).
+		SetUsername(s.username).
+		SetPassword(s.password).
+		SetCleanSession(s.qos == 0).
+		SetOrderMatters(false).
+		SetAutoAckDisabled(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectTimeout(connectTimeout).
+		SetOnConnectHandler(func(client paho.Client) {
+			token := client.Subscribe(s.pattern.Filter(), s.qos, func(_ paho.Client, msg paho.Message) {
+				s.handle(ctx, msg)
+			})
+			if token.Wait() && token.Error() != nil {
+				logger.ErrorContext(ctx, "can't subscribe to mqtt topic", logging.Error(token.Error()))
+				return
+			}
+			logger.InfoContext(ctx, "subscribed to mqtt topic", slog.Int("qos", int(s.qos)))
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			logger.WarnContext(ctx, "mqtt connection lost", logging.Error(err))
+		})
+
+	client := paho.NewClient(opts)
+
+	token := client.Connect()
+	select {
+	case <-ctx.Done():
+		client.Disconnect(0)
+		return ctx.Err()
+	case <-token.Done():
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("can't connect to mqtt broker: %w", err)
+	}
+
+	<-ctx.Done()
+	client.Disconnect(disconnectQuiesce)
+
+	return ctx.Err()
+}
+
+func (s *Subscriber) handle(ctx context.Context, msg paho.Message) {
+	logger := logging.FromContext(ctx).With(slog.String("mqtt_topic", msg.Topic()))
+	ctx = logging.WithContext(ctx, logger)
+
+	event, err := parseEvent(s.pattern, msg.Topic(), msg.Payload())
+	if err != nil {
+		logger.WarnContext(ctx, "dropping invalid mqtt message", logging.Error(err))
+		msg.Ack()
+		return
+	}
+
+	err = s.events.ReceiveEvent(ctx, event)
+	switch {
+	case err == nil:
+		msg.Ack()
+	case errors.Is(err, usecase.ErrSensorNotFound):
+		logger.WarnContext(ctx, "dropping mqtt event of unknown sensor", logging.SerialNumber(event.SensorSerialNumber))
+		msg.Ack()
+	case errors.Is(err, usecase.ErrVirtualSensor):
+		logger.WarnContext(ctx, "dropping mqtt event of virtual sensor", logging.SerialNumber(event.SensorSerialNumber))
+		msg.Ack()
+	case errors.Is(err, usecase.ErrPayloadOutOfRange), errors.Is(err, usecase.ErrInvalidEventChannels):
+		logger.WarnContext(ctx, "dropping mqtt event with invalid payload",
+			logging.SerialNumber(event.SensorSerialNumber), logging.Error(err))
+		msg.Ack()
+	default:
+		// сообщение не подтверждается, брокер доставит его повторно
+		logger.ErrorContext(ctx, "unable to process mqtt event",
+			logging.SerialNumber(event.SensorSerialNumber), logging.Error(err))
+	}
+}
+
+// mqttEvent - JSON представление события, payload - число, true/false или объект с каналами.
+// Вместо него датчик может прислать просто число или true/false.
+type mqttEvent struct {
+	Payload   json.RawMessage `json:"payload"`
+	Timestamp *time.Time      `json:"timestamp"`
+}
+
+// parseEvent - разбирает сообщение, опубликованное в топик датчика
+func parseEvent(pattern TopicPattern, topic string, payload []byte) (*domain.Event, error) {
+	serial, ok := pattern.SerialNumber(topic)
+	if !ok {
+		return nil, fmt.Errorf("%w: topic %q doesn't match %q", ErrInvalidPayload, topic, pattern)
+	}
+
+	event := &domain.Event{
+		SensorSerialNumber: serial,
+		Timestamp:          time.Now(),
+	}
+
+	data := bytes.TrimSpace(payload)
+
+	if len(data) > 0 && data[0] != '{' {
+		v, err := domain.ParseValue(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		}
+		event.SetValue(v)
+		return event, nil
+	}
+
+	var v mqttEvent
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	value := bytes.TrimSpace(v.Payload)
+	switch {
+	case len(value) == 0 || bytes.Equal(value, []byte("null")):
+		return nil, fmt.Errorf("%w: payload is required", ErrInvalidPayload)
+	case value[0] == '{':
+		channels, err := domain.ParseChannels(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		}
+		event.SetChannels(channels)
+	default:
+		parsed, err := domain.ParseValue(string(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		}
+		event.SetValue(parsed)
+	}
+
+	if v.Timestamp != nil && !v.Timestamp.IsZero() {
+		event.Timestamp = *v.Timestamp
+	}
+
+	return event, nil
+}
@@ -0,0 +1,159 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	eventInMemory "homework/internal/repository/event/inmemory"
+	sensorInMemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startBroker - поднимает встроенный MQTT брокер на свободном порту
+func startBroker(t *testing.T) (*mochi.Server, string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, server.AddHook(new(auth.AllowHook), nil))
+	require.NoError(t, server.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})))
+
+	go func() {
+		_ = server.Serve()
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	return server, "tcp://" + addr
+}
+
+func TestSubscriber(t *testing.T) {
+	broker, url := startBroker(t)
+
+	er := eventInMemory.NewEventRepository()
+	sr := sensorInMemory.NewSensorRepository()
+	sensor := &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC}
+	require.NoError(t, sr.SaveSensor(context.Background(), sensor))
+
+	events := usecase.NewEvent(er, sr)
+
+	pattern, err := ParseTopicPattern("home/{serial}/events")
+	require.NoError(t, err)
+
+	s, err := NewSubscriber(events, url, WithTopicPattern(pattern), WithClientID("test"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	// публикуем, пока подписчик не подключится и не получит событие
+	waitPayload := func(topic string, msg []byte, payload int64) {
+		t.Helper()
+		assert.Eventually(t, func() bool {
+			require.NoError(t, broker.Publish(topic, msg, false, 1))
+			event, err := er.GetLastEventBySensorID(ctx, sensor.ID)
+			return err == nil && event.Payload == payload
+		}, 5*time.Second, 50*time.Millisecond)
+	}
+
+	t.Run("ok, plain payload", func(t *testing.T) {
+		waitPayload("home/1234567890/events", []byte("42"), 42)
+
+		actual, err := sr.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), actual.CurrentState)
+	})
+
+	t.Run("ok, json payload", func(t *testing.T) {
+		ts := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+		waitPayload("home/1234567890/events", []byte(`{"payload": 7, "timestamp": "`+ts.Format(time.RFC3339)+`"}`), 7)
+
+		event, err := er.GetLastEventBySensorID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assert.True(t, event.Timestamp.Equal(ts))
+	})
+
+	t.Run("ok, invalid messages are dropped", func(t *testing.T) {
+		require.NoError(t, broker.Publish("home/1234567890/events", []byte("not a number"), false, 1))
+		require.NoError(t, broker.Publish("home/0000000000/events", []byte("1"), false, 1))
+		ts := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		waitPayload("home/1234567890/events", []byte(`{"payload": 8, "timestamp": "`+ts+`"}`), 8)
+	})
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func Test_parseEvent(t *testing.T) {
+	pattern, err := ParseTopicPattern(defaultTopic)
+	require.NoError(t, err)
+
+	t.Run("err, invalid payload", func(t *testing.T) {
+		for _, payload := range []string{"", "abc", `{"timestamp": "2024-01-02T03:04:05Z"}`, "nan", `{"payload": "on"}`, `{"payload": {}}`} {
+			_, err := parseEvent(pattern, "sensors/1234567890/events", []byte(payload))
+			assert.ErrorIs(t, err, ErrInvalidPayload, payload)
+		}
+	})
+
+	t.Run("err, topic mismatch", func(t *testing.T) {
+		_, err := parseEvent(pattern, "other/1234567890", []byte("1"))
+		assert.ErrorIs(t, err, ErrInvalidPayload)
+	})
+
+	t.Run("ok, plain payload", func(t *testing.T) {
+		event, err := parseEvent(pattern, "sensors/1234567890/events", []byte(" -3\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "1234567890", event.SensorSerialNumber)
+		assert.Equal(t, int64(-3), event.Payload)
+		assert.False(t, event.Timestamp.IsZero())
+	})
+
+	t.Run("ok, typed payloads", func(t *testing.T) {
+		event, err := parseEvent(pattern, "sensors/1234567890/events", []byte("21.6"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(22), event.Payload)
+		assert.Equal(t, map[string]domain.Value{"value": domain.FloatValue(21.6)}, event.Channels)
+
+		event, err = parseEvent(pattern, "sensors/1234567890/events", []byte("true"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), event.Payload)
+
+		event, err = parseEvent(pattern, "sensors/1234567890/events",
+			[]byte(`{"payload": {"temperature": 21.5, "door": false}, "timestamp": "2024-01-02T03:04:05Z"}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]domain.Value{
+			"temperature": domain.FloatValue(21.5),
+			"door":        domain.BoolValue(false),
+		}, event.Channels)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), event.Timestamp)
+	})
+}
+
+func TestNewSubscriber(t *testing.T) {
+	_, err := NewSubscriber(nil, "tcp://localhost:1883", WithQoS(3))
+	assert.ErrorIs(t, err, ErrInvalidQoS)
+}
@@ -0,0 +1,132 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SerialPlaceholder - уровень шаблона топика, на месте которого стоит серийный номер датчика
+const SerialPlaceholder = "{serial}"
+
+var ErrInvalidTopicPattern = errors.New("invalid topic pattern")
+
+// TopicPattern - шаблон топика вида sensors/{serial}/events.
+// Для подписки {serial} заменяется на +, а из топика сообщения извлекается серийный номер.
+type TopicPattern struct {
+	levels []string
+	serial int
+}
+
+func ParseTopicPattern(pattern string) (TopicPattern, error) {
+	levels := strings.Split(pattern, "/")
+	serial := -1
+
+	for i, level := range levels {
+		switch {
+		case level == SerialPlaceholder:
+			if serial != -1 {
+				return TopicPattern{}, fmt.Errorf("%w: %s must occur once: %q", ErrInvalidTopicPattern, SerialPlaceholder, pattern)
+			}
+			serial = i
+		case level == "#" && i != len(levels)-1:
+			return TopicPattern{}, fmt.Errorf("%w: # must be the last level: %q", ErrInvalidTopicPattern, pattern)
+		case level != "+" && level != "#" && strings.ContainsAny(level, "+#{}"):
+			return TopicPattern{}, fmt.Errorf("%w: invalid level %q: %q", ErrInvalidTopicPattern, level, pattern)
+		}
+	}
+
+	if serial == -1 {
+		return TopicPattern{}, fmt.Errorf("%w: %s is required: %q", ErrInvalidTopicPattern, SerialPlaceholder, pattern)
+	}
+
+	return TopicPattern{levels: levels, serial: serial}, nil
+}
+
+// Filter - фильтр топиков для подписки на брокере
+func (p TopicPattern) Filter() string {
+	levels := make([]string, len(p.levels))
+	copy(levels, p.levels)
+	levels[p.serial] = "+"
+	return strings.Join(levels, "/")
+}
+
+// Topic - топик датчика с серийным номером serial.
+// Для шаблонов с + и # топик не определён однозначно, для них возвращается false.
+func (p TopicPattern) Topic(serial string) (string, bool) {
+	levels := make([]string, len(p.levels))
+	for i, level := range p.levels {
+		if level == "+" || level == "#" {
+			return "", false
+		}
+		levels[i] = level
+	}
+	levels[p.serial] = serial
+	return strings.Join(levels, "/"), true
+}
+
+// SerialNumber - извлекает серийный номер датчика из топика
+func (p TopicPattern) SerialNumber(topic string) (string, bool) {
+	levels := strings.Split(topic, "/")
+
+	for i, level := range p.levels {
+		if level == "#" {
+			break
+		}
+		if i >= len(levels) {
+			return "", false
+		}
+		if level != "+" && i != p.serial && level != levels[i] {
+			return "", false
+		}
+	}
+
+	if p.levels[len(p.levels)-1] != "#" && len(levels) != len(p.levels) {
+		return "", false
+	}
+
+	serial := levels[p.serial]
+	if serial == "" {
+		return "", false
+	}
+
+	return serial, true
+}
+
+// CoversOtherSerial - может ли фильтр подписки filter получать сообщения из топиков шаблона
+// с серийным номером, отличным от serial. Например, для шаблона in/{serial}/commands чужие топики
+// покрывают фильтры #, in/+/commands и in/0000000000/commands, а in/1234567890/# для датчика
+// 1234567890 - нет. Префикс общей подписки $share/{группа}/ не учитывается.
+func (p TopicPattern) CoversOtherSerial(filter, serial string) bool {
+	if parts := strings.SplitN(filter, "/", 3); len(parts) == 3 && strings.EqualFold(parts[0], "$share") {
+		filter = parts[2]
+	}
+	levels := strings.Split(filter, "/")
+
+	other := false
+	for i, level := range p.levels {
+		if i >= len(levels) {
+			// # в конце шаблона совпадает и с родительским уровнем
+			return other && level == "#"
+		}
+		switch f := levels[i]; {
+		case f == "#":
+			return other || i <= p.serial
+		case i == p.serial:
+			other = f != serial
+		case level == "#":
+			return other
+		case f != "+" && level != "+" && f != level:
+			return false
+		}
+	}
+
+	if len(levels) == len(p.levels) {
+		return other
+	}
+	return other && len(levels) == len(p.levels)+1 && levels[len(p.levels)] == "#"
+}
+
+func (p TopicPattern) String() string {
+	return strings.Join(p.levels, "/")
+}
@@ -0,0 +1,92 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTopicPattern(t *testing.T) {
+	t.Run("err, invalid patterns", func(t *testing.T) {
+		for _, pattern := range []string{
+			"sensors/events",
+			"sensors/{serial}/{serial}",
+			"sensors/#/{serial}",
+			"sensors/a+b/{serial}",
+			"sensors/{serial}x",
+		} {
+			_, err := ParseTopicPattern(pattern)
+			assert.ErrorIs(t, err, ErrInvalidTopicPattern, pattern)
+		}
+	})
+
+	t.Run("ok, filter", func(t *testing.T) {
+		p, err := ParseTopicPattern("home/+/{serial}/#")
+		require.NoError(t, err)
+		assert.Equal(t, "home/+/+/#", p.Filter())
+	})
+}
+
+func TestTopicPattern_SerialNumber(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		serial  string
+		ok      bool
+	}{
+		{"sensors/{serial}/events", "sensors/1234567890/events", "1234567890", true},
+		{"sensors/{serial}/events", "sensors/1234567890/state", "", false},
+		{"sensors/{serial}/events", "sensors/1234567890/events/extra", "", false},
+		{"sensors/{serial}/events", "sensors//events", "", false},
+		{"{serial}", "1234567890", "1234567890", true},
+		{"home/+/{serial}", "home/kitchen/1234567890", "1234567890", true},
+		{"home/{serial}/#", "home/1234567890/a/b", "1234567890", true},
+		{"home/{serial}/#", "home", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.topic, func(t *testing.T) {
+			p, err := ParseTopicPattern(tt.pattern)
+			require.NoError(t, err)
+
+			serial, ok := p.SerialNumber(tt.topic)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.serial, serial)
+		})
+	}
+}
+
+func TestTopicPattern_CoversOtherSerial(t *testing.T) {
+	tests := []struct {
+		pattern string
+		filter  string
+		covers  bool
+	}{
+		{"in/devices/{serial}/commands", "in/devices/1234567890/commands", false},
+		{"in/devices/{serial}/commands", "in/devices/1234567890/+", false},
+		{"in/devices/{serial}/commands", "in/devices/1234567890/#", false},
+		{"in/devices/{serial}/commands", "out/#", false},
+		{"in/devices/{serial}/commands", "in/devices/+/state", false},
+		{"in/devices/{serial}/commands", "in/devices/+/commands/extra", false},
+		{"in/devices/{serial}/commands", "#", true},
+		{"in/devices/{serial}/commands", "in/#", true},
+		{"in/devices/{serial}/commands", "in/devices/#", true},
+		{"in/devices/{serial}/commands", "+/+/+/+", true},
+		{"in/devices/{serial}/commands", "in/devices/+/commands", true},
+		{"in/devices/{serial}/commands", "in/devices/0000000000/commands", true},
+		{"in/devices/{serial}/commands", "in/devices/0000000000/commands/#", true},
+		{"in/devices/{serial}/commands", "$share/group/in/devices/+/commands", true},
+		{"in/devices/{serial}/commands", "$share/group/in/devices/1234567890/commands", false},
+		{"in/{serial}/#", "in/0000000000", true},
+		{"in/{serial}/#", "in/1234567890/a/+", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.filter, func(t *testing.T) {
+			p, err := ParseTopicPattern(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.covers, p.CoversOtherSerial(tt.filter, "1234567890"))
+		})
+	}
+}
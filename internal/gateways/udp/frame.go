@@ -0,0 +1,104 @@
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"time"
+)
+
+// Формат кадра (все числа - big endian):
+//
+//	0      версия, frameVersion
+//	1      флаги, flagTimestamp - в кадре есть время события
+//	2      длина серийного номера N
+//	3      серийный номер, N байт ASCII
+//	3+N    показание датчика, int64
+//	11+N   время события в миллисекундах Unix, int64, только с flagTimestamp
+const (
+	frameVersion = 1
+
+	flagTimestamp = 1 << 0
+
+	headerSize   = 3
+	payloadSize  = 8
+	maxSerialLen = 32
+	maxFrameSize = headerSize + maxSerialLen + 2*payloadSize
+)
+
+var ErrMalformedFrame = errors.New("malformed frame")
+
+// Причины отклонения кадра, они же значения метки reason метрики некорректных датаграмм
+const (
+	reasonShort   = "short"
+	reasonVersion = "version"
+	reasonFlags   = "flags"
+	reasonSerial  = "serial"
+	reasonLength  = "length"
+)
+
+// frameError - ошибка разбора кадра с причиной для метрик
+type frameError struct {
+	reason string
+	detail string
+}
+
+func (e *frameError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMalformedFrame, e.detail)
+}
+
+func (e *frameError) Unwrap() error {
+	return ErrMalformedFrame
+}
+
+func malformed(reason, format string, args ...any) error {
+	return &frameError{reason: reason, detail: fmt.Sprintf(format, args...)}
+}
+
+// parseFrame - разбирает датаграмму. Если времени в кадре нет, берётся now.
+func parseFrame(data []byte, now time.Time) (*domain.Event, error) {
+	if len(data) < headerSize {
+		return nil, malformed(reasonShort, "%d bytes", len(data))
+	}
+	if data[0] != frameVersion {
+		return nil, malformed(reasonVersion, "unsupported version %d", data[0])
+	}
+
+	flags := data[1]
+	if flags&^flagTimestamp != 0 {
+		return nil, malformed(reasonFlags, "unknown flags %#x", flags)
+	}
+
+	serialLen := int(data[2])
+	if serialLen == 0 || serialLen > maxSerialLen {
+		return nil, malformed(reasonSerial, "serial number length %d", serialLen)
+	}
+
+	size := headerSize + serialLen + payloadSize
+	if flags&flagTimestamp != 0 {
+		size += payloadSize
+	}
+	if len(data) != size {
+		return nil, malformed(reasonLength, "got %d bytes, want %d", len(data), size)
+	}
+
+	serial := data[headerSize : headerSize+serialLen]
+	for _, c := range serial {
+		if c < 0x21 || c > 0x7e {
+			return nil, malformed(reasonSerial, "invalid serial number character %#x", c)
+		}
+	}
+
+	rest := data[headerSize+serialLen:]
+	event := &domain.Event{
+		SensorSerialNumber: string(serial),
+		Payload:            int64(binary.BigEndian.Uint64(rest)),
+		Timestamp:          now,
+	}
+	if flags&flagTimestamp != 0 {
+		event.Timestamp = time.UnixMilli(int64(binary.BigEndian.Uint64(rest[payloadSize:])))
+	}
+
+	return event, nil
+}
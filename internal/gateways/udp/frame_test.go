@@ -0,0 +1,78 @@
+package udp
+
+import (
+	"encoding/binary"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appendFrame - кодирует событие в кадр. Нулевое время события в кадр не записывается.
+func appendFrame(b []byte, event domain.Event) []byte {
+	var flags byte
+	if !event.Timestamp.IsZero() {
+		flags |= flagTimestamp
+	}
+
+	b = append(b, frameVersion, flags, byte(len(event.SensorSerialNumber)))
+	b = append(b, event.SensorSerialNumber...)
+	b = binary.BigEndian.AppendUint64(b, uint64(event.Payload))
+	if flags&flagTimestamp != 0 {
+		b = binary.BigEndian.AppendUint64(b, uint64(event.Timestamp.UnixMilli()))
+	}
+
+	return b
+}
+
+func Test_parseFrame(t *testing.T) {
+	now := time.Now()
+	ts := time.UnixMilli(1700000000123)
+
+	t.Run("ok, without timestamp", func(t *testing.T) {
+		event, err := parseFrame(appendFrame(nil, domain.Event{SensorSerialNumber: "1234567890", Payload: -5}), now)
+		require.NoError(t, err)
+		assert.Equal(t, &domain.Event{SensorSerialNumber: "1234567890", Payload: -5, Timestamp: now}, event)
+	})
+
+	t.Run("ok, with timestamp", func(t *testing.T) {
+		data := appendFrame(nil, domain.Event{SensorSerialNumber: "1234567890", Payload: 42, Timestamp: ts})
+		assert.Len(t, data, 29)
+
+		event, err := parseFrame(data, now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), event.Payload)
+		assert.True(t, ts.Equal(event.Timestamp))
+	})
+
+	valid := appendFrame(nil, domain.Event{SensorSerialNumber: "1234567890", Payload: 1})
+
+	tests := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"empty", nil, reasonShort},
+		{"short header", []byte{frameVersion, 0}, reasonShort},
+		{"unknown version", append([]byte{2}, valid[1:]...), reasonVersion},
+		{"unknown flags", append([]byte{frameVersion, 0x80}, valid[2:]...), reasonFlags},
+		{"empty serial", []byte{frameVersion, 0, 0}, reasonSerial},
+		{"too long serial", []byte{frameVersion, 0, maxSerialLen + 1}, reasonSerial},
+		{"truncated payload", valid[:len(valid)-1], reasonLength},
+		{"trailing bytes", append(append([]byte{}, valid...), 0), reasonLength},
+		{"missing timestamp", append([]byte{frameVersion, flagTimestamp}, valid[2:]...), reasonLength},
+		{"binary serial", binary.BigEndian.AppendUint64([]byte{frameVersion, 0, 2, 0, 1}, 1), reasonSerial},
+	}
+	for _, tt := range tests {
+		t.Run("err, "+tt.name, func(t *testing.T) {
+			_, err := parseFrame(tt.data, now)
+			assert.ErrorIs(t, err, ErrMalformedFrame)
+
+			var fe *frameError
+			require.ErrorAs(t, err, &fe)
+			assert.Equal(t, tt.reason, fe.reason)
+		})
+	}
+}
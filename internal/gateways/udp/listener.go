@@ -0,0 +1,213 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"log/slog"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultInterval = time.Second
+	defaultBurst    = 10
+
+	// limiterTTL - через сколько забывается лимит источника, от которого нет датаграмм
+	limiterTTL = 10 * time.Minute
+	// maxLimiters - сколько источников лимитируется по отдельности. Адрес отправителя UDP легко подделать,
+	// поэтому новые источники сверх этого числа делят один общий лимит.
+	maxLimiters = 10000
+)
+
+// Исходы обработки датаграммы, значения метки result
+const (
+	resultAccepted    = "accepted"
+	resultMalformed   = "malformed"
+	resultRateLimited = "rate_limited"
+	resultRejected    = "rejected"
+	resultFailed      = "failed"
+)
+
+var (
+	udpDatagramsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "udp_datagrams_total",
+			Help: "Total number of received UDP datagrams by processing result",
+		},
+		[]string{"result"},
+	)
+
+	udpMalformedDatagramsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "udp_malformed_datagrams_total",
+			Help: "Total number of malformed UDP datagrams by reason",
+		},
+		[]string{"reason"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(udpDatagramsTotal, udpMalformedDatagramsTotal)
+}
+
+// Listener - приём событий датчиков в UDP датаграммах, по одному событию в кадре (см. parseFrame).
+//
+// Ответы не отправляются: датчик не ждёт подтверждения и не тратит на это энергию.
+// Датаграммы сверх лимита источника (IP адреса) отбрасываются.
+type Listener struct {
+	events   *usecase.Event
+	address  string
+	interval time.Duration
+	burst    int
+
+	// limiters используется только из цикла Serve и не требует блокировки
+	limiters map[netip.Addr]*sourceLimiter
+	shared   *rate.Limiter
+	swept    time.Time
+}
+
+type sourceLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewListener(events *usecase.Event, address string, options ...func(*Listener)) *Listener {
+	l := &Listener{
+		events:   events,
+		address:  address,
+		interval: defaultInterval,
+		burst:    defaultBurst,
+		limiters: make(map[netip.Addr]*sourceLimiter),
+	}
+	for _, o := range options {
+		o(l)
+	}
+
+	return l
+}
+
+// WithRateLimit - источник может присылать одну датаграмму в interval и до burst датаграмм подряд.
+// Нулевой interval отключает ограничение.
+func WithRateLimit(interval time.Duration, burst int) func(*Listener) {
+	return func(l *Listener) {
+		l.interval = interval
+		l.burst = burst
+	}
+}
+
+// Run - слушает address и принимает события до отмены ctx
+func (l *Listener) Run(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", l.address)
+	if err != nil {
+		return err
+	}
+
+	return l.Serve(ctx, conn)
+}
+
+// Serve - принимает события из conn до отмены ctx, после чего закрывает conn
+func (l *Listener) Serve(ctx context.Context, conn net.PacketConn) error {
+	logger := logging.FromContext(ctx).With(slog.String("udp_address", conn.LocalAddr().String()))
+	ctx = logging.WithContext(ctx, logger)
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	// кадр больше maxFrameSize заведомо некорректен, лишний байт позволяет это заметить
+	buf := make([]byte, maxFrameSize+1)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			logger.WarnContext(ctx, "can't read udp datagram", logging.Error(err))
+			continue
+		}
+
+		udpDatagramsTotal.WithLabelValues(l.handle(ctx, buf[:n], addr)).Inc()
+	}
+}
+
+func (l *Listener) handle(ctx context.Context, data []byte, addr net.Addr) string {
+	now := time.Now()
+
+	if !l.allow(addr, now) {
+		return resultRateLimited
+	}
+
+	logger := logging.FromContext(ctx).With(slog.String("udp_source", addr.String()))
+
+	event, err := parseFrame(data, now)
+	if err != nil {
+		var fe *frameError
+		if errors.As(err, &fe) {
+			udpMalformedDatagramsTotal.WithLabelValues(fe.reason).Inc()
+		}
+		logger.DebugContext(ctx, "dropping malformed udp datagram", logging.Error(err))
+		return resultMalformed
+	}
+
+	err = l.events.ReceiveEvent(ctx, event)
+	switch {
+	case err == nil:
+		return resultAccepted
+	case errors.Is(err, usecase.ErrSensorNotFound), errors.Is(err, usecase.ErrInvalidEventTimestamp),
+		errors.Is(err, usecase.ErrPayloadOutOfRange), errors.Is(err, usecase.ErrVirtualSensor):
+		logger.DebugContext(ctx, "udp event rejected",
+			logging.SerialNumber(event.SensorSerialNumber), logging.Error(err))
+		return resultRejected
+	default:
+		logger.ErrorContext(ctx, "unable to process udp event",
+			logging.SerialNumber(event.SensorSerialNumber), logging.Error(err))
+		return resultFailed
+	}
+}
+
+// allow - проверяет лимит источника и заодно забывает давно молчащие источники.
+// Если источников уже maxLimiters, новый проверяется по общему лимиту.
+func (l *Listener) allow(addr net.Addr, now time.Time) bool {
+	if l.interval <= 0 {
+		return true
+	}
+
+	var ip netip.Addr
+	if ua, ok := addr.(*net.UDPAddr); ok {
+		ip = ua.AddrPort().Addr().Unmap()
+	}
+
+	if now.Sub(l.swept) > limiterTTL {
+		for a, s := range l.limiters {
+			if now.Sub(s.lastSeen) > limiterTTL {
+				delete(l.limiters, a)
+			}
+		}
+		l.swept = now
+	}
+
+	s, ok := l.limiters[ip]
+	if !ok && len(l.limiters) >= maxLimiters {
+		if l.shared == nil {
+			l.shared = rate.NewLimiter(rate.Every(l.interval), l.burst)
+		}
+		return l.shared.AllowN(now, 1)
+	}
+	if !ok {
+		s = &sourceLimiter{limiter: rate.NewLimiter(rate.Every(l.interval), l.burst)}
+		l.limiters[ip] = s
+	}
+	s.lastSeen = now
+
+	return s.limiter.AllowN(now, 1)
+}
@@ -0,0 +1,116 @@
+package udp
+
+import (
+	"context"
+	"homework/internal/domain"
+	eventInMemory "homework/internal/repository/event/inmemory"
+	sensorInMemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sr := sensorInMemory.NewSensorRepository()
+	er := eventInMemory.NewEventRepository()
+	events := usecase.NewEvent(er, sr)
+
+	sensor, err := usecase.NewSensor(sr).RegisterSensor(ctx, &domain.Sensor{
+		SerialNumber: "1234567890",
+		Type:         domain.SensorTypeADC,
+	})
+	require.NoError(t, err)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	l := NewListener(events, "", WithRateLimit(time.Hour, 4))
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Serve(ctx, conn)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	counter := func(result string) float64 {
+		return testutil.ToFloat64(udpDatagramsTotal.WithLabelValues(result))
+	}
+	accepted, malformed, rejected, limited := counter(resultAccepted), counter(resultMalformed),
+		counter(resultRejected), counter(resultRateLimited)
+	malformedVersion := testutil.ToFloat64(udpMalformedDatagramsTotal.WithLabelValues(reasonVersion))
+
+	ts := time.Now().Truncate(time.Millisecond)
+	for _, data := range [][]byte{
+		appendFrame(nil, domain.Event{SensorSerialNumber: "1234567890", Payload: 7, Timestamp: ts}),
+		{9, 0, 0},
+		appendFrame(nil, domain.Event{SensorSerialNumber: "0000000000", Payload: 1}),
+		appendFrame(nil, domain.Event{SensorSerialNumber: "1234567890", Payload: 8}),
+		// пятая датаграмма превышает burst
+		appendFrame(nil, domain.Event{SensorSerialNumber: "1234567890", Payload: 9}),
+	} {
+		_, err := client.Write(data)
+		require.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		return counter(resultRateLimited)-limited == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 2.0, counter(resultAccepted)-accepted)
+	assert.Equal(t, 1.0, counter(resultMalformed)-malformed)
+	assert.Equal(t, 1.0, counter(resultRejected)-rejected)
+	assert.Equal(t, 1.0, testutil.ToFloat64(udpMalformedDatagramsTotal.WithLabelValues(reasonVersion))-malformedVersion)
+
+	history, err := events.GetEventsByTimeFrame(ctx, sensor.ID, ts.Add(-time.Minute), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.True(t, ts.Equal(history[0].Timestamp))
+	assert.Equal(t, int64(7), history[0].Payload)
+	assert.Equal(t, int64(8), history[1].Payload)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestListener_allow(t *testing.T) {
+	l := NewListener(nil, "", WithRateLimit(time.Second, 1))
+	now := time.Now()
+	a := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5683}
+	b := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5683}
+
+	assert.True(t, l.allow(a, now))
+	assert.False(t, l.allow(a, now.Add(100*time.Millisecond)))
+	// лимит считается по адресу, а не по порту источника
+	assert.False(t, l.allow(&net.UDPAddr{IP: a.IP, Port: 1}, now.Add(200*time.Millisecond)))
+	assert.True(t, l.allow(b, now))
+	assert.True(t, l.allow(a, now.Add(time.Second)))
+
+	// источники, от которых давно нет датаграмм, забываются
+	assert.True(t, l.allow(b, now.Add(2*limiterTTL)))
+	assert.Len(t, l.limiters, 1)
+
+	full := NewListener(nil, "", WithRateLimit(time.Second, 1))
+	for i := 0; i < maxLimiters; i++ {
+		spoofed := &net.UDPAddr{IP: net.IPv4(10, byte(i>>16), byte(i>>8), byte(i)), Port: 1}
+		assert.True(t, full.allow(spoofed, now))
+	}
+	assert.Len(t, full.limiters, maxLimiters)
+	// новые источники сверх maxLimiters делят общий лимит
+	assert.True(t, full.allow(&net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1}, now))
+	assert.False(t, full.allow(&net.UDPAddr{IP: net.IPv4(192, 168, 0, 2), Port: 1}, now))
+	assert.Len(t, full.limiters, maxLimiters)
+
+	unlimited := NewListener(nil, "", WithRateLimit(0, 0))
+	assert.True(t, unlimited.allow(a, now))
+	assert.True(t, unlimited.allow(a, now))
+}
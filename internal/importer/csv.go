@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"io"
+	"strings"
+)
+
+// columns - обязательные колонки и их синонимы
+var columns = map[domain.ImportKind][][]string{
+	domain.ImportKindSensors: {{"serial_number"}, {"type"}},
+	domain.ImportKindEvents:  {{"sensor_serial_number", "serial_number"}, {"timestamp"}, {"payload"}},
+}
+
+type csvReader struct {
+	r     *csv.Reader
+	kind  domain.ImportKind
+	index map[string]int
+	row   int64
+}
+
+func newCSVReader(r io.Reader, kind domain.ImportKind) *csvReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	cr.TrimLeadingSpace = true
+
+	return &csvReader{r: cr, kind: kind}
+}
+
+func (r *csvReader) readHeader() error {
+	header, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("can't read header: %w", err)
+	}
+
+	r.index = make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := r.index[name]; !ok {
+			r.index[name] = i
+		}
+	}
+
+	for _, names := range columns[r.kind] {
+		if r.column(names...) < 0 {
+			return fmt.Errorf("%w: %s", ErrMissingColumn, names[0])
+		}
+	}
+
+	return nil
+}
+
+// column - номер первой из колонок names, которая есть в заголовке
+func (r *csvReader) column(names ...string) int {
+	for _, name := range names {
+		if i, ok := r.index[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *csvReader) field(record []string, names ...string) string {
+	i := r.column(names...)
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func (r *csvReader) Next() (usecase.ImportRecord, error) {
+	if r.index == nil {
+		if err := r.readHeader(); err != nil {
+			return usecase.ImportRecord{}, err
+		}
+	}
+
+	record, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return usecase.ImportRecord{}, io.EOF
+	}
+
+	r.row++
+	out := usecase.ImportRecord{Row: r.row}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		out.Err = invalidRow("%s", parseErr.Err)
+		return out, nil
+	}
+	if err != nil {
+		return usecase.ImportRecord{}, err
+	}
+
+	switch r.kind {
+	case domain.ImportKindSensors:
+		out.Sensor, out.Err = r.sensor(record)
+	case domain.ImportKindEvents:
+		out.Event, out.Err = r.event(record)
+	}
+
+	return out, nil
+}
+
+func (r *csvReader) sensor(record []string) (*domain.Sensor, error) {
+	isActive, err := parseIsActive(r.field(record, "is_active"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Sensor{
+		SerialNumber: r.field(record, "serial_number"),
+		Type:         domain.SensorType(r.field(record, "type")),
+		Description:  r.field(record, "description"),
+		IsActive:     isActive,
+	}, nil
+}
+
+func (r *csvReader) event(record []string) (*domain.Event, error) {
+	timestamp, err := parseTimestamp(r.field(record, "timestamp"))
+	if err != nil {
+		return nil, err
+	}
+
+	event := &domain.Event{
+		SensorSerialNumber: r.field(record, "sensor_serial_number", "serial_number"),
+		Timestamp:          timestamp,
+	}
+	if err := setEventValue(event, r.field(record, "payload"), []byte(r.field(record, "channels"))); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,127 @@
+// Package importer - разбор файлов загрузки датчиков и событий в форматах CSV и NDJSON
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format - формат файла загрузки
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported import format")
+	ErrMissingColumn     = errors.New("missing required column")
+	ErrInvalidRow        = errors.New("invalid row")
+)
+
+var mediaTypes = map[string]Format{
+	"text/csv":             FormatCSV,
+	"application/csv":      FormatCSV,
+	"application/x-ndjson": FormatNDJSON,
+	"application/ndjson":   FormatNDJSON,
+	"application/jsonl":    FormatNDJSON,
+}
+
+// ParseFormat - формат по имени или расширению файла, например csv или .ndjson
+func ParseFormat(name string) (Format, error) {
+	switch strings.TrimPrefix(strings.ToLower(name), ".") {
+	case "csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// FormatFromContentType - формат по заголовку Content-Type
+func FormatFromContentType(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+	if f, ok := mediaTypes[mediaType]; ok {
+		return f, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// NewReader - источник строк загрузки kind из r. Колонки CSV и поля NDJSON совпадают:
+// для датчиков serial_number, type, description и is_active (по умолчанию true),
+// для событий sensor_serial_number (или serial_number), timestamp в RFC 3339 и payload.
+// Поэтому файлы выгрузки /events/export загружаются без изменений.
+func NewReader(r io.Reader, format Format, kind domain.ImportKind) (usecase.ImportSource, error) {
+	if kind != domain.ImportKindSensors && kind != domain.ImportKindEvents {
+		return nil, usecase.ErrWrongImportKind
+	}
+
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r, kind), nil
+	case FormatNDJSON:
+		return newNDJSONReader(r, kind), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func invalidRow(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRow, fmt.Sprintf(format, args...))
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, invalidRow("timestamp is required")
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, invalidRow("timestamp %q is not RFC 3339", s)
+	}
+	return t, nil
+}
+
+// setEventValue - значение события из payload (целое, дробное или true/false) и каналов в JSON.
+// Если каналы заданы, payload не учитывается: при выгрузке в нём округлённое значение основного канала.
+func setEventValue(event *domain.Event, payload string, channels []byte) error {
+	if len(bytes.TrimSpace(channels)) > 0 {
+		parsed, err := domain.ParseChannels(channels)
+		if err != nil {
+			return invalidRow("channels: %s", err)
+		}
+		event.SetChannels(parsed)
+		return nil
+	}
+
+	if payload == "" {
+		return invalidRow("payload is required")
+	}
+	v, err := domain.ParseValue(payload)
+	if err != nil {
+		return invalidRow("payload %q is not a number or boolean", payload)
+	}
+	event.SetValue(v)
+	return nil
+}
+
+func parseIsActive(s string) (bool, error) {
+	if s == "" {
+		return true, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, invalidRow("is_active %q is not a boolean", s)
+	}
+	return v, nil
+}
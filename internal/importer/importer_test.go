@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, src usecase.ImportSource) []usecase.ImportRecord {
+	t.Helper()
+
+	var records []usecase.ImportRecord
+	for {
+		r, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, r)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for name, want := range map[string]Format{"csv": FormatCSV, ".CSV": FormatCSV, "ndjson": FormatNDJSON, ".jsonl": FormatNDJSON} {
+		f, err := ParseFormat(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, f)
+	}
+	_, err := ParseFormat("parquet")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	f, err := FormatFromContentType("text/csv; charset=utf-8")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+
+	f, err = FormatFromContentType("application/x-ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, FormatNDJSON, f)
+
+	_, err = FormatFromContentType("application/json")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestCSVReader(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("events, export layout", func(t *testing.T) {
+		src, err := NewReader(strings.NewReader(strings.Join([]string{
+			"sensor_id,sensor_serial_number,timestamp,payload",
+			"1,1234567890,2024-03-01T12:00:00Z,42",
+			"1,1234567890,yesterday,1",
+			"1,1234567890,2024-03-01T12:00:00Z,",
+			`1,"1234567890,2024-03-01T12:00:00Z,1`,
+		}, "\n")), FormatCSV, domain.ImportKindEvents)
+		require.NoError(t, err)
+
+		records := readAll(t, src)
+		require.Len(t, records, 4)
+
+		assert.Equal(t, usecase.ImportRecord{
+			Row:   1,
+			Event: &domain.Event{SensorSerialNumber: "1234567890", Timestamp: ts, Payload: 42},
+		}, records[0])
+		assert.ErrorIs(t, records[1].Err, ErrInvalidRow)
+		assert.ErrorContains(t, records[1].Err, "timestamp")
+		assert.ErrorContains(t, records[2].Err, "payload is required")
+		assert.ErrorIs(t, records[3].Err, ErrInvalidRow)
+		assert.Equal(t, int64(4), records[3].Row)
+	})
+
+	t.Run("events, typed payload and channels", func(t *testing.T) {
+		src, err := NewReader(strings.NewReader(strings.Join([]string{
+			"sensor_id,sensor_serial_number,timestamp,payload,channels",
+			"1,1234567890,2024-03-01T12:00:00Z,21.6,",
+			"1,1234567890,2024-03-01T12:00:00Z,true,",
+			`1,1234567890,2024-03-01T12:00:00Z,22,"{""value"":21.5,""humidity"":40}"`,
+			"1,1234567890,2024-03-01T12:00:00Z,on,",
+			`1,1234567890,2024-03-01T12:00:00Z,1,"{""value"":"""",}"`,
+		}, "\n")), FormatCSV, domain.ImportKindEvents)
+		require.NoError(t, err)
+
+		records := readAll(t, src)
+		require.Len(t, records, 5)
+
+		assert.Equal(t, int64(22), records[0].Event.Payload)
+		assert.Equal(t, map[string]domain.Value{"value": domain.FloatValue(21.6)}, records[0].Event.Channels)
+		assert.Equal(t, map[string]domain.Value{"value": domain.BoolValue(true)}, records[1].Event.Channels)
+		assert.Equal(t, map[string]domain.Value{
+			"value":    domain.FloatValue(21.5),
+			"humidity": domain.IntValue(40),
+		}, records[2].Event.Channels)
+		assert.ErrorContains(t, records[3].Err, "not a number or boolean")
+		assert.ErrorContains(t, records[4].Err, "channels")
+	})
+
+	t.Run("sensors, default is_active", func(t *testing.T) {
+		src, err := NewReader(strings.NewReader("\ufeffType,Serial_Number,description\ncc,1234567890,door\nadc,0987654321,\"temp, kitchen\"\n"),
+			FormatCSV, domain.ImportKindSensors)
+		require.NoError(t, err)
+
+		records := readAll(t, src)
+		require.Len(t, records, 2)
+		assert.Equal(t, &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeContactClosure, Description: "door", IsActive: true}, records[0].Sensor)
+		assert.Equal(t, "temp, kitchen", records[1].Sensor.Description)
+		assert.Equal(t, int64(2), records[1].Row)
+	})
+
+	t.Run("err, missing column", func(t *testing.T) {
+		src, err := NewReader(strings.NewReader("serial_number,payload\n1234567890,1\n"), FormatCSV, domain.ImportKindEvents)
+		require.NoError(t, err)
+
+		_, err = src.Next()
+		assert.ErrorIs(t, err, ErrMissingColumn)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		src, err := NewReader(strings.NewReader(""), FormatCSV, domain.ImportKindEvents)
+		require.NoError(t, err)
+		assert.Empty(t, readAll(t, src))
+	})
+}
+
+func TestNDJSONReader(t *testing.T) {
+	src, err := NewReader(strings.NewReader(strings.Join([]string{
+		`{"sensor_id":1,"sensor_serial_number":"1234567890","timestamp":"2024-03-01T12:00:00Z","payload":42}`,
+		``,
+		`{"serial_number":"0987654321","timestamp":"2024-03-01T12:00:00.5+03:00","payload":-1}`,
+		`{"serial_number":"0987654321","timestamp":"2024-03-01T12:00:00Z"}`,
+		`{"serial_number":`,
+	}, "\n")), FormatNDJSON, domain.ImportKindEvents)
+	require.NoError(t, err)
+
+	records := readAll(t, src)
+	require.Len(t, records, 4)
+
+	assert.Equal(t, "1234567890", records[0].Event.SensorSerialNumber)
+	assert.Equal(t, int64(42), records[0].Event.Payload)
+	assert.Equal(t, int64(2), records[1].Row)
+	assert.Equal(t, "0987654321", records[1].Event.SensorSerialNumber)
+	assert.Equal(t, int64(-1), records[1].Event.Payload)
+	assert.ErrorContains(t, records[2].Err, "payload is required")
+	assert.ErrorIs(t, records[3].Err, ErrInvalidRow)
+
+	src, err = NewReader(strings.NewReader(strings.Join([]string{
+		`{"serial_number":"1234567890","timestamp":"2024-03-01T12:00:00Z","payload":false}`,
+		`{"serial_number":"1234567890","timestamp":"2024-03-01T12:00:00Z","payload":{"temperature":21.5}}`,
+		`{"serial_number":"1234567890","timestamp":"2024-03-01T12:00:00Z","payload":22,"channels":{"value":21.5}}`,
+		`{"serial_number":"1234567890","timestamp":"2024-03-01T12:00:00Z","payload":"42"}`,
+	}, "\n")), FormatNDJSON, domain.ImportKindEvents)
+	require.NoError(t, err)
+
+	records = readAll(t, src)
+	require.Len(t, records, 4)
+	assert.Equal(t, map[string]domain.Value{"value": domain.BoolValue(false)}, records[0].Event.Channels)
+	assert.Equal(t, map[string]domain.Value{"temperature": domain.FloatValue(21.5)}, records[1].Event.Channels)
+	assert.Equal(t, int64(22), records[1].Event.Payload)
+	assert.Equal(t, map[string]domain.Value{"value": domain.FloatValue(21.5)}, records[2].Event.Channels)
+	assert.ErrorIs(t, records[3].Err, ErrInvalidRow)
+
+	src, err = NewReader(strings.NewReader(`{"serial_number":"1234567890","type":"adc","is_active":false}`),
+		FormatNDJSON, domain.ImportKindSensors)
+	require.NoError(t, err)
+	records = readAll(t, src)
+	require.Len(t, records, 1)
+	assert.Equal(t, &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC}, records[0].Sensor)
+
+	_, err = NewReader(strings.NewReader(""), FormatNDJSON, "users")
+	assert.ErrorIs(t, err, usecase.ErrWrongImportKind)
+}
@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"io"
+)
+
+const maxLineSize = 1 << 20
+
+type sensorRecord struct {
+	SerialNumber string `json:"serial_number"`
+	Type         string `json:"type"`
+	Description  string `json:"description"`
+	IsActive     *bool  `json:"is_active"`
+}
+
+type eventRecord struct {
+	SensorSerialNumber string          `json:"sensor_serial_number"`
+	SerialNumber       string          `json:"serial_number"`
+	Timestamp          string          `json:"timestamp"`
+	Payload            json.RawMessage `json:"payload"`
+	Channels           json.RawMessage `json:"channels"`
+}
+
+// ndjsonReader - по объекту на строку, пустые строки пропускаются и не нумеруются
+type ndjsonReader struct {
+	s    *bufio.Scanner
+	kind domain.ImportKind
+	row  int64
+}
+
+func newNDJSONReader(r io.Reader, kind domain.ImportKind) *ndjsonReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 4096), maxLineSize)
+
+	return &ndjsonReader{s: s, kind: kind}
+}
+
+func (r *ndjsonReader) Next() (usecase.ImportRecord, error) {
+	for r.s.Scan() {
+		line := bytes.TrimSpace(r.s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		r.row++
+		out := usecase.ImportRecord{Row: r.row}
+		switch r.kind {
+		case domain.ImportKindSensors:
+			out.Sensor, out.Err = parseSensor(line)
+		case domain.ImportKindEvents:
+			out.Event, out.Err = parseEvent(line)
+		}
+
+		return out, nil
+	}
+
+	if err := r.s.Err(); err != nil {
+		return usecase.ImportRecord{}, err
+	}
+	return usecase.ImportRecord{}, io.EOF
+}
+
+func parseSensor(line []byte) (*domain.Sensor, error) {
+	var record sensorRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, invalidRow("%s", err)
+	}
+
+	sensor := &domain.Sensor{
+		SerialNumber: record.SerialNumber,
+		Type:         domain.SensorType(record.Type),
+		Description:  record.Description,
+		IsActive:     true,
+	}
+	if record.IsActive != nil {
+		sensor.IsActive = *record.IsActive
+	}
+
+	return sensor, nil
+}
+
+func parseEvent(line []byte) (*domain.Event, error) {
+	var record eventRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, invalidRow("%s", err)
+	}
+
+	timestamp, err := parseTimestamp(record.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &domain.Event{
+		SensorSerialNumber: record.SensorSerialNumber,
+		Timestamp:          timestamp,
+	}
+	if event.SensorSerialNumber == "" {
+		event.SensorSerialNumber = record.SerialNumber
+	}
+
+	// payload может быть и объектом с каналами, как в POST /events
+	payload, channels := bytes.TrimSpace(record.Payload), record.Channels
+	if bytes.Equal(payload, []byte("null")) {
+		payload = nil
+	}
+	if bytes.Equal(bytes.TrimSpace(channels), []byte("null")) {
+		channels = nil
+	}
+	if len(channels) == 0 && len(payload) > 0 && payload[0] == '{' {
+		payload, channels = nil, payload
+	}
+	if err := setEventValue(event, string(payload), channels); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,33 @@
+package logging
+
+import "log/slog"
+
+const (
+	RequestIDKey    = "request_id"
+	TraceIDKey      = "trace_id"
+	SpanIDKey       = "span_id"
+	SensorIDKey     = "sensor_id"
+	SerialNumberKey = "serial_number"
+	UserIDKey       = "user_id"
+	ErrorKey        = "error"
+)
+
+func RequestID(id string) slog.Attr {
+	return slog.String(RequestIDKey, id)
+}
+
+func SensorID(id int64) slog.Attr {
+	return slog.Int64(SensorIDKey, id)
+}
+
+func SerialNumber(sn string) slog.Attr {
+	return slog.String(SerialNumberKey, sn)
+}
+
+func UserID(id int64) slog.Attr {
+	return slog.Int64(UserIDKey, id)
+}
+
+func Error(err error) slog.Attr {
+	return slog.Any(ErrorKey, err)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("err, unknown format", func(t *testing.T) {
+		_, err := New(&bytes.Buffer{}, Config{Format: "xml"})
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+	t.Run("err, unknown level", func(t *testing.T) {
+		_, err := New(&bytes.Buffer{}, Config{Level: "verbose"})
+		assert.ErrorIs(t, err, ErrUnknownLevel)
+	})
+
+	t.Run("ok, level filter", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger, err := New(buf, Config{Level: "warn", Format: FormatText})
+		require.NoError(t, err)
+
+		logger.Info("skipped")
+		assert.Empty(t, buf.String())
+
+		logger.Warn("written", SensorID(1))
+		assert.Contains(t, buf.String(), "msg=written")
+		assert.Contains(t, buf.String(), "sensor_id=1")
+	})
+
+	t.Run("ok, trace id from context", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger, err := New(buf, Config{Format: FormatJSON})
+		require.NoError(t, err)
+
+		ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "test")
+		defer span.End()
+
+		logger.InfoContext(ctx, "traced", UserID(2))
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, span.SpanContext().TraceID().String(), record[TraceIDKey])
+		assert.Equal(t, span.SpanContext().SpanID().String(), record[SpanIDKey])
+		assert.Equal(t, float64(2), record[UserIDKey])
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("ok, default logger", func(t *testing.T) {
+		assert.Equal(t, slog.Default(), FromContext(context.Background()))
+	})
+
+	t.Run("ok, logger from context", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+		ctx := WithContext(context.Background(), logger)
+		assert.Equal(t, logger, FromContext(ctx))
+	})
+}
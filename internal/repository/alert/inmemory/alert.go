@@ -0,0 +1,284 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+	"time"
+)
+
+type AlertRepository struct {
+	mu          sync.Mutex
+	lastRuleID  int64
+	lastAlertID int64
+	revision    int64
+	rules       map[int64]domain.AlertRule
+	alerts      map[int64]domain.Alert
+}
+
+func NewAlertRepository() *AlertRepository {
+	return &AlertRepository{
+		rules:  make(map[int64]domain.AlertRule),
+		alerts: make(map[int64]domain.Alert),
+	}
+}
+
+func (r *AlertRepository) SaveAlertRule(ctx context.Context, rule *domain.AlertRule) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if rule == nil {
+		return errors.New("alert rule is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rule.ID == 0 {
+		r.lastRuleID++
+		rule.ID = r.lastRuleID
+	} else if _, ok := r.rules[rule.ID]; !ok {
+		return usecase.ErrAlertRuleNotFound
+	}
+	r.rules[rule.ID] = *rule
+
+	return nil
+}
+
+func (r *AlertRepository) GetAlertRuleByID(ctx context.Context, id int64) (*domain.AlertRule, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.rules[id]
+	if !ok {
+		return nil, usecase.ErrAlertRuleNotFound
+	}
+	return &rule, nil
+}
+
+func (r *AlertRepository) GetAlertRules(ctx context.Context, sensorID int64) ([]domain.AlertRule, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := make([]domain.AlertRule, 0)
+	for _, rule := range r.rules {
+		if sensorID == 0 || rule.SensorID == sensorID {
+			rules = append(rules, rule)
+		}
+	}
+	slices.SortFunc(rules, func(a, b domain.AlertRule) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return rules, nil
+}
+
+func (r *AlertRepository) DeleteAlertRule(ctx context.Context, id int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[id]; !ok {
+		return usecase.ErrAlertRuleNotFound
+	}
+	delete(r.rules, id)
+
+	for alertID, alert := range r.alerts {
+		if alert.RuleID == id {
+			alert.RuleID = 0
+			r.alerts[alertID] = alert
+		}
+	}
+
+	return nil
+}
+
+// save - сохраняет тревогу с новой ревизией
+func (r *AlertRepository) save(alert *domain.Alert) {
+	r.revision++
+	alert.Revision = r.revision
+	r.alerts[alert.ID] = *alert
+}
+
+func (r *AlertRepository) RaiseAlert(ctx context.Context, alert *domain.Alert) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if alert == nil {
+		return errors.New("alert is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[alert.RuleID]; !ok {
+		return usecase.ErrAlertRuleNotFound
+	}
+
+	for _, stored := range r.alerts {
+		if stored.RuleID != alert.RuleID || !stored.Status.Active() {
+			continue
+		}
+		stored.Count++
+		stored.LastSeenAt = alert.LastSeenAt
+		stored.Message = alert.Message
+		stored.Value = alert.Value
+		r.save(&stored)
+		*alert = stored
+		return nil
+	}
+
+	r.lastAlertID++
+	alert.ID = r.lastAlertID
+	r.save(alert)
+
+	return nil
+}
+
+func (r *AlertRepository) GetAlertByID(ctx context.Context, id int64) (*domain.Alert, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alert, ok := r.alerts[id]
+	if !ok {
+		return nil, usecase.ErrAlertNotFound
+	}
+	return &alert, nil
+}
+
+func (r *AlertRepository) GetLastAlertByRuleID(ctx context.Context, ruleID int64) (*domain.Alert, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last *domain.Alert
+	for _, alert := range r.alerts {
+		if alert.RuleID == ruleID && (last == nil || alert.ID > last.ID) {
+			last = &alert
+		}
+	}
+	if last == nil {
+		return nil, usecase.ErrAlertNotFound
+	}
+	return last, nil
+}
+
+func (r *AlertRepository) GetAlerts(ctx context.Context, query domain.AlertQuery) ([]domain.Alert, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alerts := make([]domain.Alert, 0)
+	for _, alert := range r.alerts {
+		if len(query.SensorIDs) > 0 && !slices.Contains(query.SensorIDs, alert.SensorID) {
+			continue
+		}
+		if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, alert.Status) {
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	slices.SortFunc(alerts, func(a, b domain.Alert) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+	if query.Limit > 0 && len(alerts) > query.Limit {
+		alerts = alerts[:query.Limit]
+	}
+
+	return alerts, nil
+}
+
+func (r *AlertRepository) GetAlertChanges(ctx context.Context, sensorIDs []int64, after int64, limit int) ([]domain.Alert, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alerts := make([]domain.Alert, 0)
+	for _, alert := range r.alerts {
+		if alert.Revision > after && slices.Contains(sensorIDs, alert.SensorID) {
+			alerts = append(alerts, alert)
+		}
+	}
+	slices.SortFunc(alerts, func(a, b domain.Alert) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+
+	return alerts, nil
+}
+
+func (r *AlertRepository) GetAlertRevision(ctx context.Context) (int64, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.revision, nil
+}
+
+func (r *AlertRepository) UpdateAlertStatus(
+	ctx context.Context,
+	id int64,
+	from []domain.AlertStatus,
+	to domain.AlertStatus,
+	userID int64,
+	at time.Time,
+) (*domain.Alert, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alert, ok := r.alerts[id]
+	if !ok {
+		return nil, usecase.ErrAlertNotFound
+	}
+	if !slices.Contains(from, alert.Status) {
+		return nil, usecase.ErrAlertStatusConflict
+	}
+
+	alert.Status = to
+	switch to {
+	case domain.AlertAcknowledged:
+		alert.AcknowledgedBy, alert.AcknowledgedAt = userID, at
+	case domain.AlertResolved:
+		alert.ResolvedBy, alert.ResolvedAt = userID, at
+	}
+	r.save(&alert)
+
+	return &alert, nil
+}
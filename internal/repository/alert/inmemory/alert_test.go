@@ -0,0 +1,140 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRepository(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	newRule := func(sensorID int64) *domain.AlertRule {
+		return &domain.AlertRule{
+			SensorID:  sensorID,
+			Name:      "leak",
+			Kind:      domain.AlertRuleThreshold,
+			Channel:   domain.DefaultChannel,
+			Operator:  domain.AlertOperatorGTE,
+			Threshold: 1,
+		}
+	}
+
+	newAlert := func(rule *domain.AlertRule, at time.Time) *domain.Alert {
+		return &domain.Alert{
+			RuleID:     rule.ID,
+			SensorID:   rule.SensorID,
+			Kind:       rule.Kind,
+			Status:     domain.AlertOpen,
+			Message:    "leak",
+			Count:      1,
+			OpenedAt:   at,
+			LastSeenAt: at,
+		}
+	}
+
+	t.Run("ok, rules", func(t *testing.T) {
+		r := NewAlertRepository()
+		ctx := context.Background()
+
+		first, second := newRule(1), newRule(2)
+		require.NoError(t, r.SaveAlertRule(ctx, first))
+		require.NoError(t, r.SaveAlertRule(ctx, second))
+
+		rules, err := r.GetAlertRules(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.AlertRule{*second}, rules)
+
+		rules, err = r.GetAlertRules(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.AlertRule{*first, *second}, rules)
+
+		require.NoError(t, r.DeleteAlertRule(ctx, first.ID))
+		_, err = r.GetAlertRuleByID(ctx, first.ID)
+		assert.ErrorIs(t, err, usecase.ErrAlertRuleNotFound)
+		assert.ErrorIs(t, r.SaveAlertRule(ctx, first), usecase.ErrAlertRuleNotFound)
+	})
+
+	t.Run("ok, repeats are deduplicated until resolved", func(t *testing.T) {
+		r := NewAlertRepository()
+		ctx := context.Background()
+
+		rule := newRule(1)
+		require.NoError(t, r.SaveAlertRule(ctx, rule))
+
+		first := newAlert(rule, now)
+		require.NoError(t, r.RaiseAlert(ctx, first))
+
+		repeat := newAlert(rule, now.Add(time.Minute))
+		require.NoError(t, r.RaiseAlert(ctx, repeat))
+		assert.Equal(t, first.ID, repeat.ID)
+		assert.Equal(t, int64(2), repeat.Count)
+		assert.Equal(t, now, repeat.OpenedAt)
+		assert.Equal(t, now.Add(time.Minute), repeat.LastSeenAt)
+		assert.Greater(t, repeat.Revision, first.Revision)
+
+		acked, err := r.UpdateAlertStatus(ctx, first.ID, []domain.AlertStatus{domain.AlertOpen}, domain.AlertAcknowledged, 7, now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), acked.AcknowledgedBy)
+
+		_, err = r.UpdateAlertStatus(ctx, first.ID, []domain.AlertStatus{domain.AlertOpen}, domain.AlertAcknowledged, 7, now)
+		assert.ErrorIs(t, err, usecase.ErrAlertStatusConflict)
+
+		resolved, err := r.UpdateAlertStatus(ctx, first.ID,
+			[]domain.AlertStatus{domain.AlertOpen, domain.AlertAcknowledged}, domain.AlertResolved, 8, now)
+		require.NoError(t, err)
+		assert.Equal(t, domain.AlertResolved, resolved.Status)
+		assert.Equal(t, int64(8), resolved.ResolvedBy)
+
+		next := newAlert(rule, now.Add(time.Hour))
+		require.NoError(t, r.RaiseAlert(ctx, next))
+		assert.NotEqual(t, first.ID, next.ID)
+		assert.Equal(t, int64(1), next.Count)
+
+		last, err := r.GetLastAlertByRuleID(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, next, last)
+
+		open, err := r.GetAlerts(ctx, domain.AlertQuery{Statuses: []domain.AlertStatus{domain.AlertOpen}})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Alert{*next}, open)
+	})
+
+	t.Run("ok, changes", func(t *testing.T) {
+		r := NewAlertRepository()
+		ctx := context.Background()
+
+		first, second := newRule(1), newRule(2)
+		require.NoError(t, r.SaveAlertRule(ctx, first))
+		require.NoError(t, r.SaveAlertRule(ctx, second))
+
+		revision, err := r.GetAlertRevision(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, revision)
+
+		a, b := newAlert(first, now), newAlert(second, now)
+		require.NoError(t, r.RaiseAlert(ctx, a))
+		require.NoError(t, r.RaiseAlert(ctx, b))
+		require.NoError(t, r.RaiseAlert(ctx, newAlert(first, now)))
+
+		changes, err := r.GetAlertChanges(ctx, []int64{1}, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, int64(3), changes[0].Revision)
+
+		changes, err = r.GetAlertChanges(ctx, []int64{1, 2}, 0, 1)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, b.ID, changes[0].ID)
+
+		require.NoError(t, r.DeleteAlertRule(ctx, first.ID))
+		stored, err := r.GetAlertByID(ctx, a.ID)
+		require.NoError(t, err)
+		assert.Zero(t, stored.RuleID)
+	})
+}
@@ -0,0 +1,436 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+type AlertRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAlertRepository(pool *pgxpool.Pool) *AlertRepository {
+	return &AlertRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/alert/postgres")
+
+// foreignKeyViolation - код ошибки postgres при ссылке на несуществующую запись
+const foreignKeyViolation = "23503"
+
+const (
+	ruleColumns     = `id, sensor_id, name, kind, channel, operator, threshold, stale_after_ms, created_at, updated_at`
+	insertRuleQuery = `INSERT INTO alert_rules (sensor_id, name, kind, channel, operator, threshold, stale_after_ms, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id;`
+	updateRuleQuery = `UPDATE alert_rules SET sensor_id = $2, name = $3, kind = $4, channel = $5, operator = $6,
+threshold = $7, stale_after_ms = $8, updated_at = $9 WHERE id = $1;`
+	getRuleQuery          = `SELECT ` + ruleColumns + ` FROM alert_rules WHERE id = $1;`
+	getRulesQuery         = `SELECT ` + ruleColumns + ` FROM alert_rules ORDER BY id;`
+	getSensorRulesQuery   = `SELECT ` + ruleColumns + ` FROM alert_rules WHERE sensor_id = $1 ORDER BY id;`
+	deleteRuleQuery       = `DELETE FROM alert_rules WHERE id = $1;`
+	alertColumns          = `id, rule_id, sensor_id, kind, status, message, value, count, opened_at, last_seen_at, acknowledged_by, acknowledged_at, resolved_by, resolved_at, revision`
+	getAlertQuery         = `SELECT ` + alertColumns + ` FROM alerts WHERE id = $1;`
+	getLastRuleAlertQuery = `SELECT ` + alertColumns + ` FROM alerts WHERE rule_id = $1 ORDER BY id DESC LIMIT 1;`
+	// raiseAlertQuery - незакрытая тревога правила может быть только одна, поэтому при конфликте
+	// с ней повторное срабатывание засчитывается ей
+	raiseAlertQuery = `INSERT INTO alerts (rule_id, sensor_id, kind, status, message, value, count, opened_at, last_seen_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (rule_id) WHERE status <> 'resolved' DO UPDATE SET count = alerts.count + 1,
+last_seen_at = EXCLUDED.last_seen_at, message = EXCLUDED.message, value = EXCLUDED.value,
+revision = nextval('alerts_revision_seq')
+RETURNING ` + alertColumns + `;`
+	getAlertsQuery = `SELECT ` + alertColumns + ` FROM alerts
+WHERE (cardinality($1::bigint[]) = 0 OR sensor_id = ANY($1)) AND (cardinality($2::text[]) = 0 OR status = ANY($2))
+ORDER BY id DESC LIMIT NULLIF($3, 0);`
+	getAlertChangesQuery = `SELECT ` + alertColumns + ` FROM alerts
+WHERE sensor_id = ANY($1) AND revision > $2 ORDER BY revision LIMIT $3;`
+	getAlertRevisionQuery  = `SELECT COALESCE(max(revision), 0) FROM alerts;`
+	updateAlertStatusQuery = `UPDATE alerts SET status = $3,
+acknowledged_by = COALESCE($4, acknowledged_by), acknowledged_at = COALESCE($5, acknowledged_at),
+resolved_by = COALESCE($6, resolved_by), resolved_at = COALESCE($7, resolved_at),
+revision = nextval('alerts_revision_seq')
+WHERE id = $1 AND status = ANY($2)
+RETURNING ` + alertColumns + `;`
+)
+
+func (r *AlertRepository) SaveAlertRule(ctx context.Context, rule *domain.AlertRule) (err error) {
+	query := updateRuleQuery
+	if rule != nil && rule.ID == 0 {
+		query = insertRuleQuery
+	}
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.SaveAlertRule", query)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if rule == nil {
+		return errors.New("alert rule is nil")
+	}
+
+	if rule.ID == 0 {
+		err = r.pool.QueryRow(ctx, insertRuleQuery,
+			rule.SensorID,
+			rule.Name,
+			rule.Kind,
+			rule.Channel,
+			rule.Operator,
+			rule.Threshold,
+			rule.StaleAfter.Milliseconds(),
+			rule.CreatedAt,
+			rule.UpdatedAt,
+		).Scan(&rule.ID)
+		if err != nil {
+			return fmt.Errorf("can't insert alert rule: %w", err)
+		}
+		return nil
+	}
+
+	tag, err := r.pool.Exec(ctx, updateRuleQuery,
+		rule.ID,
+		rule.SensorID,
+		rule.Name,
+		rule.Kind,
+		rule.Channel,
+		rule.Operator,
+		rule.Threshold,
+		rule.StaleAfter.Milliseconds(),
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("can't update alert rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+func (r *AlertRepository) GetAlertRuleByID(ctx context.Context, id int64) (_ *domain.AlertRule, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.GetAlertRuleByID", getRuleQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var rule domain.AlertRule
+	err = scanRule(r.pool.QueryRow(ctx, getRuleQuery, id), &rule)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrAlertRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get alert rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func (r *AlertRepository) GetAlertRules(ctx context.Context, sensorID int64) (_ []domain.AlertRule, err error) {
+	query, args := getRulesQuery, []any{}
+	if sensorID != 0 {
+		query, args = getSensorRulesQuery, []any{sensorID}
+	}
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.GetAlertRules", query)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't get alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]domain.AlertRule, 0)
+	for rows.Next() {
+		var rule domain.AlertRule
+		if err := scanRule(rows, &rule); err != nil {
+			return nil, fmt.Errorf("can't scan alert rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *AlertRepository) DeleteAlertRule(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.DeleteAlertRule", deleteRuleQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	tag, err := r.pool.Exec(ctx, deleteRuleQuery, id)
+	if err != nil {
+		return fmt.Errorf("can't delete alert rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrAlertRuleNotFound
+	}
+	return nil
+}
+
+func (r *AlertRepository) RaiseAlert(ctx context.Context, alert *domain.Alert) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.RaiseAlert", raiseAlertQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if alert == nil {
+		return errors.New("alert is nil")
+	}
+
+	err = scanAlert(r.pool.QueryRow(ctx, raiseAlertQuery,
+		alert.RuleID,
+		alert.SensorID,
+		alert.Kind,
+		alert.Status,
+		alert.Message,
+		alert.Value,
+		alert.Count,
+		alert.OpenedAt,
+		alert.LastSeenAt,
+	), alert)
+	if isViolation(err, foreignKeyViolation) {
+		return usecase.ErrAlertRuleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("can't raise alert: %w", err)
+	}
+	return nil
+}
+
+func (r *AlertRepository) GetAlertByID(ctx context.Context, id int64) (_ *domain.Alert, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.GetAlertByID", getAlertQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.get(ctx, getAlertQuery, id)
+}
+
+func (r *AlertRepository) GetLastAlertByRuleID(ctx context.Context, ruleID int64) (_ *domain.Alert, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.GetLastAlertByRuleID", getLastRuleAlertQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.get(ctx, getLastRuleAlertQuery, ruleID)
+}
+
+func (r *AlertRepository) get(ctx context.Context, query string, args ...any) (*domain.Alert, error) {
+	var alert domain.Alert
+	err := scanAlert(r.pool.QueryRow(ctx, query, args...), &alert)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrAlertNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get alert: %w", err)
+	}
+	return &alert, nil
+}
+
+func (r *AlertRepository) GetAlerts(ctx context.Context, query domain.AlertQuery) (_ []domain.Alert, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.GetAlerts", getAlertsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	sensorIDs := query.SensorIDs
+	if sensorIDs == nil {
+		sensorIDs = []int64{}
+	}
+
+	return r.query(ctx, getAlertsQuery, sensorIDs, statusStrings(query.Statuses), query.Limit)
+}
+
+func (r *AlertRepository) GetAlertChanges(ctx context.Context, sensorIDs []int64, after int64, limit int) (_ []domain.Alert, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.GetAlertChanges", getAlertChangesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.query(ctx, getAlertChangesQuery, sensorIDs, after, limit)
+}
+
+func (r *AlertRepository) query(ctx context.Context, query string, args ...any) ([]domain.Alert, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't get alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make([]domain.Alert, 0)
+	for rows.Next() {
+		var alert domain.Alert
+		if err := scanAlert(rows, &alert); err != nil {
+			return nil, fmt.Errorf("can't scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+func (r *AlertRepository) GetAlertRevision(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.GetAlertRevision", getAlertRevisionQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	var revision int64
+	if err := r.pool.QueryRow(ctx, getAlertRevisionQuery).Scan(&revision); err != nil {
+		return 0, fmt.Errorf("can't get alert revision: %w", err)
+	}
+	return revision, nil
+}
+
+func (r *AlertRepository) UpdateAlertStatus(
+	ctx context.Context,
+	id int64,
+	from []domain.AlertStatus,
+	to domain.AlertStatus,
+	userID int64,
+	at time.Time,
+) (_ *domain.Alert, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "AlertRepository.UpdateAlertStatus", updateAlertStatusQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var (
+		acknowledgedBy, resolvedBy *int64
+		acknowledgedAt, resolvedAt *time.Time
+	)
+	switch to {
+	case domain.AlertAcknowledged:
+		acknowledgedBy, acknowledgedAt = &userID, &at
+	case domain.AlertResolved:
+		resolvedBy, resolvedAt = &userID, &at
+	}
+
+	var alert domain.Alert
+	err = scanAlert(r.pool.QueryRow(ctx, updateAlertStatusQuery,
+		id, statusStrings(from), to, acknowledgedBy, acknowledgedAt, resolvedBy, resolvedAt,
+	), &alert)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// тревоги нет или она не в одном из состояний from
+		if _, err := r.get(ctx, getAlertQuery, id); err != nil {
+			return nil, err
+		}
+		return nil, usecase.ErrAlertStatusConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't update alert status: %w", err)
+	}
+	return &alert, nil
+}
+
+func scanRule(row pgx.Row, rule *domain.AlertRule) error {
+	var staleAfterMs int64
+	err := row.Scan(
+		&rule.ID,
+		&rule.SensorID,
+		&rule.Name,
+		&rule.Kind,
+		&rule.Channel,
+		&rule.Operator,
+		&rule.Threshold,
+		&staleAfterMs,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	rule.StaleAfter = time.Duration(staleAfterMs) * time.Millisecond
+	return nil
+}
+
+func scanAlert(row pgx.Row, alert *domain.Alert) error {
+	var (
+		ruleID, acknowledgedBy, resolvedBy *int64
+		acknowledgedAt, resolvedAt         *time.Time
+	)
+	err := row.Scan(
+		&alert.ID,
+		&ruleID,
+		&alert.SensorID,
+		&alert.Kind,
+		&alert.Status,
+		&alert.Message,
+		&alert.Value,
+		&alert.Count,
+		&alert.OpenedAt,
+		&alert.LastSeenAt,
+		&acknowledgedBy,
+		&acknowledgedAt,
+		&resolvedBy,
+		&resolvedAt,
+		&alert.Revision,
+	)
+	if err != nil {
+		return err
+	}
+
+	alert.RuleID = deref(ruleID)
+	alert.AcknowledgedBy = deref(acknowledgedBy)
+	alert.ResolvedBy = deref(resolvedBy)
+	alert.AcknowledgedAt = deref(acknowledgedAt)
+	alert.ResolvedAt = deref(resolvedAt)
+	return nil
+}
+
+// deref - значение nullable колонки, NULL - нулевое значение
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+func statusStrings(statuses []domain.AlertStatus) []string {
+	out := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		out = append(out, string(status))
+	}
+	return out
+}
+
+func isViolation(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
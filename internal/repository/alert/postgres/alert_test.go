@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AlertTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *AlertRepository
+}
+
+func (suite *AlertTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewAlertRepository(suite.testDbInstance)
+}
+
+func (suite *AlertTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *AlertTestSuite) newRule(sensorID int64, now time.Time) *domain.AlertRule {
+	return &domain.AlertRule{
+		SensorID:  sensorID,
+		Name:      "leak",
+		Kind:      domain.AlertRuleThreshold,
+		Channel:   domain.DefaultChannel,
+		Operator:  domain.AlertOperatorGTE,
+		Threshold: 1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func newAlert(rule *domain.AlertRule, at time.Time) *domain.Alert {
+	value := 1.0
+	return &domain.Alert{
+		RuleID:     rule.ID,
+		SensorID:   rule.SensorID,
+		Kind:       rule.Kind,
+		Status:     domain.AlertOpen,
+		Message:    "leak",
+		Value:      &value,
+		Count:      1,
+		OpenedAt:   at,
+		LastSeenAt: at,
+	}
+}
+
+func (suite *AlertTestSuite) TestAlertRepository_Rules() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetAlertRuleByID(ctx, 1000)
+	assert.ErrorIs(suite.T(), err, usecase.ErrAlertRuleNotFound)
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	rule := suite.newRule(101, now)
+	require.NoError(suite.T(), suite.repo.SaveAlertRule(ctx, rule))
+	assert.NotZero(suite.T(), rule.ID)
+
+	stale := &domain.AlertRule{
+		SensorID:   101,
+		Name:       "silent",
+		Kind:       domain.AlertRuleStale,
+		StaleAfter: 2 * time.Hour,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(suite.T(), suite.repo.SaveAlertRule(ctx, stale))
+
+	rule.Threshold = 2
+	require.NoError(suite.T(), suite.repo.SaveAlertRule(ctx, rule))
+
+	rules, err := suite.repo.GetAlertRules(ctx, 101)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.AlertRule{*rule, *stale}, rules)
+
+	require.NoError(suite.T(), suite.repo.DeleteAlertRule(ctx, stale.ID))
+	assert.ErrorIs(suite.T(), suite.repo.DeleteAlertRule(ctx, stale.ID), usecase.ErrAlertRuleNotFound)
+}
+
+func (suite *AlertTestSuite) TestAlertRepository_Lifecycle() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	rule := suite.newRule(102, now)
+	require.NoError(suite.T(), suite.repo.SaveAlertRule(ctx, rule))
+
+	first := newAlert(rule, now)
+	require.NoError(suite.T(), suite.repo.RaiseAlert(ctx, first))
+
+	repeat := newAlert(rule, now.Add(time.Minute))
+	require.NoError(suite.T(), suite.repo.RaiseAlert(ctx, repeat))
+	assert.Equal(suite.T(), first.ID, repeat.ID)
+	assert.Equal(suite.T(), int64(2), repeat.Count)
+	assert.Equal(suite.T(), now, repeat.OpenedAt)
+	assert.Greater(suite.T(), repeat.Revision, first.Revision)
+
+	acked, err := suite.repo.UpdateAlertStatus(ctx, first.ID,
+		[]domain.AlertStatus{domain.AlertOpen}, domain.AlertAcknowledged, 7, now)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(7), acked.AcknowledgedBy)
+	assert.Equal(suite.T(), now, acked.AcknowledgedAt)
+
+	_, err = suite.repo.UpdateAlertStatus(ctx, first.ID,
+		[]domain.AlertStatus{domain.AlertOpen}, domain.AlertAcknowledged, 7, now)
+	assert.ErrorIs(suite.T(), err, usecase.ErrAlertStatusConflict)
+
+	_, err = suite.repo.UpdateAlertStatus(ctx, 100000,
+		[]domain.AlertStatus{domain.AlertOpen}, domain.AlertAcknowledged, 7, now)
+	assert.ErrorIs(suite.T(), err, usecase.ErrAlertNotFound)
+
+	resolved, err := suite.repo.UpdateAlertStatus(ctx, first.ID,
+		[]domain.AlertStatus{domain.AlertOpen, domain.AlertAcknowledged}, domain.AlertResolved, 8, now)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), domain.AlertResolved, resolved.Status)
+	assert.Equal(suite.T(), int64(7), resolved.AcknowledgedBy)
+	assert.Equal(suite.T(), int64(8), resolved.ResolvedBy)
+
+	next := newAlert(rule, now.Add(time.Hour))
+	require.NoError(suite.T(), suite.repo.RaiseAlert(ctx, next))
+	assert.NotEqual(suite.T(), first.ID, next.ID)
+
+	last, err := suite.repo.GetLastAlertByRuleID(ctx, rule.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), next, last)
+
+	open, err := suite.repo.GetAlerts(ctx, domain.AlertQuery{
+		SensorIDs: []int64{102},
+		Statuses:  []domain.AlertStatus{domain.AlertOpen},
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Alert{*next}, open)
+
+	all, err := suite.repo.GetAlerts(ctx, domain.AlertQuery{SensorIDs: []int64{102}, Limit: 1})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Alert{*next}, all)
+
+	changes, err := suite.repo.GetAlertChanges(ctx, []int64{102}, resolved.Revision, 10)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Alert{*next}, changes)
+
+	revision, err := suite.repo.GetAlertRevision(ctx)
+	require.NoError(suite.T(), err)
+	assert.GreaterOrEqual(suite.T(), revision, next.Revision)
+
+	require.NoError(suite.T(), suite.repo.DeleteAlertRule(ctx, rule.ID))
+	orphan, err := suite.repo.GetAlertByID(ctx, next.ID)
+	require.NoError(suite.T(), err)
+	assert.Zero(suite.T(), orphan.RuleID)
+
+	assert.ErrorIs(suite.T(), suite.repo.RaiseAlert(ctx, newAlert(rule, now)), usecase.ErrAlertRuleNotFound)
+}
+
+func TestAlertTestSuite(t *testing.T) {
+	suite.Run(t, new(AlertTestSuite))
+}
@@ -0,0 +1,155 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+	"time"
+)
+
+type CommandRepository struct {
+	mu       sync.Mutex
+	lastID   int64
+	commands map[int64]domain.Command
+}
+
+func NewCommandRepository() *CommandRepository {
+	return &CommandRepository{
+		commands: make(map[int64]domain.Command),
+	}
+}
+
+func (r *CommandRepository) SaveCommand(ctx context.Context, command *domain.Command) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if command == nil {
+		return errors.New("command is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if command.ID == 0 {
+		r.lastID++
+		command.ID = r.lastID
+	}
+	r.commands[command.ID] = *command
+
+	return nil
+}
+
+func (r *CommandRepository) GetCommandByID(ctx context.Context, id int64) (*domain.Command, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	command, ok := r.commands[id]
+	if !ok {
+		return nil, usecase.ErrCommandNotFound
+	}
+	return &command, nil
+}
+
+func (r *CommandRepository) GetCommands(ctx context.Context, sensorID int64, statuses []domain.CommandStatus) ([]domain.Command, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.update(sensorID, func(c *domain.Command) bool {
+		return len(statuses) == 0 || slices.Contains(statuses, c.Status)
+	}), nil
+}
+
+func (r *CommandRepository) DeliverCommands(ctx context.Context, sensorID int64, now time.Time) ([]domain.Command, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.update(sensorID, func(c *domain.Command) bool {
+		if c.Status != domain.CommandPending || !c.ExpiresAt.After(now) {
+			return false
+		}
+		c.Status = domain.CommandDelivered
+		c.DeliveredAt = now
+		return true
+	}), nil
+}
+
+func (r *CommandRepository) DeliverCommand(ctx context.Context, sensorID, id int64, now time.Time) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	command, ok := r.commands[id]
+	if !ok || command.SensorID != sensorID || command.Status != domain.CommandPending || !command.ExpiresAt.After(now) {
+		return false, nil
+	}
+	command.Status = domain.CommandDelivered
+	command.DeliveredAt = now
+	r.commands[id] = command
+
+	return true, nil
+}
+
+func (r *CommandRepository) ConfirmCommands(ctx context.Context, sensorID int64, state int64, now time.Time) ([]domain.Command, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.update(sensorID, func(c *domain.Command) bool {
+		if !c.Active() || c.State != state || !c.ExpiresAt.After(now) {
+			return false
+		}
+		c.Status = domain.CommandConfirmed
+		c.ConfirmedAt = now
+		return true
+	}), nil
+}
+
+func (r *CommandRepository) ExpireCommands(ctx context.Context, now time.Time) (int64, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	expired := r.update(0, func(c *domain.Command) bool {
+		if !c.Active() || c.ExpiresAt.After(now) {
+			return false
+		}
+		c.Status = domain.CommandExpired
+		return true
+	})
+	return int64(len(expired)), nil
+}
+
+// update - применяет fn к командам датчика sensorID (0 - всех датчиков) и возвращает
+// по возрастанию ID те, для которых fn вернула true
+func (r *CommandRepository) update(sensorID int64, fn func(*domain.Command) bool) []domain.Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []domain.Command
+	for id, command := range r.commands {
+		if sensorID != 0 && command.SensorID != sensorID {
+			continue
+		}
+		if fn(&command) {
+			r.commands[id] = command
+			matched = append(matched, command)
+		}
+	}
+
+	slices.SortFunc(matched, func(a, b domain.Command) int {
+		return int(a.ID - b.ID)
+	})
+	return matched
+}
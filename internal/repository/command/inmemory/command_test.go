@@ -0,0 +1,144 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandRepository(t *testing.T) {
+	now := time.Now()
+
+	newCommand := func(sensorID, state int64) *domain.Command {
+		return &domain.Command{
+			SensorID:  sensorID,
+			Kind:      domain.CommandSetState,
+			State:     state,
+			Status:    domain.CommandPending,
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Minute),
+		}
+	}
+
+	t.Run("ok, save and get", func(t *testing.T) {
+		r := NewCommandRepository()
+		ctx := context.Background()
+
+		command := newCommand(1, 1)
+		require.NoError(t, r.SaveCommand(ctx, command))
+		assert.Equal(t, int64(1), command.ID)
+
+		stored, err := r.GetCommandByID(ctx, command.ID)
+		require.NoError(t, err)
+		assert.Equal(t, command, stored)
+
+		_, err = r.GetCommandByID(ctx, 2)
+		assert.ErrorIs(t, err, usecase.ErrCommandNotFound)
+	})
+
+	t.Run("ok, deliver, confirm and expire", func(t *testing.T) {
+		r := NewCommandRepository()
+		ctx := context.Background()
+
+		first, second, other := newCommand(1, 1), newCommand(1, 0), newCommand(2, 1)
+		require.NoError(t, r.SaveCommand(ctx, first))
+		require.NoError(t, r.SaveCommand(ctx, second))
+		require.NoError(t, r.SaveCommand(ctx, other))
+
+		delivered, err := r.DeliverCommands(ctx, 1, now)
+		require.NoError(t, err)
+		require.Len(t, delivered, 2)
+		assert.Equal(t, []int64{first.ID, second.ID}, []int64{delivered[0].ID, delivered[1].ID})
+		assert.Equal(t, domain.CommandDelivered, delivered[0].Status)
+		assert.Equal(t, now, delivered[0].DeliveredAt)
+
+		delivered, err = r.DeliverCommands(ctx, 1, now)
+		require.NoError(t, err)
+		assert.Empty(t, delivered)
+
+		confirmed, err := r.ConfirmCommands(ctx, 1, 1, now)
+		require.NoError(t, err)
+		require.Len(t, confirmed, 1)
+		assert.Equal(t, first.ID, confirmed[0].ID)
+		assert.Equal(t, domain.CommandConfirmed, confirmed[0].Status)
+
+		expired, err := r.ExpireCommands(ctx, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), expired)
+
+		commands, err := r.GetCommands(ctx, 1, []domain.CommandStatus{domain.CommandExpired})
+		require.NoError(t, err)
+		require.Len(t, commands, 1)
+		assert.Equal(t, second.ID, commands[0].ID)
+
+		commands, err = r.GetCommands(ctx, 1, nil)
+		require.NoError(t, err)
+		assert.Len(t, commands, 2)
+	})
+
+	t.Run("ok, expired command is not delivered", func(t *testing.T) {
+		r := NewCommandRepository()
+		ctx := context.Background()
+
+		require.NoError(t, r.SaveCommand(ctx, newCommand(1, 1)))
+
+		delivered, err := r.DeliverCommands(ctx, 1, now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, delivered)
+	})
+
+	t.Run("ok, deliver one command", func(t *testing.T) {
+		r := NewCommandRepository()
+		ctx := context.Background()
+
+		command := newCommand(1, 1)
+		require.NoError(t, r.SaveCommand(ctx, command))
+
+		for _, sensorID := range []int64{2, 1} {
+			delivered, err := r.DeliverCommand(ctx, sensorID, command.ID, now.Add(time.Hour))
+			require.NoError(t, err)
+			assert.False(t, delivered)
+		}
+
+		delivered, err := r.DeliverCommand(ctx, 1, command.ID, now)
+		require.NoError(t, err)
+		assert.True(t, delivered)
+
+		got, err := r.GetCommandByID(ctx, command.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.CommandDelivered, got.Status)
+		assert.Equal(t, now, got.DeliveredAt)
+
+		delivered, err = r.DeliverCommand(ctx, 1, command.ID, now)
+		require.NoError(t, err)
+		assert.False(t, delivered)
+	})
+
+	t.Run("err, nil", func(t *testing.T) {
+		r := NewCommandRepository()
+		assert.Error(t, r.SaveCommand(context.Background(), nil))
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		r := NewCommandRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, r.SaveCommand(ctx, newCommand(1, 1)), context.Canceled)
+		_, err := r.GetCommandByID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = r.GetCommands(ctx, 1, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = r.DeliverCommands(ctx, 1, now)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = r.ConfirmCommands(ctx, 1, 1, now)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = r.ExpireCommands(ctx, now)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
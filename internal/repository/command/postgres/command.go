@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+type CommandRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCommandRepository(pool *pgxpool.Pool) *CommandRepository {
+	return &CommandRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/command/postgres")
+
+const (
+	commandColumns     = `id, sensor_id, kind, state, duration_ms, status, created_at, expires_at, delivered_at, confirmed_at`
+	insertCommandQuery = `INSERT INTO commands (sensor_id, kind, state, duration_ms, status, created_at, expires_at, delivered_at, confirmed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id;`
+	updateCommandQuery = `UPDATE commands SET status = $2, expires_at = $3, delivered_at = $4, confirmed_at = $5 WHERE id = $1;`
+	getCommandQuery    = `SELECT ` + commandColumns + ` FROM commands WHERE id = $1;`
+	getCommandsQuery   = `SELECT ` + commandColumns + ` FROM commands
+WHERE sensor_id = $1 AND (cardinality($2::text[]) = 0 OR status = ANY($2)) ORDER BY id;`
+	deliverCommandsQuery = `WITH delivered AS (
+	UPDATE commands SET status = 'delivered', delivered_at = $2
+	WHERE sensor_id = $1 AND status = 'pending' AND expires_at > $2
+	RETURNING ` + commandColumns + `
+) SELECT ` + commandColumns + ` FROM delivered ORDER BY id;`
+	deliverCommandQuery = `UPDATE commands SET status = 'delivered', delivered_at = $3
+WHERE sensor_id = $1 AND id = $2 AND status = 'pending' AND expires_at > $3;`
+	confirmCommandsQuery = `WITH confirmed AS (
+	UPDATE commands SET status = 'confirmed', confirmed_at = $3
+	WHERE sensor_id = $1 AND status IN ('pending', 'delivered') AND state = $2 AND expires_at > $3
+	RETURNING ` + commandColumns + `
+) SELECT ` + commandColumns + ` FROM confirmed ORDER BY id;`
+	expireCommandsQuery = `UPDATE commands SET status = 'expired' WHERE status IN ('pending', 'delivered') AND expires_at <= $1;`
+)
+
+func (r *CommandRepository) SaveCommand(ctx context.Context, command *domain.Command) (err error) {
+	query := updateCommandQuery
+	if command != nil && command.ID == 0 {
+		query = insertCommandQuery
+	}
+	ctx, span := tracing.StartQuery(ctx, tracer, "CommandRepository.SaveCommand", query)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if command == nil {
+		return errors.New("command is nil")
+	}
+
+	if command.ID == 0 {
+		err = r.pool.QueryRow(ctx, insertCommandQuery,
+			command.SensorID,
+			command.Kind,
+			command.State,
+			command.Duration.Milliseconds(),
+			command.Status,
+			command.CreatedAt,
+			command.ExpiresAt,
+			nullTime(command.DeliveredAt),
+			nullTime(command.ConfirmedAt),
+		).Scan(&command.ID)
+		if err != nil {
+			return fmt.Errorf("can't insert command: %w", err)
+		}
+		return nil
+	}
+
+	tag, err := r.pool.Exec(ctx, updateCommandQuery,
+		command.ID,
+		command.Status,
+		command.ExpiresAt,
+		nullTime(command.DeliveredAt),
+		nullTime(command.ConfirmedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("can't update command: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrCommandNotFound
+	}
+	return nil
+}
+
+func (r *CommandRepository) GetCommandByID(ctx context.Context, id int64) (_ *domain.Command, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "CommandRepository.GetCommandByID", getCommandQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var command domain.Command
+	err = scanCommand(r.pool.QueryRow(ctx, getCommandQuery, id), &command)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrCommandNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get command: %w", err)
+	}
+	return &command, nil
+}
+
+func (r *CommandRepository) GetCommands(ctx context.Context, sensorID int64, statuses []domain.CommandStatus) (_ []domain.Command, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "CommandRepository.GetCommands", getCommandsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	filter := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		filter = append(filter, string(status))
+	}
+
+	return r.queryCommands(ctx, getCommandsQuery, sensorID, filter)
+}
+
+func (r *CommandRepository) DeliverCommands(ctx context.Context, sensorID int64, now time.Time) (_ []domain.Command, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "CommandRepository.DeliverCommands", deliverCommandsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.queryCommands(ctx, deliverCommandsQuery, sensorID, now)
+}
+
+func (r *CommandRepository) DeliverCommand(ctx context.Context, sensorID, id int64, now time.Time) (_ bool, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "CommandRepository.DeliverCommand", deliverCommandQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	tag, err := r.pool.Exec(ctx, deliverCommandQuery, sensorID, id, now)
+	if err != nil {
+		return false, fmt.Errorf("can't deliver command: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *CommandRepository) ConfirmCommands(ctx context.Context, sensorID int64, state int64, now time.Time) (_ []domain.Command, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "CommandRepository.ConfirmCommands", confirmCommandsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.queryCommands(ctx, confirmCommandsQuery, sensorID, state, now)
+}
+
+func (r *CommandRepository) ExpireCommands(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "CommandRepository.ExpireCommands", expireCommandsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	tag, err := r.pool.Exec(ctx, expireCommandsQuery, now)
+	if err != nil {
+		return 0, fmt.Errorf("can't expire commands: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *CommandRepository) queryCommands(ctx context.Context, query string, args ...any) ([]domain.Command, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't get commands: %w", err)
+	}
+	defer rows.Close()
+
+	var commands []domain.Command
+	for rows.Next() {
+		var command domain.Command
+		if err := scanCommand(rows, &command); err != nil {
+			return nil, fmt.Errorf("can't scan command: %w", err)
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, rows.Err()
+}
+
+func scanCommand(row pgx.Row, command *domain.Command) error {
+	var (
+		durationMs               int64
+		deliveredAt, confirmedAt *time.Time
+	)
+	err := row.Scan(
+		&command.ID,
+		&command.SensorID,
+		&command.Kind,
+		&command.State,
+		&durationMs,
+		&command.Status,
+		&command.CreatedAt,
+		&command.ExpiresAt,
+		&deliveredAt,
+		&confirmedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	command.Duration = time.Duration(durationMs) * time.Millisecond
+	if deliveredAt != nil {
+		command.DeliveredAt = *deliveredAt
+	}
+	if confirmedAt != nil {
+		command.ConfirmedAt = *confirmedAt
+	}
+	return nil
+}
+
+// nullTime - нулевое время сохраняется как NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *CommandRepository
+}
+
+func (suite *CommandTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewCommandRepository(suite.testDbInstance)
+}
+
+func (suite *CommandTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *CommandTestSuite) newCommand(sensorID, state int64, now time.Time) *domain.Command {
+	return &domain.Command{
+		SensorID:  sensorID,
+		Kind:      domain.CommandPulse,
+		State:     state,
+		Duration:  1500 * time.Millisecond,
+		Status:    domain.CommandPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Minute),
+	}
+}
+
+func (suite *CommandTestSuite) TestCommandRepository_SaveCommand() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetCommandByID(ctx, 1000)
+	assert.ErrorIs(suite.T(), err, usecase.ErrCommandNotFound)
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	command := suite.newCommand(1, 1, now)
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, command))
+	assert.NotZero(suite.T(), command.ID)
+
+	command.Status = domain.CommandConfirmed
+	command.ConfirmedAt = now
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, command))
+
+	actual, err := suite.repo.GetCommandByID(ctx, command.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), command, actual)
+}
+
+func (suite *CommandTestSuite) TestCommandRepository_Lifecycle() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	first, second := suite.newCommand(2, 1, now), suite.newCommand(2, 0, now)
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, first))
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, second))
+
+	delivered, err := suite.repo.DeliverCommands(ctx, 2, now)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), delivered, 2)
+	assert.Equal(suite.T(), first.ID, delivered[0].ID)
+	assert.Equal(suite.T(), domain.CommandDelivered, delivered[0].Status)
+	assert.Equal(suite.T(), now, delivered[0].DeliveredAt)
+
+	confirmed, err := suite.repo.ConfirmCommands(ctx, 2, 1, now)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), confirmed, 1)
+	assert.Equal(suite.T(), first.ID, confirmed[0].ID)
+
+	expired, err := suite.repo.ExpireCommands(ctx, now.Add(time.Minute))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), expired)
+
+	commands, err := suite.repo.GetCommands(ctx, 2, []domain.CommandStatus{domain.CommandExpired})
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), commands, 1)
+	assert.Equal(suite.T(), second.ID, commands[0].ID)
+
+	commands, err = suite.repo.GetCommands(ctx, 2, nil)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), commands, 2)
+}
+
+func (suite *CommandTestSuite) TestCommandRepository_DeliverCommand() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	command := suite.newCommand(3, 1, now)
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, command))
+
+	delivered, err := suite.repo.DeliverCommand(ctx, 4, command.ID, now)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), delivered)
+
+	delivered, err = suite.repo.DeliverCommand(ctx, 3, command.ID, now)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), delivered)
+
+	got, err := suite.repo.GetCommandByID(ctx, command.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), domain.CommandDelivered, got.Status)
+	assert.Equal(suite.T(), now, got.DeliveredAt)
+
+	delivered, err = suite.repo.DeliverCommand(ctx, 3, command.ID, now)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), delivered)
+}
+
+func TestCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
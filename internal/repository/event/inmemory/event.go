@@ -1,11 +1,14 @@
 package inmemory
 
 import (
+	"cmp"
 	"context"
 	"errors"
-	"fmt"
 	"homework/internal/domain"
 	"homework/internal/usecase"
+	"maps"
+	"math"
+	"slices"
 	"sync"
 	"time"
 )
@@ -33,7 +36,9 @@ func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) er
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.events = append(r.events, event)
+	stored := *event
+	stored.Channels = maps.Clone(event.Channels)
+	r.events = append(r.events, &stored)
 
 	return nil
 }
@@ -56,11 +61,11 @@ func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64)
 				out = event
 			}
 		}
-		fmt.Println(event.Timestamp)
 	}
 
 	if out != nil {
-		return out, nil
+		event := *out
+		return &event, nil
 	}
 
 	return nil, usecase.ErrEventNotFound
@@ -84,3 +89,115 @@ func (r *EventRepository) GetEventsByTimeFrame(ctx context.Context, id int64, st
 
 	return out, nil
 }
+
+func (r *EventRepository) IterateEvents(
+	ctx context.Context,
+	ids []int64,
+	start, finish time.Time,
+	fn func(domain.Event) error,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	var out []domain.Event
+	for _, event := range r.events {
+		if slices.Contains(ids, event.SensorID) && !(event.Timestamp.Before(start) || event.Timestamp.After(finish)) {
+			out = append(out, *event)
+		}
+	}
+	r.mu.Unlock()
+
+	slices.SortStableFunc(out, func(a, b domain.Event) int {
+		if c := cmp.Compare(a.SensorID, b.SensorID); c != 0 {
+			return c
+		}
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	for _, event := range out {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *EventRepository) AggregateEvents(
+	ctx context.Context,
+	id int64,
+	channel string,
+	start, finish time.Time,
+	interval time.Duration,
+) ([]domain.Aggregate, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	events, err := r.GetEventsByTimeFrame(ctx, id, start, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[time.Time]*domain.Aggregate)
+	for _, event := range events {
+		v, ok := event.Value(channel)
+		if !ok {
+			continue
+		}
+
+		bucket := start
+		if interval > 0 {
+			bucket = start.Add(event.Timestamp.Sub(start) / interval * interval)
+		}
+
+		n := v.Number()
+		agg, ok := buckets[bucket]
+		if !ok {
+			agg = &domain.Aggregate{Start: bucket, Min: math.Inf(1), Max: math.Inf(-1)}
+			buckets[bucket] = agg
+		}
+		agg.Count++
+		agg.Sum += n
+		agg.Min = min(agg.Min, n)
+		agg.Max = max(agg.Max, n)
+	}
+
+	out := make([]domain.Aggregate, 0, len(buckets))
+	for _, agg := range buckets {
+		agg.Avg = agg.Sum / float64(agg.Count)
+		out = append(out, *agg)
+	}
+	slices.SortFunc(out, func(a, b domain.Aggregate) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return out, nil
+}
+
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events[:0]
+	for _, event := range r.events {
+		if !event.Timestamp.Before(before) {
+			events = append(events, event)
+		}
+	}
+
+	deleted := int64(len(r.events) - len(events))
+	clear(r.events[len(events):])
+	r.events = events
+
+	return deleted, nil
+}
@@ -216,3 +216,134 @@ func TestEventRepository_GetEventsByTimeFrame(t *testing.T) {
 		assert.Equal(t, lastEvent.Payload, events[1].Payload)
 	})
 }
+
+func TestEventRepository_DeleteEventsBefore(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := er.DeleteEventsBefore(ctx, time.Now())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, delete old events", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, er.SaveEvent(ctx, &domain.Event{
+				Timestamp: now.Add(time.Duration(i-5) * time.Hour),
+				SensorID:  1,
+			}))
+		}
+
+		deleted, err := er.DeleteEventsBefore(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), deleted)
+
+		events, err := er.GetEventsByTimeFrame(ctx, 1, now.Add(-24*time.Hour), now.Add(24*time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, events, 5)
+	})
+}
+
+func TestEventRepository_IterateEvents(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := er.IterateEvents(ctx, []int64{1}, time.Now(), time.Now(), func(domain.Event) error { return nil })
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, ordered by sensor and time", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx := context.Background()
+
+		now := time.Now()
+		for _, event := range []domain.Event{
+			{SensorID: 2, Timestamp: now.Add(time.Minute), Payload: 4},
+			{SensorID: 1, Timestamp: now.Add(2 * time.Minute), Payload: 2},
+			{SensorID: 3, Timestamp: now, Payload: 0},
+			{SensorID: 1, Timestamp: now, Payload: 1},
+			{SensorID: 2, Timestamp: now, Payload: 3},
+			{SensorID: 1, Timestamp: now.Add(-time.Hour), Payload: -1},
+		} {
+			assert.NoError(t, er.SaveEvent(ctx, &event))
+		}
+
+		var payloads []int64
+		err := er.IterateEvents(ctx, []int64{1, 2}, now, now.Add(time.Hour), func(event domain.Event) error {
+			payloads = append(payloads, event.Payload)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3, 4}, payloads)
+	})
+
+	t.Run("err, fn error stops iteration", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx := context.Background()
+
+		now := time.Now()
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, er.SaveEvent(ctx, &domain.Event{SensorID: 1, Timestamp: now.Add(time.Duration(i) * time.Second)}))
+		}
+
+		calls := 0
+		err := er.IterateEvents(ctx, []int64{1}, now, now.Add(time.Hour), func(domain.Event) error {
+			calls++
+			return usecase.ErrEventNotFound
+		})
+		assert.ErrorIs(t, err, usecase.ErrEventNotFound)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestEventRepository_AggregateEvents(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := er.AggregateEvents(ctx, 1, domain.DefaultChannel, time.Now(), time.Now(), 0)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, buckets by channel", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx := context.Background()
+
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		events := []domain.Event{
+			{SensorID: 1, Timestamp: start, Payload: 20},
+			{SensorID: 1, Timestamp: start.Add(10 * time.Minute)},
+			{SensorID: 1, Timestamp: start.Add(70 * time.Minute)},
+			{SensorID: 2, Timestamp: start, Payload: 100},
+		}
+		events[1].SetChannels(map[string]domain.Value{"value": domain.FloatValue(21), "humidity": domain.IntValue(40)})
+		events[2].SetChannels(map[string]domain.Value{"value": domain.FloatValue(22.5), "door": domain.BoolValue(true)})
+		for _, event := range events {
+			assert.NoError(t, er.SaveEvent(ctx, &event))
+		}
+
+		aggs, err := er.AggregateEvents(ctx, 1, domain.DefaultChannel, start, start.Add(2*time.Hour), time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Aggregate{
+			{Start: start, Count: 2, Min: 20, Max: 21, Avg: 20.5, Sum: 41},
+			{Start: start.Add(time.Hour), Count: 1, Min: 22.5, Max: 22.5, Avg: 22.5, Sum: 22.5},
+		}, aggs)
+
+		aggs, err = er.AggregateEvents(ctx, 1, "humidity", start, start.Add(2*time.Hour), 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Aggregate{{Start: start, Count: 1, Min: 40, Max: 40, Avg: 40, Sum: 40}}, aggs)
+
+		aggs, err = er.AggregateEvents(ctx, 1, "missing", start, start.Add(2*time.Hour), 0)
+		assert.NoError(t, err)
+		assert.Empty(t, aggs)
+	})
+}
@@ -2,12 +2,18 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+
+	transaction "homework/internal/repository/transaction/postgres"
 )
 
 var ErrEventNotFound = errors.New("event not found")
@@ -22,13 +28,59 @@ func NewEventRepository(pool *pgxpool.Pool) *EventRepository {
 	}
 }
 
+var tracer = otel.Tracer("homework/internal/repository/event/postgres")
+
 const (
-	saveEventQuery              = `INSERT INTO events (timestamp, sensor_serial_number, sensor_id, payload) VALUES ($1, $2, $3, $4);`
-	getLastEventBySensorIDQuery = `SELECT * FROM events WHERE sensor_id = $1 AND timestamp = (SELECT MAX(timestamp) FROM events WHERE sensor_id = $1);`
-	getEventsByTimeFrameQuery   = `SELECT * FROM events WHERE sensor_id = $1 AND timestamp BETWEEN $2 AND $3`
+	saveEventQuery              = `INSERT INTO events (timestamp, sensor_serial_number, sensor_id, payload, channels) VALUES ($1, $2, $3, $4, $5);`
+	getLastEventBySensorIDQuery = `SELECT timestamp, sensor_serial_number, sensor_id, payload, channels FROM events
+		WHERE sensor_id = $1 AND timestamp = (SELECT MAX(timestamp) FROM events WHERE sensor_id = $1);`
+	getEventsByTimeFrameQuery = `SELECT timestamp, sensor_serial_number, sensor_id, payload, channels FROM events
+		WHERE sensor_id = $1 AND timestamp BETWEEN $2 AND $3`
+	deleteEventsBeforeQuery = `DELETE FROM events WHERE timestamp < $1;`
+	iterateEventsQuery      = `SELECT timestamp, sensor_serial_number, sensor_id, payload, channels FROM events
+		WHERE sensor_id = ANY($1) AND timestamp BETWEEN $2 AND $3 ORDER BY sensor_id, timestamp`
+	// у событий без каналов единственное значение лежит в payload и считается каналом value
+	aggregateEventsQuery = `WITH v AS (
+			SELECT timestamp AS ts, CASE jsonb_typeof(channels -> $2::text)
+				WHEN 'number' THEN (channels ->> $2::text)::double precision
+				WHEN 'boolean' THEN CASE WHEN (channels -> $2::text)::boolean THEN 1 ELSE 0 END
+				ELSE CASE WHEN channels IS NULL AND $2::text = 'value' THEN payload::double precision END
+			END AS value
+			FROM events WHERE sensor_id = $1 AND timestamp BETWEEN $3 AND $4
+		)
+		SELECT $3::timestamp + floor(extract(epoch FROM ts - $3::timestamp) / $5::double precision)
+				* make_interval(secs => $5::double precision) AS bucket,
+			count(value), min(value), max(value), avg(value), sum(value)
+		FROM v WHERE value IS NOT NULL GROUP BY bucket ORDER BY bucket`
 )
 
-func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+// marshalChannels - каналы события для колонки jsonb, NULL у событий с одним целым значением
+func marshalChannels(channels map[string]domain.Value) ([]byte, error) {
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(channels)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row scanner, event *domain.Event) error {
+	var channels []byte
+	if err := row.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload, &channels); err != nil {
+		return err
+	}
+	if channels == nil {
+		return nil
+	}
+	return json.Unmarshal(channels, &event.Channels)
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "EventRepository.SaveEvent", saveEventQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -37,22 +89,33 @@ func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) er
 		return errors.New("event is nil")
 	}
 
-	_, err := r.pool.Exec(ctx, saveEventQuery, event.Timestamp, event.SensorSerialNumber, event.SensorID, event.Payload)
+	channels, err := marshalChannels(event.Channels)
+	if err != nil {
+		return fmt.Errorf("can't encode event channels: %w", err)
+	}
+
+	_, err = transaction.DB(ctx, r.pool).Exec(ctx, saveEventQuery, event.Timestamp, event.SensorSerialNumber, event.SensorID, event.Payload, channels)
 	if err != nil {
 		return fmt.Errorf("can't save event: %w", err)
 	}
 
+	logging.FromContext(ctx).DebugContext(ctx, "event saved",
+		logging.SensorID(event.SensorID), logging.SerialNumber(event.SensorSerialNumber))
+
 	return nil
 }
 
-func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "EventRepository.GetLastEventBySensorID", getLastEventBySensorIDQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
 	var event domain.Event
 
-	err := r.pool.QueryRow(ctx, getLastEventBySensorIDQuery, id).Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload)
+	err = scanEvent(transaction.DB(ctx, r.pool).QueryRow(ctx, getLastEventBySensorIDQuery, id), &event)
 	if err != nil {
 		return nil, fmt.Errorf("can't get last event: %w", err)
 	}
@@ -60,12 +123,15 @@ func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64)
 	return &event, nil
 }
 
-func (r *EventRepository) GetEventsByTimeFrame(ctx context.Context, id int64, start, finish time.Time) ([]domain.Event, error) {
+func (r *EventRepository) GetEventsByTimeFrame(ctx context.Context, id int64, start, finish time.Time) (_ []domain.Event, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "EventRepository.GetEventsByTimeFrame", getEventsByTimeFrameQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
-	rows, err := r.pool.Query(ctx, getEventsByTimeFrameQuery, id, start, finish)
+	rows, err := transaction.DB(ctx, r.pool).Query(ctx, getEventsByTimeFrameQuery, id, start, finish)
 	if err != nil {
 		return nil, fmt.Errorf("can't get events: %w", err)
 	}
@@ -75,7 +141,7 @@ func (r *EventRepository) GetEventsByTimeFrame(ctx context.Context, id int64, st
 
 	for rows.Next() {
 		var event domain.Event
-		err = rows.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload)
+		err = scanEvent(rows, &event)
 		if err != nil {
 			return nil, err
 		}
@@ -84,3 +150,90 @@ func (r *EventRepository) GetEventsByTimeFrame(ctx context.Context, id int64, st
 
 	return events, nil
 }
+
+func (r *EventRepository) IterateEvents(
+	ctx context.Context,
+	ids []int64,
+	start, finish time.Time,
+	fn func(domain.Event) error,
+) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "EventRepository.IterateEvents", iterateEventsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// строки читаются из соединения по мере обхода, поэтому выборка не буферизуется целиком
+	rows, err := transaction.DB(ctx, r.pool).Query(ctx, iterateEventsQuery, ids, start, finish)
+	if err != nil {
+		return fmt.Errorf("can't iterate events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event domain.Event
+		if err := scanEvent(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *EventRepository) AggregateEvents(
+	ctx context.Context,
+	id int64,
+	channel string,
+	start, finish time.Time,
+	interval time.Duration,
+) (_ []domain.Aggregate, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "EventRepository.AggregateEvents", aggregateEventsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// без разбиения весь период попадает в один интервал, начинающийся в start
+	if interval <= 0 {
+		interval = finish.Sub(start) + time.Second
+	}
+
+	rows, err := transaction.DB(ctx, r.pool).Query(ctx, aggregateEventsQuery, id, channel, start, finish, interval.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("can't aggregate events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.Aggregate
+
+	for rows.Next() {
+		var agg domain.Aggregate
+		if err := rows.Scan(&agg.Start, &agg.Count, &agg.Min, &agg.Max, &agg.Avg, &agg.Sum); err != nil {
+			return nil, err
+		}
+		out = append(out, agg)
+	}
+
+	return out, rows.Err()
+}
+
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "EventRepository.DeleteEventsBefore", deleteEventsBeforeQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	tag, err := transaction.DB(ctx, r.pool).Exec(ctx, deleteEventsBeforeQuery, before)
+	if err != nil {
+		return 0, fmt.Errorf("can't delete events: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
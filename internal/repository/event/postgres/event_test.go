@@ -104,6 +104,85 @@ func (suite *EventTestSuite) TestEventRepository_GetEventsByTimeFrame() {
 	assert.Contains(suite.T(), events, secondEvent)
 }
 
+func (suite *EventTestSuite) TestEventRepository_DeleteEventsBefore() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	oldEvent := domain.Event{
+		Timestamp:          time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		SensorSerialNumber: "1111111111",
+		SensorID:           3,
+		Payload:            1,
+	}
+
+	err := suite.repo.SaveEvent(ctx, &oldEvent)
+	assert.Nil(suite.T(), err)
+
+	deleted, err := suite.repo.DeleteEventsBefore(ctx, oldEvent.Timestamp.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), deleted)
+
+	events, err := suite.repo.GetEventsByTimeFrame(ctx, 3, oldEvent.Timestamp, oldEvent.Timestamp)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), events)
+}
+
+func (suite *EventTestSuite) TestEventRepository_IterateEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{Timestamp: start.Add(time.Minute), SensorSerialNumber: "5555555555", SensorID: 5, Payload: 2},
+		{Timestamp: start, SensorSerialNumber: "5555555555", SensorID: 5, Payload: 1},
+		{Timestamp: start, SensorSerialNumber: "4444444444", SensorID: 4, Payload: 0},
+		{Timestamp: start, SensorSerialNumber: "6666666666", SensorID: 6, Payload: 3},
+	}
+	for _, event := range events {
+		assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event))
+	}
+
+	var actual []domain.Event
+	err := suite.repo.IterateEvents(ctx, []int64{4, 5}, start, start.Add(time.Hour), func(event domain.Event) error {
+		actual = append(actual, event)
+		return nil
+	})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Event{events[2], events[1], events[0]}, actual)
+}
+
+func (suite *EventTestSuite) TestEventRepository_Channels() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{Timestamp: start, SensorSerialNumber: "7777777777", SensorID: 7, Payload: 20},
+		{Timestamp: start.Add(10 * time.Minute), SensorSerialNumber: "7777777777", SensorID: 7},
+		{Timestamp: start.Add(70 * time.Minute), SensorSerialNumber: "7777777777", SensorID: 7},
+	}
+	events[1].SetChannels(map[string]domain.Value{"value": domain.FloatValue(21), "humidity": domain.IntValue(40)})
+	events[2].SetChannels(map[string]domain.Value{"value": domain.FloatValue(22.5), "door": domain.BoolValue(true)})
+	for _, event := range events {
+		assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event))
+	}
+
+	actual, err := suite.repo.GetEventsByTimeFrame(ctx, 7, start, start.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.ElementsMatch(suite.T(), events[:2], actual)
+
+	aggs, err := suite.repo.AggregateEvents(ctx, 7, domain.DefaultChannel, start, start.Add(2*time.Hour), time.Hour)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Aggregate{
+		{Start: start, Count: 2, Min: 20, Max: 21, Avg: 20.5, Sum: 41},
+		{Start: start.Add(time.Hour), Count: 1, Min: 22.5, Max: 22.5, Avg: 22.5, Sum: 22.5},
+	}, aggs)
+
+	aggs, err = suite.repo.AggregateEvents(ctx, 7, "door", start, start.Add(2*time.Hour), 0)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Aggregate{{Start: start, Count: 1, Min: 1, Max: 1, Avg: 1, Sum: 1}}, aggs)
+}
+
 func TestEventTestSuite(t *testing.T) {
 	suite.Run(t, new(EventTestSuite))
 }
@@ -0,0 +1,91 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+)
+
+// ImportRepository - состояние загрузок в памяти. Датчики и события сохраняются
+// через переданные репозитории, поэтому пачка сохраняется не атомарно.
+type ImportRepository struct {
+	mu      sync.Mutex
+	imports map[string]domain.Import
+
+	sr usecase.SensorRepository
+	er usecase.EventRepository
+}
+
+func NewImportRepository(sr usecase.SensorRepository, er usecase.EventRepository) *ImportRepository {
+	return &ImportRepository{
+		imports: make(map[string]domain.Import),
+		sr:      sr,
+		er:      er,
+	}
+}
+
+func (r *ImportRepository) GetImport(ctx context.Context, id string) (*domain.Import, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	imp, ok := r.imports[id]
+	if !ok {
+		return nil, usecase.ErrImportNotFound
+	}
+	imp.Errors = slices.Clone(imp.Errors)
+
+	return &imp, nil
+}
+
+func (r *ImportRepository) SaveImport(ctx context.Context, imp *domain.Import) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if imp == nil {
+		return errors.New("import is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *imp
+	stored.Errors = slices.Clone(imp.Errors)
+	r.imports[imp.ID] = stored
+
+	return nil
+}
+
+func (r *ImportRepository) CommitSensors(ctx context.Context, imp *domain.Import, sensors []domain.Sensor) error {
+	for _, sensor := range sensors {
+		_, err := r.sr.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, usecase.ErrSensorNotFound) {
+			return err
+		}
+		if err := r.sr.SaveSensor(ctx, &sensor); err != nil {
+			return err
+		}
+	}
+
+	return r.SaveImport(ctx, imp)
+}
+
+func (r *ImportRepository) CommitEvents(ctx context.Context, imp *domain.Import, events []domain.Event) error {
+	for _, event := range events {
+		if err := r.er.SaveEvent(ctx, &event); err != nil {
+			return err
+		}
+	}
+
+	return r.SaveImport(ctx, imp)
+}
@@ -0,0 +1,72 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	eventInMemory "homework/internal/repository/event/inmemory"
+	sensorInMemory "homework/internal/repository/sensor/inmemory"
+)
+
+func TestImportRepository(t *testing.T) {
+	ctx := context.Background()
+
+	sr := sensorInMemory.NewSensorRepository()
+	er := eventInMemory.NewEventRepository()
+	r := NewImportRepository(sr, er)
+
+	t.Run("err, not found", func(t *testing.T) {
+		_, err := r.GetImport(ctx, "missing")
+		assert.ErrorIs(t, err, usecase.ErrImportNotFound)
+	})
+
+	t.Run("ok, save returns copy", func(t *testing.T) {
+		imp := &domain.Import{ID: "a", Kind: domain.ImportKindSensors, Errors: []domain.ImportRowError{{Row: 1, Reason: "bad"}}}
+		require.NoError(t, r.SaveImport(ctx, imp))
+		imp.Errors[0].Reason = "changed"
+
+		stored, err := r.GetImport(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "bad", stored.Errors[0].Reason)
+	})
+
+	t.Run("ok, commit sensors skips registered", func(t *testing.T) {
+		require.NoError(t, sr.SaveSensor(ctx, &domain.Sensor{SerialNumber: "1234567890", Description: "old"}))
+
+		imp := &domain.Import{ID: "a", Kind: domain.ImportKindSensors, RowsProcessed: 2}
+		require.NoError(t, r.CommitSensors(ctx, imp, []domain.Sensor{
+			{SerialNumber: "1234567890", Description: "new"},
+			{SerialNumber: "0987654321", Description: "new"},
+		}))
+
+		sensor, err := sr.GetSensorBySerialNumber(ctx, "1234567890")
+		require.NoError(t, err)
+		assert.Equal(t, "old", sensor.Description)
+		_, err = sr.GetSensorBySerialNumber(ctx, "0987654321")
+		assert.NoError(t, err)
+
+		stored, err := r.GetImport(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored.RowsProcessed)
+	})
+
+	t.Run("ok, commit events", func(t *testing.T) {
+		now := time.Now()
+		imp := &domain.Import{ID: "b", Kind: domain.ImportKindEvents, RowsProcessed: 1}
+		require.NoError(t, r.CommitEvents(ctx, imp, []domain.Event{{SensorID: 1, Timestamp: now, Payload: 3}}))
+
+		events, err := er.GetEventsByTimeFrame(ctx, 1, now, now)
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		stored, err := r.GetImport(ctx, "b")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), stored.RowsProcessed)
+	})
+}
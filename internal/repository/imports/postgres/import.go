@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+type ImportRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewImportRepository(pool *pgxpool.Pool) *ImportRepository {
+	return &ImportRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/imports/postgres")
+
+const (
+	getImportQuery  = `SELECT id, kind, status, rows_processed, rows_imported, rows_failed, errors, error, created_at, updated_at FROM imports WHERE id = $1;`
+	saveImportQuery = `INSERT INTO imports (id, kind, status, rows_processed, rows_imported, rows_failed, errors, error, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (id) DO UPDATE SET status = excluded.status, rows_processed = excluded.rows_processed,
+	rows_imported = excluded.rows_imported, rows_failed = excluded.rows_failed, errors = excluded.errors,
+	error = excluded.error, updated_at = excluded.updated_at;`
+	createImportSensorsQuery = `CREATE TEMPORARY TABLE import_sensors (serial_number text, type text, description text, is_active boolean) ON COMMIT DROP;`
+	insertImportSensorsQuery = `INSERT INTO sensors (serial_number, type, current_state, description, is_active, registered_at, last_activity)
+SELECT DISTINCT ON (s.serial_number) s.serial_number, s.type, 0, s.description, s.is_active, $1, $2 FROM import_sensors s
+WHERE NOT EXISTS (SELECT 1 FROM sensors WHERE sensors.serial_number = s.serial_number);`
+)
+
+var (
+	importSensorsColumns = []string{"serial_number", "type", "description", "is_active"}
+	importEventsColumns  = []string{"timestamp", "sensor_serial_number", "sensor_id", "payload", "channels"}
+)
+
+// rowError - ошибка строки в колонке errors
+type rowError struct {
+	Row    int64  `json:"row"`
+	Reason string `json:"reason"`
+}
+
+func (r *ImportRepository) GetImport(ctx context.Context, id string) (_ *domain.Import, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ImportRepository.GetImport", getImportQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var (
+		imp       domain.Import
+		rowErrors []rowError
+	)
+	err = r.pool.QueryRow(ctx, getImportQuery, id).Scan(
+		&imp.ID,
+		&imp.Kind,
+		&imp.Status,
+		&imp.RowsProcessed,
+		&imp.RowsImported,
+		&imp.RowsFailed,
+		&rowErrors,
+		&imp.Error,
+		&imp.CreatedAt,
+		&imp.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrImportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get import: %w", err)
+	}
+
+	for _, e := range rowErrors {
+		imp.Errors = append(imp.Errors, domain.ImportRowError{Row: e.Row, Reason: e.Reason})
+	}
+
+	return &imp, nil
+}
+
+func (r *ImportRepository) SaveImport(ctx context.Context, imp *domain.Import) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ImportRepository.SaveImport", saveImportQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return saveImport(ctx, r.pool, imp)
+}
+
+// CommitSensors - датчики копируются во временную таблицу через COPY, а оттуда в sensors
+// переносятся только незарегистрированные серийные номера
+func (r *ImportRepository) CommitSensors(ctx context.Context, imp *domain.Import, sensors []domain.Sensor) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ImportRepository.CommitSensors", insertImportSensorsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	return r.commit(ctx, imp, func(tx pgx.Tx) error {
+		if len(sensors) == 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, createImportSensorsQuery); err != nil {
+			return fmt.Errorf("can't create import table: %w", err)
+		}
+
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"import_sensors"}, importSensorsColumns,
+			pgx.CopyFromSlice(len(sensors), func(i int) ([]any, error) {
+				s := sensors[i]
+				return []any{s.SerialNumber, string(s.Type), s.Description, s.IsActive}, nil
+			}))
+		if err != nil {
+			return fmt.Errorf("can't copy sensors: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, insertImportSensorsQuery, time.Now(), time.Time{})
+		if err != nil {
+			return fmt.Errorf("can't save sensors: %w", err)
+		}
+
+		logging.FromContext(ctx).DebugContext(ctx, "sensors imported", slog.Int64("count", tag.RowsAffected()))
+
+		return nil
+	})
+}
+
+func (r *ImportRepository) CommitEvents(ctx context.Context, imp *domain.Import, events []domain.Event) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "ImportRepository.CommitEvents")
+	defer func() { tracing.End(span, err) }()
+
+	return r.commit(ctx, imp, func(tx pgx.Tx) error {
+		if len(events) == 0 {
+			return nil
+		}
+
+		count, err := tx.CopyFrom(ctx, pgx.Identifier{"events"}, importEventsColumns,
+			pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+				e := events[i]
+				var channels []byte
+				if len(e.Channels) > 0 {
+					data, err := json.Marshal(e.Channels)
+					if err != nil {
+						return nil, err
+					}
+					channels = data
+				}
+				return []any{e.Timestamp, e.SensorSerialNumber, e.SensorID, e.Payload, channels}, nil
+			}))
+		if err != nil {
+			return fmt.Errorf("can't copy events: %w", err)
+		}
+
+		logging.FromContext(ctx).DebugContext(ctx, "events imported", slog.Int64("count", count))
+
+		return nil
+	})
+}
+
+// commit - выполняет fn и сохраняет состояние загрузки в одной транзакции
+func (r *ImportRepository) commit(ctx context.Context, imp *domain.Import, fn func(pgx.Tx) error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if imp == nil {
+		return errors.New("import is nil")
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := saveImport(ctx, tx, imp); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit import: %w", err)
+	}
+
+	return nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func saveImport(ctx context.Context, db execer, imp *domain.Import) error {
+	errs := make([]rowError, 0, len(imp.Errors))
+	for _, e := range imp.Errors {
+		errs = append(errs, rowError{Row: e.Row, Reason: e.Reason})
+	}
+	data, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, saveImportQuery,
+		imp.ID,
+		imp.Kind,
+		imp.Status,
+		imp.RowsProcessed,
+		imp.RowsImported,
+		imp.RowsFailed,
+		data,
+		imp.Error,
+		imp.CreatedAt,
+		imp.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("can't save import: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	eventRepository "homework/internal/repository/event/postgres"
+	sensorRepository "homework/internal/repository/sensor/postgres"
+)
+
+type ImportTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *ImportRepository
+}
+
+func (suite *ImportTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewImportRepository(suite.testDbInstance)
+}
+
+func (suite *ImportTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *ImportTestSuite) TestImportRepository_SaveImport() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetImport(ctx, "missing")
+	assert.ErrorIs(suite.T(), err, usecase.ErrImportNotFound)
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	imp := &domain.Import{
+		ID:        "save",
+		Kind:      domain.ImportKindEvents,
+		Status:    domain.ImportStatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	assert.Nil(suite.T(), suite.repo.SaveImport(ctx, imp))
+
+	imp.Status = domain.ImportStatusFailed
+	imp.RowsProcessed = 3
+	imp.RowsFailed = 1
+	imp.Errors = []domain.ImportRowError{{Row: 2, Reason: "wrong sensor serial number"}}
+	imp.Error = "connection reset"
+	assert.Nil(suite.T(), suite.repo.SaveImport(ctx, imp))
+
+	actual, err := suite.repo.GetImport(ctx, "save")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), imp, actual)
+}
+
+func (suite *ImportTestSuite) TestImportRepository_CommitSensors() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sensors := sensorRepository.NewSensorRepository(suite.testDbInstance)
+	assert.Nil(suite.T(), sensors.SaveSensor(ctx, &domain.Sensor{
+		SerialNumber: "1111111111",
+		Type:         domain.SensorTypeADC,
+		Description:  "old",
+	}))
+
+	imp := &domain.Import{ID: "sensors", Kind: domain.ImportKindSensors, Status: domain.ImportStatusRunning, RowsProcessed: 2}
+	err := suite.repo.CommitSensors(ctx, imp, []domain.Sensor{
+		{SerialNumber: "1111111111", Type: domain.SensorTypeADC, Description: "new"},
+		{SerialNumber: "2222222222", Type: domain.SensorTypeContactClosure, Description: "new", IsActive: true},
+	})
+	assert.Nil(suite.T(), err)
+
+	old, err := sensors.GetSensorBySerialNumber(ctx, "1111111111")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "old", old.Description)
+
+	added, err := sensors.GetSensorBySerialNumber(ctx, "2222222222")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), domain.SensorTypeContactClosure, added.Type)
+	assert.True(suite.T(), added.IsActive)
+
+	stored, err := suite.repo.GetImport(ctx, "sensors")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), stored.RowsProcessed)
+}
+
+func (suite *ImportTestSuite) TestImportRepository_CommitEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	events := []domain.Event{
+		{Timestamp: start, SensorSerialNumber: "3333333333", SensorID: 33, Payload: 1},
+		{Timestamp: start.Add(time.Minute), SensorSerialNumber: "3333333333", SensorID: 33, Payload: 2},
+	}
+
+	imp := &domain.Import{ID: "events", Kind: domain.ImportKindEvents, Status: domain.ImportStatusRunning, RowsProcessed: 2, RowsImported: 2}
+	assert.Nil(suite.T(), suite.repo.CommitEvents(ctx, imp, events))
+
+	actual, err := eventRepository.NewEventRepository(suite.testDbInstance).
+		GetEventsByTimeFrame(ctx, 33, start, start.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.ElementsMatch(suite.T(), events, actual)
+
+	stored, err := suite.repo.GetImport(ctx, "events")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), stored.RowsImported)
+}
+
+func TestImportTestSuite(t *testing.T) {
+	suite.Run(t, new(ImportTestSuite))
+}
@@ -0,0 +1,78 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sync"
+)
+
+// OutboxRepository - outbox в памяти. Исключительный доступ к сообщениям даёт единица работы
+// in-memory хранилища: единицы работы выполняются по одной.
+type OutboxRepository struct {
+	mu       sync.Mutex
+	lastID   int64
+	messages []domain.OutboxMessage
+}
+
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{}
+}
+
+func (r *OutboxRepository) AddOutboxMessage(ctx context.Context, message *domain.OutboxMessage) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if message == nil {
+		return errors.New("outbox message is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	message.ID = r.lastID
+	r.messages = append(r.messages, *message)
+
+	return nil
+}
+
+func (r *OutboxRepository) GetOutboxMessages(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// сообщения добавляются по возрастанию ID
+	n := len(r.messages)
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	return append([]domain.OutboxMessage(nil), r.messages[:n]...), nil
+}
+
+func (r *OutboxRepository) DeleteOutboxMessages(ctx context.Context, ids []int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	deleted := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		deleted[id] = struct{}{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.messages[:0]
+	for _, message := range r.messages {
+		if _, ok := deleted[message.ID]; !ok {
+			kept = append(kept, message)
+		}
+	}
+	r.messages = kept
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewOutboxRepository()
+
+	for _, key := range []string{"1", "2", "1"} {
+		require.NoError(t, repo.AddOutboxMessage(ctx, &domain.OutboxMessage{Topic: domain.OutboxSensorEvent, Key: key}))
+	}
+
+	messages, err := repo.GetOutboxMessages(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, int64(1), messages[0].ID)
+	assert.Equal(t, int64(2), messages[1].ID)
+
+	require.NoError(t, repo.DeleteOutboxMessages(ctx, []int64{1, 3}))
+
+	messages, err = repo.GetOutboxMessages(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "2", messages[0].Key)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, repo.AddOutboxMessage(cancelled, &domain.OutboxMessage{}), context.Canceled)
+}
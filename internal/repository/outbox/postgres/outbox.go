@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+
+	transaction "homework/internal/repository/transaction/postgres"
+)
+
+type OutboxRepository struct {
+	pool *pgxpool.Pool
+	key  int64
+}
+
+func NewOutboxRepository(pool *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{
+		pool: pool,
+		key:  defaultRelayLockKey,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/outbox/postgres")
+
+const (
+	// defaultRelayLockKey - ключ advisory блокировки транзакции, которая публикует сообщения outbox
+	defaultRelayLockKey int64 = 0x6f7574626f78 // "outbox"
+
+	addMessageQuery = `INSERT INTO outbox (topic, key, payload, created_at) VALUES ($1, $2, $3, $4) RETURNING id;`
+	// tryLockQuery - публикует одна транзакция за раз, иначе две реплики могли бы опубликовать
+	// сообщения одного ключа не по порядку
+	tryLockQuery       = `SELECT pg_try_advisory_xact_lock($1);`
+	getMessagesQuery   = `SELECT id, topic, key, payload, created_at FROM outbox ORDER BY id LIMIT NULLIF($1, 0);`
+	deleteMessageQuery = `DELETE FROM outbox WHERE id = ANY($1);`
+)
+
+func (r *OutboxRepository) AddOutboxMessage(ctx context.Context, message *domain.OutboxMessage) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "OutboxRepository.AddOutboxMessage", addMessageQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if message == nil {
+		return errors.New("outbox message is nil")
+	}
+
+	err = transaction.DB(ctx, r.pool).QueryRow(ctx, addMessageQuery,
+		message.Topic,
+		message.Key,
+		message.Payload,
+		message.CreatedAt,
+	).Scan(&message.ID)
+	if err != nil {
+		return fmt.Errorf("can't add outbox message: %w", err)
+	}
+	return nil
+}
+
+func (r *OutboxRepository) GetOutboxMessages(ctx context.Context, limit int) (_ []domain.OutboxMessage, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "OutboxRepository.GetOutboxMessages", getMessagesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	db := transaction.DB(ctx, r.pool)
+
+	var locked bool
+	if err := db.QueryRow(ctx, tryLockQuery, r.key).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("can't lock outbox: %w", err)
+	}
+	if !locked {
+		return []domain.OutboxMessage{}, nil
+	}
+
+	rows, err := db.Query(ctx, getMessagesQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't get outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]domain.OutboxMessage, 0)
+	for rows.Next() {
+		var message domain.OutboxMessage
+		err := rows.Scan(&message.ID, &message.Topic, &message.Key, &message.Payload, &message.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan outbox message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (r *OutboxRepository) DeleteOutboxMessages(ctx context.Context, ids []int64) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "OutboxRepository.DeleteOutboxMessages", deleteMessageQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if _, err := transaction.DB(ctx, r.pool).Exec(ctx, deleteMessageQuery, ids); err != nil {
+		return fmt.Errorf("can't delete outbox messages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	transaction "homework/internal/repository/transaction/postgres"
+)
+
+type OutboxTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *OutboxRepository
+	uow  *transaction.UnitOfWork
+}
+
+func (suite *OutboxTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewOutboxRepository(suite.testDbInstance)
+	suite.uow = transaction.NewUnitOfWork(suite.testDbInstance)
+}
+
+func (suite *OutboxTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func newMessage(key string) *domain.OutboxMessage {
+	return &domain.OutboxMessage{
+		Topic:     domain.OutboxSensorEvent,
+		Key:       key,
+		Payload:   []byte(`{"sensor_id":1}`),
+		CreatedAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
+	}
+}
+
+func (suite *OutboxTestSuite) TestOutboxRepository() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rolledBack := errors.New("rolled back")
+	err := suite.uow.Do(ctx, func(ctx context.Context) error {
+		require.NoError(suite.T(), suite.repo.AddOutboxMessage(ctx, newMessage("0")))
+		return rolledBack
+	})
+	assert.ErrorIs(suite.T(), err, rolledBack)
+
+	first, second := newMessage("1"), newMessage("2")
+	err = suite.uow.Do(ctx, func(ctx context.Context) error {
+		if err := suite.repo.AddOutboxMessage(ctx, first); err != nil {
+			return err
+		}
+		return suite.repo.AddOutboxMessage(ctx, second)
+	})
+	require.NoError(suite.T(), err)
+
+	err = suite.uow.Do(ctx, func(ctx context.Context) error {
+		messages, err := suite.repo.GetOutboxMessages(ctx, 10)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), []domain.OutboxMessage{*first, *second}, messages)
+
+		// пока сообщения публикует эта единица работы, другие их не получают
+		err = suite.uow.Do(context.Background(), func(other context.Context) error {
+			messages, err := suite.repo.GetOutboxMessages(other, 10)
+			require.NoError(suite.T(), err)
+			assert.Empty(suite.T(), messages)
+			return nil
+		})
+		require.NoError(suite.T(), err)
+
+		return suite.repo.DeleteOutboxMessages(ctx, []int64{first.ID})
+	})
+	require.NoError(suite.T(), err)
+
+	err = suite.uow.Do(ctx, func(ctx context.Context) error {
+		messages, err := suite.repo.GetOutboxMessages(ctx, 0)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), []domain.OutboxMessage{*second}, messages)
+		return nil
+	})
+	require.NoError(suite.T(), err)
+}
+
+func TestOutboxTestSuite(t *testing.T) {
+	suite.Run(t, new(OutboxTestSuite))
+}
@@ -0,0 +1,155 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+)
+
+type SceneRepository struct {
+	mu               sync.Mutex
+	lastID           int64
+	lastActivationID int64
+	scenes           map[int64]domain.Scene
+	activations      map[int64][]domain.SceneActivation
+}
+
+func NewSceneRepository() *SceneRepository {
+	return &SceneRepository{
+		scenes:      make(map[int64]domain.Scene),
+		activations: make(map[int64][]domain.SceneActivation),
+	}
+}
+
+func (r *SceneRepository) SaveScene(ctx context.Context, scene *domain.Scene) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if scene == nil {
+		return errors.New("scene is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if scene.ID != 0 {
+		if _, ok := r.scenes[scene.ID]; !ok {
+			return usecase.ErrSceneNotFound
+		}
+	}
+	for _, s := range r.scenes {
+		if s.Name == scene.Name && s.ID != scene.ID {
+			return usecase.ErrSceneExists
+		}
+	}
+
+	if scene.ID == 0 {
+		r.lastID++
+		scene.ID = r.lastID
+	}
+	stored := *scene
+	stored.Targets = slices.Clone(scene.Targets)
+	r.scenes[scene.ID] = stored
+
+	return nil
+}
+
+func (r *SceneRepository) GetSceneByID(ctx context.Context, id int64) (*domain.Scene, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scene, ok := r.scenes[id]
+	if !ok {
+		return nil, usecase.ErrSceneNotFound
+	}
+	scene.Targets = slices.Clone(scene.Targets)
+	return &scene, nil
+}
+
+func (r *SceneRepository) GetScenes(ctx context.Context) ([]domain.Scene, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scenes := make([]domain.Scene, 0, len(r.scenes))
+	for _, scene := range r.scenes {
+		scene.Targets = slices.Clone(scene.Targets)
+		scenes = append(scenes, scene)
+	}
+	slices.SortFunc(scenes, func(a, b domain.Scene) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return scenes, nil
+}
+
+func (r *SceneRepository) DeleteScene(ctx context.Context, id int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.scenes[id]; !ok {
+		return usecase.ErrSceneNotFound
+	}
+	delete(r.scenes, id)
+	delete(r.activations, id)
+
+	return nil
+}
+
+func (r *SceneRepository) SaveActivation(ctx context.Context, activation *domain.SceneActivation) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if activation == nil {
+		return errors.New("activation is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.scenes[activation.SceneID]; !ok {
+		return usecase.ErrSceneNotFound
+	}
+
+	r.lastActivationID++
+	activation.ID = r.lastActivationID
+	stored := *activation
+	stored.Results = slices.Clone(activation.Results)
+	r.activations[activation.SceneID] = append(r.activations[activation.SceneID], stored)
+
+	return nil
+}
+
+func (r *SceneRepository) GetActivations(ctx context.Context, sceneID int64, limit int) ([]domain.SceneActivation, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.activations[sceneID]
+	activations := make([]domain.SceneActivation, 0, min(limit, len(stored)))
+	for i := len(stored) - 1; i >= 0 && len(activations) < limit; i-- {
+		activation := stored[i]
+		activation.Results = slices.Clone(activation.Results)
+		activations = append(activations, activation)
+	}
+
+	return activations, nil
+}
@@ -0,0 +1,99 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSceneRepository(t *testing.T) {
+	now := time.Now()
+	active := true
+
+	newScene := func(name string) *domain.Scene {
+		return &domain.Scene{
+			Name:      name,
+			Targets:   []domain.SceneTarget{{SensorID: 1, IsActive: &active}},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	t.Run("ok, save, get and delete", func(t *testing.T) {
+		r := NewSceneRepository()
+		ctx := context.Background()
+
+		night, away := newScene("night"), newScene("away")
+		require.NoError(t, r.SaveScene(ctx, night))
+		require.NoError(t, r.SaveScene(ctx, away))
+		assert.Equal(t, int64(1), night.ID)
+		assert.Equal(t, int64(2), away.ID)
+
+		stored, err := r.GetSceneByID(ctx, night.ID)
+		require.NoError(t, err)
+		assert.Equal(t, night, stored)
+
+		night.Description = "lights off"
+		require.NoError(t, r.SaveScene(ctx, night))
+
+		scenes, err := r.GetScenes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Scene{*night, *away}, scenes)
+
+		require.NoError(t, r.DeleteScene(ctx, night.ID))
+		_, err = r.GetSceneByID(ctx, night.ID)
+		assert.ErrorIs(t, err, usecase.ErrSceneNotFound)
+		assert.ErrorIs(t, r.DeleteScene(ctx, night.ID), usecase.ErrSceneNotFound)
+	})
+
+	t.Run("err, duplicate name", func(t *testing.T) {
+		r := NewSceneRepository()
+		ctx := context.Background()
+
+		night, away := newScene("night"), newScene("away")
+		require.NoError(t, r.SaveScene(ctx, night))
+		require.NoError(t, r.SaveScene(ctx, away))
+
+		assert.ErrorIs(t, r.SaveScene(ctx, newScene("night")), usecase.ErrSceneExists)
+
+		away.Name = "night"
+		assert.ErrorIs(t, r.SaveScene(ctx, away), usecase.ErrSceneExists)
+	})
+
+	t.Run("ok, activations newest first", func(t *testing.T) {
+		r := NewSceneRepository()
+		ctx := context.Background()
+
+		night := newScene("night")
+		require.NoError(t, r.SaveScene(ctx, night))
+
+		for i := 0; i < 3; i++ {
+			activation := &domain.SceneActivation{
+				SceneID:     night.ID,
+				Status:      domain.SceneActivationApplied,
+				ActivatedAt: now.Add(time.Duration(i) * time.Second),
+				Results:     []domain.SceneTargetResult{{SensorID: 1}},
+			}
+			require.NoError(t, r.SaveActivation(ctx, activation))
+			assert.Equal(t, int64(i+1), activation.ID)
+		}
+
+		activations, err := r.GetActivations(ctx, night.ID, 2)
+		require.NoError(t, err)
+		require.Len(t, activations, 2)
+		assert.Equal(t, []int64{3, 2}, []int64{activations[0].ID, activations[1].ID})
+
+		err = r.SaveActivation(ctx, &domain.SceneActivation{SceneID: 42})
+		assert.ErrorIs(t, err, usecase.ErrSceneNotFound)
+
+		require.NoError(t, r.DeleteScene(ctx, night.ID))
+		activations, err = r.GetActivations(ctx, night.ID, 10)
+		require.NoError(t, err)
+		assert.Empty(t, activations)
+	})
+}
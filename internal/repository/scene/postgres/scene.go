@@ -0,0 +1,300 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+type SceneRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSceneRepository(pool *pgxpool.Pool) *SceneRepository {
+	return &SceneRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/scene/postgres")
+
+// uniqueViolation, foreignKeyViolation - коды ошибок postgres при нарушении уникальности
+// и при ссылке на несуществующую запись
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+const (
+	sceneColumns     = `id, name, description, targets, created_at, updated_at`
+	insertSceneQuery = `INSERT INTO scenes (name, description, targets, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5) RETURNING id;`
+	updateSceneQuery = `UPDATE scenes SET name = $2, description = $3, targets = $4, updated_at = $5 WHERE id = $1;`
+	getSceneQuery    = `SELECT ` + sceneColumns + ` FROM scenes WHERE id = $1;`
+	getScenesQuery   = `SELECT ` + sceneColumns + ` FROM scenes ORDER BY id;`
+	deleteSceneQuery = `DELETE FROM scenes WHERE id = $1;`
+
+	insertActivationQuery = `INSERT INTO scene_activations (scene_id, status, activated_at, results)
+VALUES ($1, $2, $3, $4) RETURNING id;`
+	getActivationsQuery = `SELECT id, scene_id, status, activated_at, results FROM scene_activations
+WHERE scene_id = $1 ORDER BY id DESC LIMIT $2;`
+)
+
+// sceneTarget, sceneTargetResult - представление настроек и результатов сцены в jsonb
+type sceneTarget struct {
+	SensorID    int64   `json:"sensor_id"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+	Description *string `json:"description,omitempty"`
+	State       *int64  `json:"state,omitempty"`
+}
+
+type sceneTargetResult struct {
+	SensorID  int64  `json:"sensor_id"`
+	Error     string `json:"error,omitempty"`
+	CommandID int64  `json:"command_id,omitempty"`
+}
+
+func marshalTargets(targets []domain.SceneTarget) ([]byte, error) {
+	rows := make([]sceneTarget, 0, len(targets))
+	for _, t := range targets {
+		rows = append(rows, sceneTarget(t))
+	}
+	return json.Marshal(rows)
+}
+
+func unmarshalTargets(data []byte) ([]domain.SceneTarget, error) {
+	var rows []sceneTarget
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	targets := make([]domain.SceneTarget, 0, len(rows))
+	for _, t := range rows {
+		targets = append(targets, domain.SceneTarget(t))
+	}
+	return targets, nil
+}
+
+func (r *SceneRepository) SaveScene(ctx context.Context, scene *domain.Scene) (err error) {
+	query := updateSceneQuery
+	if scene != nil && scene.ID == 0 {
+		query = insertSceneQuery
+	}
+	ctx, span := tracing.StartQuery(ctx, tracer, "SceneRepository.SaveScene", query)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if scene == nil {
+		return errors.New("scene is nil")
+	}
+
+	targets, err := marshalTargets(scene.Targets)
+	if err != nil {
+		return fmt.Errorf("can't encode scene targets: %w", err)
+	}
+
+	if scene.ID == 0 {
+		err = r.pool.QueryRow(ctx, insertSceneQuery,
+			scene.Name,
+			scene.Description,
+			targets,
+			scene.CreatedAt,
+			scene.UpdatedAt,
+		).Scan(&scene.ID)
+		if isViolation(err, uniqueViolation) {
+			return usecase.ErrSceneExists
+		}
+		if err != nil {
+			return fmt.Errorf("can't insert scene: %w", err)
+		}
+		return nil
+	}
+
+	tag, err := r.pool.Exec(ctx, updateSceneQuery,
+		scene.ID,
+		scene.Name,
+		scene.Description,
+		targets,
+		scene.UpdatedAt,
+	)
+	if isViolation(err, uniqueViolation) {
+		return usecase.ErrSceneExists
+	}
+	if err != nil {
+		return fmt.Errorf("can't update scene: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrSceneNotFound
+	}
+	return nil
+}
+
+func isViolation(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+func (r *SceneRepository) GetSceneByID(ctx context.Context, id int64) (_ *domain.Scene, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SceneRepository.GetSceneByID", getSceneQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var scene domain.Scene
+	err = scanScene(r.pool.QueryRow(ctx, getSceneQuery, id), &scene)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrSceneNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get scene: %w", err)
+	}
+	return &scene, nil
+}
+
+func (r *SceneRepository) GetScenes(ctx context.Context) (_ []domain.Scene, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SceneRepository.GetScenes", getScenesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := r.pool.Query(ctx, getScenesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't get scenes: %w", err)
+	}
+	defer rows.Close()
+
+	scenes := make([]domain.Scene, 0)
+	for rows.Next() {
+		var scene domain.Scene
+		if err := scanScene(rows, &scene); err != nil {
+			return nil, fmt.Errorf("can't scan scene: %w", err)
+		}
+		scenes = append(scenes, scene)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get scenes: %w", err)
+	}
+	return scenes, nil
+}
+
+func (r *SceneRepository) DeleteScene(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SceneRepository.DeleteScene", deleteSceneQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	tag, err := r.pool.Exec(ctx, deleteSceneQuery, id)
+	if err != nil {
+		return fmt.Errorf("can't delete scene: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrSceneNotFound
+	}
+	return nil
+}
+
+func (r *SceneRepository) SaveActivation(ctx context.Context, activation *domain.SceneActivation) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SceneRepository.SaveActivation", insertActivationQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if activation == nil {
+		return errors.New("activation is nil")
+	}
+
+	rows := make([]sceneTargetResult, 0, len(activation.Results))
+	for _, result := range activation.Results {
+		rows = append(rows, sceneTargetResult(result))
+	}
+	results, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("can't encode activation results: %w", err)
+	}
+
+	err = r.pool.QueryRow(ctx, insertActivationQuery,
+		activation.SceneID,
+		activation.Status,
+		activation.ActivatedAt,
+		results,
+	).Scan(&activation.ID)
+	if isViolation(err, foreignKeyViolation) {
+		return usecase.ErrSceneNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("can't insert activation: %w", err)
+	}
+	return nil
+}
+
+func (r *SceneRepository) GetActivations(ctx context.Context, sceneID int64, limit int) (_ []domain.SceneActivation, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SceneRepository.GetActivations", getActivationsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := r.pool.Query(ctx, getActivationsQuery, sceneID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't get activations: %w", err)
+	}
+	defer rows.Close()
+
+	activations := make([]domain.SceneActivation, 0)
+	for rows.Next() {
+		var (
+			activation domain.SceneActivation
+			results    []byte
+		)
+		if err := rows.Scan(&activation.ID, &activation.SceneID, &activation.Status, &activation.ActivatedAt, &results); err != nil {
+			return nil, fmt.Errorf("can't scan activation: %w", err)
+		}
+
+		var decoded []sceneTargetResult
+		if err := json.Unmarshal(results, &decoded); err != nil {
+			return nil, fmt.Errorf("can't decode activation results: %w", err)
+		}
+		activation.Results = make([]domain.SceneTargetResult, 0, len(decoded))
+		for _, result := range decoded {
+			activation.Results = append(activation.Results, domain.SceneTargetResult(result))
+		}
+
+		activations = append(activations, activation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get activations: %w", err)
+	}
+	return activations, nil
+}
+
+func scanScene(row pgx.Row, scene *domain.Scene) error {
+	var targets []byte
+	err := row.Scan(&scene.ID, &scene.Name, &scene.Description, &targets, &scene.CreatedAt, &scene.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	scene.Targets, err = unmarshalTargets(targets)
+	if err != nil {
+		return fmt.Errorf("can't decode scene targets: %w", err)
+	}
+	return nil
+}
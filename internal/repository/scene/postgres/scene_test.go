@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SceneTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *SceneRepository
+}
+
+func (suite *SceneTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewSceneRepository(suite.testDbInstance)
+}
+
+func (suite *SceneTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *SceneTestSuite) newScene(name string, now time.Time) *domain.Scene {
+	active, state := false, int64(1)
+	return &domain.Scene{
+		Name: name,
+		Targets: []domain.SceneTarget{
+			{SensorID: 1, IsActive: &active},
+			{SensorID: 2, State: &state},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (suite *SceneTestSuite) TestSceneRepository_SaveScene() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetSceneByID(ctx, 1000)
+	assert.ErrorIs(suite.T(), err, usecase.ErrSceneNotFound)
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	scene := suite.newScene("night", now)
+	assert.Nil(suite.T(), suite.repo.SaveScene(ctx, scene))
+	assert.NotZero(suite.T(), scene.ID)
+
+	scene.Description = "lights off"
+	scene.UpdatedAt = now.Add(time.Second)
+	assert.Nil(suite.T(), suite.repo.SaveScene(ctx, scene))
+
+	actual, err := suite.repo.GetSceneByID(ctx, scene.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), scene, actual)
+
+	assert.ErrorIs(suite.T(), suite.repo.SaveScene(ctx, suite.newScene("night", now)), usecase.ErrSceneExists)
+
+	scenes, err := suite.repo.GetScenes(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Contains(suite.T(), scenes, *scene)
+}
+
+func (suite *SceneTestSuite) TestSceneRepository_Activations() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	scene := suite.newScene("away", now)
+	assert.Nil(suite.T(), suite.repo.SaveScene(ctx, scene))
+
+	first := &domain.SceneActivation{
+		SceneID:     scene.ID,
+		Status:      domain.SceneActivationApplied,
+		ActivatedAt: now,
+		Results:     []domain.SceneTargetResult{{SensorID: 1}, {SensorID: 2, CommandID: 5}},
+	}
+	second := &domain.SceneActivation{
+		SceneID:     scene.ID,
+		Status:      domain.SceneActivationPartial,
+		ActivatedAt: now.Add(time.Second),
+		Results:     []domain.SceneTargetResult{{SensorID: 1}, {SensorID: 2, Error: "sensor not found"}},
+	}
+	assert.Nil(suite.T(), suite.repo.SaveActivation(ctx, first))
+	assert.Nil(suite.T(), suite.repo.SaveActivation(ctx, second))
+
+	activations, err := suite.repo.GetActivations(ctx, scene.ID, 10)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.SceneActivation{*second, *first}, activations)
+
+	err = suite.repo.SaveActivation(ctx, &domain.SceneActivation{SceneID: 1000, Status: domain.SceneActivationFailed, ActivatedAt: now})
+	assert.ErrorIs(suite.T(), err, usecase.ErrSceneNotFound)
+
+	assert.Nil(suite.T(), suite.repo.DeleteScene(ctx, scene.ID))
+	assert.ErrorIs(suite.T(), suite.repo.DeleteScene(ctx, scene.ID), usecase.ErrSceneNotFound)
+
+	activations, err = suite.repo.GetActivations(ctx, scene.ID, 10)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), activations)
+}
+
+func TestSceneTestSuite(t *testing.T) {
+	suite.Run(t, new(SceneTestSuite))
+}
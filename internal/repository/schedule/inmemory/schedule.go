@@ -0,0 +1,197 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+	"time"
+)
+
+type ScheduleRepository struct {
+	mu        sync.Mutex
+	lastID    int64
+	lastRunID int64
+	schedules map[int64]domain.Schedule
+	runs      map[int64][]domain.ScheduleRun
+}
+
+func NewScheduleRepository() *ScheduleRepository {
+	return &ScheduleRepository{
+		schedules: make(map[int64]domain.Schedule),
+		runs:      make(map[int64][]domain.ScheduleRun),
+	}
+}
+
+// clone - копия расписания, не разделяющая с ним срезы
+func clone(schedule domain.Schedule) domain.Schedule {
+	schedule.Action.SensorIDs = slices.Clone(schedule.Action.SensorIDs)
+	return schedule
+}
+
+func (r *ScheduleRepository) SaveSchedule(ctx context.Context, schedule *domain.Schedule) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if schedule == nil {
+		return errors.New("schedule is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schedule.ID != 0 {
+		if _, ok := r.schedules[schedule.ID]; !ok {
+			return usecase.ErrScheduleNotFound
+		}
+	}
+	for _, s := range r.schedules {
+		if s.Name == schedule.Name && s.ID != schedule.ID {
+			return usecase.ErrScheduleExists
+		}
+	}
+
+	if schedule.ID == 0 {
+		r.lastID++
+		schedule.ID = r.lastID
+	}
+	r.schedules[schedule.ID] = clone(*schedule)
+
+	return nil
+}
+
+func (r *ScheduleRepository) GetScheduleByID(ctx context.Context, id int64) (*domain.Schedule, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return nil, usecase.ErrScheduleNotFound
+	}
+	schedule = clone(schedule)
+	return &schedule, nil
+}
+
+func (r *ScheduleRepository) GetSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedules := make([]domain.Schedule, 0, len(r.schedules))
+	for _, schedule := range r.schedules {
+		schedules = append(schedules, clone(schedule))
+	}
+	slices.SortFunc(schedules, func(a, b domain.Schedule) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return schedules, nil
+}
+
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.schedules[id]; !ok {
+		return usecase.ErrScheduleNotFound
+	}
+	delete(r.schedules, id)
+	delete(r.runs, id)
+
+	return nil
+}
+
+func (r *ScheduleRepository) GetDueSchedules(ctx context.Context, now time.Time) ([]domain.Schedule, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedules := make([]domain.Schedule, 0)
+	for _, schedule := range r.schedules {
+		if schedule.Enabled && !schedule.NextRunAt.IsZero() && !schedule.NextRunAt.After(now) {
+			schedules = append(schedules, clone(schedule))
+		}
+	}
+	slices.SortFunc(schedules, func(a, b domain.Schedule) int {
+		if c := a.NextRunAt.Compare(b.NextRunAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return schedules, nil
+}
+
+func (r *ScheduleRepository) ClaimSchedule(ctx context.Context, id int64, scheduledAt, next time.Time) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedule, ok := r.schedules[id]
+	if !ok || !schedule.NextRunAt.Equal(scheduledAt) {
+		return false, nil
+	}
+	schedule.NextRunAt = next
+	r.schedules[id] = schedule
+
+	return true, nil
+}
+
+func (r *ScheduleRepository) SaveRun(ctx context.Context, run *domain.ScheduleRun) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if run == nil {
+		return errors.New("run is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.schedules[run.ScheduleID]; !ok {
+		return usecase.ErrScheduleNotFound
+	}
+
+	r.lastRunID++
+	run.ID = r.lastRunID
+	r.runs[run.ScheduleID] = append(r.runs[run.ScheduleID], *run)
+
+	return nil
+}
+
+func (r *ScheduleRepository) GetRuns(ctx context.Context, scheduleID int64, limit int) ([]domain.ScheduleRun, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.runs[scheduleID]
+	runs := make([]domain.ScheduleRun, 0, min(limit, len(stored)))
+	for i := len(stored) - 1; i >= 0 && len(runs) < limit; i-- {
+		runs = append(runs, stored[i])
+	}
+
+	return runs, nil
+}
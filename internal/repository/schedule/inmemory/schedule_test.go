@@ -0,0 +1,101 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleRepository(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	newSchedule := func(name string, next time.Time) *domain.Schedule {
+		return &domain.Schedule{
+			Name:       name,
+			Cron:       "0 8 * * *",
+			Timezone:   "UTC",
+			Action:     domain.ScheduleAction{Kind: domain.ScheduleHTTP, URL: "http://localhost/hook", SensorIDs: []int64{1}},
+			MissedRuns: domain.MissedRunOnce,
+			Enabled:    !next.IsZero(),
+			NextRunAt:  next,
+		}
+	}
+
+	t.Run("ok, save, get and delete", func(t *testing.T) {
+		r := NewScheduleRepository()
+		ctx := context.Background()
+
+		morning := newSchedule("morning", now)
+		require.NoError(t, r.SaveSchedule(ctx, morning))
+		assert.Equal(t, int64(1), morning.ID)
+
+		stored, err := r.GetScheduleByID(ctx, morning.ID)
+		require.NoError(t, err)
+		assert.Equal(t, morning, stored)
+
+		assert.ErrorIs(t, r.SaveSchedule(ctx, newSchedule("morning", now)), usecase.ErrScheduleExists)
+
+		schedules, err := r.GetSchedules(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Schedule{*morning}, schedules)
+
+		require.NoError(t, r.DeleteSchedule(ctx, morning.ID))
+		_, err = r.GetScheduleByID(ctx, morning.ID)
+		assert.ErrorIs(t, err, usecase.ErrScheduleNotFound)
+	})
+
+	t.Run("ok, due and claim", func(t *testing.T) {
+		r := NewScheduleRepository()
+		ctx := context.Background()
+
+		later, earlier, disabled, future := newSchedule("later", now),
+			newSchedule("earlier", now.Add(-time.Hour)),
+			newSchedule("disabled", time.Time{}),
+			newSchedule("future", now.Add(time.Hour))
+		for _, s := range []*domain.Schedule{later, earlier, disabled, future} {
+			require.NoError(t, r.SaveSchedule(ctx, s))
+		}
+
+		due, err := r.GetDueSchedules(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, due, 2)
+		assert.Equal(t, []int64{earlier.ID, later.ID}, []int64{due[0].ID, due[1].ID})
+
+		claimed, err := r.ClaimSchedule(ctx, earlier.ID, earlier.NextRunAt, now.Add(23*time.Hour))
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = r.ClaimSchedule(ctx, earlier.ID, earlier.NextRunAt, now.Add(23*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		due, err = r.GetDueSchedules(ctx, now)
+		require.NoError(t, err)
+		assert.Len(t, due, 1)
+	})
+
+	t.Run("ok, runs newest first", func(t *testing.T) {
+		r := NewScheduleRepository()
+		ctx := context.Background()
+
+		morning := newSchedule("morning", now)
+		require.NoError(t, r.SaveSchedule(ctx, morning))
+
+		for i := 0; i < 3; i++ {
+			run := &domain.ScheduleRun{ScheduleID: morning.ID, ScheduledAt: now, StartedAt: now, Status: domain.ScheduleRunSucceeded}
+			require.NoError(t, r.SaveRun(ctx, run))
+		}
+
+		runs, err := r.GetRuns(ctx, morning.ID, 2)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, []int64{3, 2}, []int64{runs[0].ID, runs[1].ID})
+
+		assert.ErrorIs(t, r.SaveRun(ctx, &domain.ScheduleRun{ScheduleID: 42}), usecase.ErrScheduleNotFound)
+	})
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/logging"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// defaultLeaderLockKey - ключ advisory блокировки, которую держит ведущая реплика планировщика
+	defaultLeaderLockKey  int64 = 0x736368656475 // "schedu"
+	defaultLeaderInterval       = 10 * time.Second
+
+	tryLockQuery = `SELECT pg_try_advisory_lock($1);`
+)
+
+// Leader - выбор ведущей реплики через сессионную advisory блокировку postgres. Блокировку держит
+// отдельное соединение из пула; если оно рвётся, postgres снимает блокировку сам, и ведущей
+// становится другая реплика.
+type Leader struct {
+	pool     *pgxpool.Pool
+	key      int64
+	interval time.Duration
+}
+
+func NewLeader(pool *pgxpool.Pool, options ...func(*Leader)) *Leader {
+	l := &Leader{
+		pool:     pool,
+		key:      defaultLeaderLockKey,
+		interval: defaultLeaderInterval,
+	}
+	for _, o := range options {
+		o(l)
+	}
+	return l
+}
+
+// WithLeaderLockKey - ключ advisory блокировки; реплики с разными ключами не мешают друг другу
+func WithLeaderLockKey(key int64) func(*Leader) {
+	return func(l *Leader) {
+		l.key = key
+	}
+}
+
+// WithLeaderInterval - как часто пытаться захватить блокировку и проверять соединение, которое её держит
+func WithLeaderInterval(interval time.Duration) func(*Leader) {
+	return func(l *Leader) {
+		l.interval = interval
+	}
+}
+
+func (l *Leader) Lead(ctx context.Context, fn func(ctx context.Context)) error {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.lead(ctx, fn); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).WarnContext(ctx, "leader election failed", logging.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead - пытается захватить блокировку и, если получилось, вызывает fn, пока соединение живо
+func (l *Leader) lead(ctx context.Context, fn func(ctx context.Context)) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, tryLockQuery, l.key).Scan(&locked); err != nil {
+		conn.Release()
+		return err
+	}
+	if !locked {
+		conn.Release()
+		return nil
+	}
+
+	// соединение с блокировкой не возвращается в пул: закрытие снимает блокировку, даже если
+	// соединение уже неисправно
+	pgConn := conn.Hijack()
+	defer func() { _ = pgConn.Close(context.Background()) }()
+
+	logger := logging.FromContext(ctx)
+	logger.InfoContext(ctx, "became leader")
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(leadCtx)
+	}()
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return nil
+		case <-done:
+			return nil
+		case <-ticker.C:
+			if err = pgConn.Ping(ctx); err != nil {
+				logger.WarnContext(ctx, "leadership lost", logging.Error(err))
+				cancel()
+				<-done
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+type ScheduleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewScheduleRepository(pool *pgxpool.Pool) *ScheduleRepository {
+	return &ScheduleRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/schedule/postgres")
+
+// uniqueViolation, foreignKeyViolation - коды ошибок postgres при нарушении уникальности
+// и при ссылке на несуществующую запись
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+const (
+	scheduleColumns     = `id, name, cron, timezone, action, missed_runs, enabled, next_run_at, created_at, updated_at`
+	insertScheduleQuery = `INSERT INTO schedules (name, cron, timezone, action, missed_runs, enabled, next_run_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id;`
+	updateScheduleQuery = `UPDATE schedules SET name = $2, cron = $3, timezone = $4, action = $5, missed_runs = $6,
+enabled = $7, next_run_at = $8, updated_at = $9 WHERE id = $1;`
+	getScheduleQuery     = `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = $1;`
+	getSchedulesQuery    = `SELECT ` + scheduleColumns + ` FROM schedules ORDER BY id;`
+	deleteScheduleQuery  = `DELETE FROM schedules WHERE id = $1;`
+	getDueSchedulesQuery = `SELECT ` + scheduleColumns + ` FROM schedules
+WHERE enabled AND next_run_at <= $1 ORDER BY next_run_at, id;`
+	claimScheduleQuery = `UPDATE schedules SET next_run_at = $3 WHERE id = $1 AND next_run_at = $2;`
+
+	insertRunQuery = `INSERT INTO schedule_runs (schedule_id, scheduled_at, started_at, status, error)
+VALUES ($1, $2, $3, $4, $5) RETURNING id;`
+	getRunsQuery = `SELECT id, schedule_id, scheduled_at, started_at, status, error FROM schedule_runs
+WHERE schedule_id = $1 ORDER BY id DESC LIMIT $2;`
+)
+
+// scheduleAction - представление действия расписания в jsonb
+type scheduleAction struct {
+	Kind        domain.ScheduleActionKind `json:"kind"`
+	SensorIDs   []int64                   `json:"sensor_ids,omitempty"`
+	IsActive    *bool                     `json:"is_active,omitempty"`
+	Description *string                   `json:"description,omitempty"`
+	SceneID     int64                     `json:"scene_id,omitempty"`
+	CommandKind domain.CommandKind        `json:"command_kind,omitempty"`
+	State       int64                     `json:"state,omitempty"`
+	URL         string                    `json:"url,omitempty"`
+}
+
+func (r *ScheduleRepository) SaveSchedule(ctx context.Context, schedule *domain.Schedule) (err error) {
+	query := updateScheduleQuery
+	if schedule != nil && schedule.ID == 0 {
+		query = insertScheduleQuery
+	}
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.SaveSchedule", query)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if schedule == nil {
+		return errors.New("schedule is nil")
+	}
+
+	action, err := json.Marshal(scheduleAction(schedule.Action))
+	if err != nil {
+		return fmt.Errorf("can't encode schedule action: %w", err)
+	}
+
+	if schedule.ID == 0 {
+		err = r.pool.QueryRow(ctx, insertScheduleQuery,
+			schedule.Name,
+			schedule.Cron,
+			schedule.Timezone,
+			action,
+			schedule.MissedRuns,
+			schedule.Enabled,
+			nullTime(schedule.NextRunAt),
+			schedule.CreatedAt,
+			schedule.UpdatedAt,
+		).Scan(&schedule.ID)
+		if isViolation(err, uniqueViolation) {
+			return usecase.ErrScheduleExists
+		}
+		if err != nil {
+			return fmt.Errorf("can't insert schedule: %w", err)
+		}
+		return nil
+	}
+
+	tag, err := r.pool.Exec(ctx, updateScheduleQuery,
+		schedule.ID,
+		schedule.Name,
+		schedule.Cron,
+		schedule.Timezone,
+		action,
+		schedule.MissedRuns,
+		schedule.Enabled,
+		nullTime(schedule.NextRunAt),
+		schedule.UpdatedAt,
+	)
+	if isViolation(err, uniqueViolation) {
+		return usecase.ErrScheduleExists
+	}
+	if err != nil {
+		return fmt.Errorf("can't update schedule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrScheduleNotFound
+	}
+	return nil
+}
+
+func (r *ScheduleRepository) GetScheduleByID(ctx context.Context, id int64) (_ *domain.Schedule, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.GetScheduleByID", getScheduleQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var schedule domain.Schedule
+	err = scanSchedule(r.pool.QueryRow(ctx, getScheduleQuery, id), &schedule)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+func (r *ScheduleRepository) GetSchedules(ctx context.Context) (_ []domain.Schedule, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.GetSchedules", getSchedulesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.query(ctx, getSchedulesQuery)
+}
+
+func (r *ScheduleRepository) GetDueSchedules(ctx context.Context, now time.Time) (_ []domain.Schedule, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.GetDueSchedules", getDueSchedulesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.query(ctx, getDueSchedulesQuery, now)
+}
+
+func (r *ScheduleRepository) query(ctx context.Context, query string, args ...any) ([]domain.Schedule, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't get schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]domain.Schedule, 0)
+	for rows.Next() {
+		var schedule domain.Schedule
+		if err := scanSchedule(rows, &schedule); err != nil {
+			return nil, fmt.Errorf("can't scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.DeleteSchedule", deleteScheduleQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	tag, err := r.pool.Exec(ctx, deleteScheduleQuery, id)
+	if err != nil {
+		return fmt.Errorf("can't delete schedule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrScheduleNotFound
+	}
+	return nil
+}
+
+func (r *ScheduleRepository) ClaimSchedule(ctx context.Context, id int64, scheduledAt, next time.Time) (_ bool, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.ClaimSchedule", claimScheduleQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	tag, err := r.pool.Exec(ctx, claimScheduleQuery, id, scheduledAt, nullTime(next))
+	if err != nil {
+		return false, fmt.Errorf("can't claim schedule: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *ScheduleRepository) SaveRun(ctx context.Context, run *domain.ScheduleRun) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.SaveRun", insertRunQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if run == nil {
+		return errors.New("run is nil")
+	}
+
+	err = r.pool.QueryRow(ctx, insertRunQuery,
+		run.ScheduleID,
+		run.ScheduledAt,
+		run.StartedAt,
+		run.Status,
+		run.Error,
+	).Scan(&run.ID)
+	if isViolation(err, foreignKeyViolation) {
+		return usecase.ErrScheduleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("can't insert schedule run: %w", err)
+	}
+	return nil
+}
+
+func (r *ScheduleRepository) GetRuns(ctx context.Context, scheduleID int64, limit int) (_ []domain.ScheduleRun, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "ScheduleRepository.GetRuns", getRunsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := r.pool.Query(ctx, getRunsQuery, scheduleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't get schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]domain.ScheduleRun, 0)
+	for rows.Next() {
+		var run domain.ScheduleRun
+		if err := rows.Scan(&run.ID, &run.ScheduleID, &run.ScheduledAt, &run.StartedAt, &run.Status, &run.Error); err != nil {
+			return nil, fmt.Errorf("can't scan schedule run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get schedule runs: %w", err)
+	}
+	return runs, nil
+}
+
+func scanSchedule(row pgx.Row, schedule *domain.Schedule) error {
+	var (
+		action    []byte
+		nextRunAt *time.Time
+	)
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.Name,
+		&schedule.Cron,
+		&schedule.Timezone,
+		&action,
+		&schedule.MissedRuns,
+		&schedule.Enabled,
+		&nextRunAt,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	var decoded scheduleAction
+	if err := json.Unmarshal(action, &decoded); err != nil {
+		return fmt.Errorf("can't decode schedule action: %w", err)
+	}
+	schedule.Action = domain.ScheduleAction(decoded)
+
+	if nextRunAt != nil {
+		schedule.NextRunAt = *nextRunAt
+	}
+	return nil
+}
+
+func isViolation(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// nullTime - нулевое время сохраняется как NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
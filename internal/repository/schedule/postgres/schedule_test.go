@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ScheduleTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *ScheduleRepository
+}
+
+func (suite *ScheduleTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewScheduleRepository(suite.testDbInstance)
+}
+
+func (suite *ScheduleTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *ScheduleTestSuite) newSchedule(name string, now, next time.Time) *domain.Schedule {
+	active := false
+	return &domain.Schedule{
+		Name:     name,
+		Cron:     "0 8 * * MON-FRI",
+		Timezone: "Europe/Moscow",
+		Action: domain.ScheduleAction{
+			Kind:      domain.ScheduleUpdateSensors,
+			SensorIDs: []int64{1, 2},
+			IsActive:  &active,
+		},
+		MissedRuns: domain.MissedRunSkip,
+		Enabled:    true,
+		NextRunAt:  next,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func (suite *ScheduleTestSuite) TestScheduleRepository_SaveSchedule() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetScheduleByID(ctx, 1000)
+	assert.ErrorIs(suite.T(), err, usecase.ErrScheduleNotFound)
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	schedule := suite.newSchedule("garage", now, now.Add(time.Hour))
+	assert.Nil(suite.T(), suite.repo.SaveSchedule(ctx, schedule))
+	assert.NotZero(suite.T(), schedule.ID)
+
+	schedule.Enabled = false
+	schedule.NextRunAt = time.Time{}
+	assert.Nil(suite.T(), suite.repo.SaveSchedule(ctx, schedule))
+
+	actual, err := suite.repo.GetScheduleByID(ctx, schedule.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), schedule, actual)
+
+	assert.ErrorIs(suite.T(), suite.repo.SaveSchedule(ctx, suite.newSchedule("garage", now, now)), usecase.ErrScheduleExists)
+}
+
+func (suite *ScheduleTestSuite) TestScheduleRepository_DueAndRuns() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	schedule := suite.newSchedule("greenhouse", now, now.Add(-time.Minute))
+	assert.Nil(suite.T(), suite.repo.SaveSchedule(ctx, schedule))
+
+	due, err := suite.repo.GetDueSchedules(ctx, now)
+	assert.Nil(suite.T(), err)
+	assert.Contains(suite.T(), due, *schedule)
+
+	claimed, err := suite.repo.ClaimSchedule(ctx, schedule.ID, schedule.NextRunAt, now.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), claimed)
+
+	claimed, err = suite.repo.ClaimSchedule(ctx, schedule.ID, schedule.NextRunAt, now.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), claimed)
+
+	run := &domain.ScheduleRun{
+		ScheduleID:  schedule.ID,
+		ScheduledAt: schedule.NextRunAt,
+		StartedAt:   now,
+		Status:      domain.ScheduleRunFailed,
+		Error:       "sensor 2: sensor not found",
+	}
+	assert.Nil(suite.T(), suite.repo.SaveRun(ctx, run))
+
+	runs, err := suite.repo.GetRuns(ctx, schedule.ID, 10)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.ScheduleRun{*run}, runs)
+
+	assert.Nil(suite.T(), suite.repo.DeleteSchedule(ctx, schedule.ID))
+	assert.ErrorIs(suite.T(), suite.repo.SaveRun(ctx, run), usecase.ErrScheduleNotFound)
+}
+
+func (suite *ScheduleTestSuite) TestLeader() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var leaders, runs atomic.Int32
+	fn := func(ctx context.Context) {
+		leaders.Add(1)
+		runs.Add(1)
+		<-ctx.Done()
+		leaders.Add(-1)
+	}
+
+	for i := 0; i < 3; i++ {
+		leader := NewLeader(suite.testDbInstance, WithLeaderInterval(50*time.Millisecond))
+		go func() { _ = leader.Lead(ctx, fn) }()
+	}
+
+	assert.Eventually(suite.T(), func() bool { return runs.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Never(suite.T(), func() bool { return leaders.Load() > 1 }, 500*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/transaction"
+	"homework/internal/usecase"
+	"sync"
+	"time"
+)
+
+const defaultTTL = 5 * time.Second
+
+// SensorRepository - кэш датчиков в памяти процесса поверх другого репозитория.
+// Записи живут не дольше ttl и сбрасываются при каждом SaveSensor через этот репозиторий,
+// поэтому изменения, сделанные в обход него (другими экземплярами сервиса, загрузкой),
+// становятся видны не позже чем через ttl.
+//
+// Внутри единицы работы кэш не используется: чтения идут в репозиторий и не кэшируются, потому что
+// видят незафиксированные изменения, а сохранённый датчик сбрасывается ещё раз после фиксации
+// или отката, чтобы в кэш не попала версия, прочитанная до завершения транзакции.
+type SensorRepository struct {
+	sr  usecase.SensorRepository
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	byID map[int64]entry
+	bySN map[string]int64
+	all  *listEntry
+	// generation - увеличивается при каждом сбросе, чтобы чтение, начатое до сохранения,
+	// не положило в кэш устаревший датчик
+	generation uint64
+}
+
+type entry struct {
+	sensor  domain.Sensor
+	expires time.Time
+}
+
+type listEntry struct {
+	sensors []domain.Sensor
+	expires time.Time
+}
+
+func NewSensorRepository(sr usecase.SensorRepository, options ...func(*SensorRepository)) *SensorRepository {
+	r := &SensorRepository{
+		sr:   sr,
+		ttl:  defaultTTL,
+		now:  time.Now,
+		byID: make(map[int64]entry),
+		bySN: make(map[string]int64),
+	}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// WithTTL - сколько датчик хранится в кэше
+func WithTTL(ttl time.Duration) func(*SensorRepository) {
+	return func(r *SensorRepository) {
+		r.ttl = ttl
+	}
+}
+
+// WithClock - источник текущего времени
+func WithClock(now func() time.Time) func(*SensorRepository) {
+	return func(r *SensorRepository) {
+		r.now = now
+	}
+}
+
+// SaveSensor - сохраняет датчик и сбрасывает его в кэше, в том числе при ErrSensorVersionConflict:
+// конфликт означает, что в кэше устаревшая версия.
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	err := r.sr.SaveSensor(ctx, sensor)
+	if sensor != nil {
+		id, sn := sensor.ID, sensor.SerialNumber
+		r.invalidate(id, sn)
+		transaction.AfterEnd(ctx, func() { r.invalidate(id, sn) })
+	}
+	return err
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	if transaction.InUnit(ctx) {
+		return r.sr.GetSensors(ctx)
+	}
+
+	r.mu.Lock()
+	if r.all != nil && r.now().Before(r.all.expires) {
+		sensors := cloneSensors(r.all.sensors)
+		r.mu.Unlock()
+		return sensors, nil
+	}
+	generation := r.generation
+	r.mu.Unlock()
+
+	sensors, err := r.sr.GetSensors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation == r.generation {
+		r.all = &listEntry{
+			sensors: cloneSensors(sensors),
+			expires: r.now().Add(r.ttl),
+		}
+	}
+
+	return sensors, nil
+}
+
+// ListSensors - выборки с условиями не кэшируются
+func (r *SensorRepository) ListSensors(ctx context.Context, query domain.SensorQuery) ([]domain.Sensor, error) {
+	return r.sr.ListSensors(ctx, query)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	if transaction.InUnit(ctx) {
+		return r.sr.GetSensorByID(ctx, id)
+	}
+	if sensor, ok := r.get(id); ok {
+		return sensor, nil
+	}
+
+	generation := r.currentGeneration()
+	sensor, err := r.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.put(generation, sensor)
+
+	return sensor, nil
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	if transaction.InUnit(ctx) {
+		return r.sr.GetSensorBySerialNumber(ctx, sn)
+	}
+
+	r.mu.Lock()
+	id, ok := r.bySN[sn]
+	r.mu.Unlock()
+	if ok {
+		if sensor, ok := r.get(id); ok {
+			return sensor, nil
+		}
+	}
+
+	generation := r.currentGeneration()
+	sensor, err := r.sr.GetSensorBySerialNumber(ctx, sn)
+	if err != nil {
+		return nil, err
+	}
+	r.put(generation, sensor)
+
+	return sensor, nil
+}
+
+func (r *SensorRepository) get(id int64) (*domain.Sensor, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.byID[id]
+	if !ok {
+		return nil, false
+	}
+	if !r.now().Before(e.expires) {
+		delete(r.byID, id)
+		delete(r.bySN, e.sensor.SerialNumber)
+		return nil, false
+	}
+
+	sensor := clone(e.sensor)
+	return &sensor, true
+}
+
+func (r *SensorRepository) currentGeneration() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.generation
+}
+
+// put - кладёт копию датчика в кэш, если с начала чтения он не сбрасывался
+func (r *SensorRepository) put(generation uint64, sensor *domain.Sensor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if generation != r.generation {
+		return
+	}
+	r.byID[sensor.ID] = entry{sensor: clone(*sensor), expires: r.now().Add(r.ttl)}
+	r.bySN[sensor.SerialNumber] = sensor.ID
+}
+
+// clone - копия датчика, не разделяющая с ним калибровку, чтобы изменения вызывающего не попадали в кэш
+func clone(sensor domain.Sensor) domain.Sensor {
+	sensor.Calibration = sensor.Calibration.Clone()
+	return sensor
+}
+
+func cloneSensors(sensors []domain.Sensor) []domain.Sensor {
+	out := make([]domain.Sensor, len(sensors))
+	for i, sensor := range sensors {
+		out[i] = clone(sensor)
+	}
+	return out
+}
+
+func (r *SensorRepository) invalidate(id int64, sn string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.all = nil
+	if e, ok := r.byID[id]; ok {
+		delete(r.bySN, e.sensor.SerialNumber)
+		delete(r.byID, id)
+	}
+	delete(r.bySN, sn)
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/repository/transaction"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	sensor := &domain.Sensor{ID: 1, SerialNumber: "1234567890", Version: 1}
+
+	t.Run("ok, repeated reads are served from cache", func(t *testing.T) {
+		sr := usecase.NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(sensor, nil)
+		sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{*sensor}, nil)
+
+		r := NewSensorRepository(sr)
+		for i := 0; i < 3; i++ {
+			actual, err := r.GetSensorByID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, sensor, actual)
+
+			actual, err = r.GetSensorBySerialNumber(ctx, "1234567890")
+			require.NoError(t, err)
+			assert.Equal(t, sensor, actual)
+
+			sensors, err := r.GetSensors(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []domain.Sensor{*sensor}, sensors)
+		}
+	})
+
+	t.Run("ok, cached sensor is a copy", func(t *testing.T) {
+		sr := usecase.NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Version: 1}, nil)
+
+		r := NewSensorRepository(sr)
+		actual, err := r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		actual.Description = "changed"
+
+		actual, err = r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Empty(t, actual.Description)
+	})
+
+	t.Run("ok, cached calibration is a copy", func(t *testing.T) {
+		calibrated := domain.Sensor{
+			ID:          1,
+			Version:     1,
+			Calibration: &domain.Calibration{Kind: domain.CalibrationPolynomial, Coefficients: []float64{1, 2}},
+		}
+		sr := usecase.NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).DoAndReturn(func(context.Context, int64) (*domain.Sensor, error) {
+			sensor := calibrated
+			sensor.Calibration = calibrated.Calibration.Clone()
+			return &sensor, nil
+		})
+		sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{calibrated}, nil)
+
+		r := NewSensorRepository(sr)
+		actual, err := r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		actual.Calibration.Coefficients[0] = 10
+		actual.Calibration.Scale = 3
+
+		actual, err = r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, calibrated.Calibration, actual.Calibration)
+
+		_, err = r.GetSensors(ctx)
+		require.NoError(t, err)
+		sensors, err := r.GetSensors(ctx)
+		require.NoError(t, err)
+		sensors[0].Calibration.Coefficients[1] = 20
+
+		sensors, err = r.GetSensors(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []float64{1, 2}, sensors[0].Calibration.Coefficients)
+		assert.Equal(t, []float64{1, 2}, calibrated.Calibration.Coefficients)
+	})
+
+	t.Run("ok, entries expire", func(t *testing.T) {
+		now := time.Now()
+
+		sr := usecase.NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(2).Return(sensor, nil)
+
+		r := NewSensorRepository(sr, WithTTL(time.Second), WithClock(func() time.Time { return now }))
+		_, err := r.GetSensorBySerialNumber(ctx, "1234567890")
+		require.NoError(t, err)
+
+		now = now.Add(time.Second)
+		_, err = r.GetSensorBySerialNumber(ctx, "1234567890")
+		require.NoError(t, err)
+	})
+
+	t.Run("ok, save invalidates", func(t *testing.T) {
+		updated := &domain.Sensor{ID: 1, SerialNumber: "1234567890", Version: 2}
+
+		sr := usecase.NewMockSensorRepository(ctrl)
+		gomock.InOrder(
+			sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(sensor, nil),
+			sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(updated, nil),
+		)
+		gomock.InOrder(
+			sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{*sensor}, nil),
+			sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{*updated}, nil),
+		)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		r := NewSensorRepository(sr)
+		_, err := r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		_, err = r.GetSensors(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, r.SaveSensor(ctx, &domain.Sensor{ID: 1, SerialNumber: "1234567890", Version: 1}))
+
+		actual, err := r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, updated, actual)
+		sensors, err := r.GetSensors(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Sensor{*updated}, sensors)
+	})
+
+	t.Run("ok, conflict invalidates", func(t *testing.T) {
+		sr := usecase.NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(2).Return(sensor, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(usecase.ErrSensorVersionConflict)
+
+		r := NewSensorRepository(sr)
+		_, err := r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+
+		err = r.SaveSensor(ctx, &domain.Sensor{ID: 1, SerialNumber: "1234567890"})
+		assert.ErrorIs(t, err, usecase.ErrSensorVersionConflict)
+
+		_, err = r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("ok, unit of work bypasses cache and invalidates after end", func(t *testing.T) {
+		txCtx, end := transaction.Begin(ctx)
+		uncommitted := &domain.Sensor{ID: 1, SerialNumber: "1234567890", Version: 2, CurrentState: 7}
+
+		sr := usecase.NewMockSensorRepository(ctrl)
+		sr.EXPECT().SaveSensor(txCtx, gomock.Any()).Times(1).Return(nil)
+		sr.EXPECT().GetSensorByID(txCtx, int64(1)).Times(2).Return(uncommitted, nil)
+		sr.EXPECT().GetSensorBySerialNumber(txCtx, "1234567890").Times(1).Return(uncommitted, nil)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(2).Return(sensor, nil)
+
+		r := NewSensorRepository(sr)
+		require.NoError(t, r.SaveSensor(txCtx, uncommitted))
+		for i := 0; i < 2; i++ {
+			actual, err := r.GetSensorByID(txCtx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, uncommitted, actual)
+		}
+		_, err := r.GetSensorBySerialNumber(txCtx, "1234567890")
+		require.NoError(t, err)
+
+		// чтение вне транзакции до её завершения кладёт в кэш зафиксированную версию
+		actual, err := r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, sensor, actual)
+
+		// после отката или фиксации она сброшена и читается заново
+		end()
+		actual, err = r.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, sensor, actual)
+	})
+
+	t.Run("err, errors are not cached", func(t *testing.T) {
+		expectedError := errors.New("some error")
+
+		sr := usecase.NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(2).Return(nil, expectedError)
+
+		r := NewSensorRepository(sr)
+		for i := 0; i < 2; i++ {
+			_, err := r.GetSensorByID(ctx, 1)
+			assert.ErrorIs(t, err, expectedError)
+		}
+	})
+}
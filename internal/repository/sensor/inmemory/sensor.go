@@ -1,10 +1,13 @@
 package inmemory
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"homework/internal/domain"
 	"homework/internal/usecase"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -41,16 +44,28 @@ func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor
 	r.muBySN.Lock()
 	defer r.muBySN.Unlock()
 
-	r.score++
-	sensor.ID = r.score
+	if stored, ok := r.senorsByID[sensor.ID]; ok {
+		if stored.Version != sensor.Version {
+			return usecase.ErrSensorVersionConflict
+		}
+		delete(r.sensorBySN, stored.SerialNumber)
+		sensor.Version++
+	} else {
+		r.score++
+		sensor.ID = r.score
+		sensor.RegisteredAt = time.Now()
+		sensor.Version = 1
+	}
 
-	sensor.RegisteredAt = time.Now()
-	r.senorsByID[sensor.ID] = sensor
-	r.sensorBySN[sensor.SerialNumber] = sensor
+	// храним копию, чтобы вызывающий код не менял датчик в обход репозитория
+	stored := *sensor
+	r.senorsByID[sensor.ID] = &stored
+	r.sensorBySN[sensor.SerialNumber] = &stored
 
 	return nil
 }
 
+// getSensorsBy - датчики по возрастанию ID, чтобы список не менялся от обхода к обходу
 func getSensorsBy[T comparable](source map[T]*domain.Sensor) []domain.Sensor {
 	var sensors []domain.Sensor
 
@@ -58,6 +73,10 @@ func getSensorsBy[T comparable](source map[T]*domain.Sensor) []domain.Sensor {
 		sensors = append(sensors, *sensor)
 	}
 
+	slices.SortFunc(sensors, func(a, b domain.Sensor) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
 	return sensors
 }
 
@@ -80,7 +99,8 @@ func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain
 	defer r.muByID.Unlock()
 
 	if sensor, ok := r.senorsByID[id]; ok {
-		return sensor, nil
+		out := *sensor
+		return &out, nil
 	}
 
 	return nil, usecase.ErrSensorNotFound
@@ -95,8 +115,75 @@ func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn strin
 	defer r.muBySN.Unlock()
 
 	if sensor, ok := r.sensorBySN[sn]; ok {
-		return sensor, nil
+		out := *sensor
+		return &out, nil
 	}
 
 	return nil, usecase.ErrSensorNotFound
 }
+
+func (r *SensorRepository) ListSensors(ctx context.Context, query domain.SensorQuery) ([]domain.Sensor, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.muBySN.Lock()
+	all := getSensorsBy(r.sensorBySN)
+	r.muBySN.Unlock()
+
+	compare := func(a, b domain.Sensor) int {
+		c := compareSensors(a, b, query.Sort)
+		if query.Desc {
+			return -c
+		}
+		return c
+	}
+
+	var sensors []domain.Sensor
+	for _, sensor := range all {
+		if matchSensor(sensor, query) && (query.After == nil || compare(sensor, *query.After) > 0) {
+			sensors = append(sensors, sensor)
+		}
+	}
+
+	slices.SortFunc(sensors, compare)
+	if query.Limit > 0 && len(sensors) > query.Limit {
+		sensors = sensors[:query.Limit]
+	}
+
+	return sensors, nil
+}
+
+// matchSensor - те же условия, что и WHERE в postgres репозитории
+func matchSensor(sensor domain.Sensor, query domain.SensorQuery) bool {
+	if query.Type != "" && sensor.Type != query.Type {
+		return false
+	}
+	if query.IsActive != nil && sensor.IsActive != *query.IsActive {
+		return false
+	}
+	if query.Online != nil && sensor.LastActivity.Before(query.OnlineSince) == *query.Online {
+		return false
+	}
+	if query.Search != "" && !strings.Contains(strings.ToLower(sensor.Description), strings.ToLower(query.Search)) {
+		return false
+	}
+	return strings.HasPrefix(sensor.SerialNumber, query.SerialPrefix)
+}
+
+// compareSensors - порядок по полю сортировки, а при равенстве - по ID
+func compareSensors(a, b domain.Sensor, sort domain.SensorSort) int {
+	var c int
+	switch sort {
+	case domain.SensorSortSerialNumber:
+		c = strings.Compare(a.SerialNumber, b.SerialNumber)
+	case domain.SensorSortRegisteredAt:
+		c = a.RegisteredAt.Compare(b.RegisteredAt)
+	case domain.SensorSortLastActivity:
+		c = a.LastActivity.Compare(b.LastActivity)
+	}
+	if c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
@@ -66,6 +66,52 @@ func TestSensorRepository_SaveSensor(t *testing.T) {
 		assert.Empty(t, actualSensor.LastActivity)
 	})
 
+	t.Run("ok, update keeps id", func(t *testing.T) {
+		sr := NewSensorRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{
+			SerialNumber: "12345678",
+			Type:         domain.SensorTypeADC,
+		}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		id := sensor.ID
+
+		sensor.CurrentState = 10
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		assert.Equal(t, id, sensor.ID)
+
+		sensors, err := sr.GetSensors(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, sensors, 1)
+		assert.Equal(t, int64(10), sensors[0].CurrentState)
+		assert.Equal(t, int64(2), sensors[0].Version)
+	})
+
+	t.Run("err, stale version", func(t *testing.T) {
+		sr := NewSensorRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{
+			SerialNumber: "12345678",
+			Type:         domain.SensorTypeADC,
+		}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+
+		stale := *sensor
+		sensor.Description = "first"
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+
+		stale.Description = "second"
+		assert.ErrorIs(t, sr.SaveSensor(ctx, &stale), usecase.ErrSensorVersionConflict)
+
+		actual, err := sr.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", actual.Description)
+	})
+
 	t.Run("ok, collision test", func(t *testing.T) {
 		sr := NewSensorRepository()
 		ctx, cancel := context.WithCancel(context.Background())
@@ -230,3 +276,57 @@ func TestSensorRepository_GetSensorBySerialNumber(t *testing.T) {
 		assert.Empty(t, actualSensor.LastActivity)
 	})
 }
+
+func TestSensorRepository_ListSensors(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	sr := NewSensorRepository()
+	for _, s := range []domain.Sensor{
+		{SerialNumber: "3000000000", Type: domain.SensorTypeADC, Description: "Kitchen 50%", IsActive: true, LastActivity: now},
+		{SerialNumber: "1000000000", Type: domain.SensorTypeContactClosure, Description: "kitchen door", IsActive: true},
+		{SerialNumber: "1200000000", Type: domain.SensorTypeADC, Description: "hall", LastActivity: now.Add(-time.Hour)},
+		{SerialNumber: "2000000000", Type: domain.SensorTypeADC, Description: "kitchen_window", IsActive: true, LastActivity: now},
+	} {
+		assert.NoError(t, sr.SaveSensor(ctx, &s))
+	}
+
+	ids := func(sensors []domain.Sensor) []int64 {
+		var out []int64
+		for _, s := range sensors {
+			out = append(out, s.ID)
+		}
+		return out
+	}
+	active, online, offline := true, true, false
+
+	tests := []struct {
+		name  string
+		query domain.SensorQuery
+		want  []int64
+	}{
+		{"all by id", domain.SensorQuery{}, []int64{1, 2, 3, 4}},
+		{"type", domain.SensorQuery{Type: domain.SensorTypeADC}, []int64{1, 3, 4}},
+		{"active", domain.SensorQuery{IsActive: &active}, []int64{1, 2, 4}},
+		{"online", domain.SensorQuery{Online: &online, OnlineSince: now.Add(-time.Minute)}, []int64{1, 4}},
+		{"offline", domain.SensorQuery{Online: &offline, OnlineSince: now.Add(-time.Minute)}, []int64{2, 3}},
+		{"search ignores case", domain.SensorQuery{Search: "KITCHEN"}, []int64{1, 2, 4}},
+		{"search special characters", domain.SensorQuery{Search: "n_w"}, []int64{4}},
+		{"serial prefix", domain.SensorQuery{SerialPrefix: "1"}, []int64{2, 3}},
+		{"sort by serial", domain.SensorQuery{Sort: domain.SensorSortSerialNumber}, []int64{2, 3, 4, 1}},
+		{"sort by activity desc", domain.SensorQuery{Sort: domain.SensorSortLastActivity, Desc: true}, []int64{4, 1, 3, 2}},
+		{"limit", domain.SensorQuery{Sort: domain.SensorSortSerialNumber, Limit: 2}, []int64{2, 3}},
+		{"after", domain.SensorQuery{
+			Sort:  domain.SensorSortLastActivity,
+			Desc:  true,
+			After: &domain.Sensor{ID: 4, LastActivity: now},
+		}, []int64{1, 3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sensors, err := sr.ListSensors(ctx, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, ids(sensors))
+		})
+	}
+}
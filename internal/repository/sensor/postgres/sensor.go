@@ -2,14 +2,22 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
 	"homework/internal/usecase"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+
+	transaction "homework/internal/repository/transaction/postgres"
 )
 
 type SensorRepository struct {
@@ -22,18 +30,42 @@ func NewSensorRepository(pool *pgxpool.Pool) *SensorRepository {
 	}
 }
 
+var tracer = otel.Tracer("homework/internal/repository/sensor/postgres")
+
 const (
-	saveSensorQuery = `INSERT INTO sensors (serial_number, type, current_state, description, is_active, registered_at, last_activity) 
-VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	saveSensorQuery = `INSERT INTO sensors (serial_number, type, current_state, description, is_active, registered_at, last_activity, unit, calibration, current_value) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, version;`
 	getSensorByIDQuery           = `SELECT * FROM sensors WHERE id = $1;`
 	getSensorBySerialNumberQuery = `SELECT * FROM sensors WHERE serial_number = $1;`
-	getSensorsQuery              = `SELECT * FROM sensors;`
+	getSensorsQuery              = `SELECT * FROM sensors ORDER BY id;`
 	updateSensorQuery            = `UPDATE sensors SET serial_number = $1, type = $2, current_state = $3, 
-                   description = $4, is_active = $5, last_activity = $6 WHERE id = $7;`
+                   description = $4, is_active = $5, last_activity = $6, unit = $9, calibration = $10, current_value = $11, version = version + 1
+                   WHERE id = $7 AND version = $8 RETURNING version;`
 )
 
-func (r *SensorRepository) updateSensor(ctx context.Context, sensor *domain.Sensor) error {
-	_, err := r.pool.Exec(ctx, updateSensorQuery,
+// marshalCalibration - калибровка для колонки jsonb, NULL - без калибровки
+func marshalCalibration(c *domain.Calibration) ([]byte, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+func (r *SensorRepository) updateSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorRepository.SaveSensor", updateSensorQuery)
+	defer func() { tracing.End(span, err) }()
+
+	calibration, err := marshalCalibration(sensor.Calibration)
+	if err != nil {
+		return fmt.Errorf("can't encode sensor calibration: %w", err)
+	}
+
+	value, err := json.Marshal(sensor.State())
+	if err != nil {
+		return fmt.Errorf("can't encode sensor value: %w", err)
+	}
+
+	err = transaction.DB(ctx, r.pool).QueryRow(ctx, updateSensorQuery,
 		sensor.SerialNumber,
 		sensor.Type,
 		sensor.CurrentState,
@@ -41,11 +73,24 @@ func (r *SensorRepository) updateSensor(ctx context.Context, sensor *domain.Sens
 		sensor.IsActive,
 		sensor.LastActivity,
 		sensor.ID,
-	)
-	return err
+		sensor.Version,
+		sensor.Unit,
+		calibration,
+		value,
+	).Scan(&sensor.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return usecase.ErrSensorVersionConflict
+	}
+	if err != nil {
+		return fmt.Errorf("can't update sensor: %w", err)
+	}
+
+	logging.FromContext(ctx).DebugContext(ctx, "sensor updated", logging.SensorID(sensor.ID))
+
+	return nil
 }
 
-func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -58,9 +103,22 @@ func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor
 		return r.updateSensor(ctx, sensor)
 	}
 
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorRepository.SaveSensor", saveSensorQuery)
+	defer func() { tracing.End(span, err) }()
+
 	sensor.RegisteredAt = time.Now()
 
-	_, err := r.pool.Exec(ctx, saveSensorQuery,
+	calibration, err := marshalCalibration(sensor.Calibration)
+	if err != nil {
+		return fmt.Errorf("can't encode sensor calibration: %w", err)
+	}
+
+	value, err := json.Marshal(sensor.State())
+	if err != nil {
+		return fmt.Errorf("can't encode sensor value: %w", err)
+	}
+
+	err = transaction.DB(ctx, r.pool).QueryRow(ctx, saveSensorQuery,
 		sensor.SerialNumber,
 		sensor.Type,
 		sensor.CurrentState,
@@ -68,16 +126,25 @@ func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor
 		sensor.IsActive,
 		sensor.RegisteredAt,
 		sensor.LastActivity,
-	)
+		sensor.Unit,
+		calibration,
+		value,
+	).Scan(&sensor.ID, &sensor.Version)
 	if err != nil {
-		return fmt.Errorf("can't save event: %w", err)
+		return fmt.Errorf("can't save sensor: %w", err)
 	}
 
+	logging.FromContext(ctx).DebugContext(ctx, "sensor saved", logging.SerialNumber(sensor.SerialNumber))
+
 	return nil
 }
 
 func sensorMap(row pgx.Row) (*domain.Sensor, error) {
-	var sensor domain.Sensor
+	var (
+		sensor      domain.Sensor
+		calibration []byte
+		value       []byte
+	)
 
 	err := row.Scan(
 		&sensor.ID,
@@ -88,20 +155,39 @@ func sensorMap(row pgx.Row) (*domain.Sensor, error) {
 		&sensor.IsActive,
 		&sensor.RegisteredAt,
 		&sensor.LastActivity,
+		&sensor.Version,
+		&sensor.Unit,
+		&calibration,
+		&value,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("can't get sensor %w", err)
 	}
 
+	if calibration != nil {
+		sensor.Calibration = &domain.Calibration{}
+		if err := json.Unmarshal(calibration, sensor.Calibration); err != nil {
+			return nil, fmt.Errorf("can't decode sensor calibration: %w", err)
+		}
+	}
+	if value != nil {
+		if err := json.Unmarshal(value, &sensor.CurrentValue); err != nil {
+			return nil, fmt.Errorf("can't decode sensor value: %w", err)
+		}
+	}
+
 	return &sensor, nil
 }
 
-func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+func (r *SensorRepository) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorRepository.GetSensors", getSensorsQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
-	rows, err := r.pool.Query(ctx, getSensorsQuery)
+	rows, err := transaction.DB(ctx, r.pool).Query(ctx, getSensorsQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -120,12 +206,15 @@ func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, err
 	return sensors, nil
 }
 
-func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorRepository.GetSensorByID", getSensorByIDQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
-	s, err := sensorMap(r.pool.QueryRow(ctx, getSensorByIDQuery, id))
+	s, err := sensorMap(transaction.DB(ctx, r.pool).QueryRow(ctx, getSensorByIDQuery, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, usecase.ErrSensorNotFound
@@ -136,12 +225,15 @@ func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain
 	return s, nil
 }
 
-func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorRepository.GetSensorBySerialNumber", getSensorBySerialNumberQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
-	s, err := sensorMap(r.pool.QueryRow(ctx, getSensorBySerialNumberQuery, sn))
+	s, err := sensorMap(transaction.DB(ctx, r.pool).QueryRow(ctx, getSensorBySerialNumberQuery, sn))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, usecase.ErrSensorNotFound
@@ -151,3 +243,126 @@ func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn strin
 
 	return s, nil
 }
+
+// sensorSortColumns - колонки сортировки. Серийные номера сравниваются побайтно,
+// как в inmemory репозитории, независимо от локали базы.
+var sensorSortColumns = map[domain.SensorSort]string{
+	domain.SensorSortID:           "id",
+	domain.SensorSortSerialNumber: `serial_number COLLATE "C"`,
+	domain.SensorSortRegisteredAt: "registered_at",
+	domain.SensorSortLastActivity: "last_activity",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// listSensorsQuery - запрос страницы датчиков. Страницы выбираются по ключу (поле сортировки, id),
+// а не через OFFSET, поэтому следующая страница не сдвигается при добавлении датчиков.
+func listSensorsQuery(query domain.SensorQuery) (string, []any, error) {
+	column := "id"
+	if query.Sort != "" {
+		var ok bool
+		if column, ok = sensorSortColumns[query.Sort]; !ok {
+			return "", nil, fmt.Errorf("unknown sort %q", query.Sort)
+		}
+	}
+
+	var (
+		conditions []string
+		args       []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if query.Type != "" {
+		conditions = append(conditions, "type = "+arg(query.Type))
+	}
+	if query.IsActive != nil {
+		conditions = append(conditions, "is_active = "+arg(*query.IsActive))
+	}
+	if query.Online != nil {
+		if *query.Online {
+			conditions = append(conditions, "last_activity >= "+arg(query.OnlineSince))
+		} else {
+			conditions = append(conditions, "last_activity < "+arg(query.OnlineSince))
+		}
+	}
+	if query.Search != "" {
+		conditions = append(conditions, "description ILIKE '%' || "+arg(likeEscaper.Replace(query.Search))+" || '%'")
+	}
+	if query.SerialPrefix != "" {
+		conditions = append(conditions, "serial_number LIKE "+arg(likeEscaper.Replace(query.SerialPrefix))+" || '%'")
+	}
+
+	direction, operator := "ASC", ">"
+	if query.Desc {
+		direction, operator = "DESC", "<"
+	}
+
+	if query.After != nil {
+		var key any
+		switch query.Sort {
+		case domain.SensorSortSerialNumber:
+			key = query.After.SerialNumber
+		case domain.SensorSortRegisteredAt:
+			key = query.After.RegisteredAt
+		case domain.SensorSortLastActivity:
+			key = query.After.LastActivity
+		}
+		if key == nil {
+			conditions = append(conditions, "id "+operator+" "+arg(query.After.ID))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", column, operator, arg(key), arg(query.After.ID)))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT * FROM sensors")
+	if len(conditions) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(conditions, " AND "))
+	}
+	if column == "id" {
+		fmt.Fprintf(&b, " ORDER BY id %s", direction)
+	} else {
+		fmt.Fprintf(&b, " ORDER BY %s %s, id %s", column, direction, direction)
+	}
+	if query.Limit > 0 {
+		b.WriteString(" LIMIT " + arg(query.Limit))
+	}
+
+	return b.String(), args, nil
+}
+
+func (r *SensorRepository) ListSensors(ctx context.Context, query domain.SensorQuery) (_ []domain.Sensor, err error) {
+	sql, args, err := listSensorsQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorRepository.ListSensors", sql)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := transaction.DB(ctx, r.pool).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sensors []domain.Sensor
+
+	for rows.Next() {
+		s, err := sensorMap(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't get sensor %w", err)
+		}
+		sensors = append(sensors, *s)
+	}
+
+	return sensors, rows.Err()
+}
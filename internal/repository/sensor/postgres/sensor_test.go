@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
@@ -61,10 +62,17 @@ func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
 		CurrentState: 2,
+		CurrentValue: domain.FloatValue(2.4),
 		Description:  "test_desc_2",
 		IsActive:     false,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
 		LastActivity: time.Now().Truncate(time.Microsecond).In(time.UTC),
+		Version:      sensor.Version,
+		Unit:         "°C",
+		Calibration: &domain.Calibration{
+			Kind:   domain.CalibrationLookup,
+			Points: []domain.CalibrationPoint{{Raw: 0, Value: -20}, {Raw: 1023, Value: 80}},
+		},
 	}
 
 	// update old sensor
@@ -78,6 +86,12 @@ func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 
 	assert.Nil(suite.T(), err)
 	assert.Equal(suite.T(), updatedSensor, *sensor)
+	assert.Equal(suite.T(), int64(2), sensor.Version)
+
+	// сохранение устаревшей версии
+	updatedSensor.Version = 1
+	err = suite.repo.SaveSensor(ctx, &updatedSensor)
+	assert.ErrorIs(suite.T(), err, usecase.ErrSensorVersionConflict)
 }
 
 func (suite *SensorTestSuite) TestSensorRepository_GetSensors() {
@@ -120,6 +134,7 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorByID() {
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
 		CurrentState: 1,
+		CurrentValue: domain.IntValue(1),
 		Description:  "test_desc_4",
 		IsActive:     true,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
@@ -151,6 +166,7 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorBySerialNumber() {
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
 		CurrentState: 1,
+		CurrentValue: domain.IntValue(1),
 		Description:  "test_desc_5",
 		IsActive:     true,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
@@ -169,6 +185,54 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorBySerialNumber() {
 	assert.Equal(suite.T(), newSensor, *sensor)
 }
 
+func (suite *SensorTestSuite) TestSensorRepository_ListSensors() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+
+	var ids []int64
+	for _, s := range []domain.Sensor{
+		{SerialNumber: "9300000000", Type: domain.SensorTypeADC, Description: "Kitchen 50%", IsActive: true, LastActivity: now},
+		{SerialNumber: "9100000000", Type: domain.SensorTypeContactClosure, Description: "kitchen door", IsActive: true},
+		{SerialNumber: "9120000000", Type: domain.SensorTypeADC, Description: "hall", LastActivity: now.Add(-time.Hour)},
+		{SerialNumber: "9200000000", Type: domain.SensorTypeADC, Description: "kitchen_window", IsActive: true, LastActivity: now},
+	} {
+		assert.Nil(suite.T(), suite.repo.SaveSensor(ctx, &s))
+		ids = append(ids, s.ID)
+	}
+
+	list := func(query domain.SensorQuery) []int64 {
+		// датчики других тестов отсекаются префиксом
+		if query.SerialPrefix == "" {
+			query.SerialPrefix = "9"
+		}
+		sensors, err := suite.repo.ListSensors(ctx, query)
+		assert.Nil(suite.T(), err)
+
+		var out []int64
+		for _, s := range sensors {
+			out = append(out, s.ID)
+		}
+		return out
+	}
+	active, online := true, true
+
+	assert.Equal(suite.T(), ids, list(domain.SensorQuery{}))
+	assert.Equal(suite.T(), []int64{ids[0], ids[2], ids[3]}, list(domain.SensorQuery{Type: domain.SensorTypeADC}))
+	assert.Equal(suite.T(), []int64{ids[0], ids[1], ids[3]}, list(domain.SensorQuery{IsActive: &active}))
+	assert.Equal(suite.T(), []int64{ids[0], ids[3]}, list(domain.SensorQuery{Online: &online, OnlineSince: now.Add(-time.Minute)}))
+	assert.Equal(suite.T(), []int64{ids[0], ids[1], ids[3]}, list(domain.SensorQuery{Search: "KITCHEN"}))
+	assert.Equal(suite.T(), []int64{ids[3]}, list(domain.SensorQuery{Search: "n_w"}))
+	assert.Equal(suite.T(), []int64{ids[1], ids[2]}, list(domain.SensorQuery{SerialPrefix: "91"}))
+	assert.Equal(suite.T(), []int64{ids[1], ids[2]}, list(domain.SensorQuery{Sort: domain.SensorSortSerialNumber, Limit: 2}))
+	assert.Equal(suite.T(), []int64{ids[0], ids[2], ids[1]}, list(domain.SensorQuery{
+		Sort:  domain.SensorSortLastActivity,
+		Desc:  true,
+		After: &domain.Sensor{ID: ids[3], LastActivity: now},
+	}))
+}
+
 func TestSensorTestSuite(t *testing.T) {
 	suite.Run(t, new(SensorTestSuite))
 }
@@ -0,0 +1,55 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sync"
+)
+
+type SensorTypeRepository struct {
+	mu    sync.Mutex
+	types map[domain.SensorType]domain.SensorTypeSpec
+}
+
+// NewSensorTypeRepository - репозиторий, в котором изначально есть domain.DefaultSensorTypes
+func NewSensorTypeRepository() *SensorTypeRepository {
+	r := &SensorTypeRepository{
+		types: make(map[domain.SensorType]domain.SensorTypeSpec),
+	}
+	for _, spec := range domain.DefaultSensorTypes() {
+		r.types[spec.Name] = spec
+	}
+	return r
+}
+
+func (r *SensorTypeRepository) GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	specs := make([]domain.SensorTypeSpec, 0, len(r.types))
+	for _, spec := range r.types {
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func (r *SensorTypeRepository) SaveSensorType(ctx context.Context, spec *domain.SensorTypeSpec) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if spec == nil {
+		return errors.New("sensor type is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.types[spec.Name] = *spec
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorTypeRepository(t *testing.T) {
+	t.Run("ok, defaults", func(t *testing.T) {
+		r := NewSensorTypeRepository()
+
+		specs, err := r.GetSensorTypes(context.Background())
+		require.NoError(t, err)
+		assert.ElementsMatch(t, domain.DefaultSensorTypes(), specs)
+	})
+
+	t.Run("ok, save and replace", func(t *testing.T) {
+		r := NewSensorTypeRepository()
+		ctx := context.Background()
+
+		limit := 100.0
+		spec := domain.SensorTypeSpec{Name: "humidity", Payload: domain.PayloadKindLevel, Max: &limit, Unit: "%"}
+		require.NoError(t, r.SaveSensorType(ctx, &spec))
+
+		spec.Unit = "percent"
+		require.NoError(t, r.SaveSensorType(ctx, &spec))
+
+		specs, err := r.GetSensorTypes(ctx)
+		require.NoError(t, err)
+		assert.Len(t, specs, len(domain.DefaultSensorTypes())+1)
+		assert.Contains(t, specs, spec)
+	})
+
+	t.Run("err, nil", func(t *testing.T) {
+		r := NewSensorTypeRepository()
+		assert.Error(t, r.SaveSensorType(context.Background(), nil))
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		r := NewSensorTypeRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := r.GetSensorTypes(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		err = r.SaveSensorType(ctx, &domain.SensorTypeSpec{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
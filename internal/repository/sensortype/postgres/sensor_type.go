@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+type SensorTypeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSensorTypeRepository(pool *pgxpool.Pool) *SensorTypeRepository {
+	return &SensorTypeRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/sensortype/postgres")
+
+const (
+	getSensorTypesQuery = `SELECT name, description, payload, min_value, max_value, unit, report_interval_ms, actuator, virtual FROM sensor_types;`
+	saveSensorTypeQuery = `INSERT INTO sensor_types (name, description, payload, min_value, max_value, unit, report_interval_ms, actuator, virtual)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (name) DO UPDATE SET description = excluded.description, payload = excluded.payload,
+    min_value = excluded.min_value, max_value = excluded.max_value, unit = excluded.unit,
+    report_interval_ms = excluded.report_interval_ms, actuator = excluded.actuator,
+    virtual = excluded.virtual;`
+)
+
+func (r *SensorTypeRepository) GetSensorTypes(ctx context.Context) (_ []domain.SensorTypeSpec, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorTypeRepository.GetSensorTypes", getSensorTypesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := r.pool.Query(ctx, getSensorTypesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensor types: %w", err)
+	}
+	defer rows.Close()
+
+	var specs []domain.SensorTypeSpec
+	for rows.Next() {
+		var (
+			spec     domain.SensorTypeSpec
+			interval int64
+		)
+		err := rows.Scan(&spec.Name, &spec.Description, &spec.Payload, &spec.Min, &spec.Max, &spec.Unit, &interval, &spec.Actuator, &spec.Virtual)
+		if err != nil {
+			return nil, fmt.Errorf("can't get sensor type: %w", err)
+		}
+		spec.ReportInterval = time.Duration(interval) * time.Millisecond
+		specs = append(specs, spec)
+	}
+
+	return specs, rows.Err()
+}
+
+func (r *SensorTypeRepository) SaveSensorType(ctx context.Context, spec *domain.SensorTypeSpec) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorTypeRepository.SaveSensorType", saveSensorTypeQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if spec == nil {
+		return errors.New("sensor type is nil")
+	}
+
+	_, err = r.pool.Exec(ctx, saveSensorTypeQuery,
+		spec.Name,
+		spec.Description,
+		spec.Payload,
+		spec.Min,
+		spec.Max,
+		spec.Unit,
+		spec.ReportInterval.Milliseconds(),
+		spec.Actuator,
+		spec.Virtual,
+	)
+	if err != nil {
+		return fmt.Errorf("can't save sensor type: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	sensorRepository "homework/internal/repository/sensor/postgres"
+)
+
+type SensorTypeTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *SensorTypeRepository
+}
+
+func (suite *SensorTypeTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewSensorTypeRepository(suite.testDbInstance)
+}
+
+func (suite *SensorTypeTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_Defaults() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// миграции добавляют только cc и adc, остальные встроенные типы сохраняет реестр при запуске
+	specs, err := suite.repo.GetSensorTypes(ctx)
+	require.NoError(suite.T(), err)
+	assert.ElementsMatch(suite.T(), domain.DefaultSensorTypes()[:2], specs)
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_SaveSensorType() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	low, high := -40.0, 125.0
+	spec := domain.SensorTypeSpec{
+		Name:           "temperature",
+		Description:    "Термометр",
+		Payload:        domain.PayloadKindLevel,
+		Min:            &low,
+		Max:            &high,
+		Unit:           "°C",
+		ReportInterval: time.Minute,
+	}
+	require.NoError(suite.T(), suite.repo.SaveSensorType(ctx, &spec))
+
+	spec.Max = nil
+	require.NoError(suite.T(), suite.repo.SaveSensorType(ctx, &spec))
+
+	specs, err := suite.repo.GetSensorTypes(ctx)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), specs, spec)
+
+	// датчик нового типа можно зарегистрировать, неизвестного - нет
+	sr := sensorRepository.NewSensorRepository(suite.testDbInstance)
+	require.NoError(suite.T(), sr.SaveSensor(ctx, &domain.Sensor{SerialNumber: "1111111111", Type: "temperature"}))
+	assert.Error(suite.T(), sr.SaveSensor(ctx, &domain.Sensor{SerialNumber: "2222222222", Type: "unknown"}))
+}
+
+func TestSensorTypeTestSuite(t *testing.T) {
+	suite.Run(t, new(SensorTypeTestSuite))
+}
@@ -0,0 +1,37 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/repository/transaction"
+	"sync"
+)
+
+type txKey struct{}
+
+// UnitOfWork - единица работы in-memory хранилища: единицы работы выполняются по одной под общей
+// блокировкой, поэтому не видят промежуточных изменений друг друга. Откатить изменения
+// in-memory репозитории не могут, и сделанные до ошибки fn изменения остаются.
+type UnitOfWork struct {
+	mu sync.Mutex
+}
+
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{}
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	ctx, end := transaction.Begin(ctx)
+	defer end()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return fn(context.WithValue(ctx, txKey{}, struct{}{}))
+}
@@ -0,0 +1,57 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	uow := NewUnitOfWork()
+
+	t.Run("ok, nested unit joins outer", func(t *testing.T) {
+		calls := 0
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			return uow.Do(ctx, func(context.Context) error {
+				calls++
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("err, fn error returned", func(t *testing.T) {
+		expectedError := errors.New("some error")
+		err := uow.Do(ctx, func(context.Context) error { return expectedError })
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("ok, units serialized", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			running int
+			overlap bool
+		)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = uow.Do(ctx, func(context.Context) error {
+					running++
+					if running > 1 {
+						overlap = true
+					}
+					running--
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+		assert.False(t, overlap)
+	})
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/repository/transaction"
+	"homework/internal/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("homework/internal/repository/transaction/postgres")
+
+// Querier - запросы, общие для пула соединений и транзакции
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+// DB - транзакция единицы работы, в которой выполняется ctx, а вне единицы работы - pool.
+// Репозитории выполняют через DB запросы, которые должны участвовать в единице работы.
+func DB(ctx context.Context, pool *pgxpool.Pool) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
+
+// UnitOfWork - единица работы как одна транзакция postgres. Транзакция передаётся репозиториям
+// через контекст, поэтому они могут быть обёрнуты, например кэшем, и ничего о ней не знать.
+type UnitOfWork struct {
+	pool *pgxpool.Pool
+}
+
+func NewUnitOfWork(pool *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{
+		pool: pool,
+	}
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, tracer, "UnitOfWork.Do")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	// отложенные действия выполняются последними, после фиксации или отката
+	ctx, end := transaction.Begin(ctx)
+	defer end()
+	defer func() {
+		// после Commit откат ничего не делает
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			err = errors.Join(err, fmt.Errorf("can't roll back transaction: %w", rbErr))
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Package transaction - общая для хранилищ часть единиц работы: отметка контекста единицы работы
+// и действия после её завершения. По ней обёртки репозиториев, например кэш, узнают о транзакции,
+// ничего не зная о конкретном хранилище.
+package transaction
+
+import (
+	"context"
+	"sync"
+)
+
+type unitKey struct{}
+
+type unit struct {
+	mu    sync.Mutex
+	ended bool
+	after []func()
+}
+
+// Begin - отмечает ctx как контекст единицы работы. end вызывается единицей работы после фиксации
+// или отката и выполняет действия, отложенные AfterEnd.
+func Begin(ctx context.Context) (_ context.Context, end func()) {
+	u := &unit{}
+	return context.WithValue(ctx, unitKey{}, u), u.end
+}
+
+// InUnit - выполняется ли ctx внутри единицы работы
+func InUnit(ctx context.Context) bool {
+	_, ok := ctx.Value(unitKey{}).(*unit)
+	return ok
+}
+
+// AfterEnd - выполняет fn после завершения единицы работы ctx, вне единицы работы - сразу
+func AfterEnd(ctx context.Context, fn func()) {
+	u, ok := ctx.Value(unitKey{}).(*unit)
+	if ok {
+		u.mu.Lock()
+		if !u.ended {
+			u.after = append(u.after, fn)
+			u.mu.Unlock()
+			return
+		}
+		u.mu.Unlock()
+	}
+	fn()
+}
+
+func (u *unit) end() {
+	u.mu.Lock()
+	u.ended = true
+	after := u.after
+	u.after = nil
+	u.mu.Unlock()
+
+	for _, fn := range after {
+		fn()
+	}
+}
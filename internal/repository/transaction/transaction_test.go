@@ -0,0 +1,36 @@
+package transaction
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAfterEnd(t *testing.T) {
+	t.Run("ok, outside unit runs at once", func(t *testing.T) {
+		calls := 0
+		ctx := context.Background()
+		assert.False(t, InUnit(ctx))
+
+		AfterEnd(ctx, func() { calls++ })
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("ok, inside unit runs after end", func(t *testing.T) {
+		calls := 0
+		ctx, end := Begin(context.Background())
+		assert.True(t, InUnit(ctx))
+
+		AfterEnd(ctx, func() { calls++ })
+		AfterEnd(ctx, func() { calls++ })
+		assert.Zero(t, calls)
+
+		end()
+		assert.Equal(t, 2, calls)
+
+		// после завершения действие выполняется сразу
+		AfterEnd(ctx, func() { calls++ })
+		assert.Equal(t, 3, calls)
+	})
+}
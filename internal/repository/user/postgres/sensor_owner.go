@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -23,20 +25,29 @@ const (
 	getSensorsByUserIDQuery = `SELECT sensor_id, user_id FROM sensors_users WHERE user_id = $1;`
 )
 
-func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorOwnerRepository.SaveSensorOwner", saveSensorOwnerQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
 
-	_, err := r.pool.Exec(ctx, saveSensorOwnerQuery, sensorOwner.SensorID, sensorOwner.UserID)
+	_, err = r.pool.Exec(ctx, saveSensorOwnerQuery, sensorOwner.SensorID, sensorOwner.UserID)
 	if err != nil {
 		return fmt.Errorf("can't save sensor owner: %w", err)
 	}
 
+	logging.FromContext(ctx).DebugContext(ctx, "sensor owner saved",
+		logging.UserID(sensorOwner.UserID), logging.SensorID(sensorOwner.SensorID))
+
 	return nil
 }
 
-func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) (_ []domain.SensorOwner, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "SensorOwnerRepository.GetSensorsByUserID", getSensorsByUserIDQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"homework/internal/domain"
+	"homework/internal/tracing"
 	"homework/internal/usecase"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
 )
 
 type UserRepository struct {
@@ -21,12 +23,17 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 	}
 }
 
+var tracer = otel.Tracer("homework/internal/repository/user/postgres")
+
 const (
 	saveUserQuery    = `INSERT INTO users (name) VALUES ($1);`
 	getUserByIDQuery = `SELECT * FROM users WHERE id = $1;`
 )
 
-func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "UserRepository.SaveUser", saveUserQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -34,7 +41,7 @@ func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error
 		return errors.New("user is nil")
 	}
 
-	_, err := r.pool.Exec(ctx, saveUserQuery, user.Name)
+	_, err = r.pool.Exec(ctx, saveUserQuery, user.Name)
 	if err != nil {
 		return fmt.Errorf("can't save user: %w", err)
 	}
@@ -42,14 +49,17 @@ func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error
 	return nil
 }
 
-func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (_ *domain.User, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "UserRepository.GetUserByID", getUserByIDQuery)
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
 	var user domain.User
 
-	err := r.pool.QueryRow(ctx, getUserByIDQuery, id).Scan(&user.ID, &user.Name)
+	err = r.pool.QueryRow(ctx, getUserByIDQuery, id).Scan(&user.ID, &user.Name)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, usecase.ErrUserNotFound
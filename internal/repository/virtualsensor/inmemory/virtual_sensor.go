@@ -0,0 +1,101 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+)
+
+type VirtualSensorRepository struct {
+	mu      sync.Mutex
+	sensors map[int64]domain.VirtualSensor
+}
+
+func NewVirtualSensorRepository() *VirtualSensorRepository {
+	return &VirtualSensorRepository{
+		sensors: make(map[int64]domain.VirtualSensor),
+	}
+}
+
+func (r *VirtualSensorRepository) SaveVirtualSensor(ctx context.Context, vs *domain.VirtualSensor) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if vs == nil {
+		return errors.New("virtual sensor is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *vs
+	stored.Inputs = slices.Clone(vs.Inputs)
+	r.sensors[vs.SensorID] = stored
+
+	return nil
+}
+
+func (r *VirtualSensorRepository) GetVirtualSensor(ctx context.Context, sensorID int64) (*domain.VirtualSensor, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	vs, ok := r.sensors[sensorID]
+	if !ok {
+		return nil, usecase.ErrVirtualSensorNotFound
+	}
+	vs.Inputs = slices.Clone(vs.Inputs)
+	return &vs, nil
+}
+
+func (r *VirtualSensorRepository) GetVirtualSensors(ctx context.Context) ([]domain.VirtualSensor, error) {
+	return r.find(ctx, func(domain.VirtualSensor) bool { return true })
+}
+
+func (r *VirtualSensorRepository) GetVirtualSensorsByInput(ctx context.Context, inputID int64) ([]domain.VirtualSensor, error) {
+	return r.find(ctx, func(vs domain.VirtualSensor) bool { return slices.Contains(vs.Inputs, inputID) })
+}
+
+// find - определения, подходящие под match, по возрастанию ID датчика
+func (r *VirtualSensorRepository) find(ctx context.Context, match func(domain.VirtualSensor) bool) ([]domain.VirtualSensor, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]domain.VirtualSensor, 0)
+	for _, vs := range r.sensors {
+		if match(vs) {
+			vs.Inputs = slices.Clone(vs.Inputs)
+			out = append(out, vs)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.VirtualSensor) int {
+		return cmp.Compare(a.SensorID, b.SensorID)
+	})
+	return out, nil
+}
+
+func (r *VirtualSensorRepository) DeleteVirtualSensor(ctx context.Context, sensorID int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sensors[sensorID]; !ok {
+		return usecase.ErrVirtualSensorNotFound
+	}
+	delete(r.sensors, sensorID)
+	return nil
+}
@@ -0,0 +1,72 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualSensorRepository(t *testing.T) {
+	now := time.Now()
+
+	t.Run("ok, save, find by input and delete", func(t *testing.T) {
+		r := NewVirtualSensorRepository()
+		ctx := context.Background()
+
+		average := &domain.VirtualSensor{SensorID: 5, Expression: "avg(s1, s2)", Inputs: []int64{1, 2}, CreatedAt: now, UpdatedAt: now}
+		door := &domain.VirtualSensor{SensorID: 4, Expression: "s2 && s3", Inputs: []int64{2, 3}, CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, r.SaveVirtualSensor(ctx, average))
+		require.NoError(t, r.SaveVirtualSensor(ctx, door))
+
+		stored, err := r.GetVirtualSensor(ctx, average.SensorID)
+		require.NoError(t, err)
+		assert.Equal(t, average, stored)
+
+		all, err := r.GetVirtualSensors(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.VirtualSensor{*door, *average}, all)
+
+		byInput, err := r.GetVirtualSensorsByInput(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.VirtualSensor{*door, *average}, byInput)
+
+		average.Expression, average.Inputs = "s1", []int64{1}
+		require.NoError(t, r.SaveVirtualSensor(ctx, average))
+		byInput, err = r.GetVirtualSensorsByInput(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.VirtualSensor{*door}, byInput)
+
+		require.NoError(t, r.DeleteVirtualSensor(ctx, door.SensorID))
+		_, err = r.GetVirtualSensor(ctx, door.SensorID)
+		assert.ErrorIs(t, err, usecase.ErrVirtualSensorNotFound)
+		assert.ErrorIs(t, r.DeleteVirtualSensor(ctx, door.SensorID), usecase.ErrVirtualSensorNotFound)
+	})
+
+	t.Run("ok, stored inputs are copied", func(t *testing.T) {
+		r := NewVirtualSensorRepository()
+		ctx := context.Background()
+
+		vs := &domain.VirtualSensor{SensorID: 1, Expression: "s2", Inputs: []int64{2}}
+		require.NoError(t, r.SaveVirtualSensor(ctx, vs))
+		vs.Inputs[0] = 3
+
+		stored, err := r.GetVirtualSensor(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2}, stored.Inputs)
+	})
+
+	t.Run("err, context canceled", func(t *testing.T) {
+		r := NewVirtualSensorRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, r.SaveVirtualSensor(ctx, &domain.VirtualSensor{SensorID: 1}), context.Canceled)
+		_, err := r.GetVirtualSensors(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
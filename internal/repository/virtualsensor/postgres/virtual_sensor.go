@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+
+	transaction "homework/internal/repository/transaction/postgres"
+)
+
+type VirtualSensorRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewVirtualSensorRepository(pool *pgxpool.Pool) *VirtualSensorRepository {
+	return &VirtualSensorRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/virtualsensor/postgres")
+
+const (
+	virtualSensorColumns   = `sensor_id, expression, inputs, created_at, updated_at`
+	saveVirtualSensorQuery = `INSERT INTO virtual_sensors (` + virtualSensorColumns + `) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (sensor_id) DO UPDATE SET expression = $2, inputs = $3, updated_at = $5;`
+	getVirtualSensorQuery    = `SELECT ` + virtualSensorColumns + ` FROM virtual_sensors WHERE sensor_id = $1;`
+	getVirtualSensorsQuery   = `SELECT ` + virtualSensorColumns + ` FROM virtual_sensors ORDER BY sensor_id;`
+	getByInputQuery          = `SELECT ` + virtualSensorColumns + ` FROM virtual_sensors WHERE inputs @> ARRAY[$1::bigint] ORDER BY sensor_id;`
+	deleteVirtualSensorQuery = `DELETE FROM virtual_sensors WHERE sensor_id = $1;`
+)
+
+func (r *VirtualSensorRepository) SaveVirtualSensor(ctx context.Context, vs *domain.VirtualSensor) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "VirtualSensorRepository.SaveVirtualSensor", saveVirtualSensorQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if vs == nil {
+		return errors.New("virtual sensor is nil")
+	}
+
+	inputs := vs.Inputs
+	if inputs == nil {
+		inputs = []int64{}
+	}
+
+	_, err = transaction.DB(ctx, r.pool).Exec(ctx, saveVirtualSensorQuery,
+		vs.SensorID,
+		vs.Expression,
+		inputs,
+		vs.CreatedAt,
+		vs.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("can't save virtual sensor: %w", err)
+	}
+	return nil
+}
+
+func (r *VirtualSensorRepository) GetVirtualSensor(ctx context.Context, sensorID int64) (_ *domain.VirtualSensor, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "VirtualSensorRepository.GetVirtualSensor", getVirtualSensorQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var vs domain.VirtualSensor
+	err = scanVirtualSensor(transaction.DB(ctx, r.pool).QueryRow(ctx, getVirtualSensorQuery, sensorID), &vs)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrVirtualSensorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get virtual sensor: %w", err)
+	}
+	return &vs, nil
+}
+
+func (r *VirtualSensorRepository) GetVirtualSensors(ctx context.Context) (_ []domain.VirtualSensor, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "VirtualSensorRepository.GetVirtualSensors", getVirtualSensorsQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.query(ctx, getVirtualSensorsQuery)
+}
+
+func (r *VirtualSensorRepository) GetVirtualSensorsByInput(ctx context.Context, inputID int64) (_ []domain.VirtualSensor, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "VirtualSensorRepository.GetVirtualSensorsByInput", getByInputQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.query(ctx, getByInputQuery, inputID)
+}
+
+func (r *VirtualSensorRepository) query(ctx context.Context, query string, args ...any) ([]domain.VirtualSensor, error) {
+	rows, err := transaction.DB(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't get virtual sensors: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]domain.VirtualSensor, 0)
+	for rows.Next() {
+		var vs domain.VirtualSensor
+		if err := scanVirtualSensor(rows, &vs); err != nil {
+			return nil, fmt.Errorf("can't scan virtual sensor: %w", err)
+		}
+		out = append(out, vs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get virtual sensors: %w", err)
+	}
+	return out, nil
+}
+
+func (r *VirtualSensorRepository) DeleteVirtualSensor(ctx context.Context, sensorID int64) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "VirtualSensorRepository.DeleteVirtualSensor", deleteVirtualSensorQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	tag, err := transaction.DB(ctx, r.pool).Exec(ctx, deleteVirtualSensorQuery, sensorID)
+	if err != nil {
+		return fmt.Errorf("can't delete virtual sensor: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrVirtualSensorNotFound
+	}
+	return nil
+}
+
+func scanVirtualSensor(row pgx.Row, vs *domain.VirtualSensor) error {
+	return row.Scan(
+		&vs.SensorID,
+		&vs.Expression,
+		&vs.Inputs,
+		&vs.CreatedAt,
+		&vs.UpdatedAt,
+	)
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type VirtualSensorTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *VirtualSensorRepository
+}
+
+func (suite *VirtualSensorTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewVirtualSensorRepository(suite.testDbInstance)
+}
+
+func (suite *VirtualSensorTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *VirtualSensorTestSuite) TestVirtualSensorRepository() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetVirtualSensor(ctx, 1000)
+	assert.ErrorIs(suite.T(), err, usecase.ErrVirtualSensorNotFound)
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	average := &domain.VirtualSensor{SensorID: 5, Expression: "avg(s1, s2)", Inputs: []int64{1, 2}, CreatedAt: now, UpdatedAt: now}
+	door := &domain.VirtualSensor{SensorID: 4, Expression: "s2 && s3", Inputs: []int64{2, 3}, CreatedAt: now, UpdatedAt: now}
+	require.NoError(suite.T(), suite.repo.SaveVirtualSensor(ctx, average))
+	require.NoError(suite.T(), suite.repo.SaveVirtualSensor(ctx, door))
+
+	stored, err := suite.repo.GetVirtualSensor(ctx, average.SensorID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), average, stored)
+
+	byInput, err := suite.repo.GetVirtualSensorsByInput(ctx, 2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.VirtualSensor{*door, *average}, byInput)
+
+	average.Expression, average.Inputs = "s1 * 2", []int64{1}
+	average.UpdatedAt = now.Add(time.Minute)
+	require.NoError(suite.T(), suite.repo.SaveVirtualSensor(ctx, average))
+
+	byInput, err = suite.repo.GetVirtualSensorsByInput(ctx, 2)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.VirtualSensor{*door}, byInput)
+
+	all, err := suite.repo.GetVirtualSensors(ctx)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.VirtualSensor{*door, *average}, all)
+
+	require.NoError(suite.T(), suite.repo.DeleteVirtualSensor(ctx, door.SensorID))
+	assert.ErrorIs(suite.T(), suite.repo.DeleteVirtualSensor(ctx, door.SensorID), usecase.ErrVirtualSensorNotFound)
+}
+
+func TestVirtualSensorTestSuite(t *testing.T) {
+	suite.Run(t, new(VirtualSensorTestSuite))
+}
@@ -0,0 +1,220 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+	"time"
+)
+
+type WebhookRepository struct {
+	mu             sync.Mutex
+	lastWebhookID  int64
+	lastDeliveryID int64
+	webhooks       map[int64]domain.Webhook
+	deliveries     map[int64]domain.WebhookDelivery
+}
+
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{
+		webhooks:   make(map[int64]domain.Webhook),
+		deliveries: make(map[int64]domain.WebhookDelivery),
+	}
+}
+
+// cloneWebhook - копия подписки, не разделяющая срезы фильтров с оригиналом
+func cloneWebhook(webhook domain.Webhook) domain.Webhook {
+	webhook.Topics = slices.Clone(webhook.Topics)
+	webhook.SensorIDs = slices.Clone(webhook.SensorIDs)
+	webhook.SensorTypes = slices.Clone(webhook.SensorTypes)
+	return webhook
+}
+
+func (r *WebhookRepository) SaveWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if webhook == nil {
+		return errors.New("webhook is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if webhook.ID == 0 {
+		r.lastWebhookID++
+		webhook.ID = r.lastWebhookID
+	} else if _, ok := r.webhooks[webhook.ID]; !ok {
+		return usecase.ErrWebhookNotFound
+	}
+	r.webhooks[webhook.ID] = cloneWebhook(*webhook)
+
+	return nil
+}
+
+func (r *WebhookRepository) GetWebhookByID(ctx context.Context, id int64) (*domain.Webhook, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, usecase.ErrWebhookNotFound
+	}
+	webhook = cloneWebhook(webhook)
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) GetWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhooks := make([]domain.Webhook, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		webhooks = append(webhooks, cloneWebhook(webhook))
+	}
+	slices.SortFunc(webhooks, func(a, b domain.Webhook) int { return cmp.Compare(a.ID, b.ID) })
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return usecase.ErrWebhookNotFound
+	}
+	delete(r.webhooks, id)
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) SaveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if delivery == nil {
+		return errors.New("webhook delivery is nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if delivery.ID == 0 {
+		if _, ok := r.webhooks[delivery.WebhookID]; !ok {
+			return usecase.ErrWebhookNotFound
+		}
+		r.lastDeliveryID++
+		delivery.ID = r.lastDeliveryID
+	} else if _, ok := r.deliveries[delivery.ID]; !ok {
+		return usecase.ErrDeliveryNotFound
+	}
+	r.deliveries[delivery.ID] = *delivery
+
+	return nil
+}
+
+func (r *WebhookRepository) GetDeliveryByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, usecase.ErrDeliveryNotFound
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) GetDeliveries(
+	ctx context.Context,
+	webhookID int64,
+	statuses []domain.WebhookDeliveryStatus,
+	limit int,
+) ([]domain.WebhookDelivery, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID != webhookID {
+			continue
+		}
+		if len(statuses) > 0 && !slices.Contains(statuses, delivery.Status) {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int { return cmp.Compare(b.ID, a.ID) })
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) ClaimDeliveries(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) ([]domain.WebhookDelivery, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for _, delivery := range r.deliveries {
+		if delivery.Status != domain.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if !r.webhooks[delivery.WebhookID].Enabled {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), cmp.Compare(a.ID, b.ID))
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = now.Add(lease)
+		r.deliveries[deliveries[i].ID] = deliveries[i]
+	}
+
+	return deliveries, nil
+}
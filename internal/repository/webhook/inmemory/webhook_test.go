@@ -0,0 +1,104 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewWebhookRepository()
+
+	webhook := &domain.Webhook{
+		URL:       "http://localhost/hook",
+		Secret:    "0123456789abcdef",
+		SensorIDs: []int64{1},
+		Enabled:   true,
+	}
+	require.NoError(t, repo.SaveWebhook(ctx, webhook))
+	paused := &domain.Webhook{URL: "http://localhost/paused", Secret: "0123456789abcdef"}
+	require.NoError(t, repo.SaveWebhook(ctx, paused))
+
+	webhook.SensorIDs[0] = 2
+	stored, err := repo.GetWebhookByID(ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, stored.SensorIDs)
+
+	_, err = repo.GetWebhookByID(ctx, 100)
+	assert.ErrorIs(t, err, usecase.ErrWebhookNotFound)
+
+	newDelivery := func(webhookID int64, at time.Time) *domain.WebhookDelivery {
+		return &domain.WebhookDelivery{
+			WebhookID:     webhookID,
+			Topic:         domain.WebhookSensorEvent,
+			SensorID:      1,
+			Payload:       []byte(`{}`),
+			Status:        domain.WebhookDeliveryPending,
+			NextAttemptAt: at,
+			CreatedAt:     at,
+		}
+	}
+
+	assert.ErrorIs(t, repo.SaveDelivery(ctx, newDelivery(100, now)), usecase.ErrWebhookNotFound)
+
+	late := newDelivery(webhook.ID, now.Add(time.Second))
+	first := newDelivery(webhook.ID, now.Add(-time.Minute))
+	second := newDelivery(webhook.ID, now)
+	waiting := newDelivery(paused.ID, now)
+	for _, d := range []*domain.WebhookDelivery{late, first, second, waiting} {
+		require.NoError(t, repo.SaveDelivery(ctx, d))
+	}
+
+	t.Run("ok, claim due deliveries of enabled webhooks", func(t *testing.T) {
+		claimed, err := repo.ClaimDeliveries(ctx, now, time.Minute, 1)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, first.ID, claimed[0].ID)
+		assert.Equal(t, now.Add(time.Minute), claimed[0].NextAttemptAt)
+
+		claimed, err = repo.ClaimDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, second.ID, claimed[0].ID)
+
+		claimed, err = repo.ClaimDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+	})
+
+	t.Run("ok, delivery log", func(t *testing.T) {
+		first.Status = domain.WebhookDeliveryDead
+		first.Attempts = 3
+		require.NoError(t, repo.SaveDelivery(ctx, first))
+
+		dead, err := repo.GetDeliveries(ctx, webhook.ID, []domain.WebhookDeliveryStatus{domain.WebhookDeliveryDead}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.WebhookDelivery{*first}, dead)
+
+		all, err := repo.GetDeliveries(ctx, webhook.ID, nil, 2)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, second.ID, all[0].ID)
+		assert.Equal(t, first.ID, all[1].ID)
+	})
+
+	t.Run("ok, delete with deliveries", func(t *testing.T) {
+		require.NoError(t, repo.DeleteWebhook(ctx, webhook.ID))
+		assert.ErrorIs(t, repo.DeleteWebhook(ctx, webhook.ID), usecase.ErrWebhookNotFound)
+
+		_, err := repo.GetDeliveryByID(ctx, first.ID)
+		assert.ErrorIs(t, err, usecase.ErrDeliveryNotFound)
+
+		webhooks, err := repo.GetWebhooks(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		assert.Equal(t, paused.ID, webhooks[0].ID)
+	})
+}
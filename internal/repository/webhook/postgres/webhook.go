@@ -0,0 +1,412 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	transaction "homework/internal/repository/transaction/postgres"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+type WebhookRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookRepository(pool *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		pool: pool,
+	}
+}
+
+var tracer = otel.Tracer("homework/internal/repository/webhook/postgres")
+
+// foreignKeyViolation - код ошибки postgres при ссылке на несуществующую запись
+const foreignKeyViolation = "23503"
+
+const (
+	webhookColumns     = `id, url, secret, topics, sensor_ids, user_id, sensor_types, enabled, created_at, updated_at`
+	insertWebhookQuery = `INSERT INTO webhooks (url, secret, topics, sensor_ids, user_id, sensor_types, enabled, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id;`
+	updateWebhookQuery = `UPDATE webhooks SET url = $2, secret = $3, topics = $4, sensor_ids = $5, user_id = $6,
+sensor_types = $7, enabled = $8, updated_at = $9 WHERE id = $1;`
+	getWebhookQuery     = `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1;`
+	getWebhooksQuery    = `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id;`
+	deleteWebhookQuery  = `DELETE FROM webhooks WHERE id = $1;`
+	deliveryColumns     = `id, webhook_id, topic, sensor_id, payload, status, attempts, next_attempt_at, last_attempt_at, last_status_code, last_error, created_at, delivered_at`
+	insertDeliveryQuery = `INSERT INTO webhook_deliveries (webhook_id, topic, sensor_id, payload, status, attempts, next_attempt_at,
+last_attempt_at, last_status_code, last_error, created_at, delivered_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id;`
+	updateDeliveryQuery = `UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+last_status_code = $6, last_error = $7, delivered_at = $8 WHERE id = $1;`
+	getDeliveryQuery   = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1;`
+	getDeliveriesQuery = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+WHERE webhook_id = $1 AND (cardinality($2::text[]) = 0 OR status = ANY($2))
+ORDER BY id DESC LIMIT NULLIF($3, 0);`
+	// claimDeliveriesQuery - SKIP LOCKED не даёт двум репликам забрать одну доставку,
+	// а перенос next_attempt_at на конец аренды - забрать её снова, пока первая реплика её отправляет
+	claimDeliveriesQuery = `WITH claimed AS (
+	SELECT d.id, d.next_attempt_at AS claimed_at FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.status = 'pending' AND w.enabled AND d.next_attempt_at <= $1
+	ORDER BY d.next_attempt_at, d.id LIMIT $3
+	FOR UPDATE OF d SKIP LOCKED
+), leased AS (
+	UPDATE webhook_deliveries d SET next_attempt_at = $2 FROM claimed WHERE d.id = claimed.id
+	RETURNING d.*, claimed.claimed_at
+)
+SELECT ` + deliveryColumns + ` FROM leased ORDER BY claimed_at, id;`
+)
+
+func (r *WebhookRepository) SaveWebhook(ctx context.Context, webhook *domain.Webhook) (err error) {
+	query := updateWebhookQuery
+	if webhook != nil && webhook.ID == 0 {
+		query = insertWebhookQuery
+	}
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.SaveWebhook", query)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if webhook == nil {
+		return errors.New("webhook is nil")
+	}
+
+	sensorIDs := webhook.SensorIDs
+	if sensorIDs == nil {
+		sensorIDs = []int64{}
+	}
+
+	if webhook.ID == 0 {
+		err = r.pool.QueryRow(ctx, insertWebhookQuery,
+			webhook.URL,
+			webhook.Secret,
+			toStrings(webhook.Topics),
+			sensorIDs,
+			webhook.UserID,
+			toStrings(webhook.SensorTypes),
+			webhook.Enabled,
+			webhook.CreatedAt,
+			webhook.UpdatedAt,
+		).Scan(&webhook.ID)
+		if err != nil {
+			return fmt.Errorf("can't insert webhook: %w", err)
+		}
+		return nil
+	}
+
+	tag, err := r.pool.Exec(ctx, updateWebhookQuery,
+		webhook.ID,
+		webhook.URL,
+		webhook.Secret,
+		toStrings(webhook.Topics),
+		sensorIDs,
+		webhook.UserID,
+		toStrings(webhook.SensorTypes),
+		webhook.Enabled,
+		webhook.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("can't update webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetWebhookByID(ctx context.Context, id int64) (_ *domain.Webhook, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.GetWebhookByID", getWebhookQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var webhook domain.Webhook
+	err = scanWebhook(r.pool.QueryRow(ctx, getWebhookQuery, id), &webhook)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) GetWebhooks(ctx context.Context) (_ []domain.Webhook, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.GetWebhooks", getWebhooksQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	rows, err := transaction.DB(ctx, r.pool).Query(ctx, getWebhooksQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]domain.Webhook, 0)
+	for rows.Next() {
+		var webhook domain.Webhook
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, fmt.Errorf("can't scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.DeleteWebhook", deleteWebhookQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// доставки удаляются каскадом
+	tag, err := r.pool.Exec(ctx, deleteWebhookQuery, id)
+	if err != nil {
+		return fmt.Errorf("can't delete webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) SaveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	query := updateDeliveryQuery
+	if delivery != nil && delivery.ID == 0 {
+		query = insertDeliveryQuery
+	}
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.SaveDelivery", query)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if delivery == nil {
+		return errors.New("webhook delivery is nil")
+	}
+
+	if delivery.ID == 0 {
+		err = transaction.DB(ctx, r.pool).QueryRow(ctx, insertDeliveryQuery,
+			delivery.WebhookID,
+			delivery.Topic,
+			delivery.SensorID,
+			delivery.Payload,
+			delivery.Status,
+			delivery.Attempts,
+			delivery.NextAttemptAt,
+			nullTime(delivery.LastAttemptAt),
+			delivery.LastStatusCode,
+			delivery.LastError,
+			delivery.CreatedAt,
+			nullTime(delivery.DeliveredAt),
+		).Scan(&delivery.ID)
+		if isViolation(err, foreignKeyViolation) {
+			return usecase.ErrWebhookNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("can't insert webhook delivery: %w", err)
+		}
+		return nil
+	}
+
+	tag, err := transaction.DB(ctx, r.pool).Exec(ctx, updateDeliveryQuery,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		nullTime(delivery.LastAttemptAt),
+		delivery.LastStatusCode,
+		delivery.LastError,
+		nullTime(delivery.DeliveredAt),
+	)
+	if err != nil {
+		return fmt.Errorf("can't update webhook delivery: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetDeliveryByID(ctx context.Context, id int64) (_ *domain.WebhookDelivery, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.GetDeliveryByID", getDeliveryQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var delivery domain.WebhookDelivery
+	err = scanDelivery(r.pool.QueryRow(ctx, getDeliveryQuery, id), &delivery)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) GetDeliveries(
+	ctx context.Context,
+	webhookID int64,
+	statuses []domain.WebhookDeliveryStatus,
+	limit int,
+) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.GetDeliveries", getDeliveriesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.query(ctx, getDeliveriesQuery, webhookID, toStrings(statuses), limit)
+}
+
+func (r *WebhookRepository) ClaimDeliveries(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := tracing.StartQuery(ctx, tracer, "WebhookRepository.ClaimDeliveries", claimDeliveriesQuery)
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return r.query(ctx, claimDeliveriesQuery, now, now.Add(lease), limit)
+}
+
+func (r *WebhookRepository) query(ctx context.Context, query string, args ...any) ([]domain.WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		if err := scanDelivery(rows, &delivery); err != nil {
+			return nil, fmt.Errorf("can't scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func scanWebhook(row pgx.Row, webhook *domain.Webhook) error {
+	var topics, sensorTypes []string
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		&topics,
+		&webhook.SensorIDs,
+		&webhook.UserID,
+		&sensorTypes,
+		&webhook.Enabled,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(webhook.SensorIDs) == 0 {
+		webhook.SensorIDs = nil
+	}
+	webhook.Topics = fromStrings[domain.WebhookTopic](topics)
+	webhook.SensorTypes = fromStrings[domain.SensorType](sensorTypes)
+	return nil
+}
+
+func scanDelivery(row pgx.Row, delivery *domain.WebhookDelivery) error {
+	var lastAttemptAt, deliveredAt *time.Time
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Topic,
+		&delivery.SensorID,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&lastAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&deliveredAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	delivery.LastAttemptAt = deref(lastAttemptAt)
+	delivery.DeliveredAt = deref(deliveredAt)
+	return nil
+}
+
+// nullTime - нулевое время хранится как NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// deref - значение nullable колонки, NULL - нулевое значение
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+func toStrings[T ~string](values []T) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		out = append(out, string(value))
+	}
+	return out
+}
+
+func fromStrings[T ~string](values []string) []T {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]T, 0, len(values))
+	for _, value := range values {
+		out = append(out, T(value))
+	}
+	return out
+}
+
+func isViolation(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *WebhookRepository
+}
+
+func (suite *WebhookTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewWebhookRepository(suite.testDbInstance)
+}
+
+func (suite *WebhookTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func newDelivery(webhookID int64, at time.Time) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		WebhookID:     webhookID,
+		Topic:         domain.WebhookSensorEvent,
+		SensorID:      1,
+		Payload:       []byte(`{"topic":"sensor.event"}`),
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: at,
+		CreatedAt:     at,
+	}
+}
+
+func (suite *WebhookTestSuite) TestWebhookRepository_Webhooks() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetWebhookByID(ctx, 1000)
+	assert.ErrorIs(suite.T(), err, usecase.ErrWebhookNotFound)
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	webhook := &domain.Webhook{
+		URL:         "http://localhost/hook",
+		Secret:      "0123456789abcdef",
+		Topics:      []domain.WebhookTopic{domain.WebhookSensorEvent},
+		SensorIDs:   []int64{1, 2},
+		UserID:      3,
+		SensorTypes: []domain.SensorType{domain.SensorTypeADC},
+		Enabled:     true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	require.NoError(suite.T(), suite.repo.SaveWebhook(ctx, webhook))
+	assert.NotZero(suite.T(), webhook.ID)
+
+	got, err := suite.repo.GetWebhookByID(ctx, webhook.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), webhook, got)
+
+	webhook.Topics, webhook.SensorIDs, webhook.SensorTypes, webhook.UserID = nil, nil, nil, 0
+	webhook.Enabled = false
+	require.NoError(suite.T(), suite.repo.SaveWebhook(ctx, webhook))
+
+	webhooks, err := suite.repo.GetWebhooks(ctx)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), webhooks, *webhook)
+
+	require.NoError(suite.T(), suite.repo.DeleteWebhook(ctx, webhook.ID))
+	assert.ErrorIs(suite.T(), suite.repo.DeleteWebhook(ctx, webhook.ID), usecase.ErrWebhookNotFound)
+	assert.ErrorIs(suite.T(), suite.repo.SaveWebhook(ctx, webhook), usecase.ErrWebhookNotFound)
+}
+
+func (suite *WebhookTestSuite) TestWebhookRepository_Deliveries() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	enabled := &domain.Webhook{URL: "http://localhost/a", Secret: "0123456789abcdef", Enabled: true, CreatedAt: now, UpdatedAt: now}
+	disabled := &domain.Webhook{URL: "http://localhost/b", Secret: "0123456789abcdef", CreatedAt: now, UpdatedAt: now}
+	require.NoError(suite.T(), suite.repo.SaveWebhook(ctx, enabled))
+	require.NoError(suite.T(), suite.repo.SaveWebhook(ctx, disabled))
+
+	assert.ErrorIs(suite.T(), suite.repo.SaveDelivery(ctx, newDelivery(100000, now)), usecase.ErrWebhookNotFound)
+
+	late := newDelivery(enabled.ID, now.Add(time.Minute))
+	second := newDelivery(enabled.ID, now.Add(-time.Second))
+	first := newDelivery(enabled.ID, now.Add(-time.Minute))
+	paused := newDelivery(disabled.ID, now.Add(-time.Hour))
+	for _, delivery := range []*domain.WebhookDelivery{late, second, first, paused} {
+		require.NoError(suite.T(), suite.repo.SaveDelivery(ctx, delivery))
+	}
+
+	got, err := suite.repo.GetDeliveryByID(ctx, first.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), first, got)
+
+	claimed, err := suite.repo.ClaimDeliveries(ctx, now, 5*time.Minute, 10)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), claimed, 2)
+	assert.Equal(suite.T(), first.ID, claimed[0].ID)
+	assert.Equal(suite.T(), second.ID, claimed[1].ID)
+	assert.Equal(suite.T(), now.Add(5*time.Minute), claimed[0].NextAttemptAt)
+
+	claimed, err = suite.repo.ClaimDeliveries(ctx, now, 5*time.Minute, 10)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), claimed)
+
+	first.Status = domain.WebhookDeliverySucceeded
+	first.Attempts = 1
+	first.LastAttemptAt = now
+	first.LastStatusCode = 204
+	first.DeliveredAt = now
+	require.NoError(suite.T(), suite.repo.SaveDelivery(ctx, first))
+
+	succeeded, err := suite.repo.GetDeliveries(ctx, enabled.ID, []domain.WebhookDeliveryStatus{domain.WebhookDeliverySucceeded}, 0)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.WebhookDelivery{*first}, succeeded)
+
+	all, err := suite.repo.GetDeliveries(ctx, enabled.ID, nil, 2)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), all, 2)
+	assert.Equal(suite.T(), first.ID, all[0].ID)
+	assert.Equal(suite.T(), second.ID, all[1].ID)
+
+	require.NoError(suite.T(), suite.repo.DeleteWebhook(ctx, enabled.ID))
+	_, err = suite.repo.GetDeliveryByID(ctx, late.ID)
+	assert.ErrorIs(suite.T(), err, usecase.ErrDeliveryNotFound)
+}
+
+func TestWebhookTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestStart(t *testing.T) {
+	t.Run("ok, not recording span keeps context", func(t *testing.T) {
+		ctx := context.Background()
+
+		spanCtx, span := Start(ctx, noop.NewTracerProvider().Tracer("test"), "test")
+		defer span.End()
+
+		assert.Equal(t, ctx, spanCtx)
+		assert.Empty(t, TraceID(spanCtx))
+	})
+
+	t.Run("ok, recording span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		spanCtx, span := StartQuery(context.Background(), provider.Tracer("test"), "query", "SELECT 1;")
+		End(span, errors.New("some error"))
+
+		assert.NotEmpty(t, TraceID(spanCtx))
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "query", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Contains(t, spans[0].Attributes(), semconv.DBStatement("SELECT 1;"))
+	})
+}
@@ -0,0 +1,535 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	maxAlertRuleNameLength = 64
+	// minStaleAfter, maxStaleAfter - допустимое время молчания датчика в правиле stale
+	minStaleAfter = time.Minute
+	maxStaleAfter = 30 * 24 * time.Hour
+	// defaultAlerts, maxAlerts - сколько тревог отдаётся по умолчанию и максимум
+	defaultAlerts = 50
+	maxAlerts     = 1000
+	// alertChangesBatch - сколько изменений тревог читается за один запрос при наблюдении
+	alertChangesBatch = 100
+)
+
+var alertOperatorSigns = map[domain.AlertOperator]string{
+	domain.AlertOperatorGT:  ">",
+	domain.AlertOperatorGTE: ">=",
+	domain.AlertOperatorLT:  "<",
+	domain.AlertOperatorLTE: "<=",
+	domain.AlertOperatorEQ:  "=",
+	domain.AlertOperatorNE:  "!=",
+}
+
+// Alert - тревоги по датчикам.
+//
+// Правила threshold проверяются на каждом событии, принятом ReceiveEvent (CheckEvent подключается
+// получателем событий usecase.Event), правила stale - периодически вызовом CheckStale. Сработавшее
+// правило поднимает тревогу, а пока она не закрыта, повторные срабатывания только увеличивают её
+// счётчик. Тревогу принимает в работу и закрывает пользователь, к которому привязан датчик.
+type Alert struct {
+	repo    AlertRepository
+	sensors *Sensor
+	users   *User
+	now     func() time.Time
+}
+
+func NewAlert(repo AlertRepository, sensors *Sensor, users *User) *Alert {
+	return &Alert{
+		repo:    repo,
+		sensors: sensors,
+		users:   users,
+		now:     time.Now,
+	}
+}
+
+func (a *Alert) validate(ctx context.Context, rule *domain.AlertRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" || utf8.RuneCountInString(rule.Name) > maxAlertRuleNameLength {
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidAlertRule, maxAlertRuleNameLength)
+	}
+
+	switch rule.Kind {
+	case domain.AlertRuleThreshold:
+		if rule.Channel == "" {
+			rule.Channel = domain.DefaultChannel
+		}
+		if !channelNameRe.MatchString(rule.Channel) {
+			return fmt.Errorf("%w: channel name must match %s", ErrInvalidAlertRule, channelNameRe)
+		}
+		if _, ok := alertOperatorSigns[rule.Operator]; !ok {
+			return fmt.Errorf("%w: unknown operator %q", ErrInvalidAlertRule, rule.Operator)
+		}
+		if math.IsNaN(rule.Threshold) || math.IsInf(rule.Threshold, 0) {
+			return fmt.Errorf("%w: threshold must be finite", ErrInvalidAlertRule)
+		}
+		if rule.StaleAfter != 0 {
+			return fmt.Errorf("%w: stale_after is only allowed in stale rules", ErrInvalidAlertRule)
+		}
+
+	case domain.AlertRuleStale:
+		if rule.StaleAfter < minStaleAfter || rule.StaleAfter > maxStaleAfter {
+			return fmt.Errorf("%w: stale_after must be between %s and %s", ErrInvalidAlertRule, minStaleAfter, maxStaleAfter)
+		}
+		if rule.Channel != "" || rule.Operator != "" || rule.Threshold != 0 {
+			return fmt.Errorf("%w: channel, operator and threshold are only allowed in threshold rules", ErrInvalidAlertRule)
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidAlertRule, rule.Kind)
+	}
+
+	if _, err := a.sensors.GetSensorByID(ctx, rule.SensorID); err != nil {
+		if errors.Is(err, ErrSensorNotFound) {
+			return fmt.Errorf("%w: sensor %d not found", ErrInvalidAlertRule, rule.SensorID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// CreateAlertRule - добавляет правило тревоги
+func (a *Alert) CreateAlertRule(ctx context.Context, rule *domain.AlertRule) (_ *domain.AlertRule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.CreateAlertRule")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if err := a.validate(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	now := a.now().UTC()
+	rule.ID = 0
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	if err := a.repo.SaveAlertRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "alert rule created",
+		slog.Int64("alert_rule_id", rule.ID), logging.SensorID(rule.SensorID))
+
+	return rule, nil
+}
+
+// UpdateAlertRule - заменяет правило id. Уже поднятые правилом тревоги не меняются.
+func (a *Alert) UpdateAlertRule(ctx context.Context, id int64, rule *domain.AlertRule) (_ *domain.AlertRule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.UpdateAlertRule",
+		trace.WithAttributes(attribute.Int64("alert_rule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	stored, err := a.repo.GetAlertRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.validate(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	rule.ID = stored.ID
+	rule.CreatedAt = stored.CreatedAt
+	rule.UpdatedAt = a.now().UTC()
+
+	if err := a.repo.SaveAlertRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "alert rule updated", slog.Int64("alert_rule_id", rule.ID))
+
+	return rule, nil
+}
+
+// GetAlertRule - правило тревоги по ID
+func (a *Alert) GetAlertRule(ctx context.Context, id int64) (_ *domain.AlertRule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.GetAlertRule",
+		trace.WithAttributes(attribute.Int64("alert_rule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return a.repo.GetAlertRuleByID(ctx, id)
+}
+
+// GetAlertRules - правила датчика по возрастанию ID, sensorID = 0 - правила всех датчиков
+func (a *Alert) GetAlertRules(ctx context.Context, sensorID int64) (_ []domain.AlertRule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.GetAlertRules")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if sensorID != 0 {
+		if _, err := a.sensors.GetSensorByID(ctx, sensorID); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.repo.GetAlertRules(ctx, sensorID)
+}
+
+// DeleteAlertRule - удаляет правило, поднятые им тревоги остаются
+func (a *Alert) DeleteAlertRule(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.DeleteAlertRule",
+		trace.WithAttributes(attribute.Int64("alert_rule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := a.repo.DeleteAlertRule(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "alert rule deleted", slog.Int64("alert_rule_id", id))
+
+	return nil
+}
+
+// CheckEvent - получатель событий usecase.Event: проверяет правила threshold датчика
+func (a *Alert) CheckEvent(ctx context.Context, event domain.Event, sensor domain.Sensor) {
+	logger := logging.FromContext(ctx).With(logging.SensorID(sensor.ID))
+
+	rules, err := a.repo.GetAlertRules(ctx, sensor.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "can't get alert rules", logging.Error(err))
+		return
+	}
+
+	for _, rule := range rules {
+		if rule.Kind != domain.AlertRuleThreshold {
+			continue
+		}
+
+		v, ok := event.Value(rule.Channel)
+		if !ok {
+			continue
+		}
+		value := v.Number()
+		if rule.Channel == domain.DefaultChannel {
+			value = sensor.Calibration.Apply(value)
+		}
+		if !rule.Operator.Compare(value, rule.Threshold) {
+			continue
+		}
+
+		message := fmt.Sprintf("%s: %s %g %s %g", rule.Name, rule.Channel, value,
+			alertOperatorSigns[rule.Operator], rule.Threshold)
+		if err := a.raise(ctx, rule, message, &value); err != nil {
+			logger.ErrorContext(ctx, "can't raise alert", slog.Int64("alert_rule_id", rule.ID), logging.Error(err))
+		}
+	}
+}
+
+// CheckStale - проверяет правила stale и возвращает число сработавших. Правило срабатывает один раз
+// за каждый период молчания датчика; выключенные датчики не проверяются.
+func (a *Alert) CheckStale(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.CheckStale")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	rules, err := a.repo.GetAlertRules(ctx, 0)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "can't get alert rules", logging.Error(err))
+		return 0, err
+	}
+
+	now := a.now().UTC()
+	raised := 0
+	for _, rule := range rules {
+		if rule.Kind != domain.AlertRuleStale {
+			continue
+		}
+		if ctx.Err() != nil {
+			return raised, ctx.Err()
+		}
+
+		ok, err := a.checkStale(ctx, rule, now)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "can't check stale sensor",
+				slog.Int64("alert_rule_id", rule.ID), logging.SensorID(rule.SensorID), logging.Error(err))
+			continue
+		}
+		if ok {
+			raised++
+		}
+	}
+
+	return raised, nil
+}
+
+func (a *Alert) checkStale(ctx context.Context, rule domain.AlertRule, now time.Time) (bool, error) {
+	sensor, err := a.sensors.GetSensorByID(ctx, rule.SensorID)
+	if err != nil {
+		return false, err
+	}
+	if !sensor.IsActive {
+		return false, nil
+	}
+
+	lastActivity := sensor.LastActivity
+	if lastActivity.IsZero() {
+		lastActivity = sensor.RegisteredAt
+	}
+	silence := now.Sub(lastActivity)
+	if silence < rule.StaleAfter {
+		return false, nil
+	}
+
+	// это молчание уже зарегистрировано, если тревога срабатывала после последнего события
+	last, err := a.repo.GetLastAlertByRuleID(ctx, rule.ID)
+	switch {
+	case errors.Is(err, ErrAlertNotFound):
+	case err != nil:
+		return false, err
+	case last.LastSeenAt.After(lastActivity):
+		return false, nil
+	}
+
+	message := fmt.Sprintf("%s: no events for %s", rule.Name, silence.Truncate(time.Second))
+	return true, a.raise(ctx, rule, message, nil)
+}
+
+// raise - регистрирует срабатывание правила
+func (a *Alert) raise(ctx context.Context, rule domain.AlertRule, message string, value *float64) error {
+	now := a.now().UTC()
+	alert := &domain.Alert{
+		RuleID:     rule.ID,
+		SensorID:   rule.SensorID,
+		Kind:       rule.Kind,
+		Status:     domain.AlertOpen,
+		Message:    message,
+		Value:      value,
+		Count:      1,
+		OpenedAt:   now,
+		LastSeenAt: now,
+	}
+	if err := a.repo.RaiseAlert(ctx, alert); err != nil {
+		return err
+	}
+
+	logger := logging.FromContext(ctx).With(slog.Int64("alert_id", alert.ID), logging.SensorID(alert.SensorID))
+	if alert.Count == 1 {
+		logger.InfoContext(ctx, "alert raised", slog.String("message", message))
+	} else {
+		logger.DebugContext(ctx, "alert repeated", slog.Int64("count", alert.Count))
+	}
+
+	return nil
+}
+
+// userSensorIDs - ID датчиков, привязанных к пользователю
+func (a *Alert) userSensorIDs(ctx context.Context, userID int64) ([]int64, error) {
+	sensors, err := a.users.GetUserSensors(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(sensors))
+	for _, sensor := range sensors {
+		ids = append(ids, sensor.ID)
+	}
+	return ids, nil
+}
+
+// GetUserAlerts - последние limit тревог датчиков пользователя в состояниях statuses, от новых к старым.
+// Пустой statuses - тревоги в любом состоянии, limit = 0 - значение по умолчанию.
+func (a *Alert) GetUserAlerts(ctx context.Context, userID int64, statuses []domain.AlertStatus, limit int) (_ []domain.Alert, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.GetUserAlerts",
+		trace.WithAttributes(attribute.Int64("user.id", userID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if limit < 0 || limit > maxAlerts {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidAlertQuery, maxAlerts)
+	}
+	for _, status := range statuses {
+		if status != domain.AlertOpen && status != domain.AlertAcknowledged && status != domain.AlertResolved {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidAlertQuery, status)
+		}
+	}
+	if limit == 0 {
+		limit = defaultAlerts
+	}
+
+	ids, err := a.userSensorIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []domain.Alert{}, nil
+	}
+
+	return a.repo.GetAlerts(ctx, domain.AlertQuery{SensorIDs: ids, Statuses: statuses, Limit: limit})
+}
+
+// GetUserAlert - тревога по ID, если её датчик привязан к пользователю, иначе ErrAlertNotFound
+func (a *Alert) GetUserAlert(ctx context.Context, userID, id int64) (_ *domain.Alert, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.GetUserAlert",
+		trace.WithAttributes(attribute.Int64("user.id", userID), attribute.Int64("alert.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return a.userAlert(ctx, userID, id)
+}
+
+func (a *Alert) userAlert(ctx context.Context, userID, id int64) (*domain.Alert, error) {
+	ids, err := a.userSensorIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := a.repo.GetAlertByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(ids, alert.SensorID) {
+		return nil, ErrAlertNotFound
+	}
+
+	return alert, nil
+}
+
+// AcknowledgeAlert - пользователь принимает открытую тревогу в работу
+func (a *Alert) AcknowledgeAlert(ctx context.Context, userID, id int64) (_ *domain.Alert, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.AcknowledgeAlert",
+		trace.WithAttributes(attribute.Int64("user.id", userID), attribute.Int64("alert.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	return a.transition(ctx, userID, id, []domain.AlertStatus{domain.AlertOpen}, domain.AlertAcknowledged)
+}
+
+// ResolveAlert - пользователь закрывает открытую или принятую в работу тревогу
+func (a *Alert) ResolveAlert(ctx context.Context, userID, id int64) (_ *domain.Alert, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Alert.ResolveAlert",
+		trace.WithAttributes(attribute.Int64("user.id", userID), attribute.Int64("alert.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	return a.transition(ctx, userID, id, []domain.AlertStatus{domain.AlertOpen, domain.AlertAcknowledged}, domain.AlertResolved)
+}
+
+func (a *Alert) transition(ctx context.Context, userID, id int64, from []domain.AlertStatus, to domain.AlertStatus) (*domain.Alert, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if _, err := a.userAlert(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	alert, err := a.repo.UpdateAlertStatus(ctx, id, from, to, userID, a.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "alert "+string(to),
+		slog.Int64("alert_id", id), logging.UserID(userID))
+
+	return alert, nil
+}
+
+// WatchUserAlerts - раз в interval передаёт в fn тревоги датчиков пользователя, изменённые с прошлой
+// проверки, по возрастанию Revision. Если since не нулевое, сначала передаёт изменения после ревизии since.
+// Блокируется до отмены ctx или ошибки fn.
+func (a *Alert) WatchUserAlerts(
+	ctx context.Context,
+	userID int64,
+	since int64,
+	interval time.Duration,
+	fn func(domain.Alert) error,
+) error {
+	if _, err := a.userSensorIDs(ctx, userID); err != nil {
+		return err
+	}
+
+	if since == 0 {
+		revision, err := a.repo.GetAlertRevision(ctx)
+		if err != nil {
+			return err
+		}
+		since = revision
+	} else if err := a.sendAlertChanges(ctx, userID, &since, fn); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := a.sendAlertChanges(ctx, userID, &since, fn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendAlertChanges - передаёт в fn изменения тревог после ревизии since и сдвигает since.
+// Привязки датчиков перечитываются каждый раз, чтобы поток следовал за ними.
+func (a *Alert) sendAlertChanges(ctx context.Context, userID int64, since *int64, fn func(domain.Alert) error) error {
+	ids, err := a.userSensorIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for {
+		changes, err := a.repo.GetAlertChanges(ctx, ids, *since, alertChangesBatch)
+		if err != nil {
+			return err
+		}
+		for _, alert := range changes {
+			if err := fn(alert); err != nil {
+				return err
+			}
+			*since = alert.Revision
+		}
+		if len(changes) < alertChangesBatch {
+			return nil
+		}
+	}
+}
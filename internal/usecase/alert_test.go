@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_alert_CreateAlertRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).AnyTimes().Return(&domain.Sensor{ID: 1}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(2)).AnyTimes().Return(nil, ErrSensorNotFound)
+
+	t.Run("ok, threshold on default channel", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().SaveAlertRule(ctx, gomock.Any()).Times(1).Return(nil)
+
+		rule, err := NewAlert(repo, NewSensor(sr), nil).CreateAlertRule(ctx, &domain.AlertRule{
+			SensorID:  1,
+			Name:      " leak ",
+			Kind:      domain.AlertRuleThreshold,
+			Operator:  domain.AlertOperatorGTE,
+			Threshold: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "leak", rule.Name)
+		assert.Equal(t, domain.DefaultChannel, rule.Channel)
+	})
+
+	tests := []struct {
+		name string
+		rule domain.AlertRule
+	}{
+		{"err, empty name", domain.AlertRule{SensorID: 1, Kind: domain.AlertRuleThreshold, Operator: domain.AlertOperatorGT}},
+		{"err, unknown kind", domain.AlertRule{SensorID: 1, Name: "a", Kind: "flood"}},
+		{"err, operator", domain.AlertRule{SensorID: 1, Name: "a", Kind: domain.AlertRuleThreshold, Operator: "like"}},
+		{"err, channel", domain.AlertRule{SensorID: 1, Name: "a", Kind: domain.AlertRuleThreshold,
+			Operator: domain.AlertOperatorGT, Channel: "a b"}},
+		{"err, stale_after in threshold", domain.AlertRule{SensorID: 1, Name: "a", Kind: domain.AlertRuleThreshold,
+			Operator: domain.AlertOperatorGT, StaleAfter: time.Hour}},
+		{"err, short stale_after", domain.AlertRule{SensorID: 1, Name: "a", Kind: domain.AlertRuleStale, StaleAfter: time.Second}},
+		{"err, operator in stale", domain.AlertRule{SensorID: 1, Name: "a", Kind: domain.AlertRuleStale,
+			StaleAfter: time.Hour, Operator: domain.AlertOperatorGT}},
+		{"err, unknown sensor", domain.AlertRule{SensorID: 2, Name: "a", Kind: domain.AlertRuleStale, StaleAfter: time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockAlertRepository(ctrl)
+			repo.EXPECT().SaveAlertRule(gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := NewAlert(repo, NewSensor(sr), nil).CreateAlertRule(context.Background(), &tt.rule)
+			assert.ErrorIs(t, err, ErrInvalidAlertRule)
+		})
+	}
+}
+
+func Test_alert_CheckEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rules := []domain.AlertRule{
+		{ID: 1, SensorID: 5, Name: "hot", Kind: domain.AlertRuleThreshold, Channel: domain.DefaultChannel,
+			Operator: domain.AlertOperatorGT, Threshold: 30},
+		{ID: 2, SensorID: 5, Name: "humid", Kind: domain.AlertRuleThreshold, Channel: "humidity",
+			Operator: domain.AlertOperatorGTE, Threshold: 80},
+		{ID: 3, SensorID: 5, Name: "silent", Kind: domain.AlertRuleStale, StaleAfter: time.Hour},
+	}
+	// сырое значение 16 после калибровки - 32 градуса
+	sensor := domain.Sensor{ID: 5, Calibration: &domain.Calibration{Kind: domain.CalibrationLinear, Scale: 2}}
+
+	t.Run("ok, calibrated value over threshold", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertRules(ctx, int64(5)).Times(1).Return(rules, nil)
+		repo.EXPECT().RaiseAlert(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, alert *domain.Alert) error {
+			assert.Equal(t, int64(1), alert.RuleID)
+			assert.Equal(t, domain.AlertOpen, alert.Status)
+			assert.Equal(t, 32.0, *alert.Value)
+			assert.Equal(t, "hot: value 32 > 30", alert.Message)
+			alert.ID, alert.Count = 1, 1
+			return nil
+		})
+
+		NewAlert(repo, nil, nil).CheckEvent(ctx, domain.Event{SensorID: 5, Payload: 16}, sensor)
+	})
+
+	t.Run("ok, other channel", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertRules(ctx, int64(5)).Times(1).Return(rules, nil)
+		repo.EXPECT().RaiseAlert(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, alert *domain.Alert) error {
+			assert.Equal(t, int64(2), alert.RuleID)
+			return nil
+		})
+
+		event := domain.Event{SensorID: 5}
+		event.SetChannels(map[string]domain.Value{"value": domain.FloatValue(10), "humidity": domain.IntValue(85)})
+		NewAlert(repo, nil, nil).CheckEvent(ctx, event, sensor)
+	})
+
+	t.Run("ok, nothing raised", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertRules(ctx, int64(5)).Times(1).Return(rules, nil)
+		repo.EXPECT().RaiseAlert(gomock.Any(), gomock.Any()).Times(0)
+
+		NewAlert(repo, nil, nil).CheckEvent(ctx, domain.Event{SensorID: 5, Payload: 15}, sensor)
+	})
+}
+
+func Test_alert_CheckStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	lastActivity := now.Add(-2 * time.Hour)
+	rules := []domain.AlertRule{
+		{ID: 1, SensorID: 5, Name: "hot", Kind: domain.AlertRuleThreshold, Operator: domain.AlertOperatorGT},
+		{ID: 2, SensorID: 5, Name: "silent", Kind: domain.AlertRuleStale, StaleAfter: time.Hour},
+		{ID: 3, SensorID: 6, Name: "off", Kind: domain.AlertRuleStale, StaleAfter: time.Hour},
+		{ID: 4, SensorID: 7, Name: "fresh", Kind: domain.AlertRuleStale, StaleAfter: 3 * time.Hour},
+	}
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(5)).AnyTimes().
+		Return(&domain.Sensor{ID: 5, IsActive: true, LastActivity: lastActivity}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(6)).AnyTimes().
+		Return(&domain.Sensor{ID: 6, IsActive: false, LastActivity: lastActivity}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(7)).AnyTimes().
+		Return(&domain.Sensor{ID: 7, IsActive: true, LastActivity: lastActivity}, nil)
+
+	newAlert := func(repo AlertRepository) *Alert {
+		a := NewAlert(repo, NewSensor(sr), nil)
+		a.now = func() time.Time { return now }
+		return a
+	}
+
+	t.Run("ok, raised once per silence", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertRules(ctx, int64(0)).Times(1).Return(rules, nil)
+		repo.EXPECT().GetLastAlertByRuleID(gomock.Any(), int64(2)).Times(1).
+			Return(&domain.Alert{ID: 1, RuleID: 2, LastSeenAt: lastActivity.Add(-time.Hour)}, nil)
+		repo.EXPECT().RaiseAlert(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, alert *domain.Alert) error {
+			assert.Equal(t, int64(2), alert.RuleID)
+			assert.Equal(t, domain.AlertRuleStale, alert.Kind)
+			assert.Equal(t, "silent: no events for 2h0m0s", alert.Message)
+			return nil
+		})
+
+		raised, err := newAlert(repo).CheckStale(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, raised)
+	})
+
+	t.Run("ok, already raised", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertRules(ctx, int64(0)).Times(1).Return(rules, nil)
+		repo.EXPECT().GetLastAlertByRuleID(gomock.Any(), int64(2)).Times(1).
+			Return(&domain.Alert{ID: 1, RuleID: 2, LastSeenAt: now.Add(-time.Minute)}, nil)
+		repo.EXPECT().RaiseAlert(gomock.Any(), gomock.Any()).Times(0)
+
+		raised, err := newAlert(repo).CheckStale(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, raised)
+	})
+}
+
+func Test_alert_transitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	ur := NewMockUserRepository(ctrl)
+	ur.EXPECT().GetUserByID(gomock.Any(), int64(1)).AnyTimes().Return(&domain.User{ID: 1}, nil)
+	ur.EXPECT().GetUserByID(gomock.Any(), int64(2)).AnyTimes().Return(nil, ErrUserNotFound)
+	sor := NewMockSensorOwnerRepository(ctrl)
+	sor.EXPECT().GetSensorsByUserID(gomock.Any(), int64(1)).AnyTimes().
+		Return([]domain.SensorOwner{{UserID: 1, SensorID: 5}}, nil)
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(5)).AnyTimes().Return(&domain.Sensor{ID: 5}, nil)
+
+	newAlert := func(repo AlertRepository) *Alert {
+		a := NewAlert(repo, NewSensor(sr), NewUser(ur, sor, sr))
+		a.now = func() time.Time { return now }
+		return a
+	}
+
+	t.Run("ok, acknowledge", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertByID(ctx, int64(10)).Times(1).Return(&domain.Alert{ID: 10, SensorID: 5}, nil)
+		repo.EXPECT().UpdateAlertStatus(ctx, int64(10), []domain.AlertStatus{domain.AlertOpen},
+			domain.AlertAcknowledged, int64(1), now).Times(1).
+			Return(&domain.Alert{ID: 10, SensorID: 5, Status: domain.AlertAcknowledged}, nil)
+
+		alert, err := newAlert(repo).AcknowledgeAlert(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, domain.AlertAcknowledged, alert.Status)
+	})
+
+	t.Run("ok, resolve", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertByID(ctx, int64(10)).Times(1).Return(&domain.Alert{ID: 10, SensorID: 5}, nil)
+		repo.EXPECT().UpdateAlertStatus(ctx, int64(10),
+			[]domain.AlertStatus{domain.AlertOpen, domain.AlertAcknowledged}, domain.AlertResolved, int64(1), now).
+			Times(1).Return(nil, ErrAlertStatusConflict)
+
+		_, err := newAlert(repo).ResolveAlert(ctx, 1, 10)
+		assert.ErrorIs(t, err, ErrAlertStatusConflict)
+	})
+
+	t.Run("err, sensor of another user", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockAlertRepository(ctrl)
+		repo.EXPECT().GetAlertByID(ctx, int64(11)).Times(1).Return(&domain.Alert{ID: 11, SensorID: 6}, nil)
+		repo.EXPECT().UpdateAlertStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := newAlert(repo).AcknowledgeAlert(ctx, 1, 11)
+		assert.ErrorIs(t, err, ErrAlertNotFound)
+	})
+
+	t.Run("err, unknown user", func(t *testing.T) {
+		_, err := newAlert(NewMockAlertRepository(ctrl)).GetUserAlerts(context.Background(), 2, nil, 0)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("err, unknown status", func(t *testing.T) {
+		_, err := newAlert(NewMockAlertRepository(ctrl)).GetUserAlerts(context.Background(), 1, []domain.AlertStatus{"closed"}, 0)
+		assert.ErrorIs(t, err, ErrInvalidAlertQuery)
+	})
+}
+
+func Test_alert_WatchUserAlerts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ur := NewMockUserRepository(ctrl)
+	ur.EXPECT().GetUserByID(gomock.Any(), int64(1)).AnyTimes().Return(&domain.User{ID: 1}, nil)
+	sor := NewMockSensorOwnerRepository(ctrl)
+	sor.EXPECT().GetSensorsByUserID(gomock.Any(), int64(1)).AnyTimes().
+		Return([]domain.SensorOwner{{UserID: 1, SensorID: 5}}, nil)
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(5)).AnyTimes().Return(&domain.Sensor{ID: 5}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	repo := NewMockAlertRepository(ctrl)
+	gomock.InOrder(
+		repo.EXPECT().GetAlertChanges(gomock.Any(), []int64{5}, int64(3), alertChangesBatch).Times(1).
+			Return([]domain.Alert{{ID: 1, SensorID: 5, Revision: 4}}, nil),
+		repo.EXPECT().GetAlertChanges(gomock.Any(), []int64{5}, int64(4), alertChangesBatch).Times(1).
+			Return([]domain.Alert{{ID: 2, SensorID: 5, Revision: 6}}, nil),
+	)
+	repo.EXPECT().GetAlertChanges(gomock.Any(), []int64{5}, int64(6), alertChangesBatch).AnyTimes().
+		Return([]domain.Alert{}, nil)
+
+	errStop := errors.New("stop")
+	var got []int64
+	err := NewAlert(repo, NewSensor(sr), NewUser(ur, sor, sr)).WatchUserAlerts(ctx, 1, 3, time.Millisecond,
+		func(alert domain.Alert) error {
+			got = append(got, alert.Revision)
+			if len(got) == 2 {
+				return errStop
+			}
+			return nil
+		})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, []int64{4, 6}, got)
+}
@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// maxUnitLength - сколько символов может быть в единице измерения датчика
+	maxUnitLength = 16
+	// maxCalibrationCoefficients - наибольшее число коэффициентов полинома калибровки
+	maxCalibrationCoefficients = 8
+	// maxCalibrationPoints - наибольшее число точек в таблице калибровки
+	maxCalibrationPoints = 256
+)
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// ValidateCalibration - проверяет калибровку датчика перед сохранением, nil - без калибровки
+func ValidateCalibration(c *domain.Calibration) error {
+	if c == nil {
+		return nil
+	}
+
+	switch c.Kind {
+	case domain.CalibrationLinear:
+		if !finite(c.Scale) || !finite(c.Offset) || c.Scale == 0 {
+			return fmt.Errorf("%w: linear scale must be finite and non-zero", ErrInvalidCalibration)
+		}
+	case domain.CalibrationPolynomial:
+		if len(c.Coefficients) == 0 || len(c.Coefficients) > maxCalibrationCoefficients {
+			return fmt.Errorf("%w: polynomial must have 1 to %d coefficients", ErrInvalidCalibration, maxCalibrationCoefficients)
+		}
+		for _, v := range c.Coefficients {
+			if !finite(v) {
+				return fmt.Errorf("%w: polynomial coefficients must be finite", ErrInvalidCalibration)
+			}
+		}
+	case domain.CalibrationLookup:
+		if len(c.Points) < 2 || len(c.Points) > maxCalibrationPoints {
+			return fmt.Errorf("%w: lookup table must have 2 to %d points", ErrInvalidCalibration, maxCalibrationPoints)
+		}
+		for i, p := range c.Points {
+			if !finite(p.Raw) || !finite(p.Value) {
+				return fmt.Errorf("%w: lookup points must be finite", ErrInvalidCalibration)
+			}
+			if i > 0 && p.Raw <= c.Points[i-1].Raw {
+				return fmt.Errorf("%w: lookup raw values must be strictly increasing", ErrInvalidCalibration)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidCalibration, c.Kind)
+	}
+	return nil
+}
+
+// NormalizeUnit - проверяет единицу измерения датчика и возвращает её каноническое имя
+func NormalizeUnit(unit string) (string, error) {
+	unit = domain.CanonicalUnit(unit)
+	if len([]rune(unit)) > maxUnitLength {
+		return "", fmt.Errorf("%w: unit is longer than %d characters", ErrInvalidUnit, maxUnitLength)
+	}
+	return unit, nil
+}
+
+// Conversion - перевод сырых значений канала датчика в значения в единицах Unit.
+// Калибровка и единица датчика относятся к каналу domain.DefaultChannel,
+// значения остальных каналов не меняются.
+type Conversion struct {
+	Unit string
+
+	calibration *domain.Calibration
+	scale       float64
+	offset      float64
+}
+
+// Convert - значение в единицах Unit для сырого значения raw
+func (c *Conversion) Convert(raw float64) float64 {
+	return c.calibration.Apply(raw)*c.scale + c.offset
+}
+
+// ConvertAggregate - сводка в единицах Unit. Сводку можно пересчитать только при линейной калибровке,
+// иначе возвращается ErrInvalidUnit.
+func (c *Conversion) ConvertAggregate(agg domain.Aggregate) (domain.Aggregate, error) {
+	scale, offset, ok := c.calibration.Affine()
+	if !ok {
+		return domain.Aggregate{}, fmt.Errorf("%w: aggregates can't be converted with %s calibration",
+			ErrInvalidUnit, c.calibration.Kind)
+	}
+	scale, offset = scale*c.scale, offset*c.scale+c.offset
+
+	agg.Min, agg.Max = scale*agg.Min+offset, scale*agg.Max+offset
+	if scale < 0 {
+		agg.Min, agg.Max = agg.Max, agg.Min
+	}
+	agg.Avg = scale*agg.Avg + offset
+	agg.Sum = scale*agg.Sum + offset*float64(agg.Count)
+	return agg, nil
+}
+
+// sensorUnit - единица значений датчика после калибровки: своя или единица типа датчика
+func (e *Event) sensorUnit(ctx context.Context, sensor *domain.Sensor) string {
+	if sensor.Unit != "" {
+		return sensor.Unit
+	}
+	if spec, err := e.types.GetSensorType(ctx, sensor.Type); err == nil {
+		return domain.CanonicalUnit(spec.Unit)
+	}
+	return ""
+}
+
+// conversion - перевод значений канала датчика в единицу unit, пустая unit - единица датчика
+func (e *Event) conversion(ctx context.Context, sensor *domain.Sensor, channel, unit string) (*Conversion, error) {
+	if channel != domain.DefaultChannel {
+		if unit != "" {
+			return nil, fmt.Errorf("%w: only channel %s has a unit", ErrInvalidUnit, domain.DefaultChannel)
+		}
+		return &Conversion{scale: 1}, nil
+	}
+
+	from := e.sensorUnit(ctx, sensor)
+	scale, offset, err := domain.ConvertUnit(from, unit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidUnit, err)
+	}
+	if unit == "" {
+		unit = from
+	}
+
+	return &Conversion{
+		Unit:        domain.CanonicalUnit(unit),
+		calibration: sensor.Calibration,
+		scale:       scale,
+		offset:      offset,
+	}, nil
+}
+
+// GetReadingsByTimeFrame - события датчика за период со значениями канала после калибровки
+// в единицах unit. Пустой channel - domain.DefaultChannel, пустая unit - единица датчика.
+// События без канала пропускаются, сами события остаются с сырыми значениями.
+func (e *Event) GetReadingsByTimeFrame(
+	ctx context.Context,
+	id int64,
+	channel string,
+	start, finish time.Time,
+	unit string,
+) (_ []domain.Reading, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.GetReadingsByTimeFrame",
+		trace.WithAttributes(attribute.Int64("sensor.id", id), attribute.String("unit", unit)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if channel == "" {
+		channel = domain.DefaultChannel
+	}
+
+	sensor, err := e.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	conv, err := e.conversion(ctx, sensor, channel, unit)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := e.er.GetEventsByTimeFrame(ctx, id, start, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	readings := make([]domain.Reading, 0, len(events))
+	for _, event := range events {
+		v, ok := event.Value(channel)
+		if !ok {
+			continue
+		}
+		readings = append(readings, domain.Reading{Event: event, Value: conv.Convert(v.Number()), Unit: conv.Unit})
+	}
+	return readings, nil
+}
+
+// AggregateReadings - то же, что AggregateEvents, но значения переводятся калибровкой датчика
+// в единицы unit; возвращает также итоговую единицу
+func (e *Event) AggregateReadings(
+	ctx context.Context,
+	id int64,
+	channel string,
+	start, finish time.Time,
+	interval time.Duration,
+	unit string,
+) (_ []domain.Aggregate, _ string, err error) {
+	aggs, err := e.AggregateEvents(ctx, id, channel, start, finish, interval)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if channel == "" {
+		channel = domain.DefaultChannel
+	}
+
+	sensor, err := e.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conv, err := e.conversion(ctx, sensor, channel, unit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range aggs {
+		if aggs[i], err = conv.ConvertAggregate(aggs[i]); err != nil {
+			return nil, "", err
+		}
+	}
+	return aggs, conv.Unit, nil
+}
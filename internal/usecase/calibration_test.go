@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCalibration(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration *domain.Calibration
+		wantErr     bool
+	}{
+		{name: "ok, none"},
+		{name: "ok, linear", calibration: &domain.Calibration{Kind: domain.CalibrationLinear, Scale: 0.5, Offset: -40}},
+		{name: "ok, polynomial", calibration: &domain.Calibration{Kind: domain.CalibrationPolynomial, Coefficients: []float64{1, 2, 3}}},
+		{name: "ok, lookup", calibration: &domain.Calibration{Kind: domain.CalibrationLookup, Points: []domain.CalibrationPoint{{Raw: 0, Value: 0}, {Raw: 10, Value: 100}}}},
+		{name: "err, unknown kind", calibration: &domain.Calibration{Kind: "log"}, wantErr: true},
+		{name: "err, zero scale", calibration: &domain.Calibration{Kind: domain.CalibrationLinear, Offset: 1}, wantErr: true},
+		{name: "err, infinite offset", calibration: &domain.Calibration{Kind: domain.CalibrationLinear, Scale: 1, Offset: math.Inf(1)}, wantErr: true},
+		{name: "err, no coefficients", calibration: &domain.Calibration{Kind: domain.CalibrationPolynomial}, wantErr: true},
+		{name: "err, one point", calibration: &domain.Calibration{Kind: domain.CalibrationLookup, Points: []domain.CalibrationPoint{{Raw: 0, Value: 0}}}, wantErr: true},
+		{
+			name:        "err, unordered points",
+			calibration: &domain.Calibration{Kind: domain.CalibrationLookup, Points: []domain.CalibrationPoint{{Raw: 10, Value: 0}, {Raw: 10, Value: 1}}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCalibration(tt.calibration)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCalibration)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCalibration_Apply(t *testing.T) {
+	lookup := &domain.Calibration{
+		Kind:   domain.CalibrationLookup,
+		Points: []domain.CalibrationPoint{{Raw: 0, Value: 0}, {Raw: 10, Value: 100}, {Raw: 20, Value: 120}},
+	}
+	assert.Equal(t, 50.0, lookup.Apply(5))
+	assert.Equal(t, 110.0, lookup.Apply(15))
+	assert.Equal(t, 0.0, lookup.Apply(-5))
+	assert.Equal(t, 120.0, lookup.Apply(25))
+
+	poly := &domain.Calibration{Kind: domain.CalibrationPolynomial, Coefficients: []float64{1, 2, 3}}
+	assert.Equal(t, 17.0, poly.Apply(2))
+
+	var none *domain.Calibration
+	assert.Equal(t, 7.0, none.Apply(7))
+}
+
+func TestConvertUnit(t *testing.T) {
+	scale, offset, err := domain.ConvertUnit("C", "°F")
+	require.NoError(t, err)
+	assert.InDelta(t, 212, 100*scale+offset, 1e-9)
+
+	scale, offset, err = domain.ConvertUnit("°F", "kelvin")
+	require.NoError(t, err)
+	assert.InDelta(t, 273.15, 32*scale+offset, 1e-9)
+
+	_, _, err = domain.ConvertUnit("°C", "hPa")
+	assert.ErrorIs(t, err, domain.ErrIncompatibleUnits)
+
+	_, _, err = domain.ConvertUnit("", "°C")
+	assert.ErrorIs(t, err, domain.ErrIncompatibleUnits)
+}
+
+func Test_event_GetReadingsByTimeFrame(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start, finish := time.Unix(0, 0), time.Unix(100, 0)
+	sensor := &domain.Sensor{
+		ID:          1,
+		Unit:        "°C",
+		Calibration: &domain.Calibration{Kind: domain.CalibrationLinear, Scale: 0.5, Offset: -40},
+	}
+	events := []domain.Event{
+		{Timestamp: time.Unix(1, 0), SensorID: 1, Payload: 100},
+		{Timestamp: time.Unix(2, 0), SensorID: 1, Payload: 200},
+	}
+
+	t.Run("ok, sensor unit", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(sensor, nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetEventsByTimeFrame(ctx, int64(1), start, finish).Times(1).Return(events, nil)
+
+		readings, err := NewEvent(er, sr).GetReadingsByTimeFrame(ctx, 1, "", start, finish, "")
+		require.NoError(t, err)
+		require.Len(t, readings, 2)
+		assert.Equal(t, 10.0, readings[0].Value)
+		assert.Equal(t, 60.0, readings[1].Value)
+		assert.Equal(t, "°C", readings[0].Unit)
+		assert.Equal(t, int64(100), readings[0].Event.Payload)
+	})
+
+	t.Run("ok, fahrenheit", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(sensor, nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetEventsByTimeFrame(ctx, int64(1), start, finish).Times(1).Return(events, nil)
+
+		readings, err := NewEvent(er, sr).GetReadingsByTimeFrame(ctx, 1, "", start, finish, "F")
+		require.NoError(t, err)
+		require.Len(t, readings, 2)
+		assert.InDelta(t, 50.0, readings[0].Value, 1e-9)
+		assert.InDelta(t, 140.0, readings[1].Value, 1e-9)
+		assert.Equal(t, "°F", readings[0].Unit)
+	})
+
+	t.Run("err, incompatible unit", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(sensor, nil)
+
+		_, err := NewEvent(nil, sr).GetReadingsByTimeFrame(ctx, 1, "", start, finish, "hPa")
+		assert.ErrorIs(t, err, ErrInvalidUnit)
+	})
+
+	t.Run("err, unit of other channel", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(sensor, nil)
+
+		_, err := NewEvent(nil, sr).GetReadingsByTimeFrame(ctx, 1, "humidity", start, finish, "°F")
+		assert.ErrorIs(t, err, ErrInvalidUnit)
+	})
+}
+
+func Test_event_AggregateReadings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start, finish := time.Unix(0, 0), time.Unix(100, 0)
+	aggs := []domain.Aggregate{{Start: start, Count: 2, Min: 100, Max: 200, Avg: 150, Sum: 300}}
+
+	t.Run("ok, negative scale", func(t *testing.T) {
+		ctx := context.Background()
+
+		sensor := &domain.Sensor{ID: 1, Calibration: &domain.Calibration{Kind: domain.CalibrationLinear, Scale: -1, Offset: 10}}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(2).Return(sensor, nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().AggregateEvents(ctx, int64(1), domain.DefaultChannel, start, finish, time.Duration(0)).
+			Times(1).Return(aggs, nil)
+
+		out, unit, err := NewEvent(er, sr).AggregateReadings(ctx, 1, "", start, finish, 0, "")
+		require.NoError(t, err)
+		assert.Equal(t, "", unit)
+		assert.Equal(t, []domain.Aggregate{{Start: start, Count: 2, Min: -190, Max: -90, Avg: -140, Sum: -280}}, out)
+	})
+
+	t.Run("err, nonlinear calibration", func(t *testing.T) {
+		ctx := context.Background()
+
+		sensor := &domain.Sensor{ID: 1, Calibration: &domain.Calibration{
+			Kind:   domain.CalibrationLookup,
+			Points: []domain.CalibrationPoint{{Raw: 0, Value: 0}, {Raw: 1, Value: 1}},
+		}}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(2).Return(sensor, nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().AggregateEvents(ctx, int64(1), domain.DefaultChannel, start, finish, time.Duration(0)).
+			Times(1).Return(aggs, nil)
+
+		_, _, err := NewEvent(er, sr).AggregateReadings(ctx, 1, "", start, finish, 0, "")
+		assert.ErrorIs(t, err, ErrInvalidUnit)
+	})
+}
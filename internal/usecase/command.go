@@ -0,0 +1,425 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// defaultCommandTTL - через сколько неподтверждённая команда истекает, если срок не задан
+	defaultCommandTTL = time.Minute
+	// maxCommandTTL - наибольший срок жизни команды
+	maxCommandTTL = 24 * time.Hour
+	// maxPulseDuration - наибольшая длительность импульса
+	maxPulseDuration = time.Hour
+	// defaultCommandPollInterval - как часто ожидающий команд получатель перечитывает очередь.
+	// Команды этого экземпляра приходят сразу, а команды других экземпляров - не позже этого интервала.
+	defaultCommandPollInterval = time.Second
+)
+
+// CommandListener - получатель команд, поставленных в очередь этим экземпляром сервиса
+type CommandListener func(ctx context.Context, command domain.Command, sensor domain.Sensor)
+
+// Command - очередь команд исполнительным устройствам. Устройство забирает команды
+// (ReceiveCommands), а подтверждает их событием с новым состоянием, которое передаётся
+// в ConfirmCommands как получатель событий usecase.Event.
+type Command struct {
+	cr           CommandRepository
+	sr           SensorRepository
+	types        *SensorTypes
+	ttl          time.Duration
+	pollInterval time.Duration
+	now          func() time.Time
+
+	mu        sync.Mutex
+	waiters   map[int64]chan struct{}
+	listeners []CommandListener
+}
+
+func NewCommand(cr CommandRepository, sr SensorRepository, options ...func(*Command)) *Command {
+	c := &Command{
+		cr:           cr,
+		sr:           sr,
+		types:        NewSensorTypes(nil),
+		ttl:          defaultCommandTTL,
+		pollInterval: defaultCommandPollInterval,
+		now:          time.Now,
+		waiters:      make(map[int64]chan struct{}),
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// WithCommandSensorTypes - реестр, по которому определяется, принимает ли устройство команды
+func WithCommandSensorTypes(types *SensorTypes) func(*Command) {
+	return func(c *Command) {
+		c.types = types
+	}
+}
+
+// WithCommandTTL - срок жизни команды, для которой он не задан
+func WithCommandTTL(ttl time.Duration) func(*Command) {
+	return func(c *Command) {
+		c.ttl = ttl
+	}
+}
+
+// WithCommandPollInterval - как часто ожидание команд перечитывает очередь
+func WithCommandPollInterval(interval time.Duration) func(*Command) {
+	return func(c *Command) {
+		c.pollInterval = interval
+	}
+}
+
+// actuator - устройство и описание его типа, ErrNotActuator, если тип не принимает команды
+func (c *Command) actuator(ctx context.Context, id int64) (*domain.Sensor, *domain.SensorTypeSpec, error) {
+	sensor, err := c.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spec, err := c.types.GetSensorType(ctx, sensor.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !spec.Actuator {
+		return nil, nil, fmt.Errorf("%w: type %s does not accept commands", ErrNotActuator, sensor.Type)
+	}
+
+	return sensor, spec, nil
+}
+
+// validateCommand - проверяет команду и вычисляет состояние, в которое она переводит устройство
+func validateCommand(spec *domain.SensorTypeSpec, sensor *domain.Sensor, command *domain.Command) error {
+	if command.Kind != domain.CommandPulse && command.Duration != 0 {
+		return fmt.Errorf("%w: duration is only allowed for %s", ErrInvalidCommand, domain.CommandPulse)
+	}
+
+	switch command.Kind {
+	case domain.CommandSetState:
+	case domain.CommandToggle:
+		if spec.Payload != domain.PayloadKindBinary {
+			return fmt.Errorf("%w: only binary devices can be toggled", ErrInvalidCommand)
+		}
+		command.State = 1
+		if sensor.CurrentState != 0 {
+			command.State = 0
+		}
+	case domain.CommandPulse:
+		if command.Duration <= 0 || command.Duration > maxPulseDuration {
+			return fmt.Errorf("%w: pulse duration must be positive and at most %s", ErrInvalidCommand, maxPulseDuration)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidCommand, command.Kind)
+	}
+
+	if spec.Payload == domain.PayloadKindBinary && command.State != 0 && command.State != 1 {
+		return fmt.Errorf("%w: state of binary device must be 0 or 1", ErrInvalidCommand)
+	}
+	if err := ValidatePayload(spec, domain.IntValue(command.State)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCommand, err)
+	}
+	return nil
+}
+
+// GetActuator - исполнительное устройство id, ErrNotActuator, если его тип не принимает команды
+func (c *Command) GetActuator(ctx context.Context, id int64) (*domain.Sensor, error) {
+	sensor, _, err := c.actuator(ctx, id)
+	return sensor, err
+}
+
+// SendCommand - ставит команду в очередь устройства id. ttl - через сколько неподтверждённая команда
+// истекает, 0 - срок по умолчанию.
+func (c *Command) SendCommand(ctx context.Context, id int64, command *domain.Command, ttl time.Duration) (_ *domain.Command, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Command.SendCommand",
+		trace.WithAttributes(attribute.Int64("sensor.id", id), attribute.String("command.kind", string(command.Kind))))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if ttl < 0 || ttl > maxCommandTTL {
+		return nil, fmt.Errorf("%w: ttl must be between 0 and %s", ErrInvalidCommand, maxCommandTTL)
+	}
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+
+	sensor, spec, err := c.actuator(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateCommand(spec, sensor, command); err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	command.ID = 0
+	command.SensorID = sensor.ID
+	command.Status = domain.CommandPending
+	command.CreatedAt = now
+	command.ExpiresAt = now.Add(ttl)
+	command.DeliveredAt = time.Time{}
+	command.ConfirmedAt = time.Time{}
+
+	logger := logging.FromContext(ctx).With(logging.SensorID(sensor.ID))
+
+	if err := c.cr.SaveCommand(ctx, command); err != nil {
+		logger.ErrorContext(ctx, "can't save command", logging.Error(err))
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "command queued",
+		slog.Int64("command_id", command.ID), slog.String("kind", string(command.Kind)))
+
+	c.notify(sensor.ID)
+
+	c.mu.Lock()
+	listeners := c.listeners
+	c.mu.Unlock()
+	for _, l := range listeners {
+		l(ctx, *command, *sensor)
+	}
+
+	return command, nil
+}
+
+// AddListener - добавляет получателя команд, поставленных в очередь через SendCommand.
+// Получатели вызываются синхронно и не должны надолго блокироваться.
+func (c *Command) AddListener(l CommandListener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, l)
+}
+
+// GetCommand - команда id устройства sensorID
+func (c *Command) GetCommand(ctx context.Context, sensorID, id int64) (_ *domain.Command, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Command.GetCommand",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID), attribute.Int64("command.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	command, err := c.cr.GetCommandByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if command.SensorID != sensorID {
+		return nil, ErrCommandNotFound
+	}
+	return command, nil
+}
+
+// GetCommands - команды устройства по возрастанию ID, пустой statuses - в любом состоянии
+func (c *Command) GetCommands(ctx context.Context, sensorID int64, statuses []domain.CommandStatus) (_ []domain.Command, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Command.GetCommands",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if _, err := c.sr.GetSensorByID(ctx, sensorID); err != nil {
+		return nil, err
+	}
+	return c.cr.GetCommands(ctx, sensorID, statuses)
+}
+
+// ReceiveCommands - забирает ожидающие команды устройства, помечая их доставленными. Если команд нет,
+// ждёт их не дольше wait; пустой результат означает, что за это время команды не появились.
+func (c *Command) ReceiveCommands(ctx context.Context, sensorID int64, wait time.Duration) (_ []domain.Command, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Command.ReceiveCommands",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if _, _, err := c.actuator(ctx, sensorID); err != nil {
+		return nil, err
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	for {
+		// ожидание регистрируется до чтения очереди, чтобы не пропустить команду между ними
+		notified := c.wait(sensorID)
+
+		commands, err := c.cr.DeliverCommands(ctx, sensorID, c.now())
+		if err != nil || len(commands) > 0 {
+			if err == nil {
+				logging.FromContext(ctx).DebugContext(ctx, "commands delivered",
+					logging.SensorID(sensorID), slog.Int("count", len(commands)))
+			}
+			return commands, err
+		}
+
+		poll := time.NewTimer(c.pollInterval)
+		select {
+		case <-ctx.Done():
+			poll.Stop()
+			return nil, ctx.Err()
+		case <-deadline.C:
+			poll.Stop()
+			return nil, nil
+		case <-notified:
+		case <-poll.C:
+		}
+		poll.Stop()
+	}
+}
+
+// PendingCommands - ожидающие доставки неистёкшие команды устройства по возрастанию ID. В отличие
+// от ReceiveCommands не помечает их доставленными: получатель делает это сам через DeliverCommand,
+// когда устройство подтвердит получение.
+func (c *Command) PendingCommands(ctx context.Context, sensorID int64) (_ []domain.Command, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Command.PendingCommands",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if _, _, err := c.actuator(ctx, sensorID); err != nil {
+		return nil, err
+	}
+
+	commands, err := c.cr.GetCommands(ctx, sensorID, []domain.CommandStatus{domain.CommandPending})
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	pending := commands[:0]
+	for _, command := range commands {
+		if command.ExpiresAt.After(now) {
+			pending = append(pending, command)
+		}
+	}
+	return pending, nil
+}
+
+// DeliverCommand - помечает доставленной ожидающую команду id устройства sensorID. Возвращает false,
+// если команда уже не ожидает доставки: истекла или её забрал другой получатель.
+func (c *Command) DeliverCommand(ctx context.Context, sensorID, id int64) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Command.DeliverCommand",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID), attribute.Int64("command.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	delivered, err := c.cr.DeliverCommand(ctx, sensorID, id, c.now())
+	if err != nil {
+		return false, err
+	}
+	if delivered {
+		logging.FromContext(ctx).DebugContext(ctx, "command delivered",
+			logging.SensorID(sensorID), slog.Int64("command_id", id))
+	}
+	return delivered, nil
+}
+
+// WatchCommands - передаёт в fn команды устройства по мере их появления, помечая доставленными.
+// Блокируется до отмены ctx или ошибки fn; команда, на которой fn вернула ошибку, остаётся доставленной.
+func (c *Command) WatchCommands(ctx context.Context, sensorID int64, fn func(domain.Command) error) error {
+	for {
+		commands, err := c.ReceiveCommands(ctx, sensorID, maxCommandTTL)
+		if err != nil {
+			return err
+		}
+		for _, command := range commands {
+			if err := fn(command); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ConfirmCommands - получатель событий usecase.Event: событие исполнительного устройства
+// подтверждает его активные команды с тем же состоянием
+func (c *Command) ConfirmCommands(ctx context.Context, event domain.Event, sensor domain.Sensor) {
+	spec, err := c.types.GetSensorType(ctx, sensor.Type)
+	if err != nil || !spec.Actuator {
+		return
+	}
+
+	logger := logging.FromContext(ctx).With(logging.SensorID(sensor.ID))
+
+	confirmed, err := c.cr.ConfirmCommands(ctx, sensor.ID, event.Payload, c.now())
+	if err != nil {
+		logger.ErrorContext(ctx, "can't confirm commands", logging.Error(err))
+		return
+	}
+	for _, command := range confirmed {
+		logger.InfoContext(ctx, "command confirmed", slog.Int64("command_id", command.ID))
+	}
+}
+
+// ExpireCommands - помечает истёкшими неподтверждённые команды с прошедшим сроком
+func (c *Command) ExpireCommands(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Command.ExpireCommands")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	expired, err := c.cr.ExpireCommands(ctx, c.now())
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			logging.FromContext(ctx).ErrorContext(ctx, "can't expire commands", logging.Error(err))
+		}
+		return 0, err
+	}
+
+	if expired > 0 {
+		logging.FromContext(ctx).InfoContext(ctx, "commands expired", slog.Int64("expired", expired))
+	}
+
+	return expired, nil
+}
+
+// wait - канал, который закроется, когда в очередь устройства добавят команду
+func (c *Command) wait(sensorID int64) <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.waiters[sensorID]
+	if !ok {
+		ch = make(chan struct{})
+		c.waiters[sensorID] = ch
+	}
+	return ch
+}
+
+func (c *Command) notify(sensorID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ch, ok := c.waiters[sensorID]; ok {
+		close(ch)
+		delete(c.waiters, sensorID)
+	}
+}
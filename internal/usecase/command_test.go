@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_command_SendCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1000, 0)
+	relay := &domain.Sensor{ID: 1, Type: "relay", CurrentState: 1}
+
+	newCommand := func(cr CommandRepository, sr SensorRepository) *Command {
+		c := NewCommand(cr, sr)
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	t.Run("ok, toggle", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(relay, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, c *domain.Command) error {
+			c.ID = 7
+			return nil
+		})
+
+		var heard []domain.Command
+		c := newCommand(cr, sr)
+		c.AddListener(func(_ context.Context, command domain.Command, _ domain.Sensor) {
+			heard = append(heard, command)
+		})
+
+		command, err := c.SendCommand(ctx, 1, &domain.Command{Kind: domain.CommandToggle}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, &domain.Command{
+			ID:        7,
+			SensorID:  1,
+			Kind:      domain.CommandToggle,
+			State:     0,
+			Status:    domain.CommandPending,
+			CreatedAt: now,
+			ExpiresAt: now.Add(defaultCommandTTL),
+		}, command)
+		assert.Equal(t, []domain.Command{*command}, heard)
+	})
+
+	t.Run("ok, pulse with ttl", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(relay, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(ctx, gomock.Any()).Times(1).Return(nil)
+
+		command, err := newCommand(cr, sr).SendCommand(ctx, 1,
+			&domain.Command{Kind: domain.CommandPulse, State: 1, Duration: time.Second}, 10*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(10*time.Second), command.ExpiresAt)
+	})
+
+	t.Run("err, not actuator", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).
+			Return(&domain.Sensor{ID: 2, Type: domain.SensorTypeADC}, nil)
+
+		_, err := newCommand(nil, sr).SendCommand(ctx, 2, &domain.Command{Kind: domain.CommandSetState}, 0)
+		assert.ErrorIs(t, err, ErrNotActuator)
+	})
+
+	t.Run("err, invalid command", func(t *testing.T) {
+		for _, command := range []domain.Command{
+			{Kind: "blink"},
+			{Kind: domain.CommandSetState, State: 2},
+			{Kind: domain.CommandSetState, State: 1, Duration: time.Second},
+			{Kind: domain.CommandPulse, State: 1},
+			{Kind: domain.CommandPulse, State: 1, Duration: 2 * time.Hour},
+		} {
+			ctx := context.Background()
+
+			sr := NewMockSensorRepository(ctrl)
+			sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(relay, nil)
+
+			_, err := newCommand(nil, sr).SendCommand(ctx, 1, &command, 0)
+			assert.ErrorIs(t, err, ErrInvalidCommand, command)
+		}
+	})
+
+	t.Run("err, invalid ttl", func(t *testing.T) {
+		_, err := newCommand(nil, nil).SendCommand(context.Background(), 1,
+			&domain.Command{Kind: domain.CommandSetState}, 48*time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidCommand)
+	})
+}
+
+func Test_command_GetCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	cr := NewMockCommandRepository(ctrl)
+	cr.EXPECT().GetCommandByID(ctx, int64(7)).Times(2).Return(&domain.Command{ID: 7, SensorID: 1}, nil)
+
+	c := NewCommand(cr, nil)
+
+	command, err := c.GetCommand(ctx, 1, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), command.ID)
+
+	_, err = c.GetCommand(ctx, 2, 7)
+	assert.ErrorIs(t, err, ErrCommandNotFound)
+}
+
+func Test_command_ReceiveCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	relay := &domain.Sensor{ID: 1, Type: "relay"}
+
+	t.Run("ok, queued while waiting", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(relay, nil)
+		cr := NewMockCommandRepository(ctrl)
+		gomock.InOrder(
+			cr.EXPECT().DeliverCommands(ctx, int64(1), gomock.Any()).Times(1).Return(nil, nil),
+			cr.EXPECT().DeliverCommands(ctx, int64(1), gomock.Any()).Times(1).Return([]domain.Command{{ID: 7}}, nil),
+		)
+
+		// интервал опроса больше времени теста: вторая попытка возможна только по уведомлению
+		c := NewCommand(cr, sr, WithCommandPollInterval(time.Hour))
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			c.notify(1)
+		}()
+
+		commands, err := c.ReceiveCommands(ctx, 1, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Command{{ID: 7}}, commands)
+	})
+
+	t.Run("ok, nothing before deadline", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(relay, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().DeliverCommands(ctx, int64(1), gomock.Any()).MinTimes(1).Return(nil, nil)
+
+		commands, err := NewCommand(cr, sr, WithCommandPollInterval(time.Millisecond)).
+			ReceiveCommands(ctx, 1, 20*time.Millisecond)
+		require.NoError(t, err)
+		assert.Empty(t, commands)
+	})
+
+	t.Run("fail, ctx cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(relay, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().DeliverCommands(gomock.Any(), int64(1), gomock.Any()).Times(1).Return(nil, nil)
+
+		_, err := NewCommand(cr, sr, WithCommandPollInterval(time.Hour)).ReceiveCommands(ctx, 1, time.Minute)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func Test_command_PendingCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	t.Run("ok, expired commands are skipped", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: "relay"}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommands(ctx, int64(1), []domain.CommandStatus{domain.CommandPending}).Times(1).Return([]domain.Command{
+			{ID: 6, ExpiresAt: now},
+			{ID: 7, ExpiresAt: now.Add(time.Minute)},
+		}, nil)
+
+		c := NewCommand(cr, sr)
+		c.now = func() time.Time { return now }
+
+		commands, err := c.PendingCommands(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, commands, 1)
+		assert.Equal(t, int64(7), commands[0].ID)
+	})
+
+	t.Run("err, not an actuator", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).Return(&domain.Sensor{ID: 2, Type: domain.SensorTypeADC}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommands(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewCommand(cr, sr).PendingCommands(ctx, 2)
+		assert.ErrorIs(t, err, ErrNotActuator)
+	})
+}
+
+func Test_command_DeliverCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	cr := NewMockCommandRepository(ctrl)
+	gomock.InOrder(
+		cr.EXPECT().DeliverCommand(ctx, int64(1), int64(7), now).Times(1).Return(true, nil),
+		cr.EXPECT().DeliverCommand(ctx, int64(1), int64(7), now).Times(1).Return(false, nil),
+	)
+
+	c := NewCommand(cr, nil)
+	c.now = func() time.Time { return now }
+
+	delivered, err := c.DeliverCommand(ctx, 1, 7)
+	require.NoError(t, err)
+	assert.True(t, delivered)
+
+	delivered, err = c.DeliverCommand(ctx, 1, 7)
+	require.NoError(t, err)
+	assert.False(t, delivered)
+}
+
+func Test_command_ConfirmCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	cr := NewMockCommandRepository(ctrl)
+	cr.EXPECT().ConfirmCommands(ctx, int64(1), int64(1), now).Times(1).Return([]domain.Command{{ID: 7}}, nil)
+
+	c := NewCommand(cr, nil)
+	c.now = func() time.Time { return now }
+
+	c.ConfirmCommands(ctx, domain.Event{SensorID: 1, Payload: 1}, domain.Sensor{ID: 1, Type: "relay"})
+	// события обычных датчиков не трогают очередь
+	c.ConfirmCommands(ctx, domain.Event{SensorID: 2, Payload: 1}, domain.Sensor{ID: 2, Type: domain.SensorTypeADC})
+}
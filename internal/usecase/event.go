@@ -2,23 +2,131 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// EventListener - получатель сохранённых событий вместе с обновлённым датчиком
+type EventListener func(ctx context.Context, event domain.Event, sensor domain.Sensor)
+
 type Event struct {
-	er EventRepository
-	sr SensorRepository
+	er      EventRepository
+	sr      SensorRepository
+	types   *SensorTypes
+	uow     UnitOfWork
+	outbox  OutboxRepository
+	virtual *VirtualSensor
+
+	mu        sync.RWMutex
+	listeners []EventListener
 }
 
-func NewEvent(er EventRepository, sr SensorRepository) *Event {
-	return &Event{
-		er: er,
-		sr: sr,
+func NewEvent(er EventRepository, sr SensorRepository, options ...func(*Event)) *Event {
+	e := &Event{
+		er:    er,
+		sr:    sr,
+		types: NewSensorTypes(nil),
+		uow:   noUnitOfWork{},
+	}
+	for _, o := range options {
+		o(e)
+	}
+	return e
+}
+
+// WithEventSensorTypes - реестр, по которому проверяется payload событий
+func WithEventSensorTypes(types *SensorTypes) func(*Event) {
+	return func(e *Event) {
+		e.types = types
+	}
+}
+
+// WithEventUnitOfWork - единица работы, в которой событие сохраняется вместе с состоянием датчика
+// и сообщением outbox
+func WithEventUnitOfWork(uow UnitOfWork) func(*Event) {
+	return func(e *Event) {
+		e.uow = uow
+	}
+}
+
+// WithEventOutbox - outbox, в который записывается сообщение о каждом принятом событии
+func WithEventOutbox(outbox OutboxRepository) func(*Event) {
+	return func(e *Event) {
+		e.outbox = outbox
+	}
+}
+
+// WithEventVirtualSensors - виртуальные датчики, которые пересчитываются при событиях их входов
+func WithEventVirtualSensors(virtual *VirtualSensor) func(*Event) {
+	return func(e *Event) {
+		e.virtual = virtual
+	}
+}
+
+// eventMessage - тело сообщения outbox о принятом событии
+type eventMessage struct {
+	SensorID     int64                   `json:"sensor_id"`
+	SerialNumber string                  `json:"serial_number"`
+	Timestamp    time.Time               `json:"timestamp"`
+	Payload      int64                   `json:"payload"`
+	Channels     map[string]domain.Value `json:"channels,omitempty"`
+}
+
+const (
+	// maxEventChannels - сколько каналов может быть в одном событии
+	maxEventChannels = 32
+	// maxAggregateBuckets - сколько интервалов может вернуть AggregateEvents
+	maxAggregateBuckets = 10000
+)
+
+var channelNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ValidateChannels - проверяет имена и число каналов события
+func ValidateChannels(channels map[string]domain.Value) error {
+	if len(channels) > maxEventChannels {
+		return fmt.Errorf("%w: %d channels, at most %d allowed", ErrInvalidEventChannels, len(channels), maxEventChannels)
+	}
+	for name := range channels {
+		if !channelNameRe.MatchString(name) {
+			return fmt.Errorf("%w: channel name %q must match %s", ErrInvalidEventChannels, name, channelNameRe)
+		}
+	}
+	return nil
+}
+
+// validateEvent - проверяет каналы события и значение основного канала по описанию типа датчика.
+// Если типа нет в реестре, диапазон не проверяется.
+func validateEvent(ctx context.Context, types *SensorTypes, sensor *domain.Sensor, event *domain.Event) error {
+	if err := ValidateChannels(event.Channels); err != nil {
+		return err
+	}
+	spec, err := types.GetSensorType(ctx, sensor.Type)
+	if errors.Is(err, ErrWrongSensorType) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
+	return ValidatePayload(spec, event.Primary())
 }
 
-func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) error {
+func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.ReceiveEvent")
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -27,23 +135,139 @@ func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) error {
 		return ErrInvalidEventTimestamp
 	}
 
+	span.SetAttributes(attribute.String("sensor.serial_number", event.SensorSerialNumber))
+
 	sensor, err := e.sr.GetSensorBySerialNumber(ctx, event.SensorSerialNumber)
 	if err != nil {
 		return err
 	}
 
-	sensor.LastActivity = time.Now()
-	sensor.CurrentState = event.Payload
 	event.SensorID = sensor.ID
 
+	span.SetAttributes(attribute.Int64("sensor.id", sensor.ID))
+
+	logger := logging.FromContext(ctx).With(
+		logging.SensorID(sensor.ID),
+		logging.SerialNumber(sensor.SerialNumber),
+	)
+
+	virtual, err := e.types.IsVirtual(ctx, sensor.Type)
+	if err != nil {
+		return err
+	}
+	if virtual {
+		logger.DebugContext(ctx, "event of virtual sensor rejected")
+		return fmt.Errorf("%w: its events are computed from an expression", ErrVirtualSensor)
+	}
+
+	if err := validateEvent(ctx, e.types, sensor, event); err != nil {
+		logger.DebugContext(ctx, "event rejected", logging.Error(err))
+		return err
+	}
+
+	// событие, состояние датчика, сообщение outbox и события зависящих виртуальных датчиков сохраняются
+	// вместе: без этого сбой между записями оставил бы событие без обновлённого CurrentState
+	type received struct {
+		event  domain.Event
+		sensor domain.Sensor
+	}
+	var saved []received
+
+	lastActivity := time.Now()
+	err = e.uow.Do(ctx, func(ctx context.Context) error {
+		saved = saved[:0]
+		if err := e.store(ctx, sensor, event, lastActivity); err != nil {
+			return err
+		}
+		saved = append(saved, received{*event, *sensor})
+
+		if e.virtual == nil {
+			return nil
+		}
+		return e.virtual.recompute(ctx, sensor.ID, event.Timestamp,
+			func(ctx context.Context, sensor *domain.Sensor, event *domain.Event) error {
+				if err := e.store(ctx, sensor, event, lastActivity); err != nil {
+					return err
+				}
+				saved = append(saved, received{*event, *sensor})
+				return nil
+			})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.DebugContext(ctx, "event received", slog.Int64("payload", event.Payload))
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, r := range saved {
+		for _, l := range e.listeners {
+			l(ctx, r.event, r.sensor)
+		}
+	}
+
+	return nil
+}
+
+// store - сохраняет событие датчика, его новое состояние и, если outbox задан, сообщение о событии
+func (e *Event) store(ctx context.Context, sensor *domain.Sensor, event *domain.Event, lastActivity time.Time) error {
+	logger := logging.FromContext(ctx).With(logging.SensorID(sensor.ID))
+
 	if err := e.er.SaveEvent(ctx, event); err != nil {
+		logger.ErrorContext(ctx, "can't save event", logging.Error(err))
+		return err
+	}
+
+	err := updateSensor(ctx, e.sr, sensor, true, func(sensor *domain.Sensor) {
+		sensor.LastActivity = lastActivity
+		sensor.CurrentState = event.Payload
+		sensor.CurrentValue = event.Primary()
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "can't update sensor state", logging.Error(err))
+		return err
+	}
+
+	if e.outbox == nil {
+		return nil
+	}
+	payload, err := json.Marshal(eventMessage{
+		SensorID:     sensor.ID,
+		SerialNumber: sensor.SerialNumber,
+		Timestamp:    event.Timestamp.UTC(),
+		Payload:      event.Payload,
+		Channels:     event.Channels,
+	})
+	if err != nil {
+		return fmt.Errorf("can't marshal outbox message: %w", err)
+	}
+	err = e.outbox.AddOutboxMessage(ctx, &domain.OutboxMessage{
+		Topic:     domain.OutboxSensorEvent,
+		Key:       strconv.FormatInt(sensor.ID, 10),
+		Payload:   payload,
+		CreatedAt: lastActivity.UTC(),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "can't add outbox message", logging.Error(err))
 		return err
 	}
+	return nil
+}
 
-	return e.sr.SaveSensor(ctx, sensor)
+// AddListener - добавляет получателя событий, успешно принятых ReceiveEvent.
+// Получатели вызываются синхронно и не должны надолго блокироваться.
+func (e *Event) AddListener(l EventListener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners = append(e.listeners, l)
 }
 
-func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.GetLastEventBySensorID",
+		trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
@@ -51,9 +275,224 @@ func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.E
 	return e.er.GetLastEventBySensorID(ctx, id)
 }
 
-func (e *Event) GetEventsByTimeFrame(ctx context.Context, id int64, start, finish time.Time) ([]domain.Event, error) {
+// DeleteExpiredEvents - удаляет события старше retention
+func (e *Event) DeleteExpiredEvents(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.DeleteExpiredEvents")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	deleted, err := e.er.DeleteEventsBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "can't delete expired events", logging.Error(err))
+		return 0, err
+	}
+
+	if deleted > 0 {
+		logging.FromContext(ctx).InfoContext(ctx, "expired events deleted", slog.Int64("deleted", deleted))
+	}
+
+	return deleted, nil
+}
+
+func (e *Event) GetEventsByTimeFrame(ctx context.Context, id int64, start, finish time.Time) (_ []domain.Event, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.GetEventsByTimeFrame",
+		trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 	return e.er.GetEventsByTimeFrame(ctx, id, start, finish)
 }
+
+// AggregateEvents - сводка значений канала событий датчика за период по интервалам interval,
+// при interval = 0 - за весь период. Пустой channel - канал domain.DefaultChannel.
+func (e *Event) AggregateEvents(
+	ctx context.Context,
+	id int64,
+	channel string,
+	start, finish time.Time,
+	interval time.Duration,
+) (_ []domain.Aggregate, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.AggregateEvents",
+		trace.WithAttributes(attribute.Int64("sensor.id", id), attribute.String("event.channel", channel)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if channel == "" {
+		channel = domain.DefaultChannel
+	}
+
+	switch {
+	case finish.Before(start):
+		return nil, fmt.Errorf("%w: end is before start", ErrInvalidAggregation)
+	case interval < 0:
+		return nil, fmt.Errorf("%w: negative interval", ErrInvalidAggregation)
+	case interval > 0 && interval < time.Second:
+		return nil, fmt.Errorf("%w: interval is shorter than a second", ErrInvalidAggregation)
+	case interval > 0 && finish.Sub(start)/interval >= maxAggregateBuckets:
+		return nil, fmt.Errorf("%w: more than %d intervals", ErrInvalidAggregation, maxAggregateBuckets)
+	}
+
+	if _, err := e.sr.GetSensorByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return e.er.AggregateEvents(ctx, id, channel, start, finish, interval)
+}
+
+// ExportEvents - передаёт в fn события датчиков ids за период, упорядоченные по датчику и времени,
+// не загружая их в память целиком. Пустой ids - все датчики. Если какого-то датчика нет,
+// возвращает ErrSensorNotFound до первого вызова fn.
+func (e *Event) ExportEvents(
+	ctx context.Context,
+	ids []int64,
+	start, finish time.Time,
+	fn func(domain.Event) error,
+) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.ExportEvents",
+		trace.WithAttributes(attribute.Int64Slice("sensor.ids", ids)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if len(ids) == 0 {
+		sensors, err := e.sr.GetSensors(ctx)
+		if err != nil {
+			return err
+		}
+		for _, sensor := range sensors {
+			ids = append(ids, sensor.ID)
+		}
+	} else {
+		for _, id := range ids {
+			if _, err := e.sr.GetSensorByID(ctx, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return e.er.IterateEvents(ctx, ids, start, finish, fn)
+}
+
+// WatchSensorEvents - раз в interval проверяет последнее событие датчика и передаёт в fn каждое новое.
+// Если since не нулевое, сначала передаёт события, пропущенные после since.
+// Блокируется до отмены ctx или ошибки fn.
+func (e *Event) WatchSensorEvents(
+	ctx context.Context,
+	id int64,
+	since time.Time,
+	interval time.Duration,
+	fn func(domain.Event) error,
+) error {
+	if !since.IsZero() {
+		missed, err := e.er.GetEventsByTimeFrame(ctx, id, since.Add(time.Nanosecond), time.Now())
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(missed, func(i, j int) bool {
+			return missed[i].Timestamp.Before(missed[j].Timestamp)
+		})
+
+		for _, event := range missed {
+			if err := fn(event); err != nil {
+				return err
+			}
+			since = event.Timestamp
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := !since.IsZero()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			event, err := e.er.GetLastEventBySensorID(ctx, id)
+			if err != nil {
+				continue
+			}
+
+			if seen && !event.Timestamp.After(since) {
+				continue
+			}
+
+			seen = true
+			since = event.Timestamp
+
+			if err := fn(*event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WatchEvents - то же, что WatchSensorEvents, но для нескольких датчиков сразу.
+// Вызовы fn не пересекаются между собой.
+func (e *Event) WatchEvents(
+	ctx context.Context,
+	ids []int64,
+	since time.Time,
+	interval time.Duration,
+	fn func(domain.Event) error,
+) error {
+	from := make(map[int64]time.Time, len(ids))
+	for _, id := range ids {
+		from[id] = since
+	}
+
+	return e.WatchEventsFrom(ctx, from, interval, fn)
+}
+
+// WatchEventsFrom - то же, что WatchEvents, но у каждого датчика свой момент since.
+func (e *Event) WatchEventsFrom(
+	ctx context.Context,
+	since map[int64]time.Time,
+	interval time.Duration,
+	fn func(domain.Event) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		once sync.Once
+		out  error
+	)
+
+	for id, from := range since {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := e.WatchSensorEvents(ctx, id, from, interval, func(event domain.Event) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return fn(event)
+			})
+
+			once.Do(func() {
+				out = err
+				cancel()
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	return out
+}
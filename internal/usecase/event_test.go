@@ -9,6 +9,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_event_ReceiveEvent(t *testing.T) {
@@ -111,11 +112,537 @@ func Test_event_ReceiveEvent(t *testing.T) {
 		})
 
 		e := NewEvent(er, sr)
+
+		var notified []domain.Event
+		e.AddListener(func(_ context.Context, event domain.Event, sensor domain.Sensor) {
+			assert.Equal(t, int64(8), sensor.CurrentState)
+			notified = append(notified, event)
+		})
+
 		err := e.ReceiveEvent(ctx, &domain.Event{
 			Timestamp:          time.Now(),
 			SensorSerialNumber: "123",
 			Payload:            8,
 		})
 		assert.NoError(t, err)
+		assert.Len(t, notified, 1)
+		assert.Equal(t, int64(1), notified[0].SensorID)
+	})
+
+	t.Run("ok, sensor saved concurrently", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{ID: 1, Version: 1}, nil)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{
+			ID: 1, Version: 2, Description: "changed",
+		}, nil)
+		gomock.InOrder(
+			sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(ErrSensorVersionConflict),
+			sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
+				assert.Equal(t, int64(2), s.Version)
+				assert.Equal(t, "changed", s.Description)
+				assert.Equal(t, int64(8), s.CurrentState)
+			}),
+		)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            8,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("err, payload out of range", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		low, high := -40.0, 125.0
+		str := NewMockSensorTypeRepository(ctrl)
+		str.EXPECT().GetSensorTypes(ctx).Times(1).Return([]domain.SensorTypeSpec{
+			{Name: "temperature", Payload: domain.PayloadKindLevel, Min: &low, Max: &high},
+		}, nil)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(2).Return(&domain.Sensor{ID: 1, Type: "temperature"}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		e := NewEvent(er, sr, WithEventSensorTypes(NewSensorTypes(str)))
+
+		err := e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123", Payload: 200})
+		assert.ErrorIs(t, err, ErrPayloadOutOfRange)
+
+		err = e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123", Payload: -40})
+		assert.NoError(t, err)
+	})
+
+	t.Run("err, float payload out of range", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		high := 125.0
+		str := NewMockSensorTypeRepository(ctrl)
+		str.EXPECT().GetSensorTypes(ctx).Times(1).Return([]domain.SensorTypeSpec{
+			{Name: "temperature", Payload: domain.PayloadKindLevel, Max: &high},
+		}, nil)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{ID: 1, Type: "temperature"}, nil)
+
+		e := NewEvent(NewMockEventRepository(ctrl), sr, WithEventSensorTypes(NewSensorTypes(str)))
+
+		// payload округляется до 125, но проверяется исходное значение
+		event := &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123"}
+		event.SetValue(domain.FloatValue(125.2))
+
+		err := e.ReceiveEvent(ctx, event)
+		assert.ErrorIs(t, err, ErrPayloadOutOfRange)
+	})
+
+	t.Run("err, invalid channel name", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+
+		e := NewEvent(NewMockEventRepository(ctrl), sr)
+
+		event := &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123"}
+		event.SetChannels(map[string]domain.Value{"bad name": domain.IntValue(1)})
+
+		err := e.ReceiveEvent(ctx, event)
+		assert.ErrorIs(t, err, ErrInvalidEventChannels)
+	})
+
+	t.Run("ok, channels saved", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, sensor *domain.Sensor) error {
+			assert.Equal(t, int64(40), sensor.CurrentState)
+			return nil
+		})
+
+		event := &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123"}
+		event.SetChannels(map[string]domain.Value{"temperature": domain.FloatValue(21.6), "humidity": domain.IntValue(40)})
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, event).Times(1).Return(nil)
+
+		e := NewEvent(er, sr)
+
+		err := e.ReceiveEvent(ctx, event)
+		assert.NoError(t, err)
+		// основной канал - первый по имени
+		assert.Equal(t, int64(40), event.Payload)
+	})
+
+	t.Run("ok, float value kept in sensor state", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, sensor *domain.Sensor) error {
+			assert.Equal(t, int64(22), sensor.CurrentState)
+			assert.Equal(t, domain.FloatValue(21.6), sensor.CurrentValue)
+			return nil
+		})
+
+		event := &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123"}
+		event.SetValue(domain.FloatValue(21.6))
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, event).Times(1).Return(nil)
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, event)
+		assert.NoError(t, err)
+	})
+
+	type txKey struct{}
+
+	// inTx - единица работы, которая передаёт репозиториям свой контекст и возвращает ошибку fn
+	inTx := func(ctx context.Context) (*MockUnitOfWork, context.Context) {
+		txCtx := context.WithValue(ctx, txKey{}, true)
+		uow := NewMockUnitOfWork(ctrl)
+		uow.EXPECT().Do(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, fn func(context.Context) error) error {
+			return fn(txCtx)
+		})
+		return uow, txCtx
+	}
+
+	t.Run("ok, outbox message in unit of work", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		uow, txCtx := inTx(ctx)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{ID: 1, SerialNumber: "123"}, nil)
+		sr.EXPECT().SaveSensor(txCtx, gomock.Any()).Times(1).Return(nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(txCtx, gomock.Any()).Times(1).Return(nil)
+
+		timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		outbox := NewMockOutboxRepository(ctrl)
+		outbox.EXPECT().AddOutboxMessage(txCtx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, m *domain.OutboxMessage) error {
+			assert.Equal(t, domain.OutboxSensorEvent, m.Topic)
+			assert.Equal(t, "1", m.Key)
+			assert.JSONEq(t, `{"sensor_id":1,"serial_number":"123","timestamp":"2024-05-01T12:00:00Z","payload":8}`, string(m.Payload))
+			return nil
+		})
+
+		e := NewEvent(er, sr, WithEventUnitOfWork(uow), WithEventOutbox(outbox))
+
+		err := e.ReceiveEvent(ctx, &domain.Event{Timestamp: timestamp, SensorSerialNumber: "123", Payload: 8})
+		assert.NoError(t, err)
+	})
+
+	t.Run("err, outbox error fails unit of work", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		uow, txCtx := inTx(ctx)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().SaveSensor(txCtx, gomock.Any()).Times(1).Return(nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(txCtx, gomock.Any()).Times(1).Return(nil)
+
+		expectedError := errors.New("some error")
+		outbox := NewMockOutboxRepository(ctrl)
+		outbox.EXPECT().AddOutboxMessage(txCtx, gomock.Any()).Times(1).Return(expectedError)
+
+		e := NewEvent(er, sr, WithEventUnitOfWork(uow), WithEventOutbox(outbox))
+		e.AddListener(func(context.Context, domain.Event, domain.Sensor) {
+			t.Error("listener notified of a failed event")
+		})
+
+		err := e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123"})
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("err, event of virtual sensor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "500").Times(1).Return(&domain.Sensor{ID: 5, Type: "virtual"}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "500", Payload: 1})
+		assert.ErrorIs(t, err, ErrVirtualSensor)
+	})
+
+	t.Run("ok, virtual sensors recomputed in unit of work", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		uow, txCtx := inTx(ctx)
+
+		// s5 = s1 * 2, s6 = s5 + s1: s6 вычисляется после s5
+		sensors := map[int64]domain.Sensor{
+			1: {ID: 1, SerialNumber: "123", Type: domain.SensorTypeADC},
+			5: {ID: 5, SerialNumber: "500", Type: "virtual", IsActive: true},
+			6: {ID: 6, SerialNumber: "600", Type: "virtual", IsActive: true},
+		}
+		s5 := domain.VirtualSensor{SensorID: 5, Expression: "s1 * 2", Inputs: []int64{1}}
+		s6 := domain.VirtualSensor{SensorID: 6, Expression: "s5 + s1", Inputs: []int64{1, 5}}
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).DoAndReturn(func(context.Context, string) (*domain.Sensor, error) {
+			s := sensors[1]
+			return &s, nil
+		})
+		sr.EXPECT().GetSensorByID(txCtx, gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, id int64) (*domain.Sensor, error) {
+			s := sensors[id]
+			return &s, nil
+		})
+		sr.EXPECT().SaveSensor(txCtx, gomock.Any()).Times(3).Return(nil)
+
+		last := make(map[int64]domain.Event)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(txCtx, gomock.Any()).Times(3).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+			last[event.SensorID] = *event
+			return nil
+		})
+		er.EXPECT().GetLastEventBySensorID(txCtx, gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, id int64) (*domain.Event, error) {
+			event, ok := last[id]
+			if !ok {
+				return nil, ErrEventNotFound
+			}
+			return &event, nil
+		})
+
+		vr := NewMockVirtualSensorRepository(ctrl)
+		vr.EXPECT().GetVirtualSensorsByInput(txCtx, int64(1)).Times(1).Return([]domain.VirtualSensor{s5, s6}, nil)
+		vr.EXPECT().GetVirtualSensorsByInput(txCtx, int64(5)).Times(1).Return([]domain.VirtualSensor{s6}, nil)
+		vr.EXPECT().GetVirtualSensorsByInput(txCtx, int64(6)).Times(1).Return(nil, nil)
+
+		e := NewEvent(er, sr,
+			WithEventUnitOfWork(uow),
+			WithEventVirtualSensors(NewVirtualSensor(vr, NewSensor(sr), er)),
+		)
+
+		var notified []domain.Event
+		e.AddListener(func(_ context.Context, event domain.Event, _ domain.Sensor) {
+			notified = append(notified, event)
+		})
+
+		timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		err := e.ReceiveEvent(ctx, &domain.Event{Timestamp: timestamp, SensorSerialNumber: "123", Payload: 8})
+		require.NoError(t, err)
+
+		require.Len(t, notified, 3)
+		for i, want := range []struct {
+			serial  string
+			payload int64
+		}{{"123", 8}, {"500", 16}, {"600", 24}} {
+			assert.Equal(t, want.serial, notified[i].SensorSerialNumber)
+			assert.Equal(t, want.payload, notified[i].Payload)
+			assert.Equal(t, timestamp, notified[i].Timestamp)
+		}
+	})
+}
+
+func Test_event_AggregateEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("err, invalid interval", func(t *testing.T) {
+		e := NewEvent(NewMockEventRepository(ctrl), NewMockSensorRepository(ctrl))
+
+		_, err := e.AggregateEvents(context.Background(), 1, "", start, start.Add(-time.Hour), 0)
+		assert.ErrorIs(t, err, ErrInvalidAggregation)
+
+		_, err = e.AggregateEvents(context.Background(), 1, "", start, start.Add(time.Hour), time.Millisecond)
+		assert.ErrorIs(t, err, ErrInvalidAggregation)
+
+		_, err = e.AggregateEvents(context.Background(), 1, "", start, start.Add(24*time.Hour), time.Second)
+		assert.ErrorIs(t, err, ErrInvalidAggregation)
+	})
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(nil, ErrSensorNotFound)
+
+		e := NewEvent(NewMockEventRepository(ctrl), sr)
+
+		_, err := e.AggregateEvents(ctx, 1, "", start, start.Add(time.Hour), 0)
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("ok, default channel", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+
+		expected := []domain.Aggregate{{Start: start, Count: 1, Min: 1, Max: 1, Avg: 1, Sum: 1}}
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().AggregateEvents(ctx, int64(1), domain.DefaultChannel, start, start.Add(time.Hour), time.Minute).
+			Times(1).Return(expected, nil)
+
+		e := NewEvent(er, sr)
+
+		aggs, err := e.AggregateEvents(ctx, 1, "", start, start.Add(time.Hour), time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, aggs)
+	})
+}
+
+func Test_event_DeleteExpiredEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("err, delete error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		expectedError := errors.New("some error")
+		er.EXPECT().DeleteEventsBefore(ctx, gomock.Any()).Times(1).Return(int64(0), expectedError)
+
+		e := NewEvent(er, nil)
+
+		_, err := e.DeleteExpiredEvents(ctx, time.Hour)
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("ok, no error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().DeleteEventsBefore(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Second)
+			return 3, nil
+		})
+
+		e := NewEvent(er, nil)
+
+		deleted, err := e.DeleteExpiredEvents(ctx, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+	})
+}
+
+func Test_event_ExportEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Now().Add(-time.Hour)
+	finish := time.Now()
+	collect := func(events *[]domain.Event) func(domain.Event) error {
+		return func(event domain.Event) error {
+			*events = append(*events, event)
+			return nil
+		}
+	}
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).Return(nil, ErrSensorNotFound)
+
+		e := NewEvent(NewMockEventRepository(ctrl), sr)
+
+		err := e.ExportEvents(ctx, []int64{1, 2}, start, finish, func(domain.Event) error { return nil })
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("ok, selected sensors", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().IterateEvents(ctx, []int64{1}, start, finish, gomock.Any()).Times(1).DoAndReturn(
+			func(_ context.Context, _ []int64, _, _ time.Time, fn func(domain.Event) error) error {
+				return fn(domain.Event{SensorID: 1, Payload: 5})
+			})
+
+		var events []domain.Event
+		err := NewEvent(er, sr).ExportEvents(ctx, []int64{1}, start, finish, collect(&events))
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Event{{SensorID: 1, Payload: 5}}, events)
+	})
+
+	t.Run("ok, all sensors", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{{ID: 3}, {ID: 7}}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().IterateEvents(ctx, []int64{3, 7}, start, finish, gomock.Any()).Times(1).Return(nil)
+
+		err := NewEvent(er, sr).ExportEvents(ctx, nil, start, finish, func(domain.Event) error { return nil })
+		assert.NoError(t, err)
+	})
+}
+
+func Test_event_WatchSensorEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, backfill and new events", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		since := time.Now().Add(-time.Hour)
+		first := domain.Event{SensorID: 1, Timestamp: since.Add(time.Minute), Payload: 1}
+		second := domain.Event{SensorID: 1, Timestamp: since.Add(2 * time.Minute), Payload: 2}
+		third := domain.Event{SensorID: 1, Timestamp: time.Now(), Payload: 3}
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetEventsByTimeFrame(ctx, int64(1), since.Add(time.Nanosecond), gomock.Any()).
+			Times(1).Return([]domain.Event{second, first}, nil)
+		gomock.InOrder(
+			er.EXPECT().GetLastEventBySensorID(ctx, int64(1)).Times(1).Return(&second, nil),
+			er.EXPECT().GetLastEventBySensorID(ctx, int64(1)).Times(1).Return(&third, nil),
+			er.EXPECT().GetLastEventBySensorID(ctx, int64(1)).AnyTimes().Return(&third, nil),
+		)
+
+		e := NewEvent(er, nil)
+
+		var got []int64
+		err := e.WatchSensorEvents(ctx, 1, since, time.Millisecond, func(event domain.Event) error {
+			got = append(got, event.Payload)
+			if len(got) == 3 {
+				cancel()
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, []int64{1, 2, 3}, got)
+	})
+
+	t.Run("err, callback error", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetLastEventBySensorID(ctx, int64(1)).Times(1).Return(&domain.Event{Timestamp: time.Now()}, nil)
+
+		e := NewEvent(er, nil)
+
+		expectedError := errors.New("some error")
+		err := e.WatchSensorEvents(ctx, 1, time.Time{}, time.Millisecond, func(domain.Event) error {
+			return expectedError
+		})
+		assert.ErrorIs(t, err, expectedError)
+	})
+}
+
+func Test_event_WatchEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, events of several sensors", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(1)).AnyTimes().
+			Return(&domain.Event{SensorID: 1, Timestamp: time.Now()}, nil)
+		er.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(2)).AnyTimes().
+			Return(&domain.Event{SensorID: 2, Timestamp: time.Now()}, nil)
+
+		e := NewEvent(er, nil)
+
+		got := make(map[int64]int)
+		err := e.WatchEvents(ctx, []int64{1, 2}, time.Time{}, time.Millisecond, func(event domain.Event) error {
+			got[event.SensorID]++
+			if len(got) == 2 {
+				cancel()
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, map[int64]int{1: 1, 2: 1}, got)
 	})
 }
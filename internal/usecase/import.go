@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultImportBatchSize = 1000
+	// maxImportErrors - сколько ошибок строк хранится в состоянии загрузки, остальные только считаются
+	maxImportErrors = 100
+)
+
+var ErrWrongImportKind = errors.New("wrong import kind")
+
+// ImportRecord - строка загружаемого файла. В зависимости от вида загрузки заполнен Sensor или Event.
+type ImportRecord struct {
+	// Row - номер строки данных, начиная с 1
+	Row    int64
+	Sensor *domain.Sensor
+	Event  *domain.Event
+	// Err - ошибка разбора строки, она не прерывает загрузку
+	Err error
+}
+
+// ImportSource - источник строк загрузки, Next возвращает io.EOF после последней строки.
+// Прочие ошибки Next прерывают загрузку.
+type ImportSource interface {
+	Next() (ImportRecord, error)
+}
+
+type Import struct {
+	ir        ImportRepository
+	sr        SensorRepository
+	types     *SensorTypes
+	batchSize int
+}
+
+func NewImport(ir ImportRepository, sr SensorRepository, options ...func(*Import)) *Import {
+	i := &Import{ir: ir, sr: sr, types: NewSensorTypes(nil), batchSize: defaultImportBatchSize}
+	for _, o := range options {
+		o(i)
+	}
+	return i
+}
+
+// WithImportBatchSize - число строк, сохраняемых одной транзакцией
+func WithImportBatchSize(size int) func(*Import) {
+	return func(i *Import) {
+		i.batchSize = size
+	}
+}
+
+// WithImportSensorTypes - реестр, по которому проверяются типы датчиков и payload событий
+func WithImportSensorTypes(types *SensorTypes) func(*Import) {
+	return func(i *Import) {
+		i.types = types
+	}
+}
+
+// GetImport - состояние загрузки по ID
+func (i *Import) GetImport(ctx context.Context, id string) (_ *domain.Import, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Import.GetImport",
+		trace.WithAttributes(attribute.String("import.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return i.ir.GetImport(ctx, id)
+}
+
+// Run - загружает строки src. Строки сохраняются пачками вместе с номером последней обработанной строки,
+// поэтому прерванную загрузку можно продолжить, передав тот же файл с тем же id: уже сохранённые
+// строки будут пропущены. Пустой id означает новую загрузку. Ошибки в строках не прерывают загрузку,
+// а попадают в её состояние.
+func (i *Import) Run(ctx context.Context, id string, kind domain.ImportKind, src ImportSource) (_ *domain.Import, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Import.Run",
+		trace.WithAttributes(attribute.String("import.id", id), attribute.String("import.kind", string(kind))))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if kind != domain.ImportKindSensors && kind != domain.ImportKindEvents {
+		return nil, ErrWrongImportKind
+	}
+
+	imp, err := i.begin(ctx, id, kind)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("import.id", imp.ID))
+
+	logger := logging.FromContext(ctx).With(slog.String("import_id", imp.ID), slog.String("kind", string(kind)))
+	if imp.Status == domain.ImportStatusCompleted {
+		logger.DebugContext(ctx, "import already completed")
+		return imp, nil
+	}
+
+	if err = i.load(ctx, imp, src); err != nil {
+		imp.Status = domain.ImportStatusFailed
+		imp.Error = err.Error()
+		imp.UpdatedAt = time.Now()
+		// состояние сохраняется и при отмене запроса, чтобы по нему было видно, где загрузка остановилась
+		if saveErr := i.ir.SaveImport(context.WithoutCancel(ctx), imp); saveErr != nil {
+			logger.ErrorContext(ctx, "can't save import state", logging.Error(saveErr))
+		}
+		logger.WarnContext(ctx, "import interrupted", slog.Int64("rows", imp.RowsProcessed), logging.Error(err))
+		return imp, err
+	}
+
+	imp.Status = domain.ImportStatusCompleted
+	imp.UpdatedAt = time.Now()
+	if err = i.ir.SaveImport(ctx, imp); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "import completed",
+		slog.Int64("imported", imp.RowsImported), slog.Int64("failed", imp.RowsFailed))
+
+	return imp, nil
+}
+
+// begin - новая загрузка или продолжение существующей
+func (i *Import) begin(ctx context.Context, id string, kind domain.ImportKind) (*domain.Import, error) {
+	if id != "" {
+		imp, err := i.ir.GetImport(ctx, id)
+		if err == nil {
+			if imp.Kind != kind {
+				return nil, fmt.Errorf("%w: import %s loads %s", ErrImportKindMismatch, id, imp.Kind)
+			}
+			if imp.Status != domain.ImportStatusCompleted {
+				imp.Status = domain.ImportStatusRunning
+				imp.Error = ""
+			}
+			return imp, nil
+		}
+		if !errors.Is(err, ErrImportNotFound) {
+			return nil, err
+		}
+	} else {
+		id = uuid.NewString()
+	}
+
+	now := time.Now()
+	imp := &domain.Import{
+		ID:        id,
+		Kind:      kind,
+		Status:    domain.ImportStatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := i.ir.SaveImport(ctx, imp); err != nil {
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+// importBatch - строки, ещё не сохранённые в хранилище
+type importBatch struct {
+	lastRow  int64
+	rows     int
+	imported int64
+	failed   int64
+	errors   []domain.ImportRowError
+	sensors  []domain.Sensor
+	events   []domain.Event
+}
+
+func (b *importBatch) fail(row int64, err error, stored int) {
+	b.failed++
+	if stored+len(b.errors) < maxImportErrors {
+		b.errors = append(b.errors, domain.ImportRowError{Row: row, Reason: err.Error()})
+	}
+}
+
+func (i *Import) load(ctx context.Context, imp *domain.Import, src ImportSource) error {
+	var (
+		batch importBatch
+		// sensors - датчики, уже встреченные в файле или найденные по серийному номеру; nil - датчика нет
+		sensors = make(map[string]*domain.Sensor)
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("can't read import file: %w", err)
+		}
+		if record.Row <= imp.RowsProcessed {
+			continue
+		}
+
+		batch.lastRow = record.Row
+		batch.rows++
+
+		if record.Err == nil {
+			switch imp.Kind {
+			case domain.ImportKindSensors:
+				record.Err = i.addSensor(ctx, &batch, sensors, record.Sensor)
+			case domain.ImportKindEvents:
+				record.Err = i.addEvent(ctx, &batch, sensors, record.Event)
+			}
+		}
+		if record.Err != nil {
+			if errors.As(record.Err, new(storageError)) {
+				return record.Err
+			}
+			batch.fail(record.Row, record.Err, len(imp.Errors))
+		}
+
+		if batch.rows >= i.batchSize {
+			if err := i.commit(ctx, imp, &batch); err != nil {
+				return err
+			}
+		}
+	}
+
+	return i.commit(ctx, imp, &batch)
+}
+
+// storageError - ошибка хранилища при проверке строки. В отличие от ошибок данных она прерывает загрузку.
+type storageError struct {
+	err error
+}
+
+func (e storageError) Error() string { return e.err.Error() }
+func (e storageError) Unwrap() error { return e.err }
+
+func (i *Import) addSensor(ctx context.Context, batch *importBatch, seen map[string]*domain.Sensor, sensor *domain.Sensor) error {
+	if _, err := i.types.GetSensorType(ctx, sensor.Type); err != nil {
+		if errors.Is(err, ErrWrongSensorType) {
+			return err
+		}
+		return storageError{err: err}
+	}
+	if !validateSerialNumber(sensor.SerialNumber) {
+		return ErrWrongSensorSerialNumber
+	}
+
+	batch.imported++
+	// как и при регистрации, повторный серийный номер не создаёт новый датчик
+	if _, ok := seen[sensor.SerialNumber]; ok {
+		return nil
+	}
+	seen[sensor.SerialNumber] = sensor
+	batch.sensors = append(batch.sensors, *sensor)
+
+	return nil
+}
+
+func (i *Import) addEvent(ctx context.Context, batch *importBatch, sensors map[string]*domain.Sensor, event *domain.Event) error {
+	if event.Timestamp.IsZero() {
+		return ErrInvalidEventTimestamp
+	}
+	if !validateSerialNumber(event.SensorSerialNumber) {
+		return ErrWrongSensorSerialNumber
+	}
+
+	sensor, ok := sensors[event.SensorSerialNumber]
+	if !ok {
+		var err error
+		sensor, err = i.sr.GetSensorBySerialNumber(ctx, event.SensorSerialNumber)
+		if err != nil && !errors.Is(err, ErrSensorNotFound) {
+			return storageError{err: err}
+		}
+		sensors[event.SensorSerialNumber] = sensor
+	}
+	if sensor == nil {
+		return ErrSensorNotFound
+	}
+	if err := validateEvent(ctx, i.types, sensor, event); err != nil {
+		if errors.Is(err, ErrPayloadOutOfRange) || errors.Is(err, ErrInvalidEventChannels) {
+			return err
+		}
+		return storageError{err: err}
+	}
+
+	event.SensorID = sensor.ID
+	batch.imported++
+	batch.events = append(batch.events, *event)
+
+	return nil
+}
+
+// commit - сохраняет пачку и продвигает состояние загрузки. Состояние меняется только после
+// успешного сохранения, чтобы при сбое в хранилище не попал номер несохранённой строки.
+func (i *Import) commit(ctx context.Context, imp *domain.Import, batch *importBatch) error {
+	if batch.rows == 0 {
+		return nil
+	}
+
+	next := *imp
+	next.RowsProcessed = batch.lastRow
+	next.RowsImported += batch.imported
+	next.RowsFailed += batch.failed
+	next.Errors = append(slices.Clip(imp.Errors), batch.errors...)
+	next.UpdatedAt = time.Now()
+
+	var err error
+	switch imp.Kind {
+	case domain.ImportKindSensors:
+		err = i.ir.CommitSensors(ctx, &next, batch.sensors)
+	case domain.ImportKindEvents:
+		err = i.ir.CommitEvents(ctx, &next, batch.events)
+	}
+	if err != nil {
+		return err
+	}
+
+	*imp = next
+	*batch = importBatch{sensors: batch.sensors[:0], events: batch.events[:0]}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceSource - строки загрузки из памяти, после них возвращает err или io.EOF
+type sliceSource struct {
+	records []ImportRecord
+	err     error
+}
+
+func (s *sliceSource) Next() (ImportRecord, error) {
+	if len(s.records) == 0 {
+		if s.err != nil {
+			return ImportRecord{}, s.err
+		}
+		return ImportRecord{}, io.EOF
+	}
+	r := s.records[0]
+	s.records = s.records[1:]
+	return r, nil
+}
+
+func eventRecords(t time.Time) []ImportRecord {
+	return []ImportRecord{
+		{Row: 1, Event: &domain.Event{SensorSerialNumber: "1234567890", Timestamp: t, Payload: 1}},
+		{Row: 2, Event: &domain.Event{SensorSerialNumber: "123", Timestamp: t, Payload: 2}},
+		{Row: 3, Event: &domain.Event{SensorSerialNumber: "0987654321", Timestamp: t, Payload: 3}},
+		{Row: 4, Err: errors.New("invalid row")},
+		{Row: 5, Event: &domain.Event{SensorSerialNumber: "1234567890", Timestamp: t, Payload: 5}},
+	}
+}
+
+func Test_import_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+
+	t.Run("err, wrong kind", func(t *testing.T) {
+		_, err := NewImport(nil, nil).Run(context.Background(), "", "users", &sliceSource{})
+		assert.ErrorIs(t, err, ErrWrongImportKind)
+	})
+
+	t.Run("err, kind mismatch", func(t *testing.T) {
+		ctx := context.Background()
+
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().GetImport(ctx, "a").Times(1).Return(&domain.Import{ID: "a", Kind: domain.ImportKindSensors}, nil)
+
+		_, err := NewImport(ir, nil).Run(ctx, "a", domain.ImportKindEvents, &sliceSource{})
+		assert.ErrorIs(t, err, ErrImportKindMismatch)
+	})
+
+	t.Run("ok, events in batches with row errors", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(1).Return(&domain.Sensor{ID: 7}, nil)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "0987654321").Times(1).Return(nil, ErrSensorNotFound)
+
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().GetImport(ctx, "a").Times(1).Return(nil, ErrImportNotFound)
+		var saved []domain.Import
+		ir.EXPECT().SaveImport(ctx, gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, imp *domain.Import) error {
+			saved = append(saved, *imp)
+			return nil
+		})
+
+		var batches [][]domain.Event
+		var progress []int64
+		ir.EXPECT().CommitEvents(ctx, gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+			func(_ context.Context, imp *domain.Import, events []domain.Event) error {
+				batches = append(batches, append([]domain.Event(nil), events...))
+				progress = append(progress, imp.RowsProcessed)
+				return nil
+			})
+
+		imp, err := NewImport(ir, sr, WithImportBatchSize(2)).Run(ctx, "a", domain.ImportKindEvents,
+			&sliceSource{records: eventRecords(now)})
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{2, 4, 5}, progress)
+		assert.Equal(t, [][]domain.Event{
+			{{SensorSerialNumber: "1234567890", SensorID: 7, Timestamp: now, Payload: 1}},
+			nil,
+			{{SensorSerialNumber: "1234567890", SensorID: 7, Timestamp: now, Payload: 5}},
+		}, batches)
+
+		assert.Equal(t, domain.ImportStatusCompleted, imp.Status)
+		assert.Equal(t, int64(5), imp.RowsProcessed)
+		assert.Equal(t, int64(2), imp.RowsImported)
+		assert.Equal(t, int64(3), imp.RowsFailed)
+		assert.Equal(t, []domain.ImportRowError{
+			{Row: 2, Reason: ErrWrongSensorSerialNumber.Error()},
+			{Row: 3, Reason: ErrSensorNotFound.Error()},
+			{Row: 4, Reason: "invalid row"},
+		}, imp.Errors)
+		require.Len(t, saved, 2)
+		assert.Equal(t, domain.ImportStatusRunning, saved[0].Status)
+		assert.Equal(t, domain.ImportStatusCompleted, saved[1].Status)
+	})
+
+	t.Run("ok, resume skips processed rows", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(1).Return(&domain.Sensor{ID: 7}, nil)
+
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().GetImport(ctx, "a").Times(1).Return(&domain.Import{
+			ID:            "a",
+			Kind:          domain.ImportKindEvents,
+			Status:        domain.ImportStatusFailed,
+			RowsProcessed: 4,
+			RowsImported:  1,
+			RowsFailed:    3,
+			Error:         "connection reset",
+		}, nil)
+		ir.EXPECT().CommitEvents(ctx, gomock.Any(), gomock.Len(1)).Times(1).Return(nil)
+		ir.EXPECT().SaveImport(ctx, gomock.Any()).Times(1).Return(nil)
+
+		imp, err := NewImport(ir, sr).Run(ctx, "a", domain.ImportKindEvents, &sliceSource{records: eventRecords(now)})
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.ImportStatusCompleted, imp.Status)
+		assert.Equal(t, int64(5), imp.RowsProcessed)
+		assert.Equal(t, int64(2), imp.RowsImported)
+		assert.Empty(t, imp.Error)
+	})
+
+	t.Run("ok, completed import is not loaded again", func(t *testing.T) {
+		ctx := context.Background()
+
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().GetImport(ctx, "a").Times(1).Return(&domain.Import{
+			ID: "a", Kind: domain.ImportKindEvents, Status: domain.ImportStatusCompleted, RowsProcessed: 5,
+		}, nil)
+
+		imp, err := NewImport(ir, nil).Run(ctx, "a", domain.ImportKindEvents, &sliceSource{records: eventRecords(now)})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), imp.RowsProcessed)
+	})
+
+	t.Run("err, storage error keeps committed progress", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(1).Return(&domain.Sensor{ID: 7}, nil)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "0987654321").Times(1).Return(nil, ErrSensorNotFound)
+
+		expectedError := errors.New("some error")
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().GetImport(ctx, "a").Times(1).Return(nil, ErrImportNotFound)
+		ir.EXPECT().SaveImport(ctx, gomock.Any()).Times(1).Return(nil)
+		gomock.InOrder(
+			ir.EXPECT().CommitEvents(ctx, gomock.Any(), gomock.Any()).Times(1).Return(nil),
+			ir.EXPECT().CommitEvents(ctx, gomock.Any(), gomock.Any()).Times(1).Return(expectedError),
+		)
+		var failed domain.Import
+		ir.EXPECT().SaveImport(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, imp *domain.Import) error {
+			failed = *imp
+			return nil
+		})
+
+		imp, err := NewImport(ir, sr, WithImportBatchSize(2)).Run(ctx, "a", domain.ImportKindEvents,
+			&sliceSource{records: eventRecords(now)})
+		assert.ErrorIs(t, err, expectedError)
+		require.NotNil(t, imp)
+
+		assert.Equal(t, domain.ImportStatusFailed, failed.Status)
+		assert.Equal(t, int64(2), failed.RowsProcessed)
+		assert.Equal(t, int64(1), failed.RowsFailed)
+		assert.Equal(t, expectedError.Error(), failed.Error)
+	})
+
+	t.Run("err, read error", func(t *testing.T) {
+		ctx := context.Background()
+
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().SaveImport(ctx, gomock.Any()).Times(1).Return(nil)
+		ir.EXPECT().SaveImport(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		imp, err := NewImport(ir, nil).Run(ctx, "", domain.ImportKindSensors,
+			&sliceSource{err: io.ErrUnexpectedEOF})
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.NotNil(t, imp)
+		assert.NotEmpty(t, imp.ID)
+		assert.Equal(t, domain.ImportStatusFailed, imp.Status)
+	})
+
+	t.Run("ok, sensors", func(t *testing.T) {
+		ctx := context.Background()
+
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().SaveImport(ctx, gomock.Any()).Times(2).Return(nil)
+
+		var sensors []domain.Sensor
+		ir.EXPECT().CommitSensors(ctx, gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+			func(_ context.Context, _ *domain.Import, batch []domain.Sensor) error {
+				sensors = append(sensors, batch...)
+				return nil
+			})
+
+		imp, err := NewImport(ir, nil).Run(ctx, "", domain.ImportKindSensors, &sliceSource{records: []ImportRecord{
+			{Row: 1, Sensor: &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC, IsActive: true}},
+			{Row: 2, Sensor: &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC}},
+			{Row: 3, Sensor: &domain.Sensor{SerialNumber: "0987654321", Type: "some"}},
+		}})
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.Sensor{{SerialNumber: "1234567890", Type: domain.SensorTypeADC, IsActive: true}}, sensors)
+		assert.Equal(t, int64(2), imp.RowsImported)
+		assert.Equal(t, []domain.ImportRowError{{Row: 3, Reason: ErrWrongSensorType.Error()}}, imp.Errors)
+	})
+
+	t.Run("ok, payload out of range", func(t *testing.T) {
+		ctx := context.Background()
+
+		types := NewSensorTypes(nil)
+		high := 1.0
+		require.NoError(t, types.SaveSensorType(ctx, &domain.SensorTypeSpec{
+			Name: "switch", Payload: domain.PayloadKindBinary, Max: &high,
+		}))
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(1).Return(&domain.Sensor{ID: 1, Type: "switch"}, nil)
+
+		ir := NewMockImportRepository(ctrl)
+		ir.EXPECT().SaveImport(ctx, gomock.Any()).Times(2).Return(nil)
+		ir.EXPECT().CommitEvents(ctx, gomock.Any(), gomock.Len(1)).Times(1).Return(nil)
+
+		imp, err := NewImport(ir, sr, WithImportSensorTypes(types)).Run(ctx, "", domain.ImportKindEvents, &sliceSource{records: []ImportRecord{
+			{Row: 1, Event: &domain.Event{SensorSerialNumber: "1234567890", Timestamp: now, Payload: 1}},
+			{Row: 2, Event: &domain.Event{SensorSerialNumber: "1234567890", Timestamp: now, Payload: 5}},
+		}})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), imp.RowsImported)
+		require.Len(t, imp.Errors, 1)
+		assert.Equal(t, int64(2), imp.Errors[0].Row)
+		assert.Contains(t, imp.Errors[0].Reason, ErrPayloadOutOfRange.Error())
+	})
+}
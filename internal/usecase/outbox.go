@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"log/slog"
+)
+
+// defaultOutboxBatch - сколько сообщений публикуется в одной единице работы
+const defaultOutboxBatch = 100
+
+// PublisherFunc - функция публикации как Publisher
+type PublisherFunc func(ctx context.Context, message domain.OutboxMessage) error
+
+func (f PublisherFunc) Publish(ctx context.Context, message domain.OutboxMessage) error {
+	return f(ctx, message)
+}
+
+// noUnitOfWork - единица работы без транзакции, каждое изменение сохраняется сразу
+type noUnitOfWork struct{}
+
+func (noUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Outbox - пересылка сообщений outbox во внешнюю систему.
+//
+// Relay забирает самые старые сообщения, публикует их по порядку и удаляет опубликованные в той же
+// единице работы. Если публикация не удалась, сообщение и следующие за ним остаются в outbox до
+// следующего прохода, поэтому каждое сообщение публикуется хотя бы один раз.
+type Outbox struct {
+	repo      OutboxRepository
+	uow       UnitOfWork
+	publisher Publisher
+	batch     int
+}
+
+func NewOutbox(repo OutboxRepository, uow UnitOfWork, publisher Publisher, options ...func(*Outbox)) *Outbox {
+	o := &Outbox{
+		repo:      repo,
+		uow:       uow,
+		publisher: publisher,
+		batch:     defaultOutboxBatch,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+	return o
+}
+
+// WithOutboxBatchSize - сколько сообщений публикуется в одной единице работы
+func WithOutboxBatchSize(n int) func(*Outbox) {
+	return func(o *Outbox) {
+		o.batch = n
+	}
+}
+
+// Relay - функция, которая публикует накопившиеся сообщения и возвращает число опубликованных.
+// Ошибка публикации прерывает проход, опубликованные до неё сообщения удаляются из outbox.
+func (o *Outbox) Relay(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Outbox.Relay")
+	defer func() { tracing.End(span, err) }()
+
+	logger := logging.FromContext(ctx)
+
+	published := 0
+	for {
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+
+		var (
+			claimed    int
+			ids        []int64
+			publishErr error
+		)
+		err := o.uow.Do(ctx, func(ctx context.Context) error {
+			messages, err := o.repo.GetOutboxMessages(ctx, o.batch)
+			if err != nil {
+				return err
+			}
+			claimed = len(messages)
+
+			ids = make([]int64, 0, len(messages))
+			for _, message := range messages {
+				if publishErr = o.publisher.Publish(ctx, message); publishErr != nil {
+					logger.WarnContext(ctx, "can't publish outbox message",
+						slog.Int64("message_id", message.ID), slog.String("topic", message.Topic), logging.Error(publishErr))
+					break
+				}
+
+				ids = append(ids, message.ID)
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			return o.repo.DeleteOutboxMessages(ctx, ids)
+		})
+		if err != nil {
+			logger.ErrorContext(ctx, "can't relay outbox messages", logging.Error(err))
+			return published, err
+		}
+
+		published += len(ids)
+		if publishErr != nil {
+			return published, publishErr
+		}
+		if claimed < o.batch {
+			return published, nil
+		}
+	}
+}
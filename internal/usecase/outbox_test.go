@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outbox_Relay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	newUnitOfWork := func(times int) *MockUnitOfWork {
+		uow := NewMockUnitOfWork(ctrl)
+		uow.EXPECT().Do(gomock.Any(), gomock.Any()).Times(times).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+		return uow
+	}
+
+	messages := func(ids ...int64) []domain.OutboxMessage {
+		out := make([]domain.OutboxMessage, 0, len(ids))
+		for _, id := range ids {
+			out = append(out, domain.OutboxMessage{ID: id, Topic: domain.OutboxSensorEvent})
+		}
+		return out
+	}
+
+	t.Run("ok, full batches drained", func(t *testing.T) {
+		repo := NewMockOutboxRepository(ctrl)
+		gomock.InOrder(
+			repo.EXPECT().GetOutboxMessages(gomock.Any(), 2).Return(messages(1, 2), nil),
+			repo.EXPECT().DeleteOutboxMessages(gomock.Any(), []int64{1, 2}).Return(nil),
+			repo.EXPECT().GetOutboxMessages(gomock.Any(), 2).Return(messages(3), nil),
+			repo.EXPECT().DeleteOutboxMessages(gomock.Any(), []int64{3}).Return(nil),
+		)
+
+		var published []int64
+		publisher := PublisherFunc(func(_ context.Context, m domain.OutboxMessage) error {
+			published = append(published, m.ID)
+			return nil
+		})
+
+		n, err := NewOutbox(repo, newUnitOfWork(2), publisher, WithOutboxBatchSize(2)).Relay(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, []int64{1, 2, 3}, published)
+	})
+
+	t.Run("ok, empty outbox", func(t *testing.T) {
+		repo := NewMockOutboxRepository(ctrl)
+		repo.EXPECT().GetOutboxMessages(gomock.Any(), defaultOutboxBatch).Times(1).Return(nil, nil)
+		repo.EXPECT().DeleteOutboxMessages(gomock.Any(), gomock.Any()).Times(0)
+
+		n, err := NewOutbox(repo, newUnitOfWork(1), nil).Relay(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("err, publish error keeps the rest", func(t *testing.T) {
+		expectedError := errors.New("broker unavailable")
+
+		repo := NewMockOutboxRepository(ctrl)
+		repo.EXPECT().GetOutboxMessages(gomock.Any(), defaultOutboxBatch).Times(1).Return(messages(1, 2, 3), nil)
+		repo.EXPECT().DeleteOutboxMessages(gomock.Any(), []int64{1}).Times(1).Return(nil)
+
+		publisher := PublisherFunc(func(_ context.Context, m domain.OutboxMessage) error {
+			if m.ID == 2 {
+				return expectedError
+			}
+			return nil
+		})
+
+		n, err := NewOutbox(repo, newUnitOfWork(1), publisher).Relay(ctx)
+		assert.ErrorIs(t, err, expectedError)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("err, unit of work error", func(t *testing.T) {
+		expectedError := errors.New("some error")
+
+		repo := NewMockOutboxRepository(ctrl)
+		repo.EXPECT().GetOutboxMessages(gomock.Any(), defaultOutboxBatch).Times(1).Return(nil, expectedError)
+
+		n, err := NewOutbox(repo, newUnitOfWork(1), nil).Relay(ctx)
+		assert.ErrorIs(t, err, expectedError)
+		assert.Zero(t, n)
+	})
+}
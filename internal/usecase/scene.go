@@ -0,0 +1,314 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	maxSceneNameLength = 64
+	maxSceneTargets    = 100
+	// defaultSceneActivations, maxSceneActivations - сколько записей истории сцены отдаётся по умолчанию и максимум
+	defaultSceneActivations = 50
+	maxSceneActivations     = 1000
+)
+
+// Scene - сцены: наборы настроек, которые применяются к нескольким датчикам сразу.
+// Настройки датчиков меняются через usecase.Sensor, состояния устройств - командами usecase.Command.
+type Scene struct {
+	repo     SceneRepository
+	sensors  *Sensor
+	commands *Command
+	now      func() time.Time
+}
+
+func NewScene(repo SceneRepository, sensors *Sensor, options ...func(*Scene)) *Scene {
+	s := &Scene{
+		repo:    repo,
+		sensors: sensors,
+		now:     time.Now,
+	}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithSceneCommands - очередь команд для состояний исполнительных устройств, без неё сцены не могут задавать state
+func WithSceneCommands(commands *Command) func(*Scene) {
+	return func(s *Scene) {
+		s.commands = commands
+	}
+}
+
+// validate - проверяет сцену перед сохранением: все датчики должны существовать,
+// а state можно задавать только исполнительным устройствам
+func (s *Scene) validate(ctx context.Context, scene *domain.Scene) error {
+	scene.Name = strings.TrimSpace(scene.Name)
+	if scene.Name == "" || utf8.RuneCountInString(scene.Name) > maxSceneNameLength {
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidScene, maxSceneNameLength)
+	}
+	if len(scene.Targets) == 0 || len(scene.Targets) > maxSceneTargets {
+		return fmt.Errorf("%w: scene must have 1 to %d targets", ErrInvalidScene, maxSceneTargets)
+	}
+
+	seen := make(map[int64]bool, len(scene.Targets))
+	for _, target := range scene.Targets {
+		if seen[target.SensorID] {
+			return fmt.Errorf("%w: duplicate target for sensor %d", ErrInvalidScene, target.SensorID)
+		}
+		seen[target.SensorID] = true
+
+		if target.IsActive == nil && target.Description == nil && target.State == nil {
+			return fmt.Errorf("%w: target for sensor %d changes nothing", ErrInvalidScene, target.SensorID)
+		}
+
+		if _, err := s.sensors.GetSensorByID(ctx, target.SensorID); err != nil {
+			if errors.Is(err, ErrSensorNotFound) {
+				return fmt.Errorf("%w: sensor %d not found", ErrInvalidScene, target.SensorID)
+			}
+			return err
+		}
+
+		if target.State == nil {
+			continue
+		}
+		if s.commands == nil {
+			return fmt.Errorf("%w: device states are not supported", ErrInvalidScene)
+		}
+		if _, err := s.commands.GetActuator(ctx, target.SensorID); err != nil {
+			if errors.Is(err, ErrNotActuator) {
+				return fmt.Errorf("%w: sensor %d does not accept commands", ErrInvalidScene, target.SensorID)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateScene - добавляет сцену
+func (s *Scene) CreateScene(ctx context.Context, scene *domain.Scene) (_ *domain.Scene, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scene.CreateScene")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if err := s.validate(ctx, scene); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	scene.ID = 0
+	scene.CreatedAt = now
+	scene.UpdatedAt = now
+
+	if err := s.repo.SaveScene(ctx, scene); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "scene created",
+		slog.Int64("scene_id", scene.ID), slog.String("name", scene.Name))
+
+	return scene, nil
+}
+
+// UpdateScene - заменяет название, описание и настройки сцены id
+func (s *Scene) UpdateScene(ctx context.Context, id int64, scene *domain.Scene) (_ *domain.Scene, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scene.UpdateScene",
+		trace.WithAttributes(attribute.Int64("scene.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	stored, err := s.repo.GetSceneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(ctx, scene); err != nil {
+		return nil, err
+	}
+
+	scene.ID = stored.ID
+	scene.CreatedAt = stored.CreatedAt
+	scene.UpdatedAt = s.now()
+
+	if err := s.repo.SaveScene(ctx, scene); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "scene updated", slog.Int64("scene_id", scene.ID))
+
+	return scene, nil
+}
+
+// GetScene - сцена по ID
+func (s *Scene) GetScene(ctx context.Context, id int64) (_ *domain.Scene, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scene.GetScene",
+		trace.WithAttributes(attribute.Int64("scene.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return s.repo.GetSceneByID(ctx, id)
+}
+
+// GetScenes - все сцены по возрастанию ID
+func (s *Scene) GetScenes(ctx context.Context) (_ []domain.Scene, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scene.GetScenes")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return s.repo.GetScenes(ctx)
+}
+
+// DeleteScene - удаляет сцену и историю её применения
+func (s *Scene) DeleteScene(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scene.DeleteScene",
+		trace.WithAttributes(attribute.Int64("scene.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := s.repo.DeleteScene(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "scene deleted", slog.Int64("scene_id", id))
+
+	return nil
+}
+
+// ActivateScene - применяет настройки сцены ко всем её датчикам. Ошибка одного датчика не прерывает
+// применение к остальным: она попадает в результат, а итог записывается в историю сцены.
+func (s *Scene) ActivateScene(ctx context.Context, id int64) (_ *domain.SceneActivation, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scene.ActivateScene",
+		trace.WithAttributes(attribute.Int64("scene.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	scene, err := s.repo.GetSceneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logging.FromContext(ctx).With(slog.Int64("scene_id", scene.ID))
+
+	activation := &domain.SceneActivation{
+		SceneID:     scene.ID,
+		ActivatedAt: s.now(),
+		Results:     make([]domain.SceneTargetResult, 0, len(scene.Targets)),
+	}
+
+	failed := 0
+	for _, target := range scene.Targets {
+		result := s.apply(ctx, target)
+		if result.Error != "" {
+			failed++
+			logger.WarnContext(ctx, "scene target not applied",
+				logging.SensorID(target.SensorID), slog.String("reason", result.Error))
+		}
+		activation.Results = append(activation.Results, result)
+	}
+
+	switch failed {
+	case 0:
+		activation.Status = domain.SceneActivationApplied
+	case len(scene.Targets):
+		activation.Status = domain.SceneActivationFailed
+	default:
+		activation.Status = domain.SceneActivationPartial
+	}
+
+	if err := s.repo.SaveActivation(ctx, activation); err != nil {
+		logger.ErrorContext(ctx, "can't save scene activation", logging.Error(err))
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "scene activated",
+		slog.String("status", string(activation.Status)), slog.Int("failed", failed))
+
+	return activation, nil
+}
+
+// apply - применяет настройки к одному датчику
+func (s *Scene) apply(ctx context.Context, target domain.SceneTarget) domain.SceneTargetResult {
+	result := domain.SceneTargetResult{SensorID: target.SensorID}
+
+	if target.IsActive != nil || target.Description != nil {
+		_, err := s.sensors.UpdateSensor(ctx, target.SensorID, 0, SensorUpdate{
+			IsActive:    target.IsActive,
+			Description: target.Description,
+		})
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	if target.State != nil {
+		if s.commands == nil {
+			result.Error = "device states are not supported"
+			return result
+		}
+		command, err := s.commands.SendCommand(ctx, target.SensorID,
+			&domain.Command{Kind: domain.CommandSetState, State: *target.State}, 0)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.CommandID = command.ID
+	}
+
+	return result
+}
+
+// GetActivations - последние limit записей истории сцены, от новых к старым; 0 - значение по умолчанию
+func (s *Scene) GetActivations(ctx context.Context, id int64, limit int) (_ []domain.SceneActivation, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scene.GetActivations",
+		trace.WithAttributes(attribute.Int64("scene.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if limit < 0 || limit > maxSceneActivations {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidScene, maxSceneActivations)
+	}
+	if limit == 0 {
+		limit = defaultSceneActivations
+	}
+
+	if _, err := s.repo.GetSceneByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetActivations(ctx, id, limit)
+}
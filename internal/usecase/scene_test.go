@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_scene_CreateScene(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1000, 0)
+	off, on := false, int64(1)
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).AnyTimes().
+		Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(2)).AnyTimes().
+		Return(&domain.Sensor{ID: 2, Type: "relay"}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(3)).AnyTimes().Return(nil, ErrSensorNotFound)
+
+	newScene := func(repo SceneRepository, commands bool) *Scene {
+		var options []func(*Scene)
+		if commands {
+			options = append(options, WithSceneCommands(NewCommand(nil, sr)))
+		}
+		s := NewScene(repo, NewSensor(sr), options...)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockSceneRepository(ctrl)
+		repo.EXPECT().SaveScene(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, s *domain.Scene) error {
+			s.ID = 5
+			return nil
+		})
+
+		scene, err := newScene(repo, true).CreateScene(ctx, &domain.Scene{
+			Name: "  night ",
+			Targets: []domain.SceneTarget{
+				{SensorID: 1, IsActive: &off},
+				{SensorID: 2, State: &on},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), scene.ID)
+		assert.Equal(t, "night", scene.Name)
+		assert.Equal(t, now, scene.CreatedAt)
+		assert.Equal(t, now, scene.UpdatedAt)
+	})
+
+	tests := []struct {
+		name     string
+		commands bool
+		scene    domain.Scene
+	}{
+		{
+			name:  "err, empty name",
+			scene: domain.Scene{Name: " ", Targets: []domain.SceneTarget{{SensorID: 1, IsActive: &off}}},
+		},
+		{
+			name:  "err, no targets",
+			scene: domain.Scene{Name: "night"},
+		},
+		{
+			name: "err, duplicate target",
+			scene: domain.Scene{Name: "night", Targets: []domain.SceneTarget{
+				{SensorID: 1, IsActive: &off},
+				{SensorID: 1, IsActive: &off},
+			}},
+		},
+		{
+			name:  "err, empty target",
+			scene: domain.Scene{Name: "night", Targets: []domain.SceneTarget{{SensorID: 1}}},
+		},
+		{
+			name:  "err, unknown sensor",
+			scene: domain.Scene{Name: "night", Targets: []domain.SceneTarget{{SensorID: 3, IsActive: &off}}},
+		},
+		{
+			name:  "err, state without commands",
+			scene: domain.Scene{Name: "night", Targets: []domain.SceneTarget{{SensorID: 2, State: &on}}},
+		},
+		{
+			name:     "err, state of not actuator",
+			commands: true,
+			scene:    domain.Scene{Name: "night", Targets: []domain.SceneTarget{{SensorID: 1, State: &on}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newScene(nil, tt.commands).CreateScene(context.Background(), &tt.scene)
+			assert.ErrorIs(t, err, ErrInvalidScene)
+		})
+	}
+}
+
+func Test_scene_ActivateScene(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1000, 0)
+	off, on := false, int64(1)
+
+	t.Run("ok, partial", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).
+			Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC, IsActive: true}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+		sr.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).
+			Return(&domain.Sensor{ID: 2, Type: "relay"}, nil)
+		sr.EXPECT().GetSensorByID(ctx, int64(3)).Times(1).Return(nil, ErrSensorNotFound)
+
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, c *domain.Command) error {
+			c.ID = 9
+			return nil
+		})
+
+		repo := NewMockSceneRepository(ctrl)
+		repo.EXPECT().GetSceneByID(ctx, int64(5)).Times(1).Return(&domain.Scene{
+			ID:   5,
+			Name: "night",
+			Targets: []domain.SceneTarget{
+				{SensorID: 1, IsActive: &off},
+				{SensorID: 2, State: &on},
+				{SensorID: 3, IsActive: &off},
+			},
+		}, nil)
+		repo.EXPECT().SaveActivation(ctx, gomock.Any()).Times(1).Return(nil)
+
+		s := NewScene(repo, NewSensor(sr), WithSceneCommands(NewCommand(cr, sr)))
+		s.now = func() time.Time { return now }
+
+		activation, err := s.ActivateScene(ctx, 5)
+		require.NoError(t, err)
+		assert.Equal(t, &domain.SceneActivation{
+			SceneID:     5,
+			Status:      domain.SceneActivationPartial,
+			ActivatedAt: now,
+			Results: []domain.SceneTargetResult{
+				{SensorID: 1},
+				{SensorID: 2, CommandID: 9},
+				{SensorID: 3, Error: ErrSensorNotFound.Error()},
+			},
+		}, activation)
+	})
+
+	t.Run("ok, failed", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(3)).Times(1).Return(nil, ErrSensorNotFound)
+
+		repo := NewMockSceneRepository(ctrl)
+		repo.EXPECT().GetSceneByID(ctx, int64(5)).Times(1).Return(&domain.Scene{
+			ID:      5,
+			Targets: []domain.SceneTarget{{SensorID: 3, IsActive: &off}},
+		}, nil)
+		repo.EXPECT().SaveActivation(ctx, gomock.Any()).Times(1).Return(nil)
+
+		activation, err := NewScene(repo, NewSensor(sr)).ActivateScene(ctx, 5)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SceneActivationFailed, activation.Status)
+	})
+
+	t.Run("err, not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockSceneRepository(ctrl)
+		repo.EXPECT().GetSceneByID(ctx, int64(5)).Times(1).Return(nil, ErrSceneNotFound)
+
+		_, err := NewScene(repo, nil).ActivateScene(ctx, 5)
+		assert.ErrorIs(t, err, ErrSceneNotFound)
+	})
+}
+
+func Test_scene_GetActivations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, default limit", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockSceneRepository(ctrl)
+		repo.EXPECT().GetSceneByID(ctx, int64(5)).Times(1).Return(&domain.Scene{ID: 5}, nil)
+		repo.EXPECT().GetActivations(ctx, int64(5), defaultSceneActivations).Times(1).Return(nil, nil)
+
+		_, err := NewScene(repo, nil).GetActivations(ctx, 5, 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("err, limit", func(t *testing.T) {
+		_, err := NewScene(nil, nil).GetActivations(context.Background(), 5, maxSceneActivations+1)
+		assert.ErrorIs(t, err, ErrInvalidScene)
+	})
+}
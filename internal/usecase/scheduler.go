@@ -0,0 +1,619 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/cron"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	maxScheduleNameLength = 64
+	maxScheduleSensors    = 100
+	// defaultScheduleInterval - как часто проверяются расписания, которым пора сработать
+	defaultScheduleInterval = 10 * time.Second
+	// defaultMissedRunGrace - запуск, опоздавший больше чем на это время, считается пропущенным
+	defaultMissedRunGrace = time.Minute
+	// defaultScheduleHTTPTimeout - сколько ждать ответа на запрос действия http
+	defaultScheduleHTTPTimeout = 10 * time.Second
+	// defaultScheduleRuns, maxScheduleRuns - сколько записей истории отдаётся по умолчанию и максимум
+	defaultScheduleRuns = 50
+	maxScheduleRuns     = 1000
+)
+
+// Scheduler - расписания: действия, которые выполняются по cron выражениям.
+//
+// Run проверяет расписания раз в interval и выполняет те, чьё время пришло. Запуск, опоздавший
+// больше чем на grace (например, пока сервис был остановлен), считается пропущенным, и дальше
+// всё решает политика расписания: выполнить его один раз или пропустить. Если задан Leader,
+// расписания выполняет только ведущая реплика.
+type Scheduler struct {
+	repo     ScheduleRepository
+	sensors  *Sensor
+	scenes   *Scene
+	commands *Command
+	leader   Leader
+	client   *http.Client
+	interval time.Duration
+	grace    time.Duration
+	now      func() time.Time
+}
+
+func NewScheduler(repo ScheduleRepository, sensors *Sensor, options ...func(*Scheduler)) *Scheduler {
+	s := &Scheduler{
+		repo:     repo,
+		sensors:  sensors,
+		client:   &http.Client{Timeout: defaultScheduleHTTPTimeout},
+		interval: defaultScheduleInterval,
+		grace:    defaultMissedRunGrace,
+		now:      time.Now,
+	}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithSchedulerScenes - сцены для действия activate_scene, без них оно недоступно
+func WithSchedulerScenes(scenes *Scene) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.scenes = scenes
+	}
+}
+
+// WithSchedulerCommands - очередь команд для действия send_command, без неё оно недоступно
+func WithSchedulerCommands(commands *Command) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.commands = commands
+	}
+}
+
+// WithSchedulerLeader - выполнять расписания, только пока реплика ведущая
+func WithSchedulerLeader(leader Leader) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.leader = leader
+	}
+}
+
+// WithSchedulerHTTPClient - клиент для действия http
+func WithSchedulerHTTPClient(client *http.Client) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.client = client
+	}
+}
+
+// WithSchedulerInterval - как часто проверять расписания
+func WithSchedulerInterval(interval time.Duration) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.interval = interval
+	}
+}
+
+// WithMissedRunGrace - на сколько запуск может опоздать, не считаясь пропущенным
+func WithMissedRunGrace(grace time.Duration) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.grace = grace
+	}
+}
+
+// validate - проверяет расписание перед сохранением и возвращает его временную зону
+func (s *Scheduler) validate(ctx context.Context, schedule *domain.Schedule) (*time.Location, error) {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" || utf8.RuneCountInString(schedule.Name) > maxScheduleNameLength {
+		return nil, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidSchedule, maxScheduleNameLength)
+	}
+
+	if _, err := cron.Parse(schedule.Cron); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+
+	if schedule.Timezone == "" {
+		schedule.Timezone = time.UTC.String()
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, schedule.Timezone)
+	}
+
+	switch schedule.MissedRuns {
+	case "":
+		schedule.MissedRuns = domain.MissedRunOnce
+	case domain.MissedRunOnce, domain.MissedRunSkip:
+	default:
+		return nil, fmt.Errorf("%w: unknown missed runs policy %q", ErrInvalidSchedule, schedule.MissedRuns)
+	}
+
+	if err := s.validateAction(ctx, &schedule.Action); err != nil {
+		return nil, err
+	}
+
+	return loc, nil
+}
+
+func (s *Scheduler) validateAction(ctx context.Context, action *domain.ScheduleAction) error {
+	switch action.Kind {
+	case domain.ScheduleUpdateSensors:
+		if action.IsActive == nil && action.Description == nil {
+			return fmt.Errorf("%w: update_sensors action changes nothing", ErrInvalidSchedule)
+		}
+		return s.validateSensors(ctx, action.SensorIDs, false, false)
+
+	case domain.ScheduleActivateScene:
+		if s.scenes == nil {
+			return fmt.Errorf("%w: scenes are not supported", ErrInvalidSchedule)
+		}
+		if _, err := s.scenes.GetScene(ctx, action.SceneID); err != nil {
+			if errors.Is(err, ErrSceneNotFound) {
+				return fmt.Errorf("%w: scene %d not found", ErrInvalidSchedule, action.SceneID)
+			}
+			return err
+		}
+		return nil
+
+	case domain.ScheduleSendCommand:
+		if s.commands == nil {
+			return fmt.Errorf("%w: device commands are not supported", ErrInvalidSchedule)
+		}
+		if action.CommandKind != domain.CommandSetState && action.CommandKind != domain.CommandToggle {
+			return fmt.Errorf("%w: command must be set_state or toggle", ErrInvalidSchedule)
+		}
+		return s.validateSensors(ctx, action.SensorIDs, false, true)
+
+	case domain.ScheduleHTTP:
+		u, err := url.Parse(action.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidSchedule)
+		}
+		return s.validateSensors(ctx, action.SensorIDs, true, false)
+
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidSchedule, action.Kind)
+	}
+}
+
+// validateSensors - датчики действия должны существовать и не повторяться, а для команд - принимать их
+func (s *Scheduler) validateSensors(ctx context.Context, ids []int64, allowEmpty, actuators bool) error {
+	if (len(ids) == 0 && !allowEmpty) || len(ids) > maxScheduleSensors {
+		return fmt.Errorf("%w: action must have 1 to %d sensors", ErrInvalidSchedule, maxScheduleSensors)
+	}
+
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("%w: duplicate sensor %d", ErrInvalidSchedule, id)
+		}
+		seen[id] = true
+
+		var err error
+		if actuators {
+			_, err = s.commands.GetActuator(ctx, id)
+		} else {
+			_, err = s.sensors.GetSensorByID(ctx, id)
+		}
+		switch {
+		case errors.Is(err, ErrSensorNotFound):
+			return fmt.Errorf("%w: sensor %d not found", ErrInvalidSchedule, id)
+		case errors.Is(err, ErrNotActuator):
+			return fmt.Errorf("%w: sensor %d does not accept commands", ErrInvalidSchedule, id)
+		case err != nil:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextRun - следующий запуск включённого расписания после now
+func nextRun(schedule *domain.Schedule, loc *time.Location, now time.Time) time.Time {
+	if !schedule.Enabled {
+		return time.Time{}
+	}
+	expr, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	next := expr.Next(now.In(loc))
+	if next.IsZero() {
+		return next
+	}
+	return next.UTC()
+}
+
+// CreateSchedule - добавляет расписание
+func (s *Scheduler) CreateSchedule(ctx context.Context, schedule *domain.Schedule) (_ *domain.Schedule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.CreateSchedule")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	loc, err := s.validate(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	schedule.ID = 0
+	schedule.NextRunAt = nextRun(schedule, loc, now)
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	if err := s.repo.SaveSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "schedule created",
+		slog.Int64("schedule_id", schedule.ID), slog.String("name", schedule.Name))
+
+	return schedule, nil
+}
+
+// UpdateSchedule - заменяет расписание id, следующий запуск считается заново от текущего времени
+func (s *Scheduler) UpdateSchedule(ctx context.Context, id int64, schedule *domain.Schedule) (_ *domain.Schedule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.UpdateSchedule",
+		trace.WithAttributes(attribute.Int64("schedule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	stored, err := s.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := s.validate(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	schedule.ID = stored.ID
+	schedule.NextRunAt = nextRun(schedule, loc, now)
+	schedule.CreatedAt = stored.CreatedAt
+	schedule.UpdatedAt = now
+
+	if err := s.repo.SaveSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "schedule updated", slog.Int64("schedule_id", schedule.ID))
+
+	return schedule, nil
+}
+
+// GetSchedule - расписание по ID
+func (s *Scheduler) GetSchedule(ctx context.Context, id int64) (_ *domain.Schedule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.GetSchedule",
+		trace.WithAttributes(attribute.Int64("schedule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return s.repo.GetScheduleByID(ctx, id)
+}
+
+// GetSchedules - все расписания по возрастанию ID
+func (s *Scheduler) GetSchedules(ctx context.Context) (_ []domain.Schedule, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.GetSchedules")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return s.repo.GetSchedules(ctx)
+}
+
+// DeleteSchedule - удаляет расписание и историю его запусков
+func (s *Scheduler) DeleteSchedule(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.DeleteSchedule",
+		trace.WithAttributes(attribute.Int64("schedule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := s.repo.DeleteSchedule(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "schedule deleted", slog.Int64("schedule_id", id))
+
+	return nil
+}
+
+// GetRuns - последние limit записей истории запусков, от новых к старым; 0 - значение по умолчанию
+func (s *Scheduler) GetRuns(ctx context.Context, id int64, limit int) (_ []domain.ScheduleRun, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.GetRuns",
+		trace.WithAttributes(attribute.Int64("schedule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if limit < 0 || limit > maxScheduleRuns {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidSchedule, maxScheduleRuns)
+	}
+	if limit == 0 {
+		limit = defaultScheduleRuns
+	}
+
+	if _, err := s.repo.GetScheduleByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetRuns(ctx, id, limit)
+}
+
+// RunSchedule - выполняет действие расписания сейчас, не меняя время следующего запуска
+func (s *Scheduler) RunSchedule(ctx context.Context, id int64) (_ *domain.ScheduleRun, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.RunSchedule",
+		trace.WithAttributes(attribute.Int64("schedule.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	schedule, err := s.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	run := &domain.ScheduleRun{ScheduleID: schedule.ID, ScheduledAt: now, StartedAt: now}
+	s.execute(ctx, schedule, run)
+
+	if err := s.repo.SaveRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// Run - выполняет расписания, пока не отменён ctx
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.leader != nil {
+		return s.leader.Lead(ctx, s.run)
+	}
+	s.run(ctx)
+	return ctx.Err()
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	logging.FromContext(ctx).InfoContext(ctx, "scheduler started")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		// ошибки уже записаны в лог
+		_ = s.RunDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			logging.FromContext(ctx).InfoContext(ctx, "scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue - выполняет расписания, время которых пришло
+func (s *Scheduler) RunDue(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Scheduler.RunDue")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	now := s.now().UTC()
+	schedules, err := s.repo.GetDueSchedules(ctx, now)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "can't get due schedules", logging.Error(err))
+		return err
+	}
+
+	for i := range schedules {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.fire(ctx, &schedules[i], now); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "can't run schedule",
+				slog.Int64("schedule_id", schedules[i].ID), logging.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// fire - переносит следующий запуск расписания и выполняет текущий. Запуск переносится до
+// выполнения, поэтому при сбое посреди действия оно не повторится.
+func (s *Scheduler) fire(ctx context.Context, schedule *domain.Schedule, now time.Time) error {
+	logger := logging.FromContext(ctx).With(slog.Int64("schedule_id", schedule.ID))
+	ctx = logging.WithContext(ctx, logger)
+
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	scheduledAt := schedule.NextRunAt
+	claimed, err := s.repo.ClaimSchedule(ctx, schedule.ID, scheduledAt, nextRun(schedule, loc, now))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	run := &domain.ScheduleRun{ScheduleID: schedule.ID, ScheduledAt: scheduledAt, StartedAt: now}
+	if late := now.Sub(scheduledAt); late > s.grace && schedule.MissedRuns == domain.MissedRunSkip {
+		run.Status = domain.ScheduleRunSkipped
+		run.Error = fmt.Sprintf("run missed by %s", late.Truncate(time.Second))
+		logger.InfoContext(ctx, "missed schedule run skipped", slog.Time("scheduled_at", scheduledAt))
+	} else {
+		s.execute(ctx, schedule, run)
+	}
+
+	return s.repo.SaveRun(ctx, run)
+}
+
+// execute - выполняет действие расписания и записывает итог в run
+func (s *Scheduler) execute(ctx context.Context, schedule *domain.Schedule, run *domain.ScheduleRun) {
+	logger := logging.FromContext(ctx)
+
+	if err := s.act(ctx, schedule, run.StartedAt); err != nil {
+		run.Status = domain.ScheduleRunFailed
+		run.Error = err.Error()
+		logger.WarnContext(ctx, "schedule run failed", slog.String("name", schedule.Name), logging.Error(err))
+		return
+	}
+
+	run.Status = domain.ScheduleRunSucceeded
+	logger.InfoContext(ctx, "schedule run succeeded", slog.String("name", schedule.Name))
+}
+
+func (s *Scheduler) act(ctx context.Context, schedule *domain.Schedule, now time.Time) error {
+	action := schedule.Action
+
+	switch action.Kind {
+	case domain.ScheduleUpdateSensors:
+		var errs []error
+		for _, id := range action.SensorIDs {
+			_, err := s.sensors.UpdateSensor(ctx, id, 0, SensorUpdate{IsActive: action.IsActive, Description: action.Description})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("sensor %d: %w", id, err))
+			}
+		}
+		return errors.Join(errs...)
+
+	case domain.ScheduleActivateScene:
+		if s.scenes == nil {
+			return errors.New("scenes are not supported")
+		}
+		activation, err := s.scenes.ActivateScene(ctx, action.SceneID)
+		if err != nil {
+			return err
+		}
+		if activation.Status != domain.SceneActivationApplied {
+			return fmt.Errorf("scene activation %d is %s", activation.ID, activation.Status)
+		}
+		return nil
+
+	case domain.ScheduleSendCommand:
+		if s.commands == nil {
+			return errors.New("device commands are not supported")
+		}
+		var errs []error
+		for _, id := range action.SensorIDs {
+			_, err := s.commands.SendCommand(ctx, id, &domain.Command{Kind: action.CommandKind, State: action.State}, 0)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("sensor %d: %w", id, err))
+			}
+		}
+		return errors.Join(errs...)
+
+	case domain.ScheduleHTTP:
+		return s.post(ctx, schedule, now)
+
+	default:
+		return fmt.Errorf("unknown action %q", action.Kind)
+	}
+}
+
+// scheduleReport - тело запроса действия http
+type scheduleReport struct {
+	ScheduleID int64                  `json:"schedule_id"`
+	Name       string                 `json:"name"`
+	FiredAt    time.Time              `json:"fired_at"`
+	Sensors    []scheduleReportSensor `json:"sensors"`
+}
+
+type scheduleReportSensor struct {
+	ID           int64        `json:"id"`
+	SerialNumber string       `json:"serial_number"`
+	Type         string       `json:"type"`
+	Description  string       `json:"description"`
+	IsActive     bool         `json:"is_active"`
+	CurrentState int64        `json:"current_state"`
+	CurrentValue domain.Value `json:"current_value"`
+	Unit         string       `json:"unit,omitempty"`
+	LastActivity time.Time    `json:"last_activity"`
+}
+
+// post - отправляет сводку по датчикам действия на его URL
+func (s *Scheduler) post(ctx context.Context, schedule *domain.Schedule, now time.Time) error {
+	report := scheduleReport{
+		ScheduleID: schedule.ID,
+		Name:       schedule.Name,
+		FiredAt:    now,
+		Sensors:    make([]scheduleReportSensor, 0, len(schedule.Action.SensorIDs)),
+	}
+	for _, id := range schedule.Action.SensorIDs {
+		sensor, err := s.sensors.GetSensorByID(ctx, id)
+		if errors.Is(err, ErrSensorNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		report.Sensors = append(report.Sensors, scheduleReportSensor{
+			ID:           sensor.ID,
+			SerialNumber: sensor.SerialNumber,
+			Type:         string(sensor.Type),
+			Description:  sensor.Description,
+			IsActive:     sensor.IsActive,
+			CurrentState: sensor.CurrentState,
+			CurrentValue: sensor.State(),
+			Unit:         sensor.Unit,
+			LastActivity: sensor.LastActivity,
+		})
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, schedule.Action.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
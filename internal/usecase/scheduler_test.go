@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_scheduler_CreateSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC) // 09:00 по Москве
+	off := false
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).AnyTimes().
+		Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(3)).AnyTimes().Return(nil, ErrSensorNotFound)
+
+	newScheduler := func(repo ScheduleRepository) *Scheduler {
+		s := NewScheduler(repo, NewSensor(sr))
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("ok, next run in timezone", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockScheduleRepository(ctrl)
+		repo.EXPECT().SaveSchedule(ctx, gomock.Any()).Times(1).Return(nil)
+
+		schedule, err := newScheduler(repo).CreateSchedule(ctx, &domain.Schedule{
+			Name:     "garage",
+			Cron:     "0 8 * * MON-FRI",
+			Timezone: "Europe/Moscow",
+			Action:   domain.ScheduleAction{Kind: domain.ScheduleUpdateSensors, SensorIDs: []int64{1}, IsActive: &off},
+			Enabled:  true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC), schedule.NextRunAt)
+		assert.Equal(t, domain.MissedRunOnce, schedule.MissedRuns)
+	})
+
+	t.Run("ok, disabled", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockScheduleRepository(ctrl)
+		repo.EXPECT().SaveSchedule(ctx, gomock.Any()).Times(1).Return(nil)
+
+		schedule, err := newScheduler(repo).CreateSchedule(ctx, &domain.Schedule{
+			Name:   "report",
+			Cron:   "@hourly",
+			Action: domain.ScheduleAction{Kind: domain.ScheduleHTTP, URL: "https://example.com/hook"},
+		})
+		require.NoError(t, err)
+		assert.True(t, schedule.NextRunAt.IsZero())
+		assert.Equal(t, "UTC", schedule.Timezone)
+	})
+
+	update := domain.ScheduleAction{Kind: domain.ScheduleUpdateSensors, SensorIDs: []int64{1}, IsActive: &off}
+	tests := []struct {
+		name     string
+		schedule domain.Schedule
+	}{
+		{"err, empty name", domain.Schedule{Cron: "@daily", Action: update}},
+		{"err, cron", domain.Schedule{Name: "a", Cron: "0 25 * * *", Action: update}},
+		{"err, timezone", domain.Schedule{Name: "a", Cron: "@daily", Timezone: "Mars/Olympus", Action: update}},
+		{"err, policy", domain.Schedule{Name: "a", Cron: "@daily", MissedRuns: "twice", Action: update}},
+		{"err, unknown action", domain.Schedule{Name: "a", Cron: "@daily", Action: domain.ScheduleAction{Kind: "reboot"}}},
+		{"err, empty update", domain.Schedule{Name: "a", Cron: "@daily", Action: domain.ScheduleAction{
+			Kind: domain.ScheduleUpdateSensors, SensorIDs: []int64{1},
+		}}},
+		{"err, unknown sensor", domain.Schedule{Name: "a", Cron: "@daily", Action: domain.ScheduleAction{
+			Kind: domain.ScheduleUpdateSensors, SensorIDs: []int64{3}, IsActive: &off,
+		}}},
+		{"err, url", domain.Schedule{Name: "a", Cron: "@daily", Action: domain.ScheduleAction{
+			Kind: domain.ScheduleHTTP, URL: "ftp://example.com",
+		}}},
+		{"err, commands not supported", domain.Schedule{Name: "a", Cron: "@daily", Action: domain.ScheduleAction{
+			Kind: domain.ScheduleSendCommand, SensorIDs: []int64{1}, CommandKind: domain.CommandToggle,
+		}}},
+		{"err, scenes not supported", domain.Schedule{Name: "a", Cron: "@daily", Action: domain.ScheduleAction{
+			Kind: domain.ScheduleActivateScene, SceneID: 1,
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newScheduler(nil).CreateSchedule(context.Background(), &tt.schedule)
+			assert.ErrorIs(t, err, ErrInvalidSchedule)
+		})
+	}
+}
+
+func Test_scheduler_RunDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2024, 3, 1, 6, 0, 10, 0, time.UTC)
+
+	var reports []scheduleReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report scheduleReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reports = append(reports, report)
+	}))
+	defer server.Close()
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).AnyTimes().
+		Return(&domain.Sensor{ID: 1, SerialNumber: "1234567890", Type: domain.SensorTypeADC, CurrentState: 42, CurrentValue: domain.FloatValue(42.4)}, nil)
+
+	newSchedule := func(scheduledAt time.Time, policy domain.MissedRunPolicy) domain.Schedule {
+		return domain.Schedule{
+			ID:         5,
+			Name:       "greenhouse",
+			Cron:       "0 * * * *",
+			Timezone:   "UTC",
+			Action:     domain.ScheduleAction{Kind: domain.ScheduleHTTP, URL: server.URL, SensorIDs: []int64{1}},
+			MissedRuns: policy,
+			Enabled:    true,
+			NextRunAt:  scheduledAt,
+		}
+	}
+
+	newScheduler := func(repo ScheduleRepository) *Scheduler {
+		s := NewScheduler(repo, NewSensor(sr), WithSchedulerHTTPClient(server.Client()))
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("ok, on time", func(t *testing.T) {
+		ctx := context.Background()
+		reports = nil
+		scheduledAt := now.Truncate(time.Hour)
+
+		var run domain.ScheduleRun
+		repo := NewMockScheduleRepository(ctrl)
+		repo.EXPECT().GetDueSchedules(ctx, now).Times(1).
+			Return([]domain.Schedule{newSchedule(scheduledAt, domain.MissedRunSkip)}, nil)
+		repo.EXPECT().ClaimSchedule(gomock.Any(), int64(5), scheduledAt, scheduledAt.Add(time.Hour)).Times(1).Return(true, nil)
+		repo.EXPECT().SaveRun(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, r *domain.ScheduleRun) error {
+			run = *r
+			return nil
+		})
+
+		require.NoError(t, newScheduler(repo).RunDue(ctx))
+		assert.Equal(t, domain.ScheduleRunSucceeded, run.Status, run.Error)
+		assert.Equal(t, scheduledAt, run.ScheduledAt)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "greenhouse", reports[0].Name)
+		assert.Equal(t, []scheduleReportSensor{{ID: 1, SerialNumber: "1234567890", Type: "adc", CurrentState: 42, CurrentValue: domain.FloatValue(42.4)}}, reports[0].Sensors)
+	})
+
+	t.Run("ok, missed run skipped", func(t *testing.T) {
+		ctx := context.Background()
+		reports = nil
+		scheduledAt := now.Truncate(time.Hour).Add(-3 * time.Hour)
+
+		var run domain.ScheduleRun
+		repo := NewMockScheduleRepository(ctrl)
+		repo.EXPECT().GetDueSchedules(ctx, now).Times(1).
+			Return([]domain.Schedule{newSchedule(scheduledAt, domain.MissedRunSkip)}, nil)
+		repo.EXPECT().ClaimSchedule(gomock.Any(), int64(5), scheduledAt, now.Truncate(time.Hour).Add(time.Hour)).Times(1).Return(true, nil)
+		repo.EXPECT().SaveRun(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, r *domain.ScheduleRun) error {
+			run = *r
+			return nil
+		})
+
+		require.NoError(t, newScheduler(repo).RunDue(ctx))
+		assert.Equal(t, domain.ScheduleRunSkipped, run.Status)
+		assert.Empty(t, reports)
+	})
+
+	t.Run("ok, missed run once", func(t *testing.T) {
+		ctx := context.Background()
+		reports = nil
+		scheduledAt := now.Truncate(time.Hour).Add(-3 * time.Hour)
+
+		repo := NewMockScheduleRepository(ctrl)
+		repo.EXPECT().GetDueSchedules(ctx, now).Times(1).
+			Return([]domain.Schedule{newSchedule(scheduledAt, domain.MissedRunOnce)}, nil)
+		repo.EXPECT().ClaimSchedule(gomock.Any(), int64(5), scheduledAt, gomock.Any()).Times(1).Return(true, nil)
+		repo.EXPECT().SaveRun(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		require.NoError(t, newScheduler(repo).RunDue(ctx))
+		assert.Len(t, reports, 1)
+	})
+
+	t.Run("ok, claimed by another run", func(t *testing.T) {
+		ctx := context.Background()
+		reports = nil
+
+		repo := NewMockScheduleRepository(ctrl)
+		repo.EXPECT().GetDueSchedules(ctx, now).Times(1).
+			Return([]domain.Schedule{newSchedule(now.Truncate(time.Hour), domain.MissedRunOnce)}, nil)
+		repo.EXPECT().ClaimSchedule(gomock.Any(), int64(5), gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+
+		require.NoError(t, newScheduler(repo).RunDue(ctx))
+		assert.Empty(t, reports)
+	})
+
+	t.Run("ok, failed request", func(t *testing.T) {
+		ctx := context.Background()
+
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer failing.Close()
+
+		schedule := newSchedule(now.Truncate(time.Hour), domain.MissedRunOnce)
+		schedule.Action.URL = failing.URL
+
+		var run domain.ScheduleRun
+		repo := NewMockScheduleRepository(ctrl)
+		repo.EXPECT().GetDueSchedules(ctx, now).Times(1).Return([]domain.Schedule{schedule}, nil)
+		repo.EXPECT().ClaimSchedule(gomock.Any(), int64(5), gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+		repo.EXPECT().SaveRun(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, r *domain.ScheduleRun) error {
+			run = *r
+			return nil
+		})
+
+		require.NoError(t, newScheduler(repo).RunDue(ctx))
+		assert.Equal(t, domain.ScheduleRunFailed, run.Status)
+		assert.Contains(t, run.Error, "502")
+	})
+}
+
+func Test_scheduler_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, only while leading", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		leader := NewMockLeader(ctrl)
+		leader.EXPECT().Lead(ctx, gomock.Any()).Times(1).Return(context.Canceled)
+
+		err := NewScheduler(nil, nil, WithSchedulerLeader(leader)).Run(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -2,65 +2,371 @@ package usecase
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"homework/internal/domain"
-	"log"
+	"homework/internal/logging"
+	"homework/internal/tracing"
 	"regexp"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultOnlineWindow - датчик без событий дольше этого считается офлайн
+const defaultOnlineWindow = 5 * time.Minute
+
+// SensorListener - получатель зарегистрированных (created) и изменённых датчиков
+type SensorListener func(ctx context.Context, sensor domain.Sensor, created bool)
+
 type Sensor struct {
-	sr SensorRepository
+	sr           SensorRepository
+	types        *SensorTypes
+	onlineWindow time.Duration
+	now          func() time.Time
+
+	mu        sync.RWMutex
+	listeners []SensorListener
+}
+
+func NewSensor(sr SensorRepository, options ...func(*Sensor)) *Sensor {
+	s := &Sensor{sr: sr, types: NewSensorTypes(nil), onlineWindow: defaultOnlineWindow, now: time.Now}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithOnlineWindow - датчик считается онлайн, если его последнее событие не старше window
+func WithOnlineWindow(window time.Duration) func(*Sensor) {
+	return func(s *Sensor) {
+		s.onlineWindow = window
+	}
+}
+
+// WithSensorTypes - реестр, по которому проверяется тип регистрируемого датчика
+func WithSensorTypes(types *SensorTypes) func(*Sensor) {
+	return func(s *Sensor) {
+		s.types = types
+	}
+}
+
+// AddListener - добавляет получателя датчиков, зарегистрированных RegisterSensor и изменённых UpdateSensor.
+// Получатели вызываются синхронно и не должны надолго блокироваться.
+func (s *Sensor) AddListener(l SensorListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, l)
 }
 
-func NewSensor(sr SensorRepository) *Sensor {
-	return &Sensor{sr: sr}
+func (s *Sensor) notify(ctx context.Context, sensor domain.Sensor, created bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, l := range s.listeners {
+		l(ctx, sensor, created)
+	}
+}
+
+// maxSensorSaveAttempts - сколько раз пытаться сохранить датчик, который параллельно меняют другие запросы
+const maxSensorSaveAttempts = 3
+
+// SensorUpdate - изменяемые поля датчика, nil - поле не меняется.
+// Калибровка с пустым Kind удаляет калибровку датчика.
+type SensorUpdate struct {
+	Description *string
+	IsActive    *bool
+	Unit        *string
+	Calibration *domain.Calibration
+}
+
+// validateSensorUnits - проверяет единицу и калибровку датчика, единица приводится к каноническому имени
+func validateSensorUnits(sensor *domain.Sensor) (err error) {
+	if sensor.Unit, err = NormalizeUnit(sensor.Unit); err != nil {
+		return err
+	}
+	return ValidateCalibration(sensor.Calibration)
 }
 
 func validateSerialNumber(serialNumber string) bool {
 	return regexp.MustCompile(`^(\d\D*){10}$`).MatchString(serialNumber)
 }
 
-func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (*domain.Sensor, error) {
+// IsVirtual - тип датчика виртуальный, его события вычисляются по выражению
+func (s *Sensor) IsVirtual(ctx context.Context, sensor *domain.Sensor) (bool, error) {
+	return s.types.IsVirtual(ctx, sensor.Type)
+}
+
+func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.RegisterSensor",
+		trace.WithAttributes(attribute.String("sensor.serial_number", sensor.SerialNumber)))
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
-	if sensor.Type != domain.SensorTypeContactClosure && sensor.Type != domain.SensorTypeADC {
-		return nil, ErrWrongSensorType
+	spec, err := s.types.GetSensorType(ctx, sensor.Type)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Virtual {
+		return nil, fmt.Errorf("%w: %w, it is defined by an expression", ErrWrongSensorType, ErrVirtualSensor)
 	}
 
 	if !validateSerialNumber(sensor.SerialNumber) {
 		return nil, ErrWrongSensorSerialNumber
 	}
 
+	if err := validateSensorUnits(sensor); err != nil {
+		return nil, err
+	}
+
+	return s.register(ctx, sensor)
+}
+
+// register - сохраняет проверенный датчик, если датчика с таким серийным номером ещё нет,
+// иначе возвращает уже зарегистрированный
+func (s *Sensor) register(ctx context.Context, sensor *domain.Sensor) (*domain.Sensor, error) {
+	logger := logging.FromContext(ctx).With(logging.SerialNumber(sensor.SerialNumber))
+
 	out, err := s.sr.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
 	if !errors.Is(err, ErrSensorNotFound) {
-		log.Println(err)
 		if err != nil {
+			logger.ErrorContext(ctx, "can't get sensor by serial number", logging.Error(err))
 			return nil, err
 		}
+		logger.DebugContext(ctx, "sensor already registered", logging.SensorID(out.ID))
 		return out, nil
 	}
 
 	err = s.sr.SaveSensor(ctx, sensor)
 	if err != nil {
+		logger.ErrorContext(ctx, "can't save sensor", logging.Error(err))
 		return nil, err
 	}
 
+	logger.InfoContext(ctx, "sensor registered", logging.SensorID(sensor.ID))
+	s.notify(ctx, *sensor, true)
+
 	return sensor, nil
 }
 
-func (s *Sensor) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+func (s *Sensor) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.GetSensors")
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 	return s.sr.GetSensors(ctx)
 }
 
-func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+// ListSensors - страница датчиков, подходящих под query. cursor - значение next предыдущей страницы,
+// пустой next означает, что страница последняя. Курсор действителен только с той же сортировкой.
+func (s *Sensor) ListSensors(ctx context.Context, query domain.SensorQuery, cursor string) (_ []domain.Sensor, next string, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.ListSensors",
+		trace.WithAttributes(attribute.String("sensors.sort", string(query.Sort))))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, "", ctx.Err()
+	}
+
+	if query.Sort == "" {
+		query.Sort = domain.SensorSortID
+	}
+	switch query.Sort {
+	case domain.SensorSortID, domain.SensorSortSerialNumber, domain.SensorSortRegisteredAt, domain.SensorSortLastActivity:
+	default:
+		return nil, "", ErrWrongSensorSort
+	}
+
+	if cursor != "" {
+		if query.After, err = decodeSensorCursor(cursor, query); err != nil {
+			return nil, "", err
+		}
+	}
+	if query.Online != nil {
+		query.OnlineSince = s.now().Add(-s.onlineWindow)
+	}
+
+	limit := query.Limit
+	if limit > 0 {
+		// лишний датчик показывает, что за страницей есть ещё
+		query.Limit++
+	}
+
+	var sensors []domain.Sensor
+	if query == (domain.SensorQuery{Sort: domain.SensorSortID}) {
+		// полный список без условий берётся так же, как в GetSensors, и может прийти из кэша
+		sensors, err = s.sr.GetSensors(ctx)
+	} else {
+		sensors, err = s.sr.ListSensors(ctx, query)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if limit > 0 && len(sensors) > limit {
+		sensors = sensors[:limit]
+		next = encodeSensorCursor(sensors[limit-1], query)
+	}
+
+	return sensors, next, nil
+}
+
+// sensorCursor - позиция в списке датчиков вместе с сортировкой, для которой она получена
+type sensorCursor struct {
+	Sort         domain.SensorSort `json:"s"`
+	Desc         bool              `json:"d,omitempty"`
+	ID           int64             `json:"i"`
+	SerialNumber string            `json:"n,omitempty"`
+	Time         *time.Time        `json:"t,omitempty"`
+}
+
+func encodeSensorCursor(sensor domain.Sensor, query domain.SensorQuery) string {
+	c := sensorCursor{Sort: query.Sort, Desc: query.Desc, ID: sensor.ID}
+	switch query.Sort {
+	case domain.SensorSortSerialNumber:
+		c.SerialNumber = sensor.SerialNumber
+	case domain.SensorSortRegisteredAt:
+		c.Time = &sensor.RegisteredAt
+	case domain.SensorSortLastActivity:
+		c.Time = &sensor.LastActivity
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSensorCursor(cursor string, query domain.SensorQuery) (*domain.Sensor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c sensorCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != query.Sort || c.Desc != query.Desc {
+		return nil, fmt.Errorf("%w: cursor was issued for another sort", ErrInvalidCursor)
+	}
+
+	after := &domain.Sensor{ID: c.ID, SerialNumber: c.SerialNumber}
+	switch query.Sort {
+	case domain.SensorSortRegisteredAt, domain.SensorSortLastActivity:
+		if c.Time == nil {
+			return nil, ErrInvalidCursor
+		}
+		after.RegisteredAt, after.LastActivity = *c.Time, *c.Time
+	}
+
+	return after, nil
+}
+
+func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.GetSensorByID",
+		trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
 	return s.sr.GetSensorByID(ctx, id)
 }
+
+func (s *Sensor) GetSensorBySerialNumber(ctx context.Context, sn string) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.GetSensorBySerialNumber",
+		trace.WithAttributes(attribute.String("sensor.serial_number", sn)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return s.sr.GetSensorBySerialNumber(ctx, sn)
+}
+
+// UpdateSensor - изменяет датчик. Если version не 0, датчик изменяется, только пока его версия
+// равна version, иначе возвращается ErrSensorVersionConflict.
+func (s *Sensor) UpdateSensor(ctx context.Context, id, version int64, update SensorUpdate) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.UpdateSensor",
+		trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if update.Unit != nil {
+		unit, err := NormalizeUnit(*update.Unit)
+		if err != nil {
+			return nil, err
+		}
+		update.Unit = &unit
+	}
+	if update.Calibration != nil && update.Calibration.Kind != "" {
+		if err := ValidateCalibration(update.Calibration); err != nil {
+			return nil, err
+		}
+	}
+
+	sensor, err := s.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && sensor.Version != version {
+		return nil, ErrSensorVersionConflict
+	}
+
+	err = updateSensor(ctx, s.sr, sensor, version == 0, func(sensor *domain.Sensor) {
+		if update.Description != nil {
+			sensor.Description = *update.Description
+		}
+		if update.IsActive != nil {
+			sensor.IsActive = *update.IsActive
+		}
+		if update.Unit != nil {
+			sensor.Unit = *update.Unit
+		}
+		if update.Calibration != nil {
+			sensor.Calibration = update.Calibration
+			if update.Calibration.Kind == "" {
+				sensor.Calibration = nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "sensor updated", logging.SensorID(sensor.ID))
+	s.notify(ctx, *sensor, false)
+
+	return sensor, nil
+}
+
+// updateSensor - сохраняет датчик, изменённый fn. Если retry и датчик успели сохранить параллельно,
+// он перечитывается и fn применяется к новой версии.
+func updateSensor(ctx context.Context, sr SensorRepository, sensor *domain.Sensor, retry bool, fn func(*domain.Sensor)) error {
+	for attempt := 1; ; attempt++ {
+		fn(sensor)
+
+		err := sr.SaveSensor(ctx, sensor)
+		if !retry || !errors.Is(err, ErrSensorVersionConflict) || attempt == maxSensorSaveAttempts {
+			return err
+		}
+
+		fresh, err := sr.GetSensorByID(ctx, sensor.ID)
+		if err != nil {
+			return err
+		}
+		*sensor = *fresh
+	}
+}
@@ -41,6 +41,20 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 			SerialNumber: "123456789011", // wrong, should be 10 digits
 		})
 		assert.ErrorIs(t, err, ErrWrongSensorSerialNumber)
+
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeADC,
+			SerialNumber: "1234567890",
+			Calibration:  &domain.Calibration{Kind: domain.CalibrationLinear},
+		})
+		assert.ErrorIs(t, err, ErrInvalidCalibration)
+
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeADC,
+			SerialNumber: "1234567890",
+			Unit:         "much too long unit name",
+		})
+		assert.ErrorIs(t, err, ErrInvalidUnit)
 	})
 
 	t.Run("fail, repository return an error", func(t *testing.T) {
@@ -253,3 +267,246 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		assert.NotNil(t, sensor)
 	})
 }
+
+func Test_sensor_GetSensorBySerialNumber(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "0123456789").Times(1).Return(nil, ErrSensorNotFound)
+
+		s := NewSensor(sr)
+
+		_, err := s.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("ok, no error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "0123456789").Times(1).Return(&domain.Sensor{ID: 1}, nil)
+
+		s := NewSensor(sr)
+
+		sensor, err := s.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), sensor.ID)
+	})
+}
+
+func Test_sensor_UpdateSensor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	description := "new description"
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(nil, ErrSensorNotFound)
+
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, 0, SensorUpdate{Description: &description})
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("err, version mismatch", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Version: 3}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(0)
+
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, 2, SensorUpdate{Description: &description})
+		assert.ErrorIs(t, err, ErrSensorVersionConflict)
+	})
+
+	t.Run("err, saved concurrently with version", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Version: 2}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(ErrSensorVersionConflict)
+
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, 2, SensorUpdate{Description: &description})
+		assert.ErrorIs(t, err, ErrSensorVersionConflict)
+	})
+
+	t.Run("ok, only set fields change", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{
+			ID: 1, Version: 2, Description: "old", IsActive: true,
+		}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, s *domain.Sensor) error {
+			s.Version++
+			return nil
+		})
+
+		sensor, err := NewSensor(sr).UpdateSensor(ctx, 1, 2, SensorUpdate{Description: &description})
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.Sensor{ID: 1, Version: 3, Description: description, IsActive: true}, sensor)
+	})
+
+	t.Run("err, invalid calibration", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(0)
+
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, 0, SensorUpdate{
+			Calibration: &domain.Calibration{Kind: domain.CalibrationPolynomial},
+		})
+		assert.ErrorIs(t, err, ErrInvalidCalibration)
+	})
+
+	t.Run("ok, unit and calibration", func(t *testing.T) {
+		ctx := context.Background()
+
+		unit := "celsius"
+		calibration := &domain.Calibration{Kind: domain.CalibrationLinear, Scale: 2}
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Version: 1}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		sensor, err := NewSensor(sr).UpdateSensor(ctx, 1, 0, SensorUpdate{Unit: &unit, Calibration: calibration})
+		assert.NoError(t, err)
+		assert.Equal(t, "°C", sensor.Unit)
+		assert.Equal(t, calibration, sensor.Calibration)
+	})
+
+	t.Run("ok, calibration removed", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{
+			ID: 1, Calibration: &domain.Calibration{Kind: domain.CalibrationLinear, Scale: 2},
+		}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		sensor, err := NewSensor(sr).UpdateSensor(ctx, 1, 0, SensorUpdate{Calibration: &domain.Calibration{}})
+		assert.NoError(t, err)
+		assert.Nil(t, sensor.Calibration)
+	})
+
+	t.Run("ok, listeners notified", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		var notified []domain.Sensor
+		s := NewSensor(sr)
+		s.AddListener(func(_ context.Context, sensor domain.Sensor, created bool) {
+			assert.False(t, created)
+			notified = append(notified, sensor)
+		})
+
+		_, err := s.UpdateSensor(ctx, 1, 0, SensorUpdate{Description: &description})
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Sensor{{ID: 1, Description: description}}, notified)
+	})
+}
+
+func Test_sensor_ListSensors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	sensors := []domain.Sensor{
+		{ID: 3, SerialNumber: "3333333333", LastActivity: now.Add(-time.Minute)},
+		{ID: 1, SerialNumber: "1111111111", LastActivity: now.Add(-2 * time.Minute)},
+		{ID: 2, SerialNumber: "2222222222", LastActivity: now.Add(-3 * time.Minute)},
+	}
+
+	t.Run("err, wrong sort", func(t *testing.T) {
+		_, _, err := NewSensor(nil).ListSensors(context.Background(), domain.SensorQuery{Sort: "type"}, "")
+		assert.ErrorIs(t, err, ErrWrongSensorSort)
+	})
+
+	t.Run("err, invalid cursor", func(t *testing.T) {
+		_, _, err := NewSensor(nil).ListSensors(context.Background(), domain.SensorQuery{}, "not a cursor")
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("ok, pages", func(t *testing.T) {
+		ctx := context.Background()
+		query := domain.SensorQuery{Sort: domain.SensorSortLastActivity, Desc: true, Limit: 2}
+
+		sr := NewMockSensorRepository(ctrl)
+		gomock.InOrder(
+			sr.EXPECT().ListSensors(ctx, domain.SensorQuery{Sort: domain.SensorSortLastActivity, Desc: true, Limit: 3}).
+				Times(1).Return(sensors, nil),
+			sr.EXPECT().ListSensors(ctx, gomock.Any()).Times(1).DoAndReturn(
+				func(_ context.Context, q domain.SensorQuery) ([]domain.Sensor, error) {
+					assert.Equal(t, int64(1), q.After.ID)
+					assert.True(t, sensors[1].LastActivity.Equal(q.After.LastActivity))
+					return sensors[2:], nil
+				}),
+		)
+
+		s := NewSensor(sr)
+		page, next, err := s.ListSensors(ctx, query, "")
+		assert.NoError(t, err)
+		assert.Equal(t, sensors[:2], page)
+		assert.NotEmpty(t, next)
+
+		page, next, err = s.ListSensors(ctx, query, next)
+		assert.NoError(t, err)
+		assert.Equal(t, sensors[2:], page)
+		assert.Empty(t, next)
+	})
+
+	t.Run("err, cursor of another sort", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().ListSensors(ctx, gomock.Any()).Times(1).Return(sensors, nil)
+
+		s := NewSensor(sr)
+		_, next, err := s.ListSensors(ctx, domain.SensorQuery{Limit: 1}, "")
+		assert.NoError(t, err)
+
+		_, _, err = s.ListSensors(ctx, domain.SensorQuery{Limit: 1, Desc: true}, next)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("ok, whole list", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensors(ctx).Times(1).Return(sensors, nil)
+
+		page, next, err := NewSensor(sr).ListSensors(ctx, domain.SensorQuery{}, "")
+		assert.NoError(t, err)
+		assert.Equal(t, sensors, page)
+		assert.Empty(t, next)
+	})
+
+	t.Run("ok, online window", func(t *testing.T) {
+		ctx := context.Background()
+		online := true
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().ListSensors(ctx, domain.SensorQuery{
+			Sort:        domain.SensorSortID,
+			Online:      &online,
+			OnlineSince: now.Add(-time.Minute),
+		}).Times(1).Return(nil, nil)
+
+		s := NewSensor(sr, WithOnlineWindow(time.Minute))
+		s.now = func() time.Time { return now }
+
+		_, _, err := s.ListSensors(ctx, domain.SensorQuery{Online: &online}, "")
+		assert.NoError(t, err)
+	})
+}
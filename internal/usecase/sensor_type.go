@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"math"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sensorTypesReloadInterval - как часто реестр перечитывается из хранилища, если тип не найден.
+// Типы, добавленные другими экземплярами сервиса, видны не позже чем через этот интервал.
+const sensorTypesReloadInterval = 10 * time.Second
+
+var sensorTypeNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// SensorTypes - реестр типов датчиков. Описания кэшируются в памяти, хранилище перечитывается,
+// только когда запрошен неизвестный тип. Без хранилища в реестре есть только domain.DefaultSensorTypes.
+type SensorTypes struct {
+	str SensorTypeRepository
+	now func() time.Time
+
+	mu       sync.RWMutex
+	types    map[domain.SensorType]domain.SensorTypeSpec
+	loadedAt time.Time
+}
+
+func NewSensorTypes(str SensorTypeRepository) *SensorTypes {
+	t := &SensorTypes{str: str, now: time.Now}
+	if str == nil {
+		t.types = make(map[domain.SensorType]domain.SensorTypeSpec)
+		for _, spec := range domain.DefaultSensorTypes() {
+			t.types[spec.Name] = spec
+		}
+	}
+	return t
+}
+
+// ValidateSensorType - проверяет описание типа датчика перед сохранением
+func ValidateSensorType(spec *domain.SensorTypeSpec) error {
+	if !sensorTypeNameRe.MatchString(string(spec.Name)) {
+		return fmt.Errorf("%w: name %q must match %s", ErrInvalidSensorType, spec.Name, sensorTypeNameRe)
+	}
+	switch spec.Payload {
+	case domain.PayloadKindBinary, domain.PayloadKindLevel, domain.PayloadKindCounter:
+	default:
+		return fmt.Errorf("%w: unknown payload %q", ErrInvalidSensorType, spec.Payload)
+	}
+	for _, v := range []*float64{spec.Min, spec.Max} {
+		if v != nil && (math.IsNaN(*v) || math.IsInf(*v, 0)) {
+			return fmt.Errorf("%w: range bounds must be finite", ErrInvalidSensorType)
+		}
+	}
+	if spec.Min != nil && spec.Max != nil && *spec.Min > *spec.Max {
+		return fmt.Errorf("%w: min %v is greater than max %v", ErrInvalidSensorType, *spec.Min, *spec.Max)
+	}
+	if spec.ReportInterval < 0 {
+		return fmt.Errorf("%w: negative report interval", ErrInvalidSensorType)
+	}
+	if spec.Actuator && spec.Virtual {
+		return fmt.Errorf("%w: virtual sensors can't be actuators", ErrInvalidSensorType)
+	}
+	return nil
+}
+
+// ValidatePayload - проверяет, что значение основного канала события входит в допустимый диапазон типа
+func ValidatePayload(spec *domain.SensorTypeSpec, payload domain.Value) error {
+	v := payload.Number()
+	if (spec.Min != nil && v < *spec.Min) || (spec.Max != nil && v > *spec.Max) {
+		return fmt.Errorf("%w: %s is outside %s", ErrPayloadOutOfRange, payload, payloadRange(spec))
+	}
+	return nil
+}
+
+func payloadRange(spec *domain.SensorTypeSpec) string {
+	bound := func(v *float64, inf string) string {
+		if v == nil {
+			return inf
+		}
+		return fmt.Sprint(*v)
+	}
+	return "[" + bound(spec.Min, "-inf") + ", " + bound(spec.Max, "+inf") + "]"
+}
+
+// GetSensorTypes - все типы реестра по возрастанию имени, читаются из хранилища
+func (t *SensorTypes) GetSensorTypes(ctx context.Context) (_ []domain.SensorTypeSpec, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "SensorTypes.GetSensorTypes")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if t.str != nil {
+		if err := t.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	specs := make([]domain.SensorTypeSpec, 0, len(t.types))
+	for _, spec := range t.types {
+		specs = append(specs, spec)
+	}
+	slices.SortFunc(specs, func(a, b domain.SensorTypeSpec) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return specs, nil
+}
+
+// GetSensorType - описание типа по имени, ErrWrongSensorType, если такого типа нет
+func (t *SensorTypes) GetSensorType(ctx context.Context, name domain.SensorType) (*domain.SensorTypeSpec, error) {
+	if spec, ok := t.get(name); ok {
+		return spec, nil
+	}
+
+	if t.str == nil {
+		return nil, ErrWrongSensorType
+	}
+
+	t.mu.RLock()
+	fresh := t.types != nil && t.now().Sub(t.loadedAt) < sensorTypesReloadInterval
+	t.mu.RUnlock()
+	if fresh {
+		return nil, ErrWrongSensorType
+	}
+
+	if err := t.load(ctx); err != nil {
+		return nil, err
+	}
+
+	if spec, ok := t.get(name); ok {
+		return spec, nil
+	}
+	return nil, ErrWrongSensorType
+}
+
+// SaveDefaultSensorTypes - сохраняет в хранилище те domain.DefaultSensorTypes, которых там ещё нет.
+// Уже сохранённые описания не заменяются.
+func (t *SensorTypes) SaveDefaultSensorTypes(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "SensorTypes.SaveDefaultSensorTypes")
+	defer func() { tracing.End(span, err) }()
+
+	if t.str == nil {
+		return nil
+	}
+
+	specs, err := t.str.GetSensorTypes(ctx)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "can't load sensor types", logging.Error(err))
+		return err
+	}
+	saved := make(map[domain.SensorType]bool, len(specs))
+	for _, spec := range specs {
+		saved[spec.Name] = true
+	}
+
+	for _, spec := range domain.DefaultSensorTypes() {
+		if saved[spec.Name] {
+			continue
+		}
+		if err := t.str.SaveSensorType(ctx, &spec); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "can't save sensor type", logging.Error(err))
+			return err
+		}
+	}
+
+	return t.load(ctx)
+}
+
+// IsVirtual - датчики типа name виртуальные. Неизвестный тип не виртуальный.
+func (t *SensorTypes) IsVirtual(ctx context.Context, name domain.SensorType) (bool, error) {
+	spec, err := t.GetSensorType(ctx, name)
+	if errors.Is(err, ErrWrongSensorType) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return spec.Virtual, nil
+}
+
+// VirtualSensorType - тип, с которым регистрируются виртуальные датчики: первый по имени
+// виртуальный тип реестра, ErrWrongSensorType, если такого нет
+func (t *SensorTypes) VirtualSensorType(ctx context.Context) (domain.SensorType, error) {
+	specs, err := t.GetSensorTypes(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, spec := range specs {
+		if spec.Virtual {
+			return spec.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no virtual sensor type in the registry", ErrWrongSensorType)
+}
+
+// SaveSensorType - добавляет тип в реестр или заменяет описание существующего
+func (t *SensorTypes) SaveSensorType(ctx context.Context, spec *domain.SensorTypeSpec) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "SensorTypes.SaveSensorType",
+		trace.WithAttributes(attribute.String("sensor.type", string(spec.Name))))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := ValidateSensorType(spec); err != nil {
+		return err
+	}
+
+	if t.str != nil {
+		if err := t.str.SaveSensorType(ctx, spec); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "can't save sensor type", logging.Error(err))
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// если реестр ещё не загружен, тип попадёт в него при первой загрузке
+	if t.types != nil {
+		t.types[spec.Name] = *spec
+	}
+
+	return nil
+}
+
+func (t *SensorTypes) get(name domain.SensorType) (*domain.SensorTypeSpec, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	spec, ok := t.types[name]
+	if !ok {
+		return nil, false
+	}
+	return &spec, true
+}
+
+func (t *SensorTypes) load(ctx context.Context) error {
+	specs, err := t.str.GetSensorTypes(ctx)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "can't load sensor types", logging.Error(err))
+		return err
+	}
+
+	types := make(map[domain.SensorType]domain.SensorTypeSpec, len(specs))
+	for _, spec := range specs {
+		types[spec.Name] = spec
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.types = types
+	t.loadedAt = t.now()
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSensorType(t *testing.T) {
+	low, high, nan := 10.0, 1.0, math.NaN()
+
+	for name, spec := range map[string]domain.SensorTypeSpec{
+		"empty name":        {Payload: domain.PayloadKindLevel},
+		"wrong name":        {Name: "Temp-1", Payload: domain.PayloadKindLevel},
+		"unknown payload":   {Name: "temp", Payload: "float"},
+		"min above max":     {Name: "temp", Payload: domain.PayloadKindLevel, Min: &low, Max: &high},
+		"nan bound":         {Name: "temp", Payload: domain.PayloadKindLevel, Min: &nan},
+		"negative interval": {Name: "temp", Payload: domain.PayloadKindLevel, ReportInterval: -time.Second},
+		"virtual actuator":  {Name: "temp", Payload: domain.PayloadKindLevel, Actuator: true, Virtual: true},
+	} {
+		assert.ErrorIs(t, ValidateSensorType(&spec), ErrInvalidSensorType, name)
+	}
+
+	for _, spec := range domain.DefaultSensorTypes() {
+		assert.NoError(t, ValidateSensorType(&spec), spec.Name)
+	}
+}
+
+func TestValidatePayload(t *testing.T) {
+	low, high := 0.0, 1.0
+	spec := &domain.SensorTypeSpec{Name: "switch", Payload: domain.PayloadKindBinary, Min: &low, Max: &high}
+
+	assert.NoError(t, ValidatePayload(spec, domain.IntValue(0)))
+	assert.NoError(t, ValidatePayload(spec, domain.IntValue(1)))
+	assert.ErrorIs(t, ValidatePayload(spec, domain.IntValue(2)), ErrPayloadOutOfRange)
+	assert.ErrorIs(t, ValidatePayload(spec, domain.IntValue(-1)), ErrPayloadOutOfRange)
+
+	spec.Min = nil
+	assert.NoError(t, ValidatePayload(spec, domain.IntValue(math.MinInt64)))
+}
+
+func Test_sensorTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, defaults without repository", func(t *testing.T) {
+		types := NewSensorTypes(nil)
+
+		spec, err := types.GetSensorType(context.Background(), domain.SensorTypeADC)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PayloadKindLevel, spec.Payload)
+
+		_, err = types.GetSensorType(context.Background(), "temperature")
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+
+		require.NoError(t, types.SaveSensorType(context.Background(), &domain.SensorTypeSpec{
+			Name: "temperature", Payload: domain.PayloadKindLevel,
+		}))
+		specs, err := types.GetSensorTypes(context.Background())
+		require.NoError(t, err)
+		require.Len(t, specs, 5)
+		assert.Equal(t, domain.SensorTypeADC, specs[0].Name)
+		assert.Equal(t, domain.SensorType("relay"), specs[2].Name)
+		assert.True(t, specs[2].Actuator)
+		assert.Equal(t, domain.SensorType("temperature"), specs[3].Name)
+	})
+
+	t.Run("ok, reload on miss", func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Now()
+
+		str := NewMockSensorTypeRepository(ctrl)
+		gomock.InOrder(
+			str.EXPECT().GetSensorTypes(ctx).Times(1).Return(domain.DefaultSensorTypes(), nil),
+			str.EXPECT().GetSensorTypes(ctx).Times(1).Return(append(domain.DefaultSensorTypes(),
+				domain.SensorTypeSpec{Name: "temperature", Payload: domain.PayloadKindLevel}), nil),
+		)
+
+		types := NewSensorTypes(str)
+		types.now = func() time.Time { return now }
+
+		_, err := types.GetSensorType(ctx, domain.SensorTypeContactClosure)
+		require.NoError(t, err)
+
+		// реестр только что загружен, повторно хранилище не читается
+		_, err = types.GetSensorType(ctx, "temperature")
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+
+		now = now.Add(sensorTypesReloadInterval)
+		spec, err := types.GetSensorType(ctx, "temperature")
+		require.NoError(t, err)
+		assert.Equal(t, domain.SensorType("temperature"), spec.Name)
+	})
+
+	t.Run("ok, missing default types are saved", func(t *testing.T) {
+		ctx := context.Background()
+		relay := domain.SensorTypeSpec{Name: "relay", Description: "Силовое реле", Payload: domain.PayloadKindBinary, Actuator: true}
+		saved := []domain.SensorTypeSpec{domain.DefaultSensorTypes()[0], domain.DefaultSensorTypes()[1], relay}
+
+		str := NewMockSensorTypeRepository(ctrl)
+		virtual := domain.DefaultSensorTypes()[3]
+		gomock.InOrder(
+			str.EXPECT().GetSensorTypes(ctx).Times(1).Return(saved, nil),
+			// изменённое описание relay не перезаписывается
+			str.EXPECT().SaveSensorType(ctx, &virtual).Times(1).Return(nil),
+			str.EXPECT().GetSensorTypes(ctx).Times(1).Return(append(saved, virtual), nil),
+		)
+
+		types := NewSensorTypes(str)
+		require.NoError(t, types.SaveDefaultSensorTypes(ctx))
+
+		spec, err := types.GetSensorType(ctx, "relay")
+		require.NoError(t, err)
+		assert.Equal(t, "Силовое реле", spec.Description)
+	})
+
+	t.Run("ok, virtual types by capability", func(t *testing.T) {
+		ctx := context.Background()
+		str := NewMockSensorTypeRepository(ctrl)
+		str.EXPECT().GetSensorTypes(ctx).AnyTimes().Return([]domain.SensorTypeSpec{
+			{Name: domain.SensorTypeADC, Payload: domain.PayloadKindLevel},
+			{Name: "formula", Payload: domain.PayloadKindLevel, Virtual: true},
+		}, nil)
+
+		types := NewSensorTypes(str)
+
+		name, err := types.VirtualSensorType(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SensorType("formula"), name)
+
+		virtual, err := types.IsVirtual(ctx, "formula")
+		require.NoError(t, err)
+		assert.True(t, virtual)
+
+		for _, name := range []domain.SensorType{domain.SensorTypeADC, "virtual"} {
+			virtual, err = types.IsVirtual(ctx, name)
+			require.NoError(t, err)
+			assert.False(t, virtual, name)
+		}
+	})
+
+	t.Run("err, no virtual type", func(t *testing.T) {
+		str := NewMockSensorTypeRepository(ctrl)
+		str.EXPECT().GetSensorTypes(gomock.Any()).Times(1).Return(domain.DefaultSensorTypes()[:2], nil)
+
+		_, err := NewSensorTypes(str).VirtualSensorType(context.Background())
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
+	t.Run("err, invalid type is not saved", func(t *testing.T) {
+		str := NewMockSensorTypeRepository(ctrl)
+		str.EXPECT().SaveSensorType(gomock.Any(), gomock.Any()).Times(0)
+
+		err := NewSensorTypes(str).SaveSensorType(context.Background(), &domain.SensorTypeSpec{Name: "x"})
+		assert.ErrorIs(t, err, ErrInvalidSensorType)
+	})
+
+	t.Run("err, repository error", func(t *testing.T) {
+		expectedError := errors.New("some error")
+		str := NewMockSensorTypeRepository(ctrl)
+		str.EXPECT().GetSensorTypes(gomock.Any()).Times(1).Return(nil, expectedError)
+
+		_, err := NewSensorTypes(str).GetSensorType(context.Background(), domain.SensorTypeADC)
+		assert.ErrorIs(t, err, expectedError)
+	})
+}
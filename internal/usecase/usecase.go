@@ -5,36 +5,248 @@ import (
 	"errors"
 	"homework/internal/domain"
 	"time"
+
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("homework/internal/usecase")
+
 var (
 	ErrWrongSensorSerialNumber = errors.New("wrong sensor serial number")
 	ErrWrongSensorType         = errors.New("wrong sensor type")
 	ErrInvalidEventTimestamp   = errors.New("invalid event timestamp")
 	ErrInvalidUserName         = errors.New("invalid user name")
 	ErrSensorNotFound          = errors.New("sensor not found")
+	ErrSensorVersionConflict   = errors.New("sensor version conflict")
 	ErrUserNotFound            = errors.New("user not found")
 	ErrEventNotFound           = errors.New("event not found")
+	ErrImportNotFound          = errors.New("import not found")
+	ErrImportKindMismatch      = errors.New("import kind mismatch")
+	ErrInvalidCursor           = errors.New("invalid cursor")
+	ErrWrongSensorSort         = errors.New("wrong sensor sort")
+	ErrInvalidSensorType       = errors.New("invalid sensor type")
+	ErrPayloadOutOfRange       = errors.New("payload out of range")
+	ErrInvalidAggregation      = errors.New("invalid aggregation")
+	ErrInvalidEventChannels    = errors.New("invalid event channels")
+	ErrInvalidCalibration      = errors.New("invalid calibration")
+	ErrInvalidUnit             = errors.New("invalid unit")
+	ErrCommandNotFound         = errors.New("command not found")
+	ErrInvalidCommand          = errors.New("invalid command")
+	ErrNotActuator             = errors.New("sensor is not an actuator")
+	ErrSceneNotFound           = errors.New("scene not found")
+	ErrSceneExists             = errors.New("scene already exists")
+	ErrInvalidScene            = errors.New("invalid scene")
+	ErrScheduleNotFound        = errors.New("schedule not found")
+	ErrScheduleExists          = errors.New("schedule already exists")
+	ErrInvalidSchedule         = errors.New("invalid schedule")
+	ErrAlertRuleNotFound       = errors.New("alert rule not found")
+	ErrInvalidAlertRule        = errors.New("invalid alert rule")
+	ErrAlertNotFound           = errors.New("alert not found")
+	ErrInvalidAlertQuery       = errors.New("invalid alert query")
+	ErrAlertStatusConflict     = errors.New("alert status conflict")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrInvalidWebhook          = errors.New("invalid webhook")
+	ErrDeliveryNotFound        = errors.New("webhook delivery not found")
+	ErrInvalidDeliveryQuery    = errors.New("invalid webhook delivery query")
+	ErrDeliveryStatusConflict  = errors.New("webhook delivery status conflict")
+	ErrWebhookTargetDenied     = errors.New("webhook target address is not allowed")
+	ErrSensorExists            = errors.New("sensor already exists")
+	ErrVirtualSensor           = errors.New("sensor is virtual")
+	ErrVirtualSensorNotFound   = errors.New("virtual sensor not found")
+	ErrInvalidVirtualSensor    = errors.New("invalid virtual sensor")
 )
 
 //go:generate mockgen -source usecase.go -package usecase -destination usecase_mock.go
 type SensorRepository interface {
-	// SaveSensor - функция сохранения датчика
+	// SaveSensor - функция сохранения датчика. Сохранённый датчик обновляется, только если его версия
+	// совпадает с sensor.Version, иначе возвращается ErrSensorVersionConflict. После сохранения
+	// sensor.Version содержит новую версию.
 	SaveSensor(ctx context.Context, sensor *domain.Sensor) error
-	// GetSensors - функция получения списка датчиков
+	// GetSensors - функция получения списка датчиков по возрастанию ID
 	GetSensors(ctx context.Context) ([]domain.Sensor, error)
+	// ListSensors - функция получения страницы датчиков, подходящих под query
+	ListSensors(ctx context.Context, query domain.SensorQuery) ([]domain.Sensor, error)
 	// GetSensorByID - функция получения датчика по ID
 	GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error)
 	// GetSensorBySerialNumber - функция получения датчика по серийному номеру
 	GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error)
 }
 
+type SensorTypeRepository interface {
+	// GetSensorTypes - функция получения всех типов датчиков из реестра
+	GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error)
+	// SaveSensorType - функция сохранения типа датчика, описание с тем же именем заменяется
+	SaveSensorType(ctx context.Context, spec *domain.SensorTypeSpec) error
+}
+
 type EventRepository interface {
 	// SaveEvent - функция сохранения события по датчику
 	SaveEvent(ctx context.Context, event *domain.Event) error
 	// GetLastEventBySensorID - функция получения последнего события по ID датчика
 	GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error)
 	GetEventsByTimeFrame(ctx context.Context, id int64, start, finish time.Time) ([]domain.Event, error)
+	// IterateEvents - функция обхода событий датчиков ids за период, упорядоченных по датчику и времени.
+	// События передаются в fn по одному, не загружаясь в память целиком; ошибка fn прерывает обход.
+	IterateEvents(ctx context.Context, ids []int64, start, finish time.Time, fn func(domain.Event) error) error
+	// AggregateEvents - функция получения сводки значений канала событий датчика за период, разбитый
+	// на интервалы interval от start; при interval = 0 период не разбивается. Пустые интервалы не возвращаются.
+	AggregateEvents(ctx context.Context, id int64, channel string, start, finish time.Time, interval time.Duration) ([]domain.Aggregate, error)
+	// DeleteEventsBefore - функция удаления событий, произошедших раньше before, возвращает число удалённых событий
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type CommandRepository interface {
+	// SaveCommand - функция сохранения команды, команда с нулевым ID добавляется в очередь и получает ID
+	SaveCommand(ctx context.Context, command *domain.Command) error
+	// GetCommandByID - функция получения команды по ID
+	GetCommandByID(ctx context.Context, id int64) (*domain.Command, error)
+	// GetCommands - функция получения команд устройства по возрастанию ID, пустой statuses - команды в любом состоянии
+	GetCommands(ctx context.Context, sensorID int64, statuses []domain.CommandStatus) ([]domain.Command, error)
+	// DeliverCommands - функция, которая помечает доставленными ожидающие команды устройства, не истёкшие к now,
+	// и возвращает их по возрастанию ID. Одну команду не может забрать несколько получателей.
+	DeliverCommands(ctx context.Context, sensorID int64, now time.Time) ([]domain.Command, error)
+	// DeliverCommand - функция, которая помечает доставленной ожидающую команду id устройства, не истёкшую к now,
+	// false, если такой команды нет
+	DeliverCommand(ctx context.Context, sensorID, id int64, now time.Time) (bool, error)
+	// ConfirmCommands - функция, которая помечает подтверждёнными активные команды устройства с состоянием state,
+	// не истёкшие к now, и возвращает их
+	ConfirmCommands(ctx context.Context, sensorID int64, state int64, now time.Time) ([]domain.Command, error)
+	// ExpireCommands - функция, которая помечает истёкшими активные команды с ExpiresAt не позже now,
+	// возвращает число таких команд
+	ExpireCommands(ctx context.Context, now time.Time) (int64, error)
+}
+
+type SceneRepository interface {
+	// SaveScene - функция сохранения сцены, сцена с нулевым ID добавляется и получает ID.
+	// Если сцена с таким именем уже есть, возвращается ErrSceneExists.
+	SaveScene(ctx context.Context, scene *domain.Scene) error
+	// GetSceneByID - функция получения сцены по ID
+	GetSceneByID(ctx context.Context, id int64) (*domain.Scene, error)
+	// GetScenes - функция получения всех сцен по возрастанию ID
+	GetScenes(ctx context.Context) ([]domain.Scene, error)
+	// DeleteScene - функция удаления сцены вместе с историей её применения
+	DeleteScene(ctx context.Context, id int64) error
+	// SaveActivation - функция добавления записи в историю применения сцены, запись получает ID
+	SaveActivation(ctx context.Context, activation *domain.SceneActivation) error
+	// GetActivations - функция получения последних limit записей истории сцены, от новых к старым
+	GetActivations(ctx context.Context, sceneID int64, limit int) ([]domain.SceneActivation, error)
+}
+
+type ScheduleRepository interface {
+	// SaveSchedule - функция сохранения расписания, расписание с нулевым ID добавляется и получает ID.
+	// Если расписание с таким именем уже есть, возвращается ErrScheduleExists.
+	SaveSchedule(ctx context.Context, schedule *domain.Schedule) error
+	// GetScheduleByID - функция получения расписания по ID
+	GetScheduleByID(ctx context.Context, id int64) (*domain.Schedule, error)
+	// GetSchedules - функция получения всех расписаний по возрастанию ID
+	GetSchedules(ctx context.Context) ([]domain.Schedule, error)
+	// DeleteSchedule - функция удаления расписания вместе с историей запусков
+	DeleteSchedule(ctx context.Context, id int64) error
+	// GetDueSchedules - функция получения включённых расписаний с NextRunAt не позже now по возрастанию NextRunAt
+	GetDueSchedules(ctx context.Context, now time.Time) ([]domain.Schedule, error)
+	// ClaimSchedule - функция, которая переносит следующий запуск расписания с scheduledAt на next.
+	// Возвращает false, если запуск уже перенесён или расписание с тех пор изменили.
+	ClaimSchedule(ctx context.Context, id int64, scheduledAt, next time.Time) (bool, error)
+	// SaveRun - функция добавления записи в историю запусков расписания, запись получает ID
+	SaveRun(ctx context.Context, run *domain.ScheduleRun) error
+	// GetRuns - функция получения последних limit записей истории расписания, от новых к старым
+	GetRuns(ctx context.Context, scheduleID int64, limit int) ([]domain.ScheduleRun, error)
+}
+
+type AlertRepository interface {
+	// SaveAlertRule - функция сохранения правила тревоги, правило с нулевым ID добавляется и получает ID
+	SaveAlertRule(ctx context.Context, rule *domain.AlertRule) error
+	// GetAlertRuleByID - функция получения правила тревоги по ID
+	GetAlertRuleByID(ctx context.Context, id int64) (*domain.AlertRule, error)
+	// GetAlertRules - функция получения правил датчика по возрастанию ID, sensorID = 0 - правил всех датчиков
+	GetAlertRules(ctx context.Context, sensorID int64) ([]domain.AlertRule, error)
+	// DeleteAlertRule - функция удаления правила, поднятые им тревоги остаются с RuleID = 0
+	DeleteAlertRule(ctx context.Context, id int64) error
+	// RaiseAlert - функция регистрации срабатывания правила. Если у правила есть незакрытая тревога,
+	// у неё увеличивается Count и обновляются LastSeenAt, Message и Value, иначе alert добавляется
+	// как новая тревога. После вызова alert содержит сохранённую тревогу.
+	RaiseAlert(ctx context.Context, alert *domain.Alert) error
+	// GetAlertByID - функция получения тревоги по ID
+	GetAlertByID(ctx context.Context, id int64) (*domain.Alert, error)
+	// GetLastAlertByRuleID - функция получения последней тревоги правила в любом состоянии
+	GetLastAlertByRuleID(ctx context.Context, ruleID int64) (*domain.Alert, error)
+	// GetAlerts - функция получения тревог, подходящих под query, от новых к старым
+	GetAlerts(ctx context.Context, query domain.AlertQuery) ([]domain.Alert, error)
+	// GetAlertChanges - функция получения не больше limit тревог датчиков sensorIDs, изменённых
+	// после ревизии after, по возрастанию Revision
+	GetAlertChanges(ctx context.Context, sensorIDs []int64, after int64, limit int) ([]domain.Alert, error)
+	// GetAlertRevision - функция получения номера последнего изменения тревог, 0 - тревог ещё не было
+	GetAlertRevision(ctx context.Context) (int64, error)
+	// UpdateAlertStatus - функция перевода тревоги в состояние to пользователем userID в момент at.
+	// Если тревога не в одном из состояний from, возвращается ErrAlertStatusConflict.
+	UpdateAlertStatus(ctx context.Context, id int64, from []domain.AlertStatus, to domain.AlertStatus, userID int64, at time.Time) (*domain.Alert, error)
+}
+
+type WebhookRepository interface {
+	// SaveWebhook - функция сохранения подписки, подписка с нулевым ID добавляется и получает ID
+	SaveWebhook(ctx context.Context, webhook *domain.Webhook) error
+	// GetWebhookByID - функция получения подписки по ID
+	GetWebhookByID(ctx context.Context, id int64) (*domain.Webhook, error)
+	// GetWebhooks - функция получения всех подписок по возрастанию ID
+	GetWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	// DeleteWebhook - функция удаления подписки вместе с её доставками
+	DeleteWebhook(ctx context.Context, id int64) error
+	// SaveDelivery - функция сохранения доставки, доставка с нулевым ID ставится в очередь и получает ID
+	SaveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	// GetDeliveryByID - функция получения доставки по ID
+	GetDeliveryByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error)
+	// GetDeliveries - функция получения последних limit доставок подписки в состояниях statuses,
+	// от новых к старым. Пустой statuses - доставки в любом состоянии.
+	GetDeliveries(ctx context.Context, webhookID int64, statuses []domain.WebhookDeliveryStatus, limit int) ([]domain.WebhookDelivery, error)
+	// ClaimDeliveries - функция, которая забирает не больше limit ожидающих доставок включённых подписок
+	// с NextAttemptAt не позже now, по возрастанию NextAttemptAt. Забранным доставкам NextAttemptAt
+	// переносится на now + lease, поэтому одну доставку не может одновременно забрать несколько реплик.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error)
+}
+
+type VirtualSensorRepository interface {
+	// SaveVirtualSensor - функция сохранения определения виртуального датчика, определение того же датчика заменяется
+	SaveVirtualSensor(ctx context.Context, vs *domain.VirtualSensor) error
+	// GetVirtualSensor - функция получения определения по ID датчика
+	GetVirtualSensor(ctx context.Context, sensorID int64) (*domain.VirtualSensor, error)
+	// GetVirtualSensors - функция получения всех определений по возрастанию ID датчика
+	GetVirtualSensors(ctx context.Context) ([]domain.VirtualSensor, error)
+	// GetVirtualSensorsByInput - функция получения определений, выражения которых ссылаются на датчик inputID
+	GetVirtualSensorsByInput(ctx context.Context, inputID int64) ([]domain.VirtualSensor, error)
+	// DeleteVirtualSensor - функция удаления определения, сам датчик и его события остаются
+	DeleteVirtualSensor(ctx context.Context, sensorID int64) error
+}
+
+// UnitOfWork - единица работы: изменения, сделанные через репозитории внутри Do, сохраняются вместе
+type UnitOfWork interface {
+	// Do - функция, которая выполняет fn в одной транзакции. Репозитории, получившие контекст fn,
+	// работают внутри неё; изменения фиксируются, только если fn вернула nil. Вложенный Do
+	// выполняется во внешней единице работы.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type OutboxRepository interface {
+	// AddOutboxMessage - функция записи сообщения в outbox, сообщение получает ID
+	AddOutboxMessage(ctx context.Context, message *domain.OutboxMessage) error
+	// GetOutboxMessages - функция получения не больше limit самых старых сообщений по возрастанию ID.
+	// Вызывается внутри единицы работы: пока она не завершена, другие единицы работы получают пустой список.
+	GetOutboxMessages(ctx context.Context, limit int) ([]domain.OutboxMessage, error)
+	// DeleteOutboxMessages - функция удаления опубликованных сообщений
+	DeleteOutboxMessages(ctx context.Context, ids []int64) error
+}
+
+// Publisher - внешняя система, в которую outbox публикует сообщения
+type Publisher interface {
+	// Publish - функция публикации сообщения. Сообщение может быть опубликовано повторно,
+	// если сервис остановился до того, как публикация была записана.
+	Publish(ctx context.Context, message domain.OutboxMessage) error
+}
+
+// Leader - выбор ведущей реплики, когда сервис запущен в нескольких экземплярах
+type Leader interface {
+	// Lead - ждёт, пока реплика станет ведущей, и вызывает fn с контекстом, который отменяется
+	// при потере лидерства, после чего снова ждёт. Возвращается после отмены ctx.
+	Lead(ctx context.Context, fn func(ctx context.Context)) error
 }
 
 type UserRepository interface {
@@ -50,3 +262,15 @@ type SensorOwnerRepository interface {
 	// GetSensorsByUserID -функция, возвращающая список привязок для пользователя
 	GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error)
 }
+
+type ImportRepository interface {
+	// GetImport - функция получения состояния загрузки по ID
+	GetImport(ctx context.Context, id string) (*domain.Import, error)
+	// SaveImport - функция сохранения состояния загрузки
+	SaveImport(ctx context.Context, imp *domain.Import) error
+	// CommitSensors - функция сохранения пачки датчиков вместе с состоянием загрузки в одной транзакции.
+	// Датчики с уже зарегистрированными серийными номерами пропускаются.
+	CommitSensors(ctx context.Context, imp *domain.Import, sensors []domain.Sensor) error
+	// CommitEvents - функция сохранения пачки событий вместе с состоянием загрузки в одной транзакции
+	CommitEvents(ctx context.Context, imp *domain.Import, events []domain.Event) error
+}
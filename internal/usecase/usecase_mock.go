@@ -81,85 +81,1171 @@ func (mr *MockSensorRepositoryMockRecorder) GetSensors(ctx interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSensors", reflect.TypeOf((*MockSensorRepository)(nil).GetSensors), ctx)
 }
 
+// ListSensors mocks base method.
+func (m *MockSensorRepository) ListSensors(ctx context.Context, query domain.SensorQuery) ([]domain.Sensor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSensors", ctx, query)
+	ret0, _ := ret[0].([]domain.Sensor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSensors indicates an expected call of ListSensors.
+func (mr *MockSensorRepositoryMockRecorder) ListSensors(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSensors", reflect.TypeOf((*MockSensorRepository)(nil).ListSensors), ctx, query)
+}
+
 // SaveSensor mocks base method.
 func (m *MockSensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveSensor", ctx, sensor)
+	ret := m.ctrl.Call(m, "SaveSensor", ctx, sensor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSensor indicates an expected call of SaveSensor.
+func (mr *MockSensorRepositoryMockRecorder) SaveSensor(ctx, sensor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensor", reflect.TypeOf((*MockSensorRepository)(nil).SaveSensor), ctx, sensor)
+}
+
+// MockSensorTypeRepository is a mock of SensorTypeRepository interface.
+type MockSensorTypeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSensorTypeRepositoryMockRecorder
+}
+
+// MockSensorTypeRepositoryMockRecorder is the mock recorder for MockSensorTypeRepository.
+type MockSensorTypeRepositoryMockRecorder struct {
+	mock *MockSensorTypeRepository
+}
+
+// NewMockSensorTypeRepository creates a new mock instance.
+func NewMockSensorTypeRepository(ctrl *gomock.Controller) *MockSensorTypeRepository {
+	mock := &MockSensorTypeRepository{ctrl: ctrl}
+	mock.recorder = &MockSensorTypeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSensorTypeRepository) EXPECT() *MockSensorTypeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetSensorTypes mocks base method.
+func (m *MockSensorTypeRepository) GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSensorTypes", ctx)
+	ret0, _ := ret[0].([]domain.SensorTypeSpec)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSensorTypes indicates an expected call of GetSensorTypes.
+func (mr *MockSensorTypeRepositoryMockRecorder) GetSensorTypes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSensorTypes", reflect.TypeOf((*MockSensorTypeRepository)(nil).GetSensorTypes), ctx)
+}
+
+// SaveSensorType mocks base method.
+func (m *MockSensorTypeRepository) SaveSensorType(ctx context.Context, spec *domain.SensorTypeSpec) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSensorType", ctx, spec)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSensorType indicates an expected call of SaveSensorType.
+func (mr *MockSensorTypeRepositoryMockRecorder) SaveSensorType(ctx, spec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensorType", reflect.TypeOf((*MockSensorTypeRepository)(nil).SaveSensorType), ctx, spec)
+}
+
+// MockEventRepository is a mock of EventRepository interface.
+type MockEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRepositoryMockRecorder
+}
+
+// MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
+type MockEventRepositoryMockRecorder struct {
+	mock *MockEventRepository
+}
+
+// NewMockEventRepository creates a new mock instance.
+func NewMockEventRepository(ctrl *gomock.Controller) *MockEventRepository {
+	mock := &MockEventRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AggregateEvents mocks base method.
+func (m *MockEventRepository) AggregateEvents(ctx context.Context, id int64, channel string, start, finish time.Time, interval time.Duration) ([]domain.Aggregate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateEvents", ctx, id, channel, start, finish, interval)
+	ret0, _ := ret[0].([]domain.Aggregate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateEvents indicates an expected call of AggregateEvents.
+func (mr *MockEventRepositoryMockRecorder) AggregateEvents(ctx, id, channel, start, finish, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateEvents", reflect.TypeOf((*MockEventRepository)(nil).AggregateEvents), ctx, id, channel, start, finish, interval)
+}
+
+// DeleteEventsBefore mocks base method.
+func (m *MockEventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEventsBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEventsBefore indicates an expected call of DeleteEventsBefore.
+func (mr *MockEventRepositoryMockRecorder) DeleteEventsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventsBefore", reflect.TypeOf((*MockEventRepository)(nil).DeleteEventsBefore), ctx, before)
+}
+
+// GetEventsByTimeFrame mocks base method.
+func (m *MockEventRepository) GetEventsByTimeFrame(ctx context.Context, id int64, start, finish time.Time) ([]domain.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEventsByTimeFrame", ctx, id, start, finish)
+	ret0, _ := ret[0].([]domain.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventsByTimeFrame indicates an expected call of GetEventsByTimeFrame.
+func (mr *MockEventRepositoryMockRecorder) GetEventsByTimeFrame(ctx, id, start, finish interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsByTimeFrame", reflect.TypeOf((*MockEventRepository)(nil).GetEventsByTimeFrame), ctx, id, start, finish)
+}
+
+// GetLastEventBySensorID mocks base method.
+func (m *MockEventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastEventBySensorID", ctx, id)
+	ret0, _ := ret[0].(*domain.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastEventBySensorID indicates an expected call of GetLastEventBySensorID.
+func (mr *MockEventRepositoryMockRecorder) GetLastEventBySensorID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEventBySensorID", reflect.TypeOf((*MockEventRepository)(nil).GetLastEventBySensorID), ctx, id)
+}
+
+// IterateEvents mocks base method.
+func (m *MockEventRepository) IterateEvents(ctx context.Context, ids []int64, start, finish time.Time, fn func(domain.Event) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateEvents", ctx, ids, start, finish, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateEvents indicates an expected call of IterateEvents.
+func (mr *MockEventRepositoryMockRecorder) IterateEvents(ctx, ids, start, finish, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateEvents", reflect.TypeOf((*MockEventRepository)(nil).IterateEvents), ctx, ids, start, finish, fn)
+}
+
+// SaveEvent mocks base method.
+func (m *MockEventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEvent indicates an expected call of SaveEvent.
+func (mr *MockEventRepositoryMockRecorder) SaveEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvent", reflect.TypeOf((*MockEventRepository)(nil).SaveEvent), ctx, event)
+}
+
+// MockCommandRepository is a mock of CommandRepository interface.
+type MockCommandRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandRepositoryMockRecorder
+}
+
+// MockCommandRepositoryMockRecorder is the mock recorder for MockCommandRepository.
+type MockCommandRepositoryMockRecorder struct {
+	mock *MockCommandRepository
+}
+
+// NewMockCommandRepository creates a new mock instance.
+func NewMockCommandRepository(ctrl *gomock.Controller) *MockCommandRepository {
+	mock := &MockCommandRepository{ctrl: ctrl}
+	mock.recorder = &MockCommandRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandRepository) EXPECT() *MockCommandRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConfirmCommands mocks base method.
+func (m *MockCommandRepository) ConfirmCommands(ctx context.Context, sensorID, state int64, now time.Time) ([]domain.Command, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmCommands", ctx, sensorID, state, now)
+	ret0, _ := ret[0].([]domain.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmCommands indicates an expected call of ConfirmCommands.
+func (mr *MockCommandRepositoryMockRecorder) ConfirmCommands(ctx, sensorID, state, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmCommands", reflect.TypeOf((*MockCommandRepository)(nil).ConfirmCommands), ctx, sensorID, state, now)
+}
+
+// DeliverCommand mocks base method.
+func (m *MockCommandRepository) DeliverCommand(ctx context.Context, sensorID, id int64, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeliverCommand", ctx, sensorID, id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeliverCommand indicates an expected call of DeliverCommand.
+func (mr *MockCommandRepositoryMockRecorder) DeliverCommand(ctx, sensorID, id, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliverCommand", reflect.TypeOf((*MockCommandRepository)(nil).DeliverCommand), ctx, sensorID, id, now)
+}
+
+// DeliverCommands mocks base method.
+func (m *MockCommandRepository) DeliverCommands(ctx context.Context, sensorID int64, now time.Time) ([]domain.Command, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeliverCommands", ctx, sensorID, now)
+	ret0, _ := ret[0].([]domain.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeliverCommands indicates an expected call of DeliverCommands.
+func (mr *MockCommandRepositoryMockRecorder) DeliverCommands(ctx, sensorID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliverCommands", reflect.TypeOf((*MockCommandRepository)(nil).DeliverCommands), ctx, sensorID, now)
+}
+
+// ExpireCommands mocks base method.
+func (m *MockCommandRepository) ExpireCommands(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireCommands", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireCommands indicates an expected call of ExpireCommands.
+func (mr *MockCommandRepositoryMockRecorder) ExpireCommands(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireCommands", reflect.TypeOf((*MockCommandRepository)(nil).ExpireCommands), ctx, now)
+}
+
+// GetCommandByID mocks base method.
+func (m *MockCommandRepository) GetCommandByID(ctx context.Context, id int64) (*domain.Command, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommandByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommandByID indicates an expected call of GetCommandByID.
+func (mr *MockCommandRepositoryMockRecorder) GetCommandByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommandByID", reflect.TypeOf((*MockCommandRepository)(nil).GetCommandByID), ctx, id)
+}
+
+// GetCommands mocks base method.
+func (m *MockCommandRepository) GetCommands(ctx context.Context, sensorID int64, statuses []domain.CommandStatus) ([]domain.Command, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommands", ctx, sensorID, statuses)
+	ret0, _ := ret[0].([]domain.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommands indicates an expected call of GetCommands.
+func (mr *MockCommandRepositoryMockRecorder) GetCommands(ctx, sensorID, statuses interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommands", reflect.TypeOf((*MockCommandRepository)(nil).GetCommands), ctx, sensorID, statuses)
+}
+
+// SaveCommand mocks base method.
+func (m *MockCommandRepository) SaveCommand(ctx context.Context, command *domain.Command) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCommand", ctx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCommand indicates an expected call of SaveCommand.
+func (mr *MockCommandRepositoryMockRecorder) SaveCommand(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCommand", reflect.TypeOf((*MockCommandRepository)(nil).SaveCommand), ctx, command)
+}
+
+// MockSceneRepository is a mock of SceneRepository interface.
+type MockSceneRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSceneRepositoryMockRecorder
+}
+
+// MockSceneRepositoryMockRecorder is the mock recorder for MockSceneRepository.
+type MockSceneRepositoryMockRecorder struct {
+	mock *MockSceneRepository
+}
+
+// NewMockSceneRepository creates a new mock instance.
+func NewMockSceneRepository(ctrl *gomock.Controller) *MockSceneRepository {
+	mock := &MockSceneRepository{ctrl: ctrl}
+	mock.recorder = &MockSceneRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSceneRepository) EXPECT() *MockSceneRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteScene mocks base method.
+func (m *MockSceneRepository) DeleteScene(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScene", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteScene indicates an expected call of DeleteScene.
+func (mr *MockSceneRepositoryMockRecorder) DeleteScene(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScene", reflect.TypeOf((*MockSceneRepository)(nil).DeleteScene), ctx, id)
+}
+
+// GetActivations mocks base method.
+func (m *MockSceneRepository) GetActivations(ctx context.Context, sceneID int64, limit int) ([]domain.SceneActivation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActivations", ctx, sceneID, limit)
+	ret0, _ := ret[0].([]domain.SceneActivation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActivations indicates an expected call of GetActivations.
+func (mr *MockSceneRepositoryMockRecorder) GetActivations(ctx, sceneID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivations", reflect.TypeOf((*MockSceneRepository)(nil).GetActivations), ctx, sceneID, limit)
+}
+
+// GetSceneByID mocks base method.
+func (m *MockSceneRepository) GetSceneByID(ctx context.Context, id int64) (*domain.Scene, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSceneByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Scene)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSceneByID indicates an expected call of GetSceneByID.
+func (mr *MockSceneRepositoryMockRecorder) GetSceneByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSceneByID", reflect.TypeOf((*MockSceneRepository)(nil).GetSceneByID), ctx, id)
+}
+
+// GetScenes mocks base method.
+func (m *MockSceneRepository) GetScenes(ctx context.Context) ([]domain.Scene, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScenes", ctx)
+	ret0, _ := ret[0].([]domain.Scene)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScenes indicates an expected call of GetScenes.
+func (mr *MockSceneRepositoryMockRecorder) GetScenes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScenes", reflect.TypeOf((*MockSceneRepository)(nil).GetScenes), ctx)
+}
+
+// SaveActivation mocks base method.
+func (m *MockSceneRepository) SaveActivation(ctx context.Context, activation *domain.SceneActivation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveActivation", ctx, activation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveActivation indicates an expected call of SaveActivation.
+func (mr *MockSceneRepositoryMockRecorder) SaveActivation(ctx, activation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveActivation", reflect.TypeOf((*MockSceneRepository)(nil).SaveActivation), ctx, activation)
+}
+
+// SaveScene mocks base method.
+func (m *MockSceneRepository) SaveScene(ctx context.Context, scene *domain.Scene) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveScene", ctx, scene)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveScene indicates an expected call of SaveScene.
+func (mr *MockSceneRepositoryMockRecorder) SaveScene(ctx, scene interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveScene", reflect.TypeOf((*MockSceneRepository)(nil).SaveScene), ctx, scene)
+}
+
+// MockScheduleRepository is a mock of ScheduleRepository interface.
+type MockScheduleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleRepositoryMockRecorder
+}
+
+// MockScheduleRepositoryMockRecorder is the mock recorder for MockScheduleRepository.
+type MockScheduleRepositoryMockRecorder struct {
+	mock *MockScheduleRepository
+}
+
+// NewMockScheduleRepository creates a new mock instance.
+func NewMockScheduleRepository(ctrl *gomock.Controller) *MockScheduleRepository {
+	mock := &MockScheduleRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduleRepository) EXPECT() *MockScheduleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimSchedule mocks base method.
+func (m *MockScheduleRepository) ClaimSchedule(ctx context.Context, id int64, scheduledAt, next time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimSchedule", ctx, id, scheduledAt, next)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimSchedule indicates an expected call of ClaimSchedule.
+func (mr *MockScheduleRepositoryMockRecorder) ClaimSchedule(ctx, id, scheduledAt, next interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimSchedule", reflect.TypeOf((*MockScheduleRepository)(nil).ClaimSchedule), ctx, id, scheduledAt, next)
+}
+
+// DeleteSchedule mocks base method.
+func (m *MockScheduleRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSchedule indicates an expected call of DeleteSchedule.
+func (mr *MockScheduleRepositoryMockRecorder) DeleteSchedule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedule", reflect.TypeOf((*MockScheduleRepository)(nil).DeleteSchedule), ctx, id)
+}
+
+// GetDueSchedules mocks base method.
+func (m *MockScheduleRepository) GetDueSchedules(ctx context.Context, now time.Time) ([]domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueSchedules", ctx, now)
+	ret0, _ := ret[0].([]domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueSchedules indicates an expected call of GetDueSchedules.
+func (mr *MockScheduleRepositoryMockRecorder) GetDueSchedules(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueSchedules", reflect.TypeOf((*MockScheduleRepository)(nil).GetDueSchedules), ctx, now)
+}
+
+// GetRuns mocks base method.
+func (m *MockScheduleRepository) GetRuns(ctx context.Context, scheduleID int64, limit int) ([]domain.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRuns", ctx, scheduleID, limit)
+	ret0, _ := ret[0].([]domain.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRuns indicates an expected call of GetRuns.
+func (mr *MockScheduleRepositoryMockRecorder) GetRuns(ctx, scheduleID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuns", reflect.TypeOf((*MockScheduleRepository)(nil).GetRuns), ctx, scheduleID, limit)
+}
+
+// GetScheduleByID mocks base method.
+func (m *MockScheduleRepository) GetScheduleByID(ctx context.Context, id int64) (*domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleByID indicates an expected call of GetScheduleByID.
+func (mr *MockScheduleRepositoryMockRecorder) GetScheduleByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleByID", reflect.TypeOf((*MockScheduleRepository)(nil).GetScheduleByID), ctx, id)
+}
+
+// GetSchedules mocks base method.
+func (m *MockScheduleRepository) GetSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", ctx)
+	ret0, _ := ret[0].([]domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockScheduleRepositoryMockRecorder) GetSchedules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockScheduleRepository)(nil).GetSchedules), ctx)
+}
+
+// SaveRun mocks base method.
+func (m *MockScheduleRepository) SaveRun(ctx context.Context, run *domain.ScheduleRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRun", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRun indicates an expected call of SaveRun.
+func (mr *MockScheduleRepositoryMockRecorder) SaveRun(ctx, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRun", reflect.TypeOf((*MockScheduleRepository)(nil).SaveRun), ctx, run)
+}
+
+// SaveSchedule mocks base method.
+func (m *MockScheduleRepository) SaveSchedule(ctx context.Context, schedule *domain.Schedule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSchedule", ctx, schedule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSchedule indicates an expected call of SaveSchedule.
+func (mr *MockScheduleRepositoryMockRecorder) SaveSchedule(ctx, schedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSchedule", reflect.TypeOf((*MockScheduleRepository)(nil).SaveSchedule), ctx, schedule)
+}
+
+// MockAlertRepository is a mock of AlertRepository interface.
+type MockAlertRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAlertRepositoryMockRecorder
+}
+
+// MockAlertRepositoryMockRecorder is the mock recorder for MockAlertRepository.
+type MockAlertRepositoryMockRecorder struct {
+	mock *MockAlertRepository
+}
+
+// NewMockAlertRepository creates a new mock instance.
+func NewMockAlertRepository(ctrl *gomock.Controller) *MockAlertRepository {
+	mock := &MockAlertRepository{ctrl: ctrl}
+	mock.recorder = &MockAlertRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAlertRepository) EXPECT() *MockAlertRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteAlertRule mocks base method.
+func (m *MockAlertRepository) DeleteAlertRule(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAlertRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAlertRule indicates an expected call of DeleteAlertRule.
+func (mr *MockAlertRepositoryMockRecorder) DeleteAlertRule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAlertRule", reflect.TypeOf((*MockAlertRepository)(nil).DeleteAlertRule), ctx, id)
+}
+
+// GetAlertByID mocks base method.
+func (m *MockAlertRepository) GetAlertByID(ctx context.Context, id int64) (*domain.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAlertByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlertByID indicates an expected call of GetAlertByID.
+func (mr *MockAlertRepositoryMockRecorder) GetAlertByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlertByID", reflect.TypeOf((*MockAlertRepository)(nil).GetAlertByID), ctx, id)
+}
+
+// GetAlertChanges mocks base method.
+func (m *MockAlertRepository) GetAlertChanges(ctx context.Context, sensorIDs []int64, after int64, limit int) ([]domain.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAlertChanges", ctx, sensorIDs, after, limit)
+	ret0, _ := ret[0].([]domain.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlertChanges indicates an expected call of GetAlertChanges.
+func (mr *MockAlertRepositoryMockRecorder) GetAlertChanges(ctx, sensorIDs, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlertChanges", reflect.TypeOf((*MockAlertRepository)(nil).GetAlertChanges), ctx, sensorIDs, after, limit)
+}
+
+// GetAlertRevision mocks base method.
+func (m *MockAlertRepository) GetAlertRevision(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAlertRevision", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlertRevision indicates an expected call of GetAlertRevision.
+func (mr *MockAlertRepositoryMockRecorder) GetAlertRevision(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlertRevision", reflect.TypeOf((*MockAlertRepository)(nil).GetAlertRevision), ctx)
+}
+
+// GetAlertRuleByID mocks base method.
+func (m *MockAlertRepository) GetAlertRuleByID(ctx context.Context, id int64) (*domain.AlertRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAlertRuleByID", ctx, id)
+	ret0, _ := ret[0].(*domain.AlertRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlertRuleByID indicates an expected call of GetAlertRuleByID.
+func (mr *MockAlertRepositoryMockRecorder) GetAlertRuleByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlertRuleByID", reflect.TypeOf((*MockAlertRepository)(nil).GetAlertRuleByID), ctx, id)
+}
+
+// GetAlertRules mocks base method.
+func (m *MockAlertRepository) GetAlertRules(ctx context.Context, sensorID int64) ([]domain.AlertRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAlertRules", ctx, sensorID)
+	ret0, _ := ret[0].([]domain.AlertRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlertRules indicates an expected call of GetAlertRules.
+func (mr *MockAlertRepositoryMockRecorder) GetAlertRules(ctx, sensorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlertRules", reflect.TypeOf((*MockAlertRepository)(nil).GetAlertRules), ctx, sensorID)
+}
+
+// GetAlerts mocks base method.
+func (m *MockAlertRepository) GetAlerts(ctx context.Context, query domain.AlertQuery) ([]domain.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAlerts", ctx, query)
+	ret0, _ := ret[0].([]domain.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlerts indicates an expected call of GetAlerts.
+func (mr *MockAlertRepositoryMockRecorder) GetAlerts(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlerts", reflect.TypeOf((*MockAlertRepository)(nil).GetAlerts), ctx, query)
+}
+
+// GetLastAlertByRuleID mocks base method.
+func (m *MockAlertRepository) GetLastAlertByRuleID(ctx context.Context, ruleID int64) (*domain.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastAlertByRuleID", ctx, ruleID)
+	ret0, _ := ret[0].(*domain.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastAlertByRuleID indicates an expected call of GetLastAlertByRuleID.
+func (mr *MockAlertRepositoryMockRecorder) GetLastAlertByRuleID(ctx, ruleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastAlertByRuleID", reflect.TypeOf((*MockAlertRepository)(nil).GetLastAlertByRuleID), ctx, ruleID)
+}
+
+// RaiseAlert mocks base method.
+func (m *MockAlertRepository) RaiseAlert(ctx context.Context, alert *domain.Alert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RaiseAlert", ctx, alert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RaiseAlert indicates an expected call of RaiseAlert.
+func (mr *MockAlertRepositoryMockRecorder) RaiseAlert(ctx, alert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RaiseAlert", reflect.TypeOf((*MockAlertRepository)(nil).RaiseAlert), ctx, alert)
+}
+
+// SaveAlertRule mocks base method.
+func (m *MockAlertRepository) SaveAlertRule(ctx context.Context, rule *domain.AlertRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAlertRule", ctx, rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAlertRule indicates an expected call of SaveAlertRule.
+func (mr *MockAlertRepositoryMockRecorder) SaveAlertRule(ctx, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAlertRule", reflect.TypeOf((*MockAlertRepository)(nil).SaveAlertRule), ctx, rule)
+}
+
+// UpdateAlertStatus mocks base method.
+func (m *MockAlertRepository) UpdateAlertStatus(ctx context.Context, id int64, from []domain.AlertStatus, to domain.AlertStatus, userID int64, at time.Time) (*domain.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAlertStatus", ctx, id, from, to, userID, at)
+	ret0, _ := ret[0].(*domain.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAlertStatus indicates an expected call of UpdateAlertStatus.
+func (mr *MockAlertRepositoryMockRecorder) UpdateAlertStatus(ctx, id, from, to, userID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAlertStatus", reflect.TypeOf((*MockAlertRepository)(nil).UpdateAlertStatus), ctx, id, from, to, userID, at)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface.
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository.
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance.
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimDeliveries mocks base method.
+func (m *MockWebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDeliveries", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDeliveries indicates an expected call of ClaimDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) ClaimDeliveries(ctx, now, lease, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).ClaimDeliveries), ctx, now, lease, limit)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockWebhookRepository) DeleteWebhook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveSensor indicates an expected call of SaveSensor.
-func (mr *MockSensorRepositoryMockRecorder) SaveSensor(ctx, sensor interface{}) *gomock.Call {
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookRepositoryMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensor", reflect.TypeOf((*MockSensorRepository)(nil).SaveSensor), ctx, sensor)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookRepository)(nil).DeleteWebhook), ctx, id)
 }
 
-// MockEventRepository is a mock of EventRepository interface.
-type MockEventRepository struct {
+// GetDeliveries mocks base method.
+func (m *MockWebhookRepository) GetDeliveries(ctx context.Context, webhookID int64, statuses []domain.WebhookDeliveryStatus, limit int) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveries", ctx, webhookID, statuses, limit)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeliveries indicates an expected call of GetDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) GetDeliveries(ctx, webhookID, statuses, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).GetDeliveries), ctx, webhookID, statuses, limit)
+}
+
+// GetDeliveryByID mocks base method.
+func (m *MockWebhookRepository) GetDeliveryByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveryByID", ctx, id)
+	ret0, _ := ret[0].(*domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeliveryByID indicates an expected call of GetDeliveryByID.
+func (mr *MockWebhookRepositoryMockRecorder) GetDeliveryByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveryByID", reflect.TypeOf((*MockWebhookRepository)(nil).GetDeliveryByID), ctx, id)
+}
+
+// GetWebhookByID mocks base method.
+func (m *MockWebhookRepository) GetWebhookByID(ctx context.Context, id int64) (*domain.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookByID indicates an expected call of GetWebhookByID.
+func (mr *MockWebhookRepositoryMockRecorder) GetWebhookByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookByID", reflect.TypeOf((*MockWebhookRepository)(nil).GetWebhookByID), ctx, id)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockWebhookRepository) GetWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", ctx)
+	ret0, _ := ret[0].([]domain.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockWebhookRepositoryMockRecorder) GetWebhooks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockWebhookRepository)(nil).GetWebhooks), ctx)
+}
+
+// SaveDelivery mocks base method.
+func (m *MockWebhookRepository) SaveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDelivery indicates an expected call of SaveDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) SaveDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).SaveDelivery), ctx, delivery)
+}
+
+// SaveWebhook mocks base method.
+func (m *MockWebhookRepository) SaveWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebhook", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWebhook indicates an expected call of SaveWebhook.
+func (mr *MockWebhookRepositoryMockRecorder) SaveWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebhook", reflect.TypeOf((*MockWebhookRepository)(nil).SaveWebhook), ctx, webhook)
+}
+
+// MockVirtualSensorRepository is a mock of VirtualSensorRepository interface.
+type MockVirtualSensorRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockEventRepositoryMockRecorder
+	recorder *MockVirtualSensorRepositoryMockRecorder
 }
 
-// MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
-type MockEventRepositoryMockRecorder struct {
-	mock *MockEventRepository
+// MockVirtualSensorRepositoryMockRecorder is the mock recorder for MockVirtualSensorRepository.
+type MockVirtualSensorRepositoryMockRecorder struct {
+	mock *MockVirtualSensorRepository
 }
 
-// NewMockEventRepository creates a new mock instance.
-func NewMockEventRepository(ctrl *gomock.Controller) *MockEventRepository {
-	mock := &MockEventRepository{ctrl: ctrl}
-	mock.recorder = &MockEventRepositoryMockRecorder{mock}
+// NewMockVirtualSensorRepository creates a new mock instance.
+func NewMockVirtualSensorRepository(ctrl *gomock.Controller) *MockVirtualSensorRepository {
+	mock := &MockVirtualSensorRepository{ctrl: ctrl}
+	mock.recorder = &MockVirtualSensorRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
+func (m *MockVirtualSensorRepository) EXPECT() *MockVirtualSensorRepositoryMockRecorder {
 	return m.recorder
 }
 
-// GetEventsByTimeFrame mocks base method.
-func (m *MockEventRepository) GetEventsByTimeFrame(ctx context.Context, id int64, start, finish time.Time) ([]domain.Event, error) {
+// DeleteVirtualSensor mocks base method.
+func (m *MockVirtualSensorRepository) DeleteVirtualSensor(ctx context.Context, sensorID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEventsByTimeFrame", ctx, id, start, finish)
-	ret0, _ := ret[0].([]domain.Event)
+	ret := m.ctrl.Call(m, "DeleteVirtualSensor", ctx, sensorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVirtualSensor indicates an expected call of DeleteVirtualSensor.
+func (mr *MockVirtualSensorRepositoryMockRecorder) DeleteVirtualSensor(ctx, sensorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVirtualSensor", reflect.TypeOf((*MockVirtualSensorRepository)(nil).DeleteVirtualSensor), ctx, sensorID)
+}
+
+// GetVirtualSensor mocks base method.
+func (m *MockVirtualSensorRepository) GetVirtualSensor(ctx context.Context, sensorID int64) (*domain.VirtualSensor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualSensor", ctx, sensorID)
+	ret0, _ := ret[0].(*domain.VirtualSensor)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEventsByTimeFrame indicates an expected call of GetEventsByTimeFrame.
-func (mr *MockEventRepositoryMockRecorder) GetEventsByTimeFrame(ctx, id, start, finish interface{}) *gomock.Call {
+// GetVirtualSensor indicates an expected call of GetVirtualSensor.
+func (mr *MockVirtualSensorRepositoryMockRecorder) GetVirtualSensor(ctx, sensorID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsByTimeFrame", reflect.TypeOf((*MockEventRepository)(nil).GetEventsByTimeFrame), ctx, id, start, finish)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualSensor", reflect.TypeOf((*MockVirtualSensorRepository)(nil).GetVirtualSensor), ctx, sensorID)
 }
 
-// GetLastEventBySensorID mocks base method.
-func (m *MockEventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+// GetVirtualSensors mocks base method.
+func (m *MockVirtualSensorRepository) GetVirtualSensors(ctx context.Context) ([]domain.VirtualSensor, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLastEventBySensorID", ctx, id)
-	ret0, _ := ret[0].(*domain.Event)
+	ret := m.ctrl.Call(m, "GetVirtualSensors", ctx)
+	ret0, _ := ret[0].([]domain.VirtualSensor)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetLastEventBySensorID indicates an expected call of GetLastEventBySensorID.
-func (mr *MockEventRepositoryMockRecorder) GetLastEventBySensorID(ctx, id interface{}) *gomock.Call {
+// GetVirtualSensors indicates an expected call of GetVirtualSensors.
+func (mr *MockVirtualSensorRepositoryMockRecorder) GetVirtualSensors(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEventBySensorID", reflect.TypeOf((*MockEventRepository)(nil).GetLastEventBySensorID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualSensors", reflect.TypeOf((*MockVirtualSensorRepository)(nil).GetVirtualSensors), ctx)
 }
 
-// SaveEvent mocks base method.
-func (m *MockEventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+// GetVirtualSensorsByInput mocks base method.
+func (m *MockVirtualSensorRepository) GetVirtualSensorsByInput(ctx context.Context, inputID int64) ([]domain.VirtualSensor, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveEvent", ctx, event)
+	ret := m.ctrl.Call(m, "GetVirtualSensorsByInput", ctx, inputID)
+	ret0, _ := ret[0].([]domain.VirtualSensor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualSensorsByInput indicates an expected call of GetVirtualSensorsByInput.
+func (mr *MockVirtualSensorRepositoryMockRecorder) GetVirtualSensorsByInput(ctx, inputID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualSensorsByInput", reflect.TypeOf((*MockVirtualSensorRepository)(nil).GetVirtualSensorsByInput), ctx, inputID)
+}
+
+// SaveVirtualSensor mocks base method.
+func (m *MockVirtualSensorRepository) SaveVirtualSensor(ctx context.Context, vs *domain.VirtualSensor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVirtualSensor", ctx, vs)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveEvent indicates an expected call of SaveEvent.
-func (mr *MockEventRepositoryMockRecorder) SaveEvent(ctx, event interface{}) *gomock.Call {
+// SaveVirtualSensor indicates an expected call of SaveVirtualSensor.
+func (mr *MockVirtualSensorRepositoryMockRecorder) SaveVirtualSensor(ctx, vs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvent", reflect.TypeOf((*MockEventRepository)(nil).SaveEvent), ctx, event)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVirtualSensor", reflect.TypeOf((*MockVirtualSensorRepository)(nil).SaveVirtualSensor), ctx, vs)
+}
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockUnitOfWorkMockRecorder) Do(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockUnitOfWork)(nil).Do), ctx, fn)
+}
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddOutboxMessage mocks base method.
+func (m *MockOutboxRepository) AddOutboxMessage(ctx context.Context, message *domain.OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOutboxMessage", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOutboxMessage indicates an expected call of AddOutboxMessage.
+func (mr *MockOutboxRepositoryMockRecorder) AddOutboxMessage(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOutboxMessage", reflect.TypeOf((*MockOutboxRepository)(nil).AddOutboxMessage), ctx, message)
+}
+
+// DeleteOutboxMessages mocks base method.
+func (m *MockOutboxRepository) DeleteOutboxMessages(ctx context.Context, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOutboxMessages", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOutboxMessages indicates an expected call of DeleteOutboxMessages.
+func (mr *MockOutboxRepositoryMockRecorder) DeleteOutboxMessages(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOutboxMessages", reflect.TypeOf((*MockOutboxRepository)(nil).DeleteOutboxMessages), ctx, ids)
+}
+
+// GetOutboxMessages mocks base method.
+func (m *MockOutboxRepository) GetOutboxMessages(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutboxMessages", ctx, limit)
+	ret0, _ := ret[0].([]domain.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOutboxMessages indicates an expected call of GetOutboxMessages.
+func (mr *MockOutboxRepositoryMockRecorder) GetOutboxMessages(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxMessages", reflect.TypeOf((*MockOutboxRepository)(nil).GetOutboxMessages), ctx, limit)
+}
+
+// MockPublisher is a mock of Publisher interface.
+type MockPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockPublisherMockRecorder
+}
+
+// MockPublisherMockRecorder is the mock recorder for MockPublisher.
+type MockPublisherMockRecorder struct {
+	mock *MockPublisher
+}
+
+// NewMockPublisher creates a new mock instance.
+func NewMockPublisher(ctrl *gomock.Controller) *MockPublisher {
+	mock := &MockPublisher{ctrl: ctrl}
+	mock.recorder = &MockPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPublisher) EXPECT() *MockPublisherMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockPublisher) Publish(ctx context.Context, message domain.OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockPublisherMockRecorder) Publish(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockPublisher)(nil).Publish), ctx, message)
+}
+
+// MockLeader is a mock of Leader interface.
+type MockLeader struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaderMockRecorder
+}
+
+// MockLeaderMockRecorder is the mock recorder for MockLeader.
+type MockLeaderMockRecorder struct {
+	mock *MockLeader
+}
+
+// NewMockLeader creates a new mock instance.
+func NewMockLeader(ctrl *gomock.Controller) *MockLeader {
+	mock := &MockLeader{ctrl: ctrl}
+	mock.recorder = &MockLeaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeader) EXPECT() *MockLeaderMockRecorder {
+	return m.recorder
+}
+
+// Lead mocks base method.
+func (m *MockLeader) Lead(ctx context.Context, fn func(context.Context)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lead", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lead indicates an expected call of Lead.
+func (mr *MockLeaderMockRecorder) Lead(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lead", reflect.TypeOf((*MockLeader)(nil).Lead), ctx, fn)
 }
 
 // MockUserRepository is a mock of UserRepository interface.
@@ -265,3 +1351,83 @@ func (mr *MockSensorOwnerRepositoryMockRecorder) SaveSensorOwner(ctx, sensorOwne
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensorOwner", reflect.TypeOf((*MockSensorOwnerRepository)(nil).SaveSensorOwner), ctx, sensorOwner)
 }
+
+// MockImportRepository is a mock of ImportRepository interface.
+type MockImportRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportRepositoryMockRecorder
+}
+
+// MockImportRepositoryMockRecorder is the mock recorder for MockImportRepository.
+type MockImportRepositoryMockRecorder struct {
+	mock *MockImportRepository
+}
+
+// NewMockImportRepository creates a new mock instance.
+func NewMockImportRepository(ctrl *gomock.Controller) *MockImportRepository {
+	mock := &MockImportRepository{ctrl: ctrl}
+	mock.recorder = &MockImportRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportRepository) EXPECT() *MockImportRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CommitEvents mocks base method.
+func (m *MockImportRepository) CommitEvents(ctx context.Context, imp *domain.Import, events []domain.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitEvents", ctx, imp, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitEvents indicates an expected call of CommitEvents.
+func (mr *MockImportRepositoryMockRecorder) CommitEvents(ctx, imp, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitEvents", reflect.TypeOf((*MockImportRepository)(nil).CommitEvents), ctx, imp, events)
+}
+
+// CommitSensors mocks base method.
+func (m *MockImportRepository) CommitSensors(ctx context.Context, imp *domain.Import, sensors []domain.Sensor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitSensors", ctx, imp, sensors)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitSensors indicates an expected call of CommitSensors.
+func (mr *MockImportRepositoryMockRecorder) CommitSensors(ctx, imp, sensors interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitSensors", reflect.TypeOf((*MockImportRepository)(nil).CommitSensors), ctx, imp, sensors)
+}
+
+// GetImport mocks base method.
+func (m *MockImportRepository) GetImport(ctx context.Context, id string) (*domain.Import, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImport", ctx, id)
+	ret0, _ := ret[0].(*domain.Import)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImport indicates an expected call of GetImport.
+func (mr *MockImportRepositoryMockRecorder) GetImport(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImport", reflect.TypeOf((*MockImportRepository)(nil).GetImport), ctx, id)
+}
+
+// SaveImport mocks base method.
+func (m *MockImportRepository) SaveImport(ctx context.Context, imp *domain.Import) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveImport", ctx, imp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveImport indicates an expected call of SaveImport.
+func (mr *MockImportRepositoryMockRecorder) SaveImport(ctx, imp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveImport", reflect.TypeOf((*MockImportRepository)(nil).SaveImport), ctx, imp)
+}
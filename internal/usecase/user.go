@@ -3,6 +3,11 @@ package usecase
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type User struct {
@@ -19,7 +24,10 @@ func NewUser(ur UserRepository, sor SensorOwnerRepository, sr SensorRepository)
 	}
 }
 
-func (u *User) RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+func (u *User) RegisterUser(ctx context.Context, user *domain.User) (_ *domain.User, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "User.RegisterUser")
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
@@ -27,7 +35,7 @@ func (u *User) RegisterUser(ctx context.Context, user *domain.User) (*domain.Use
 		return nil, ErrInvalidUserName
 	}
 
-	err := u.ur.SaveUser(ctx, user)
+	err = u.ur.SaveUser(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -35,11 +43,15 @@ func (u *User) RegisterUser(ctx context.Context, user *domain.User) (*domain.Use
 	return user, nil
 }
 
-func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) error {
+func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "User.AttachSensorToUser",
+		trace.WithAttributes(attribute.Int64("user.id", userID), attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
-	_, err := u.ur.GetUserByID(ctx, userID)
+	_, err = u.ur.GetUserByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -54,20 +66,29 @@ func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) e
 		SensorID: sensorID,
 	}
 
+	logger := logging.FromContext(ctx).With(logging.UserID(userID), logging.SensorID(sensorID))
+
 	err = u.sor.SaveSensorOwner(ctx, so)
 	if err != nil {
+		logger.ErrorContext(ctx, "can't attach sensor to user", logging.Error(err))
 		return err
 	}
 
+	logger.InfoContext(ctx, "sensor attached to user")
+
 	return nil
 }
 
-func (u *User) GetUserSensors(ctx context.Context, userID int64) ([]domain.Sensor, error) {
+func (u *User) GetUserSensors(ctx context.Context, userID int64) (_ []domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "User.GetUserSensors",
+		trace.WithAttributes(attribute.Int64("user.id", userID)))
+	defer func() { tracing.End(span, err) }()
+
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
-	_, err := u.ur.GetUserByID(ctx, userID)
+	_, err = u.ur.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
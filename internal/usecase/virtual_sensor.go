@@ -0,0 +1,441 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/expression"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxVirtualSensorInputs - на сколько датчиков может ссылаться выражение виртуального датчика
+const maxVirtualSensorInputs = 32
+
+// VirtualSensor - виртуальные датчики: значения вычисляются по выражениям над текущими значениями
+// других датчиков и записываются обычными событиями, поэтому история, сводки, подписки и тревоги
+// работают с ними так же, как с физическими датчиками. Значения пересчитывает usecase.Event,
+// когда принимает событие одного из входов.
+type VirtualSensor struct {
+	repo    VirtualSensorRepository
+	sensors *Sensor
+	er      EventRepository
+	now     func() time.Time
+}
+
+func NewVirtualSensor(repo VirtualSensorRepository, sensors *Sensor, er EventRepository) *VirtualSensor {
+	return &VirtualSensor{
+		repo:    repo,
+		sensors: sensors,
+		er:      er,
+		now:     time.Now,
+	}
+}
+
+// validate - разбирает выражение виртуального датчика sensorID и проверяет его входы: они должны
+// существовать и не зависеть от самого датчика. sensorID = 0 - датчик ещё не зарегистрирован.
+func (v *VirtualSensor) validate(ctx context.Context, sensorID int64, source string) (*expression.Expression, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("%w: expression is required", ErrInvalidVirtualSensor)
+	}
+
+	e, err := expression.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidVirtualSensor, err)
+	}
+
+	inputs := e.Inputs()
+	if len(inputs) > maxVirtualSensorInputs {
+		return nil, fmt.Errorf("%w: expression references more than %d sensors", ErrInvalidVirtualSensor, maxVirtualSensorInputs)
+	}
+	for _, id := range inputs {
+		if id == sensorID {
+			return nil, fmt.Errorf("%w: expression references the sensor itself", ErrInvalidVirtualSensor)
+		}
+		if _, err := v.sensors.GetSensorByID(ctx, id); err != nil {
+			if errors.Is(err, ErrSensorNotFound) {
+				return nil, fmt.Errorf("%w: sensor %d not found", ErrInvalidVirtualSensor, id)
+			}
+			return nil, err
+		}
+	}
+
+	if sensorID == 0 {
+		return e, nil
+	}
+
+	// входы не должны зависеть от датчика через другие виртуальные датчики, иначе пересчёт не закончится
+	defs, err := v.repo.GetVirtualSensors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	graph := make(map[int64][]int64, len(defs))
+	for _, def := range defs {
+		graph[def.SensorID] = def.Inputs
+	}
+
+	seen := make(map[int64]bool)
+	stack := slices.Clone(inputs)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if slices.Contains(graph[id], sensorID) {
+			return nil, fmt.Errorf("%w: sensor %d depends on this virtual sensor", ErrInvalidVirtualSensor, id)
+		}
+		stack = append(stack, graph[id]...)
+	}
+
+	return e, nil
+}
+
+// CreateVirtualSensor - регистрирует датчик виртуального типа из реестра и сохраняет его выражение.
+// Серийный номер, оставшийся от удалённого виртуального датчика, используется повторно вместе с историей,
+// номер любого другого датчика - ErrSensorExists.
+func (v *VirtualSensor) CreateVirtualSensor(ctx context.Context, sensor *domain.Sensor, source string) (_ *domain.VirtualSensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "VirtualSensor.CreateVirtualSensor",
+		trace.WithAttributes(attribute.String("sensor.serial_number", sensor.SerialNumber)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if !validateSerialNumber(sensor.SerialNumber) {
+		return nil, ErrWrongSensorSerialNumber
+	}
+	if err := validateSensorUnits(sensor); err != nil {
+		return nil, err
+	}
+
+	var sensorID int64
+	existing, err := v.sensors.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	switch {
+	case errors.Is(err, ErrSensorNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		virtual, err := v.sensors.IsVirtual(ctx, existing)
+		if err != nil {
+			return nil, err
+		}
+		if !virtual {
+			return nil, ErrSensorExists
+		}
+		if _, err := v.repo.GetVirtualSensor(ctx, existing.ID); !errors.Is(err, ErrVirtualSensorNotFound) {
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrSensorExists
+		}
+		sensorID = existing.ID
+	}
+
+	e, err := v.validate(ctx, sensorID, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if sensorID == 0 {
+		if sensor.Type, err = v.sensors.types.VirtualSensorType(ctx); err != nil {
+			return nil, err
+		}
+		if sensor, err = v.sensors.register(ctx, sensor); err != nil {
+			return nil, err
+		}
+		// номер мог занять параллельный запрос
+		virtual, err := v.sensors.IsVirtual(ctx, sensor)
+		if err != nil {
+			return nil, err
+		}
+		if !virtual {
+			return nil, ErrSensorExists
+		}
+		sensorID = sensor.ID
+	} else {
+		_, err = v.sensors.UpdateSensor(ctx, sensorID, 0, SensorUpdate{
+			Description: &sensor.Description,
+			IsActive:    &sensor.IsActive,
+			Unit:        &sensor.Unit,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := v.now()
+	vs := &domain.VirtualSensor{
+		SensorID:   sensorID,
+		Expression: e.String(),
+		Inputs:     e.Inputs(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := v.repo.SaveVirtualSensor(ctx, vs); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "virtual sensor created", logging.SensorID(sensorID))
+
+	return vs, nil
+}
+
+// UpdateVirtualSensor - заменяет выражение виртуального датчика. Новое значение вычисляется
+// со следующим событием любого из входов.
+func (v *VirtualSensor) UpdateVirtualSensor(ctx context.Context, sensorID int64, source string) (_ *domain.VirtualSensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "VirtualSensor.UpdateVirtualSensor",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	vs, err := v.repo.GetVirtualSensor(ctx, sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := v.validate(ctx, sensorID, source)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.Expression = e.String()
+	vs.Inputs = e.Inputs()
+	vs.UpdatedAt = v.now()
+	if err := v.repo.SaveVirtualSensor(ctx, vs); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "virtual sensor updated", logging.SensorID(sensorID))
+
+	return vs, nil
+}
+
+// GetVirtualSensor - определение виртуального датчика по ID датчика
+func (v *VirtualSensor) GetVirtualSensor(ctx context.Context, sensorID int64) (_ *domain.VirtualSensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "VirtualSensor.GetVirtualSensor",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return v.repo.GetVirtualSensor(ctx, sensorID)
+}
+
+// GetVirtualSensors - все определения виртуальных датчиков по возрастанию ID датчика
+func (v *VirtualSensor) GetVirtualSensors(ctx context.Context) (_ []domain.VirtualSensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "VirtualSensor.GetVirtualSensors")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return v.repo.GetVirtualSensors(ctx)
+}
+
+// DeleteVirtualSensor - удаляет выражение виртуального датчика. Датчик и его история остаются,
+// но значения больше не вычисляются.
+func (v *VirtualSensor) DeleteVirtualSensor(ctx context.Context, sensorID int64) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "VirtualSensor.DeleteVirtualSensor",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := v.repo.DeleteVirtualSensor(ctx, sensorID); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "virtual sensor deleted", logging.SensorID(sensorID))
+
+	return nil
+}
+
+// recompute - пересчитывает виртуальные датчики, зависящие от датчика inputID прямо или через другие
+// виртуальные датчики, после его события в момент at. Каждый датчик вычисляется один раз и после
+// всех своих затронутых входов; вычисленное событие передаётся в save.
+func (v *VirtualSensor) recompute(
+	ctx context.Context,
+	inputID int64,
+	at time.Time,
+	save func(ctx context.Context, sensor *domain.Sensor, event *domain.Event) error,
+) error {
+	affected := make(map[int64]domain.VirtualSensor)
+	queue := []int64{inputID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		defs, err := v.repo.GetVirtualSensorsByInput(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, def := range defs {
+			if _, ok := affected[def.SensorID]; ok || def.SensorID == inputID {
+				continue
+			}
+			affected[def.SensorID] = def
+			queue = append(queue, def.SensorID)
+		}
+	}
+
+	ids := make([]int64, 0, len(affected))
+	for id := range affected {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	done := make(map[int64]bool, len(affected))
+	pending := func(id int64) bool {
+		_, ok := affected[id]
+		return ok && !done[id]
+	}
+
+	for len(done) < len(affected) {
+		progress := false
+		for _, id := range ids {
+			if done[id] || slices.ContainsFunc(affected[id].Inputs, pending) {
+				continue
+			}
+			done[id], progress = true, true
+
+			if err := v.compute(ctx, affected[id], at, save); err != nil {
+				return err
+			}
+		}
+		if !progress {
+			// циклы отвергаются при сохранении выражений, сюда можно попасть, только если их изменили в обход usecase
+			return fmt.Errorf("%w: virtual sensors depend on each other", ErrInvalidVirtualSensor)
+		}
+	}
+
+	return nil
+}
+
+// compute - вычисляет значение виртуального датчика и передаёт событие с ним в save.
+// Если у входов нет значений или результат не определён, событие не создаётся.
+func (v *VirtualSensor) compute(
+	ctx context.Context,
+	def domain.VirtualSensor,
+	at time.Time,
+	save func(ctx context.Context, sensor *domain.Sensor, event *domain.Event) error,
+) error {
+	logger := logging.FromContext(ctx).With(logging.SensorID(def.SensorID))
+
+	sensor, err := v.sensors.GetSensorByID(ctx, def.SensorID)
+	if err != nil {
+		return err
+	}
+	if !sensor.IsActive {
+		return nil
+	}
+
+	e, err := expression.Parse(def.Expression)
+	if err != nil {
+		logger.WarnContext(ctx, "invalid virtual sensor expression", logging.Error(err))
+		return nil
+	}
+
+	value, err := e.Eval(ctx, virtualEnv{v: v, at: at})
+	if errors.Is(err, expression.ErrNoValue) || errors.Is(err, expression.ErrEvaluation) {
+		logger.DebugContext(ctx, "virtual sensor value is undefined", logging.Error(err))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	event := &domain.Event{
+		Timestamp:          at,
+		SensorSerialNumber: sensor.SerialNumber,
+		SensorID:           sensor.ID,
+	}
+	event.SetValue(value)
+
+	return save(ctx, sensor, event)
+}
+
+// virtualEnv - значения входов выражения: последние события датчиков и сводки за окно, которое
+// заканчивается в момент at. Значения канала domain.DefaultChannel берутся после калибровки датчика.
+type virtualEnv struct {
+	v  *VirtualSensor
+	at time.Time
+}
+
+func (e virtualEnv) sensor(ctx context.Context, id int64) (*domain.Sensor, error) {
+	sensor, err := e.v.sensors.GetSensorByID(ctx, id)
+	if errors.Is(err, ErrSensorNotFound) {
+		return nil, fmt.Errorf("%w: sensor not found", expression.ErrNoValue)
+	}
+	return sensor, err
+}
+
+func (e virtualEnv) Value(ctx context.Context, sensorID int64, channel string) (domain.Value, error) {
+	sensor, err := e.sensor(ctx, sensorID)
+	if err != nil {
+		return domain.Value{}, err
+	}
+
+	event, err := e.v.er.GetLastEventBySensorID(ctx, sensorID)
+	if errors.Is(err, ErrEventNotFound) {
+		return domain.Value{}, fmt.Errorf("%w: sensor has no events", expression.ErrNoValue)
+	}
+	if err != nil {
+		return domain.Value{}, err
+	}
+
+	channel = cmp.Or(channel, domain.PrimaryChannel(event.Channels))
+	value, ok := event.Value(channel)
+	if !ok {
+		return domain.Value{}, fmt.Errorf("%w: last event has no channel %s", expression.ErrNoValue, channel)
+	}
+	if channel == domain.DefaultChannel && sensor.Calibration != nil {
+		value = domain.FloatValue(sensor.Calibration.Apply(value.Number()))
+	}
+	return value, nil
+}
+
+func (e virtualEnv) Aggregate(ctx context.Context, sensorID int64, channel string, window time.Duration) (domain.Aggregate, error) {
+	sensor, err := e.sensor(ctx, sensorID)
+	if err != nil {
+		return domain.Aggregate{}, err
+	}
+
+	channel = cmp.Or(channel, domain.DefaultChannel)
+	aggs, err := e.v.er.AggregateEvents(ctx, sensorID, channel, e.at.Add(-window), e.at, 0)
+	if err != nil {
+		return domain.Aggregate{}, err
+	}
+	if len(aggs) == 0 {
+		return domain.Aggregate{}, nil
+	}
+	if channel != domain.DefaultChannel {
+		return aggs[0], nil
+	}
+
+	conv := &Conversion{calibration: sensor.Calibration, scale: 1}
+	agg, err := conv.ConvertAggregate(aggs[0])
+	if err != nil {
+		return domain.Aggregate{}, fmt.Errorf("%w: %w", expression.ErrEvaluation, err)
+	}
+	return agg, nil
+}
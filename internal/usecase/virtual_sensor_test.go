@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_virtualSensor_CreateVirtualSensor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).AnyTimes().Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(2)).AnyTimes().Return(nil, ErrSensorNotFound)
+	sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1000000000").AnyTimes().
+		Return(&domain.Sensor{ID: 1, SerialNumber: "1000000000", Type: domain.SensorTypeADC}, nil)
+	sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "5000000000").AnyTimes().Return(nil, ErrSensorNotFound)
+
+	t.Run("ok, sensor registered", func(t *testing.T) {
+		ctx := context.Background()
+
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, s *domain.Sensor) error {
+			assert.Equal(t, domain.SensorType("virtual"), s.Type)
+			s.ID = 5
+			return nil
+		})
+		repo := NewMockVirtualSensorRepository(ctrl)
+		repo.EXPECT().SaveVirtualSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		vs, err := NewVirtualSensor(repo, NewSensor(sr), nil).CreateVirtualSensor(ctx,
+			&domain.Sensor{SerialNumber: "5000000000", IsActive: true}, " max_over(s1, 1h) - s1 ")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), vs.SensorID)
+		assert.Equal(t, "max_over(s1, 1h) - s1", vs.Expression)
+		assert.Equal(t, []int64{1}, vs.Inputs)
+		assert.False(t, vs.CreatedAt.IsZero())
+	})
+
+	t.Run("err, serial number taken", func(t *testing.T) {
+		repo := NewMockVirtualSensorRepository(ctrl)
+		repo.EXPECT().SaveVirtualSensor(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewVirtualSensor(repo, NewSensor(sr), nil).CreateVirtualSensor(context.Background(),
+			&domain.Sensor{SerialNumber: "1000000000"}, "s1")
+		assert.ErrorIs(t, err, ErrSensorExists)
+	})
+
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"err, empty expression", " "},
+		{"err, syntax error", "s1 +"},
+		{"err, unknown sensor", "s1 + s2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockVirtualSensorRepository(ctrl)
+			repo.EXPECT().SaveVirtualSensor(gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := NewVirtualSensor(repo, NewSensor(sr), nil).CreateVirtualSensor(context.Background(),
+				&domain.Sensor{SerialNumber: "5000000000"}, tt.expression)
+			assert.ErrorIs(t, err, ErrInvalidVirtualSensor)
+		})
+	}
+}
+
+func Test_virtualSensor_UpdateVirtualSensor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, id int64) (*domain.Sensor, error) {
+		return &domain.Sensor{ID: id}, nil
+	})
+
+	// s6 = s5 + 1, s5 = s1 * 2
+	defs := []domain.VirtualSensor{
+		{SensorID: 5, Expression: "s1 * 2", Inputs: []int64{1}},
+		{SensorID: 6, Expression: "s5 + 1", Inputs: []int64{5}},
+	}
+
+	t.Run("ok, expression replaced", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockVirtualSensorRepository(ctrl)
+		repo.EXPECT().GetVirtualSensor(ctx, int64(6)).Times(1).Return(&defs[1], nil)
+		repo.EXPECT().GetVirtualSensors(ctx).Times(1).Return(defs, nil)
+		repo.EXPECT().SaveVirtualSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		vs, err := NewVirtualSensor(repo, NewSensor(sr), nil).UpdateVirtualSensor(ctx, 6, "s5 + s1")
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 5}, vs.Inputs)
+	})
+
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"err, self reference", "s5 + 1"},
+		{"err, cycle", "s6 * 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockVirtualSensorRepository(ctrl)
+			repo.EXPECT().GetVirtualSensor(gomock.Any(), int64(5)).Times(1).Return(&domain.VirtualSensor{SensorID: 5}, nil)
+			repo.EXPECT().GetVirtualSensors(gomock.Any()).AnyTimes().Return(defs, nil)
+			repo.EXPECT().SaveVirtualSensor(gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := NewVirtualSensor(repo, NewSensor(sr), nil).UpdateVirtualSensor(context.Background(), 5, tt.expression)
+			assert.ErrorIs(t, err, ErrInvalidVirtualSensor)
+		})
+	}
+
+	t.Run("err, not found", func(t *testing.T) {
+		repo := NewMockVirtualSensorRepository(ctrl)
+		repo.EXPECT().GetVirtualSensor(gomock.Any(), int64(7)).Times(1).Return(nil, ErrVirtualSensorNotFound)
+
+		_, err := NewVirtualSensor(repo, NewSensor(sr), nil).UpdateVirtualSensor(context.Background(), 7, "s1")
+		assert.ErrorIs(t, err, ErrVirtualSensorNotFound)
+	})
+}
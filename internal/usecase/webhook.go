@@ -0,0 +1,738 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/tracing"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Заголовки запроса доставки
+	WebhookDeliveryHeader  = "X-Smarthome-Delivery"
+	WebhookTopicHeader     = "X-Smarthome-Topic"
+	WebhookTimestampHeader = "X-Smarthome-Timestamp"
+	WebhookSignatureHeader = "X-Smarthome-Signature"
+)
+
+const (
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookMaxAttempts = 10
+	defaultWebhookMinBackoff  = 10 * time.Second
+	defaultWebhookMaxBackoff  = time.Hour
+	// webhookDeliveryBatch - сколько доставок забирается из очереди за раз
+	webhookDeliveryBatch = 100
+	// webhookDeliveryLease - на сколько забранная доставка скрывается от других реплик,
+	// должно быть больше таймаута запроса
+	webhookDeliveryLease = 5 * time.Minute
+	// maxWebhookURLLength, maxWebhookFilter - ограничения подписки
+	maxWebhookURLLength = 2048
+	maxWebhookFilter    = 100
+	// minWebhookSecretLength, maxWebhookSecretLength - допустимая длина секрета, заданного клиентом
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 256
+	// defaultDeliveries, maxDeliveries - сколько доставок отдаётся по умолчанию и максимум
+	defaultDeliveries = 50
+	maxDeliveries     = 1000
+)
+
+var webhookTopics = []domain.WebhookTopic{
+	domain.WebhookSensorEvent,
+	domain.WebhookSensorCreated,
+	domain.WebhookSensorUpdated,
+}
+
+// Webhook - подписки внешних систем на события и изменения датчиков.
+//
+// Получатели OnEvent и OnSensorChange ставят в очередь доставку каждой подходящей подписке,
+// DeliverPending отправляет доставки POST запросом с HMAC подписью. Неудачная попытка
+// повторяется с экспоненциально растущей паузой, а когда попытки кончаются, доставка
+// становится dead и ждёт ручного повтора RetryDelivery.
+type Webhook struct {
+	repo        WebhookRepository
+	sensors     *Sensor
+	users       *User
+	types       *SensorTypes
+	client      *http.Client
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	now         func() time.Time
+}
+
+func NewWebhook(repo WebhookRepository, sensors *Sensor, users *User, options ...func(*Webhook)) *Webhook {
+	w := &Webhook{
+		repo:        repo,
+		sensors:     sensors,
+		users:       users,
+		types:       NewSensorTypes(nil),
+		client:      NewWebhookHTTPClient(defaultWebhookTimeout, false),
+		maxAttempts: defaultWebhookMaxAttempts,
+		minBackoff:  defaultWebhookMinBackoff,
+		maxBackoff:  defaultWebhookMaxBackoff,
+		now:         time.Now,
+	}
+	for _, o := range options {
+		o(w)
+	}
+	return w
+}
+
+// WithWebhookSensorTypes - реестр, по которому проверяется фильтр по типам датчиков
+func WithWebhookSensorTypes(types *SensorTypes) func(*Webhook) {
+	return func(w *Webhook) {
+		w.types = types
+	}
+}
+
+// WithWebhookHTTPClient - клиент для отправки доставок
+func WithWebhookHTTPClient(client *http.Client) func(*Webhook) {
+	return func(w *Webhook) {
+		w.client = client
+	}
+}
+
+// sharedAddressSpace - адреса провайдерского NAT (RFC 6598), они тоже не видны из интернета
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewWebhookHTTPClient - клиент для отправки доставок. Без allowPrivate он не соединяется с loopback,
+// частными, link-local и другими внутренними адресами, чтобы подписка не открывала доступ к внутренней
+// сети. Проверяется адрес, с которым устанавливается соединение, поэтому запрет не обходится
+// ни через DNS, ни перенаправлениями.
+func NewWebhookHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = denyPrivateTarget
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// через прокси соединение устанавливается с самим прокси, и адрес получателя не проверить
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// denyPrivateTarget - отвергает соединение с адресом, недоступным из интернета
+func denyPrivateTarget(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookTargetDenied, ip)
+	}
+	return nil
+}
+
+// WithWebhookRetries - сколько раз пытаться доставить и в каких пределах растёт пауза между попытками
+func WithWebhookRetries(maxAttempts int, minBackoff, maxBackoff time.Duration) func(*Webhook) {
+	return func(w *Webhook) {
+		w.maxAttempts = maxAttempts
+		w.minBackoff = minBackoff
+		w.maxBackoff = maxBackoff
+	}
+}
+
+// SignWebhook - подпись доставки: HMAC-SHA256 строки "<timestamp>.<body>" на ключе secret, в hex
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (w *Webhook) validate(ctx context.Context, webhook *domain.Webhook) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(webhook.URL) > maxWebhookURLLength {
+		return fmt.Errorf("%w: url must be an absolute http or https URL of at most %d characters", ErrInvalidWebhook, maxWebhookURLLength)
+	}
+
+	if webhook.Secret != "" && (len(webhook.Secret) < minWebhookSecretLength || len(webhook.Secret) > maxWebhookSecretLength) {
+		return fmt.Errorf("%w: secret must be %d to %d characters", ErrInvalidWebhook, minWebhookSecretLength, maxWebhookSecretLength)
+	}
+
+	for _, topic := range webhook.Topics {
+		if !slices.Contains(webhookTopics, topic) {
+			return fmt.Errorf("%w: unknown topic %q", ErrInvalidWebhook, topic)
+		}
+	}
+
+	if len(webhook.SensorIDs) > maxWebhookFilter || len(webhook.SensorTypes) > maxWebhookFilter {
+		return fmt.Errorf("%w: at most %d sensors and sensor types", ErrInvalidWebhook, maxWebhookFilter)
+	}
+	for _, id := range webhook.SensorIDs {
+		if _, err := w.sensors.GetSensorByID(ctx, id); err != nil {
+			if errors.Is(err, ErrSensorNotFound) {
+				return fmt.Errorf("%w: sensor %d not found", ErrInvalidWebhook, id)
+			}
+			return err
+		}
+	}
+	for _, t := range webhook.SensorTypes {
+		if _, err := w.types.GetSensorType(ctx, t); err != nil {
+			if errors.Is(err, ErrWrongSensorType) {
+				return fmt.Errorf("%w: unknown sensor type %q", ErrInvalidWebhook, t)
+			}
+			return err
+		}
+	}
+
+	if webhook.UserID != 0 {
+		if _, err := w.users.GetUserSensors(ctx, webhook.UserID); err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				return fmt.Errorf("%w: user %d not found", ErrInvalidWebhook, webhook.UserID)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateWebhook - добавляет подписку. Если секрет не задан, он генерируется.
+func (w *Webhook) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (_ *domain.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.CreateWebhook")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if err := w.validate(ctx, webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		if webhook.Secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := w.now().UTC()
+	webhook.ID = 0
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	if err := w.repo.SaveWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "webhook created", slog.Int64("webhook_id", webhook.ID))
+
+	return webhook, nil
+}
+
+// UpdateWebhook - заменяет подписку id. Пустой секрет оставляет прежний, поставленные доставки не меняются.
+func (w *Webhook) UpdateWebhook(ctx context.Context, id int64, webhook *domain.Webhook) (_ *domain.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.UpdateWebhook",
+		trace.WithAttributes(attribute.Int64("webhook.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	stored, err := w.repo.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.validate(ctx, webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = stored.Secret
+	}
+
+	webhook.ID = stored.ID
+	webhook.CreatedAt = stored.CreatedAt
+	webhook.UpdatedAt = w.now().UTC()
+
+	if err := w.repo.SaveWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "webhook updated", slog.Int64("webhook_id", webhook.ID))
+
+	return webhook, nil
+}
+
+// GetWebhook - подписка по ID
+func (w *Webhook) GetWebhook(ctx context.Context, id int64) (_ *domain.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.GetWebhook",
+		trace.WithAttributes(attribute.Int64("webhook.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return w.repo.GetWebhookByID(ctx, id)
+}
+
+// GetWebhooks - все подписки по возрастанию ID
+func (w *Webhook) GetWebhooks(ctx context.Context) (_ []domain.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.GetWebhooks")
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return w.repo.GetWebhooks(ctx)
+}
+
+// DeleteWebhook - удаляет подписку вместе с её доставками
+func (w *Webhook) DeleteWebhook(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.DeleteWebhook",
+		trace.WithAttributes(attribute.Int64("webhook.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := w.repo.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "webhook deleted", slog.Int64("webhook_id", id))
+
+	return nil
+}
+
+// webhookPayload - тело запроса доставки
+type webhookPayload struct {
+	Topic      domain.WebhookTopic `json:"topic"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Sensor     webhookSensor       `json:"sensor"`
+	Event      *webhookEvent       `json:"event,omitempty"`
+}
+
+type webhookSensor struct {
+	ID           int64        `json:"id"`
+	SerialNumber string       `json:"serial_number"`
+	Type         string       `json:"type"`
+	Description  string       `json:"description"`
+	IsActive     bool         `json:"is_active"`
+	CurrentState int64        `json:"current_state"`
+	CurrentValue domain.Value `json:"current_value"`
+	Unit         string       `json:"unit,omitempty"`
+	RegisteredAt time.Time    `json:"registered_at"`
+	LastActivity time.Time    `json:"last_activity"`
+}
+
+type webhookEvent struct {
+	Timestamp time.Time               `json:"timestamp"`
+	Payload   int64                   `json:"payload"`
+	Channels  map[string]domain.Value `json:"channels,omitempty"`
+}
+
+// OnEvent - получатель событий usecase.Event: ставит доставки sensor.event.
+// Не используется, если события доставляются через outbox (см. PublishEvent).
+func (w *Webhook) OnEvent(ctx context.Context, event domain.Event, sensor domain.Sensor) {
+	err := w.enqueue(ctx, domain.WebhookSensorEvent, sensor, webhookPayload{
+		OccurredAt: event.Timestamp.UTC(),
+		Event: &webhookEvent{
+			Timestamp: event.Timestamp.UTC(),
+			Payload:   event.Payload,
+			Channels:  event.Channels,
+		},
+	})
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "can't enqueue webhook deliveries",
+			logging.SensorID(sensor.ID), logging.Error(err))
+	}
+}
+
+// PublishEvent - публикатор outbox: ставит доставки sensor.event по сообщению о принятом событии.
+// Relay вызывает его в своей единице работы, поэтому доставки сохраняются вместе с удалением сообщения:
+// событие, принятое до сбоя, не теряется. Сообщения других тем пропускаются.
+func (w *Webhook) PublishEvent(ctx context.Context, message domain.OutboxMessage) error {
+	if message.Topic != domain.OutboxSensorEvent {
+		return nil
+	}
+
+	logger := logging.FromContext(ctx).With(slog.Int64("message_id", message.ID))
+
+	var m eventMessage
+	if err := json.Unmarshal(message.Payload, &m); err != nil {
+		// повтор не поможет, сообщение не должно остановить очередь
+		logger.ErrorContext(ctx, "can't decode outbox message", logging.Error(err))
+		return nil
+	}
+	sensor, err := w.sensors.GetSensorByID(ctx, m.SensorID)
+	if errors.Is(err, ErrSensorNotFound) {
+		logger.WarnContext(ctx, "sensor of outbox message not found", logging.SensorID(m.SensorID))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't get sensor: %w", err)
+	}
+
+	return w.enqueue(ctx, domain.WebhookSensorEvent, *sensor, webhookPayload{
+		OccurredAt: m.Timestamp.UTC(),
+		Event: &webhookEvent{
+			Timestamp: m.Timestamp.UTC(),
+			Payload:   m.Payload,
+			Channels:  m.Channels,
+		},
+	})
+}
+
+// OnSensorChange - получатель изменений usecase.Sensor: ставит доставки sensor.created или sensor.updated
+func (w *Webhook) OnSensorChange(ctx context.Context, sensor domain.Sensor, created bool) {
+	topic := domain.WebhookSensorUpdated
+	if created {
+		topic = domain.WebhookSensorCreated
+	}
+	if err := w.enqueue(ctx, topic, sensor, webhookPayload{OccurredAt: w.now().UTC()}); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "can't enqueue webhook deliveries",
+			logging.SensorID(sensor.ID), logging.Error(err))
+	}
+}
+
+// enqueue - ставит в очередь доставку payload каждой включённой подписке, под которую подходит датчик.
+// Подписки, которые не удалось проверить, пропускаются; ошибка чтения подписок или сохранения доставки
+// возвращается.
+func (w *Webhook) enqueue(
+	ctx context.Context, topic domain.WebhookTopic, sensor domain.Sensor, payload webhookPayload,
+) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.enqueue",
+		trace.WithAttributes(attribute.String("webhook.topic", string(topic)), attribute.Int64("sensor.id", sensor.ID)))
+	defer func() { tracing.End(span, err) }()
+
+	logger := logging.FromContext(ctx).With(logging.SensorID(sensor.ID), slog.String("topic", string(topic)))
+
+	webhooks, err := w.repo.GetWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get webhooks: %w", err)
+	}
+
+	var body []byte
+	userSensors := map[int64][]int64{}
+	for _, webhook := range webhooks {
+		ok, err := w.matches(ctx, webhook, topic, sensor, userSensors)
+		if err != nil {
+			logger.ErrorContext(ctx, "can't match webhook", slog.Int64("webhook_id", webhook.ID), logging.Error(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if body == nil {
+			payload.Topic = topic
+			payload.Sensor = webhookSensor{
+				ID:           sensor.ID,
+				SerialNumber: sensor.SerialNumber,
+				Type:         string(sensor.Type),
+				Description:  sensor.Description,
+				IsActive:     sensor.IsActive,
+				CurrentState: sensor.CurrentState,
+				CurrentValue: sensor.State(),
+				Unit:         sensor.Unit,
+				RegisteredAt: sensor.RegisteredAt.UTC(),
+				LastActivity: sensor.LastActivity.UTC(),
+			}
+			if body, err = json.Marshal(payload); err != nil {
+				return fmt.Errorf("can't marshal webhook payload: %w", err)
+			}
+		}
+
+		now := w.now().UTC()
+		delivery := &domain.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Topic:         topic,
+			SensorID:      sensor.ID,
+			Payload:       body,
+			Status:        domain.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		if err = w.repo.SaveDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("can't enqueue webhook delivery %d: %w", webhook.ID, err)
+		}
+		logger.DebugContext(ctx, "webhook delivery enqueued",
+			slog.Int64("webhook_id", webhook.ID), slog.Int64("delivery_id", delivery.ID))
+	}
+	return nil
+}
+
+// matches - подходит ли датчик под фильтры подписки. userSensors - датчики пользователей,
+// прочитанные для предыдущих подписок.
+func (w *Webhook) matches(
+	ctx context.Context,
+	webhook domain.Webhook,
+	topic domain.WebhookTopic,
+	sensor domain.Sensor,
+	userSensors map[int64][]int64,
+) (bool, error) {
+	if !webhook.Enabled {
+		return false, nil
+	}
+	if len(webhook.Topics) > 0 && !slices.Contains(webhook.Topics, topic) {
+		return false, nil
+	}
+	if len(webhook.SensorIDs) > 0 && !slices.Contains(webhook.SensorIDs, sensor.ID) {
+		return false, nil
+	}
+	if len(webhook.SensorTypes) > 0 && !slices.Contains(webhook.SensorTypes, sensor.Type) {
+		return false, nil
+	}
+	if webhook.UserID == 0 {
+		return true, nil
+	}
+
+	ids, ok := userSensors[webhook.UserID]
+	if !ok {
+		sensors, err := w.users.GetUserSensors(ctx, webhook.UserID)
+		if err != nil && !errors.Is(err, ErrUserNotFound) {
+			return false, err
+		}
+		for _, s := range sensors {
+			ids = append(ids, s.ID)
+		}
+		userSensors[webhook.UserID] = ids
+	}
+	return slices.Contains(ids, sensor.ID), nil
+}
+
+// DeliverPending - отправляет доставки, чья очередь подошла, пока они не кончатся, и возвращает
+// число сделанных попыток
+func (w *Webhook) DeliverPending(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.DeliverPending")
+	defer func() { tracing.End(span, err) }()
+
+	attempts := 0
+	for {
+		if ctx.Err() != nil {
+			return attempts, ctx.Err()
+		}
+
+		deliveries, err := w.repo.ClaimDeliveries(ctx, w.now().UTC(), webhookDeliveryLease, webhookDeliveryBatch)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "can't claim webhook deliveries", logging.Error(err))
+			return attempts, err
+		}
+
+		webhooks := map[int64]*domain.Webhook{}
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			// аренда кончится раньше, чем запрос: оставшиеся доставки заберёт следующий проход
+			if w.now().Add(w.client.Timeout).After(delivery.NextAttemptAt) {
+				return attempts, nil
+			}
+
+			webhook, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				webhook, err = w.repo.GetWebhookByID(ctx, delivery.WebhookID)
+				if err != nil {
+					// подписку удалили вместе с доставками, пока они были забраны
+					if !errors.Is(err, ErrWebhookNotFound) {
+						logging.FromContext(ctx).ErrorContext(ctx, "can't get webhook",
+							slog.Int64("webhook_id", delivery.WebhookID), logging.Error(err))
+					}
+					continue
+				}
+				webhooks[delivery.WebhookID] = webhook
+			}
+
+			if w.attempt(ctx, webhook, delivery) {
+				attempts++
+			}
+		}
+
+		if len(deliveries) < webhookDeliveryBatch {
+			return attempts, nil
+		}
+	}
+}
+
+// attempt - одна попытка доставки, возвращает false, если попытка не сделана или её результат не сохранён
+func (w *Webhook) attempt(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery) bool {
+	logger := logging.FromContext(ctx).With(
+		slog.Int64("webhook_id", webhook.ID), slog.Int64("delivery_id", delivery.ID))
+
+	now := w.now().UTC()
+	code, err := w.send(ctx, webhook, delivery, now)
+	if ctx.Err() != nil {
+		// сервис останавливается: доставку повторит эта или другая реплика, когда истечёт аренда
+		return false
+	}
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+	delivery.LastStatusCode = code
+	delivery.LastError = ""
+	switch {
+	case err == nil:
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.DeliveredAt = w.now().UTC()
+	case delivery.Attempts >= w.maxAttempts:
+		delivery.Status = domain.WebhookDeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(w.backoff(delivery.Attempts))
+	}
+
+	if err := w.repo.SaveDelivery(ctx, delivery); err != nil {
+		logger.ErrorContext(ctx, "can't save webhook delivery", logging.Error(err))
+		return false
+	}
+
+	switch delivery.Status {
+	case domain.WebhookDeliverySucceeded:
+		logger.DebugContext(ctx, "webhook delivered", slog.Int("attempts", delivery.Attempts))
+	case domain.WebhookDeliveryDead:
+		logger.WarnContext(ctx, "webhook delivery is dead", slog.Int("attempts", delivery.Attempts), logging.Error(err))
+	default:
+		logger.InfoContext(ctx, "webhook delivery failed", slog.Int("attempts", delivery.Attempts),
+			slog.Time("next_attempt_at", delivery.NextAttemptAt), logging.Error(err))
+	}
+
+	return true
+}
+
+// backoff - пауза после attempts неудачных попыток: minBackoff, удваиваясь, но не больше maxBackoff
+func (w *Webhook) backoff(attempts int) time.Duration {
+	d := w.minBackoff
+	for i := 1; i < attempts && d < w.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, w.maxBackoff)
+}
+
+// send - отправляет доставку и возвращает код ответа, 0 - ответа не было
+func (w *Webhook) send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookTopicHeader, string(delivery.Topic))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// GetDeliveries - журнал доставок подписки: последние limit доставок в состояниях statuses, от новых к старым.
+// Пустой statuses - доставки в любом состоянии, limit = 0 - значение по умолчанию.
+func (w *Webhook) GetDeliveries(
+	ctx context.Context,
+	webhookID int64,
+	statuses []domain.WebhookDeliveryStatus,
+	limit int,
+) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.GetDeliveries",
+		trace.WithAttributes(attribute.Int64("webhook.id", webhookID)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if limit < 0 || limit > maxDeliveries {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidDeliveryQuery, maxDeliveries)
+	}
+	for _, status := range statuses {
+		if status != domain.WebhookDeliveryPending && status != domain.WebhookDeliverySucceeded && status != domain.WebhookDeliveryDead {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidDeliveryQuery, status)
+		}
+	}
+	if limit == 0 {
+		limit = defaultDeliveries
+	}
+
+	if _, err := w.repo.GetWebhookByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return w.repo.GetDeliveries(ctx, webhookID, statuses, limit)
+}
+
+// RetryDelivery - возвращает в очередь доставку подписки, у которой кончились попытки.
+// Счётчик попыток начинается заново.
+func (w *Webhook) RetryDelivery(ctx context.Context, webhookID, id int64) (_ *domain.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Webhook.RetryDelivery",
+		trace.WithAttributes(attribute.Int64("webhook.id", webhookID), attribute.Int64("delivery.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	delivery, err := w.repo.GetDeliveryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+	if delivery.Status != domain.WebhookDeliveryDead {
+		return nil, fmt.Errorf("%w: only dead deliveries can be retried, delivery is %s", ErrDeliveryStatusConflict, delivery.Status)
+	}
+
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = w.now().UTC()
+
+	if err := w.repo.SaveDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "webhook delivery requeued",
+		slog.Int64("webhook_id", webhookID), slog.Int64("delivery_id", id))
+
+	return delivery, nil
+}
@@ -0,0 +1,384 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webhook_CreateWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).AnyTimes().Return(&domain.Sensor{ID: 1}, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(2)).AnyTimes().Return(nil, ErrSensorNotFound)
+	ur := NewMockUserRepository(ctrl)
+	ur.EXPECT().GetUserByID(gomock.Any(), int64(5)).AnyTimes().Return(nil, ErrUserNotFound)
+	users := NewUser(ur, nil, sr)
+
+	t.Run("ok, secret generated", func(t *testing.T) {
+		ctx := context.Background()
+
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().SaveWebhook(ctx, gomock.Any()).Times(1).Return(nil)
+
+		webhook, err := NewWebhook(repo, NewSensor(sr), users).CreateWebhook(ctx, &domain.Webhook{
+			URL:         "https://example.com/hook",
+			Topics:      []domain.WebhookTopic{domain.WebhookSensorEvent},
+			SensorIDs:   []int64{1},
+			SensorTypes: []domain.SensorType{domain.SensorTypeContactClosure},
+			Enabled:     true,
+		})
+		require.NoError(t, err)
+		assert.Len(t, webhook.Secret, 64)
+		assert.False(t, webhook.CreatedAt.IsZero())
+	})
+
+	tests := []struct {
+		name    string
+		webhook domain.Webhook
+	}{
+		{"err, relative url", domain.Webhook{URL: "/hook"}},
+		{"err, ftp url", domain.Webhook{URL: "ftp://example.com/hook"}},
+		{"err, short secret", domain.Webhook{URL: "http://example.com", Secret: "short"}},
+		{"err, unknown topic", domain.Webhook{URL: "http://example.com", Topics: []domain.WebhookTopic{"sensor.deleted"}}},
+		{"err, unknown sensor", domain.Webhook{URL: "http://example.com", SensorIDs: []int64{2}}},
+		{"err, unknown sensor type", domain.Webhook{URL: "http://example.com", SensorTypes: []domain.SensorType{"lamp"}}},
+		{"err, unknown user", domain.Webhook{URL: "http://example.com", UserID: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockWebhookRepository(ctrl)
+			repo.EXPECT().SaveWebhook(gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := NewWebhook(repo, NewSensor(sr), users).CreateWebhook(context.Background(), &tt.webhook)
+			assert.ErrorIs(t, err, ErrInvalidWebhook)
+		})
+	}
+}
+
+func Test_webhook_OnEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sensor := domain.Sensor{ID: 3, SerialNumber: "1000000000", Type: domain.SensorTypeContactClosure, CurrentState: 1}
+
+	ur := NewMockUserRepository(ctrl)
+	ur.EXPECT().GetUserByID(gomock.Any(), int64(7)).Times(1).Return(&domain.User{ID: 7}, nil)
+	sor := NewMockSensorOwnerRepository(ctrl)
+	sor.EXPECT().GetSensorsByUserID(gomock.Any(), int64(7)).Times(1).Return([]domain.SensorOwner{{UserID: 7, SensorID: 3}}, nil)
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(3)).AnyTimes().Return(&sensor, nil)
+
+	repo := NewMockWebhookRepository(ctrl)
+	repo.EXPECT().GetWebhooks(gomock.Any()).Times(1).Return([]domain.Webhook{
+		{ID: 1, Enabled: true},
+		{ID: 2, Enabled: false},
+		{ID: 3, Enabled: true, Topics: []domain.WebhookTopic{domain.WebhookSensorUpdated}},
+		{ID: 4, Enabled: true, SensorIDs: []int64{4}},
+		{ID: 5, Enabled: true, SensorTypes: []domain.SensorType{domain.SensorTypeADC}},
+		{ID: 6, Enabled: true, UserID: 7},
+		{ID: 7, Enabled: true, UserID: 7, SensorIDs: []int64{3}, Topics: []domain.WebhookTopic{domain.WebhookSensorEvent}},
+	}, nil)
+
+	var enqueued []int64
+	repo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(_ context.Context, d *domain.WebhookDelivery) error {
+		assert.Equal(t, domain.WebhookSensorEvent, d.Topic)
+		assert.Equal(t, domain.WebhookDeliveryPending, d.Status)
+		assert.Equal(t, int64(3), d.SensorID)
+		assert.JSONEq(t, `{
+			"topic": "sensor.event",
+			"occurred_at": "2024-05-01T12:00:00Z",
+			"sensor": {"id": 3, "serial_number": "1000000000", "type": "cc", "description": "", "is_active": false,
+				"current_state": 1, "current_value": 1, "registered_at": "0001-01-01T00:00:00Z", "last_activity": "0001-01-01T00:00:00Z"},
+			"event": {"timestamp": "2024-05-01T12:00:00Z", "payload": 1}
+		}`, string(d.Payload))
+		enqueued = append(enqueued, d.WebhookID)
+		return nil
+	})
+
+	w := NewWebhook(repo, NewSensor(sr), NewUser(ur, sor, sr))
+	w.OnEvent(ctx, domain.Event{SensorID: 3, Timestamp: at, Payload: 1}, sensor)
+	assert.Equal(t, []int64{1, 6, 7}, enqueued)
+}
+
+func Test_webhook_PublishEvent(t *testing.T) {
+	ctx := context.Background()
+	sensor := domain.Sensor{ID: 3, SerialNumber: "1000000000", Type: domain.SensorTypeContactClosure, CurrentState: 1}
+	message := domain.OutboxMessage{
+		ID:      1,
+		Topic:   domain.OutboxSensorEvent,
+		Key:     "3",
+		Payload: []byte(`{"sensor_id": 3, "serial_number": "1000000000", "timestamp": "2024-05-01T12:00:00Z", "payload": 1}`),
+	}
+
+	t.Run("ok, enqueues deliveries", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(3)).Times(1).Return(&sensor, nil)
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().GetWebhooks(gomock.Any()).Times(1).Return([]domain.Webhook{{ID: 1, Enabled: true}}, nil)
+		repo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, d *domain.WebhookDelivery) error {
+			assert.Equal(t, int64(1), d.WebhookID)
+			assert.Equal(t, domain.WebhookSensorEvent, d.Topic)
+			assert.JSONEq(t, `{
+				"topic": "sensor.event",
+				"occurred_at": "2024-05-01T12:00:00Z",
+				"sensor": {"id": 3, "serial_number": "1000000000", "type": "cc", "description": "", "is_active": false,
+					"current_state": 1, "current_value": 1, "registered_at": "0001-01-01T00:00:00Z", "last_activity": "0001-01-01T00:00:00Z"},
+				"event": {"timestamp": "2024-05-01T12:00:00Z", "payload": 1}
+			}`, string(d.Payload))
+			return nil
+		})
+
+		err := NewWebhook(repo, NewSensor(sr), nil).PublishEvent(ctx, message)
+		assert.NoError(t, err)
+	})
+
+	t.Run("err, delivery not saved", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(3)).Times(1).Return(&sensor, nil)
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().GetWebhooks(gomock.Any()).Times(1).Return([]domain.Webhook{{ID: 1, Enabled: true}}, nil)
+		repo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("some error"))
+
+		err := NewWebhook(repo, NewSensor(sr), nil).PublishEvent(ctx, message)
+		assert.Error(t, err)
+	})
+
+	t.Run("ok, skips unknown sensor and other topics", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(3)).Times(1).Return(nil, ErrSensorNotFound)
+		repo := NewMockWebhookRepository(ctrl)
+
+		w := NewWebhook(repo, NewSensor(sr), nil)
+		assert.NoError(t, w.PublishEvent(ctx, message))
+		assert.NoError(t, w.PublishEvent(ctx, domain.OutboxMessage{Topic: "other"}))
+	})
+}
+
+func Test_webhook_DeliverPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	webhook := &domain.Webhook{ID: 1, Secret: "0123456789abcdef", Enabled: true}
+	payload := []byte(`{"topic":"sensor.event"}`)
+
+	var statuses []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, body)
+		assert.Equal(t, "10", r.Header.Get(WebhookDeliveryHeader))
+		assert.Equal(t, "sensor.event", r.Header.Get(WebhookTopicHeader))
+		timestamp := r.Header.Get(WebhookTimestampHeader)
+		assert.Equal(t, "1714564800", timestamp)
+		assert.Equal(t, SignWebhook(webhook.Secret, timestamp, body), r.Header.Get(WebhookSignatureHeader))
+
+		w.WriteHeader(statuses[0])
+		statuses = statuses[1:]
+	}))
+	defer srv.Close()
+	webhook.URL = srv.URL
+
+	newDelivery := func(attempts int) domain.WebhookDelivery {
+		return domain.WebhookDelivery{
+			ID: 10, WebhookID: 1, Topic: domain.WebhookSensorEvent, Payload: payload,
+			Status: domain.WebhookDeliveryPending, Attempts: attempts, NextAttemptAt: now.Add(webhookDeliveryLease),
+		}
+	}
+
+	deliver := func(t *testing.T, delivery domain.WebhookDelivery) *domain.WebhookDelivery {
+		t.Helper()
+
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().ClaimDeliveries(gomock.Any(), now, webhookDeliveryLease, webhookDeliveryBatch).
+			Times(1).Return([]domain.WebhookDelivery{delivery}, nil)
+		repo.EXPECT().GetWebhookByID(gomock.Any(), int64(1)).Times(1).Return(webhook, nil)
+
+		var saved *domain.WebhookDelivery
+		repo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, d *domain.WebhookDelivery) error {
+			saved = d
+			return nil
+		})
+
+		w := NewWebhook(repo, nil, nil,
+			WithWebhookHTTPClient(srv.Client()),
+			WithWebhookRetries(3, time.Second, 3*time.Second),
+		)
+		w.now = func() time.Time { return now }
+
+		n, err := w.DeliverPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		return saved
+	}
+
+	t.Run("ok, failed attempt backs off", func(t *testing.T) {
+		statuses = []int{http.StatusInternalServerError}
+
+		saved := deliver(t, newDelivery(1))
+		assert.Equal(t, domain.WebhookDeliveryPending, saved.Status)
+		assert.Equal(t, 2, saved.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, saved.LastStatusCode)
+		assert.Equal(t, "unexpected response status 500 Internal Server Error", saved.LastError)
+		assert.Equal(t, now.Add(2*time.Second), saved.NextAttemptAt)
+	})
+
+	t.Run("ok, delivered", func(t *testing.T) {
+		statuses = []int{http.StatusNoContent}
+
+		saved := deliver(t, newDelivery(2))
+		assert.Equal(t, domain.WebhookDeliverySucceeded, saved.Status)
+		assert.Equal(t, 3, saved.Attempts)
+		assert.Equal(t, now, saved.DeliveredAt)
+		assert.Empty(t, saved.LastError)
+	})
+
+	t.Run("ok, dead after last attempt", func(t *testing.T) {
+		statuses = []int{http.StatusBadGateway}
+
+		saved := deliver(t, newDelivery(2))
+		assert.Equal(t, domain.WebhookDeliveryDead, saved.Status)
+		assert.Equal(t, 3, saved.Attempts)
+		assert.Equal(t, http.StatusBadGateway, saved.LastStatusCode)
+	})
+
+	t.Run("ok, deleted webhook skipped", func(t *testing.T) {
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().ClaimDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).Return([]domain.WebhookDelivery{newDelivery(0)}, nil)
+		repo.EXPECT().GetWebhookByID(gomock.Any(), int64(1)).Times(1).Return(nil, ErrWebhookNotFound)
+		repo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).Times(0)
+
+		w := NewWebhook(repo, nil, nil)
+		w.now = func() time.Time { return now }
+
+		n, err := w.DeliverPending(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("ok, expiring lease left for next pass", func(t *testing.T) {
+		delivery := newDelivery(0)
+		delivery.NextAttemptAt = now.Add(time.Second)
+
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().ClaimDeliveries(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).Return([]domain.WebhookDelivery{delivery}, nil)
+		repo.EXPECT().GetWebhookByID(gomock.Any(), gomock.Any()).Times(0)
+		repo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).Times(0)
+
+		w := NewWebhook(repo, nil, nil)
+		w.now = func() time.Time { return now }
+
+		n, err := w.DeliverPending(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func Test_webhook_backoff(t *testing.T) {
+	w := NewWebhook(nil, nil, nil, WithWebhookRetries(10, 10*time.Second, time.Minute))
+
+	var got []time.Duration
+	for attempts := 1; attempts <= 5; attempts++ {
+		got = append(got, w.backoff(attempts))
+	}
+	assert.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}, got)
+}
+
+func Test_webhook_RetryDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	t.Run("ok, dead delivery requeued", func(t *testing.T) {
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().GetDeliveryByID(ctx, int64(10)).Times(1).Return(&domain.WebhookDelivery{
+			ID: 10, WebhookID: 1, Status: domain.WebhookDeliveryDead, Attempts: 10, LastError: "timeout",
+		}, nil)
+		repo.EXPECT().SaveDelivery(ctx, gomock.Any()).Times(1).Return(nil)
+
+		delivery, err := NewWebhook(repo, nil, nil).RetryDelivery(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, domain.WebhookDeliveryPending, delivery.Status)
+		assert.Zero(t, delivery.Attempts)
+		assert.Equal(t, "timeout", delivery.LastError)
+	})
+
+	t.Run("err, not dead", func(t *testing.T) {
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().GetDeliveryByID(ctx, int64(10)).Times(1).Return(&domain.WebhookDelivery{
+			ID: 10, WebhookID: 1, Status: domain.WebhookDeliverySucceeded,
+		}, nil)
+
+		_, err := NewWebhook(repo, nil, nil).RetryDelivery(ctx, 1, 10)
+		assert.ErrorIs(t, err, ErrDeliveryStatusConflict)
+	})
+
+	t.Run("err, other webhook", func(t *testing.T) {
+		repo := NewMockWebhookRepository(ctrl)
+		repo.EXPECT().GetDeliveryByID(ctx, int64(10)).Times(1).Return(&domain.WebhookDelivery{
+			ID: 10, WebhookID: 2, Status: domain.WebhookDeliveryDead,
+		}, nil)
+
+		_, err := NewWebhook(repo, nil, nil).RetryDelivery(ctx, 1, 10)
+		assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	})
+}
+
+func TestSignWebhook(t *testing.T) {
+	// echo -n '1714564800.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=6772f83f980eaa45c478fbaeb2e3661d945e7ba97f73c65ac97333a82742e16f",
+		SignWebhook("secret", "1714564800", []byte("{}")))
+}
+
+func TestNewWebhookHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t.Run("err, loopback target denied", func(t *testing.T) {
+		_, err := NewWebhookHTTPClient(time.Second, false).Post(srv.URL, "application/json", nil)
+		assert.ErrorIs(t, err, ErrWebhookTargetDenied)
+	})
+
+	t.Run("ok, private targets allowed", func(t *testing.T) {
+		resp, err := NewWebhookHTTPClient(time.Second, true).Post(srv.URL, "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	for _, address := range []string{"10.0.0.1:80", "169.254.169.254:80", "[::1]:80", "[fd00::1]:443", "100.64.0.1:80", "0.0.0.0:80"} {
+		t.Run("err, "+address, func(t *testing.T) {
+			assert.ErrorIs(t, denyPrivateTarget("tcp", address, nil), ErrWebhookTargetDenied)
+		})
+	}
+	assert.NoError(t, denyPrivateTarget("tcp", "93.184.216.34:443", nil))
+}
@@ -0,0 +1 @@
+drop table imports;
\ No newline at end of file
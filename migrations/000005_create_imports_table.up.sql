@@ -0,0 +1,13 @@
+create table imports
+(
+    id              text        primary key,
+    kind            text        not null,
+    status          text        not null,
+    rows_processed  bigint      not null default 0,
+    rows_imported   bigint      not null default 0,
+    rows_failed     bigint      not null default 0,
+    errors          jsonb       not null default '[]',
+    error           text        not null default '',
+    created_at      timestamp   not null,
+    updated_at      timestamp   not null
+);
@@ -0,0 +1 @@
+alter table sensors add column version bigint not null default 1;
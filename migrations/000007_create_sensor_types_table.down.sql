@@ -0,0 +1,6 @@
+create type sensor_type as enum ('cc', 'adc');
+
+alter table sensors drop constraint sensors_type_fkey;
+alter table sensors alter column type type sensor_type using type::sensor_type;
+
+drop table sensor_types;
@@ -0,0 +1,19 @@
+create table sensor_types
+(
+    name               text             primary key,
+    description        text             not null default '',
+    payload            text             not null,
+    min_value          double precision,
+    max_value          double precision,
+    unit               text             not null default '',
+    report_interval_ms bigint           not null default 0
+);
+
+insert into sensor_types (name, description, payload)
+values ('cc', 'Контактное замыкание', 'binary'),
+       ('adc', 'АЦП', 'level');
+
+alter table sensors alter column type type text using type::text;
+alter table sensors add constraint sensors_type_fkey foreign key (type) references sensor_types (name);
+
+drop type sensor_type;
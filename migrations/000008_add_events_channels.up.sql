@@ -0,0 +1 @@
+alter table events add column channels jsonb;
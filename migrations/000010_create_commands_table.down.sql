@@ -0,0 +1,3 @@
+drop table commands;
+
+alter table sensor_types drop column actuator;
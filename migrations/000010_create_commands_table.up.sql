@@ -0,0 +1,18 @@
+alter table sensor_types add column actuator boolean not null default false;
+
+create table commands
+(
+    id           bigserial   primary key,
+    sensor_id    bigint      not null,
+    kind         text        not null,
+    state        bigint      not null,
+    duration_ms  bigint      not null default 0,
+    status       text        not null,
+    created_at   timestamp   not null,
+    expires_at   timestamp   not null,
+    delivered_at timestamp,
+    confirmed_at timestamp
+);
+
+create index commands_sensor_id_idx on commands (sensor_id, id);
+create index commands_active_idx on commands (expires_at) where status in ('pending', 'delivered');
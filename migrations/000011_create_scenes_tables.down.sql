@@ -0,0 +1,2 @@
+drop table scene_activations;
+drop table scenes;
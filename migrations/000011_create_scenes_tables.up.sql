@@ -0,0 +1,20 @@
+create table scenes
+(
+    id          bigserial primary key,
+    name        text      not null unique,
+    description text      not null default '',
+    targets     jsonb     not null,
+    created_at  timestamp not null,
+    updated_at  timestamp not null
+);
+
+create table scene_activations
+(
+    id           bigserial primary key,
+    scene_id     bigint    not null references scenes (id) on delete cascade,
+    status       text      not null,
+    activated_at timestamp not null,
+    results      jsonb     not null
+);
+
+create index scene_activations_scene_id_idx on scene_activations (scene_id, id);
@@ -0,0 +1,27 @@
+create table schedules
+(
+    id          bigserial primary key,
+    name        text      not null unique,
+    cron        text      not null,
+    timezone    text      not null,
+    action      jsonb     not null,
+    missed_runs text      not null,
+    enabled     boolean   not null,
+    next_run_at timestamp,
+    created_at  timestamp not null,
+    updated_at  timestamp not null
+);
+
+create index schedules_due_idx on schedules (next_run_at) where enabled;
+
+create table schedule_runs
+(
+    id           bigserial primary key,
+    schedule_id  bigint    not null references schedules (id) on delete cascade,
+    scheduled_at timestamp not null,
+    started_at   timestamp not null,
+    status       text      not null,
+    error        text      not null default ''
+);
+
+create index schedule_runs_schedule_id_idx on schedule_runs (schedule_id, id);
@@ -0,0 +1,3 @@
+drop table alerts;
+drop sequence alerts_revision_seq;
+drop table alert_rules;
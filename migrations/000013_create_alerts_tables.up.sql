@@ -0,0 +1,42 @@
+create table alert_rules
+(
+    id             bigserial        primary key,
+    sensor_id      bigint           not null,
+    name           text             not null,
+    kind           text             not null,
+    channel        text             not null default '',
+    operator       text             not null default '',
+    threshold      double precision not null default 0,
+    stale_after_ms bigint           not null default 0,
+    created_at     timestamp        not null,
+    updated_at     timestamp        not null
+);
+
+create index alert_rules_sensor_id_idx on alert_rules (sensor_id, id);
+
+-- revision растёт при каждом изменении тревоги, по ней клиенты читают поток изменений
+create sequence alerts_revision_seq;
+
+create table alerts
+(
+    id              bigserial primary key,
+    rule_id         bigint    references alert_rules (id) on delete set null,
+    sensor_id       bigint    not null,
+    kind            text      not null,
+    status          text      not null,
+    message         text      not null,
+    value           double precision,
+    count           bigint    not null,
+    opened_at       timestamp not null,
+    last_seen_at    timestamp not null,
+    acknowledged_by bigint,
+    acknowledged_at timestamp,
+    resolved_by     bigint,
+    resolved_at     timestamp,
+    revision        bigint    not null default nextval('alerts_revision_seq')
+);
+
+-- у правила может быть только одна незакрытая тревога
+create unique index alerts_active_rule_idx on alerts (rule_id) where status <> 'resolved';
+create index alerts_sensor_id_idx on alerts (sensor_id, id);
+create index alerts_revision_idx on alerts (revision);
@@ -0,0 +1,34 @@
+create table webhooks
+(
+    id           bigserial primary key,
+    url          text      not null,
+    secret       text      not null,
+    topics       text[]    not null default '{}',
+    sensor_ids   bigint[]  not null default '{}',
+    user_id      bigint    not null default 0,
+    sensor_types text[]    not null default '{}',
+    enabled      boolean   not null,
+    created_at   timestamp not null,
+    updated_at   timestamp not null
+);
+
+create table webhook_deliveries
+(
+    id               bigserial primary key,
+    webhook_id       bigint    not null references webhooks (id) on delete cascade,
+    topic            text      not null,
+    sensor_id        bigint    not null,
+    -- json, а не jsonb: тело уходит получателю в том виде, в каком было сформировано
+    payload          json      not null,
+    status           text      not null,
+    attempts         integer   not null default 0,
+    next_attempt_at  timestamp not null,
+    last_attempt_at  timestamp,
+    last_status_code integer   not null default 0,
+    last_error       text      not null default '',
+    created_at       timestamp not null,
+    delivered_at     timestamp
+);
+
+create index webhook_deliveries_pending_idx on webhook_deliveries (next_attempt_at, id) where status = 'pending';
+create index webhook_deliveries_webhook_id_idx on webhook_deliveries (webhook_id, id);
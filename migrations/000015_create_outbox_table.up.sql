@@ -0,0 +1,8 @@
+create table outbox
+(
+    id         bigserial primary key,
+    topic      text      not null,
+    key        text      not null,
+    payload    json      not null,
+    created_at timestamp not null
+);
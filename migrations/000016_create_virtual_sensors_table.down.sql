@@ -0,0 +1,3 @@
+drop table virtual_sensors;
+
+alter table sensor_types drop column virtual;
@@ -0,0 +1,13 @@
+alter table sensor_types add column virtual boolean not null default false;
+
+create table virtual_sensors
+(
+    sensor_id  bigint    primary key,
+    expression text      not null,
+    inputs     bigint[]  not null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+-- по входам ищутся виртуальные датчики, которые нужно пересчитать после события
+create index virtual_sensors_inputs_idx on virtual_sensors using gin (inputs);
@@ -0,0 +1 @@
+alter table sensors drop column current_value;
@@ -0,0 +1,3 @@
+alter table sensors add column current_value jsonb;
+
+update sensors set current_value = to_jsonb(current_state);